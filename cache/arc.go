@@ -0,0 +1,186 @@
+package cache
+
+import (
+	listlib "container/list"
+)
+
+// NewARC new adaptive replacement cache, it panics when the capacity is not positive
+func NewARC[K comparable, V any](cap int) *ARC[K, V] {
+	return &ARC[K, V]{
+		base:  newBase[K, V](cap),
+		items: make(map[K]*listlib.Element),
+		t1:    listlib.New(),
+		t2:    listlib.New(),
+		b1:    listlib.New(),
+		b2:    listlib.New(),
+	}
+}
+
+// ARC adaptive replacement cache, all methods are safe for concurrent use.
+// The entries used once recently are kept in one list and the ones used more than once in another,
+// and the keys recently evicted from either list are remembered without their values as ghosts.
+// Putting a ghost key again tells which list evicted too early, so the cache moves its target size towards that list,
+// it adapts to the workload between recency and frequency by itself. Get and Put take O(1) time.
+type ARC[K comparable, V any] struct {
+	base[K, V]
+	items map[K]*listlib.Element // the entries and the ghosts
+	t1    *listlib.List          // the entries used once recently, the most recently used one is at the front
+	t2    *listlib.List          // the entries used more than once recently
+	b1    *listlib.List          // the ghosts evicted from t1
+	b2    *listlib.List          // the ghosts evicted from t2
+	p     int                    // the target size of t1
+}
+
+// Count returns the number of entries, the ghosts are not counted
+func (c *ARC[K, V]) Count() int64 {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return int64(c.t1.Len() + c.t2.Len())
+}
+
+// live returns the element of the key when it is an entry and not a ghost
+func (c *ARC[K, V]) live(key K) (*listlib.Element, bool) {
+	element, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	list := element.Value.(*entry[K, V]).list
+	return element, list == c.t1 || list == c.t2
+}
+
+// Get returns the value of the specific key and counts a use of it.
+// A zero value and false will be returned when the key is not exist.
+func (c *ARC[K, V]) Get(key K) (V, bool) {
+	c.lock.Lock()
+	defer c.unlock()
+	element, ok := c.live(key)
+	if !ok {
+		return c.hit(*new(V), false)
+	}
+	return c.hit(c.move(element, c.t2).value, true)
+}
+
+// Peek returns the value of the specific key without counting a use of it
+func (c *ARC[K, V]) Peek(key K) (V, bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if element, ok := c.live(key); ok {
+		return element.Value.(*entry[K, V]).value, true
+	}
+	return *new(V), false
+}
+
+// Contains returns whether the cache has the specific key, see Peek
+func (c *ARC[K, V]) Contains(key K) bool {
+	_, ok := c.Peek(key)
+	return ok
+}
+
+// Put sets the value to the specific key and counts a use of it, an entry is evicted when the cache is full
+func (c *ARC[K, V]) Put(key K, value V) {
+	c.lock.Lock()
+	defer c.unlock()
+	element, ok := c.items[key]
+	if !ok {
+		c.admit(key, value)
+		return
+	}
+	e := element.Value.(*entry[K, V])
+	switch e.list {
+	case c.b1:
+		c.p = min(c.cap, c.p+max(c.b2.Len()/c.b1.Len(), 1))
+		c.replace(false)
+	case c.b2:
+		c.p = max(0, c.p-max(c.b1.Len()/c.b2.Len(), 1))
+		c.replace(true)
+	}
+	c.move(element, c.t2).value = value
+}
+
+// admit puts a new key into t1, the ghosts are trimmed so that there are at most cap keys in t1 and b1
+// and at most twice cap keys in all lists
+func (c *ARC[K, V]) admit(key K, value V) {
+	if c.t1.Len()+c.b1.Len() >= c.cap {
+		if c.t1.Len() < c.cap {
+			c.drop(c.b1.Back())
+			c.replace(false)
+		} else {
+			e := c.drop(c.t1.Back())
+			c.evict(e)
+		}
+	} else if total := c.t1.Len() + c.t2.Len() + c.b1.Len() + c.b2.Len(); total >= c.cap {
+		if total >= 2*c.cap {
+			c.drop(c.b2.Back())
+		}
+		c.replace(false)
+	}
+	c.items[key] = c.t1.PushFront(&entry[K, V]{key: key, value: value, list: c.t1})
+}
+
+// replace evicts an entry into the ghosts when the cache is full,
+// from t1 when it is over its target size and from t2 otherwise
+func (c *ARC[K, V]) replace(inB2 bool) {
+	if c.t1.Len()+c.t2.Len() < c.cap {
+		return
+	}
+	from, to := c.t2, c.b2
+	if c.t1.Len() > 0 && (c.t1.Len() > c.p || (inB2 && c.t1.Len() == c.p) || c.t2.Len() == 0) {
+		from, to = c.t1, c.b1
+	}
+	element := from.Back()
+	c.evict(element.Value.(*entry[K, V]))
+	e := c.move(element, to)
+	e.value = *new(V)
+}
+
+// move moves the element to the front of the list and returns its entry
+func (c *ARC[K, V]) move(element *listlib.Element, list *listlib.List) *entry[K, V] {
+	e := element.Value.(*entry[K, V])
+	if e.list == list {
+		list.MoveToFront(element)
+		return e
+	}
+	e.list.Remove(element)
+	e.list = list
+	c.items[e.key] = list.PushFront(e)
+	return e
+}
+
+// drop removes the element from its list and the keys, and returns its entry
+func (c *ARC[K, V]) drop(element *listlib.Element) *entry[K, V] {
+	e := element.Value.(*entry[K, V])
+	e.list.Remove(element)
+	delete(c.items, e.key)
+	return e
+}
+
+// Remove removes the specific key and reports whether it was in the cache, a ghost of the key is forgotten as well
+func (c *ARC[K, V]) Remove(key K) bool {
+	c.lock.Lock()
+	defer c.unlock()
+	element, ok := c.items[key]
+	if !ok {
+		return false
+	}
+	e := c.drop(element)
+	return e.list == c.t1 || e.list == c.t2
+}
+
+// Clear clears the cache and its ghosts, the stats are kept
+func (c *ARC[K, V]) Clear() {
+	c.lock.Lock()
+	defer c.unlock()
+	c.items = make(map[K]*listlib.Element)
+	c.t1.Init()
+	c.t2.Init()
+	c.b1.Init()
+	c.b2.Init()
+	c.p = 0
+}
+
+// Stats returns the statistics of the cache
+func (c *ARC[K, V]) Stats() Stats {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.stats(c.t1.Len() + c.t2.Len())
+}
@@ -0,0 +1,51 @@
+package cache
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestARC_Put(t *testing.T) {
+	cache := NewARC[int, string](2)
+	var evicted []int
+	cache.OnEvict(func(key int, value string, reason EvictionReason) {
+		evicted = append(evicted, key)
+	})
+	cache.Put(1, "a")
+	cache.Put(2, "b")
+	cache.Get(1)
+	cache.Put(3, "c")
+	assert.Equal(t, []int{2}, evicted)
+	// 2 is a ghost, putting it again grows the target of the recent list and evicts from the frequent one
+	cache.Put(2, "B")
+	assert.Equal(t, []int{2, 1}, evicted)
+	value, ok := cache.Peek(2)
+	assert.True(t, ok)
+	assert.Equal(t, "B", value)
+	assert.True(t, cache.Contains(3))
+	assert.EqualValues(t, 2, cache.Count())
+}
+
+func TestARC_Remove(t *testing.T) {
+	cache := NewARC[int, string](1)
+	cache.Put(1, "a")
+	cache.Put(2, "b")
+	assert.False(t, cache.Remove(1))
+	assert.True(t, cache.Remove(2))
+	assert.EqualValues(t, 0, cache.Count())
+}
+
+func TestARC_Scan(t *testing.T) {
+	cache := NewARC[int, int](4)
+	for _, key := range []int{1, 2} {
+		cache.Put(key, key)
+		cache.Get(key)
+	}
+	for key := 100; key < 200; key++ {
+		cache.Put(key, key)
+	}
+	assert.True(t, cache.Contains(1))
+	assert.True(t, cache.Contains(2))
+	assert.EqualValues(t, 4, cache.Count())
+}
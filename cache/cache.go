@@ -0,0 +1,133 @@
+// Package cache provides bounded caches with different eviction policies behind the [Cache] interface,
+// so a service can swap the policy without changing the code which uses the cache.
+//
+// LRU evicts the least recently used entry, it is [kv.LRUCache] which also supports the time to live of entries.
+// LFU evicts the least frequently used entry, the least recently used one among them.
+// ARC balances recency and frequency by itself with the ghost entries of the recently evicted keys.
+// TwoQueue admits new keys to a small FIFO queue first and promotes the keys which are requested again after they leave it,
+// so a scan over many keys does not flush the frequently used entries.
+//
+// Weighted bounds the total cost of its entries instead of their number, such as the bytes of the values,
+// and evicts them by a pluggable [Policy]: LRU, LFU, FIFO, random or one of the caller's own.
+package cache
+
+import (
+	listlib "container/list"
+	"fmt"
+	"sync"
+
+	"github.com/gopi-frame/collection/kv"
+)
+
+// Stats statistics of a cache, see [kv.CacheStats]
+type Stats = kv.CacheStats
+
+// EvictionReason tells why an entry left a cache, see [kv.EvictionReason]
+type EvictionReason = kv.EvictionReason
+
+// Cache bounded map which evicts entries by its policy when it is full, all implementations are safe for concurrent use
+type Cache[K comparable, V any] interface {
+	// Get returns the value of the key, it counts as a use of the entry for the policy
+	Get(key K) (V, bool)
+	// Peek returns the value of the key without counting as a use of the entry
+	Peek(key K) (V, bool)
+	// Contains returns whether the cache has the key, see Peek
+	Contains(key K) bool
+	// Put sets the value to the key, an entry is evicted by the policy when the cache is full
+	Put(key K, value V)
+	// Remove removes the key and reports whether it was in the cache
+	Remove(key K) bool
+	// Count returns the number of entries
+	Count() int64
+	// Cap returns the capacity of the cache
+	Cap() int
+	// Clear clears the cache, the stats are kept
+	Clear()
+	// Stats returns the statistics of the cache
+	Stats() Stats
+	// OnEvict sets the callback which is called with each evicted entry after the cache is unlocked
+	OnEvict(callback func(key K, value V, reason EvictionReason))
+}
+
+var (
+	_ Cache[int, int] = (*kv.LRUCache[int, int])(nil)
+	_ Cache[int, int] = (*LFU[int, int])(nil)
+	_ Cache[int, int] = (*ARC[int, int])(nil)
+	_ Cache[int, int] = (*TwoQueue[int, int])(nil)
+	_ Cache[int, int] = (*Weighted[int, int])(nil)
+)
+
+// NewLRU new cache which evicts the least recently used entry, see [kv.NewLRUCache]
+func NewLRU[K comparable, V any](cap int) Cache[K, V] {
+	return kv.NewLRUCache[K, V](cap)
+}
+
+// base the state shared by the policies, the policies lock it and keep the stats while they are locked
+type base[K comparable, V any] struct {
+	lock      sync.RWMutex
+	cap       int
+	onEvict   func(key K, value V, reason EvictionReason)
+	hits      int64
+	misses    int64
+	evictions int64
+	evicted   []entry[K, V]
+}
+
+// entry an entry of a cache, the list is the list of the policy which holds it
+type entry[K comparable, V any] struct {
+	key   K
+	value V
+	list  *listlib.List
+}
+
+func newBase[K comparable, V any](cap int) base[K, V] {
+	if cap <= 0 {
+		panic(fmt.Errorf("cache: capacity %d is not positive", cap))
+	}
+	return base[K, V]{cap: cap}
+}
+
+// Cap returns the capacity of the cache
+func (b *base[K, V]) Cap() int {
+	return b.cap
+}
+
+// OnEvict sets the callback which is called with each evicted entry after the cache is unlocked,
+// so the callback may call methods of the same cache. The entries removed by Remove or Clear are not passed to it.
+func (b *base[K, V]) OnEvict(callback func(key K, value V, reason EvictionReason)) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.onEvict = callback
+}
+
+// evict records the evicted entry, it is passed to the callback by unlock
+func (b *base[K, V]) evict(e *entry[K, V]) {
+	b.evictions++
+	if b.onEvict != nil {
+		b.evicted = append(b.evicted, *e)
+	}
+}
+
+// unlock unlocks the cache and passes the entries evicted while it was locked to the callback
+func (b *base[K, V]) unlock() {
+	evicted, callback := b.evicted, b.onEvict
+	b.evicted = nil
+	b.lock.Unlock()
+	for _, e := range evicted {
+		callback(e.key, e.value, kv.EvictedByCapacity)
+	}
+}
+
+// hit counts the lookup and returns its result
+func (b *base[K, V]) hit(value V, ok bool) (V, bool) {
+	if ok {
+		b.hits++
+	} else {
+		b.misses++
+	}
+	return value, ok
+}
+
+func (b *base[K, V]) stats(size int) Stats {
+	return Stats{Size: int64(size), Hits: b.hits, Misses: b.misses, Evictions: b.evictions}
+}
@@ -0,0 +1,114 @@
+package cache
+
+import (
+	"testing"
+
+	"github.com/gopi-frame/collection/kv"
+	"github.com/stretchr/testify/assert"
+)
+
+func caches() map[string]func(cap int) Cache[int, string] {
+	return map[string]func(cap int) Cache[int, string]{
+		"LRU":      NewLRU[int, string],
+		"LFU":      func(cap int) Cache[int, string] { return NewLFU[int, string](cap) },
+		"ARC":      func(cap int) Cache[int, string] { return NewARC[int, string](cap) },
+		"TwoQueue": func(cap int) Cache[int, string] { return NewTwoQueue[int, string](cap) },
+		"Weighted": func(cap int) Cache[int, string] { return NewWeighted[int, string](cap, NewLRUPolicy[int]()) },
+	}
+}
+
+func TestCache_Stats(t *testing.T) {
+	for name, newCache := range caches() {
+		t.Run(name, func(t *testing.T) {
+			cache := newCache(2)
+			cache.Put(1, "a")
+			cache.Put(2, "b")
+			value, ok := cache.Get(1)
+			assert.True(t, ok)
+			assert.Equal(t, "a", value)
+			_, ok = cache.Get(9)
+			assert.False(t, ok)
+			cache.Put(3, "c")
+			assert.EqualValues(t, 2, cache.Count())
+			assert.Equal(t, 2, cache.Cap())
+			assert.Equal(t, Stats{Size: 2, Hits: 1, Misses: 1, Evictions: 1}, cache.Stats())
+			cache.Clear()
+			assert.Equal(t, Stats{Hits: 1, Misses: 1, Evictions: 1}, cache.Stats())
+		})
+	}
+}
+
+func TestCache_Peek(t *testing.T) {
+	for name, newCache := range caches() {
+		t.Run(name, func(t *testing.T) {
+			cache := newCache(2)
+			cache.Put(1, "a")
+			value, ok := cache.Peek(1)
+			assert.True(t, ok)
+			assert.Equal(t, "a", value)
+			assert.True(t, cache.Contains(1))
+			assert.False(t, cache.Contains(2))
+			assert.Equal(t, Stats{Size: 1}, cache.Stats())
+		})
+	}
+}
+
+func TestCache_Put(t *testing.T) {
+	for name, newCache := range caches() {
+		t.Run(name, func(t *testing.T) {
+			cache := newCache(2)
+			cache.Put(1, "a")
+			cache.Put(1, "A")
+			value, _ := cache.Peek(1)
+			assert.Equal(t, "A", value)
+			assert.EqualValues(t, 1, cache.Count())
+		})
+	}
+}
+
+func TestCache_Remove(t *testing.T) {
+	for name, newCache := range caches() {
+		t.Run(name, func(t *testing.T) {
+			cache := newCache(2)
+			cache.Put(1, "a")
+			assert.True(t, cache.Remove(1))
+			assert.False(t, cache.Remove(1))
+			assert.EqualValues(t, 0, cache.Count())
+			cache.Put(2, "b")
+			cache.Put(3, "c")
+			assert.EqualValues(t, 2, cache.Count())
+			assert.Zero(t, cache.Stats().Evictions)
+		})
+	}
+}
+
+func TestCache_OnEvict(t *testing.T) {
+	for name, newCache := range caches() {
+		t.Run(name, func(t *testing.T) {
+			cache := newCache(1)
+			var evicted []int
+			cache.OnEvict(func(key int, value string, reason EvictionReason) {
+				assert.Equal(t, kv.EvictedByCapacity, reason)
+				assert.EqualValues(t, 1, cache.Count())
+				evicted = append(evicted, key)
+			})
+			cache.Put(1, "a")
+			cache.Put(2, "b")
+			cache.Put(3, "c")
+			cache.Remove(3)
+			assert.Equal(t, []int{1, 2}, evicted)
+		})
+	}
+}
+
+func TestNewBase(t *testing.T) {
+	assert.PanicsWithError(t, "cache: capacity 0 is not positive", func() {
+		NewLFU[int, int](0)
+	})
+	assert.PanicsWithError(t, "cache: capacity -1 is not positive", func() {
+		NewARC[int, int](-1)
+	})
+	assert.PanicsWithError(t, "cache: capacity 0 is not positive", func() {
+		NewTwoQueue[int, int](0)
+	})
+}
@@ -0,0 +1,162 @@
+package cache
+
+import (
+	listlib "container/list"
+)
+
+// NewLFU new cache which evicts the least frequently used entry, it panics when the capacity is not positive
+func NewLFU[K comparable, V any](cap int) *LFU[K, V] {
+	return &LFU[K, V]{
+		base:  newBase[K, V](cap),
+		items: make(map[K]*listlib.Element),
+		freqs: make(map[int]*listlib.List),
+	}
+}
+
+// LFU cache which evicts the least frequently used entry when it is full, the least recently used one among them,
+// all methods are safe for concurrent use.
+// The entries are kept in a list per use count, so Get, Put and the eviction take O(1) time.
+// The counts never decay, so an entry which was hot once stays in the cache until it is removed,
+// use ARC or TwoQueue when the hot keys change over time.
+type LFU[K comparable, V any] struct {
+	base[K, V]
+	items   map[K]*listlib.Element
+	freqs   map[int]*listlib.List // the entries of each use count, the most recently used one is at the front
+	minFreq int
+}
+
+type lfuEntry[K comparable, V any] struct {
+	entry[K, V]
+	freq int
+}
+
+// Count returns the number of entries
+func (c *LFU[K, V]) Count() int64 {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return int64(len(c.items))
+}
+
+// Get returns the value of the specific key and counts a use of it.
+// A zero value and false will be returned when the key is not exist.
+func (c *LFU[K, V]) Get(key K) (V, bool) {
+	c.lock.Lock()
+	defer c.unlock()
+	element, ok := c.items[key]
+	if !ok {
+		return c.hit(*new(V), false)
+	}
+	return c.hit(c.touch(element).Value.(*lfuEntry[K, V]).value, true)
+}
+
+// Peek returns the value of the specific key without counting a use of it
+func (c *LFU[K, V]) Peek(key K) (V, bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if element, ok := c.items[key]; ok {
+		return element.Value.(*lfuEntry[K, V]).value, true
+	}
+	return *new(V), false
+}
+
+// Contains returns whether the cache has the specific key, see Peek
+func (c *LFU[K, V]) Contains(key K) bool {
+	_, ok := c.Peek(key)
+	return ok
+}
+
+// Put sets the value to the specific key and counts a use of it,
+// the least frequently used entry is evicted when the cache is full
+func (c *LFU[K, V]) Put(key K, value V) {
+	c.lock.Lock()
+	defer c.unlock()
+	if element, ok := c.items[key]; ok {
+		element.Value.(*lfuEntry[K, V]).value = value
+		c.touch(element)
+		return
+	}
+	if len(c.items) >= c.cap {
+		c.evictLeast()
+	}
+	c.items[key] = c.push(&lfuEntry[K, V]{entry: entry[K, V]{key: key, value: value}, freq: 1})
+	c.minFreq = 1
+}
+
+// touch moves the entry to the list of the next use count and returns its new element
+func (c *LFU[K, V]) touch(element *listlib.Element) *listlib.Element {
+	e := c.detach(element)
+	e.freq++
+	element = c.push(e)
+	c.items[e.key] = element
+	return element
+}
+
+// push puts the entry at the front of the list of its use count
+func (c *LFU[K, V]) push(e *lfuEntry[K, V]) *listlib.Element {
+	list, ok := c.freqs[e.freq]
+	if !ok {
+		list = listlib.New()
+		c.freqs[e.freq] = list
+	}
+	e.list = list
+	return list.PushFront(e)
+}
+
+// detach removes the element from the list of its use count, the list is dropped when it becomes empty
+func (c *LFU[K, V]) detach(element *listlib.Element) *lfuEntry[K, V] {
+	e := element.Value.(*lfuEntry[K, V])
+	e.list.Remove(element)
+	if e.list.Len() == 0 {
+		delete(c.freqs, e.freq)
+		if c.minFreq == e.freq {
+			c.minFreq++
+		}
+	}
+	return e
+}
+
+// evictLeast evicts the least recently used entry of the least use count
+func (c *LFU[K, V]) evictLeast() {
+	list, ok := c.freqs[c.minFreq]
+	if !ok {
+		// the minimum is stale after Remove, find it again
+		c.minFreq = 0
+		for freq := range c.freqs {
+			if c.minFreq == 0 || freq < c.minFreq {
+				c.minFreq = freq
+			}
+		}
+		list = c.freqs[c.minFreq]
+	}
+	e := c.detach(list.Back())
+	delete(c.items, e.key)
+	c.evict(&e.entry)
+}
+
+// Remove removes the specific key and reports whether it was in the cache
+func (c *LFU[K, V]) Remove(key K) bool {
+	c.lock.Lock()
+	defer c.unlock()
+	element, ok := c.items[key]
+	if ok {
+		c.detach(element)
+		delete(c.items, key)
+	}
+	return ok
+}
+
+// Clear clears the cache, the stats are kept
+func (c *LFU[K, V]) Clear() {
+	c.lock.Lock()
+	defer c.unlock()
+	c.items = make(map[K]*listlib.Element)
+	c.freqs = make(map[int]*listlib.List)
+	c.minFreq = 0
+}
+
+// Stats returns the statistics of the cache
+func (c *LFU[K, V]) Stats() Stats {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.stats(len(c.items))
+}
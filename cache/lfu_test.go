@@ -0,0 +1,48 @@
+package cache
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLFU_Put(t *testing.T) {
+	cache := NewLFU[int, string](2)
+	cache.Put(1, "a")
+	cache.Put(2, "b")
+	cache.Get(1)
+	cache.Put(3, "c")
+	assert.True(t, cache.Contains(1))
+	assert.False(t, cache.Contains(2))
+	cache.Put(4, "d")
+	assert.True(t, cache.Contains(1))
+	assert.False(t, cache.Contains(3))
+	assert.True(t, cache.Contains(4))
+}
+
+func TestLFU_Get(t *testing.T) {
+	cache := NewLFU[int, string](2)
+	cache.Put(1, "a")
+	cache.Put(2, "b")
+	cache.Get(1)
+	cache.Get(2)
+	cache.Get(2)
+	cache.Put(3, "c")
+	assert.False(t, cache.Contains(1))
+	assert.True(t, cache.Contains(2))
+}
+
+func TestLFU_Remove(t *testing.T) {
+	cache := NewLFU[int, string](2)
+	cache.Put(1, "a")
+	cache.Put(2, "b")
+	cache.Get(2)
+	cache.Remove(1)
+	cache.Get(2)
+	cache.Put(3, "c")
+	cache.Get(3)
+	cache.Put(4, "d")
+	assert.True(t, cache.Contains(2))
+	assert.False(t, cache.Contains(3))
+	assert.True(t, cache.Contains(4))
+}
@@ -0,0 +1,209 @@
+package cache
+
+import (
+	listlib "container/list"
+	"math/rand/v2"
+)
+
+// Policy eviction strategy of a [Weighted] cache, it tracks the keys of the cache and chooses the one to evict next.
+// The cache calls it while it is locked, so an implementation needs no locking of its own,
+// and a key being set is removed from it while the cache makes room, so the key is never its own victim.
+// A policy must not be shared by several caches.
+type Policy[K comparable] interface {
+	// Add tracks a new key
+	Add(key K)
+	// Access records a use of the key by Get or an update by Put
+	Access(key K)
+	// Remove forgets the key which leaves the cache
+	Remove(key K)
+	// Victim returns the key to evict next without forgetting it, false when no key is tracked
+	Victim() (K, bool)
+	// Clear forgets all keys
+	Clear()
+}
+
+var (
+	_ Policy[int] = (*orderPolicy[int])(nil)
+	_ Policy[int] = (*lfuPolicy[int])(nil)
+	_ Policy[int] = (*randomPolicy[int])(nil)
+)
+
+// NewLRUPolicy new policy which evicts the least recently used key
+func NewLRUPolicy[K comparable]() Policy[K] {
+	return newOrderPolicy[K](true)
+}
+
+// NewFIFOPolicy new policy which evicts the key added first, the uses do not change the order
+func NewFIFOPolicy[K comparable]() Policy[K] {
+	return newOrderPolicy[K](false)
+}
+
+// orderPolicy policy which evicts the back of a list, the most recently added or used key is at the front
+type orderPolicy[K comparable] struct {
+	items   map[K]*listlib.Element
+	order   *listlib.List
+	refresh bool // whether a use moves the key to the front
+}
+
+func newOrderPolicy[K comparable](refresh bool) *orderPolicy[K] {
+	return &orderPolicy[K]{items: make(map[K]*listlib.Element), order: listlib.New(), refresh: refresh}
+}
+
+func (p *orderPolicy[K]) Add(key K) {
+	p.items[key] = p.order.PushFront(key)
+}
+
+func (p *orderPolicy[K]) Access(key K) {
+	if element, ok := p.items[key]; ok && p.refresh {
+		p.order.MoveToFront(element)
+	}
+}
+
+func (p *orderPolicy[K]) Remove(key K) {
+	if element, ok := p.items[key]; ok {
+		p.order.Remove(element)
+		delete(p.items, key)
+	}
+}
+
+func (p *orderPolicy[K]) Victim() (K, bool) {
+	if element := p.order.Back(); element != nil {
+		return element.Value.(K), true
+	}
+	return *new(K), false
+}
+
+func (p *orderPolicy[K]) Clear() {
+	p.items = make(map[K]*listlib.Element)
+	p.order.Init()
+}
+
+// NewLFUPolicy new policy which evicts the least frequently used key, the least recently used one among them.
+// The counts never decay, as the ones of [LFU].
+func NewLFUPolicy[K comparable]() Policy[K] {
+	return &lfuPolicy[K]{items: make(map[K]*listlib.Element), freqs: make(map[int]*listlib.List)}
+}
+
+// lfuPolicy policy which keeps the keys in a list per use count, as [LFU] keeps its entries
+type lfuPolicy[K comparable] struct {
+	items   map[K]*listlib.Element
+	freqs   map[int]*listlib.List // the keys of each use count, the most recently used one is at the front
+	minFreq int
+}
+
+type lfuKey[K comparable] struct {
+	key  K
+	freq int
+}
+
+func (p *lfuPolicy[K]) Add(key K) {
+	p.push(&lfuKey[K]{key: key, freq: 1})
+	p.minFreq = 1
+}
+
+func (p *lfuPolicy[K]) Access(key K) {
+	if element, ok := p.items[key]; ok {
+		k := p.detach(element)
+		k.freq++
+		p.push(k)
+	}
+}
+
+func (p *lfuPolicy[K]) Remove(key K) {
+	if element, ok := p.items[key]; ok {
+		p.detach(element)
+		delete(p.items, key)
+	}
+}
+
+func (p *lfuPolicy[K]) Victim() (K, bool) {
+	if len(p.items) == 0 {
+		return *new(K), false
+	}
+	list, ok := p.freqs[p.minFreq]
+	if !ok {
+		// the minimum is stale after Remove, find it again
+		p.minFreq = 0
+		for freq := range p.freqs {
+			if p.minFreq == 0 || freq < p.minFreq {
+				p.minFreq = freq
+			}
+		}
+		list = p.freqs[p.minFreq]
+	}
+	return list.Back().Value.(*lfuKey[K]).key, true
+}
+
+func (p *lfuPolicy[K]) Clear() {
+	p.items = make(map[K]*listlib.Element)
+	p.freqs = make(map[int]*listlib.List)
+	p.minFreq = 0
+}
+
+// push puts the key at the front of the list of its use count
+func (p *lfuPolicy[K]) push(k *lfuKey[K]) {
+	list, ok := p.freqs[k.freq]
+	if !ok {
+		list = listlib.New()
+		p.freqs[k.freq] = list
+	}
+	p.items[k.key] = list.PushFront(k)
+}
+
+// detach removes the element from the list of its use count, the list is dropped when it becomes empty
+func (p *lfuPolicy[K]) detach(element *listlib.Element) *lfuKey[K] {
+	k := element.Value.(*lfuKey[K])
+	list := p.freqs[k.freq]
+	list.Remove(element)
+	if list.Len() == 0 {
+		delete(p.freqs, k.freq)
+		if p.minFreq == k.freq {
+			p.minFreq++
+		}
+	}
+	return k
+}
+
+// NewRandomPolicy new policy which evicts a random key, it costs no bookkeeping on use,
+// which suits the workloads without a useful pattern of access
+func NewRandomPolicy[K comparable]() Policy[K] {
+	return &randomPolicy[K]{indexes: make(map[K]int)}
+}
+
+// randomPolicy policy which keeps the keys in a slice, a removed key is swapped with the last one
+type randomPolicy[K comparable] struct {
+	keys    []K
+	indexes map[K]int
+}
+
+func (p *randomPolicy[K]) Add(key K) {
+	p.indexes[key] = len(p.keys)
+	p.keys = append(p.keys, key)
+}
+
+func (p *randomPolicy[K]) Access(K) {}
+
+func (p *randomPolicy[K]) Remove(key K) {
+	index, ok := p.indexes[key]
+	if !ok {
+		return
+	}
+	last := len(p.keys) - 1
+	p.keys[index] = p.keys[last]
+	p.indexes[p.keys[index]] = index
+	p.keys[last] = *new(K)
+	p.keys = p.keys[:last]
+	delete(p.indexes, key)
+}
+
+func (p *randomPolicy[K]) Victim() (K, bool) {
+	if len(p.keys) == 0 {
+		return *new(K), false
+	}
+	return p.keys[rand.IntN(len(p.keys))], true
+}
+
+func (p *randomPolicy[K]) Clear() {
+	p.keys = nil
+	p.indexes = make(map[K]int)
+}
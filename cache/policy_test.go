@@ -0,0 +1,74 @@
+package cache
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// victims returns the keys the policy evicts in order until it is empty
+func victims(policy Policy[int]) []int {
+	var keys []int
+	for {
+		key, ok := policy.Victim()
+		if !ok {
+			return keys
+		}
+		policy.Remove(key)
+		keys = append(keys, key)
+	}
+}
+
+func TestNewLRUPolicy(t *testing.T) {
+	policy := NewLRUPolicy[int]()
+	for key := range 4 {
+		policy.Add(key)
+	}
+	policy.Access(0)
+	policy.Access(2)
+	policy.Remove(1)
+	assert.Equal(t, []int{3, 0, 2}, victims(policy))
+}
+
+func TestNewFIFOPolicy(t *testing.T) {
+	policy := NewFIFOPolicy[int]()
+	for key := range 4 {
+		policy.Add(key)
+	}
+	policy.Access(0)
+	policy.Remove(2)
+	assert.Equal(t, []int{0, 1, 3}, victims(policy))
+	policy.Add(5)
+	policy.Clear()
+	assert.Empty(t, victims(policy))
+}
+
+func TestNewLFUPolicy(t *testing.T) {
+	policy := NewLFUPolicy[int]()
+	for key := range 4 {
+		policy.Add(key)
+	}
+	policy.Access(0)
+	policy.Access(0)
+	policy.Access(1)
+	policy.Access(3)
+	policy.Remove(2)
+	assert.Equal(t, []int{1, 3, 0}, victims(policy))
+	policy.Add(4)
+	policy.Clear()
+	assert.Empty(t, victims(policy))
+}
+
+func TestNewRandomPolicy(t *testing.T) {
+	policy := NewRandomPolicy[int]()
+	for key := range 10 {
+		policy.Add(key)
+	}
+	policy.Access(0)
+	policy.Remove(4)
+	policy.Remove(9)
+	assert.ElementsMatch(t, []int{0, 1, 2, 3, 5, 6, 7, 8}, victims(policy))
+	policy.Add(1)
+	policy.Clear()
+	assert.Empty(t, victims(policy))
+}
@@ -0,0 +1,161 @@
+package cache
+
+import (
+	listlib "container/list"
+)
+
+// NewTwoQueue new 2Q cache, a quarter of the capacity admits the new keys and the ghosts of half the capacity
+// of keys are remembered. It panics when the capacity is not positive.
+func NewTwoQueue[K comparable, V any](cap int) *TwoQueue[K, V] {
+	return &TwoQueue[K, V]{
+		base:  newBase[K, V](cap),
+		items: make(map[K]*listlib.Element),
+		in:    listlib.New(),
+		out:   listlib.New(),
+		main:  listlib.New(),
+		kin:   max(cap/4, 1),
+		kout:  max(cap/2, 1),
+	}
+}
+
+// TwoQueue 2Q cache, all methods are safe for concurrent use.
+// A new key is admitted to a FIFO queue, and the keys evicted from it are remembered without their values as ghosts,
+// a key which is put again while it is a ghost is promoted to the main LRU list.
+// So the keys used only once, such as the keys of a scan, pass through the FIFO queue without flushing the main list.
+// Get and Put take O(1) time.
+type TwoQueue[K comparable, V any] struct {
+	base[K, V]
+	items map[K]*listlib.Element // the entries and the ghosts
+	in    *listlib.List          // the FIFO queue of the new entries, the newest one is at the front
+	out   *listlib.List          // the FIFO queue of the ghosts evicted from in
+	main  *listlib.List          // the LRU list of the promoted entries, the most recently used one is at the front
+	kin   int                    // the size of in above which it is evicted first
+	kout  int                    // the maximum number of ghosts
+}
+
+// Count returns the number of entries, the ghosts are not counted
+func (c *TwoQueue[K, V]) Count() int64 {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return int64(c.in.Len() + c.main.Len())
+}
+
+// live returns the element of the key when it is an entry and not a ghost
+func (c *TwoQueue[K, V]) live(key K) (*listlib.Element, bool) {
+	element, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	return element, element.Value.(*entry[K, V]).list != c.out
+}
+
+// Get returns the value of the specific key and counts a use of it, an entry in the FIFO queue keeps its place.
+// A zero value and false will be returned when the key is not exist.
+func (c *TwoQueue[K, V]) Get(key K) (V, bool) {
+	c.lock.Lock()
+	defer c.unlock()
+	element, ok := c.live(key)
+	if !ok {
+		return c.hit(*new(V), false)
+	}
+	e := element.Value.(*entry[K, V])
+	if e.list == c.main {
+		c.main.MoveToFront(element)
+	}
+	return c.hit(e.value, true)
+}
+
+// Peek returns the value of the specific key without counting a use of it
+func (c *TwoQueue[K, V]) Peek(key K) (V, bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if element, ok := c.live(key); ok {
+		return element.Value.(*entry[K, V]).value, true
+	}
+	return *new(V), false
+}
+
+// Contains returns whether the cache has the specific key, see Peek
+func (c *TwoQueue[K, V]) Contains(key K) bool {
+	_, ok := c.Peek(key)
+	return ok
+}
+
+// Put sets the value to the specific key, a new key goes to the FIFO queue and a ghost key to the main list.
+// An entry is evicted when the cache is full.
+func (c *TwoQueue[K, V]) Put(key K, value V) {
+	c.lock.Lock()
+	defer c.unlock()
+	element, ok := c.items[key]
+	if !ok {
+		c.reclaim()
+		c.items[key] = c.in.PushFront(&entry[K, V]{key: key, value: value, list: c.in})
+		return
+	}
+	e := element.Value.(*entry[K, V])
+	switch e.list {
+	case c.main:
+		c.main.MoveToFront(element)
+	case c.out:
+		c.out.Remove(element)
+		delete(c.items, key)
+		c.reclaim()
+		e.list = c.main
+		c.items[key] = c.main.PushFront(e)
+	}
+	e.value = value
+}
+
+// reclaim evicts an entry when the cache is full, from the FIFO queue when it is over its size and from the main list otherwise
+func (c *TwoQueue[K, V]) reclaim() {
+	if c.in.Len()+c.main.Len() < c.cap {
+		return
+	}
+	if c.in.Len() > c.kin || c.main.Len() == 0 {
+		element := c.in.Back()
+		e := element.Value.(*entry[K, V])
+		c.evict(e)
+		c.in.Remove(element)
+		e.value = *new(V)
+		e.list = c.out
+		c.items[e.key] = c.out.PushFront(e)
+		if c.out.Len() > c.kout {
+			delete(c.items, c.out.Remove(c.out.Back()).(*entry[K, V]).key)
+		}
+		return
+	}
+	e := c.main.Remove(c.main.Back()).(*entry[K, V])
+	delete(c.items, e.key)
+	c.evict(e)
+}
+
+// Remove removes the specific key and reports whether it was in the cache, a ghost of the key is forgotten as well
+func (c *TwoQueue[K, V]) Remove(key K) bool {
+	c.lock.Lock()
+	defer c.unlock()
+	element, ok := c.items[key]
+	if !ok {
+		return false
+	}
+	e := element.Value.(*entry[K, V])
+	e.list.Remove(element)
+	delete(c.items, key)
+	return e.list != c.out
+}
+
+// Clear clears the cache and its ghosts, the stats are kept
+func (c *TwoQueue[K, V]) Clear() {
+	c.lock.Lock()
+	defer c.unlock()
+	c.items = make(map[K]*listlib.Element)
+	c.in.Init()
+	c.out.Init()
+	c.main.Init()
+}
+
+// Stats returns the statistics of the cache
+func (c *TwoQueue[K, V]) Stats() Stats {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.stats(c.in.Len() + c.main.Len())
+}
@@ -0,0 +1,47 @@
+package cache
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTwoQueue_Put(t *testing.T) {
+	cache := NewTwoQueue[int, string](4)
+	for key, value := range []string{"a", "b", "c", "d", "e"} {
+		cache.Put(key, value)
+	}
+	assert.False(t, cache.Contains(0))
+	// 0 is a ghost, putting it again promotes it to the main list
+	cache.Put(0, "A")
+	assert.False(t, cache.Contains(1))
+	for key := 100; key < 200; key++ {
+		cache.Put(key, "scan")
+	}
+	value, ok := cache.Get(0)
+	assert.True(t, ok)
+	assert.Equal(t, "A", value)
+	assert.EqualValues(t, 4, cache.Count())
+}
+
+func TestTwoQueue_Get(t *testing.T) {
+	cache := NewTwoQueue[int, string](2)
+	cache.Put(1, "a")
+	cache.Put(2, "b")
+	cache.Get(1)
+	cache.Put(3, "c")
+	assert.False(t, cache.Contains(1))
+	assert.True(t, cache.Contains(2))
+	assert.True(t, cache.Contains(3))
+}
+
+func TestTwoQueue_Remove(t *testing.T) {
+	cache := NewTwoQueue[int, string](1)
+	cache.Put(1, "a")
+	cache.Put(2, "b")
+	assert.False(t, cache.Remove(1))
+	cache.Put(1, "a")
+	assert.True(t, cache.Contains(1))
+	assert.True(t, cache.Remove(1))
+	assert.EqualValues(t, 0, cache.Count())
+}
@@ -0,0 +1,180 @@
+package cache
+
+import "fmt"
+
+// NewWeighted new cache which keeps entries of at most the capacity in total cost and evicts them by the policy,
+// such as a cache bounded by the bytes of its values. It panics when the capacity is not positive or the policy is nil.
+func NewWeighted[K comparable, V any](cap int, policy Policy[K]) *Weighted[K, V] {
+	if policy == nil {
+		panic(fmt.Errorf("cache: policy is nil"))
+	}
+	return &Weighted[K, V]{
+		base:   newBase[K, V](cap),
+		items:  make(map[K]*weightedEntry[K, V]),
+		policy: policy,
+	}
+}
+
+// Weighted cache whose capacity bounds the total cost of its entries instead of their number,
+// the policy chooses the entries to evict until a new entry fits, all methods are safe for concurrent use.
+// SetWithCost sets an entry of its own cost, Put sets one of the cost returned by the cost func, which is 1 by default,
+// so a Weighted cache of the default cost func works as a cache of a bounded number of entries.
+type Weighted[K comparable, V any] struct {
+	base[K, V]
+	items  map[K]*weightedEntry[K, V]
+	policy Policy[K]
+	cost   int // the total cost of the entries
+	costOf func(key K, value V) int
+}
+
+type weightedEntry[K comparable, V any] struct {
+	entry[K, V]
+	cost int
+}
+
+// SetCostFunc sets the func which returns the cost of the entries set by Put, a nil func costs 1 for each entry
+func (c *Weighted[K, V]) SetCostFunc(costOf func(key K, value V) int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.costOf = costOf
+}
+
+// Count returns the number of entries
+func (c *Weighted[K, V]) Count() int64 {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return int64(len(c.items))
+}
+
+// Cost returns the total cost of the entries, it never exceeds the capacity
+func (c *Weighted[K, V]) Cost() int {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.cost
+}
+
+// Get returns the value of the specific key and records a use of it for the policy.
+// A zero value and false will be returned when the key is not exist.
+func (c *Weighted[K, V]) Get(key K) (V, bool) {
+	c.lock.Lock()
+	defer c.unlock()
+	e, ok := c.items[key]
+	if !ok {
+		return c.hit(*new(V), false)
+	}
+	c.policy.Access(key)
+	return c.hit(e.value, true)
+}
+
+// Peek returns the value of the specific key without recording a use of it
+func (c *Weighted[K, V]) Peek(key K) (V, bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if e, ok := c.items[key]; ok {
+		return e.value, true
+	}
+	return *new(V), false
+}
+
+// Contains returns whether the cache has the specific key, see Peek
+func (c *Weighted[K, V]) Contains(key K) bool {
+	_, ok := c.Peek(key)
+	return ok
+}
+
+// Put sets the value to the specific key at the cost returned by the cost func, see SetWithCost
+func (c *Weighted[K, V]) Put(key K, value V) {
+	c.lock.Lock()
+	defer c.unlock()
+	cost := 1
+	if c.costOf != nil {
+		cost = c.costOf(key, value)
+	}
+	c.set(key, value, cost)
+}
+
+// SetWithCost sets the value to the specific key at the cost, the policy evicts entries until the total cost fits the capacity.
+// It returns false and keeps no entry of the key when the cost alone exceeds the capacity. It panics when the cost is negative.
+func (c *Weighted[K, V]) SetWithCost(key K, value V, cost int) bool {
+	c.lock.Lock()
+	defer c.unlock()
+	return c.set(key, value, cost)
+}
+
+func (c *Weighted[K, V]) set(key K, value V, cost int) bool {
+	if cost < 0 {
+		panic(fmt.Errorf("cache: cost %d is negative", cost))
+	}
+	e, ok := c.items[key]
+	if cost > c.cap {
+		if ok {
+			c.remove(key, e)
+		}
+		return false
+	}
+	if !ok {
+		c.makeRoom(cost)
+		c.items[key] = &weightedEntry[K, V]{entry: entry[K, V]{key: key, value: value}, cost: cost}
+		c.policy.Add(key)
+		c.cost += cost
+		return true
+	}
+	c.cost -= e.cost
+	e.value, e.cost = value, cost
+	if c.cost+cost > c.cap {
+		c.policy.Remove(key)
+		c.makeRoom(cost)
+		c.policy.Add(key)
+	} else {
+		c.policy.Access(key)
+	}
+	c.cost += cost
+	return true
+}
+
+// makeRoom evicts the victims of the policy until the cost fits into the capacity
+func (c *Weighted[K, V]) makeRoom(cost int) {
+	for c.cost+cost > c.cap {
+		key, ok := c.policy.Victim()
+		if !ok {
+			return
+		}
+		e := c.items[key]
+		c.remove(key, e)
+		c.evict(&e.entry)
+	}
+}
+
+// remove removes the entry of the key from the cache and the policy
+func (c *Weighted[K, V]) remove(key K, e *weightedEntry[K, V]) {
+	c.policy.Remove(key)
+	delete(c.items, key)
+	c.cost -= e.cost
+}
+
+// Remove removes the specific key and reports whether it was in the cache
+func (c *Weighted[K, V]) Remove(key K) bool {
+	c.lock.Lock()
+	defer c.unlock()
+	e, ok := c.items[key]
+	if ok {
+		c.remove(key, e)
+	}
+	return ok
+}
+
+// Clear clears the cache, the stats are kept
+func (c *Weighted[K, V]) Clear() {
+	c.lock.Lock()
+	defer c.unlock()
+	c.items = make(map[K]*weightedEntry[K, V])
+	c.policy.Clear()
+	c.cost = 0
+}
+
+// Stats returns the statistics of the cache
+func (c *Weighted[K, V]) Stats() Stats {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.stats(len(c.items))
+}
@@ -0,0 +1,81 @@
+package cache
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewWeighted(t *testing.T) {
+	assert.PanicsWithError(t, "cache: policy is nil", func() {
+		NewWeighted[int, int](1, nil)
+	})
+	assert.PanicsWithError(t, "cache: capacity 0 is not positive", func() {
+		NewWeighted[int, int](0, NewLRUPolicy[int]())
+	})
+}
+
+func TestWeighted_SetWithCost(t *testing.T) {
+	cache := NewWeighted[string, string](10, NewLRUPolicy[string]())
+	var evicted []string
+	cache.OnEvict(func(key string, value string, reason EvictionReason) {
+		evicted = append(evicted, key)
+	})
+	assert.True(t, cache.SetWithCost("a", "a", 4))
+	assert.True(t, cache.SetWithCost("b", "b", 4))
+	cache.Get("a")
+	assert.True(t, cache.SetWithCost("c", "c", 5))
+	assert.Equal(t, []string{"b"}, evicted)
+	assert.Equal(t, 9, cache.Cost())
+	assert.Equal(t, 10, cache.Cap())
+
+	assert.True(t, cache.SetWithCost("c", "C", 7))
+	assert.Equal(t, []string{"b", "a"}, evicted)
+	assert.Equal(t, 7, cache.Cost())
+	value, _ := cache.Peek("c")
+	assert.Equal(t, "C", value)
+
+	assert.True(t, cache.SetWithCost("d", "d", 0))
+	assert.False(t, cache.SetWithCost("c", "big", 11))
+	assert.False(t, cache.Contains("c"))
+	assert.Equal(t, 0, cache.Cost())
+	assert.EqualValues(t, 1, cache.Count())
+	assert.Equal(t, []string{"b", "a"}, evicted)
+	assert.PanicsWithError(t, "cache: cost -1 is negative", func() {
+		cache.SetWithCost("e", "e", -1)
+	})
+
+	cache.Remove("d")
+	cache.SetWithCost("f", "f", 3)
+	cache.Clear()
+	assert.Equal(t, 0, cache.Cost())
+	assert.Equal(t, Stats{Hits: 1, Evictions: 2}, cache.Stats())
+
+	t.Run("update", func(t *testing.T) {
+		cache := NewWeighted[string, string](10, NewLFUPolicy[string]())
+		cache.SetWithCost("a", "a", 4)
+		cache.SetWithCost("b", "b", 4)
+		cache.Get("a")
+		assert.True(t, cache.SetWithCost("b", "B", 7))
+		assert.False(t, cache.Contains("a"))
+		value, _ := cache.Peek("b")
+		assert.Equal(t, "B", value)
+		assert.Equal(t, 7, cache.Cost())
+	})
+}
+
+func TestWeighted_SetCostFunc(t *testing.T) {
+	cache := NewWeighted[string, []byte](8, NewFIFOPolicy[string]())
+	cache.SetCostFunc(func(key string, value []byte) int {
+		return len(value)
+	})
+	cache.Put("a", []byte("1234"))
+	cache.Put("b", []byte("123"))
+	cache.Get("a")
+	cache.Put("c", []byte("12"))
+	assert.False(t, cache.Contains("a"))
+	assert.Equal(t, 5, cache.Cost())
+	cache.SetCostFunc(nil)
+	cache.Put("d", nil)
+	assert.Equal(t, 6, cache.Cost())
+}
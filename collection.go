@@ -0,0 +1,240 @@
+// Package collection defines the contracts shared by the collections in list, set, queue, stack, tree and kv,
+// and the helpers to build collections from iterators.
+package collection
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"reflect"
+	"runtime"
+	"slices"
+	"sync"
+)
+
+// ErrNotCollectable is the panic of CollectInto when the collection accepts no elements
+var ErrNotCollectable = errors.New("collection accepts no elements, it implements none of Pusher, Adder and Enqueuer")
+
+// Iterable collection whose elements can be iterated
+type Iterable[E any] interface {
+	// All returns an iterator over the elements
+	All() iter.Seq[E]
+}
+
+// Iterable2 collection whose key value pairs can be iterated
+type Iterable2[K, V any] interface {
+	// All returns an iterator over the key value pairs
+	All() iter.Seq2[K, V]
+}
+
+// Cloneable value which can clone itself, it is cloned by the DeepClone methods of the collections
+type Cloneable[T any] interface {
+	// Clone returns a copy of the value
+	Clone() T
+}
+
+// DeepCloner value which can clone itself and its elements, such as the collections
+type DeepCloner[T any] interface {
+	// DeepClone returns a copy of the value whose elements are cloned too
+	DeepClone() T
+}
+
+// CloneValue clones the value with DeepClone or Clone when it implements [DeepCloner] or [Cloneable],
+// otherwise the value itself is returned, so pointers, slices and maps are still shared
+func CloneValue[T any](value T) T {
+	switch v := any(value).(type) {
+	case DeepCloner[T]:
+		return v.DeepClone()
+	case Cloneable[T]:
+		return v.Clone()
+	}
+	return value
+}
+
+// Hasher value which can hash itself, values which are equal must have the same hash
+type Hasher interface {
+	// Hash returns the hash of the value, it is only stable within the process
+	Hash() uint64
+}
+
+// Equaler value which can compare itself with another value, such as the collections
+type Equaler[T any] interface {
+	// Equal returns whether the value equals the other value
+	Equal(other T) bool
+}
+
+// Equal returns whether the iterables have equal elements in the same order, such as a list and a linked queue.
+// The elements are compared with the Equal method of [Equaler] or [reflect.DeepEqual].
+func Equal[E any](a, b Iterable[E]) bool {
+	next, stop := iter.Pull(b.All())
+	defer stop()
+	for value := range a.All() {
+		other, ok := next()
+		if !ok || !equal(value, other) {
+			return false
+		}
+	}
+	_, ok := next()
+	return !ok
+}
+
+// ElementsMatch returns whether the iterables have equal elements regardless of their order,
+// each element must appear as many times in both, the elements are compared as Equal does.
+// It compares each element of a with the unmatched elements of b, so it takes quadratic time.
+func ElementsMatch[E any](a, b Iterable[E]) bool {
+	others := slices.Collect(b.All())
+	matched := make([]bool, len(others))
+	count := 0
+	for value := range a.All() {
+		count++
+		found := false
+		for index, other := range others {
+			if !matched[index] && equal(value, other) {
+				matched[index], found = true, true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return count == len(others)
+}
+
+func equal[E any](a, b E) bool {
+	if equaler, ok := any(a).(Equaler[E]); ok {
+		return equaler.Equal(b)
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// Pusher collection which accepts elements with Push, such as lists, sets and the avl tree
+type Pusher[E any] interface {
+	Push(values ...E)
+}
+
+// Adder collection which accepts elements with Add, such as the trees
+type Adder[E any] interface {
+	Add(value E) bool
+}
+
+// Enqueuer collection which accepts elements with Enqueue, such as the queues
+type Enqueuer[E any] interface {
+	Enqueue(value E) bool
+}
+
+// Setter collection which accepts key value pairs with Set, such as the maps
+type Setter[K, V any] interface {
+	Set(key K, value V)
+}
+
+// Collect pushes all elements of the iterator into the collection and returns the collection
+func Collect[E any, C Pusher[E]](collection C, seq iter.Seq[E]) C {
+	for value := range seq {
+		collection.Push(value)
+	}
+	return collection
+}
+
+// CollectAdd adds all elements of the iterator into the collection and returns the collection
+func CollectAdd[E any, C Adder[E]](collection C, seq iter.Seq[E]) C {
+	for value := range seq {
+		collection.Add(value)
+	}
+	return collection
+}
+
+// CollectQueue enqueues all elements of the iterator into the queue and returns the queue,
+// it stops when the queue refuses an element
+func CollectQueue[E any, Q Enqueuer[E]](queue Q, seq iter.Seq[E]) Q {
+	for value := range seq {
+		if !queue.Enqueue(value) {
+			break
+		}
+	}
+	return queue
+}
+
+// CollectInto pours all elements of the iterator into any of the collections and returns the collection,
+// the elements go through Push, Add or Enqueue, whichever the collection implements first in this order.
+// It panics with [ErrNotCollectable] when the collection implements none of them, use CollectMap for maps.
+func CollectInto[E any, C any](seq iter.Seq[E], into C) C {
+	switch collection := any(into).(type) {
+	case Pusher[E]:
+		Collect(collection, seq)
+	case Adder[E]:
+		CollectAdd(collection, seq)
+	case Enqueuer[E]:
+		CollectQueue(collection, seq)
+	default:
+		panic(fmt.Errorf("%w: %T", ErrNotCollectable, into))
+	}
+	return into
+}
+
+// CollectMap sets all key value pairs of the iterator into the map and returns the map
+func CollectMap[K, V any, M Setter[K, V]](m M, seq iter.Seq2[K, V]) M {
+	for key, value := range seq {
+		m.Set(key, value)
+	}
+	return m
+}
+
+// EachParallel calls fn for each element of the collection on workers goroutines, GOMAXPROCS when workers <= 0.
+// The first error stops handing out the remaining elements and is returned once the running calls return.
+// The order of the calls is not defined, see EachParallelContext to let fn observe the cancellation.
+func EachParallel[E any](c Iterable[E], workers int, fn func(value E) error) error {
+	return EachParallelContext(context.Background(), c, workers, func(_ context.Context, value E) error {
+		return fn(value)
+	})
+}
+
+// EachParallelContext calls fn for each element of the collection on workers goroutines as EachParallel does,
+// the context passed to fn is canceled on the first error, and no more elements are handed out when ctx is done.
+// It returns the first error of fn, or the error of ctx when it is done before all calls return.
+func EachParallelContext[E any](ctx context.Context, c Iterable[E], workers int, fn func(ctx context.Context, value E) error) error {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	var (
+		wg     sync.WaitGroup
+		once   sync.Once
+		result error
+		values = make(chan E)
+	)
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for value := range values {
+				if ctx.Err() != nil {
+					continue
+				}
+				if err := fn(ctx, value); err != nil {
+					once.Do(func() {
+						result = err
+						cancel(err)
+					})
+				}
+			}
+		}()
+	}
+	for value := range c.All() {
+		select {
+		case values <- value:
+			continue
+		case <-ctx.Done():
+		}
+		break
+	}
+	close(values)
+	wg.Wait()
+	if result == nil {
+		// without an error of fn, the context can only be canceled by ctx, some elements may be skipped then
+		return context.Cause(ctx)
+	}
+	return result
+}
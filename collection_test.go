@@ -0,0 +1,229 @@
+package collection_test
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gopi-frame/collection"
+	"github.com/gopi-frame/collection/kv"
+	"github.com/gopi-frame/collection/list"
+	"github.com/gopi-frame/collection/queue"
+	"github.com/gopi-frame/collection/set"
+	"github.com/gopi-frame/collection/stack"
+	"github.com/gopi-frame/collection/tree"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	_ collection.Iterable[int]          = (*list.List[int])(nil)
+	_ collection.Iterable[int]          = (*list.LinkedList[int])(nil)
+	_ collection.Iterable[int]          = (*list.CopyOnWriteList[int])(nil)
+	_ collection.Iterable[int]          = (*list.ImmutableList[int])(nil)
+	_ collection.Iterable[int]          = (*set.Set[int])(nil)
+	_ collection.Iterable[int]          = (*set.LinkedSet[int])(nil)
+	_ collection.Iterable[int]          = (*set.TreeSet[int])(nil)
+	_ collection.Iterable[int]          = (*set.Bag[int])(nil)
+	_ collection.Iterable[int]          = (*set.ImmutableSet[int])(nil)
+	_ collection.Iterable[int]          = (*queue.Queue[int])(nil)
+	_ collection.Iterable[int]          = (*queue.Heap[int])(nil)
+	_ collection.Iterable[int]          = (*queue.LinkedQueue[int])(nil)
+	_ collection.Iterable[int]          = (*queue.BlockingQueue[int])(nil)
+	_ collection.Iterable[int]          = (*queue.LinkedBlockingQueue[int])(nil)
+	_ collection.Iterable[int]          = (*queue.PriorityQueue[int])(nil)
+	_ collection.Iterable[int]          = (*queue.PriorityBlockingQueue[int])(nil)
+	_ collection.Iterable[int]          = (*queue.Deque[int])(nil)
+	_ collection.Iterable[int]          = (*queue.BlockingDeque[int])(nil)
+	_ collection.Iterable[int]          = (*queue.RingQueue[int])(nil)
+	_ collection.Iterable[int]          = (*stack.Stack[int])(nil)
+	_ collection.Iterable[int]          = (*stack.LinkedStack[int])(nil)
+	_ collection.Iterable[int]          = (*stack.SyncStack[int])(nil)
+	_ collection.Iterable[int]          = (*tree.AVLTree[int])(nil)
+	_ collection.Iterable[int]          = (*tree.RBTree[int])(nil)
+	_ collection.Iterable2[int, int]    = (*kv.Map[int, int])(nil)
+	_ collection.Iterable2[int, int]    = (*kv.LinkedMap[int, int])(nil)
+	_ collection.Iterable2[int, int]    = (*kv.TreeMultiMap[int, int])(nil)
+	_ collection.Iterable2[int, int]    = (*kv.TreeMap[int, int])(nil)
+	_ collection.Iterable2[int, int]    = (*kv.MultiMap[int, int])(nil)
+	_ collection.Iterable2[int, int]    = (*kv.BiMap[int, int])(nil)
+	_ collection.Iterable2[int, int]    = (*kv.LRUCache[int, int])(nil)
+	_ collection.Iterable2[int, int]    = (*kv.ExpiringMap[int, int])(nil)
+	_ collection.Iterable2[int, int]    = (*kv.ImmutableMap[int, int])(nil)
+	_ collection.Iterable2[int, int]    = (*kv.SkipListMap[int, int])(nil)
+	_ collection.Iterable2[int, int]    = (*tree.BTree[int, int])(nil)
+	_ collection.Iterable2[string, int] = (*tree.Trie[int])(nil)
+	_ collection.Iterable2[[]byte, int] = (*tree.RadixTree[int])(nil)
+
+	_ collection.DeepCloner[*list.List[int]]            = (*list.List[int])(nil)
+	_ collection.DeepCloner[*list.LinkedList[int]]      = (*list.LinkedList[int])(nil)
+	_ collection.DeepCloner[*list.CopyOnWriteList[int]] = (*list.CopyOnWriteList[int])(nil)
+	_ collection.DeepCloner[*set.Set[int]]              = (*set.Set[int])(nil)
+	_ collection.DeepCloner[*set.LinkedSet[int]]        = (*set.LinkedSet[int])(nil)
+	_ collection.DeepCloner[*tree.AVLTree[int]]         = (*tree.AVLTree[int])(nil)
+	_ collection.DeepCloner[*tree.RBTree[int]]          = (*tree.RBTree[int])(nil)
+	_ collection.DeepCloner[*kv.Map[int, int]]          = (*kv.Map[int, int])(nil)
+	_ collection.DeepCloner[*kv.LinkedMap[int, int]]    = (*kv.LinkedMap[int, int])(nil)
+	_ collection.DeepCloner[*kv.TreeMultiMap[int, int]] = (*kv.TreeMultiMap[int, int])(nil)
+	_ collection.DeepCloner[*kv.MultiMap[int, int]]     = (*kv.MultiMap[int, int])(nil)
+
+	_ collection.Hasher                     = (*list.List[int])(nil)
+	_ collection.Hasher                     = (*set.Set[int])(nil)
+	_ collection.Hasher                     = (*kv.Map[int, int])(nil)
+	_ collection.Equaler[*list.List[int]]   = (*list.List[int])(nil)
+	_ collection.Equaler[*set.Set[int]]     = (*set.Set[int])(nil)
+	_ collection.Equaler[*kv.Map[int, int]] = (*kv.Map[int, int])(nil)
+)
+
+type _cmp struct{}
+
+func (c _cmp) Compare(a, b int) int {
+	if a < b {
+		return -1
+	} else if a > b {
+		return 1
+	}
+	return 0
+}
+
+func TestEqual(t *testing.T) {
+	l := list.NewList(1, 2, 3)
+	assert.True(t, collection.Equal[int](l, queue.NewLinkedQueue(1, 2, 3)))
+	assert.True(t, collection.Equal[int](list.NewList[int](), tree.NewAVLTreeOrdered[int]()))
+	assert.False(t, collection.Equal[int](l, list.NewLinkedList(1, 3, 2)))
+	assert.False(t, collection.Equal[int](l, list.NewList(1, 2)))
+	assert.False(t, collection.Equal[int](list.NewList(1, 2), l))
+
+	inner := list.NewList(1)
+	assert.True(t, collection.Equal[*list.List[int]](list.NewList(inner), list.NewLinkedList(list.NewList(1))))
+}
+
+func TestElementsMatch(t *testing.T) {
+	l := list.NewList(1, 2, 2, 3)
+	assert.True(t, collection.ElementsMatch[int](l, tree.NewAVLTreeOrdered(3, 2, 1, 2)))
+	assert.False(t, collection.ElementsMatch[int](l, set.NewSet(1, 2, 3)))
+	assert.False(t, collection.ElementsMatch[int](set.NewSet(1, 2, 3), l))
+	assert.False(t, collection.ElementsMatch[int](l, list.NewList(1, 2, 3, 3)))
+	assert.True(t, collection.ElementsMatch[int](list.NewList[int](), set.NewSet[int]()))
+}
+
+func TestEachParallel(t *testing.T) {
+	var sum atomic.Int64
+	err := collection.EachParallel[int](list.NewList(1, 2, 3, 4, 5), 2, func(value int) error {
+		sum.Add(int64(value))
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.EqualValues(t, 15, sum.Load())
+
+	var calls atomic.Int64
+	err = collection.EachParallel[int](list.NewList(1, 2, 3, 4, 5), 1, func(value int) error {
+		calls.Add(1)
+		if value == 2 {
+			return errors.New("failed")
+		}
+		return nil
+	})
+	assert.EqualError(t, err, "failed")
+	assert.LessOrEqual(t, calls.Load(), int64(3))
+}
+
+func TestEachParallelContext(t *testing.T) {
+	err := collection.EachParallelContext[int](context.Background(), set.NewSet(1, 2, 3), 3, func(ctx context.Context, value int) error {
+		if value == 2 {
+			return errors.New("failed")
+		}
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	assert.EqualError(t, err, "failed")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var calls atomic.Int64
+	err = collection.EachParallelContext[int](ctx, list.NewList(1, 2, 3), 2, func(ctx context.Context, value int) error {
+		calls.Add(1)
+		return nil
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Zero(t, calls.Load())
+}
+
+func TestCollect(t *testing.T) {
+	l := collection.Collect(list.NewList[int](), slices.Values([]int{1, 2, 3}))
+	assert.Equal(t, []int{1, 2, 3}, l.ToArray())
+
+	s := collection.Collect(set.NewLinkedSet[int](), l.All())
+	assert.Equal(t, []int{1, 2, 3}, s.ToArray())
+
+	avl := collection.Collect(tree.NewAVLTreeOrdered[int](), list.NewLinkedList(3, 1, 2).All())
+	assert.Equal(t, []int{1, 2, 3}, avl.ToArray())
+}
+
+func TestCollectAdd(t *testing.T) {
+	rb := collection.CollectAdd(tree.NewRBTreeOrdered[int](), set.NewSet(3, 1, 2).All())
+	assert.Equal(t, []int{1, 2, 3}, rb.ToArray())
+}
+
+func TestCollectQueue(t *testing.T) {
+	q := collection.CollectQueue(queue.NewQueue[int](), slices.Values([]int{1, 2, 3}))
+	assert.Equal(t, []int{1, 2, 3}, q.ToArray())
+
+	pq := collection.CollectQueue(queue.NewPriorityQueue[int](_cmp{}), slices.Values([]int{3, 1, 2}))
+	value, ok := pq.Dequeue()
+	assert.True(t, ok)
+	assert.Equal(t, 1, value)
+}
+
+func TestCollectInto(t *testing.T) {
+	s := collection.CollectInto(maps.Keys(map[int]bool{1: true, 2: true}), set.NewSet[int]())
+	assert.ElementsMatch(t, []int{1, 2}, s.ToArray())
+
+	rb := collection.CollectInto(slices.Values([]int{3, 1, 2}), tree.NewRBTreeOrdered[int]())
+	assert.Equal(t, []int{1, 2, 3}, rb.ToArray())
+
+	q := collection.CollectInto(s.All(), queue.NewLinkedQueue[int]())
+	assert.EqualValues(t, 2, q.Count())
+
+	assert.PanicsWithError(t, "collection accepts no elements, it implements none of Pusher, Adder and Enqueuer: *kv.Map[int,int]", func() {
+		collection.CollectInto(slices.Values([]int{1}), kv.NewMap[int, int]())
+	})
+}
+
+func TestCollectMap(t *testing.T) {
+	m := collection.CollectMap(kv.NewLinkedMap[string, int](), maps.All(map[string]int{"a": 1}))
+	m = collection.CollectMap(m, kv.NewFromMap(map[string]int{"b": 2}).All())
+	assert.Equal(t, map[string]int{"a": 1, "b": 2}, maps.Collect(m.All()))
+}
+
+type _point struct {
+	x, y int
+}
+
+func (p *_point) Clone() *_point {
+	return &_point{x: p.x, y: p.y}
+}
+
+func TestCloneValue(t *testing.T) {
+	t.Run("cloneable", func(t *testing.T) {
+		p := &_point{x: 1, y: 2}
+		clone := collection.CloneValue(p)
+		assert.NotSame(t, p, clone)
+		assert.Equal(t, p, clone)
+	})
+
+	t.Run("deep cloner", func(t *testing.T) {
+		l := list.NewList(&_point{x: 1, y: 2})
+		clone := collection.CloneValue(l)
+		assert.NotSame(t, l, clone)
+		assert.NotSame(t, l.Get(0), clone.Get(0))
+		assert.Equal(t, l.ToArray(), clone.ToArray())
+	})
+
+	t.Run("plain value", func(t *testing.T) {
+		p := &struct{ x int }{x: 1}
+		assert.Same(t, p, collection.CloneValue(p))
+	})
+}
@@ -0,0 +1,55 @@
+// Package comparator builds the comparators of the sorted collections, such as trees, tree sets and priority queues.
+package comparator
+
+import (
+	"cmp"
+
+	"github.com/gopi-frame/contract"
+)
+
+// Func adapts a compare function to [contract.Comparator], such as [strings.Compare] or a closure
+type Func[E any] func(a, b E) int
+
+// Compare implements [contract.Comparator]
+func (fn Func[E]) Compare(a, b E) int {
+	return fn(a, b)
+}
+
+// Ordered returns the comparator of ordered values, it compares them with [cmp.Compare]
+func Ordered[E cmp.Ordered]() contract.Comparator[E] {
+	return ordered[E]{}
+}
+
+type ordered[E cmp.Ordered] struct{}
+
+func (ordered[E]) Compare(a, b E) int {
+	return cmp.Compare(a, b)
+}
+
+// Reverse returns the comparator which orders the values in the reverse order of the comparator,
+// for example a priority queue with Reverse(Ordered[int]()) dequeues the largest value first
+func Reverse[E any](comparator contract.Comparator[E]) contract.Comparator[E] {
+	return Func[E](func(a, b E) int {
+		return comparator.Compare(b, a)
+	})
+}
+
+// ByKey returns the comparator which orders the values by the keys fn extracts from them
+func ByKey[E any, K cmp.Ordered](fn func(value E) K) contract.Comparator[E] {
+	return Func[E](func(a, b E) int {
+		return cmp.Compare(fn(a), fn(b))
+	})
+}
+
+// Chain returns the comparator which orders the values by the comparators in turn,
+// a comparator is only consulted when all the comparators before it find the values equal
+func Chain[E any](comparators ...contract.Comparator[E]) contract.Comparator[E] {
+	return Func[E](func(a, b E) int {
+		for _, comparator := range comparators {
+			if c := comparator.Compare(a, b); c != 0 {
+				return c
+			}
+		}
+		return 0
+	})
+}
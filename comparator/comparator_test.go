@@ -0,0 +1,48 @@
+package comparator
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type person struct {
+	name string
+	age  int
+}
+
+func TestFunc(t *testing.T) {
+	c := Func[string](strings.Compare)
+	assert.Equal(t, -1, c.Compare("a", "b"))
+	assert.Equal(t, 0, c.Compare("a", "a"))
+}
+
+func TestOrdered(t *testing.T) {
+	c := Ordered[int]()
+	assert.Equal(t, -1, c.Compare(1, 2))
+	assert.Equal(t, 1, c.Compare(2, 1))
+	assert.Equal(t, 0, c.Compare(1, 1))
+}
+
+func TestReverse(t *testing.T) {
+	c := Reverse(Ordered[int]())
+	assert.Equal(t, 1, c.Compare(1, 2))
+	assert.Equal(t, -1, c.Compare(2, 1))
+	assert.Equal(t, 0, c.Compare(1, 1))
+}
+
+func TestByKey(t *testing.T) {
+	c := ByKey(func(p person) int { return p.age })
+	assert.Equal(t, -1, c.Compare(person{"b", 1}, person{"a", 2}))
+	assert.Equal(t, 0, c.Compare(person{"b", 1}, person{"a", 1}))
+}
+
+func TestChain(t *testing.T) {
+	people := []person{{"b", 2}, {"c", 1}, {"a", 2}}
+	c := Chain(Reverse(ByKey(func(p person) int { return p.age })), ByKey(func(p person) string { return p.name }))
+	slices.SortFunc(people, c.Compare)
+	assert.Equal(t, []person{{"a", 2}, {"b", 2}, {"c", 1}}, people)
+	assert.Equal(t, 0, Chain[int]().Compare(1, 2))
+}
@@ -0,0 +1,67 @@
+// Package convert converts between the collections of this module without slice round-trips.
+package convert
+
+import (
+	"github.com/gopi-frame/collection"
+	"github.com/gopi-frame/collection/kv"
+	"github.com/gopi-frame/collection/list"
+	"github.com/gopi-frame/collection/queue"
+	"github.com/gopi-frame/collection/set"
+	"github.com/gopi-frame/collection/tree"
+	"github.com/gopi-frame/contract"
+)
+
+// ToList converts the elements of the source to a list
+func ToList[E any](source collection.Iterable[E]) *list.List[E] {
+	return collection.Collect(list.NewList[E](), source.All())
+}
+
+// ToLinkedList converts the elements of the source to a linked list
+func ToLinkedList[E any](source collection.Iterable[E]) *list.LinkedList[E] {
+	return collection.Collect(list.NewLinkedList[E](), source.All())
+}
+
+// ToSet converts the elements of the source to a set, duplicate elements are dropped
+func ToSet[E comparable](source collection.Iterable[E]) *set.Set[E] {
+	return collection.Collect(set.NewSet[E](), source.All())
+}
+
+// ToLinkedSet converts the elements of the source to a linked set keeping the order of the first occurrences
+func ToLinkedSet[E comparable](source collection.Iterable[E]) *set.LinkedSet[E] {
+	return collection.Collect(set.NewLinkedSet[E](), source.All())
+}
+
+// QueueFrom converts the elements of the source to a queue
+func QueueFrom[E any](source collection.Iterable[E]) *queue.Queue[E] {
+	return collection.CollectQueue(queue.NewQueue[E](), source.All())
+}
+
+// LinkedQueueFrom converts the elements of the source to a linked queue
+func LinkedQueueFrom[E any](source collection.Iterable[E]) *queue.LinkedQueue[E] {
+	return collection.CollectQueue(queue.NewLinkedQueue[E](), source.All())
+}
+
+// PriorityQueueFrom converts the elements of the source to a priority queue
+func PriorityQueueFrom[E any](source collection.Iterable[E], comparator contract.Comparator[E]) *queue.PriorityQueue[E] {
+	return collection.CollectQueue(queue.NewPriorityQueue[E](comparator), source.All())
+}
+
+// ToAVLTree converts the elements of the source to an avl tree
+func ToAVLTree[E any](source collection.Iterable[E], comparator contract.Comparator[E]) *tree.AVLTree[E] {
+	return collection.CollectAdd(tree.NewAVLTree[E](comparator), source.All())
+}
+
+// ToRBTree converts the elements of the source to a rb tree
+func ToRBTree[E any](source collection.Iterable[E], comparator contract.Comparator[E]) *tree.RBTree[E] {
+	return collection.CollectAdd(tree.NewRBTree[E](comparator), source.All())
+}
+
+// ToMap converts the key value pairs of the entries to a map, the later value wins on duplicate keys
+func ToMap[K comparable, V any](entries collection.Iterable2[K, V]) *kv.Map[K, V] {
+	return collection.CollectMap(kv.NewMap[K, V](), entries.All())
+}
+
+// ToLinkedMap converts the key value pairs of the entries to a linked map keeping the order of the entries
+func ToLinkedMap[K comparable, V any](entries collection.Iterable2[K, V]) *kv.LinkedMap[K, V] {
+	return collection.CollectMap(kv.NewLinkedMap[K, V](), entries.All())
+}
@@ -0,0 +1,83 @@
+package convert
+
+import (
+	"testing"
+
+	"github.com/gopi-frame/collection/kv"
+	"github.com/gopi-frame/collection/list"
+	"github.com/gopi-frame/collection/set"
+	"github.com/gopi-frame/collection/tree"
+	"github.com/stretchr/testify/assert"
+)
+
+type _cmp struct{}
+
+func (c _cmp) Compare(a, b int) int {
+	if a < b {
+		return -1
+	} else if a > b {
+		return 1
+	}
+	return 0
+}
+
+func TestToList(t *testing.T) {
+	assert.Equal(t, []int{1, 2, 3}, ToList[int](set.NewLinkedSet(1, 2, 3)).ToArray())
+}
+
+func TestToLinkedList(t *testing.T) {
+	assert.Equal(t, []int{1, 2, 3}, ToLinkedList[int](tree.NewAVLTree(_cmp{}, 3, 1, 2)).ToArray())
+}
+
+func TestToSet(t *testing.T) {
+	s := ToSet[int](list.NewList(1, 2, 2, 3))
+	assert.EqualValues(t, 3, s.Count())
+	assert.True(t, s.Contains(2))
+}
+
+func TestToLinkedSet(t *testing.T) {
+	assert.Equal(t, []int{3, 1, 2}, ToLinkedSet[int](list.NewList(3, 1, 3, 2, 1)).ToArray())
+}
+
+func TestQueueFrom(t *testing.T) {
+	q := QueueFrom[int](list.NewList(1, 2, 3))
+	value, ok := q.Dequeue()
+	assert.True(t, ok)
+	assert.Equal(t, 1, value)
+	assert.EqualValues(t, 2, q.Count())
+}
+
+func TestLinkedQueueFrom(t *testing.T) {
+	assert.Equal(t, []int{1, 2, 3}, LinkedQueueFrom[int](list.NewLinkedList(1, 2, 3)).ToArray())
+}
+
+func TestPriorityQueueFrom(t *testing.T) {
+	q := PriorityQueueFrom[int](list.NewList(3, 1, 2), _cmp{})
+	value, ok := q.Dequeue()
+	assert.True(t, ok)
+	assert.Equal(t, 1, value)
+}
+
+func TestToAVLTree(t *testing.T) {
+	assert.Equal(t, []int{1, 2, 3}, ToAVLTree[int](list.NewList(3, 1, 2), _cmp{}).ToArray())
+}
+
+func TestToRBTree(t *testing.T) {
+	assert.Equal(t, []int{1, 2, 3}, ToRBTree[int](set.NewSet(3, 1, 2), _cmp{}).ToArray())
+}
+
+func TestToMap(t *testing.T) {
+	m := kv.NewLinkedMap[string, int]()
+	m.Set("a", 1)
+	m.Set("b", 2)
+	assert.Equal(t, map[string]int{"a": 1, "b": 2}, ToMap[string, int](m).ToMap())
+}
+
+func TestToLinkedMap(t *testing.T) {
+	m := kv.NewTreeMultiMap[int, string](_cmp{})
+	m.Put(2, "b")
+	m.Put(1, "a1", "a2")
+	linked := ToLinkedMap[int, string](m)
+	assert.Equal(t, []int{1, 2}, linked.Keys())
+	assert.Equal(t, []string{"a2", "b"}, linked.Values())
+}
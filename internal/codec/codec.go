@@ -0,0 +1,269 @@
+// Package codec implements the binary encoding shared by the collections.
+//
+// A sequence is encoded as the uvarint number of its elements followed by the elements,
+// each element is encoded as the uvarint length of its bytes followed by the bytes.
+// The bytes of an element come from its own encoder:
+// [encoding.BinaryMarshaler] when it is implemented, the raw bytes of strings and byte slices,
+// varints for integers, little endian IEEE 754 for floats, and [encoding/gob] for anything else.
+package codec
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+)
+
+// ErrInvalidData is returned when the data is not a valid encoding
+var ErrInvalidData = errors.New("codec: invalid data")
+
+var (
+	marshalerType   = reflect.TypeFor[encoding.BinaryMarshaler]()
+	unmarshalerType = reflect.TypeFor[encoding.BinaryUnmarshaler]()
+)
+
+// Encoder appends encoded counts and elements to a buffer
+type Encoder struct {
+	buf []byte
+}
+
+// Count appends the number of elements of a sequence
+func (e *Encoder) Count(n int) {
+	e.buf = binary.AppendUvarint(e.buf, uint64(n))
+}
+
+// Uvarint appends an unsigned integer which is not an element, such as a version or a counter
+func (e *Encoder) Uvarint(n uint64) {
+	e.buf = binary.AppendUvarint(e.buf, n)
+}
+
+// Bytes returns the encoded bytes
+func (e *Encoder) Bytes() []byte {
+	return e.buf
+}
+
+// Encode appends a length-prefixed element
+func Encode[E any](e *Encoder, value E) error {
+	data, err := marshal(reflect.ValueOf(&value).Elem())
+	if err != nil {
+		return err
+	}
+	e.buf = binary.AppendUvarint(e.buf, uint64(len(data)))
+	e.buf = append(e.buf, data...)
+	return nil
+}
+
+// Decoder reads counts and elements from encoded bytes
+type Decoder struct {
+	data []byte
+}
+
+// NewDecoder creates a decoder of the data
+func NewDecoder(data []byte) *Decoder {
+	return &Decoder{data: data}
+}
+
+// Count reads the number of elements of a sequence,
+// it fails when the count cannot fit in the remaining bytes
+func (d *Decoder) Count() (int, error) {
+	n, size := binary.Uvarint(d.data)
+	if size <= 0 || n > uint64(len(d.data)) {
+		return 0, fmt.Errorf("%w: malformed count", ErrInvalidData)
+	}
+	d.data = d.data[size:]
+	return int(n), nil
+}
+
+// Uvarint reads an unsigned integer appended by [Encoder.Uvarint]
+func (d *Decoder) Uvarint() (uint64, error) {
+	n, size := binary.Uvarint(d.data)
+	if size <= 0 {
+		return 0, fmt.Errorf("%w: malformed uvarint", ErrInvalidData)
+	}
+	d.data = d.data[size:]
+	return n, nil
+}
+
+// Finish returns an error when there are bytes left
+func (d *Decoder) Finish() error {
+	if len(d.data) > 0 {
+		return fmt.Errorf("%w: %d trailing bytes", ErrInvalidData, len(d.data))
+	}
+	return nil
+}
+
+// Decode reads a length-prefixed element
+func Decode[E any](d *Decoder) (E, error) {
+	var value E
+	n, size := binary.Uvarint(d.data)
+	if size <= 0 || n > uint64(len(d.data)-size) {
+		return value, fmt.Errorf("%w: malformed element", ErrInvalidData)
+	}
+	data := d.data[size : size+int(n)]
+	d.data = d.data[size+int(n):]
+	if err := unmarshal(reflect.ValueOf(&value).Elem(), data); err != nil {
+		return value, err
+	}
+	return value, nil
+}
+
+// EncodeValues encodes the values as a sequence
+func EncodeValues[E any](values []E) ([]byte, error) {
+	e := new(Encoder)
+	e.Count(len(values))
+	for _, value := range values {
+		if err := Encode(e, value); err != nil {
+			return nil, err
+		}
+	}
+	return e.Bytes(), nil
+}
+
+// DecodeValues decodes a sequence of values
+func DecodeValues[E any](data []byte) ([]E, error) {
+	d := NewDecoder(data)
+	n, err := d.Count()
+	if err != nil {
+		return nil, err
+	}
+	values := make([]E, 0, n)
+	for i := 0; i < n; i++ {
+		value, err := Decode[E](d)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, d.Finish()
+}
+
+// EncodeEntries encodes the key value pairs as a sequence, each key is followed by its value
+func EncodeEntries[K, V any](keys []K, values []V) ([]byte, error) {
+	e := new(Encoder)
+	e.Count(len(keys))
+	for index, key := range keys {
+		if err := Encode(e, key); err != nil {
+			return nil, err
+		}
+		if err := Encode(e, values[index]); err != nil {
+			return nil, err
+		}
+	}
+	return e.Bytes(), nil
+}
+
+// DecodeEntries decodes a sequence of key value pairs
+func DecodeEntries[K, V any](data []byte) ([]K, []V, error) {
+	d := NewDecoder(data)
+	n, err := d.Count()
+	if err != nil {
+		return nil, nil, err
+	}
+	keys := make([]K, 0, n)
+	values := make([]V, 0, n)
+	for i := 0; i < n; i++ {
+		key, err := Decode[K](d)
+		if err != nil {
+			return nil, nil, err
+		}
+		value, err := Decode[V](d)
+		if err != nil {
+			return nil, nil, err
+		}
+		keys = append(keys, key)
+		values = append(values, value)
+	}
+	return keys, values, d.Finish()
+}
+
+func marshal(v reflect.Value) ([]byte, error) {
+	if v.Type().Implements(marshalerType) {
+		if v.Kind() == reflect.Pointer && v.IsNil() {
+			return nil, fmt.Errorf("codec: cannot encode nil %s", v.Type())
+		}
+		return v.Interface().(encoding.BinaryMarshaler).MarshalBinary()
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return []byte(v.String()), nil
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return bytes.Clone(v.Bytes()), nil
+		}
+	case reflect.Bool:
+		if v.Bool() {
+			return []byte{1}, nil
+		}
+		return []byte{0}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return binary.AppendVarint(nil, v.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return binary.AppendUvarint(nil, v.Uint()), nil
+	case reflect.Float32:
+		return binary.LittleEndian.AppendUint32(nil, math.Float32bits(float32(v.Float()))), nil
+	case reflect.Float64:
+		return binary.LittleEndian.AppendUint64(nil, math.Float64bits(v.Float())), nil
+	}
+	buf := new(bytes.Buffer)
+	if err := gob.NewEncoder(buf).EncodeValue(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func unmarshal(v reflect.Value, data []byte) error {
+	if v.Kind() == reflect.Pointer && v.Type().Implements(unmarshalerType) {
+		v.Set(reflect.New(v.Type().Elem()))
+		return v.Interface().(encoding.BinaryUnmarshaler).UnmarshalBinary(data)
+	}
+	if v.Addr().Type().Implements(unmarshalerType) {
+		return v.Addr().Interface().(encoding.BinaryUnmarshaler).UnmarshalBinary(data)
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(string(data))
+		return nil
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			v.SetBytes(bytes.Clone(data))
+			return nil
+		}
+	case reflect.Bool:
+		if len(data) != 1 || data[0] > 1 {
+			return fmt.Errorf("%w: malformed bool", ErrInvalidData)
+		}
+		v.SetBool(data[0] == 1)
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, size := binary.Varint(data)
+		if size != len(data) || size == 0 || v.OverflowInt(n) {
+			return fmt.Errorf("%w: malformed %s", ErrInvalidData, v.Type())
+		}
+		v.SetInt(n)
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, size := binary.Uvarint(data)
+		if size != len(data) || size == 0 || v.OverflowUint(n) {
+			return fmt.Errorf("%w: malformed %s", ErrInvalidData, v.Type())
+		}
+		v.SetUint(n)
+		return nil
+	case reflect.Float32:
+		if len(data) != 4 {
+			return fmt.Errorf("%w: malformed %s", ErrInvalidData, v.Type())
+		}
+		v.SetFloat(float64(math.Float32frombits(binary.LittleEndian.Uint32(data))))
+		return nil
+	case reflect.Float64:
+		if len(data) != 8 {
+			return fmt.Errorf("%w: malformed %s", ErrInvalidData, v.Type())
+		}
+		v.SetFloat(math.Float64frombits(binary.LittleEndian.Uint64(data)))
+		return nil
+	}
+	return gob.NewDecoder(bytes.NewReader(data)).DecodeValue(v)
+}
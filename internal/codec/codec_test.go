@@ -0,0 +1,107 @@
+package codec
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type _point struct {
+	X, Y int
+}
+
+type _id struct {
+	value string
+}
+
+func (i *_id) MarshalBinary() ([]byte, error) {
+	return []byte("id:" + i.value), nil
+}
+
+func (i *_id) UnmarshalBinary(data []byte) error {
+	i.value = string(data[3:])
+	return nil
+}
+
+func roundTrip[E any](t *testing.T, values []E) []E {
+	data, err := EncodeValues(values)
+	assert.Nil(t, err)
+	decoded, err := DecodeValues[E](data)
+	assert.Nil(t, err)
+	return decoded
+}
+
+func TestEncodeValues(t *testing.T) {
+	t.Run("integers", func(t *testing.T) {
+		assert.Equal(t, []int{-1, 0, 300}, roundTrip(t, []int{-1, 0, 300}))
+		assert.Equal(t, []uint8{0, 255}, roundTrip(t, []uint8{0, 255}))
+	})
+
+	t.Run("floats", func(t *testing.T) {
+		assert.Equal(t, []float64{-1.5, 0, 3.25}, roundTrip(t, []float64{-1.5, 0, 3.25}))
+		assert.Equal(t, []float32{1.5}, roundTrip(t, []float32{1.5}))
+	})
+
+	t.Run("strings and bytes", func(t *testing.T) {
+		assert.Equal(t, []string{"a", "", "bc"}, roundTrip(t, []string{"a", "", "bc"}))
+		assert.Equal(t, [][]byte{{1, 2}, {}}, roundTrip(t, [][]byte{{1, 2}, {}}))
+	})
+
+	t.Run("bools", func(t *testing.T) {
+		assert.Equal(t, []bool{true, false}, roundTrip(t, []bool{true, false}))
+	})
+
+	t.Run("binary marshaler", func(t *testing.T) {
+		now := time.Now().Round(0)
+		decoded := roundTrip(t, []time.Time{now})
+		assert.True(t, now.Equal(decoded[0]))
+		ids := roundTrip(t, []*_id{{"a"}, {"b"}})
+		assert.Equal(t, []*_id{{"a"}, {"b"}}, ids)
+	})
+
+	t.Run("gob fallback", func(t *testing.T) {
+		assert.Equal(t, []_point{{1, 2}, {3, 4}}, roundTrip(t, []_point{{1, 2}, {3, 4}}))
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		assert.Empty(t, roundTrip(t, []int{}))
+	})
+}
+
+func TestEncodeEntries(t *testing.T) {
+	data, err := EncodeEntries([]string{"a", "b"}, []int{1, 2})
+	assert.Nil(t, err)
+	keys, values, err := DecodeEntries[string, int](data)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a", "b"}, keys)
+	assert.Equal(t, []int{1, 2}, values)
+}
+
+func TestDecodeValues(t *testing.T) {
+	t.Run("truncated", func(t *testing.T) {
+		data, err := EncodeValues([]string{"abc"})
+		assert.Nil(t, err)
+		_, err = DecodeValues[string](data[:len(data)-1])
+		assert.ErrorIs(t, err, ErrInvalidData)
+	})
+
+	t.Run("trailing bytes", func(t *testing.T) {
+		data, err := EncodeValues([]int{1})
+		assert.Nil(t, err)
+		_, err = DecodeValues[int](append(data, 0))
+		assert.ErrorIs(t, err, ErrInvalidData)
+	})
+
+	t.Run("overflow", func(t *testing.T) {
+		data, err := EncodeValues([]int{300})
+		assert.Nil(t, err)
+		_, err = DecodeValues[int8](data)
+		assert.ErrorIs(t, err, ErrInvalidData)
+	})
+
+	t.Run("huge count", func(t *testing.T) {
+		_, err := DecodeValues[int]([]byte{0xff, 0xff, 0xff, 0xff, 0x0f})
+		assert.ErrorIs(t, err, ErrInvalidData)
+	})
+}
@@ -0,0 +1,15 @@
+// Package debug verifies the invariants of the collections after each mutation.
+//
+// The checks are compiled in only with the collectiondebug build tag, such as go test -tags collectiondebug ./...,
+// so they catch corruption caused by unsynchronized use early without costing anything in normal builds.
+package debug
+
+import "fmt"
+
+// Check panics with the diagnostics of the broken invariant when err is not nil,
+// the callers call it only when Enabled so the check is removed from normal builds
+func Check(collection any, err error) {
+	if err != nil {
+		panic(fmt.Errorf("%T is corrupted, it may be used without synchronization: %w", collection, err))
+	}
+}
@@ -0,0 +1,17 @@
+package debug
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheck(t *testing.T) {
+	assert.NotPanics(t, func() {
+		Check(new(int), nil)
+	})
+	assert.PanicsWithError(t, "*int is corrupted, it may be used without synchronization: broken", func() {
+		Check(new(int), errors.New("broken"))
+	})
+}
@@ -0,0 +1,6 @@
+//go:build collectiondebug
+
+package debug
+
+// Enabled reports whether the invariants are checked after each mutation
+const Enabled = true
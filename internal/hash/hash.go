@@ -0,0 +1,205 @@
+// Package hash hashes the elements of the collections.
+//
+// Value hashes consistently with ==, it is used for the elements of sets and the keys of maps.
+// Deep hashes consistently with reflect.DeepEqual, it is used for the elements of lists and the values of maps.
+// Both use the Hash method of the values implementing [collection.Hasher].
+// The hashes are seeded once per process, so they must not be persisted.
+package hash
+
+import (
+	"encoding/binary"
+	"hash/maphash"
+	"math"
+	"reflect"
+
+	"github.com/gopi-frame/collection"
+)
+
+var seed = maphash.MakeSeed()
+
+// Value returns the hash of the value consistent with ==
+func Value(value any) uint64 {
+	return newWriter(false, nil).sum(reflect.ValueOf(value))
+}
+
+// Deep returns the hash of the value consistent with [reflect.DeepEqual]
+func Deep(value any) uint64 {
+	return newWriter(true, nil).sum(reflect.ValueOf(value))
+}
+
+// Unordered combines the hashes regardless of their order
+func Unordered(hashes ...uint64) uint64 {
+	var total uint64
+	for _, h := range hashes {
+		total += h
+	}
+	return Ordered(uint64(len(hashes)), total)
+}
+
+// Ordered combines the hashes in order
+func Ordered(hashes ...uint64) uint64 {
+	var h maphash.Hash
+	h.SetSeed(seed)
+	for _, value := range hashes {
+		writeUint64(&h, value)
+	}
+	return h.Sum64()
+}
+
+// Equal returns whether the values are equal with the Equal method of [collection.Equaler] or [reflect.DeepEqual]
+func Equal[E any](a, b E) bool {
+	if equaler, ok := any(a).(collection.Equaler[E]); ok {
+		return equaler.Equal(b)
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+type visit struct {
+	ptr uintptr
+	typ reflect.Type
+}
+
+type writer struct {
+	hash    maphash.Hash
+	deep    bool
+	visited map[visit]struct{}
+}
+
+func newWriter(deep bool, visited map[visit]struct{}) *writer {
+	w := &writer{deep: deep, visited: visited}
+	w.hash.SetSeed(seed)
+	return w
+}
+
+func (w *writer) sum(value reflect.Value) uint64 {
+	w.write(value)
+	return w.hash.Sum64()
+}
+
+func (w *writer) write(value reflect.Value) {
+	if !value.IsValid() {
+		w.hash.WriteByte(0)
+		return
+	}
+	if value.CanInterface() {
+		if hasher, ok := value.Interface().(collection.Hasher); ok && !isNilPointer(value) {
+			writeUint64(&w.hash, hasher.Hash())
+			return
+		}
+	}
+	switch value.Kind() {
+	case reflect.Bool:
+		if value.Bool() {
+			w.hash.WriteByte(1)
+		} else {
+			w.hash.WriteByte(0)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		writeUint64(&w.hash, uint64(value.Int()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		writeUint64(&w.hash, value.Uint())
+	case reflect.Float32, reflect.Float64:
+		writeFloat(&w.hash, value.Float())
+	case reflect.Complex64, reflect.Complex128:
+		writeFloat(&w.hash, real(value.Complex()))
+		writeFloat(&w.hash, imag(value.Complex()))
+	case reflect.String:
+		writeUint64(&w.hash, uint64(value.Len()))
+		w.hash.WriteString(value.String())
+	case reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			w.write(value.Index(i))
+		}
+	case reflect.Struct:
+		for i := 0; i < value.NumField(); i++ {
+			w.write(value.Field(i))
+		}
+	case reflect.Interface:
+		w.write(value.Elem())
+	case reflect.Pointer:
+		if !w.deep || value.IsNil() {
+			writeUint64(&w.hash, uint64(value.Pointer()))
+			return
+		}
+		if w.enter(value) {
+			w.write(value.Elem())
+			w.leave(value)
+		}
+	case reflect.Slice:
+		writeUint64(&w.hash, uint64(value.Len()))
+		if value.Len() > 0 && w.enter(value) {
+			for i := 0; i < value.Len(); i++ {
+				w.write(value.Index(i))
+			}
+			w.leave(value)
+		}
+	case reflect.Map:
+		writeUint64(&w.hash, uint64(value.Len()))
+		if value.Len() > 0 && w.enter(value) {
+			// the entries are hashed on their own and summed, so the order of the entries does not matter
+			var total uint64
+			iter := value.MapRange()
+			for iter.Next() {
+				entry := newWriter(false, w.visited)
+				entry.write(iter.Key())
+				entry.deep = true
+				entry.write(iter.Value())
+				total += entry.hash.Sum64()
+			}
+			writeUint64(&w.hash, total)
+			w.leave(value)
+		}
+	case reflect.Func:
+		// functions are only deeply equal when both are nil
+		if value.IsNil() {
+			w.hash.WriteByte(0)
+		} else {
+			w.hash.WriteByte(1)
+		}
+	default:
+		// channels and unsafe pointers are equal when they point to the same object
+		writeUint64(&w.hash, uint64(value.Pointer()))
+	}
+}
+
+// enter marks the pointer as being visited, it returns false when the pointer is already being visited,
+// which means the value refers to itself
+func (w *writer) enter(value reflect.Value) bool {
+	if w.visited == nil {
+		w.visited = make(map[visit]struct{})
+	}
+	key := visit{ptr: value.Pointer(), typ: value.Type()}
+	if _, ok := w.visited[key]; ok {
+		w.hash.WriteByte(2)
+		return false
+	}
+	w.visited[key] = struct{}{}
+	return true
+}
+
+// leave unmarks the pointer, so the same pointer can be visited again outside of the value
+func (w *writer) leave(value reflect.Value) {
+	delete(w.visited, visit{ptr: value.Pointer(), typ: value.Type()})
+}
+
+func isNilPointer(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.Pointer, reflect.Interface, reflect.Map, reflect.Slice:
+		return value.IsNil()
+	}
+	return false
+}
+
+func writeUint64(h *maphash.Hash, value uint64) {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], value)
+	h.Write(buf[:])
+}
+
+func writeFloat(h *maphash.Hash, value float64) {
+	if value == 0 {
+		// +0 and -0 are equal
+		value = 0
+	}
+	writeUint64(h, math.Float64bits(value))
+}
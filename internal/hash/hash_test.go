@@ -0,0 +1,65 @@
+package hash
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type _node struct {
+	value int
+	next  *_node
+}
+
+type _hasher struct {
+	id   int
+	name string
+}
+
+func (h _hasher) Hash() uint64 {
+	return uint64(h.id)
+}
+
+func (h _hasher) Equal(other _hasher) bool {
+	return h.id == other.id
+}
+
+func TestValue(t *testing.T) {
+	assert.Equal(t, Value(1), Value(1))
+	assert.NotEqual(t, Value(1), Value(2))
+	assert.Equal(t, Value("a"), Value("a"))
+	assert.NotEqual(t, Value([2]string{"ab", ""}), Value([2]string{"a", "b"}))
+	assert.Equal(t, Value(0.0), Value(math.Copysign(0, -1)))
+	assert.Equal(t, Value(nil), Value(nil))
+
+	a, b := &_node{value: 1}, &_node{value: 1}
+	assert.Equal(t, Value(a), Value(a))
+	assert.NotEqual(t, Value(a), Value(b))
+	assert.Equal(t, Value(_hasher{id: 1, name: "a"}), Value(_hasher{id: 1, name: "b"}))
+}
+
+func TestDeep(t *testing.T) {
+	a, b := &_node{value: 1}, &_node{value: 1}
+	assert.Equal(t, Deep(a), Deep(b))
+	assert.Equal(t, Deep([]*_node{a, a}), Deep([]*_node{a, b}))
+	assert.NotEqual(t, Deep([]int{1, 2}), Deep([]int{2, 1}))
+	assert.Equal(t, Deep(map[string]int{"a": 1, "b": 2}), Deep(map[string]int{"b": 2, "a": 1}))
+	assert.NotEqual(t, Deep(map[string]int{"a": 1}), Deep(map[string]int{"a": 2}))
+
+	cycle := &_node{value: 1}
+	cycle.next = cycle
+	assert.Equal(t, Deep(cycle), Deep(cycle))
+}
+
+func TestUnordered(t *testing.T) {
+	assert.Equal(t, Unordered(1, 2, 3), Unordered(3, 1, 2))
+	assert.NotEqual(t, Unordered(1, 2), Unordered(1, 2, 0))
+	assert.NotEqual(t, Ordered(1, 2), Ordered(2, 1))
+}
+
+func TestEqual(t *testing.T) {
+	assert.True(t, Equal([]int{1}, []int{1}))
+	assert.False(t, Equal([]int{1}, []int{2}))
+	assert.True(t, Equal(_hasher{id: 1, name: "a"}, _hasher{id: 1, name: "b"}))
+}
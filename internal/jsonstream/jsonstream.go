@@ -0,0 +1,190 @@
+// Package jsonstream streams the elements of the collections as json one element at a time,
+// so neither the json document nor an intermediate slice of the elements is built in memory.
+//
+// The arrays and objects are written and read in the same format as encoding/json uses for slices and maps.
+package jsonstream
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+)
+
+// Encode writes the values as a json array
+func Encode[E any](w io.Writer, seq iter.Seq[E]) error {
+	buf := bufio.NewWriter(w)
+	if err := WriteArray(buf, seq); err != nil {
+		return err
+	}
+	return buf.Flush()
+}
+
+// EncodeEntries writes the key value pairs as a json object
+func EncodeEntries[K comparable, V any](w io.Writer, seq iter.Seq2[K, V]) error {
+	buf := bufio.NewWriter(w)
+	if err := WriteObject(buf, seq); err != nil {
+		return err
+	}
+	return buf.Flush()
+}
+
+// WriteArray writes the values as a json array, each value is marshaled on its own
+func WriteArray[E any](w *bufio.Writer, seq iter.Seq[E]) error {
+	w.WriteByte('[')
+	first := true
+	for value := range seq {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		if !first {
+			w.WriteByte(',')
+		}
+		first = false
+		if _, err := w.Write(data); err != nil {
+			return err
+		}
+	}
+	return w.WriteByte(']')
+}
+
+// WriteObject writes the key value pairs as a json object,
+// the keys are converted as encoding/json converts the keys of maps
+func WriteObject[K comparable, V any](w *bufio.Writer, seq iter.Seq2[K, V]) error {
+	w.WriteByte('{')
+	first := true
+	for key, value := range seq {
+		// a map of one entry is marshaled as {"key":value}
+		data, err := json.Marshal(map[K]V{key: value})
+		if err != nil {
+			return err
+		}
+		if !first {
+			w.WriteByte(',')
+		}
+		first = false
+		if _, err := w.Write(data[1 : len(data)-1]); err != nil {
+			return err
+		}
+	}
+	return w.WriteByte('}')
+}
+
+// Decode reads a json array token by token and calls push for each element, null is read as an empty array
+func Decode[E any](r io.Reader, push func(value E)) error {
+	return ReadArray(json.NewDecoder(r), push)
+}
+
+// DecodeFunc reads a json array as Decode does, it stops at the first error of push and returns it
+func DecodeFunc[E any](r io.Reader, push func(value E) error) error {
+	return ReadArrayFunc(json.NewDecoder(r), push)
+}
+
+// DecodeEntries reads a json object token by token and calls set for each key value pair,
+// null is read as an empty object
+func DecodeEntries[K comparable, V any](r io.Reader, set func(key K, value V)) error {
+	return ReadObject(json.NewDecoder(r), set)
+}
+
+// ReadArray reads the next json array from the decoder and calls push for each element
+func ReadArray[E any](dec *json.Decoder, push func(value E)) error {
+	return ReadArrayFunc(dec, func(value E) error {
+		push(value)
+		return nil
+	})
+}
+
+// ReadArrayFunc reads the next json array from the decoder and calls push for each element,
+// it stops at the first error of push and returns it
+func ReadArrayFunc[E any](dec *json.Decoder, push func(value E) error) error {
+	if ok, err := open(dec, '['); !ok {
+		return err
+	}
+	for dec.More() {
+		var value E
+		if err := dec.Decode(&value); err != nil {
+			return err
+		}
+		if err := push(value); err != nil {
+			return err
+		}
+	}
+	_, err := dec.Token()
+	return err
+}
+
+// ReadObject reads the next json object from the decoder and calls set for each key value pair
+func ReadObject[K comparable, V any](dec *json.Decoder, set func(key K, value V)) error {
+	if ok, err := open(dec, '{'); !ok {
+		return err
+	}
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key, err := unmarshalKey[K](token.(string))
+		if err != nil {
+			return err
+		}
+		var value V
+		if err := dec.Decode(&value); err != nil {
+			return err
+		}
+		set(key, value)
+	}
+	_, err := dec.Token()
+	return err
+}
+
+// open reads the opening delimiter, it returns false without error when the value is null
+func open(dec *json.Decoder, delim json.Delim) (bool, error) {
+	token, err := dec.Token()
+	if err != nil {
+		return false, err
+	}
+	if token == nil {
+		return false, nil
+	}
+	if token != delim {
+		return false, fmt.Errorf("jsonstream: expected %v, got %v", delim, token)
+	}
+	return true, nil
+}
+
+func unmarshalKey[K comparable](key string) (K, error) {
+	// the key is converted by unmarshalling an object with one entry
+	data, err := json.Marshal(key)
+	if err != nil {
+		return *new(K), err
+	}
+	keys := make(map[K]struct{}, 1)
+	if err := json.Unmarshal(append(append([]byte{'{'}, data...), ":null}"...), &keys); err != nil {
+		return *new(K), err
+	}
+	for k := range keys {
+		return k, nil
+	}
+	return *new(K), nil
+}
+
+// ReadFields reads the next json object from the decoder and calls field with the name of each field,
+// field must read the value of the field from the decoder
+func ReadFields(dec *json.Decoder, field func(dec *json.Decoder, name string) error) error {
+	if ok, err := open(dec, '{'); !ok {
+		return err
+	}
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		if err := field(dec, token.(string)); err != nil {
+			return err
+		}
+	}
+	_, err := dec.Token()
+	return err
+}
@@ -0,0 +1,107 @@
+package jsonstream
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type _key struct {
+	a, b string
+}
+
+func (k _key) MarshalText() ([]byte, error) {
+	return []byte(k.a + "-" + k.b), nil
+}
+
+func (k *_key) UnmarshalText(data []byte) error {
+	k.a, k.b, _ = strings.Cut(string(data), "-")
+	return nil
+}
+
+func TestEncode(t *testing.T) {
+	buf := new(bytes.Buffer)
+	assert.Nil(t, Encode(buf, slices.Values([]string{"a", "<b>"})))
+	expected, _ := json.Marshal([]string{"a", "<b>"})
+	assert.Equal(t, string(expected), buf.String())
+
+	buf.Reset()
+	assert.Nil(t, Encode(buf, slices.Values([]int{})))
+	assert.Equal(t, "[]", buf.String())
+
+	assert.Error(t, Encode(buf, slices.Values([]any{func() {}})))
+}
+
+func TestEncodeEntries(t *testing.T) {
+	buf := new(bytes.Buffer)
+	assert.Nil(t, EncodeEntries(buf, maps.All(map[int]string{1: "a"})))
+	assert.Equal(t, `{"1":"a"}`, buf.String())
+
+	buf.Reset()
+	assert.Nil(t, EncodeEntries(buf, maps.All(map[_key]int{{a: "x", b: "y"}: 1})))
+	assert.Equal(t, `{"x-y":1}`, buf.String())
+}
+
+func TestDecode(t *testing.T) {
+	var values []int
+	assert.Nil(t, Decode(strings.NewReader(`[1, 2, 3]`), func(value int) {
+		values = append(values, value)
+	}))
+	assert.Equal(t, []int{1, 2, 3}, values)
+
+	values = nil
+	assert.Nil(t, Decode(strings.NewReader(`null`), func(value int) {
+		values = append(values, value)
+	}))
+	assert.Nil(t, values)
+
+	assert.Error(t, Decode(strings.NewReader(`{}`), func(int) {}))
+	assert.Error(t, Decode(strings.NewReader(`[1, "a"]`), func(int) {}))
+	assert.Error(t, Decode(strings.NewReader(`[1, 2`), func(int) {}))
+}
+
+func TestDecodeFunc(t *testing.T) {
+	var values []int
+	stop := errors.New("stop")
+	err := DecodeFunc(strings.NewReader(`[1, 2, 3]`), func(value int) error {
+		if value == 2 {
+			return stop
+		}
+		values = append(values, value)
+		return nil
+	})
+	assert.ErrorIs(t, err, stop)
+	assert.Equal(t, []int{1}, values)
+	assert.Nil(t, DecodeFunc(strings.NewReader(`[]`), func(int) error { return stop }))
+}
+
+func TestDecodeEntries(t *testing.T) {
+	values := map[_key]int{}
+	assert.Nil(t, DecodeEntries(strings.NewReader(`{"x-y": 1, "z-w": 2}`), func(key _key, value int) {
+		values[key] = value
+	}))
+	assert.Equal(t, map[_key]int{{a: "x", b: "y"}: 1, {a: "z", b: "w"}: 2}, values)
+
+	ints := map[int]int{}
+	assert.Nil(t, DecodeEntries(strings.NewReader(`{"1": 1}`), func(key int, value int) {
+		ints[key] = value
+	}))
+	assert.Equal(t, map[int]int{1: 1}, ints)
+
+	assert.Error(t, DecodeEntries(strings.NewReader(`{"a": 1}`), func(int, int) {}))
+}
+
+func TestReadFields(t *testing.T) {
+	var names []string
+	assert.Nil(t, ReadFields(json.NewDecoder(strings.NewReader(`{"a": [1], "b": {"c": 1}}`)), func(dec *json.Decoder, name string) error {
+		names = append(names, name)
+		return dec.Decode(new(json.RawMessage))
+	}))
+	assert.Equal(t, []string{"a", "b"}, names)
+}
@@ -0,0 +1,153 @@
+// Package preview formats the collections for String and the fmt verbs.
+//
+// A preview is written as a header followed by one element per line:
+//
+//	List[int](len=7){
+//		1,
+//		2,
+//		...
+//	}
+//
+// At most the limit of elements are written, the rest are replaced by "...".
+//
+// The same limit applies to the structured log values of the collections,
+// which are groups of the type, the length, the elements and whether the elements are truncated.
+package preview
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+)
+
+// DefaultLimit is the default number of elements shown in the preview of a collection
+const DefaultLimit = 5
+
+// Limit is the number of elements shown in the preview of a collection, it is safe for concurrent use.
+// The zero value uses the default limit of the collection.
+type Limit struct {
+	// value is the limit plus one, zero means unset and a negative value means no limit
+	value atomic.Int64
+}
+
+// Set sets the limit, a negative limit shows all elements
+func (l *Limit) Set(limit int) {
+	if limit < 0 {
+		l.value.Store(-1)
+		return
+	}
+	l.value.Store(int64(limit) + 1)
+}
+
+// CopyFrom sets the limit to the one of other, an unset limit stays unset
+func (l *Limit) CopyFrom(other *Limit) {
+	l.value.Store(other.value.Load())
+}
+
+// Get returns the limit, or the given default when it is not set
+func (l *Limit) Get(def int) int {
+	value := l.value.Load()
+	if value == 0 {
+		return def
+	}
+	if value < 0 {
+		return -1
+	}
+	return int(value - 1)
+}
+
+// Elements formats the elements with the header
+func Elements[E any](header string, items []E, limit int) string {
+	return write(header, len(items), limit, func(str *strings.Builder, index int) {
+		str.WriteString(Value(items[index]))
+	})
+}
+
+// Entries formats the key value pairs with the header, each pair is written as "key: value"
+func Entries[K, V any](header string, keys []K, values []V, limit int) string {
+	return write(header, len(keys), limit, func(str *strings.Builder, index int) {
+		str.WriteString(Value(keys[index]))
+		str.WriteString(": ")
+		str.WriteString(Value(values[index]))
+	})
+}
+
+// Value formats a value with its String method when it has one
+func Value(value any) string {
+	if v, ok := value.(fmt.Stringer); ok {
+		return v.String()
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+// Format implements [fmt.Formatter] for a collection.
+// %v and %s write the preview with the limit, %+v and %#v write all elements, and %q writes the quoted preview.
+func Format(f fmt.State, verb rune, format func(limit int) string, limit int) {
+	switch verb {
+	case 'v':
+		if f.Flag('+') || f.Flag('#') {
+			limit = -1
+		}
+		_, _ = io.WriteString(f, format(limit))
+	case 's':
+		_, _ = io.WriteString(f, format(limit))
+	case 'q':
+		_, _ = fmt.Fprintf(f, "%q", format(limit))
+	default:
+		_, _ = fmt.Fprintf(f, "%%!%c(%s)", verb, format(limit))
+	}
+}
+
+// LogElements returns the structured log value of a collection with the elements
+func LogElements[E any](kind string, items []E, limit int) slog.Value {
+	shown, truncated := truncate(items, limit)
+	return slog.GroupValue(
+		slog.String("type", kind),
+		slog.Int("len", len(items)),
+		slog.Any("elements", shown),
+		slog.Bool("truncated", truncated),
+	)
+}
+
+// LogEntries returns the structured log value of a collection with the key value pairs,
+// each pair is logged as an attribute keyed by the formatted key
+func LogEntries[K, V any](kind string, size int, keys []K, values []V, limit int) slog.Value {
+	shown, truncated := truncate(keys, limit)
+	entries := make([]slog.Attr, len(shown))
+	for index, key := range shown {
+		entries[index] = slog.Any(Value(key), values[index])
+	}
+	return slog.GroupValue(
+		slog.String("type", kind),
+		slog.Int("len", size),
+		slog.Attr{Key: "entries", Value: slog.GroupValue(entries...)},
+		slog.Bool("truncated", truncated),
+	)
+}
+
+func truncate[E any](items []E, limit int) ([]E, bool) {
+	if limit >= 0 && len(items) > limit {
+		return items[:limit], true
+	}
+	return items, false
+}
+
+func write(header string, n int, limit int, item func(str *strings.Builder, index int)) string {
+	str := new(strings.Builder)
+	str.WriteString(header)
+	str.WriteByte('{')
+	str.WriteByte('\n')
+	for index := 0; index < n && (limit < 0 || index < limit); index++ {
+		str.WriteByte('\t')
+		item(str, index)
+		str.WriteByte(',')
+		str.WriteByte('\n')
+	}
+	if limit >= 0 && n > limit {
+		str.WriteString("\t...\n")
+	}
+	str.WriteByte('}')
+	return str.String()
+}
@@ -0,0 +1,91 @@
+package preview
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type _collection struct {
+	items []int
+	limit Limit
+}
+
+func (c *_collection) Format(f fmt.State, verb rune) {
+	Format(f, verb, c.format, c.limit.Get(DefaultLimit))
+}
+
+func (c *_collection) format(limit int) string {
+	return Elements(fmt.Sprintf("Collection(len=%d)", len(c.items)), c.items, limit)
+}
+
+func TestLimit(t *testing.T) {
+	var limit Limit
+	assert.Equal(t, 5, limit.Get(5))
+	limit.Set(0)
+	assert.Equal(t, 0, limit.Get(5))
+	limit.Set(3)
+	assert.Equal(t, 3, limit.Get(5))
+	limit.Set(-10)
+	assert.Equal(t, -1, limit.Get(5))
+
+	var copied Limit
+	copied.CopyFrom(&limit)
+	assert.Equal(t, -1, copied.Get(5))
+	copied.CopyFrom(new(Limit))
+	assert.Equal(t, 5, copied.Get(5))
+}
+
+func TestElements(t *testing.T) {
+	assert.Equal(t, "C{\n\t1,\n\t2,\n}", Elements("C", []int{1, 2}, 5))
+	assert.Equal(t, "C{\n\t1,\n\t...\n}", Elements("C", []int{1, 2}, 1))
+	assert.Equal(t, "C{\n\t...\n}", Elements("C", []int{1, 2}, 0))
+	assert.Equal(t, "C{\n\t1,\n\t2,\n}", Elements("C", []int{1, 2}, -1))
+	assert.Equal(t, "C{\n}", Elements("C", []int{}, 5))
+}
+
+func TestEntries(t *testing.T) {
+	assert.Equal(t, "M{\n\ta: 1,\n\t...\n}", Entries("M", []string{"a", "b"}, []int{1, 2}, 1))
+}
+
+func TestFormat(t *testing.T) {
+	c := &_collection{items: []int{1, 2, 3, 4, 5, 6}}
+	assert.Equal(t, "Collection(len=6){\n\t1,\n\t2,\n\t3,\n\t4,\n\t5,\n\t...\n}", fmt.Sprintf("%v", c))
+	assert.Equal(t, "Collection(len=6){\n\t1,\n\t2,\n\t3,\n\t4,\n\t5,\n\t6,\n}", fmt.Sprintf("%+v", c))
+	assert.Equal(t, fmt.Sprintf("%+v", c), fmt.Sprintf("%#v", c))
+	c.limit.Set(2)
+	assert.Equal(t, "Collection(len=6){\n\t1,\n\t2,\n\t...\n}", fmt.Sprintf("%s", c))
+	assert.Equal(t, fmt.Sprintf("%q", "Collection(len=6){\n\t1,\n\t2,\n\t...\n}"), fmt.Sprintf("%q", c))
+	assert.Equal(t, "%!d(Collection(len=6){\n\t1,\n\t2,\n\t...\n})", fmt.Sprintf("%d", c))
+}
+
+func TestLogElements(t *testing.T) {
+	value := LogElements("C", []int{1, 2, 3}, 2)
+	assert.Equal(t, slog.KindGroup, value.Kind())
+	attrs := value.Group()
+	assert.Equal(t, "C", attrs[0].Value.String())
+	assert.Equal(t, int64(3), attrs[1].Value.Int64())
+	assert.Equal(t, []int{1, 2}, attrs[2].Value.Any())
+	assert.True(t, attrs[3].Value.Bool())
+
+	value = LogElements("C", []int{1, 2, 3}, -1)
+	assert.Equal(t, []int{1, 2, 3}, value.Group()[2].Value.Any())
+	assert.False(t, value.Group()[3].Value.Bool())
+}
+
+func TestLogEntries(t *testing.T) {
+	buf := new(bytes.Buffer)
+	logger := slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) == 0 && a.Key != "m" {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+	logger.Info("", "m", LogEntries("M", 3, []string{"a", "b", "c"}, []int{1, 2, 3}, 2))
+	assert.JSONEq(t, `{"m":{"type":"M","len":3,"entries":{"a":1,"b":2},"truncated":true}}`, buf.String())
+}
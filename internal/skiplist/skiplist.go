@@ -0,0 +1,245 @@
+// Package skiplist implements the lazy concurrent skip list shared by the sorted collections,
+// such as set.SkipListSet and kv.SkipListMap.
+package skiplist
+
+import (
+	"iter"
+	"math/bits"
+	"math/rand/v2"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// maxLevel is the number of levels of the skip list, enough for 2^32 elements
+const maxLevel = 32
+
+// New new skip list ordered by the compare function
+func New[E any](compare func(a, b E) int) *List[E] {
+	list := &List[E]{
+		compare: compare,
+		head:    newNode(*new(E), maxLevel),
+	}
+	list.head.linked.Store(true)
+	return list
+}
+
+// List concurrent sorted list of distinct elements based on a lazy skip list, all methods are safe for concurrent use.
+// Add and Remove only lock the nodes next to the element, and Get and the iterations take no lock at all.
+// The iterations are weakly consistent: they see the elements in order, and an element added or removed meanwhile
+// may or may not be seen. Count is exact once the changes are done.
+type List[E any] struct {
+	compare func(a, b E) int
+	head    *node[E]
+	count   atomic.Int64
+}
+
+type node[E any] struct {
+	value  E
+	next   []atomic.Pointer[node[E]]
+	lock   sync.Mutex
+	marked atomic.Bool
+	linked atomic.Bool
+}
+
+func newNode[E any](value E, level int) *node[E] {
+	return &node[E]{value: value, next: make([]atomic.Pointer[node[E]], level)}
+}
+
+// randomLevel returns a level in [1, maxLevel], each level being half as likely as the one below
+func randomLevel() int {
+	return min(bits.TrailingZeros64(rand.Uint64())+1, maxLevel)
+}
+
+// find fills the predecessors and the successors of the value at each level,
+// and returns the highest level where the successor holds the value or -1 when it is not found
+func (l *List[E]) find(value E, preds, succs *[maxLevel]*node[E]) int {
+	found := -1
+	pred := l.head
+	for level := maxLevel - 1; level >= 0; level-- {
+		curr := pred.next[level].Load()
+		for curr != nil && l.compare(curr.value, value) < 0 {
+			pred = curr
+			curr = pred.next[level].Load()
+		}
+		if found == -1 && curr != nil && l.compare(curr.value, value) == 0 {
+			found = level
+		}
+		preds[level] = pred
+		succs[level] = curr
+	}
+	return found
+}
+
+// lockPreds locks the distinct predecessors of the levels below top, from the bottom level up, so in decreasing order,
+// and stops at the first level where valid fails. It returns whether all levels are valid and the highest level locked.
+func lockPreds[E any](preds *[maxLevel]*node[E], top int, valid func(level int) bool) (bool, int) {
+	highest := -1
+	var prev *node[E]
+	for level := 0; level < top; level++ {
+		if pred := preds[level]; pred != prev {
+			pred.lock.Lock()
+			highest = level
+			prev = pred
+		}
+		if !valid(level) {
+			return false, highest
+		}
+	}
+	return true, highest
+}
+
+func unlockPreds[E any](preds *[maxLevel]*node[E], highest int) {
+	var prev *node[E]
+	for level := 0; level <= highest; level++ {
+		if pred := preds[level]; pred != prev {
+			pred.lock.Unlock()
+			prev = pred
+		}
+	}
+}
+
+// Add adds the value, it returns the element held by the list and whether it is the value just added,
+// the element is the equal one already in the list when it is not added
+func (l *List[E]) Add(value E) (E, bool) {
+	top := randomLevel()
+	var preds, succs [maxLevel]*node[E]
+	for {
+		if found := l.find(value, &preds, &succs); found != -1 {
+			n := succs[found]
+			if !n.marked.Load() {
+				for !n.linked.Load() {
+					runtime.Gosched()
+				}
+				return n.value, false
+			}
+			continue
+		}
+		valid, highest := lockPreds(&preds, top, func(level int) bool {
+			pred, succ := preds[level], succs[level]
+			return !pred.marked.Load() && (succ == nil || !succ.marked.Load()) && pred.next[level].Load() == succ
+		})
+		if !valid {
+			unlockPreds(&preds, highest)
+			continue
+		}
+		n := newNode(value, top)
+		for level := 0; level < top; level++ {
+			n.next[level].Store(succs[level])
+		}
+		for level := 0; level < top; level++ {
+			preds[level].next[level].Store(n)
+		}
+		n.linked.Store(true)
+		unlockPreds(&preds, highest)
+		l.count.Add(1)
+		return value, true
+	}
+}
+
+// Remove removes the value, it returns false when the list does not contain it
+func (l *List[E]) Remove(value E) bool {
+	var preds, succs [maxLevel]*node[E]
+	var victim *node[E]
+	for {
+		found := l.find(value, &preds, &succs)
+		if victim == nil {
+			if found == -1 {
+				return false
+			}
+			n := succs[found]
+			if !n.linked.Load() || len(n.next)-1 != found || n.marked.Load() {
+				return false
+			}
+			n.lock.Lock()
+			if n.marked.Load() {
+				n.lock.Unlock()
+				return false
+			}
+			n.marked.Store(true)
+			victim = n
+		}
+		valid, highest := lockPreds(&preds, len(victim.next), func(level int) bool {
+			pred := preds[level]
+			return !pred.marked.Load() && pred.next[level].Load() == victim
+		})
+		if !valid {
+			unlockPreds(&preds, highest)
+			continue
+		}
+		for level := len(victim.next) - 1; level >= 0; level-- {
+			preds[level].next[level].Store(victim.next[level].Load())
+		}
+		victim.lock.Unlock()
+		unlockPreds(&preds, highest)
+		l.count.Add(-1)
+		return true
+	}
+}
+
+// Get returns the element equal to the value, it takes no lock
+func (l *List[E]) Get(value E) (E, bool) {
+	pred := l.head
+	for level := maxLevel - 1; level >= 0; level-- {
+		curr := pred.next[level].Load()
+		for curr != nil && l.compare(curr.value, value) < 0 {
+			pred = curr
+			curr = pred.next[level].Load()
+		}
+		if curr != nil && l.compare(curr.value, value) == 0 {
+			if curr.linked.Load() && !curr.marked.Load() {
+				return curr.value, true
+			}
+			return *new(E), false
+		}
+	}
+	return *new(E), false
+}
+
+// Count returns the number of elements
+func (l *List[E]) Count() int64 {
+	return l.count.Load()
+}
+
+// First returns the smallest element, it returns zero value and false when the list is empty
+func (l *List[E]) First() (E, bool) {
+	for n := range l.nodes(l.head.next[0].Load()) {
+		return n.value, true
+	}
+	return *new(E), false
+}
+
+// nodes returns an iterator over the live nodes from the node on
+func (l *List[E]) nodes(start *node[E]) iter.Seq[*node[E]] {
+	return func(yield func(*node[E]) bool) {
+		for n := start; n != nil; n = n.next[0].Load() {
+			if n.linked.Load() && !n.marked.Load() && !yield(n) {
+				return
+			}
+		}
+	}
+}
+
+// All returns an iterator over the elements in order, it is weakly consistent
+func (l *List[E]) All() iter.Seq[E] {
+	return func(yield func(E) bool) {
+		for n := range l.nodes(l.head.next[0].Load()) {
+			if !yield(n.value) {
+				return
+			}
+		}
+	}
+}
+
+// Range returns an iterator over the elements in the range [from, to) in order, it is weakly consistent
+func (l *List[E]) Range(from, to E) iter.Seq[E] {
+	return func(yield func(E) bool) {
+		var preds, succs [maxLevel]*node[E]
+		l.find(from, &preds, &succs)
+		for n := range l.nodes(succs[0]) {
+			if l.compare(n.value, to) >= 0 || !yield(n.value) {
+				return
+			}
+		}
+	}
+}
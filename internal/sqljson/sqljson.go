@@ -0,0 +1,29 @@
+// Package sqljson stores the collections in database columns as json arrays,
+// the collections implement [driver.Valuer] and [database/sql.Scanner] with it.
+package sqljson
+
+import (
+	"database/sql/driver"
+	"fmt"
+)
+
+// Value returns the json of a collection as a driver value, it is stored as text
+func Value(data []byte, err error) (driver.Value, error) {
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Bytes returns the json of a scanned column, NULL is returned as the json null
+func Bytes(src any) ([]byte, error) {
+	switch src := src.(type) {
+	case nil:
+		return []byte("null"), nil
+	case []byte:
+		return src, nil
+	case string:
+		return []byte(src), nil
+	}
+	return nil, fmt.Errorf("sqljson: cannot scan %T into a collection", src)
+}
@@ -0,0 +1,30 @@
+package sqljson
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValue(t *testing.T) {
+	value, err := Value([]byte(`[1,2]`), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, `[1,2]`, value)
+	_, err = Value(nil, errors.New("marshal"))
+	assert.EqualError(t, err, "marshal")
+}
+
+func TestBytes(t *testing.T) {
+	data, err := Bytes(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "null", string(data))
+	data, err = Bytes([]byte(`[1]`))
+	assert.Nil(t, err)
+	assert.Equal(t, "[1]", string(data))
+	data, err = Bytes(`[2]`)
+	assert.Nil(t, err)
+	assert.Equal(t, "[2]", string(data))
+	_, err = Bytes(1)
+	assert.EqualError(t, err, "sqljson: cannot scan int into a collection")
+}
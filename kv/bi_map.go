@@ -0,0 +1,277 @@
+package kv
+
+import (
+	"encoding/json"
+	"fmt"
+	"iter"
+	"log/slog"
+	"maps"
+	"sync"
+
+	"github.com/gopi-frame/collection/internal/preview"
+)
+
+// ConflictPolicy decides what a bi map does when a value is set to a key while another key maps to it
+type ConflictPolicy int
+
+const (
+	// ReplaceConflicts removes the other key, so the value moves to the new key
+	ReplaceConflicts ConflictPolicy = iota
+	// RejectConflicts keeps the other key, the value is not set
+	RejectConflicts
+)
+
+// NewBiMap new bi map, a value which is set to a new key moves from its old key, see ReplaceConflicts
+func NewBiMap[K, V comparable]() *BiMap[K, V] {
+	return NewBiMapWithPolicy[K, V](ReplaceConflicts)
+}
+
+// NewBiMapWithPolicy new bi map with the policy to handle a value which is already mapped from another key
+func NewBiMapWithPolicy[K, V comparable](policy ConflictPolicy) *BiMap[K, V] {
+	m := &BiMap[K, V]{lock: new(sync.RWMutex), forward: make(map[K]V), backward: make(map[V]K), policy: policy}
+	m.inverse = &BiMap[V, K]{lock: m.lock, forward: m.backward, backward: m.forward, policy: policy, inverse: m}
+	return m
+}
+
+// BiMap bidirectional map, each key maps to a unique value and each value maps back to its key,
+// so both lookups take O(1) time. All methods are safe for concurrent use.
+// Inverse returns the view from the values to the keys, the map and its inverse share the entries and the lock.
+type BiMap[K, V comparable] struct {
+	lock     *sync.RWMutex
+	forward  map[K]V
+	backward map[V]K
+	policy   ConflictPolicy
+	inverse  *BiMap[V, K]
+	limit    preview.Limit
+}
+
+// Inverse returns the view of the map from the values to the keys, the changes of either are seen by the other
+func (m *BiMap[K, V]) Inverse() *BiMap[V, K] {
+	return m.inverse
+}
+
+// Count returns the size of map
+func (m *BiMap[K, V]) Count() int64 {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return int64(len(m.forward))
+}
+
+// IsEmpty returns whether the map is empty
+func (m *BiMap[K, V]) IsEmpty() bool {
+	return m.Count() == 0
+}
+
+// IsNotEmpty returns whether the map is not empty
+func (m *BiMap[K, V]) IsNotEmpty() bool {
+	return !m.IsEmpty()
+}
+
+// Get returns the value of the specific key.
+// A zero value and false will be returned when the given key is not exist
+func (m *BiMap[K, V]) Get(key K) (V, bool) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	value, ok := m.forward[key]
+	return value, ok
+}
+
+// GetOr returns the value of the specific key or the default value if the key is not exist
+func (m *BiMap[K, V]) GetOr(key K, value V) V {
+	if v, ok := m.Get(key); ok {
+		return v
+	}
+	return value
+}
+
+// GetByValue returns the key of the specific value.
+// A zero value and false will be returned when the given value is not exist
+func (m *BiMap[K, V]) GetByValue(value V) (K, bool) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	key, ok := m.backward[value]
+	return key, ok
+}
+
+// Set sets the value to the specific key, the old value of the key is removed.
+// When another key maps to the value, the conflict policy decides whether that key is removed,
+// and Set returns false when the value is rejected.
+func (m *BiMap[K, V]) Set(key K, value V) bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.set(key, value)
+}
+
+func (m *BiMap[K, V]) set(key K, value V) bool {
+	if other, ok := m.backward[value]; ok {
+		if other == key {
+			return true
+		}
+		if m.policy == RejectConflicts {
+			return false
+		}
+		delete(m.forward, other)
+	}
+	if old, ok := m.forward[key]; ok {
+		delete(m.backward, old)
+	}
+	m.forward[key] = value
+	m.backward[value] = key
+	return true
+}
+
+// Remove removes the specific key and its value
+func (m *BiMap[K, V]) Remove(key K) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if value, ok := m.forward[key]; ok {
+		delete(m.forward, key)
+		delete(m.backward, value)
+	}
+}
+
+// RemoveValue removes the specific value and its key
+func (m *BiMap[K, V]) RemoveValue(value V) {
+	m.inverse.Remove(value)
+}
+
+// ContainsKey returns whether the map contains the specific key
+func (m *BiMap[K, V]) ContainsKey(key K) bool {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	_, ok := m.forward[key]
+	return ok
+}
+
+// ContainsValue returns whether the map contains the specific value
+func (m *BiMap[K, V]) ContainsValue(value V) bool {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	_, ok := m.backward[value]
+	return ok
+}
+
+// Clear clears the map and its inverse
+func (m *BiMap[K, V]) Clear() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	clear(m.forward)
+	clear(m.backward)
+}
+
+// Keys returns all keys
+func (m *BiMap[K, V]) Keys() []K {
+	keys, _ := m.snapshot()
+	return keys
+}
+
+// Values returns all values in the order of Keys
+func (m *BiMap[K, V]) Values() []V {
+	_, values := m.snapshot()
+	return values
+}
+
+func (m *BiMap[K, V]) snapshot() ([]K, []V) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	keys := make([]K, 0, len(m.forward))
+	values := make([]V, 0, len(m.forward))
+	for key, value := range m.forward {
+		keys = append(keys, key)
+		values = append(values, value)
+	}
+	return keys, values
+}
+
+// Each ranges the map by callback, it will break the loop when the callback returns false.
+// The callback runs on a snapshot of the map, so it is allowed to modify the map.
+func (m *BiMap[K, V]) Each(callback func(key K, value V) bool) {
+	for key, value := range m.ToMap() {
+		if !callback(key, value) {
+			break
+		}
+	}
+}
+
+// All returns an iterator over the key value pairs
+func (m *BiMap[K, V]) All() iter.Seq2[K, V] {
+	return maps.All(m.ToMap())
+}
+
+// ToMap converts to map, the returned map is a copy, so modifying it does not affect the map
+func (m *BiMap[K, V]) ToMap() map[K]V {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return maps.Clone(m.forward)
+}
+
+// ToJSON converts the map to json bytes
+func (m *BiMap[K, V]) ToJSON() ([]byte, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return json.Marshal(m.forward)
+}
+
+// MarshalJSON implements [json.Marshaller]
+func (m *BiMap[K, V]) MarshalJSON() ([]byte, error) {
+	return m.ToJSON()
+}
+
+// UnmarshalJSON implements [json.Unmarshaller], the entries replace the entries of the map.
+// It returns an error and leaves the map unchanged when two keys map to the same value.
+func (m *BiMap[K, V]) UnmarshalJSON(data []byte) error {
+	items := map[K]V{}
+	if err := json.Unmarshal(data, &items); err != nil {
+		return err
+	}
+	backward := make(map[V]K, len(items))
+	for key, value := range items {
+		if other, ok := backward[value]; ok {
+			return fmt.Errorf("kv: keys %v and %v map to the same value %v", other, key, value)
+		}
+		backward[value] = key
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	clear(m.forward)
+	clear(m.backward)
+	maps.Copy(m.forward, items)
+	maps.Copy(m.backward, backward)
+	return nil
+}
+
+// SetPreviewLimit sets the number of entries shown by String, %v and LogValue, a negative limit shows all entries
+func (m *BiMap[K, V]) SetPreviewLimit(limit int) {
+	m.limit.Set(limit)
+}
+
+// String converts to string, only the preview limit of entries are shown
+func (m *BiMap[K, V]) String() string {
+	return m.format(m.limit.Get(-1))
+}
+
+// Format implements [fmt.Formatter], %v prints the preview as String does, %+v and %#v print all entries
+func (m *BiMap[K, V]) Format(f fmt.State, verb rune) {
+	preview.Format(f, verb, m.format, m.limit.Get(-1))
+}
+
+func (m *BiMap[K, V]) format(limit int) string {
+	keys, values := m.snapshot()
+	return preview.Entries(fmt.Sprintf("BiMap[%T, %T](len=%d)", *new(K), *new(V), len(keys)), keys, values, limit)
+}
+
+// LogValue implements [slog.LogValuer], only the preview limit of entries are logged
+func (m *BiMap[K, V]) LogValue() slog.Value {
+	keys, values := m.snapshot()
+	return preview.LogEntries(fmt.Sprintf("BiMap[%T, %T]", *new(K), *new(V)), len(keys), keys, values, m.limit.Get(preview.DefaultLimit))
+}
+
+// Clone clones the map with its policy, the clone has its own inverse
+func (m *BiMap[K, V]) Clone() *BiMap[K, V] {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	mm := NewBiMapWithPolicy[K, V](m.policy)
+	maps.Copy(mm.forward, m.forward)
+	maps.Copy(mm.backward, m.backward)
+	return mm
+}
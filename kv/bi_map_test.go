@@ -0,0 +1,122 @@
+package kv
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func _newBiMap() *BiMap[int, string] {
+	m := NewBiMap[int, string]()
+	m.Set(1, "a")
+	m.Set(2, "b")
+	m.Set(3, "c")
+	return m
+}
+
+func TestBiMap_Get(t *testing.T) {
+	m := _newBiMap()
+	value, ok := m.Get(1)
+	assert.True(t, ok)
+	assert.Equal(t, "a", value)
+	assert.Equal(t, "d", m.GetOr(4, "d"))
+	key, ok := m.GetByValue("b")
+	assert.True(t, ok)
+	assert.Equal(t, 2, key)
+	_, ok = m.GetByValue("d")
+	assert.False(t, ok)
+}
+
+func TestBiMap_Set(t *testing.T) {
+	m := _newBiMap()
+	assert.True(t, m.Set(1, "d"))
+	assert.False(t, m.ContainsValue("a"))
+	assert.True(t, m.Set(2, "d"))
+	assert.False(t, m.ContainsKey(1))
+	assert.True(t, m.Set(2, "d"))
+	assert.Equal(t, map[int]string{2: "d", 3: "c"}, m.ToMap())
+	assert.Equal(t, map[string]int{"d": 2, "c": 3}, m.Inverse().ToMap())
+}
+
+func TestBiMap_Set_Reject(t *testing.T) {
+	m := NewBiMapWithPolicy[int, string](RejectConflicts)
+	assert.True(t, m.Set(1, "a"))
+	assert.False(t, m.Set(2, "a"))
+	assert.True(t, m.Set(1, "b"))
+	assert.True(t, m.Set(2, "a"))
+	assert.Equal(t, map[int]string{1: "b", 2: "a"}, m.ToMap())
+	assert.False(t, m.Inverse().Set("b", 2))
+}
+
+func TestBiMap_Remove(t *testing.T) {
+	m := _newBiMap()
+	m.Remove(1)
+	m.RemoveValue("b")
+	m.Remove(4)
+	assert.Equal(t, map[int]string{3: "c"}, m.ToMap())
+	assert.Equal(t, map[string]int{"c": 3}, m.Inverse().ToMap())
+}
+
+func TestBiMap_Inverse(t *testing.T) {
+	m := _newBiMap()
+	inverse := m.Inverse()
+	assert.Same(t, m, inverse.Inverse())
+	inverse.Set("d", 4)
+	value, ok := m.Get(4)
+	assert.True(t, ok)
+	assert.Equal(t, "d", value)
+	inverse.Clear()
+	assert.True(t, m.IsEmpty())
+}
+
+func TestBiMap_MarshalJSON(t *testing.T) {
+	jsonBytes, err := json.Marshal(_newBiMap())
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"1":"a","2":"b","3":"c"}`, string(jsonBytes))
+}
+
+func TestBiMap_UnmarshalJSON(t *testing.T) {
+	m := _newBiMap()
+	assert.Nil(t, json.Unmarshal([]byte(`{"4":"d","5":"e"}`), m))
+	assert.Equal(t, map[string]int{"d": 4, "e": 5}, m.Inverse().ToMap())
+	assert.Error(t, json.Unmarshal([]byte(`{"6":"f","7":"f"}`), m))
+	assert.Equal(t, map[int]string{4: "d", 5: "e"}, m.ToMap())
+}
+
+func TestBiMap_String(t *testing.T) {
+	m := NewBiMap[int, string]()
+	m.Set(1, "a")
+	assert.Equal(t, "BiMap[int, string](len=1){\n\t1: a,\n}", m.String())
+	assert.Equal(t, "BiMap[string, int](len=1){\n\ta: 1,\n}", m.Inverse().String())
+}
+
+func TestBiMap_Clone(t *testing.T) {
+	m := _newBiMap()
+	clone := m.Clone()
+	m.Set(4, "d")
+	assert.False(t, clone.ContainsKey(4))
+	clone.Inverse().Set("e", 5)
+	assert.False(t, m.ContainsKey(5))
+	assert.EqualValues(t, 4, clone.Count())
+}
+
+func TestBiMap_Concurrent(t *testing.T) {
+	m := NewBiMap[int, int]()
+	wg := new(sync.WaitGroup)
+	for i := 0; i < 100; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			m.Set(i, -i)
+		}(i)
+		go func(i int) {
+			defer wg.Done()
+			m.Inverse().Set(-i-100, i+100)
+		}(i)
+	}
+	wg.Wait()
+	assert.EqualValues(t, 200, m.Count())
+	assert.EqualValues(t, 200, m.Inverse().Count())
+}
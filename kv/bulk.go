@@ -0,0 +1,150 @@
+package kv
+
+// Merge sets the entries of the other map into the map under one lock, the other map is left unchanged.
+// A key in both maps gets the value onConflict returns for its value in the map and in the other map,
+// or the value of the other map when onConflict is nil. The other map is copied before the map is locked,
+// so merging maps into each other concurrently does not deadlock.
+func (m *Map[K, V]) Merge(other *Map[K, V], onConflict func(key K, a, b V) V) {
+	items := other.ToMap()
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for key, value := range items {
+		if old, ok := m.items[key]; ok && onConflict != nil {
+			value = onConflict(key, old, value)
+		}
+		m.items[key] = value
+	}
+}
+
+// SetAll sets the entries of the given map under one lock, the given map is copied
+func (m *Map[K, V]) SetAll(items map[K]V) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for key, value := range items {
+		m.items[key] = value
+	}
+}
+
+// RemoveAll removes the keys under one lock, the missing keys are skipped
+func (m *Map[K, V]) RemoveAll(keys ...K) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for _, key := range keys {
+		m.delete(key)
+	}
+}
+
+// Filter returns a new map of the entries which match the callback, the map is left unchanged
+func (m *Map[K, V]) Filter(callback func(key K, value V) bool) *Map[K, V] {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	filtered := NewMap[K, V]()
+	for key, value := range m.items {
+		if callback(key, value) {
+			filtered.items[key] = value
+		}
+	}
+	return filtered
+}
+
+// Merge sets the entries of the other map into the map under one lock, the other map is left unchanged.
+// The keys only in the other map are added at the end in its order, and the keys in both keep their positions
+// with the value onConflict returns for their values in the map and in the other map,
+// or the value of the other map when onConflict is nil.
+func (m *LinkedMap[K, V]) Merge(other *LinkedMap[K, V], onConflict func(key K, a, b V) V) {
+	keys, values := other.snapshot()
+	m.lock.Lock()
+	defer m.unlock()
+	for index, key := range keys {
+		value := values[index]
+		if old, ok := m.items[key]; !ok {
+			m.keys.Push(key)
+		} else if onConflict != nil {
+			value = onConflict(key, old, value)
+		}
+		m.items[key] = value
+	}
+}
+
+// SetAll sets the entries of the given map under one lock, the given map is copied.
+// The existing keys keep their positions and the new keys are added at the end in the iteration order of the map,
+// which is random, use Merge with a [LinkedMap] to add them in order.
+func (m *LinkedMap[K, V]) SetAll(items map[K]V) {
+	m.lock.Lock()
+	defer m.unlock()
+	for key, value := range items {
+		if _, ok := m.items[key]; !ok {
+			m.keys.Push(key)
+		}
+		m.items[key] = value
+	}
+}
+
+// RemoveAll removes the keys under one lock, the missing keys are skipped.
+// The key order is rebuilt once, so it takes O(n) time however many keys are removed.
+func (m *LinkedMap[K, V]) RemoveAll(keys ...K) {
+	m.lock.Lock()
+	defer m.unlock()
+	removed := make(map[K]struct{}, len(keys))
+	for _, key := range keys {
+		if _, ok := m.items[key]; ok {
+			m.delete(key)
+			removed[key] = struct{}{}
+		}
+	}
+	if len(removed) == 0 {
+		return
+	}
+	m.keys.RemoveWhere(func(key K) bool {
+		_, ok := removed[key]
+		return ok
+	})
+}
+
+// Filter returns a new map of the entries which match the callback in order, the map is left unchanged
+func (m *LinkedMap[K, V]) Filter(callback func(key K, value V) bool) *LinkedMap[K, V] {
+	keys, values := m.snapshot()
+	filtered := NewLinkedMap[K, V]()
+	for index, key := range keys {
+		if callback(key, values[index]) {
+			filtered.keys.Push(key)
+			filtered.items[key] = values[index]
+		}
+	}
+	return filtered
+}
+
+// Merge sets the entries of the other map into the map under one lock, each of them is recorded as a set,
+// see [Map.Merge]
+func (m *JournalMap[K, V]) Merge(other *Map[K, V], onConflict func(key K, a, b V) V) {
+	items := other.ToMap()
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for key, value := range items {
+		if old, ok := m.items[key]; ok && onConflict != nil {
+			value = onConflict(key, old, value)
+		}
+		m.set(key, value)
+	}
+}
+
+// SetAll sets the entries of the given map under one lock, each of them is recorded as a set
+func (m *JournalMap[K, V]) SetAll(items map[K]V) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for key, value := range items {
+		m.set(key, value)
+	}
+}
+
+// RemoveAll removes the keys under one lock, each existing key is recorded as a remove
+func (m *JournalMap[K, V]) RemoveAll(keys ...K) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for _, key := range keys {
+		if _, ok := m.items[key]; ok {
+			m.delete(key)
+			m.journal(Op[K, V]{Kind: OpRemove, Key: key})
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package kv
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMap_Merge(t *testing.T) {
+	m := NewFromMap(map[string]int{"a": 1, "b": 2})
+	other := NewFromMap(map[string]int{"b": 3, "c": 4})
+	m.Merge(other, func(key string, a, b int) int {
+		assert.Equal(t, "b", key)
+		return a + b
+	})
+	assert.Equal(t, map[string]int{"a": 1, "b": 5, "c": 4}, m.ToMap())
+	assert.Equal(t, map[string]int{"b": 3, "c": 4}, other.ToMap())
+	m.Merge(other, nil)
+	assert.Equal(t, map[string]int{"a": 1, "b": 3, "c": 4}, m.ToMap())
+
+	t.Run("itself", func(t *testing.T) {
+		m.Merge(m, func(key string, a, b int) int {
+			return a * b
+		})
+		assert.Equal(t, map[string]int{"a": 1, "b": 9, "c": 16}, m.ToMap())
+	})
+}
+
+func TestMap_SetAll(t *testing.T) {
+	m := NewFromMap(map[string]int{"a": 1})
+	items := map[string]int{"a": 2, "b": 3}
+	m.SetAll(items)
+	items["c"] = 4
+	assert.Equal(t, map[string]int{"a": 2, "b": 3}, m.ToMap())
+}
+
+func TestMap_RemoveAll(t *testing.T) {
+	m := NewFromMap(map[string]int{"a": 1, "b": 2, "c": 3})
+	m.RemoveAll("a", "c", "missing")
+	assert.Equal(t, map[string]int{"b": 2}, m.ToMap())
+	m.RemoveAll()
+	assert.Equal(t, int64(1), m.Count())
+}
+
+func TestMap_Filter(t *testing.T) {
+	m := NewFromMap(map[string]int{"a": 1, "b": 2, "c": 3})
+	filtered := m.Filter(func(key string, value int) bool {
+		return value%2 == 1
+	})
+	assert.Equal(t, map[string]int{"a": 1, "c": 3}, filtered.ToMap())
+	assert.Equal(t, int64(3), m.Count())
+}
+
+func TestLinkedMap_Merge(t *testing.T) {
+	m := NewLinkedMap[string, int]()
+	m.Set("b", 1)
+	m.Set("a", 2)
+	other := NewLinkedMap[string, int]()
+	other.Set("d", 3)
+	other.Set("a", 4)
+	other.Set("c", 5)
+	m.Merge(other, func(key string, a, b int) int {
+		return max(a, b)
+	})
+	assert.Equal(t, []string{"b", "a", "d", "c"}, m.Keys())
+	assert.Equal(t, []int{1, 4, 3, 5}, m.Values())
+	assert.Equal(t, []string{"d", "a", "c"}, other.Keys())
+}
+
+func TestLinkedMap_SetAll(t *testing.T) {
+	m := NewLinkedMap[string, int]()
+	m.Set("a", 1)
+	m.SetAll(map[string]int{"a": 2, "b": 3})
+	assert.Equal(t, []string{"a", "b"}, m.Keys())
+	assert.Equal(t, []int{2, 3}, m.Values())
+}
+
+func TestLinkedMap_RemoveAll(t *testing.T) {
+	m := NewLinkedMap[string, int]()
+	for index, key := range []string{"a", "b", "c", "d"} {
+		m.Set(key, index)
+	}
+	m.RemoveAll("c", "a", "missing", "a")
+	assert.Equal(t, []string{"b", "d"}, m.Keys())
+	assert.Equal(t, []int{1, 3}, m.Values())
+	m.RemoveAll("missing")
+	assert.Equal(t, int64(2), m.Count())
+}
+
+func TestLinkedMap_Filter(t *testing.T) {
+	m := NewLinkedMap[string, int]()
+	for index, key := range []string{"d", "c", "b", "a"} {
+		m.Set(key, index)
+	}
+	filtered := m.Filter(func(key string, value int) bool {
+		return key != "c"
+	})
+	assert.Equal(t, []string{"d", "b", "a"}, filtered.Keys())
+	assert.Equal(t, []int{0, 2, 3}, filtered.Values())
+	assert.Equal(t, int64(4), m.Count())
+}
+
+func TestJournalMap_Merge(t *testing.T) {
+	var ops []Op[string, int]
+	m := NewJournalMap(func(op Op[string, int]) {
+		ops = append(ops, op)
+	})
+	m.Set("a", 1)
+	m.Merge(NewFromMap(map[string]int{"a": 2}), func(key string, a, b int) int {
+		return a + b
+	})
+	m.SetAll(map[string]int{"b": 4})
+	m.RemoveAll("a", "missing")
+	assert.Equal(t, []Op[string, int]{
+		{Kind: OpSet, Key: "a", Value: 1},
+		{Kind: OpSet, Key: "a", Value: 3},
+		{Kind: OpSet, Key: "b", Value: 4},
+		{Kind: OpRemove, Key: "a"},
+	}, ops)
+	replayed := NewMap[string, int]()
+	assert.NoError(t, replayed.Replay(ops...))
+	assert.Equal(t, m.ToMap(), replayed.ToMap())
+}
@@ -0,0 +1,41 @@
+package kv
+
+// compactMinPeak is the size a map must have reached before it is compacted automatically,
+// smaller maps hold too little memory to be worth rebuilding
+const compactMinPeak = 64
+
+// Compact rebuilds the map into a new one sized for its current entries.
+// Go maps never give back the memory of removed entries, so a long-lived map which has shrunk a lot
+// keeps the memory of its largest size until it is compacted.
+func (m *Map[K, V]) Compact() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.compact()
+}
+
+// SetCompactThreshold compacts the map automatically when a removal leaves fewer entries than the ratio
+// of the largest size since the last compaction, for example 0.25 compacts once three quarters are removed.
+// Maps which never held 64 entries are not compacted, a ratio not greater than 0 disables auto compaction.
+func (m *Map[K, V]) SetCompactThreshold(ratio float64) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.compactRatio = ratio
+}
+
+// delete removes the key and compacts the map when it has shrunk below the threshold
+func (m *Map[K, V]) delete(key K) {
+	m.peak = max(m.peak, len(m.items))
+	delete(m.items, key)
+	if m.compactRatio > 0 && m.peak >= compactMinPeak && float64(len(m.items)) < float64(m.peak)*m.compactRatio {
+		m.compact()
+	}
+}
+
+func (m *Map[K, V]) compact() {
+	items := make(map[K]V, len(m.items))
+	for key, value := range m.items {
+		items[key] = value
+	}
+	m.items = items
+	m.peak = len(items)
+}
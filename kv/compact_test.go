@@ -0,0 +1,103 @@
+package kv
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMap_Compact(t *testing.T) {
+	m := NewMap[int, int]()
+	for i := 0; i < 1000; i++ {
+		m.Set(i, i*2)
+	}
+	for i := 10; i < 1000; i++ {
+		m.Remove(i)
+	}
+	m.Compact()
+	assert.EqualValues(t, 10, m.Count())
+	assert.Equal(t, 10, m.peak)
+	value, ok := m.Get(9)
+	assert.True(t, ok)
+	assert.Equal(t, 18, value)
+}
+
+func TestMap_SetCompactThreshold(t *testing.T) {
+	m := NewMap[int, int]()
+	m.SetCompactThreshold(0.25)
+	for i := 0; i < 100; i++ {
+		m.Set(i, i)
+	}
+	for i := 0; i < 75; i++ {
+		m.Remove(i)
+	}
+	assert.Equal(t, 100, m.peak)
+	m.Remove(75)
+	assert.Equal(t, 24, m.peak)
+	assert.EqualValues(t, 24, m.Count())
+	assert.True(t, m.ContainsKey(99))
+
+	m.Clear()
+	for i := 0; i < 10; i++ {
+		m.Set(i, i)
+	}
+	for i := 0; i < 9; i++ {
+		m.Remove(i)
+	}
+	assert.Equal(t, 10, m.peak)
+
+	m.SetCompactThreshold(0)
+	for i := 0; i < 100; i++ {
+		m.Set(i, i)
+	}
+	for i := 0; i < 99; i++ {
+		m.Remove(i)
+	}
+	assert.Equal(t, 100, m.peak)
+}
+
+func TestJournalMap_SetCompactThreshold(t *testing.T) {
+	var ops []Op[int, int]
+	m := NewJournalMap[int, int](func(op Op[int, int]) {
+		ops = append(ops, op)
+	})
+	m.SetCompactThreshold(0.5)
+	for i := 0; i < 64; i++ {
+		m.Set(i, i)
+	}
+	for i := 0; i < 33; i++ {
+		m.Remove(i)
+	}
+	assert.Equal(t, 31, m.peak)
+	assert.Len(t, ops, 97)
+}
+
+func TestLinkedMap_SetCompactThreshold(t *testing.T) {
+	m := NewLinkedMap[int, int]()
+	m.SetCompactThreshold(0.5)
+	for i := 0; i < 64; i++ {
+		m.Set(i, i)
+	}
+	for i := 0; i < 33; i++ {
+		m.Remove(i)
+	}
+	assert.Equal(t, 31, m.peak)
+	assert.Equal(t, 33, m.Keys()[0])
+
+	for i := 64; i < 128; i++ {
+		m.Set(i, i)
+	}
+	m.RemoveAll(33, 34, 35)
+	assert.Equal(t, 95, m.peak)
+	m.Compute(36, func(value int, ok bool) (int, bool) {
+		return value, false
+	})
+	for i := 37; i < 81; i++ {
+		m.Remove(i)
+	}
+	assert.Equal(t, 47, m.peak)
+	assert.Equal(t, []int{81, 82}, m.Keys()[:2])
+
+	m.Clear()
+	assert.Equal(t, 0, m.peak)
+}
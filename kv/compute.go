@@ -0,0 +1,218 @@
+package kv
+
+import "reflect"
+
+// GetOrSet returns the value of the key and true when the key exists,
+// otherwise it sets the value to the key and returns it with false
+func (m *Map[K, V]) GetOrSet(key K, value V) (V, bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if v, ok := m.items[key]; ok {
+		return v, true
+	}
+	m.items[key] = value
+	return value, false
+}
+
+// ComputeIfAbsent returns the value of the key, a missing key is set to the value computed by the callback first,
+// such as a lazily created entry of a cache which must be created once
+func (m *Map[K, V]) ComputeIfAbsent(key K, callback func(key K) V) V {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if v, ok := m.items[key]; ok {
+		return v
+	}
+	value := callback(key)
+	m.items[key] = value
+	return value
+}
+
+// Compute calls the callback with the value of the key and whether the key exists, and sets the value it returns,
+// or removes the key when it returns false. It returns the new value and whether the key exists afterwards.
+func (m *Map[K, V]) Compute(key K, callback func(value V, ok bool) (V, bool)) (V, bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	old, exists := m.items[key]
+	value, keep := callback(old, exists)
+	if !keep {
+		if exists {
+			m.delete(key)
+		}
+		return *new(V), false
+	}
+	m.items[key] = value
+	return value, true
+}
+
+// Update replaces the value of an existing key with the value the callback returns for it,
+// it returns false and does not call the callback when the key does not exist
+func (m *Map[K, V]) Update(key K, callback func(value V) V) bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	value, ok := m.items[key]
+	if !ok {
+		return false
+	}
+	m.items[key] = callback(value)
+	return true
+}
+
+// Replace sets the new value to the key only when its value is deeply equal to the old one, it returns whether it is replaced
+func (m *Map[K, V]) Replace(key K, old, new V) bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if value, ok := m.items[key]; !ok || !reflect.DeepEqual(value, old) {
+		return false
+	}
+	m.items[key] = new
+	return true
+}
+
+// GetOrSet returns the value of the key and true when the key exists,
+// otherwise it sets the value to the key at the end of the order and returns it with false
+func (m *LinkedMap[K, V]) GetOrSet(key K, value V) (V, bool) {
+	m.lock.Lock()
+	defer m.unlock()
+	if v, ok := m.items[key]; ok {
+		return v, true
+	}
+	m.keys.Push(key)
+	m.items[key] = value
+	return value, false
+}
+
+// ComputeIfAbsent returns the value of the key, a missing key is set to the value computed by the callback first
+// at the end of the order
+func (m *LinkedMap[K, V]) ComputeIfAbsent(key K, callback func(key K) V) V {
+	m.lock.Lock()
+	defer m.unlock()
+	if v, ok := m.items[key]; ok {
+		return v
+	}
+	value := callback(key)
+	m.keys.Push(key)
+	m.items[key] = value
+	return value
+}
+
+// Compute calls the callback with the value of the key and whether the key exists, and sets the value it returns,
+// or removes the key when it returns false. An existing key keeps its position and a new key is added at the end.
+// It returns the new value and whether the key exists afterwards.
+func (m *LinkedMap[K, V]) Compute(key K, callback func(value V, ok bool) (V, bool)) (V, bool) {
+	m.lock.Lock()
+	defer m.unlock()
+	old, exists := m.items[key]
+	value, keep := callback(old, exists)
+	if !keep {
+		if exists {
+			m.delete(key)
+			m.keys.Remove(key)
+		}
+		return *new(V), false
+	}
+	if !exists {
+		m.keys.Push(key)
+	}
+	m.items[key] = value
+	return value, true
+}
+
+// Update replaces the value of an existing key with the value the callback returns for it, the key keeps its position.
+// It returns false and does not call the callback when the key does not exist.
+func (m *LinkedMap[K, V]) Update(key K, callback func(value V) V) bool {
+	m.lock.Lock()
+	defer m.unlock()
+	value, ok := m.items[key]
+	if !ok {
+		return false
+	}
+	m.items[key] = callback(value)
+	return true
+}
+
+// Replace sets the new value to the key only when its value is deeply equal to the old one, it returns whether it is replaced.
+// The key keeps its position.
+func (m *LinkedMap[K, V]) Replace(key K, old, new V) bool {
+	m.lock.Lock()
+	defer m.unlock()
+	if value, ok := m.items[key]; !ok || !reflect.DeepEqual(value, old) {
+		return false
+	}
+	m.items[key] = new
+	return true
+}
+
+// GetOrSet returns the value of the key and true when the key exists,
+// otherwise it sets the value to the key, which is recorded, and returns it with false
+func (m *JournalMap[K, V]) GetOrSet(key K, value V) (V, bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if v, ok := m.items[key]; ok {
+		return v, true
+	}
+	m.set(key, value)
+	return value, false
+}
+
+// ComputeIfAbsent returns the value of the key, a missing key is set to the value computed by the callback first,
+// which is recorded
+func (m *JournalMap[K, V]) ComputeIfAbsent(key K, callback func(key K) V) V {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if v, ok := m.items[key]; ok {
+		return v
+	}
+	value := callback(key)
+	m.set(key, value)
+	return value
+}
+
+// Compute calls the callback with the value of the key and whether the key exists, and sets the value it returns,
+// or removes the key when it returns false, the change is recorded as a set or a remove.
+// It returns the new value and whether the key exists afterwards.
+func (m *JournalMap[K, V]) Compute(key K, callback func(value V, ok bool) (V, bool)) (V, bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	old, exists := m.items[key]
+	value, keep := callback(old, exists)
+	if !keep {
+		if exists {
+			m.delete(key)
+			m.journal(Op[K, V]{Kind: OpRemove, Key: key})
+		}
+		return *new(V), false
+	}
+	m.set(key, value)
+	return value, true
+}
+
+// Update replaces the value of an existing key with the value the callback returns for it, which is recorded.
+// It returns false and does not call the callback when the key does not exist.
+func (m *JournalMap[K, V]) Update(key K, callback func(value V) V) bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	value, ok := m.items[key]
+	if !ok {
+		return false
+	}
+	m.set(key, callback(value))
+	return true
+}
+
+// Replace sets the new value to the key only when its value is deeply equal to the old one, which is recorded,
+// it returns whether it is replaced
+func (m *JournalMap[K, V]) Replace(key K, old, new V) bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if value, ok := m.items[key]; !ok || !reflect.DeepEqual(value, old) {
+		return false
+	}
+	m.set(key, new)
+	return true
+}
+
+// set sets the value to the key and records it
+func (m *JournalMap[K, V]) set(key K, value V) {
+	m.items[key] = value
+	m.journal(Op[K, V]{Kind: OpSet, Key: key, Value: value})
+}
@@ -0,0 +1,189 @@
+package kv
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMap_GetOrSet(t *testing.T) {
+	m := NewMap[string, int]()
+	value, ok := m.GetOrSet("a", 1)
+	assert.False(t, ok)
+	assert.Equal(t, 1, value)
+	value, ok = m.GetOrSet("a", 2)
+	assert.True(t, ok)
+	assert.Equal(t, 1, value)
+}
+
+func TestMap_ComputeIfAbsent(t *testing.T) {
+	m := NewMap[string, int]()
+	calls := 0
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.Equal(t, 3, m.ComputeIfAbsent("abc", func(key string) int {
+				calls++
+				return len(key)
+			}))
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 1, calls)
+}
+
+func TestMap_Compute(t *testing.T) {
+	m := NewMap[string, int]()
+	increment := func(value int, ok bool) (int, bool) {
+		return value + 1, true
+	}
+	var wg sync.WaitGroup
+	for range 100 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m.Compute("hits", increment)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 100, m.GetOr("hits", 0))
+
+	value, ok := m.Compute("hits", func(value int, ok bool) (int, bool) {
+		return 0, false
+	})
+	assert.False(t, ok)
+	assert.Zero(t, value)
+	assert.False(t, m.ContainsKey("hits"))
+	_, ok = m.Compute("missing", func(value int, ok bool) (int, bool) {
+		assert.False(t, ok)
+		return 0, false
+	})
+	assert.False(t, ok)
+	assert.True(t, m.IsEmpty())
+}
+
+func TestMap_Update(t *testing.T) {
+	m := NewMap[string, int]()
+	m.Set("a", 1)
+	assert.True(t, m.Update("a", func(value int) int {
+		return value * 10
+	}))
+	assert.Equal(t, 10, m.GetOr("a", 0))
+	assert.False(t, m.Update("b", func(value int) int {
+		assert.Fail(t, "unexpected call")
+		return value
+	}))
+	assert.False(t, m.ContainsKey("b"))
+}
+
+func TestMap_Replace(t *testing.T) {
+	m := NewMap[string, []int]()
+	m.Set("a", []int{1})
+	assert.False(t, m.Replace("a", []int{2}, []int{3}))
+	assert.True(t, m.Replace("a", []int{1}, []int{3}))
+	assert.Equal(t, []int{3}, m.GetOr("a", nil))
+	assert.False(t, m.Replace("b", nil, []int{1}))
+	assert.False(t, m.ContainsKey("b"))
+}
+
+func TestLinkedMap_GetOrSet(t *testing.T) {
+	m := NewLinkedMap[string, int]()
+	m.Set("a", 1)
+	value, ok := m.GetOrSet("b", 2)
+	assert.False(t, ok)
+	assert.Equal(t, 2, value)
+	value, ok = m.GetOrSet("a", 3)
+	assert.True(t, ok)
+	assert.Equal(t, 1, value)
+	assert.Equal(t, []string{"a", "b"}, m.Keys())
+}
+
+func TestLinkedMap_ComputeIfAbsent(t *testing.T) {
+	m := NewLinkedMap[string, int]()
+	m.Set("a", 1)
+	assert.Equal(t, 2, m.ComputeIfAbsent("bb", func(key string) int {
+		return len(key)
+	}))
+	assert.Equal(t, 1, m.ComputeIfAbsent("a", func(key string) int {
+		return 0
+	}))
+	assert.Equal(t, []string{"a", "bb"}, m.Keys())
+}
+
+func TestLinkedMap_Compute(t *testing.T) {
+	m := NewLinkedMap[string, int]()
+	m.Set("a", 1)
+	m.Set("b", 2)
+	value, ok := m.Compute("a", func(value int, ok bool) (int, bool) {
+		return value + 10, true
+	})
+	assert.True(t, ok)
+	assert.Equal(t, 11, value)
+	m.Compute("c", func(value int, ok bool) (int, bool) {
+		return 3, !ok
+	})
+	assert.Equal(t, []string{"a", "b", "c"}, m.Keys())
+	m.Compute("b", func(value int, ok bool) (int, bool) {
+		return 0, false
+	})
+	assert.Equal(t, []string{"a", "c"}, m.Keys())
+	assert.Equal(t, []int{11, 3}, m.Values())
+}
+
+func TestLinkedMap_Update(t *testing.T) {
+	m := NewLinkedMap[string, int]()
+	m.Set("a", 1)
+	m.Set("b", 2)
+	assert.True(t, m.Update("a", func(value int) int {
+		return -value
+	}))
+	assert.Equal(t, []string{"a", "b"}, m.Keys())
+	assert.Equal(t, []int{-1, 2}, m.Values())
+}
+
+func TestLinkedMap_Replace(t *testing.T) {
+	m := NewLinkedMap[string, int]()
+	m.Set("a", 1)
+	m.Set("b", 2)
+	assert.True(t, m.Replace("a", 1, 5))
+	assert.False(t, m.Replace("c", 0, 5))
+	assert.Equal(t, []string{"a", "b"}, m.Keys())
+	assert.Equal(t, []int{5, 2}, m.Values())
+}
+
+func TestJournalMap_Compute(t *testing.T) {
+	var ops []Op[string, int]
+	m := NewJournalMap(func(op Op[string, int]) {
+		ops = append(ops, op)
+	})
+	m.GetOrSet("a", 1)
+	m.GetOrSet("a", 2)
+	m.ComputeIfAbsent("b", func(key string) int {
+		return 2
+	})
+	m.Compute("a", func(value int, ok bool) (int, bool) {
+		return value + 1, true
+	})
+	m.Update("b", func(value int) int {
+		return value * 2
+	})
+	m.Replace("b", 4, 5)
+	m.Replace("b", 4, 6)
+	m.Compute("a", func(value int, ok bool) (int, bool) {
+		return 0, false
+	})
+	assert.Equal(t, []Op[string, int]{
+		{Kind: OpSet, Key: "a", Value: 1},
+		{Kind: OpSet, Key: "b", Value: 2},
+		{Kind: OpSet, Key: "a", Value: 2},
+		{Kind: OpSet, Key: "b", Value: 4},
+		{Kind: OpSet, Key: "b", Value: 5},
+		{Kind: OpRemove, Key: "a"},
+	}, ops)
+	replayed := NewMap[string, int]()
+	assert.NoError(t, replayed.Replay(ops...))
+	assert.Equal(t, m.ToMap(), replayed.ToMap())
+}
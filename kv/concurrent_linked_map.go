@@ -0,0 +1,287 @@
+package kv
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"log/slog"
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gopi-frame/collection/internal/hash"
+	"github.com/gopi-frame/collection/internal/preview"
+)
+
+// defaultShards is the number of shards used when NewConcurrentLinkedMap is given no positive number
+const defaultShards = 32
+
+// NewConcurrentLinkedMap new concurrent linked map with the number of shards, 32 when shards <= 0
+func NewConcurrentLinkedMap[K comparable, V any](shards int) *ConcurrentLinkedMap[K, V] {
+	m := new(ConcurrentLinkedMap[K, V])
+	m.init(shards)
+	return m
+}
+
+// ConcurrentLinkedMap insertion ordered map for heavy parallel writes, all methods are safe for concurrent use.
+// The keys are spread over shards with their own locks, so writes to different shards do not wait for each other,
+// and each new key takes the next number of an atomic sequence which gives the insertion order.
+// An existing key keeps its position when it is set again, a removed key goes to the end when it is set again.
+// The ordered views, such as Keys, Each and All, visit the shards one by one, so they are not an atomic snapshot,
+// writes made while they run may or may not be seen.
+type ConcurrentLinkedMap[K comparable, V any] struct {
+	shards   []shard[K, V]
+	sequence atomic.Uint64
+	count    atomic.Int64
+	limit    preview.Limit
+}
+
+type shard[K comparable, V any] struct {
+	lock  sync.RWMutex
+	items map[K]*orderedEntry[K, V]
+}
+
+type orderedEntry[K comparable, V any] struct {
+	key      K
+	value    V
+	sequence uint64
+}
+
+func (m *ConcurrentLinkedMap[K, V]) init(shards int) {
+	if shards <= 0 {
+		shards = defaultShards
+	}
+	m.shards = make([]shard[K, V], shards)
+	for index := range m.shards {
+		m.shards[index].items = make(map[K]*orderedEntry[K, V])
+	}
+}
+
+func (m *ConcurrentLinkedMap[K, V]) shard(key K) *shard[K, V] {
+	return &m.shards[hash.Value(key)%uint64(len(m.shards))]
+}
+
+// Count returns the size of map
+func (m *ConcurrentLinkedMap[K, V]) Count() int64 {
+	return m.count.Load()
+}
+
+// IsEmpty returns whether the map is empty
+func (m *ConcurrentLinkedMap[K, V]) IsEmpty() bool {
+	return m.Count() == 0
+}
+
+// IsNotEmpty returns whether the map is not empty
+func (m *ConcurrentLinkedMap[K, V]) IsNotEmpty() bool {
+	return !m.IsEmpty()
+}
+
+// Get gets element by specific key.
+// A zero value and false will be returned when the given key is not exist
+func (m *ConcurrentLinkedMap[K, V]) Get(key K) (V, bool) {
+	s := m.shard(key)
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	if entry, ok := s.items[key]; ok {
+		return entry.value, true
+	}
+	return *new(V), false
+}
+
+// GetOr gets element by specific key, the default will be returned when the key is not exist
+func (m *ConcurrentLinkedMap[K, V]) GetOr(key K, value V) V {
+	if v, ok := m.Get(key); ok {
+		return v
+	}
+	return value
+}
+
+// ContainsKey returns whether the map contains the specific key
+func (m *ConcurrentLinkedMap[K, V]) ContainsKey(key K) bool {
+	_, ok := m.Get(key)
+	return ok
+}
+
+// Set sets value to specific key, an existing key keeps its position.
+func (m *ConcurrentLinkedMap[K, V]) Set(key K, value V) {
+	s := m.shard(key)
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if entry, ok := s.items[key]; ok {
+		entry.value = value
+		return
+	}
+	s.items[key] = &orderedEntry[K, V]{key: key, value: value, sequence: m.sequence.Add(1)}
+	m.count.Add(1)
+}
+
+// GetOrSet returns the value of the key and true when the key exists,
+// otherwise it sets the value to the key at the end of the order and returns it with false
+func (m *ConcurrentLinkedMap[K, V]) GetOrSet(key K, value V) (V, bool) {
+	s := m.shard(key)
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if entry, ok := s.items[key]; ok {
+		return entry.value, true
+	}
+	s.items[key] = &orderedEntry[K, V]{key: key, value: value, sequence: m.sequence.Add(1)}
+	m.count.Add(1)
+	return value, false
+}
+
+// Remove removes the element of specific key
+func (m *ConcurrentLinkedMap[K, V]) Remove(key K) {
+	s := m.shard(key)
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if _, ok := s.items[key]; ok {
+		delete(s.items, key)
+		m.count.Add(-1)
+	}
+}
+
+// Clear clears the map, all shards are locked while clearing
+func (m *ConcurrentLinkedMap[K, V]) Clear() {
+	for index := range m.shards {
+		m.shards[index].lock.Lock()
+	}
+	for index := range m.shards {
+		s := &m.shards[index]
+		m.count.Add(-int64(len(s.items)))
+		s.items = make(map[K]*orderedEntry[K, V])
+		s.lock.Unlock()
+	}
+}
+
+// entries returns copies of the entries in insertion order
+func (m *ConcurrentLinkedMap[K, V]) entries() []orderedEntry[K, V] {
+	var entries []orderedEntry[K, V]
+	for index := range m.shards {
+		s := &m.shards[index]
+		s.lock.RLock()
+		for _, entry := range s.items {
+			entries = append(entries, *entry)
+		}
+		s.lock.RUnlock()
+	}
+	slices.SortFunc(entries, func(a, b orderedEntry[K, V]) int {
+		return cmp.Compare(a.sequence, b.sequence)
+	})
+	return entries
+}
+
+func (m *ConcurrentLinkedMap[K, V]) snapshot() ([]K, []V) {
+	entries := m.entries()
+	keys := make([]K, len(entries))
+	values := make([]V, len(entries))
+	for index, entry := range entries {
+		keys[index], values[index] = entry.key, entry.value
+	}
+	return keys, values
+}
+
+// Keys returns all keys in insertion order
+func (m *ConcurrentLinkedMap[K, V]) Keys() []K {
+	keys, _ := m.snapshot()
+	return keys
+}
+
+// Values returns all values in insertion order of their keys
+func (m *ConcurrentLinkedMap[K, V]) Values() []V {
+	_, values := m.snapshot()
+	return values
+}
+
+// Each ranges the map in insertion order by callback, it will break the loop when the callback returns false.
+// The callback runs on a snapshot of the map, so it is allowed to modify the map.
+func (m *ConcurrentLinkedMap[K, V]) Each(callback func(key K, value V) bool) {
+	for _, entry := range m.entries() {
+		if !callback(entry.key, entry.value) {
+			break
+		}
+	}
+}
+
+// All returns an iterator over the key value pairs in insertion order
+func (m *ConcurrentLinkedMap[K, V]) All() iter.Seq2[K, V] {
+	entries := m.entries()
+	return func(yield func(K, V) bool) {
+		for _, entry := range entries {
+			if !yield(entry.key, entry.value) {
+				return
+			}
+		}
+	}
+}
+
+// ToMap converts to map, the returned map is a copy, so modifying it does not affect the map
+func (m *ConcurrentLinkedMap[K, V]) ToMap() map[K]V {
+	items := make(map[K]V, m.Count())
+	for index := range m.shards {
+		s := &m.shards[index]
+		s.lock.RLock()
+		for key, entry := range s.items {
+			items[key] = entry.value
+		}
+		s.lock.RUnlock()
+	}
+	return items
+}
+
+// ToJSON converts to json in the format of [LinkedMap]
+func (m *ConcurrentLinkedMap[K, V]) ToJSON() ([]byte, error) {
+	keys, values := m.snapshot()
+	entries := make(map[K]V, len(keys))
+	for index, key := range keys {
+		entries[key] = values[index]
+	}
+	return json.Marshal(jsonObject[K, V]{Entries: entries, Keys: keys})
+}
+
+// MarshalJSON implements [json.Marshaller]
+func (m *ConcurrentLinkedMap[K, V]) MarshalJSON() ([]byte, error) {
+	return m.ToJSON()
+}
+
+// UnmarshalJSON implements [json.Unmarshaller], the entries are set in the order of the keys
+func (m *ConcurrentLinkedMap[K, V]) UnmarshalJSON(data []byte) error {
+	container := new(jsonObject[K, V])
+	if err := json.Unmarshal(data, container); err != nil {
+		return err
+	}
+	if m.shards == nil {
+		m.init(0)
+	}
+	m.Clear()
+	for _, key := range container.Keys {
+		m.Set(key, container.Entries[key])
+	}
+	return nil
+}
+
+// SetPreviewLimit sets the number of entries shown by String, %v and LogValue, a negative limit shows all entries
+func (m *ConcurrentLinkedMap[K, V]) SetPreviewLimit(limit int) {
+	m.limit.Set(limit)
+}
+
+// String converts to string, only the preview limit of entries are shown
+func (m *ConcurrentLinkedMap[K, V]) String() string {
+	return m.format(m.limit.Get(-1))
+}
+
+// Format implements [fmt.Formatter], %v prints the preview as String does, %+v and %#v print all entries
+func (m *ConcurrentLinkedMap[K, V]) Format(f fmt.State, verb rune) {
+	preview.Format(f, verb, m.format, m.limit.Get(-1))
+}
+
+func (m *ConcurrentLinkedMap[K, V]) format(limit int) string {
+	keys, values := m.snapshot()
+	return preview.Entries(fmt.Sprintf("ConcurrentLinkedMap[%T, %T](len=%d)", *new(K), *new(V), len(keys)), keys, values, limit)
+}
+
+// LogValue implements [slog.LogValuer], only the preview limit of entries are logged
+func (m *ConcurrentLinkedMap[K, V]) LogValue() slog.Value {
+	keys, values := m.snapshot()
+	return preview.LogEntries(fmt.Sprintf("ConcurrentLinkedMap[%T, %T]", *new(K), *new(V)), len(keys), keys, values, m.limit.Get(preview.DefaultLimit))
+}
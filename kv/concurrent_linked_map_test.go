@@ -0,0 +1,120 @@
+package kv
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConcurrentLinkedMap_Set(t *testing.T) {
+	m := NewConcurrentLinkedMap[string, int](4)
+	m.Set("c", 1)
+	m.Set("a", 2)
+	m.Set("b", 3)
+	m.Set("c", 4)
+	assert.Equal(t, []string{"c", "a", "b"}, m.Keys())
+	assert.Equal(t, []int{4, 2, 3}, m.Values())
+	m.Remove("c")
+	m.Remove("d")
+	m.Set("c", 5)
+	assert.Equal(t, []string{"a", "b", "c"}, m.Keys())
+	assert.EqualValues(t, 3, m.Count())
+	assert.Equal(t, 5, m.GetOr("c", 0))
+	assert.Equal(t, 0, m.GetOr("d", 0))
+	assert.True(t, m.ContainsKey("a"))
+	assert.False(t, m.ContainsKey("d"))
+}
+
+func TestConcurrentLinkedMap_GetOrSet(t *testing.T) {
+	m := NewConcurrentLinkedMap[string, int](0)
+	v, ok := m.GetOrSet("a", 1)
+	assert.False(t, ok)
+	assert.Equal(t, 1, v)
+	v, ok = m.GetOrSet("a", 2)
+	assert.True(t, ok)
+	assert.Equal(t, 1, v)
+}
+
+func TestConcurrentLinkedMap_Clear(t *testing.T) {
+	m := NewConcurrentLinkedMap[int, int](2)
+	for i := 0; i < 10; i++ {
+		m.Set(i, i)
+	}
+	m.Clear()
+	assert.True(t, m.IsEmpty())
+	assert.Empty(t, m.Keys())
+	m.Set(1, 1)
+	assert.True(t, m.IsNotEmpty())
+}
+
+func TestConcurrentLinkedMap_Each(t *testing.T) {
+	m := NewConcurrentLinkedMap[int, string](3)
+	for i := 5; i > 0; i-- {
+		m.Set(i, fmt.Sprint(i))
+	}
+	var keys []int
+	m.Each(func(key int, value string) bool {
+		keys = append(keys, key)
+		m.Remove(key)
+		return key > 3
+	})
+	assert.Equal(t, []int{5, 4, 3}, keys)
+	keys = nil
+	for key := range m.All() {
+		keys = append(keys, key)
+	}
+	assert.Equal(t, []int{2, 1}, keys)
+	assert.Equal(t, map[int]string{2: "2", 1: "1"}, m.ToMap())
+}
+
+func TestConcurrentLinkedMap_MarshalJSON(t *testing.T) {
+	m := NewConcurrentLinkedMap[string, int](0)
+	m.Set("b", 1)
+	m.Set("a", 2)
+	data, err := json.Marshal(m)
+	assert.Nil(t, err)
+	linked := NewLinkedMap[string, int]()
+	assert.Nil(t, json.Unmarshal(data, linked))
+	assert.Equal(t, []string{"b", "a"}, linked.Keys())
+
+	decoded := new(ConcurrentLinkedMap[string, int])
+	assert.Nil(t, json.Unmarshal(data, decoded))
+	assert.Equal(t, []string{"b", "a"}, decoded.Keys())
+	assert.Equal(t, []int{1, 2}, decoded.Values())
+}
+
+func TestConcurrentLinkedMap_Concurrent(t *testing.T) {
+	m := NewConcurrentLinkedMap[int, int](8)
+	var wg sync.WaitGroup
+	for worker := 0; worker < 8; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				m.Set(worker*100+i, i)
+				_ = m.Keys()
+			}
+		}()
+	}
+	wg.Wait()
+	assert.EqualValues(t, 800, m.Count())
+	positions := make(map[int]int)
+	for index, key := range m.Keys() {
+		positions[key] = index
+	}
+	for worker := 0; worker < 8; worker++ {
+		for i := 1; i < 100; i++ {
+			assert.Less(t, positions[worker*100+i-1], positions[worker*100+i])
+		}
+	}
+}
+
+func TestConcurrentLinkedMap_String(t *testing.T) {
+	m := NewConcurrentLinkedMap[string, int](0)
+	m.Set("b", 1)
+	m.Set("a", 2)
+	assert.Equal(t, "ConcurrentLinkedMap[string, int](len=2){\n\tb: 1,\n\ta: 2,\n}", m.String())
+}
@@ -0,0 +1,227 @@
+package kv
+
+import (
+	"encoding/json"
+	"fmt"
+	"iter"
+	"log/slog"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gopi-frame/collection/internal/hash"
+	"github.com/gopi-frame/collection/internal/preview"
+)
+
+// counterStripes is the number of cells each counter spreads its additions over
+const counterStripes = 8
+
+// NewCounterMap new counter map with the number of shards, 32 when shards <= 0
+func NewCounterMap[K comparable](shards int) *CounterMap[K] {
+	if shards <= 0 {
+		shards = defaultShards
+	}
+	m := &CounterMap[K]{shards: make([]counterShard[K], shards)}
+	for index := range m.shards {
+		m.shards[index].counters = make(map[K]*counter)
+	}
+	return m
+}
+
+// CounterMap map of int64 counters for hot keys, such as metrics, all methods are safe for concurrent use.
+// The keys are spread over shards with their own locks, which are only write locked when a key is added or removed,
+// and each counter spreads its additions over striped atomic cells, so concurrent Incr calls on the same key rarely contend.
+// Sum adds the cells up, so it is not an atomic read of a counter which is being incremented,
+// and an Incr racing with Remove or Clear of the same key may be added to the removed counter.
+type CounterMap[K comparable] struct {
+	shards []counterShard[K]
+	limit  preview.Limit
+}
+
+type counterShard[K comparable] struct {
+	lock     sync.RWMutex
+	counters map[K]*counter
+}
+
+// counter striped adder, each cell is padded to its own cache line
+type counter struct {
+	cells [counterStripes]struct {
+		value atomic.Int64
+		_     [56]byte
+	}
+}
+
+func (c *counter) add(delta int64) {
+	c.cells[rand.IntN(counterStripes)].value.Add(delta)
+}
+
+func (c *counter) sum() int64 {
+	var sum int64
+	for index := range c.cells {
+		sum += c.cells[index].value.Load()
+	}
+	return sum
+}
+
+func (m *CounterMap[K]) shard(key K) *counterShard[K] {
+	return &m.shards[hash.Value(key)%uint64(len(m.shards))]
+}
+
+// counter returns the counter of the key, it is created when create is true and the key does not exist
+func (m *CounterMap[K]) counter(key K, create bool) *counter {
+	s := m.shard(key)
+	s.lock.RLock()
+	c, ok := s.counters[key]
+	s.lock.RUnlock()
+	if ok || !create {
+		return c
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if c, ok = s.counters[key]; !ok {
+		c = new(counter)
+		s.counters[key] = c
+	}
+	return c
+}
+
+// Incr adds the delta to the counter of the key, the counter starts from 0 when the key does not exist
+func (m *CounterMap[K]) Incr(key K, delta int64) {
+	m.counter(key, true).add(delta)
+}
+
+// Sum returns the counter of the key, it is 0 when the key does not exist
+func (m *CounterMap[K]) Sum(key K) int64 {
+	if c := m.counter(key, false); c != nil {
+		return c.sum()
+	}
+	return 0
+}
+
+// ContainsKey returns whether the map has a counter for the key
+func (m *CounterMap[K]) ContainsKey(key K) bool {
+	return m.counter(key, false) != nil
+}
+
+// Remove removes the counter of the key
+func (m *CounterMap[K]) Remove(key K) {
+	s := m.shard(key)
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	delete(s.counters, key)
+}
+
+// Clear removes all counters
+func (m *CounterMap[K]) Clear() {
+	for index := range m.shards {
+		s := &m.shards[index]
+		s.lock.Lock()
+		s.counters = make(map[K]*counter)
+		s.lock.Unlock()
+	}
+}
+
+// Count returns the number of counters
+func (m *CounterMap[K]) Count() int64 {
+	var count int64
+	for index := range m.shards {
+		s := &m.shards[index]
+		s.lock.RLock()
+		count += int64(len(s.counters))
+		s.lock.RUnlock()
+	}
+	return count
+}
+
+// IsEmpty returns whether the map has no counter
+func (m *CounterMap[K]) IsEmpty() bool {
+	return m.Count() == 0
+}
+
+// IsNotEmpty returns whether the map has any counter
+func (m *CounterMap[K]) IsNotEmpty() bool {
+	return !m.IsEmpty()
+}
+
+// Snapshot returns the sums of all counters, the returned map is a copy, so modifying it does not affect the map
+func (m *CounterMap[K]) Snapshot() map[K]int64 {
+	sums := make(map[K]int64)
+	for index := range m.shards {
+		s := &m.shards[index]
+		s.lock.RLock()
+		for key, c := range s.counters {
+			sums[key] = c.sum()
+		}
+		s.lock.RUnlock()
+	}
+	return sums
+}
+
+// Each ranges the sums of the counters by callback, it will break the loop when the callback returns false.
+// The callback runs on a snapshot of the map, so it is allowed to modify the map.
+func (m *CounterMap[K]) Each(callback func(key K, sum int64) bool) {
+	for key, sum := range m.Snapshot() {
+		if !callback(key, sum) {
+			break
+		}
+	}
+}
+
+// All returns an iterator over the keys and the sums of their counters
+func (m *CounterMap[K]) All() iter.Seq2[K, int64] {
+	sums := m.Snapshot()
+	return func(yield func(K, int64) bool) {
+		for key, sum := range sums {
+			if !yield(key, sum) {
+				return
+			}
+		}
+	}
+}
+
+// ToJSON converts to json, an object of the sums of the counters
+func (m *CounterMap[K]) ToJSON() ([]byte, error) {
+	return json.Marshal(m.Snapshot())
+}
+
+// MarshalJSON implements [json.Marshaller]
+func (m *CounterMap[K]) MarshalJSON() ([]byte, error) {
+	return m.ToJSON()
+}
+
+func (m *CounterMap[K]) snapshot() ([]K, []int64) {
+	sums := m.Snapshot()
+	keys := make([]K, 0, len(sums))
+	values := make([]int64, 0, len(sums))
+	for key, sum := range sums {
+		keys = append(keys, key)
+		values = append(values, sum)
+	}
+	return keys, values
+}
+
+// SetPreviewLimit sets the number of entries shown by String, %v and LogValue, a negative limit shows all entries
+func (m *CounterMap[K]) SetPreviewLimit(limit int) {
+	m.limit.Set(limit)
+}
+
+// String converts to string, only the preview limit of entries are shown
+func (m *CounterMap[K]) String() string {
+	return m.format(m.limit.Get(-1))
+}
+
+// Format implements [fmt.Formatter], %v prints the preview as String does, %+v and %#v print all entries
+func (m *CounterMap[K]) Format(f fmt.State, verb rune) {
+	preview.Format(f, verb, m.format, m.limit.Get(-1))
+}
+
+func (m *CounterMap[K]) format(limit int) string {
+	keys, values := m.snapshot()
+	return preview.Entries(fmt.Sprintf("CounterMap[%T](len=%d)", *new(K), len(keys)), keys, values, limit)
+}
+
+// LogValue implements [slog.LogValuer], only the preview limit of entries are logged
+func (m *CounterMap[K]) LogValue() slog.Value {
+	keys, values := m.snapshot()
+	return preview.LogEntries(fmt.Sprintf("CounterMap[%T]", *new(K)), len(keys), keys, values, m.limit.Get(preview.DefaultLimit))
+}
@@ -0,0 +1,73 @@
+package kv
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCounterMap_Incr(t *testing.T) {
+	m := NewCounterMap[string](0)
+	m.Incr("a", 1)
+	m.Incr("a", 2)
+	m.Incr("b", -1)
+	assert.EqualValues(t, 3, m.Sum("a"))
+	assert.EqualValues(t, -1, m.Sum("b"))
+	assert.EqualValues(t, 0, m.Sum("c"))
+	assert.True(t, m.ContainsKey("a"))
+	assert.False(t, m.ContainsKey("c"))
+	assert.EqualValues(t, 2, m.Count())
+}
+
+func TestCounterMap_Concurrent(t *testing.T) {
+	m := NewCounterMap[int](4)
+	var wg sync.WaitGroup
+	for worker := 0; worker < 8; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				m.Incr(i%3, 1)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, map[int]int64{0: 2672, 1: 2664, 2: 2664}, m.Snapshot())
+}
+
+func TestCounterMap_Remove(t *testing.T) {
+	m := NewCounterMap[string](2)
+	m.Incr("a", 1)
+	m.Incr("b", 1)
+	m.Remove("a")
+	assert.Equal(t, map[string]int64{"b": 1}, m.Snapshot())
+	m.Incr("a", 5)
+	assert.EqualValues(t, 5, m.Sum("a"))
+	m.Clear()
+	assert.True(t, m.IsEmpty())
+}
+
+func TestCounterMap_Each(t *testing.T) {
+	m := NewCounterMap[string](0)
+	m.Incr("a", 1)
+	m.Incr("b", 2)
+	sums := map[string]int64{}
+	m.Each(func(key string, sum int64) bool {
+		sums[key] = sum
+		m.Remove(key)
+		return true
+	})
+	assert.Equal(t, map[string]int64{"a": 1, "b": 2}, sums)
+	assert.True(t, m.IsEmpty())
+}
+
+func TestCounterMap_MarshalJSON(t *testing.T) {
+	m := NewCounterMap[string](0)
+	m.Incr("a", 3)
+	data, err := json.Marshal(m)
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"a":3}`, string(data))
+	assert.Equal(t, "CounterMap[string](len=1){\n\ta: 3,\n}", m.String())
+}
@@ -0,0 +1,18 @@
+//go:build collectiondebug
+
+package kv
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLinkedMap_Debug(t *testing.T) {
+	m := NewLinkedMap[int, int]()
+	m.Set(1, 1)
+	m.keys.Push(1)
+	assert.PanicsWithError(t, "*kv.LinkedMap[int,int] is corrupted, it may be used without synchronization: map has 3 keys in order, expected 2", func() {
+		m.Set(2, 2)
+	})
+}
@@ -0,0 +1,298 @@
+package kv
+
+import (
+	"fmt"
+	"iter"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/gopi-frame/collection/internal/preview"
+)
+
+// NewExpiringMap new expiring map whose entries set by Set expire after the ttl, a ttl <= 0 never expires
+func NewExpiringMap[K comparable, V any](ttl time.Duration) *ExpiringMap[K, V] {
+	return NewExpiringMapWithClock[K, V](ttl, time.Now)
+}
+
+// NewExpiringMapWithClock new expiring map which reads the time to expire the entries from the clock, such as a fake clock in tests
+func NewExpiringMapWithClock[K comparable, V any](ttl time.Duration, now func() time.Time) *ExpiringMap[K, V] {
+	return &ExpiringMap[K, V]{
+		items: make(map[K]expiringEntry[V]),
+		ttl:   ttl,
+		now:   now,
+	}
+}
+
+// ExpiringMap map whose entries expire after their time to live, all methods are safe for concurrent use.
+// The expired entries are removed lazily when they are met by Get, or all at once by Purge,
+// and StartReaper starts a goroutine which purges them periodically until Stop is called.
+// The expiration callback runs after the map is unlocked, so it may call methods of the same map.
+type ExpiringMap[K comparable, V any] struct {
+	lock     sync.RWMutex
+	items    map[K]expiringEntry[V]
+	ttl      time.Duration
+	now      func() time.Time
+	onExpire func(key K, value V)
+	stop     chan struct{}
+	done     chan struct{}
+	limit    preview.Limit
+}
+
+type expiringEntry[V any] struct {
+	value    V
+	deadline time.Time
+}
+
+// expired returns whether the entry has a deadline which is not after now
+func (e expiringEntry[V]) expired(now time.Time) bool {
+	return !e.deadline.IsZero() && !now.Before(e.deadline)
+}
+
+// OnExpire sets the callback which is called with each expired entry when it is removed,
+// the entries removed by Remove or Clear are not passed to it
+func (m *ExpiringMap[K, V]) OnExpire(callback func(key K, value V)) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.onExpire = callback
+}
+
+// notify passes the expired entries to the callback, it must be called after the map is unlocked
+func (m *ExpiringMap[K, V]) notify(callback func(key K, value V), keys []K, values []V) {
+	if callback == nil {
+		return
+	}
+	for index, key := range keys {
+		callback(key, values[index])
+	}
+}
+
+// TTL returns the time to live of the entries set by Set
+func (m *ExpiringMap[K, V]) TTL() time.Duration {
+	return m.ttl
+}
+
+// Count returns the number of entries, including the expired ones which are not purged yet
+func (m *ExpiringMap[K, V]) Count() int64 {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return int64(len(m.items))
+}
+
+// IsEmpty returns whether the map is empty
+func (m *ExpiringMap[K, V]) IsEmpty() bool {
+	return m.Count() == 0
+}
+
+// IsNotEmpty returns whether the map is not empty
+func (m *ExpiringMap[K, V]) IsNotEmpty() bool {
+	return !m.IsEmpty()
+}
+
+// Get returns the value of the specific key, the entry is removed when it is expired.
+// A zero value and false will be returned when the key is not exist or its entry is expired.
+func (m *ExpiringMap[K, V]) Get(key K) (V, bool) {
+	m.lock.Lock()
+	entry, ok := m.items[key]
+	if !ok || !entry.expired(m.now()) {
+		m.lock.Unlock()
+		return entry.value, ok
+	}
+	delete(m.items, key)
+	callback := m.onExpire
+	m.lock.Unlock()
+	m.notify(callback, []K{key}, []V{entry.value})
+	return *new(V), false
+}
+
+// GetOr returns the value of the specific key, or the default value when the key is not exist or expired
+func (m *ExpiringMap[K, V]) GetOr(key K, value V) V {
+	if v, ok := m.Get(key); ok {
+		return v
+	}
+	return value
+}
+
+// ExpiresAt returns the time when the entry of the specific key expires,
+// a zero time is returned when the entry never expires and false when the key is not exist or expired
+func (m *ExpiringMap[K, V]) ExpiresAt(key K) (time.Time, bool) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	entry, ok := m.items[key]
+	if !ok || entry.expired(m.now()) {
+		return time.Time{}, false
+	}
+	return entry.deadline, true
+}
+
+// ContainsKey returns whether the map has a live entry of the specific key, it does not remove an expired entry
+func (m *ExpiringMap[K, V]) ContainsKey(key K) bool {
+	_, ok := m.ExpiresAt(key)
+	return ok
+}
+
+// Set sets the value to the specific key which expires after the ttl of the map
+func (m *ExpiringMap[K, V]) Set(key K, value V) {
+	m.SetWithTTL(key, value, m.ttl)
+}
+
+// SetWithTTL sets the value to the specific key which expires after the ttl, a ttl <= 0 never expires
+func (m *ExpiringMap[K, V]) SetWithTTL(key K, value V, ttl time.Duration) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	entry := expiringEntry[V]{value: value}
+	if ttl > 0 {
+		entry.deadline = m.now().Add(ttl)
+	}
+	m.items[key] = entry
+}
+
+// Remove removes the specific key and reports whether it had a live entry
+func (m *ExpiringMap[K, V]) Remove(key K) bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	entry, ok := m.items[key]
+	delete(m.items, key)
+	return ok && !entry.expired(m.now())
+}
+
+// Clear clears the map
+func (m *ExpiringMap[K, V]) Clear() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.items = make(map[K]expiringEntry[V])
+}
+
+// Purge removes all expired entries and returns the number of them
+func (m *ExpiringMap[K, V]) Purge() int {
+	m.lock.Lock()
+	var keys []K
+	var values []V
+	now := m.now()
+	for key, entry := range m.items {
+		if entry.expired(now) {
+			keys = append(keys, key)
+			values = append(values, entry.value)
+			delete(m.items, key)
+		}
+	}
+	callback := m.onExpire
+	m.lock.Unlock()
+	m.notify(callback, keys, values)
+	return len(keys)
+}
+
+// StartReaper starts a goroutine which calls Purge at every interval until Stop is called,
+// a reaper started before is stopped first. It panics when the interval is not positive.
+func (m *ExpiringMap[K, V]) StartReaper(interval time.Duration) {
+	if interval <= 0 {
+		panic(fmt.Errorf("kv: reaper interval %s is not positive", interval))
+	}
+	m.Stop()
+	stop, done := make(chan struct{}), make(chan struct{})
+	m.lock.Lock()
+	m.stop, m.done = stop, done
+	m.lock.Unlock()
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				m.Purge()
+			}
+		}
+	}()
+}
+
+// Stop stops the reaper and waits for it to exit, it does nothing when no reaper is running
+func (m *ExpiringMap[K, V]) Stop() {
+	m.lock.Lock()
+	stop, done := m.stop, m.done
+	m.stop, m.done = nil, nil
+	m.lock.Unlock()
+	if stop == nil {
+		return
+	}
+	close(stop)
+	<-done
+}
+
+// Keys returns the keys of the live entries
+func (m *ExpiringMap[K, V]) Keys() []K {
+	keys, _ := m.snapshot()
+	return keys
+}
+
+// Values returns the values of the live entries
+func (m *ExpiringMap[K, V]) Values() []V {
+	_, values := m.snapshot()
+	return values
+}
+
+func (m *ExpiringMap[K, V]) snapshot() ([]K, []V) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	now := m.now()
+	keys := make([]K, 0, len(m.items))
+	values := make([]V, 0, len(m.items))
+	for key, entry := range m.items {
+		if !entry.expired(now) {
+			keys = append(keys, key)
+			values = append(values, entry.value)
+		}
+	}
+	return keys, values
+}
+
+// All returns an iterator over the live entries, it iterates over a snapshot,
+// so it is allowed to modify the map while iterating
+func (m *ExpiringMap[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		keys, values := m.snapshot()
+		for index, key := range keys {
+			if !yield(key, values[index]) {
+				return
+			}
+		}
+	}
+}
+
+// ToMap returns a map of the live entries
+func (m *ExpiringMap[K, V]) ToMap() map[K]V {
+	keys, values := m.snapshot()
+	items := make(map[K]V, len(keys))
+	for index, key := range keys {
+		items[key] = values[index]
+	}
+	return items
+}
+
+// SetPreviewLimit sets the number of entries shown by String, %v and LogValue, a negative limit shows all entries
+func (m *ExpiringMap[K, V]) SetPreviewLimit(limit int) {
+	m.limit.Set(limit)
+}
+
+// String converts to string, only the preview limit of live entries are shown
+func (m *ExpiringMap[K, V]) String() string {
+	return m.format(m.limit.Get(-1))
+}
+
+// Format implements [fmt.Formatter], %v prints the preview as String does, %+v and %#v print all live entries
+func (m *ExpiringMap[K, V]) Format(f fmt.State, verb rune) {
+	preview.Format(f, verb, m.format, m.limit.Get(-1))
+}
+
+func (m *ExpiringMap[K, V]) format(limit int) string {
+	keys, values := m.snapshot()
+	return preview.Entries(fmt.Sprintf("ExpiringMap[%T, %T](len=%d)", *new(K), *new(V), len(keys)), keys, values, limit)
+}
+
+// LogValue implements [slog.LogValuer], only the preview limit of live entries are logged
+func (m *ExpiringMap[K, V]) LogValue() slog.Value {
+	keys, values := m.snapshot()
+	return preview.LogEntries(fmt.Sprintf("ExpiringMap[%T, %T]", *new(K), *new(V)), len(keys), keys, values, m.limit.Get(preview.DefaultLimit))
+}
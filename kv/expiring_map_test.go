@@ -0,0 +1,117 @@
+package kv
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpiringMap_Get(t *testing.T) {
+	clock := &_clock{now: time.Unix(1000, 0)}
+	m := NewExpiringMapWithClock[string, int](time.Second, clock.Now)
+	var expired []string
+	m.OnExpire(func(key string, value int) {
+		expired = append(expired, fmt.Sprintf("%s:%d", key, value))
+		assert.EqualValues(t, 0, m.Count())
+	})
+	m.Set("a", 1)
+	value, ok := m.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 1, value)
+	clock.now = clock.now.Add(time.Second)
+	_, ok = m.Get("a")
+	assert.False(t, ok)
+	assert.Equal(t, []string{"a:1"}, expired)
+	assert.Equal(t, 2, m.GetOr("a", 2))
+}
+
+func TestExpiringMap_SetWithTTL(t *testing.T) {
+	clock := &_clock{now: time.Unix(1000, 0)}
+	m := NewExpiringMapWithClock[string, int](time.Second, clock.Now)
+	m.SetWithTTL("a", 1, 2*time.Second)
+	m.SetWithTTL("b", 2, 0)
+	m.Set("c", 3)
+	deadline, ok := m.ExpiresAt("a")
+	assert.True(t, ok)
+	assert.Equal(t, time.Unix(1002, 0), deadline)
+	deadline, ok = m.ExpiresAt("b")
+	assert.True(t, ok)
+	assert.True(t, deadline.IsZero())
+	clock.now = clock.now.Add(time.Second)
+	assert.False(t, m.ContainsKey("c"))
+	assert.ElementsMatch(t, []string{"a", "b"}, m.Keys())
+	assert.Equal(t, map[string]int{"a": 1, "b": 2}, m.ToMap())
+	assert.EqualValues(t, 3, m.Count())
+	clock.now = clock.now.Add(time.Hour)
+	assert.Equal(t, []int{2}, m.Values())
+}
+
+func TestExpiringMap_Remove(t *testing.T) {
+	clock := &_clock{now: time.Unix(1000, 0)}
+	m := NewExpiringMapWithClock[string, int](time.Second, clock.Now)
+	m.OnExpire(func(key string, value int) {
+		assert.Fail(t, "removed entries are not expired")
+	})
+	m.Set("a", 1)
+	m.Set("b", 2)
+	assert.True(t, m.Remove("a"))
+	assert.False(t, m.Remove("a"))
+	clock.now = clock.now.Add(time.Second)
+	assert.False(t, m.Remove("b"))
+	m.Set("c", 3)
+	m.Clear()
+	assert.True(t, m.IsEmpty())
+}
+
+func TestExpiringMap_Purge(t *testing.T) {
+	clock := &_clock{now: time.Unix(1000, 0)}
+	m := NewExpiringMapWithClock[int, int](0, clock.Now)
+	var expired []int
+	m.OnExpire(func(key int, value int) {
+		expired = append(expired, key)
+	})
+	for key := range 4 {
+		m.SetWithTTL(key, key, time.Duration(key)*time.Second)
+	}
+	clock.now = clock.now.Add(2 * time.Second)
+	assert.Equal(t, 2, m.Purge())
+	assert.ElementsMatch(t, []int{1, 2}, expired)
+	assert.ElementsMatch(t, []int{0, 3}, m.Keys())
+	assert.Equal(t, 0, m.Purge())
+}
+
+func TestExpiringMap_StartReaper(t *testing.T) {
+	m := NewExpiringMap[string, int](10 * time.Millisecond)
+	expired := make(chan string, 1)
+	m.OnExpire(func(key string, value int) {
+		expired <- key
+	})
+	assert.PanicsWithError(t, "kv: reaper interval 0s is not positive", func() {
+		m.StartReaper(0)
+	})
+	m.StartReaper(time.Hour)
+	m.StartReaper(5 * time.Millisecond)
+	defer m.Stop()
+	m.Set("a", 1)
+	m.SetWithTTL("b", 2, 0)
+	select {
+	case key := <-expired:
+		assert.Equal(t, "a", key)
+	case <-time.After(time.Second):
+		assert.Fail(t, "the reaper did not purge the expired entry")
+	}
+	assert.EqualValues(t, 1, m.Count())
+	m.Stop()
+	m.Stop()
+}
+
+func TestExpiringMap_String(t *testing.T) {
+	clock := &_clock{now: time.Unix(1000, 0)}
+	m := NewExpiringMapWithClock[string, int](time.Second, clock.Now)
+	m.Set("a", 1)
+	assert.Equal(t, "ExpiringMap[string, int](len=1){\n\ta: 1,\n}", m.String())
+	clock.now = clock.now.Add(time.Second)
+	assert.Equal(t, "ExpiringMap[string, int](len=0){\n}", m.String())
+}
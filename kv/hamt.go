@@ -0,0 +1,174 @@
+package kv
+
+import (
+	"math/bits"
+	"slices"
+)
+
+const (
+	hamtBits  = 5
+	hamtWidth = 1 << hamtBits
+)
+
+// hamtNode node of a hash array mapped trie, it is immutable once built.
+// Each level takes the next 5 bits of the hash, the bitmap marks which of the 32 slots are used
+// and the entries of the used slots are kept in slot order. A node below the last bit of the hash
+// holds the keys whose hashes collide as a plain list.
+type hamtNode[K comparable, V any] struct {
+	bitmap  uint32
+	entries []hamtEntry[K, V]
+}
+
+// hamtEntry is either a key with its value or a child node
+type hamtEntry[K comparable, V any] struct {
+	hash  uint64
+	key   K
+	value V
+	child *hamtNode[K, V]
+}
+
+// hamtSlot returns the bit of the slot of the hash at the shift and the index of the slot in the entries
+func (n *hamtNode[K, V]) hamtSlot(hash uint64, shift uint) (uint32, int) {
+	bit := uint32(1) << ((hash >> shift) & (hamtWidth - 1))
+	return bit, bits.OnesCount32(n.bitmap & (bit - 1))
+}
+
+func (n *hamtNode[K, V]) get(hash uint64, shift uint, key K) (V, bool) {
+	for n != nil {
+		if shift >= 64 {
+			for _, e := range n.entries {
+				if e.key == key {
+					return e.value, true
+				}
+			}
+			break
+		}
+		bit, index := n.hamtSlot(hash, shift)
+		if n.bitmap&bit == 0 {
+			break
+		}
+		e := &n.entries[index]
+		if e.child == nil {
+			if e.hash == hash && e.key == key {
+				return e.value, true
+			}
+			break
+		}
+		n, shift = e.child, shift+hamtBits
+	}
+	return *new(V), false
+}
+
+// set returns a copy of the path to the key with the value set, and whether the key is new
+func (n *hamtNode[K, V]) set(hash uint64, shift uint, key K, value V) (*hamtNode[K, V], bool) {
+	entry := hamtEntry[K, V]{hash: hash, key: key, value: value}
+	if n == nil {
+		n = new(hamtNode[K, V])
+	}
+	if shift >= 64 {
+		for index, e := range n.entries {
+			if e.key == key {
+				entries := slices.Clone(n.entries)
+				entries[index] = entry
+				return &hamtNode[K, V]{entries: entries}, false
+			}
+		}
+		return &hamtNode[K, V]{entries: append(slices.Clone(n.entries), entry)}, true
+	}
+	bit, index := n.hamtSlot(hash, shift)
+	if n.bitmap&bit == 0 {
+		return &hamtNode[K, V]{bitmap: n.bitmap | bit, entries: slices.Insert(slices.Clone(n.entries), index, entry)}, true
+	}
+	entries := slices.Clone(n.entries)
+	e := entries[index]
+	added := false
+	switch {
+	case e.child != nil:
+		entries[index] = hamtEntry[K, V]{}
+		entries[index].child, added = e.child.set(hash, shift+hamtBits, key, value)
+	case e.hash == hash && e.key == key:
+		entries[index] = entry
+	default:
+		entries[index] = hamtEntry[K, V]{child: mergeHAMT(e, entry, shift+hamtBits)}
+		added = true
+	}
+	return &hamtNode[K, V]{bitmap: n.bitmap, entries: entries}, added
+}
+
+// mergeHAMT returns a node with the two keys, which share the slots above the shift
+func mergeHAMT[K comparable, V any](a, b hamtEntry[K, V], shift uint) *hamtNode[K, V] {
+	if shift >= 64 {
+		return &hamtNode[K, V]{entries: []hamtEntry[K, V]{a, b}}
+	}
+	slotA, slotB := (a.hash>>shift)&(hamtWidth-1), (b.hash>>shift)&(hamtWidth-1)
+	if slotA == slotB {
+		return &hamtNode[K, V]{bitmap: 1 << slotA, entries: []hamtEntry[K, V]{{child: mergeHAMT(a, b, shift+hamtBits)}}}
+	}
+	if slotA > slotB {
+		a, b = b, a
+	}
+	return &hamtNode[K, V]{bitmap: 1<<slotA | 1<<slotB, entries: []hamtEntry[K, V]{a, b}}
+}
+
+// remove returns a copy of the path to the key without it, nil when the node is left empty, and whether the key was found
+func (n *hamtNode[K, V]) remove(hash uint64, shift uint, key K) (*hamtNode[K, V], bool) {
+	if n == nil {
+		return nil, false
+	}
+	if shift >= 64 {
+		index := slices.IndexFunc(n.entries, func(e hamtEntry[K, V]) bool {
+			return e.key == key
+		})
+		if index < 0 {
+			return n, false
+		}
+		if len(n.entries) == 1 {
+			return nil, true
+		}
+		return &hamtNode[K, V]{entries: slices.Delete(slices.Clone(n.entries), index, index+1)}, true
+	}
+	bit, index := n.hamtSlot(hash, shift)
+	if n.bitmap&bit == 0 {
+		return n, false
+	}
+	e := n.entries[index]
+	var child *hamtNode[K, V]
+	if e.child != nil {
+		var removed bool
+		if child, removed = e.child.remove(hash, shift+hamtBits, key); !removed {
+			return n, false
+		}
+	} else if e.hash != hash || e.key != key {
+		return n, false
+	}
+	if child == nil {
+		if len(n.entries) == 1 {
+			return nil, true
+		}
+		return &hamtNode[K, V]{bitmap: n.bitmap &^ bit, entries: slices.Delete(slices.Clone(n.entries), index, index+1)}, true
+	}
+	entries := slices.Clone(n.entries)
+	if len(child.entries) == 1 && child.entries[0].child == nil {
+		entries[index] = child.entries[0]
+	} else {
+		entries[index] = hamtEntry[K, V]{child: child}
+	}
+	return &hamtNode[K, V]{bitmap: n.bitmap, entries: entries}, true
+}
+
+// each calls the callback with the keys and the values until it returns false
+func (n *hamtNode[K, V]) each(callback func(key K, value V) bool) bool {
+	if n == nil {
+		return true
+	}
+	for _, e := range n.entries {
+		if e.child != nil {
+			if !e.child.each(callback) {
+				return false
+			}
+		} else if !callback(e.key, e.value) {
+			return false
+		}
+	}
+	return true
+}
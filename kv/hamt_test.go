@@ -0,0 +1,74 @@
+package kv
+
+import (
+	"maps"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHAMT_Collisions(t *testing.T) {
+	var root *hamtNode[string, int]
+	root, _ = root.set(42, 0, "a", 1)
+	root, added := root.set(42, 0, "b", 2)
+	assert.True(t, added)
+	root, added = root.set(42|1<<40, 0, "c", 3)
+	assert.True(t, added)
+	root, added = root.set(42, 0, "b", 20)
+	assert.False(t, added)
+
+	value, ok := root.get(42, 0, "b")
+	assert.True(t, ok)
+	assert.Equal(t, 20, value)
+	_, ok = root.get(42, 0, "c")
+	assert.False(t, ok)
+
+	root, removed := root.remove(42, 0, "a")
+	assert.True(t, removed)
+	_, removed = root.remove(42, 0, "a")
+	assert.False(t, removed)
+	value, ok = root.get(42|1<<40, 0, "c")
+	assert.True(t, ok)
+	assert.Equal(t, 3, value)
+	_, ok = root.get(42, 0, "b")
+	assert.True(t, ok)
+
+	root, _ = root.remove(42|1<<40, 0, "c")
+	root, _ = root.remove(42, 0, "b")
+	assert.Nil(t, root)
+}
+
+func TestHAMT_Random(t *testing.T) {
+	var root *hamtNode[int, int]
+	expected := map[int]int{}
+	for i := range 5000 {
+		key := rand.IntN(1000)
+		// a narrow hash forces deep paths and collisions
+		h := uint64(key % 300)
+		if rand.IntN(3) == 0 {
+			var removed bool
+			root, removed = root.remove(h, 0, key)
+			_, ok := expected[key]
+			assert.Equal(t, ok, removed)
+			delete(expected, key)
+		} else {
+			var added bool
+			root, added = root.set(h, 0, key, i)
+			_, ok := expected[key]
+			assert.Equal(t, !ok, added)
+			expected[key] = i
+		}
+	}
+	actual := map[int]int{}
+	root.each(func(key, value int) bool {
+		actual[key] = value
+		return true
+	})
+	assert.True(t, maps.Equal(expected, actual))
+	for key, value := range expected {
+		v, ok := root.get(uint64(key%300), 0, key)
+		assert.True(t, ok)
+		assert.Equal(t, value, v)
+	}
+}
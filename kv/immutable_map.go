@@ -0,0 +1,179 @@
+package kv
+
+import (
+	"encoding/json"
+	"fmt"
+	"iter"
+	"log/slog"
+
+	"github.com/gopi-frame/collection/internal/hash"
+	"github.com/gopi-frame/collection/internal/preview"
+)
+
+// NewImmutableMap new immutable map
+func NewImmutableMap[K comparable, V any]() *ImmutableMap[K, V] {
+	return new(ImmutableMap[K, V])
+}
+
+// NewImmutableMapFromMap new immutable map of the entries of the map, the map is copied
+func NewImmutableMapFromMap[K comparable, V any](items map[K]V) *ImmutableMap[K, V] {
+	m := new(ImmutableMap[K, V])
+	for key, value := range items {
+		m.root, _ = m.root.set(hash.Value(key), 0, key, value)
+	}
+	m.size = int64(len(items))
+	return m
+}
+
+// ImmutableMap map whose mutation methods return a new map and leave the map itself unchanged,
+// so it can be shared across goroutines without locks or defensive copies.
+// It is a hash array mapped trie as the generations of [VersionedMap], Set and Remove take O(log32 n) time
+// and share all but the changed path with the previous map.
+type ImmutableMap[K comparable, V any] struct {
+	root  *hamtNode[K, V]
+	size  int64
+	limit preview.Limit
+}
+
+// Count returns the size of map
+func (m *ImmutableMap[K, V]) Count() int64 {
+	return m.size
+}
+
+// IsEmpty returns whether the map is empty
+func (m *ImmutableMap[K, V]) IsEmpty() bool {
+	return m.size == 0
+}
+
+// IsNotEmpty returns whether the map is not empty
+func (m *ImmutableMap[K, V]) IsNotEmpty() bool {
+	return !m.IsEmpty()
+}
+
+// Get returns the value of the specific key
+func (m *ImmutableMap[K, V]) Get(key K) (V, bool) {
+	return m.root.get(hash.Value(key), 0, key)
+}
+
+// GetOr returns the value of the specific key, or the default value when the key is not exist
+func (m *ImmutableMap[K, V]) GetOr(key K, value V) V {
+	if v, ok := m.Get(key); ok {
+		return v
+	}
+	return value
+}
+
+// ContainsKey returns whether the map contains the specific key
+func (m *ImmutableMap[K, V]) ContainsKey(key K) bool {
+	_, ok := m.Get(key)
+	return ok
+}
+
+// Set returns a new map with the value set to the specific key
+func (m *ImmutableMap[K, V]) Set(key K, value V) *ImmutableMap[K, V] {
+	root, added := m.root.set(hash.Value(key), 0, key, value)
+	size := m.size
+	if added {
+		size++
+	}
+	return &ImmutableMap[K, V]{root: root, size: size}
+}
+
+// Remove returns a new map without the specific key, it returns the map itself when the key is not exist
+func (m *ImmutableMap[K, V]) Remove(key K) *ImmutableMap[K, V] {
+	root, removed := m.root.remove(hash.Value(key), 0, key)
+	if !removed {
+		return m
+	}
+	return &ImmutableMap[K, V]{root: root, size: m.size - 1}
+}
+
+// Keys returns all keys
+func (m *ImmutableMap[K, V]) Keys() []K {
+	keys, _ := m.entries()
+	return keys
+}
+
+// Values returns all values
+func (m *ImmutableMap[K, V]) Values() []V {
+	_, values := m.entries()
+	return values
+}
+
+func (m *ImmutableMap[K, V]) entries() ([]K, []V) {
+	keys := make([]K, 0, m.size)
+	values := make([]V, 0, m.size)
+	for key, value := range m.All() {
+		keys = append(keys, key)
+		values = append(values, value)
+	}
+	return keys, values
+}
+
+// Each travers the map, if the callback returns false then break
+func (m *ImmutableMap[K, V]) Each(callback func(key K, value V) bool) {
+	m.root.each(callback)
+}
+
+// All returns an iterator over the keys and the values
+func (m *ImmutableMap[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		m.root.each(yield)
+	}
+}
+
+// ToMap converts to map, the returned map is a copy, so modifying it does not affect the collection
+func (m *ImmutableMap[K, V]) ToMap() map[K]V {
+	items := make(map[K]V, m.size)
+	for key, value := range m.All() {
+		items[key] = value
+	}
+	return items
+}
+
+// ToJSON converts to json
+func (m *ImmutableMap[K, V]) ToJSON() ([]byte, error) {
+	return json.Marshal(m.ToMap())
+}
+
+// MarshalJSON implements [json.Marshaller]
+func (m *ImmutableMap[K, V]) MarshalJSON() ([]byte, error) {
+	return m.ToJSON()
+}
+
+// UnmarshalJSON implements [json.Unmarshaller], it decodes into the map itself, so it must not be shared yet
+func (m *ImmutableMap[K, V]) UnmarshalJSON(data []byte) error {
+	items := map[K]V{}
+	if err := json.Unmarshal(data, &items); err != nil {
+		return err
+	}
+	decoded := NewImmutableMapFromMap(items)
+	m.root, m.size = decoded.root, decoded.size
+	return nil
+}
+
+// SetPreviewLimit sets the number of entries shown by String, %v and LogValue, a negative limit shows all entries
+func (m *ImmutableMap[K, V]) SetPreviewLimit(limit int) {
+	m.limit.Set(limit)
+}
+
+// String converts to string, only the preview limit of entries are shown
+func (m *ImmutableMap[K, V]) String() string {
+	return m.format(m.limit.Get(-1))
+}
+
+// Format implements [fmt.Formatter], %v prints the preview as String does, %+v and %#v print all entries
+func (m *ImmutableMap[K, V]) Format(f fmt.State, verb rune) {
+	preview.Format(f, verb, m.format, m.limit.Get(-1))
+}
+
+func (m *ImmutableMap[K, V]) format(limit int) string {
+	keys, values := m.entries()
+	return preview.Entries(fmt.Sprintf("ImmutableMap[%T, %T](len=%d)", *new(K), *new(V), len(keys)), keys, values, limit)
+}
+
+// LogValue implements [slog.LogValuer], only the preview limit of entries are logged
+func (m *ImmutableMap[K, V]) LogValue() slog.Value {
+	keys, values := m.entries()
+	return preview.LogEntries(fmt.Sprintf("ImmutableMap[%T, %T]", *new(K), *new(V)), len(keys), keys, values, m.limit.Get(preview.DefaultLimit))
+}
@@ -0,0 +1,67 @@
+package kv
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImmutableMap_Set(t *testing.T) {
+	m := NewImmutableMap[string, int]()
+	a := m.Set("a", 1)
+	b := a.Set("b", 2).Set("a", 3)
+	assert.True(t, m.IsEmpty())
+	assert.Equal(t, map[string]int{"a": 1}, a.ToMap())
+	assert.Equal(t, map[string]int{"a": 3, "b": 2}, b.ToMap())
+	assert.EqualValues(t, 2, b.Count())
+	value, ok := b.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 3, value)
+	assert.Equal(t, 0, b.GetOr("c", 0))
+}
+
+func TestImmutableMap_Remove(t *testing.T) {
+	m := NewImmutableMapFromMap(map[string]int{"a": 1, "b": 2})
+	removed := m.Remove("a")
+	assert.Equal(t, map[string]int{"b": 2}, removed.ToMap())
+	assert.True(t, m.ContainsKey("a"))
+	assert.Same(t, removed, removed.Remove("a"))
+	assert.EqualValues(t, 1, removed.Count())
+}
+
+func TestImmutableMap_All(t *testing.T) {
+	m := NewImmutableMap[int, int]()
+	for i := range 100 {
+		m = m.Set(i, i*i)
+	}
+	assert.Len(t, m.Keys(), 100)
+	assert.Len(t, m.Values(), 100)
+	count := 0
+	for key, value := range m.All() {
+		assert.Equal(t, key*key, value)
+		count++
+	}
+	assert.Equal(t, 100, count)
+	count = 0
+	m.Each(func(key int, value int) bool {
+		count++
+		return count < 10
+	})
+	assert.Equal(t, 10, count)
+}
+
+func TestImmutableMap_MarshalJSON(t *testing.T) {
+	data, err := json.Marshal(NewImmutableMap[string, int]().Set("a", 1))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"a":1}`, string(data))
+	decoded := NewImmutableMap[string, int]()
+	assert.NoError(t, json.Unmarshal(data, decoded))
+	assert.Equal(t, map[string]int{"a": 1}, decoded.ToMap())
+	assert.EqualValues(t, 1, decoded.Count())
+}
+
+func TestImmutableMap_String(t *testing.T) {
+	m := NewImmutableMap[string, int]().Set("a", 1)
+	assert.Equal(t, "ImmutableMap[string, int](len=1){\n\ta: 1,\n}", m.String())
+}
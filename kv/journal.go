@@ -0,0 +1,183 @@
+package kv
+
+import (
+	"fmt"
+	"io"
+)
+
+// OpKind kind of a map mutation
+type OpKind string
+
+const (
+	// OpSet sets the value of the key
+	OpSet OpKind = "set"
+	// OpRemove removes the key
+	OpRemove OpKind = "remove"
+	// OpClear removes all keys
+	OpClear OpKind = "clear"
+)
+
+// Op mutation of a map recorded by [JournalMap], Key and Value are zero when they are not used by the kind.
+// Ops can be serialized, and replayed onto another map with [Map.Replay].
+type Op[K comparable, V any] struct {
+	Kind  OpKind `json:"kind"`
+	Key   K      `json:"key"`
+	Value V      `json:"value"`
+}
+
+// NewJournalMap new journal map, journal is called with the op of each mutation in the order they are made
+func NewJournalMap[K comparable, V any](journal func(op Op[K, V])) *JournalMap[K, V] {
+	return &JournalMap[K, V]{
+		Map:     NewMap[K, V](),
+		journal: journal,
+	}
+}
+
+// JournalMap map which records its mutations as ops, so they can be replayed onto another map,
+// which has the same entries as the journal map had when the journal started.
+// Replacing the entries, such as FromMap and the decoders do, is recorded as a clear followed by the sets.
+// The journal is called while the map is locked, so it must not call methods of the same map.
+// All methods are safe for concurrent use, the methods which are not listed here are the ones of [Map].
+type JournalMap[K comparable, V any] struct {
+	*Map[K, V]
+	journal func(op Op[K, V])
+}
+
+// Set sets element to the specific key
+func (m *JournalMap[K, V]) Set(key K, value V) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.set(key, value)
+}
+
+// Remove removes the element of specific key
+func (m *JournalMap[K, V]) Remove(key K) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if _, ok := m.items[key]; ok {
+		m.delete(key)
+		m.journal(Op[K, V]{Kind: OpRemove, Key: key})
+	}
+}
+
+// Clear clears the map
+func (m *JournalMap[K, V]) Clear() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.items = make(map[K]V)
+	m.peak = 0
+	m.journal(Op[K, V]{Kind: OpClear})
+}
+
+// FromMap replaces the items of the map with the given map, the map is used as is without copying,
+// so the caller must not modify it afterwards
+func (m *JournalMap[K, V]) FromMap(items map[K]V) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.replace(items)
+}
+
+func (m *JournalMap[K, V]) replace(items map[K]V) {
+	m.items = items
+	m.journal(Op[K, V]{Kind: OpClear})
+	for key, value := range items {
+		m.journal(Op[K, V]{Kind: OpSet, Key: key, Value: value})
+	}
+}
+
+// decode decodes into a new map and records the replacement of the entries with its entries
+func (m *JournalMap[K, V]) decode(decode func(m *Map[K, V]) error) error {
+	decoded := NewMap[K, V]()
+	if err := decode(decoded); err != nil {
+		return err
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.replace(decoded.items)
+	return nil
+}
+
+// UnmarshalJSON implements [json.Unmarshaller], the replacement of the entries is recorded
+func (m *JournalMap[K, V]) UnmarshalJSON(data []byte) error {
+	return m.decode(func(decoded *Map[K, V]) error {
+		return decoded.UnmarshalJSON(data)
+	})
+}
+
+// DecodeJSON reads a json object from r one entry at a time and replaces the entries of the map,
+// the replacement is recorded
+func (m *JournalMap[K, V]) DecodeJSON(r io.Reader) error {
+	return m.decode(func(decoded *Map[K, V]) error {
+		return decoded.DecodeJSON(r)
+	})
+}
+
+// UnmarshalCBOR implements [cbor.Unmarshaler], the replacement of the entries is recorded
+func (m *JournalMap[K, V]) UnmarshalCBOR(data []byte) error {
+	return m.decode(func(decoded *Map[K, V]) error {
+		return decoded.UnmarshalCBOR(data)
+	})
+}
+
+// UnmarshalBinary implements [encoding.BinaryUnmarshaler], the replacement of the entries is recorded
+func (m *JournalMap[K, V]) UnmarshalBinary(data []byte) error {
+	return m.decode(func(decoded *Map[K, V]) error {
+		return decoded.UnmarshalBinary(data)
+	})
+}
+
+// Replay applies the ops in order and records them as the other mutations are, so a remove of a missing key is not recorded,
+// the map is left unchanged when an op has an unknown kind
+func (m *JournalMap[K, V]) Replay(ops ...Op[K, V]) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if err := checkOps(ops); err != nil {
+		return err
+	}
+	for _, op := range ops {
+		if _, ok := m.items[op.Key]; op.Kind == OpRemove && !ok {
+			continue
+		}
+		m.apply(op)
+		m.journal(op)
+	}
+	return nil
+}
+
+// Replay applies the ops in order, the map is left unchanged when an op has an unknown kind
+func (m *Map[K, V]) Replay(ops ...Op[K, V]) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if err := checkOps(ops); err != nil {
+		return err
+	}
+	for _, op := range ops {
+		m.apply(op)
+	}
+	return nil
+}
+
+// apply applies the op, its kind must be known
+func (m *Map[K, V]) apply(op Op[K, V]) {
+	switch op.Kind {
+	case OpSet:
+		m.items[op.Key] = op.Value
+	case OpRemove:
+		m.delete(op.Key)
+	case OpClear:
+		m.items = make(map[K]V)
+		m.peak = 0
+	}
+}
+
+// checkOps returns an error for the first op which has an unknown kind
+func checkOps[K comparable, V any](ops []Op[K, V]) error {
+	for index, op := range ops {
+		switch op.Kind {
+		case OpSet, OpRemove, OpClear:
+		default:
+			return fmt.Errorf("kv: op %d has unknown kind %q", index, op.Kind)
+		}
+	}
+	return nil
+}
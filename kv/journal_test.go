@@ -0,0 +1,70 @@
+package kv
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJournalMap_Replay(t *testing.T) {
+	var ops []Op[string, int]
+	m := NewJournalMap(func(op Op[string, int]) {
+		ops = append(ops, op)
+	})
+	m.Set("a", 1)
+	m.Set("b", 2)
+	m.Remove("a")
+	m.Remove("c")
+	m.Set("b", 3)
+	assert.Len(t, ops, 4)
+	assert.Nil(t, json.Unmarshal([]byte(`{"d":4}`), m))
+	m.Set("e", 5)
+
+	data, err := json.Marshal(ops)
+	assert.Nil(t, err)
+	var decoded []Op[string, int]
+	assert.Nil(t, json.Unmarshal(data, &decoded))
+	replica := NewMap[string, int]()
+	replica.Set("x", 0)
+	assert.Nil(t, replica.Replay(decoded...))
+	assert.Equal(t, map[string]int{"d": 4, "e": 5}, replica.ToMap())
+	assert.Equal(t, m.ToMap(), replica.ToMap())
+
+	t.Run("journal map", func(t *testing.T) {
+		var replayed []Op[string, int]
+		replica := NewJournalMap(func(op Op[string, int]) {
+			replayed = append(replayed, op)
+		})
+		assert.Nil(t, replica.Replay(decoded...))
+		assert.Equal(t, m.ToMap(), replica.ToMap())
+		assert.Equal(t, decoded, replayed)
+		assert.Error(t, replica.Replay(Op[string, int]{Kind: OpClear}, Op[string, int]{Kind: "move"}))
+		assert.Equal(t, m.ToMap(), replica.ToMap())
+		assert.Len(t, replayed, len(decoded))
+	})
+}
+
+func TestJournalMap_Clear(t *testing.T) {
+	var ops []Op[int, int]
+	m := NewJournalMap(func(op Op[int, int]) {
+		ops = append(ops, op)
+	})
+	m.Set(1, 1)
+	m.Clear()
+	m.FromMap(map[int]int{2: 2})
+	assert.Equal(t, []Op[int, int]{
+		{Kind: OpSet, Key: 1, Value: 1},
+		{Kind: OpClear},
+		{Kind: OpClear},
+		{Kind: OpSet, Key: 2, Value: 2},
+	}, ops)
+}
+
+func TestMap_Replay(t *testing.T) {
+	m := NewMap[int, int]()
+	m.Set(1, 1)
+	err := m.Replay(Op[int, int]{Kind: OpRemove, Key: 1}, Op[int, int]{Kind: "move"})
+	assert.EqualError(t, err, `kv: op 1 has unknown kind "move"`)
+	assert.Equal(t, map[int]int{1: 1}, m.ToMap())
+}
@@ -1,13 +1,21 @@
 package kv
 
 import (
+	"bufio"
 	"encoding/json"
 	"fmt"
-	"strings"
-	"sync"
+	"io"
+	"iter"
+	"log/slog"
+	"maps"
 
+	"github.com/fxamacker/cbor/v2"
+	"github.com/gopi-frame/collection"
+	"github.com/gopi-frame/collection/internal/codec"
+	"github.com/gopi-frame/collection/internal/debug"
+	"github.com/gopi-frame/collection/internal/jsonstream"
+	"github.com/gopi-frame/collection/internal/preview"
 	"github.com/gopi-frame/collection/list"
-	"github.com/gopi-frame/contract"
 )
 
 type jsonObject[K comparable, V any] struct {
@@ -23,28 +31,40 @@ func NewLinkedMap[K comparable, V any]() *LinkedMap[K, V] {
 	return m
 }
 
-// LinkedMap linked map
+// LinkedMap linked map, all methods are safe for concurrent use.
+// The callbacks run while the map is locked, so they must not call methods of the same map,
+// except the callback of Each which runs on a snapshot.
 type LinkedMap[K comparable, V any] struct {
-	sync.RWMutex
 	*Map[K, V]
 	keys *list.LinkedList[K]
 }
 
-// Set sets value to specific key.
+// Set sets value to specific key, an existing key keeps its position.
 func (m *LinkedMap[K, V]) Set(key K, value V) {
-	m.Map.Set(key, value)
-	m.keys.Push(key)
+	m.lock.Lock()
+	defer m.unlock()
+	if _, ok := m.items[key]; !ok {
+		m.keys.Push(key)
+	}
+	m.items[key] = value
 }
 
 // Remove removes specific key.
 func (m *LinkedMap[K, V]) Remove(key K) {
-	m.Map.Remove(key)
+	m.lock.Lock()
+	defer m.unlock()
+	if _, ok := m.items[key]; !ok {
+		return
+	}
+	m.delete(key)
 	m.keys.Remove(key)
 }
 
 // First returns the first value of the map.
 // It will return zero value and false if the map is empty
 func (m *LinkedMap[K, V]) First() (V, bool) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
 	if len(m.items) == 0 {
 		return *new(V), false
 	}
@@ -64,6 +84,8 @@ func (m *LinkedMap[K, V]) FirstOr(value V) V {
 // Last returns the last value of the map.
 // It will return zero value and false if the map is empty
 func (m *LinkedMap[K, V]) Last() (V, bool) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
 	if len(m.items) == 0 {
 		return *new(V), false
 	}
@@ -82,57 +104,161 @@ func (m *LinkedMap[K, V]) LastOr(value V) V {
 
 // Keys returns all keys
 func (m *LinkedMap[K, V]) Keys() []K {
-	var keys []K
-	m.keys.Each(func(index int, value K) bool {
-		keys = append(keys, value)
-		return true
-	})
-	return keys
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return m.keys.ToArray()
 }
 
 // Values returns all values
 func (m *LinkedMap[K, V]) Values() []V {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
 	var values []V
-	m.keys.Each(func(index int, value K) bool {
-		values = append(values, m.items[value])
-		return true
-	})
+	for _, key := range m.keys.ToArray() {
+		values = append(values, m.items[key])
+	}
 	return values
 }
 
 // Clear clears map.
 func (m *LinkedMap[K, V]) Clear() {
+	m.lock.Lock()
+	defer m.unlock()
 	m.items = make(map[K]V)
+	m.peak = 0
 	m.keys.Clear()
 }
 
-// ContainsKey returns whether the map contains specific key.
-func (m *LinkedMap[K, V]) ContainsKey(key K) bool {
-	for k := range m.items {
-		if k == key {
-			return true
+// Replay applies the ops in order, a set of a new key adds it at the end of the order and a remove takes it out,
+// the map is left unchanged when an op has an unknown kind
+func (m *LinkedMap[K, V]) Replay(ops ...Op[K, V]) error {
+	m.lock.Lock()
+	defer m.unlock()
+	if err := checkOps(ops); err != nil {
+		return err
+	}
+	for _, op := range ops {
+		_, ok := m.items[op.Key]
+		switch {
+		case op.Kind == OpSet && !ok:
+			m.keys.Push(op.Key)
+		case op.Kind == OpRemove && ok:
+			m.keys.Remove(op.Key)
+		case op.Kind == OpClear:
+			m.keys.Clear()
 		}
+		m.apply(op)
+	}
+	return nil
+}
+
+// EnableNodePool makes the map reuse the nodes of its key order through a [sync.Pool],
+// see [list.LinkedList.EnableNodePool]
+func (m *LinkedMap[K, V]) EnableNodePool() {
+	m.lock.Lock()
+	defer m.unlock()
+	m.keys.EnableNodePool()
+}
+
+// Release clears the map and gives the nodes of its key order back to the pool,
+// it works as Clear when the pool is not enabled
+func (m *LinkedMap[K, V]) Release() {
+	m.lock.Lock()
+	defer m.unlock()
+	m.items = make(map[K]V)
+	m.peak = 0
+	m.keys.Release()
+}
+
+// validate checks that each entry has exactly one key in the key order
+func (m *LinkedMap[K, V]) validate() error {
+	keys := m.keys.ToArray()
+	if len(keys) != len(m.items) {
+		return fmt.Errorf("map has %d keys in order, expected %d", len(keys), len(m.items))
+	}
+	seen := make(map[K]struct{}, len(keys))
+	for _, key := range keys {
+		if _, ok := m.items[key]; !ok {
+			return fmt.Errorf("key %v in order has no entry", key)
+		}
+		if _, ok := seen[key]; ok {
+			return fmt.Errorf("key %v is in order more than once", key)
+		}
+		seen[key] = struct{}{}
+	}
+	return nil
+}
+
+// unlock unlocks the map after a mutation, the invariants are checked first in debug builds
+func (m *LinkedMap[K, V]) unlock() {
+	if debug.Enabled {
+		debug.Check(m, m.validate())
 	}
-	return false
+	m.lock.Unlock()
+}
+
+// resetKeys empties the key order, the nodes go back to the pool when it is enabled
+func (m *LinkedMap[K, V]) resetKeys() {
+	if m.keys == nil {
+		m.keys = list.NewLinkedList[K]()
+		return
+	}
+	m.keys.Release()
+}
+
+// ContainsKey returns whether the map contains specific key.
+func (m *LinkedMap[K, V]) ContainsKey(key K) bool {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	_, ok := m.items[key]
+	return ok
 }
 
 // Reverse reverses the map
 func (m *LinkedMap[K, V]) Reverse() *LinkedMap[K, V] {
+	m.lock.Lock()
+	defer m.unlock()
 	m.keys.Reverse()
 	return m
 }
 
-// Each travers the map and break when callback returns false
+// Each travers the map and break when callback returns false.
+// The callback runs on a snapshot of the map, so it is allowed to modify the map.
 func (m *LinkedMap[K, V]) Each(callback func(key K, value V) bool) {
-	m.keys.Each(func(index int, value K) bool {
-		return callback(value, m.items[value])
-	})
+	keys, values := m.snapshot()
+	for index, key := range keys {
+		if !callback(key, values[index]) {
+			break
+		}
+	}
+}
+
+func (m *LinkedMap[K, V]) snapshot() ([]K, []V) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	keys := m.keys.ToArray()
+	values := make([]V, len(keys))
+	for index, key := range keys {
+		values[index] = m.items[key]
+	}
+	return keys, values
+}
+
+// All returns an iterator over the key value pairs in order
+func (m *LinkedMap[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		m.Each(func(key K, value V) bool {
+			return yield(key, value)
+		})
+	}
 }
 
 // ToJSON converts to json
 func (m *LinkedMap[K, V]) ToJSON() ([]byte, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
 	return json.Marshal(jsonObject[K, V]{
-		Entries: m.ToMap(),
+		Entries: m.items,
 		Keys:    m.keys.ToArray(),
 	})
 }
@@ -149,55 +275,188 @@ func (m *LinkedMap[K, V]) UnmarshalJSON(data []byte) error {
 	if err != nil {
 		return err
 	}
-	m.Map = NewMap[K, V]()
-	m.keys = list.NewLinkedList(container.Keys...)
-	m.keys.Each(func(index int, value K) bool {
-		m.Map.Set(value, container.Entries[value])
-		return true
-	})
+	if m.Map == nil {
+		m.Map = NewMap[K, V]()
+	}
+	m.lock.Lock()
+	defer m.unlock()
+	m.items = make(map[K]V, len(container.Keys))
+	m.resetKeys()
+	for _, key := range container.Keys {
+		if _, ok := m.items[key]; !ok {
+			m.keys.Push(key)
+		}
+		m.items[key] = container.Entries[key]
+	}
 	return nil
 }
 
-// ToMap converts to map
-func (m *LinkedMap[K, V]) ToMap() map[K]V {
-	return m.items
+// EncodeJSON writes the map to w in the format of ToJSON one entry at a time, the map is read locked while writing
+func (m *LinkedMap[K, V]) EncodeJSON(w io.Writer) error {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	buf := bufio.NewWriter(w)
+	buf.WriteString(`{"entries":`)
+	if err := jsonstream.WriteObject(buf, maps.All(m.items)); err != nil {
+		return err
+	}
+	buf.WriteString(`,"keys":`)
+	if err := m.keys.EncodeJSON(buf); err != nil {
+		return err
+	}
+	buf.WriteByte('}')
+	return buf.Flush()
 }
 
-// String converts to string
-func (m *LinkedMap[K, V]) String() string {
-	str := new(strings.Builder)
-	str.WriteString(fmt.Sprintf("LinkedMap[%T, %T](len=%d)", *new(K), *new(V), m.Count()))
-	str.WriteByte('{')
-	str.WriteByte('\n')
-	keys := m.Keys()
-	for _, key := range keys {
-		str.WriteByte('\t')
-		if k, ok := any(key).(contract.Stringable); ok {
-			str.WriteString(k.String())
-		} else {
-			str.WriteString(fmt.Sprintf("%v", key))
+// DecodeJSON reads the format of ToJSON from r one entry at a time and replaces the entries of the map
+func (m *LinkedMap[K, V]) DecodeJSON(r io.Reader) error {
+	entries := make(map[K]V)
+	var keys []K
+	items := make(map[K]V)
+	if err := jsonstream.ReadFields(json.NewDecoder(r), func(dec *json.Decoder, name string) error {
+		switch name {
+		case "entries":
+			return jsonstream.ReadObject(dec, func(key K, value V) {
+				entries[key] = value
+			})
+		case "keys":
+			return jsonstream.ReadArray(dec, func(key K) {
+				if _, ok := items[key]; !ok {
+					keys = append(keys, key)
+					items[key] = *new(V)
+				}
+			})
 		}
-		str.WriteByte(':')
-		str.WriteByte(' ')
-		value, _ := m.Map.Get(key)
-		if v, ok := any(value).(contract.Stringable); ok {
-			str.WriteString(v.String())
-		} else {
-			str.WriteString(fmt.Sprintf("%v", value))
+		return dec.Decode(new(json.RawMessage))
+	}); err != nil {
+		return err
+	}
+	for key := range items {
+		items[key] = entries[key]
+	}
+	if m.Map == nil {
+		m.Map = NewMap[K, V]()
+	}
+	m.lock.Lock()
+	defer m.unlock()
+	m.items = items
+	m.resetKeys()
+	m.keys.Push(keys...)
+	return nil
+}
+
+// ToCBOR converts to cbor
+func (m *LinkedMap[K, V]) ToCBOR() ([]byte, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return cbor.Marshal(jsonObject[K, V]{
+		Entries: m.items,
+		Keys:    m.keys.ToArray(),
+	})
+}
+
+// MarshalCBOR implements [cbor.Marshaler]
+func (m *LinkedMap[K, V]) MarshalCBOR() ([]byte, error) {
+	return m.ToCBOR()
+}
+
+// UnmarshalCBOR implements [cbor.Unmarshaler]
+func (m *LinkedMap[K, V]) UnmarshalCBOR(data []byte) error {
+	var container = new(jsonObject[K, V])
+	err := cbor.Unmarshal(data, container)
+	if err != nil {
+		return err
+	}
+	if m.Map == nil {
+		m.Map = NewMap[K, V]()
+	}
+	m.lock.Lock()
+	defer m.unlock()
+	m.items = make(map[K]V, len(container.Keys))
+	m.resetKeys()
+	for _, key := range container.Keys {
+		if _, ok := m.items[key]; !ok {
+			m.keys.Push(key)
 		}
-		str.WriteByte(',')
-		str.WriteByte('\n')
+		m.items[key] = container.Entries[key]
 	}
-	str.WriteByte('}')
-	return str.String()
+	return nil
+}
+
+// MarshalBinary implements [encoding.BinaryMarshaler], the keys and values are encoded in order by their own encoders
+func (m *LinkedMap[K, V]) MarshalBinary() ([]byte, error) {
+	return codec.EncodeEntries(m.snapshot())
+}
+
+// UnmarshalBinary implements [encoding.BinaryUnmarshaler]
+func (m *LinkedMap[K, V]) UnmarshalBinary(data []byte) error {
+	keys, values, err := codec.DecodeEntries[K, V](data)
+	if err != nil {
+		return err
+	}
+	if m.Map == nil {
+		m.Map = NewMap[K, V]()
+	}
+	m.lock.Lock()
+	defer m.unlock()
+	m.items = make(map[K]V, len(keys))
+	m.resetKeys()
+	for index, key := range keys {
+		if _, ok := m.items[key]; !ok {
+			m.keys.Push(key)
+		}
+		m.items[key] = values[index]
+	}
+	return nil
+}
+
+// ToMap converts to map, the returned map is a copy, so modifying it does not affect the map
+func (m *LinkedMap[K, V]) ToMap() map[K]V {
+	return m.Map.ToMap()
+}
+
+// SetPreviewLimit sets the number of entries shown by String, %v and LogValue, a negative limit shows all entries
+func (m *LinkedMap[K, V]) SetPreviewLimit(limit int) {
+	m.limit.Set(limit)
+}
+
+// String converts to string, only the preview limit of entries are shown
+func (m *LinkedMap[K, V]) String() string {
+	return m.format(m.limit.Get(-1))
+}
+
+// Format implements [fmt.Formatter], %v prints the preview as String does, %+v and %#v print all entries
+func (m *LinkedMap[K, V]) Format(f fmt.State, verb rune) {
+	preview.Format(f, verb, m.format, m.limit.Get(-1))
+}
+
+func (m *LinkedMap[K, V]) format(limit int) string {
+	keys, values := m.snapshot()
+	return preview.Entries(fmt.Sprintf("LinkedMap[%T, %T](len=%d)", *new(K), *new(V), len(keys)), keys, values, limit)
+}
+
+// LogValue implements [slog.LogValuer], only the preview limit of entries are logged
+func (m *LinkedMap[K, V]) LogValue() slog.Value {
+	keys, values := m.snapshot()
+	return preview.LogEntries(fmt.Sprintf("LinkedMap[%T, %T]", *new(K), *new(V)), len(keys), keys, values, m.limit.Get(preview.DefaultLimit))
 }
 
 // Clone clones the map
 func (m *LinkedMap[K, V]) Clone() *LinkedMap[K, V] {
 	mm := NewLinkedMap[K, V]()
-	m.keys.Each(func(index int, key K) bool {
-		mm.Set(key, m.items[key])
-		return true
-	})
-	return m
+	keys, values := m.snapshot()
+	for index, key := range keys {
+		mm.Set(key, values[index])
+	}
+	return mm
+}
+
+// DeepClone clones the map and its values, see [collection.CloneValue], the keys are not cloned
+func (m *LinkedMap[K, V]) DeepClone() *LinkedMap[K, V] {
+	mm := NewLinkedMap[K, V]()
+	keys, values := m.snapshot()
+	for index, key := range keys {
+		mm.Set(key, collection.CloneValue(values[index]))
+	}
+	return mm
 }
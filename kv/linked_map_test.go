@@ -1,11 +1,15 @@
 package kv
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"regexp"
+	"strings"
+	"sync"
 	"testing"
 
+	"github.com/fxamacker/cbor/v2"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -91,6 +95,9 @@ func TestLinkedMap_Keys(t *testing.T) {
 	m.Set(1, 1)
 	m.Set(2, 2)
 	assert.Equal(t, []int{0, 1, 2}, m.Keys())
+	m.Set(1, 10)
+	assert.Equal(t, []int{0, 1, 2}, m.Keys())
+	assert.Equal(t, []int{0, 10, 2}, m.Values())
 }
 
 func TestLinkedMap_Values(t *testing.T) {
@@ -110,6 +117,42 @@ func TestLinkedMap_Clear(t *testing.T) {
 	assert.True(t, m.IsEmpty())
 }
 
+func TestLinkedMap_Replay(t *testing.T) {
+	m := NewLinkedMap[string, int]()
+	m.Set("a", 1)
+	assert.Nil(t, m.Replay(
+		Op[string, int]{Kind: OpRemove, Key: "a"},
+		Op[string, int]{Kind: OpSet, Key: "b", Value: 2},
+		Op[string, int]{Kind: OpSet, Key: "c", Value: 3},
+		Op[string, int]{Kind: OpSet, Key: "b", Value: 4},
+		Op[string, int]{Kind: OpRemove, Key: "missing"},
+	))
+	assert.Equal(t, []string{"b", "c"}, m.Keys())
+	assert.Equal(t, []int{4, 3}, m.Values())
+
+	assert.Nil(t, m.Replay(Op[string, int]{Kind: OpClear}, Op[string, int]{Kind: OpSet, Key: "d", Value: 5}))
+	assert.Equal(t, []string{"d"}, m.Keys())
+	assert.Equal(t, map[string]int{"d": 5}, m.ToMap())
+
+	err := m.Replay(Op[string, int]{Kind: OpClear}, Op[string, int]{Kind: "move"})
+	assert.EqualError(t, err, `kv: op 1 has unknown kind "move"`)
+	assert.Equal(t, []string{"d"}, m.Keys())
+}
+
+func TestLinkedMap_Release(t *testing.T) {
+	m := NewLinkedMap[int, int]()
+	m.EnableNodePool()
+	m.Set(0, 0)
+	m.Set(1, 1)
+	m.Release()
+	assert.True(t, m.IsEmpty())
+	assert.Empty(t, m.Keys())
+	assert.Nil(t, m.DecodeJSON(strings.NewReader(`{"keys":[2,1],"entries":{"1":1,"2":2}}`)))
+	m.Set(3, 3)
+	m.Remove(1)
+	assert.Equal(t, []int{2, 3}, m.Keys())
+}
+
 func TestLinkedMap_ContainsKey(t *testing.T) {
 	m := NewLinkedMap[int, int]()
 	m.Set(0, 0)
@@ -182,6 +225,24 @@ func TestLinkedMap_UnmarshalJSON(t *testing.T) {
 	}, m.ToMap())
 }
 
+func TestLinkedMap_EncodeJSON(t *testing.T) {
+	m := NewLinkedMap[int, int]()
+	m.Set(2, 2)
+	m.Set(0, 0)
+	buf := new(bytes.Buffer)
+	assert.Nil(t, m.EncodeJSON(buf))
+	assert.JSONEq(t, `{"entries":{"0":0,"2":2},"keys":[2,0]}`, buf.String())
+}
+
+func TestLinkedMap_DecodeJSON(t *testing.T) {
+	m := NewLinkedMap[int, int]()
+	m.Set(3, 3)
+	err := m.DecodeJSON(strings.NewReader(`{"keys":[2,0,1,2],"other":[1],"entries":{"0":0,"1":1,"2":2,"4":4}}`))
+	assert.Nil(t, err)
+	assert.Equal(t, []int{2, 0, 1}, m.Keys())
+	assert.Equal(t, map[int]int{0: 0, 1: 1, 2: 2}, m.ToMap())
+}
+
 func TestLinkedMap_String(t *testing.T) {
 	m := NewLinkedMap[int, int]()
 	m.Set(0, 0)
@@ -203,6 +264,18 @@ func TestLinkedMap_Clone(t *testing.T) {
 	}, m2.ToMap())
 }
 
+func TestLinkedMap_DeepClone(t *testing.T) {
+	inner := NewLinkedMap[int, int]()
+	inner.Set(0, 0)
+	m := NewLinkedMap[string, *LinkedMap[int, int]]()
+	m.Set("inner", inner)
+	clone := m.DeepClone()
+	value, _ := clone.Get("inner")
+	assert.NotSame(t, inner, value)
+	inner.Set(1, 1)
+	assert.Equal(t, map[int]int{0: 0}, value.ToMap())
+}
+
 func TestLinkedMap_Reverse(t *testing.T) {
 	m := NewLinkedMap[int, int]()
 	m.Set(0, 0)
@@ -212,3 +285,47 @@ func TestLinkedMap_Reverse(t *testing.T) {
 	values := m.Values()
 	assert.Equal(t, []int{2, 1, 0}, values)
 }
+
+func TestLinkedMap_Concurrent(t *testing.T) {
+	m := NewLinkedMap[int, int]()
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			m.Set(i, i)
+		}(i)
+		go func() {
+			defer wg.Done()
+			_ = m.String()
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int64(100), m.Count())
+}
+
+func TestLinkedMap_MarshalCBOR(t *testing.T) {
+	value := NewLinkedMap[int, string]()
+	value.Set(2, "b")
+	value.Set(1, "a")
+	data, err := cbor.Marshal(value)
+	assert.Nil(t, err)
+	decoded := NewLinkedMap[int, string]()
+	err = cbor.Unmarshal(data, decoded)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{2, 1}, decoded.Keys())
+	assert.Equal(t, []string{"b", "a"}, decoded.Values())
+}
+
+func TestLinkedMap_MarshalBinary(t *testing.T) {
+	value := NewLinkedMap[int, string]()
+	value.Set(2, "b")
+	value.Set(1, "a")
+	data, err := value.MarshalBinary()
+	assert.Nil(t, err)
+	decoded := NewLinkedMap[int, string]()
+	err = decoded.UnmarshalBinary(data)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{2, 1}, decoded.Keys())
+	assert.Equal(t, []string{"b", "a"}, decoded.Values())
+}
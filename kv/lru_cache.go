@@ -0,0 +1,343 @@
+package kv
+
+import (
+	listlib "container/list"
+	"fmt"
+	"iter"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/gopi-frame/collection/internal/preview"
+)
+
+// EvictionReason tells why an entry left a cache without being removed
+type EvictionReason int
+
+const (
+	// EvictedByCapacity the entry was the least recently used one when a new entry needed room
+	EvictedByCapacity EvictionReason = iota
+	// EvictedByExpiry the time to live of the entry passed
+	EvictedByExpiry
+)
+
+// String returns the name of the reason
+func (r EvictionReason) String() string {
+	switch r {
+	case EvictedByCapacity:
+		return "capacity"
+	case EvictedByExpiry:
+		return "expiry"
+	}
+	return fmt.Sprintf("EvictionReason(%d)", int(r))
+}
+
+// CacheStats statistics of a cache
+type CacheStats struct {
+	// Size is the number of entries kept, including the expired ones which are not purged yet
+	Size int64
+	// Hits is the number of lookups which found a live entry
+	Hits int64
+	// Misses is the number of lookups which found no entry or an expired one
+	Misses int64
+	// Evictions is the number of entries evicted by capacity or expiry
+	Evictions int64
+}
+
+// HitRatio returns the ratio of hits to lookups, it is 0 before the first lookup
+func (s CacheStats) HitRatio() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+// NewLRUCache new lru cache which keeps at most cap entries, a cap <= 0 makes it unbounded
+func NewLRUCache[K comparable, V any](cap int) *LRUCache[K, V] {
+	return NewLRUCacheWithClock[K, V](cap, time.Now)
+}
+
+// NewLRUCacheWithClock new lru cache which reads the time to expire the entries from the clock, such as a fake clock in tests
+func NewLRUCacheWithClock[K comparable, V any](cap int, now func() time.Time) *LRUCache[K, V] {
+	return &LRUCache[K, V]{
+		items: make(map[K]*listlib.Element),
+		order: listlib.New(),
+		cap:   cap,
+		now:   now,
+	}
+}
+
+// LRUCache map of a bounded number of entries which evicts the least recently used entry when it is full,
+// all methods are safe for concurrent use.
+// Get and Put make an entry the most recently used one in O(1) time, Peek and Contains read without refreshing it.
+// The entries set by PutWithTTL expire after their time to live, they are removed lazily when they are met,
+// or all at once by Purge.
+// The eviction callback runs after the cache is unlocked, so it may call methods of the same cache.
+type LRUCache[K comparable, V any] struct {
+	lock      sync.RWMutex
+	items     map[K]*listlib.Element
+	order     *listlib.List // the most recently used entry is at the front
+	cap       int
+	now       func() time.Time
+	onEvict   func(key K, value V, reason EvictionReason)
+	hits      int64
+	misses    int64
+	evictions int64
+	limit     preview.Limit
+}
+
+type lruEntry[K comparable, V any] struct {
+	key      K
+	value    V
+	deadline time.Time
+}
+
+// expired returns whether the entry has a deadline which is not after now
+func (e *lruEntry[K, V]) expired(now time.Time) bool {
+	return !e.deadline.IsZero() && !now.Before(e.deadline)
+}
+
+type eviction[K comparable, V any] struct {
+	key    K
+	value  V
+	reason EvictionReason
+}
+
+// OnEvict sets the callback which is called with each entry evicted by capacity or expiry,
+// the entries removed by Remove or Clear are not passed to it
+func (c *LRUCache[K, V]) OnEvict(callback func(key K, value V, reason EvictionReason)) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.onEvict = callback
+}
+
+// notify passes the evicted entries to the callback, it must be called after the cache is unlocked
+func (c *LRUCache[K, V]) notify(callback func(key K, value V, reason EvictionReason), evicted []eviction[K, V]) {
+	if callback == nil {
+		return
+	}
+	for _, e := range evicted {
+		callback(e.key, e.value, e.reason)
+	}
+}
+
+// Cap returns the capacity of the cache, it is not positive when the cache is unbounded
+func (c *LRUCache[K, V]) Cap() int {
+	return c.cap
+}
+
+// Count returns the number of entries, including the expired ones which are not purged yet
+func (c *LRUCache[K, V]) Count() int64 {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return int64(len(c.items))
+}
+
+// IsEmpty returns whether the cache is empty
+func (c *LRUCache[K, V]) IsEmpty() bool {
+	return c.Count() == 0
+}
+
+// IsNotEmpty returns whether the cache is not empty
+func (c *LRUCache[K, V]) IsNotEmpty() bool {
+	return !c.IsEmpty()
+}
+
+// Get returns the value of the specific key and makes it the most recently used entry.
+// A zero value and false will be returned when the key is not exist or its entry is expired.
+func (c *LRUCache[K, V]) Get(key K) (V, bool) {
+	c.lock.Lock()
+	value, ok, evicted := c.get(key)
+	callback := c.onEvict
+	c.lock.Unlock()
+	c.notify(callback, evicted)
+	return value, ok
+}
+
+func (c *LRUCache[K, V]) get(key K) (V, bool, []eviction[K, V]) {
+	element, ok := c.items[key]
+	if !ok {
+		c.misses++
+		return *new(V), false, nil
+	}
+	entry := element.Value.(*lruEntry[K, V])
+	if entry.expired(c.now()) {
+		c.misses++
+		return *new(V), false, []eviction[K, V]{c.evict(element, EvictedByExpiry)}
+	}
+	c.hits++
+	c.order.MoveToFront(element)
+	return entry.value, true, nil
+}
+
+// Peek returns the value of the specific key without making it the most recently used entry,
+// it does not count as a hit or a miss. A zero value and false will be returned when the key is not exist or expired.
+func (c *LRUCache[K, V]) Peek(key K) (V, bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if element, ok := c.items[key]; ok {
+		if entry := element.Value.(*lruEntry[K, V]); !entry.expired(c.now()) {
+			return entry.value, true
+		}
+	}
+	return *new(V), false
+}
+
+// Contains returns whether the cache has a live entry of the specific key, see Peek
+func (c *LRUCache[K, V]) Contains(key K) bool {
+	_, ok := c.Peek(key)
+	return ok
+}
+
+// Put sets the value to the specific key without expiry and makes it the most recently used entry,
+// the least recently used entry is evicted when the cache is full
+func (c *LRUCache[K, V]) Put(key K, value V) {
+	c.PutWithTTL(key, value, 0)
+}
+
+// PutWithTTL sets the value to the specific key which expires after the ttl, a ttl <= 0 never expires, see Put
+func (c *LRUCache[K, V]) PutWithTTL(key K, value V, ttl time.Duration) {
+	c.lock.Lock()
+	evicted := c.put(key, value, ttl)
+	callback := c.onEvict
+	c.lock.Unlock()
+	c.notify(callback, evicted)
+}
+
+func (c *LRUCache[K, V]) put(key K, value V, ttl time.Duration) []eviction[K, V] {
+	var deadline time.Time
+	if ttl > 0 {
+		deadline = c.now().Add(ttl)
+	}
+	if element, ok := c.items[key]; ok {
+		entry := element.Value.(*lruEntry[K, V])
+		entry.value = value
+		entry.deadline = deadline
+		c.order.MoveToFront(element)
+		return nil
+	}
+	var evicted []eviction[K, V]
+	if c.cap > 0 && len(c.items) >= c.cap {
+		evicted = append(evicted, c.evict(c.order.Back(), EvictedByCapacity))
+	}
+	c.items[key] = c.order.PushFront(&lruEntry[K, V]{key: key, value: value, deadline: deadline})
+	return evicted
+}
+
+// evict removes the element and returns its eviction
+func (c *LRUCache[K, V]) evict(element *listlib.Element, reason EvictionReason) eviction[K, V] {
+	entry := c.order.Remove(element).(*lruEntry[K, V])
+	delete(c.items, entry.key)
+	c.evictions++
+	return eviction[K, V]{key: entry.key, value: entry.value, reason: reason}
+}
+
+// Remove removes the specific key and reports whether it was in the cache
+func (c *LRUCache[K, V]) Remove(key K) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	element, ok := c.items[key]
+	if ok {
+		c.order.Remove(element)
+		delete(c.items, key)
+	}
+	return ok
+}
+
+// Purge evicts all expired entries and returns the number of them
+func (c *LRUCache[K, V]) Purge() int {
+	c.lock.Lock()
+	var evicted []eviction[K, V]
+	now := c.now()
+	for element := c.order.Front(); element != nil; {
+		next := element.Next()
+		if element.Value.(*lruEntry[K, V]).expired(now) {
+			evicted = append(evicted, c.evict(element, EvictedByExpiry))
+		}
+		element = next
+	}
+	callback := c.onEvict
+	c.lock.Unlock()
+	c.notify(callback, evicted)
+	return len(evicted)
+}
+
+// Clear clears the cache, the stats are kept
+func (c *LRUCache[K, V]) Clear() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.items = make(map[K]*listlib.Element)
+	c.order.Init()
+}
+
+// Stats returns the statistics of the cache
+func (c *LRUCache[K, V]) Stats() CacheStats {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return CacheStats{
+		Size:      int64(len(c.items)),
+		Hits:      c.hits,
+		Misses:    c.misses,
+		Evictions: c.evictions,
+	}
+}
+
+// Keys returns the keys of the live entries from the most recently used one
+func (c *LRUCache[K, V]) Keys() []K {
+	keys, _ := c.snapshot()
+	return keys
+}
+
+func (c *LRUCache[K, V]) snapshot() ([]K, []V) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	now := c.now()
+	keys := make([]K, 0, len(c.items))
+	values := make([]V, 0, len(c.items))
+	for element := c.order.Front(); element != nil; element = element.Next() {
+		if entry := element.Value.(*lruEntry[K, V]); !entry.expired(now) {
+			keys = append(keys, entry.key)
+			values = append(values, entry.value)
+		}
+	}
+	return keys, values
+}
+
+// All returns an iterator over the live entries from the most recently used one, it does not refresh them.
+// It iterates over a snapshot, so it is allowed to modify the cache while iterating.
+func (c *LRUCache[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		keys, values := c.snapshot()
+		for index, key := range keys {
+			if !yield(key, values[index]) {
+				return
+			}
+		}
+	}
+}
+
+// SetPreviewLimit sets the number of entries shown by String, %v and LogValue, a negative limit shows all entries
+func (c *LRUCache[K, V]) SetPreviewLimit(limit int) {
+	c.limit.Set(limit)
+}
+
+// String converts to string from the most recently used entry, only the preview limit of entries are shown
+func (c *LRUCache[K, V]) String() string {
+	return c.format(c.limit.Get(-1))
+}
+
+// Format implements [fmt.Formatter], %v prints the preview as String does, %+v and %#v print all entries
+func (c *LRUCache[K, V]) Format(f fmt.State, verb rune) {
+	preview.Format(f, verb, c.format, c.limit.Get(-1))
+}
+
+func (c *LRUCache[K, V]) format(limit int) string {
+	keys, values := c.snapshot()
+	return preview.Entries(fmt.Sprintf("LRUCache[%T, %T](len=%d)", *new(K), *new(V), len(keys)), keys, values, limit)
+}
+
+// LogValue implements [slog.LogValuer], only the preview limit of entries are logged
+func (c *LRUCache[K, V]) LogValue() slog.Value {
+	keys, values := c.snapshot()
+	return preview.LogEntries(fmt.Sprintf("LRUCache[%T, %T]", *new(K), *new(V)), len(keys), keys, values, c.limit.Get(preview.DefaultLimit))
+}
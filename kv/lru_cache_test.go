@@ -0,0 +1,143 @@
+package kv
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRUCache_Put(t *testing.T) {
+	cache := NewLRUCache[int, string](2)
+	cache.Put(1, "a")
+	cache.Put(2, "b")
+	cache.Put(3, "c")
+	assert.Equal(t, []int{3, 2}, cache.Keys())
+	assert.False(t, cache.Contains(1))
+	cache.Put(2, "B")
+	assert.Equal(t, []int{2, 3}, cache.Keys())
+	assert.EqualValues(t, 2, cache.Count())
+	assert.Equal(t, 2, cache.Cap())
+}
+
+func TestLRUCache_Get(t *testing.T) {
+	cache := NewLRUCache[int, string](2)
+	cache.Put(1, "a")
+	cache.Put(2, "b")
+	value, ok := cache.Get(1)
+	assert.True(t, ok)
+	assert.Equal(t, "a", value)
+	cache.Put(3, "c")
+	assert.Equal(t, []int{3, 1}, cache.Keys())
+	_, ok = cache.Get(2)
+	assert.False(t, ok)
+}
+
+func TestLRUCache_Peek(t *testing.T) {
+	cache := NewLRUCache[int, string](2)
+	cache.Put(1, "a")
+	cache.Put(2, "b")
+	value, ok := cache.Peek(1)
+	assert.True(t, ok)
+	assert.Equal(t, "a", value)
+	cache.Put(3, "c")
+	assert.Equal(t, []int{3, 2}, cache.Keys())
+	assert.Equal(t, CacheStats{Size: 2, Evictions: 1}, cache.Stats())
+}
+
+func TestLRUCache_PutWithTTL(t *testing.T) {
+	clock := &_clock{now: time.Unix(1000, 0)}
+	cache := NewLRUCacheWithClock[string, int](0, clock.Now)
+	var evicted []string
+	cache.OnEvict(func(key string, value int, reason EvictionReason) {
+		evicted = append(evicted, fmt.Sprintf("%s:%d:%s", key, value, reason))
+	})
+	cache.PutWithTTL("a", 1, time.Second)
+	cache.PutWithTTL("b", 2, 2*time.Second)
+	cache.Put("c", 3)
+	clock.now = clock.now.Add(time.Second)
+	assert.False(t, cache.Contains("a"))
+	assert.Equal(t, []string{"c", "b"}, cache.Keys())
+	assert.EqualValues(t, 3, cache.Count())
+	_, ok := cache.Get("a")
+	assert.False(t, ok)
+	assert.Equal(t, []string{"a:1:expiry"}, evicted)
+	clock.now = clock.now.Add(time.Hour)
+	assert.Equal(t, 1, cache.Purge())
+	assert.Equal(t, []string{"a:1:expiry", "b:2:expiry"}, evicted)
+	assert.Equal(t, []string{"c"}, cache.Keys())
+}
+
+func TestLRUCache_OnEvict(t *testing.T) {
+	cache := NewLRUCache[int, string](1)
+	var evicted []string
+	cache.OnEvict(func(key int, value string, reason EvictionReason) {
+		evicted = append(evicted, fmt.Sprintf("%d:%s:%s:%d", key, value, reason, cache.Count()))
+	})
+	cache.Put(1, "a")
+	cache.Put(2, "b")
+	cache.Put(3, "c")
+	cache.Remove(3)
+	cache.Put(4, "d")
+	cache.Clear()
+	assert.Equal(t, []string{"1:a:capacity:1", "2:b:capacity:1"}, evicted)
+}
+
+func TestLRUCache_Remove(t *testing.T) {
+	cache := NewLRUCache[int, string](2)
+	cache.Put(1, "a")
+	assert.True(t, cache.Remove(1))
+	assert.False(t, cache.Remove(1))
+	assert.True(t, cache.IsEmpty())
+}
+
+func TestLRUCache_Stats(t *testing.T) {
+	cache := NewLRUCache[int, string](1)
+	cache.Put(1, "a")
+	cache.Get(1)
+	cache.Get(1)
+	cache.Get(2)
+	cache.Put(2, "b")
+	stats := cache.Stats()
+	assert.Equal(t, CacheStats{Size: 1, Hits: 2, Misses: 1, Evictions: 1}, stats)
+	assert.InDelta(t, 2.0/3, stats.HitRatio(), 1e-9)
+	assert.Zero(t, CacheStats{}.HitRatio())
+}
+
+func TestLRUCache_All(t *testing.T) {
+	cache := NewLRUCache[int, string](3)
+	cache.Put(1, "a")
+	cache.Put(2, "b")
+	var keys []int
+	for key := range cache.All() {
+		keys = append(keys, key)
+		cache.Remove(key)
+	}
+	assert.Equal(t, []int{2, 1}, keys)
+	assert.True(t, cache.IsEmpty())
+}
+
+func TestLRUCache_String(t *testing.T) {
+	cache := NewLRUCache[int, string](3)
+	cache.Put(1, "a")
+	cache.Put(2, "b")
+	assert.Equal(t, "LRUCache[int, string](len=2){\n\t2: b,\n\t1: a,\n}", cache.String())
+}
+
+func TestLRUCache_Concurrent(t *testing.T) {
+	cache := NewLRUCache[int, int](10)
+	wg := new(sync.WaitGroup)
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			cache.Put(i, i)
+			cache.Get(i - 1)
+		}(i)
+	}
+	wg.Wait()
+	assert.EqualValues(t, 10, cache.Count())
+	assert.EqualValues(t, 90, cache.Stats().Evictions)
+}
@@ -3,11 +3,19 @@ package kv
 import (
 	"encoding/json"
 	"fmt"
+	"io"
+	"iter"
+	"log/slog"
+	"maps"
 	"reflect"
-	"strings"
 	"sync"
 
-	"github.com/gopi-frame/contract"
+	"github.com/fxamacker/cbor/v2"
+	"github.com/gopi-frame/collection"
+	"github.com/gopi-frame/collection/internal/codec"
+	"github.com/gopi-frame/collection/internal/hash"
+	"github.com/gopi-frame/collection/internal/jsonstream"
+	"github.com/gopi-frame/collection/internal/preview"
 )
 
 // NewMap new map
@@ -17,21 +25,29 @@ func NewMap[K comparable, V any]() *Map[K, V] {
 	return m
 }
 
-// NewFromMap new from map
+// NewFromMap new from map, the map is used as is without copying, so the caller must not modify it afterwards
 func NewFromMap[K comparable, V any](m map[K]V) *Map[K, V] {
 	mm := NewMap[K, V]()
 	mm.items = m
 	return mm
 }
 
-// Map map
+// Map map, all methods are safe for concurrent use.
+// The callbacks run while the map is locked, so they must not call methods of the same map,
+// except the callback of Each which runs on a snapshot.
 type Map[K comparable, V any] struct {
-	sync.RWMutex
+	lock  sync.RWMutex
 	items map[K]V
+	limit preview.Limit
+
+	peak         int
+	compactRatio float64
 }
 
 // Count returns the size of map
 func (m *Map[K, V]) Count() int64 {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
 	return int64(len(m.items))
 }
 
@@ -48,6 +64,8 @@ func (m *Map[K, V]) IsNotEmpty() bool {
 // Get gets element by specific key.
 // A zero value and false will be returned when the given key is not exist
 func (m *Map[K, V]) Get(key K) (V, bool) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
 	v, ok := m.items[key]
 	return v, ok
 }
@@ -55,8 +73,7 @@ func (m *Map[K, V]) Get(key K) (V, bool) {
 // GetOr gets element by specific key
 // The default will be return
 func (m *Map[K, V]) GetOr(key K, value V) V {
-	v, ok := m.items[key]
-	if ok {
+	if v, ok := m.Get(key); ok {
 		return v
 	}
 	return value
@@ -64,16 +81,22 @@ func (m *Map[K, V]) GetOr(key K, value V) V {
 
 // Set sets element to the specific key
 func (m *Map[K, V]) Set(key K, value V) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
 	m.items[key] = value
 }
 
 // Remove removes the element of specific key
 func (m *Map[K, V]) Remove(key K) {
-	delete(m.items, key)
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.delete(key)
 }
 
 // Keys returns all keys
 func (m *Map[K, V]) Keys() []K {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
 	var keys []K
 	for key := range m.items {
 		keys = append(keys, key)
@@ -83,6 +106,8 @@ func (m *Map[K, V]) Keys() []K {
 
 // Values returns all values
 func (m *Map[K, V]) Values() []V {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
 	var values []V
 	for _, value := range m.items {
 		values = append(values, value)
@@ -92,17 +117,16 @@ func (m *Map[K, V]) Values() []V {
 
 // Clear clears the map
 func (m *Map[K, V]) Clear() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
 	m.items = make(map[K]V)
+	m.peak = 0
 }
 
 // ContainsKey returns whether the map contains the specific key
 func (m *Map[K, V]) ContainsKey(key K) bool {
-	for k := range m.items {
-		if k == key {
-			return true
-		}
-	}
-	return false
+	_, ok := m.Get(key)
+	return ok
 }
 
 // Contains returns whether the map contains the specific value
@@ -114,6 +138,8 @@ func (m *Map[K, V]) Contains(value V) bool {
 
 // ContainsWhere returns whether the map contains specific values through callback
 func (m *Map[K, V]) ContainsWhere(callback func(value V) bool) bool {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
 	for _, v := range m.items {
 		if callback(v) {
 			return true
@@ -122,17 +148,25 @@ func (m *Map[K, V]) ContainsWhere(callback func(value V) bool) bool {
 	return false
 }
 
-// Each ranges the map by callback, it will break the loop when the callback returns false
+// Each ranges the map by callback, it will break the loop when the callback returns false.
+// The callback runs on a snapshot of the map, so it is allowed to modify the map.
 func (m *Map[K, V]) Each(callback func(key K, value V) bool) {
-	for key, value := range m.items {
+	for key, value := range m.ToMap() {
 		if !callback(key, value) {
 			break
 		}
 	}
 }
 
+// All returns an iterator over the key value pairs
+func (m *Map[K, V]) All() iter.Seq2[K, V] {
+	return maps.All(m.ToMap())
+}
+
 // ToJSON converts the map to json bytes
 func (m *Map[K, V]) ToJSON() ([]byte, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
 	return json.Marshal(m.items)
 }
 
@@ -147,51 +181,173 @@ func (m *Map[K, V]) UnmarshalJSON(data []byte) error {
 	if err := json.Unmarshal(data, &values); err != nil {
 		return err
 	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.items = values
+	return nil
+}
+
+// EncodeJSON writes the map to w as a json object one entry at a time, the map is read locked while writing
+func (m *Map[K, V]) EncodeJSON(w io.Writer) error {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return jsonstream.EncodeEntries(w, maps.All(m.items))
+}
+
+// DecodeJSON reads a json object from r one entry at a time and replaces the entries of the map
+func (m *Map[K, V]) DecodeJSON(r io.Reader) error {
+	items := make(map[K]V)
+	if err := jsonstream.DecodeEntries(r, func(key K, value V) {
+		items[key] = value
+	}); err != nil {
+		return err
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.items = items
+	return nil
+}
+
+// ToCBOR converts to cbor
+func (m *Map[K, V]) ToCBOR() ([]byte, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return cbor.Marshal(m.items)
+}
+
+// MarshalCBOR implements [cbor.Marshaler]
+func (m *Map[K, V]) MarshalCBOR() ([]byte, error) {
+	return m.ToCBOR()
+}
+
+// UnmarshalCBOR implements [cbor.Unmarshaler]
+func (m *Map[K, V]) UnmarshalCBOR(data []byte) error {
+	values := map[K]V{}
+	if err := cbor.Unmarshal(data, &values); err != nil {
+		return err
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
 	m.items = values
 	return nil
 }
 
-// ToMap converts to map
+// MarshalBinary implements [encoding.BinaryMarshaler], the keys and values are encoded by their own encoders
+func (m *Map[K, V]) MarshalBinary() ([]byte, error) {
+	return codec.EncodeEntries(m.snapshot())
+}
+
+func (m *Map[K, V]) snapshot() ([]K, []V) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	keys := make([]K, 0, len(m.items))
+	values := make([]V, 0, len(m.items))
+	for key, value := range m.items {
+		keys = append(keys, key)
+		values = append(values, value)
+	}
+	return keys, values
+}
+
+// UnmarshalBinary implements [encoding.BinaryUnmarshaler]
+func (m *Map[K, V]) UnmarshalBinary(data []byte) error {
+	keys, values, err := codec.DecodeEntries[K, V](data)
+	if err != nil {
+		return err
+	}
+	items := make(map[K]V, len(keys))
+	for index, key := range keys {
+		items[key] = values[index]
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.items = items
+	return nil
+}
+
+// ToMap converts to map, the returned map is a copy, so modifying it does not affect the map
 func (m *Map[K, V]) ToMap() map[K]V {
-	return m.items
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return maps.Clone(m.items)
 }
 
+// FromMap replaces the items of the map with the given map, the map is used as is without copying,
+// so the caller must not modify it afterwards
 func (m *Map[K, V]) FromMap(items map[K]V) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
 	m.items = items
 }
 
-// String converts to string
+// SetPreviewLimit sets the number of entries shown by String, %v and LogValue, a negative limit shows all entries
+func (m *Map[K, V]) SetPreviewLimit(limit int) {
+	m.limit.Set(limit)
+}
+
+// String converts to string, only the preview limit of entries are shown
 func (m *Map[K, V]) String() string {
-	str := new(strings.Builder)
-	str.WriteString(fmt.Sprintf("Map[%T, %T](len=%d)", *new(K), *new(V), m.Count()))
-	str.WriteByte('{')
-	str.WriteByte('\n')
-	for k, v := range m.items {
-		str.WriteByte('\t')
-		if key, ok := any(k).(contract.Stringable); ok {
-			str.WriteString(key.String())
-		} else {
-			str.WriteString(fmt.Sprintf("%v", k))
-		}
-		str.WriteByte(':')
-		str.WriteByte(' ')
-		if value, ok := any(v).(contract.Stringable); ok {
-			str.WriteString(value.String())
-		} else {
-			str.WriteString(fmt.Sprintf("%v", v))
-		}
-		str.WriteByte(',')
-		str.WriteByte('\n')
-	}
-	str.WriteByte('}')
-	return str.String()
+	return m.format(m.limit.Get(-1))
+}
+
+// Format implements [fmt.Formatter], %v prints the preview as String does, %+v and %#v print all entries
+func (m *Map[K, V]) Format(f fmt.State, verb rune) {
+	preview.Format(f, verb, m.format, m.limit.Get(-1))
+}
+
+func (m *Map[K, V]) format(limit int) string {
+	keys, values := m.snapshot()
+	return preview.Entries(fmt.Sprintf("Map[%T, %T](len=%d)", *new(K), *new(V), len(keys)), keys, values, limit)
+}
+
+// LogValue implements [slog.LogValuer], only the preview limit of entries are logged
+func (m *Map[K, V]) LogValue() slog.Value {
+	keys, values := m.snapshot()
+	return preview.LogEntries(fmt.Sprintf("Map[%T, %T]", *new(K), *new(V)), len(keys), keys, values, m.limit.Get(preview.DefaultLimit))
 }
 
 // Clone clone a new map
 func (m *Map[K, V]) Clone() *Map[K, V] {
-	newMap := NewMap[K, V]()
-	for key, value := range m.items {
-		newMap.Set(key, value)
+	return NewFromMap(m.ToMap())
+}
+
+// DeepClone clones the map and its values, see [collection.CloneValue], the keys are not cloned
+func (m *Map[K, V]) DeepClone() *Map[K, V] {
+	items := m.ToMap()
+	for key, value := range items {
+		items[key] = collection.CloneValue(value)
+	}
+	return NewFromMap(items)
+}
+
+// Equal returns whether the maps have the same keys with equal values,
+// the values are compared with their Equal method or [reflect.DeepEqual]
+func (m *Map[K, V]) Equal(other *Map[K, V]) bool {
+	if m == other {
+		return true
+	}
+	if other == nil {
+		return false
+	}
+	items, others := m.ToMap(), other.ToMap()
+	if len(items) != len(others) {
+		return false
+	}
+	for key, value := range items {
+		if v, ok := others[key]; !ok || !hash.Equal(value, v) {
+			return false
+		}
+	}
+	return true
+}
+
+// Hash returns the hash of the entries regardless of their order, maps which are equal have the same hash.
+// Values with an Equal method must have a Hash method consistent with it.
+func (m *Map[K, V]) Hash() uint64 {
+	keys, values := m.snapshot()
+	hashes := make([]uint64, len(keys))
+	for index, key := range keys {
+		hashes[index] = hash.Ordered(hash.Value(key), hash.Deep(values[index]))
 	}
-	return newMap
+	return hash.Unordered(hashes...)
 }
@@ -1,11 +1,16 @@
 package kv
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"regexp"
+	"strings"
+	"sync"
 	"testing"
 
+	"github.com/fxamacker/cbor/v2"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -129,6 +134,15 @@ func TestMap_Each(t *testing.T) {
 	})
 }
 
+func TestMap_ToMap(t *testing.T) {
+	m := NewMap[int, int]()
+	m.Set(0, 0)
+	items := m.ToMap()
+	assert.Equal(t, map[int]int{0: 0}, items)
+	items[1] = 1
+	assert.False(t, m.ContainsKey(1))
+}
+
 func TestMap_ToJSON(t *testing.T) {
 	m := NewMap[int, int]()
 	m.Set(0, 0)
@@ -158,6 +172,21 @@ func TestMap_UnmarshalJSON(t *testing.T) {
 	}, m.ToMap())
 }
 
+func TestMap_EncodeJSON(t *testing.T) {
+	m := NewFromMap(map[int]string{1: "a", 2: "b"})
+	buf := new(bytes.Buffer)
+	assert.Nil(t, m.EncodeJSON(buf))
+	assert.JSONEq(t, `{"1":"a","2":"b"}`, buf.String())
+}
+
+func TestMap_DecodeJSON(t *testing.T) {
+	m := NewFromMap(map[int]string{3: "c"})
+	assert.Nil(t, m.DecodeJSON(strings.NewReader(`{"1":"a","2":"b"}`)))
+	assert.Equal(t, map[int]string{1: "a", 2: "b"}, m.ToMap())
+	assert.Error(t, m.DecodeJSON(strings.NewReader(`{"x":"a"}`)))
+	assert.Equal(t, map[int]string{1: "a", 2: "b"}, m.ToMap())
+}
+
 func TestMap_String(t *testing.T) {
 	m := NewMap[int, int]()
 	m.Set(0, 0)
@@ -178,3 +207,97 @@ func TestMap_Clone(t *testing.T) {
 		0: 0, 1: 1, 2: 2,
 	}, m2.ToMap())
 }
+
+func TestMap_DeepClone(t *testing.T) {
+	inner := NewFromMap(map[int]int{0: 0})
+	m := NewFromMap(map[string]*Map[int, int]{"inner": inner})
+	clone := m.DeepClone()
+	value, _ := clone.Get("inner")
+	assert.NotSame(t, inner, value)
+	inner.Set(1, 1)
+	assert.Equal(t, map[int]int{0: 0}, value.ToMap())
+}
+
+func TestMap_Equal(t *testing.T) {
+	m := NewFromMap(map[string][]int{"a": {1}, "b": {2}})
+	assert.True(t, m.Equal(m))
+	assert.True(t, m.Equal(NewFromMap(map[string][]int{"b": {2}, "a": {1}})))
+	assert.False(t, m.Equal(NewFromMap(map[string][]int{"a": {1}, "b": {3}})))
+	assert.False(t, m.Equal(NewFromMap(map[string][]int{"a": {1}})))
+	assert.False(t, m.Equal(nil))
+}
+
+func TestMap_Hash(t *testing.T) {
+	a := NewFromMap(map[string][]int{"a": {1}, "b": {2}})
+	b := NewFromMap(map[string][]int{"b": {2}, "a": {1}})
+	assert.Equal(t, a.Hash(), b.Hash())
+	b.Set("b", []int{3})
+	assert.NotEqual(t, a.Hash(), b.Hash())
+}
+
+func TestMap_Concurrent(t *testing.T) {
+	m := NewMap[int, int]()
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			m.Set(i, i)
+		}(i)
+		go func() {
+			defer wg.Done()
+			_ = m.String()
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int64(100), m.Count())
+}
+
+func TestMap_MarshalCBOR(t *testing.T) {
+	value := NewFromMap(map[int]string{1: "a", 2: "b"})
+	data, err := cbor.Marshal(value)
+	assert.Nil(t, err)
+	decoded := NewMap[int, string]()
+	err = cbor.Unmarshal(data, decoded)
+	assert.Nil(t, err)
+	assert.Equal(t, map[int]string{1: "a", 2: "b"}, decoded.ToMap())
+}
+
+func TestMap_MarshalBinary(t *testing.T) {
+	value := NewFromMap(map[int]string{1: "a", 2: "b"})
+	data, err := value.MarshalBinary()
+	assert.Nil(t, err)
+	decoded := NewMap[int, string]()
+	err = decoded.UnmarshalBinary(data)
+	assert.Nil(t, err)
+	assert.Equal(t, map[int]string{1: "a", 2: "b"}, decoded.ToMap())
+}
+
+func TestMap_SetPreviewLimit(t *testing.T) {
+	m := NewFromMap(map[int]int{0: 0, 1: 1, 2: 2})
+	m.SetPreviewLimit(1)
+	pattern := regexp.MustCompile(`Map\[int, int\]\(len=3\)\{\n\t\d+: \d+,\n\t\.\.\.\n\}`)
+	assert.True(t, pattern.MatchString(m.String()))
+	pattern = regexp.MustCompile(`Map\[int, int\]\(len=3\)\{\n(\t\d+: \d+,\n){3}\}`)
+	assert.True(t, pattern.MatchString(fmt.Sprintf("%+v", m)))
+}
+
+func TestMap_LogValue(t *testing.T) {
+	m := NewFromMap(map[string]int{"a": 1, "b": 2})
+	buf := new(bytes.Buffer)
+	logger := slog.New(slog.NewJSONHandler(buf, nil))
+	logger.Info("map", "map", m)
+	var record struct {
+		Map struct {
+			Type      string         `json:"type"`
+			Len       int            `json:"len"`
+			Entries   map[string]int `json:"entries"`
+			Truncated bool           `json:"truncated"`
+		} `json:"map"`
+	}
+	assert.Nil(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, "Map[string, int]", record.Map.Type)
+	assert.Equal(t, 2, record.Map.Len)
+	assert.Equal(t, map[string]int{"a": 1, "b": 2}, record.Map.Entries)
+	assert.False(t, record.Map.Truncated)
+}
@@ -0,0 +1,329 @@
+package kv
+
+import (
+	"encoding/json"
+	"fmt"
+	"iter"
+	"log/slog"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/gopi-frame/collection"
+	"github.com/gopi-frame/collection/internal/preview"
+)
+
+// NewMultiMap new multi map
+func NewMultiMap[K comparable, V any]() *MultiMap[K, V] {
+	m := new(MultiMap[K, V])
+	m.items = make(map[K][]V)
+	return m
+}
+
+// MultiMap map allowing multiple values per key, all methods are safe for concurrent use.
+// The values of a key are kept in insertion order, a key is removed with its last value.
+// Count returns the number of keys and CountValues the number of values.
+type MultiMap[K comparable, V any] struct {
+	lock  sync.RWMutex
+	items map[K][]V
+	size  int64
+	limit preview.Limit
+}
+
+// Count returns the number of keys in the map
+func (m *MultiMap[K, V]) Count() int64 {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return int64(len(m.items))
+}
+
+// CountValues returns the number of values in the map
+func (m *MultiMap[K, V]) CountValues() int64 {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return m.size
+}
+
+// IsEmpty returns whether the map is empty
+func (m *MultiMap[K, V]) IsEmpty() bool {
+	return m.Count() == 0
+}
+
+// IsNotEmpty returns whether the map is not empty
+func (m *MultiMap[K, V]) IsNotEmpty() bool {
+	return !m.IsEmpty()
+}
+
+// Add appends values to the specific key
+func (m *MultiMap[K, V]) Add(key K, values ...V) {
+	if len(values) == 0 {
+		return
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.items[key] = append(m.items[key], values...)
+	m.size += int64(len(values))
+}
+
+// Get returns the first value of the specific key.
+// A zero value and false will be returned when the given key is not exist
+func (m *MultiMap[K, V]) Get(key K) (V, bool) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	values, ok := m.items[key]
+	if !ok {
+		return *new(V), false
+	}
+	return values[0], true
+}
+
+// GetAll returns all values of the specific key in insertion order, it returns nil when the key is not exist
+func (m *MultiMap[K, V]) GetAll(key K) []V {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return slices.Clone(m.items[key])
+}
+
+// Remove removes all values of the specific key
+func (m *MultiMap[K, V]) Remove(key K) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.size -= int64(len(m.items[key]))
+	delete(m.items, key)
+}
+
+// RemoveValue removes the first occurrence of the value from the specific key,
+// it returns false if the value is not found
+func (m *MultiMap[K, V]) RemoveValue(key K, value V) bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	values := m.items[key]
+	index := slices.IndexFunc(values, func(v V) bool {
+		return reflect.DeepEqual(v, value)
+	})
+	if index < 0 {
+		return false
+	}
+	if len(values) == 1 {
+		delete(m.items, key)
+	} else {
+		m.items[key] = slices.Delete(values, index, index+1)
+	}
+	m.size--
+	return true
+}
+
+// Keys returns all distinct keys
+func (m *MultiMap[K, V]) Keys() []K {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	keys := make([]K, 0, len(m.items))
+	for key := range m.items {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// Values returns all values, the values of a key are adjacent and in insertion order
+func (m *MultiMap[K, V]) Values() []V {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	values := make([]V, 0, m.size)
+	for _, items := range m.items {
+		values = append(values, items...)
+	}
+	return values
+}
+
+// Clear clears the map
+func (m *MultiMap[K, V]) Clear() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.items = make(map[K][]V)
+	m.size = 0
+}
+
+// ContainsKey returns whether the map contains the specific key
+func (m *MultiMap[K, V]) ContainsKey(key K) bool {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	_, ok := m.items[key]
+	return ok
+}
+
+// Contains returns whether the map contains the specific value
+func (m *MultiMap[K, V]) Contains(value V) bool {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	for _, values := range m.items {
+		for _, v := range values {
+			if reflect.DeepEqual(v, value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Each ranges the key value pairs of the map, it will break the loop when the callback returns false.
+// The callback runs on a snapshot of the map, so it is allowed to modify the map.
+func (m *MultiMap[K, V]) Each(callback func(key K, value V) bool) {
+	for key, values := range m.ToMap() {
+		for _, value := range values {
+			if !callback(key, value) {
+				return
+			}
+		}
+	}
+}
+
+// All returns an iterator over the key value pairs, a key is yielded once with each of its values
+func (m *MultiMap[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		m.Each(func(key K, value V) bool {
+			return yield(key, value)
+		})
+	}
+}
+
+// ToMap converts to map of the values of each key, the returned map is a copy, so modifying it does not affect the map
+func (m *MultiMap[K, V]) ToMap() map[K][]V {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	items := make(map[K][]V, len(m.items))
+	for key, values := range m.items {
+		items[key] = slices.Clone(values)
+	}
+	return items
+}
+
+// ToJSON converts the map to json bytes, the map is encoded as an object of the values of each key
+func (m *MultiMap[K, V]) ToJSON() ([]byte, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return json.Marshal(m.items)
+}
+
+// MarshalJSON implements [json.Marshaller]
+func (m *MultiMap[K, V]) MarshalJSON() ([]byte, error) {
+	return m.ToJSON()
+}
+
+// UnmarshalJSON implements [json.Unmarshaller], the entries replace the entries of the map,
+// the keys without values are skipped
+func (m *MultiMap[K, V]) UnmarshalJSON(data []byte) error {
+	items := map[K][]V{}
+	if err := json.Unmarshal(data, &items); err != nil {
+		return err
+	}
+	m.replace(items)
+	return nil
+}
+
+// ToCBOR converts to cbor
+func (m *MultiMap[K, V]) ToCBOR() ([]byte, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return cbor.Marshal(m.items)
+}
+
+// MarshalCBOR implements [cbor.Marshaler]
+func (m *MultiMap[K, V]) MarshalCBOR() ([]byte, error) {
+	return m.ToCBOR()
+}
+
+// UnmarshalCBOR implements [cbor.Unmarshaler], see UnmarshalJSON
+func (m *MultiMap[K, V]) UnmarshalCBOR(data []byte) error {
+	items := map[K][]V{}
+	if err := cbor.Unmarshal(data, &items); err != nil {
+		return err
+	}
+	m.replace(items)
+	return nil
+}
+
+// replace replaces the entries with the decoded items, the keys without values are skipped
+func (m *MultiMap[K, V]) replace(items map[K][]V) {
+	var size int64
+	for key, values := range items {
+		if len(values) == 0 {
+			delete(items, key)
+		}
+		size += int64(len(values))
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.items = items
+	m.size = size
+}
+
+// SetPreviewLimit sets the number of keys shown by String, %v and LogValue, a negative limit shows all keys
+func (m *MultiMap[K, V]) SetPreviewLimit(limit int) {
+	m.limit.Set(limit)
+}
+
+// String converts to string, only the preview limit of keys are shown with all their values
+func (m *MultiMap[K, V]) String() string {
+	return m.format(m.limit.Get(-1))
+}
+
+// Format implements [fmt.Formatter], %v prints the preview as String does, %+v and %#v print all keys
+func (m *MultiMap[K, V]) Format(f fmt.State, verb rune) {
+	preview.Format(f, verb, m.format, m.limit.Get(-1))
+}
+
+func (m *MultiMap[K, V]) format(limit int) string {
+	keys, values := m.snapshot()
+	formatted := make([]string, len(values))
+	size := 0
+	for index, items := range values {
+		parts := make([]string, len(items))
+		for i, value := range items {
+			parts[i] = preview.Value(value)
+		}
+		formatted[index] = "[" + strings.Join(parts, ", ") + "]"
+		size += len(items)
+	}
+	return preview.Entries(fmt.Sprintf("MultiMap[%T, %T](len=%d)", *new(K), *new(V), size), keys, formatted, limit)
+}
+
+// LogValue implements [slog.LogValuer], only the preview limit of keys are logged with all their values
+func (m *MultiMap[K, V]) LogValue() slog.Value {
+	keys, values := m.snapshot()
+	size := 0
+	for _, items := range values {
+		size += len(items)
+	}
+	return preview.LogEntries(fmt.Sprintf("MultiMap[%T, %T]", *new(K), *new(V)), size, keys, values, m.limit.Get(preview.DefaultLimit))
+}
+
+func (m *MultiMap[K, V]) snapshot() ([]K, [][]V) {
+	items := m.ToMap()
+	keys := make([]K, 0, len(items))
+	values := make([][]V, 0, len(items))
+	for key, items := range items {
+		keys = append(keys, key)
+		values = append(values, items)
+	}
+	return keys, values
+}
+
+// Clone clones the map
+func (m *MultiMap[K, V]) Clone() *MultiMap[K, V] {
+	mm := NewMultiMap[K, V]()
+	mm.replace(m.ToMap())
+	return mm
+}
+
+// DeepClone clones the map and its values, see [collection.CloneValue], the keys are not cloned
+func (m *MultiMap[K, V]) DeepClone() *MultiMap[K, V] {
+	mm := m.Clone()
+	for _, values := range mm.items {
+		for index, value := range values {
+			values[index] = collection.CloneValue(value)
+		}
+	}
+	return mm
+}
@@ -0,0 +1,158 @@
+package kv
+
+import (
+	"encoding/json"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func _newMultiMap() *MultiMap[int, string] {
+	m := NewMultiMap[int, string]()
+	m.Add(3, "c1", "c2")
+	m.Add(1, "a1")
+	m.Add(2, "b1")
+	m.Add(1, "a2")
+	return m
+}
+
+func TestMultiMap_Count(t *testing.T) {
+	m := _newMultiMap()
+	assert.EqualValues(t, 3, m.Count())
+	assert.EqualValues(t, 5, m.CountValues())
+	assert.True(t, m.IsNotEmpty())
+	m.Add(4)
+	assert.EqualValues(t, 3, m.Count())
+	assert.False(t, m.ContainsKey(4))
+}
+
+func TestMultiMap_Get(t *testing.T) {
+	m := _newMultiMap()
+	value, ok := m.Get(1)
+	assert.True(t, ok)
+	assert.Equal(t, "a1", value)
+	value, ok = m.Get(4)
+	assert.False(t, ok)
+	assert.Equal(t, "", value)
+}
+
+func TestMultiMap_GetAll(t *testing.T) {
+	m := _newMultiMap()
+	values := m.GetAll(1)
+	assert.Equal(t, []string{"a1", "a2"}, values)
+	values[0] = "x"
+	assert.Equal(t, []string{"a1", "a2"}, m.GetAll(1))
+	assert.Nil(t, m.GetAll(4))
+}
+
+func TestMultiMap_Remove(t *testing.T) {
+	m := _newMultiMap()
+	m.Remove(3)
+	m.Remove(4)
+	assert.EqualValues(t, 2, m.Count())
+	assert.EqualValues(t, 3, m.CountValues())
+	assert.ElementsMatch(t, []int{1, 2}, m.Keys())
+}
+
+func TestMultiMap_RemoveValue(t *testing.T) {
+	m := _newMultiMap()
+	assert.True(t, m.RemoveValue(1, "a1"))
+	assert.False(t, m.RemoveValue(1, "a1"))
+	assert.Equal(t, []string{"a2"}, m.GetAll(1))
+	assert.True(t, m.RemoveValue(2, "b1"))
+	assert.False(t, m.ContainsKey(2))
+	assert.False(t, m.RemoveValue(4, "d1"))
+	assert.EqualValues(t, 3, m.CountValues())
+}
+
+func TestMultiMap_Values(t *testing.T) {
+	assert.ElementsMatch(t, []string{"a1", "a2", "b1", "c1", "c2"}, _newMultiMap().Values())
+}
+
+func TestMultiMap_Clear(t *testing.T) {
+	m := _newMultiMap()
+	m.Clear()
+	assert.True(t, m.IsEmpty())
+	assert.Zero(t, m.CountValues())
+}
+
+func TestMultiMap_Contains(t *testing.T) {
+	m := _newMultiMap()
+	assert.True(t, m.Contains("b1"))
+	assert.False(t, m.Contains("d1"))
+}
+
+func TestMultiMap_All(t *testing.T) {
+	m := _newMultiMap()
+	var pairs []string
+	for key, value := range m.All() {
+		m.Remove(key)
+		pairs = append(pairs, value)
+	}
+	slices.Sort(pairs)
+	assert.Equal(t, []string{"a1", "a2", "b1", "c1", "c2"}, pairs)
+	assert.True(t, m.IsEmpty())
+}
+
+func TestMultiMap_MarshalJSON(t *testing.T) {
+	jsonBytes, err := json.Marshal(_newMultiMap())
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"1":["a1","a2"],"2":["b1"],"3":["c1","c2"]}`, string(jsonBytes))
+}
+
+func TestMultiMap_UnmarshalJSON(t *testing.T) {
+	m := _newMultiMap()
+	err := json.Unmarshal([]byte(`{"2":["b1"],"1":["a1","a2"],"3":[]}`), m)
+	assert.Nil(t, err)
+	assert.Equal(t, map[int][]string{1: {"a1", "a2"}, 2: {"b1"}}, m.ToMap())
+	assert.EqualValues(t, 3, m.CountValues())
+}
+
+func TestMultiMap_MarshalCBOR(t *testing.T) {
+	data, err := cbor.Marshal(_newMultiMap())
+	assert.Nil(t, err)
+	decoded := NewMultiMap[int, string]()
+	assert.Nil(t, cbor.Unmarshal(data, decoded))
+	assert.Equal(t, _newMultiMap().ToMap(), decoded.ToMap())
+}
+
+func TestMultiMap_String(t *testing.T) {
+	m := NewMultiMap[int, string]()
+	m.Add(1, "a1", "a2")
+	assert.Equal(t, "MultiMap[int, string](len=2){\n\t1: [a1, a2],\n}", m.String())
+}
+
+func TestMultiMap_Clone(t *testing.T) {
+	m := _newMultiMap()
+	clone := m.Clone()
+	m.Add(1, "a3")
+	assert.Equal(t, []string{"a1", "a2"}, clone.GetAll(1))
+	assert.EqualValues(t, 5, clone.CountValues())
+}
+
+func TestMultiMap_DeepClone(t *testing.T) {
+	inner := NewMap[int, int]()
+	m := NewMultiMap[int, *Map[int, int]]()
+	m.Add(1, inner)
+	clone := m.DeepClone()
+	inner.Set(1, 1)
+	assert.True(t, clone.GetAll(1)[0].IsEmpty())
+}
+
+func TestMultiMap_Concurrent(t *testing.T) {
+	m := NewMultiMap[int, int]()
+	wg := new(sync.WaitGroup)
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			m.Add(i%10, i)
+		}(i)
+	}
+	wg.Wait()
+	assert.EqualValues(t, 10, m.Count())
+	assert.EqualValues(t, 100, m.CountValues())
+}
@@ -0,0 +1,198 @@
+package kv
+
+import (
+	"encoding/json"
+	"fmt"
+	"iter"
+	"log/slog"
+	"sync/atomic"
+
+	"github.com/gopi-frame/collection/internal/preview"
+	"github.com/gopi-frame/collection/internal/skiplist"
+	"github.com/gopi-frame/contract"
+)
+
+// NewSkipListMap new sorted map ordered by the comparator of the keys
+func NewSkipListMap[K, V any](comparator contract.Comparator[K]) *SkipListMap[K, V] {
+	return &SkipListMap[K, V]{
+		list: skiplist.New(func(a, b *skipEntry[K, V]) int {
+			return comparator.Compare(a.key, b.key)
+		}),
+	}
+}
+
+// SkipListMap concurrent sorted map based on a lazy skip list, all methods are safe for concurrent use.
+// Set and Remove only lock the entries next to the key, and Get and the iterations take no lock at all,
+// so it scales with the number of goroutines where [TreeMap] serializes every change behind a single lock.
+// The iterations are weakly consistent: they see the keys in order, and an entry set or removed meanwhile
+// may or may not be seen. Count is exact once the changes are done.
+type SkipListMap[K, V any] struct {
+	list  *skiplist.List[*skipEntry[K, V]]
+	limit preview.Limit
+}
+
+// skipEntry entry of a skip list map, the value is replaced in place when an existing key is set
+type skipEntry[K, V any] struct {
+	key   K
+	value atomic.Pointer[V]
+}
+
+// skipProbe returns an entry of the key to look up the list with
+func skipProbe[K, V any](key K) *skipEntry[K, V] {
+	return &skipEntry[K, V]{key: key}
+}
+
+// Count returns the size of map
+func (m *SkipListMap[K, V]) Count() int64 {
+	return m.list.Count()
+}
+
+// IsEmpty returns whether the map is empty
+func (m *SkipListMap[K, V]) IsEmpty() bool {
+	return m.Count() == 0
+}
+
+// IsNotEmpty returns whether the map is not empty
+func (m *SkipListMap[K, V]) IsNotEmpty() bool {
+	return !m.IsEmpty()
+}
+
+// Get returns the value of the specific key, it takes no lock
+func (m *SkipListMap[K, V]) Get(key K) (V, bool) {
+	if entry, ok := m.list.Get(skipProbe[K, V](key)); ok {
+		return *entry.value.Load(), true
+	}
+	return *new(V), false
+}
+
+// GetOr returns the value of the specific key, or the default value when the key is not exist
+func (m *SkipListMap[K, V]) GetOr(key K, value V) V {
+	if v, ok := m.Get(key); ok {
+		return v
+	}
+	return value
+}
+
+// ContainsKey returns whether the map contains the specific key, it takes no lock
+func (m *SkipListMap[K, V]) ContainsKey(key K) bool {
+	_, ok := m.list.Get(skipProbe[K, V](key))
+	return ok
+}
+
+// Set sets the value to the specific key, it returns true when the key is new
+func (m *SkipListMap[K, V]) Set(key K, value V) bool {
+	entry := skipProbe[K, V](key)
+	entry.value.Store(&value)
+	held, added := m.list.Add(entry)
+	if !added {
+		held.value.Store(&value)
+	}
+	return added
+}
+
+// Remove removes the specific key, it returns false when the map does not contain it
+func (m *SkipListMap[K, V]) Remove(key K) bool {
+	return m.list.Remove(skipProbe[K, V](key))
+}
+
+// First returns the entry of the smallest key, it returns zero values and false when the map is empty
+func (m *SkipListMap[K, V]) First() (K, V, bool) {
+	if entry, ok := m.list.First(); ok {
+		return entry.key, *entry.value.Load(), true
+	}
+	return *new(K), *new(V), false
+}
+
+// All returns an iterator over the entries in the order of the keys, it is weakly consistent
+func (m *SkipListMap[K, V]) All() iter.Seq2[K, V] {
+	return m.entries(m.list.All())
+}
+
+// Range returns an iterator over the entries whose keys are in the range [from, to) in order, it is weakly consistent
+func (m *SkipListMap[K, V]) Range(from, to K) iter.Seq2[K, V] {
+	return m.entries(m.list.Range(skipProbe[K, V](from), skipProbe[K, V](to)))
+}
+
+func (m *SkipListMap[K, V]) entries(entries iter.Seq[*skipEntry[K, V]]) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for entry := range entries {
+			if !yield(entry.key, *entry.value.Load()) {
+				return
+			}
+		}
+	}
+}
+
+// Keys returns the keys in order
+func (m *SkipListMap[K, V]) Keys() []K {
+	keys, _ := m.snapshot()
+	return keys
+}
+
+// Values returns the values in the order of the keys
+func (m *SkipListMap[K, V]) Values() []V {
+	_, values := m.snapshot()
+	return values
+}
+
+func (m *SkipListMap[K, V]) snapshot() ([]K, []V) {
+	keys := make([]K, 0, m.Count())
+	values := make([]V, 0, m.Count())
+	for key, value := range m.All() {
+		keys = append(keys, key)
+		values = append(values, value)
+	}
+	return keys, values
+}
+
+// ToJSON converts the map to json bytes, the entries are encoded as an array ordered by key as [TreeMap] does
+func (m *SkipListMap[K, V]) ToJSON() ([]byte, error) {
+	entries := make([]treeEntry[K, V], 0, m.Count())
+	for key, value := range m.All() {
+		entries = append(entries, treeEntry[K, V]{Key: key, Value: value})
+	}
+	return json.Marshal(entries)
+}
+
+// MarshalJSON implements [json.Marshaller]
+func (m *SkipListMap[K, V]) MarshalJSON() ([]byte, error) {
+	return m.ToJSON()
+}
+
+// UnmarshalJSON implements [json.Unmarshaller], the entries are set to the map, so it must be created with a comparator
+func (m *SkipListMap[K, V]) UnmarshalJSON(data []byte) error {
+	var entries []treeEntry[K, V]
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		m.Set(entry.Key, entry.Value)
+	}
+	return nil
+}
+
+// SetPreviewLimit sets the number of entries shown by String, %v and LogValue, a negative limit shows all entries
+func (m *SkipListMap[K, V]) SetPreviewLimit(limit int) {
+	m.limit.Set(limit)
+}
+
+// String converts to string, only the preview limit of entries are shown
+func (m *SkipListMap[K, V]) String() string {
+	return m.format(m.limit.Get(-1))
+}
+
+// Format implements [fmt.Formatter], %v prints the preview as String does, %+v and %#v print all entries
+func (m *SkipListMap[K, V]) Format(f fmt.State, verb rune) {
+	preview.Format(f, verb, m.format, m.limit.Get(-1))
+}
+
+func (m *SkipListMap[K, V]) format(limit int) string {
+	keys, values := m.snapshot()
+	return preview.Entries(fmt.Sprintf("SkipListMap[%T, %T](len=%d)", *new(K), *new(V), len(keys)), keys, values, limit)
+}
+
+// LogValue implements [slog.LogValuer], only the preview limit of entries are logged
+func (m *SkipListMap[K, V]) LogValue() slog.Value {
+	keys, values := m.snapshot()
+	return preview.LogEntries(fmt.Sprintf("SkipListMap[%T, %T]", *new(K), *new(V)), len(keys), keys, values, m.limit.Get(preview.DefaultLimit))
+}
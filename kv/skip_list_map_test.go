@@ -0,0 +1,96 @@
+package kv
+
+import (
+	"encoding/json"
+	"maps"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSkipListMap_Set(t *testing.T) {
+	m := NewSkipListMap[int, string](_intCmp{})
+	assert.True(t, m.Set(3, "c"))
+	assert.True(t, m.Set(1, "a"))
+	assert.False(t, m.Set(3, "C"))
+	assert.EqualValues(t, 2, m.Count())
+	assert.Equal(t, []int{1, 3}, m.Keys())
+	assert.Equal(t, []string{"a", "C"}, m.Values())
+	value, ok := m.Get(3)
+	assert.True(t, ok)
+	assert.Equal(t, "C", value)
+	assert.Equal(t, "x", m.GetOr(2, "x"))
+}
+
+func TestSkipListMap_Remove(t *testing.T) {
+	m := NewSkipListMap[int, string](_intCmp{})
+	m.Set(1, "a")
+	m.Set(2, "b")
+	assert.True(t, m.Remove(1))
+	assert.False(t, m.Remove(1))
+	assert.False(t, m.ContainsKey(1))
+	key, value, ok := m.First()
+	assert.True(t, ok)
+	assert.Equal(t, 2, key)
+	assert.Equal(t, "b", value)
+	m.Remove(2)
+	assert.True(t, m.IsEmpty())
+	_, _, ok = m.First()
+	assert.False(t, ok)
+}
+
+func TestSkipListMap_Range(t *testing.T) {
+	m := NewSkipListMap[int, int](_intCmp{})
+	for i := 20; i > 0; i-- {
+		m.Set(i*5, i)
+	}
+	assert.Equal(t, map[int]int{15: 3, 20: 4, 25: 5}, maps.Collect(m.Range(12, 30)))
+	var keys []int
+	for key := range m.Range(12, 30) {
+		keys = append(keys, key)
+	}
+	assert.Equal(t, []int{15, 20, 25}, keys)
+	assert.Empty(t, maps.Collect(m.Range(30, 30)))
+}
+
+func TestSkipListMap_MarshalJSON(t *testing.T) {
+	m := NewSkipListMap[int, string](_intCmp{})
+	m.Set(2, "b")
+	m.Set(1, "a")
+	data, err := json.Marshal(m)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `[{"key":1,"value":"a"},{"key":2,"value":"b"}]`, string(data))
+	decoded := NewSkipListMap[int, string](_intCmp{})
+	assert.NoError(t, json.Unmarshal(data, decoded))
+	assert.Equal(t, []int{1, 2}, decoded.Keys())
+}
+
+func TestSkipListMap_String(t *testing.T) {
+	m := NewSkipListMap[int, string](_intCmp{})
+	m.Set(1, "a")
+	assert.Equal(t, "SkipListMap[int, string](len=1){\n\t1: a,\n}", m.String())
+}
+
+func TestSkipListMap_Concurrent(t *testing.T) {
+	m := NewSkipListMap[int, int](_intCmp{})
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range 200 {
+				m.Set(j, i)
+				m.Get(j)
+				if j%2 == 0 {
+					m.Remove(j)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	for key := range m.All() {
+		assert.Equal(t, 1, key%2)
+	}
+	assert.EqualValues(t, 100, m.Count())
+}
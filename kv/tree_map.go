@@ -0,0 +1,248 @@
+package kv
+
+import (
+	"fmt"
+	"iter"
+	"log/slog"
+
+	"github.com/gopi-frame/collection"
+	"github.com/gopi-frame/collection/internal/preview"
+	"github.com/gopi-frame/collection/tree"
+	"github.com/gopi-frame/contract"
+)
+
+// treeEntry is an entry of a tree map, it is also the json form of the entry
+type treeEntry[K, V any] struct {
+	Key   K `json:"key"`
+	Value V `json:"value"`
+}
+
+// entryComparator orders the entries by their keys
+type entryComparator[K, V any] struct {
+	comparator contract.Comparator[K]
+}
+
+func (c entryComparator[K, V]) Compare(a, b treeEntry[K, V]) int {
+	return c.comparator.Compare(a.Key, b.Key)
+}
+
+// NewTreeMap new tree map, the keys are ordered by the comparator
+func NewTreeMap[K, V any](comparator contract.Comparator[K]) *TreeMap[K, V] {
+	return &TreeMap[K, V]{entries: tree.NewRBTreeWithPolicy[treeEntry[K, V]](entryComparator[K, V]{comparator}, tree.ReplaceDuplicates)}
+}
+
+// TreeMap sorted map based on a red-black tree of entries, all methods are safe for concurrent use.
+// The entries are kept in key order, two keys are the same key when the comparator returns 0,
+// and the navigation methods find the neighbours of any key in O(log n) time.
+type TreeMap[K, V any] struct {
+	entries *tree.RBTree[treeEntry[K, V]]
+	limit   preview.Limit
+}
+
+// Count returns the size of map
+func (m *TreeMap[K, V]) Count() int64 {
+	return m.entries.Count()
+}
+
+// IsEmpty returns whether the map is empty
+func (m *TreeMap[K, V]) IsEmpty() bool {
+	return m.entries.IsEmpty()
+}
+
+// IsNotEmpty returns whether the map is not empty
+func (m *TreeMap[K, V]) IsNotEmpty() bool {
+	return m.entries.IsNotEmpty()
+}
+
+// Get returns the value of the specific key.
+// A zero value and false will be returned when the given key is not exist
+func (m *TreeMap[K, V]) Get(key K) (V, bool) {
+	entry, ok := m.entries.Floor(treeEntry[K, V]{Key: key})
+	if !ok || m.compare(entry.Key, key) != 0 {
+		return *new(V), false
+	}
+	return entry.Value, true
+}
+
+// GetOr returns the value of the specific key or the default value if the key is not exist
+func (m *TreeMap[K, V]) GetOr(key K, value V) V {
+	if v, ok := m.Get(key); ok {
+		return v
+	}
+	return value
+}
+
+// Set sets value to specific key
+func (m *TreeMap[K, V]) Set(key K, value V) {
+	m.entries.Push(treeEntry[K, V]{Key: key, Value: value})
+}
+
+// Remove removes specific key
+func (m *TreeMap[K, V]) Remove(key K) {
+	m.entries.Remove(treeEntry[K, V]{Key: key})
+}
+
+// ContainsKey returns whether the map contains specific key
+func (m *TreeMap[K, V]) ContainsKey(key K) bool {
+	return m.entries.Contains(treeEntry[K, V]{Key: key})
+}
+
+// Clear clears the map
+func (m *TreeMap[K, V]) Clear() {
+	m.entries.Clear()
+}
+
+func (m *TreeMap[K, V]) compare(a, b K) int {
+	return m.entries.Comparator().Compare(treeEntry[K, V]{Key: a}, treeEntry[K, V]{Key: b})
+}
+
+// FirstKey returns the smallest key, it returns zero value and false when the map is empty
+func (m *TreeMap[K, V]) FirstKey() (K, bool) {
+	return keyOf(m.entries.First())
+}
+
+// LastKey returns the largest key, it returns zero value and false when the map is empty
+func (m *TreeMap[K, V]) LastKey() (K, bool) {
+	return keyOf(m.entries.Last())
+}
+
+// FloorKey returns the largest key less than or equal to the key.
+// It returns zero value and false when there is no such key.
+func (m *TreeMap[K, V]) FloorKey(key K) (K, bool) {
+	return keyOf(m.entries.Floor(treeEntry[K, V]{Key: key}))
+}
+
+// CeilingKey returns the smallest key greater than or equal to the key.
+// It returns zero value and false when there is no such key.
+func (m *TreeMap[K, V]) CeilingKey(key K) (K, bool) {
+	return keyOf(m.entries.Ceiling(treeEntry[K, V]{Key: key}))
+}
+
+func keyOf[K, V any](entry treeEntry[K, V], ok bool) (K, bool) {
+	return entry.Key, ok
+}
+
+// SubMap returns a new map with the entries whose keys are in the range [from, to).
+// The new map keeps the comparator, and it is built in O(k) for k entries after the range is located.
+func (m *TreeMap[K, V]) SubMap(from, to K) *TreeMap[K, V] {
+	return &TreeMap[K, V]{entries: m.entries.Sub(treeEntry[K, V]{Key: from}, treeEntry[K, V]{Key: to})}
+}
+
+// HeadMap returns a new map with the entries whose keys are less than the key, see SubMap
+func (m *TreeMap[K, V]) HeadMap(to K) *TreeMap[K, V] {
+	first, ok := m.entries.First()
+	if !ok {
+		return m.Clone()
+	}
+	return &TreeMap[K, V]{entries: m.entries.Sub(first, treeEntry[K, V]{Key: to})}
+}
+
+// TailMap returns a new map with the entries whose keys are greater than or equal to the key, see SubMap
+func (m *TreeMap[K, V]) TailMap(from K) *TreeMap[K, V] {
+	last, ok := m.entries.Last()
+	tail := &TreeMap[K, V]{entries: m.entries.Sub(treeEntry[K, V]{Key: from}, last)}
+	if ok && m.compare(last.Key, from) >= 0 {
+		tail.entries.Push(last)
+	}
+	return tail
+}
+
+// Keys returns all keys in order
+func (m *TreeMap[K, V]) Keys() []K {
+	keys, _ := m.snapshot()
+	return keys
+}
+
+// Values returns all values ordered by key
+func (m *TreeMap[K, V]) Values() []V {
+	_, values := m.snapshot()
+	return values
+}
+
+// Each ranges the map ordered by key, it will break the loop when the callback returns false.
+// The entries are snapshotted before visiting, so it is allowed to modify the map in callback.
+func (m *TreeMap[K, V]) Each(callback func(key K, value V) bool) {
+	for _, entry := range m.entries.ToArray() {
+		if !callback(entry.Key, entry.Value) {
+			return
+		}
+	}
+}
+
+func (m *TreeMap[K, V]) snapshot() ([]K, []V) {
+	entries := m.entries.ToArray()
+	keys := make([]K, len(entries))
+	values := make([]V, len(entries))
+	for index, entry := range entries {
+		keys[index] = entry.Key
+		values[index] = entry.Value
+	}
+	return keys, values
+}
+
+// All returns an iterator over the key value pairs in order
+func (m *TreeMap[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		m.Each(func(key K, value V) bool {
+			return yield(key, value)
+		})
+	}
+}
+
+// ToJSON converts the map to json bytes, the entries are encoded as an array ordered by key
+func (m *TreeMap[K, V]) ToJSON() ([]byte, error) {
+	return m.entries.ToJSON()
+}
+
+// MarshalJSON implements [json.Marshaller]
+func (m *TreeMap[K, V]) MarshalJSON() ([]byte, error) {
+	return m.ToJSON()
+}
+
+// UnmarshalJSON implements [json.Unmarshaller], the entries replace the entries of the map,
+// the last entry wins when the keys of several entries are the same
+func (m *TreeMap[K, V]) UnmarshalJSON(data []byte) error {
+	return m.entries.UnmarshalJSON(data)
+}
+
+// SetPreviewLimit sets the number of entries shown by String, %v and LogValue, a negative limit shows all entries
+func (m *TreeMap[K, V]) SetPreviewLimit(limit int) {
+	m.limit.Set(limit)
+}
+
+// String converts to string, only the preview limit of entries are shown
+func (m *TreeMap[K, V]) String() string {
+	return m.format(m.limit.Get(-1))
+}
+
+// Format implements [fmt.Formatter], %v prints the preview as String does, %+v and %#v print all entries
+func (m *TreeMap[K, V]) Format(f fmt.State, verb rune) {
+	preview.Format(f, verb, m.format, m.limit.Get(-1))
+}
+
+func (m *TreeMap[K, V]) format(limit int) string {
+	keys, values := m.snapshot()
+	return preview.Entries(fmt.Sprintf("TreeMap[%T, %T](len=%d)", *new(K), *new(V), len(keys)), keys, values, limit)
+}
+
+// LogValue implements [slog.LogValuer], only the preview limit of entries are logged
+func (m *TreeMap[K, V]) LogValue() slog.Value {
+	keys, values := m.snapshot()
+	return preview.LogEntries(fmt.Sprintf("TreeMap[%T, %T]", *new(K), *new(V)), len(keys), keys, values, m.limit.Get(preview.DefaultLimit))
+}
+
+// Clone clones the map
+func (m *TreeMap[K, V]) Clone() *TreeMap[K, V] {
+	return &TreeMap[K, V]{entries: m.entries.Clone()}
+}
+
+// DeepClone clones the map and its values, see [collection.CloneValue], the keys are not cloned
+func (m *TreeMap[K, V]) DeepClone() *TreeMap[K, V] {
+	entries := m.entries.ToArray()
+	for index := range entries {
+		entries[index].Value = collection.CloneValue(entries[index].Value)
+	}
+	mm := &TreeMap[K, V]{entries: tree.NewRBTreeWithPolicy(m.entries.Comparator(), tree.ReplaceDuplicates)}
+	mm.entries.BulkLoad(entries)
+	return mm
+}
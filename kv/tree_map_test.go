@@ -0,0 +1,118 @@
+package kv
+
+import (
+	"encoding/json"
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func _newTreeMap() *TreeMap[int, string] {
+	m := NewTreeMap[int, string](_intCmp{})
+	m.Set(30, "c")
+	m.Set(10, "a")
+	m.Set(20, "b")
+	return m
+}
+
+func TestTreeMap_Set(t *testing.T) {
+	m := _newTreeMap()
+	m.Set(20, "bb")
+	assert.EqualValues(t, 3, m.Count())
+	assert.Equal(t, []int{10, 20, 30}, m.Keys())
+	assert.Equal(t, []string{"a", "bb", "c"}, m.Values())
+	value, ok := m.Get(20)
+	assert.True(t, ok)
+	assert.Equal(t, "bb", value)
+	_, ok = m.Get(25)
+	assert.False(t, ok)
+	assert.Equal(t, "z", m.GetOr(5, "z"))
+	assert.True(t, m.ContainsKey(30))
+	assert.False(t, m.ContainsKey(5))
+}
+
+func TestTreeMap_Remove(t *testing.T) {
+	m := _newTreeMap()
+	m.Remove(20)
+	m.Remove(25)
+	assert.Equal(t, []int{10, 30}, m.Keys())
+	m.Clear()
+	assert.True(t, m.IsEmpty())
+}
+
+func TestTreeMap_Navigation(t *testing.T) {
+	m := _newTreeMap()
+	key, ok := m.FirstKey()
+	assert.True(t, ok)
+	assert.Equal(t, 10, key)
+	key, _ = m.LastKey()
+	assert.Equal(t, 30, key)
+	key, _ = m.FloorKey(25)
+	assert.Equal(t, 20, key)
+	key, _ = m.CeilingKey(25)
+	assert.Equal(t, 30, key)
+	key, _ = m.CeilingKey(20)
+	assert.Equal(t, 20, key)
+	_, ok = m.FloorKey(5)
+	assert.False(t, ok)
+	_, ok = m.CeilingKey(35)
+	assert.False(t, ok)
+	_, ok = NewTreeMap[int, string](_intCmp{}).FirstKey()
+	assert.False(t, ok)
+}
+
+func TestTreeMap_SubMap(t *testing.T) {
+	m := _newTreeMap()
+	assert.Equal(t, []int{10, 20}, m.SubMap(5, 30).Keys())
+	assert.Equal(t, []int{10, 20}, m.HeadMap(30).Keys())
+	assert.Empty(t, m.HeadMap(10).Keys())
+	assert.Equal(t, []int{20, 30}, m.TailMap(20).Keys())
+	assert.Equal(t, []int{30}, m.TailMap(25).Keys())
+	assert.Empty(t, m.TailMap(35).Keys())
+
+	empty := NewTreeMap[int, string](_intCmp{})
+	assert.True(t, empty.HeadMap(10).IsEmpty())
+	assert.True(t, empty.TailMap(10).IsEmpty())
+
+	tail := m.TailMap(20)
+	tail.Set(20, "x")
+	assert.EqualValues(t, 2, tail.Count())
+	assert.Equal(t, "b", m.GetOr(20, ""))
+}
+
+func TestTreeMap_Each(t *testing.T) {
+	m := _newTreeMap()
+	var keys []int
+	m.Each(func(key int, value string) bool {
+		keys = append(keys, key)
+		m.Remove(key)
+		return key < 20
+	})
+	assert.Equal(t, []int{10, 20}, keys)
+	assert.Equal(t, []int{30}, m.Keys())
+	assert.Equal(t, map[int]string{30: "c"}, maps.Collect(m.All()))
+}
+
+func TestTreeMap_JSON(t *testing.T) {
+	m := _newTreeMap()
+	data, err := json.Marshal(m)
+	assert.Nil(t, err)
+	assert.Equal(t, `[{"key":10,"value":"a"},{"key":20,"value":"b"},{"key":30,"value":"c"}]`, string(data))
+
+	mm := NewTreeMap[int, string](_intCmp{})
+	assert.Nil(t, json.Unmarshal([]byte(`[{"key":2,"value":"x"},{"key":1,"value":"y"},{"key":2,"value":"z"}]`), mm))
+	assert.Equal(t, []int{1, 2}, mm.Keys())
+	assert.Equal(t, "z", mm.GetOr(2, ""))
+	assert.Equal(t, "TreeMap[int, string](len=2){\n\t1: y,\n\t2: z,\n}", mm.String())
+}
+
+func TestTreeMap_DeepClone(t *testing.T) {
+	mm := _newTreeMap()
+	clone := mm.DeepClone()
+	clone.Set(10, "z")
+	assert.EqualValues(t, 3, clone.Count())
+	assert.Equal(t, "a", mm.GetOr(10, ""))
+	assert.True(t, slices.Equal(mm.Keys(), clone.Keys()))
+}
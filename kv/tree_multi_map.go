@@ -0,0 +1,421 @@
+package kv
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+	"log/slog"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/gopi-frame/collection"
+	"github.com/gopi-frame/collection/internal/codec"
+	"github.com/gopi-frame/collection/internal/jsonstream"
+	"github.com/gopi-frame/collection/internal/preview"
+	"github.com/gopi-frame/collection/tree"
+	"github.com/gopi-frame/contract"
+)
+
+type jsonMultiEntry[K comparable, V any] struct {
+	Key    K   `json:"key"`
+	Values []V `json:"values"`
+}
+
+// NewTreeMultiMap new tree multi map, the keys are ordered by the comparator.
+// The comparator must treat two keys as equal only when they are equal with ==.
+func NewTreeMultiMap[K comparable, V any](comparator contract.Comparator[K]) *TreeMultiMap[K, V] {
+	m := new(TreeMultiMap[K, V])
+	m.keys = tree.NewRBTreeWithPolicy[K](comparator, tree.IgnoreDuplicates)
+	m.items = make(map[K][]V)
+	return m
+}
+
+// TreeMultiMap map allowing multiple values per key, ordered by key, all methods are safe for concurrent use
+type TreeMultiMap[K comparable, V any] struct {
+	lock  sync.RWMutex
+	keys  *tree.RBTree[K]
+	items map[K][]V
+	size  int64
+	limit preview.Limit
+}
+
+// Count returns the number of values in the map
+func (m *TreeMultiMap[K, V]) Count() int64 {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return m.size
+}
+
+// IsEmpty returns whether the map is empty
+func (m *TreeMultiMap[K, V]) IsEmpty() bool {
+	return m.Count() == 0
+}
+
+// IsNotEmpty returns whether the map is not empty
+func (m *TreeMultiMap[K, V]) IsNotEmpty() bool {
+	return !m.IsEmpty()
+}
+
+// Put appends values to the specific key
+func (m *TreeMultiMap[K, V]) Put(key K, values ...V) {
+	if len(values) == 0 {
+		return
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.put(key, values...)
+}
+
+func (m *TreeMultiMap[K, V]) put(key K, values ...V) {
+	if _, ok := m.items[key]; !ok {
+		m.keys.Push(key)
+	}
+	m.items[key] = append(m.items[key], values...)
+	m.size += int64(len(values))
+}
+
+// Get returns the first value of the specific key.
+// A zero value and false will be returned when the given key is not exist
+func (m *TreeMultiMap[K, V]) Get(key K) (V, bool) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	values, ok := m.items[key]
+	if !ok {
+		return *new(V), false
+	}
+	return values[0], true
+}
+
+// GetAll returns all values of the specific key in insertion order
+func (m *TreeMultiMap[K, V]) GetAll(key K) []V {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return slices.Clone(m.items[key])
+}
+
+// Remove removes all values of the specific key
+func (m *TreeMultiMap[K, V]) Remove(key K) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	values, ok := m.items[key]
+	if !ok {
+		return
+	}
+	m.keys.Remove(key)
+	delete(m.items, key)
+	m.size -= int64(len(values))
+}
+
+// RemoveValue removes the first occurrence of the value from the specific key,
+// it returns false if the value is not found
+func (m *TreeMultiMap[K, V]) RemoveValue(key K, value V) bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	values := m.items[key]
+	index := slices.IndexFunc(values, func(v V) bool {
+		return reflect.DeepEqual(v, value)
+	})
+	if index < 0 {
+		return false
+	}
+	if len(values) == 1 {
+		m.keys.Remove(key)
+		delete(m.items, key)
+	} else {
+		m.items[key] = slices.Delete(values, index, index+1)
+	}
+	m.size--
+	return true
+}
+
+// Keys returns all distinct keys in order
+func (m *TreeMultiMap[K, V]) Keys() []K {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return m.keys.ToArray()
+}
+
+// Values returns all values ordered by key
+func (m *TreeMultiMap[K, V]) Values() []V {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	values := make([]V, 0, m.size)
+	for _, key := range m.keys.ToArray() {
+		values = append(values, m.items[key]...)
+	}
+	return values
+}
+
+// Clear clears the map
+func (m *TreeMultiMap[K, V]) Clear() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.keys.Clear()
+	m.items = make(map[K][]V)
+	m.size = 0
+}
+
+// ContainsKey returns whether the map contains the specific key
+func (m *TreeMultiMap[K, V]) ContainsKey(key K) bool {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	_, ok := m.items[key]
+	return ok
+}
+
+// Contains returns whether the map contains the specific value
+func (m *TreeMultiMap[K, V]) Contains(value V) bool {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	for _, values := range m.items {
+		for _, v := range values {
+			if reflect.DeepEqual(v, value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Each ranges the map ordered by key, it will break the loop when the callback returns false.
+// The entries are snapshotted before visiting, so it is allowed to modify the map in callback.
+func (m *TreeMultiMap[K, V]) Each(callback func(key K, value V) bool) {
+	for _, entry := range m.entries() {
+		for _, value := range entry.Values {
+			if !callback(entry.Key, value) {
+				return
+			}
+		}
+	}
+}
+
+func (m *TreeMultiMap[K, V]) entries() []jsonMultiEntry[K, V] {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	keys := m.keys.ToArray()
+	entries := make([]jsonMultiEntry[K, V], 0, len(keys))
+	for _, key := range keys {
+		entries = append(entries, jsonMultiEntry[K, V]{Key: key, Values: slices.Clone(m.items[key])})
+	}
+	return entries
+}
+
+// All returns an iterator over the key value pairs in order
+func (m *TreeMultiMap[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		m.Each(func(key K, value V) bool {
+			return yield(key, value)
+		})
+	}
+}
+
+// ToJSON converts the map to json bytes, the entries are encoded as an array ordered by key
+func (m *TreeMultiMap[K, V]) ToJSON() ([]byte, error) {
+	return json.Marshal(m.entries())
+}
+
+// MarshalJSON implements [json.Marshaller]
+func (m *TreeMultiMap[K, V]) MarshalJSON() ([]byte, error) {
+	return m.ToJSON()
+}
+
+// UnmarshalJSON implements [json.Unmarshaller]
+func (m *TreeMultiMap[K, V]) UnmarshalJSON(data []byte) error {
+	var entries []jsonMultiEntry[K, V]
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return err
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.keys.Clear()
+	m.items = make(map[K][]V)
+	m.size = 0
+	for _, entry := range entries {
+		if len(entry.Values) > 0 {
+			m.put(entry.Key, entry.Values...)
+		}
+	}
+	return nil
+}
+
+// EncodeJSON writes the map to w in the format of ToJSON one key at a time, the map is read locked while writing
+func (m *TreeMultiMap[K, V]) EncodeJSON(w io.Writer) error {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return jsonstream.Encode(w, func(yield func(jsonMultiEntry[K, V]) bool) {
+		for key := range m.keys.All() {
+			if !yield(jsonMultiEntry[K, V]{Key: key, Values: m.items[key]}) {
+				return
+			}
+		}
+	})
+}
+
+// DecodeJSON reads the format of ToJSON from r one key at a time and replaces the entries of the map
+func (m *TreeMultiMap[K, V]) DecodeJSON(r io.Reader) error {
+	items := make(map[K][]V)
+	size := 0
+	if err := jsonstream.Decode(r, func(entry jsonMultiEntry[K, V]) {
+		if len(entry.Values) > 0 {
+			items[entry.Key] = append(items[entry.Key], entry.Values...)
+			size += len(entry.Values)
+		}
+	}); err != nil {
+		return err
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.keys.Clear()
+	for key := range items {
+		m.keys.Add(key)
+	}
+	m.items = items
+	m.size = int64(size)
+	return nil
+}
+
+// ToCBOR converts to cbor
+func (m *TreeMultiMap[K, V]) ToCBOR() ([]byte, error) {
+	return cbor.Marshal(m.entries())
+}
+
+// MarshalCBOR implements [cbor.Marshaler]
+func (m *TreeMultiMap[K, V]) MarshalCBOR() ([]byte, error) {
+	return m.ToCBOR()
+}
+
+// UnmarshalCBOR implements [cbor.Unmarshaler]
+func (m *TreeMultiMap[K, V]) UnmarshalCBOR(data []byte) error {
+	var entries []jsonMultiEntry[K, V]
+	if err := cbor.Unmarshal(data, &entries); err != nil {
+		return err
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.keys.Clear()
+	m.items = make(map[K][]V)
+	m.size = 0
+	for _, entry := range entries {
+		if len(entry.Values) > 0 {
+			m.put(entry.Key, entry.Values...)
+		}
+	}
+	return nil
+}
+
+// MarshalBinary implements [encoding.BinaryMarshaler], the entries are encoded in key order,
+// each key is followed by the sequence of its values
+func (m *TreeMultiMap[K, V]) MarshalBinary() ([]byte, error) {
+	entries := m.entries()
+	keys := make([]K, len(entries))
+	values := make([][]byte, len(entries))
+	for index, entry := range entries {
+		data, err := codec.EncodeValues(entry.Values)
+		if err != nil {
+			return nil, err
+		}
+		keys[index] = entry.Key
+		values[index] = data
+	}
+	return codec.EncodeEntries(keys, values)
+}
+
+// UnmarshalBinary implements [encoding.BinaryUnmarshaler]
+func (m *TreeMultiMap[K, V]) UnmarshalBinary(data []byte) error {
+	keys, encoded, err := codec.DecodeEntries[K, []byte](data)
+	if err != nil {
+		return err
+	}
+	values := make([][]V, len(keys))
+	for index, data := range encoded {
+		if values[index], err = codec.DecodeValues[V](data); err != nil {
+			return err
+		}
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.keys.Clear()
+	m.items = make(map[K][]V)
+	m.size = 0
+	for index, key := range keys {
+		if len(values[index]) > 0 {
+			m.put(key, values[index]...)
+		}
+	}
+	return nil
+}
+
+// SetPreviewLimit sets the number of entries shown by String, %v and LogValue, a negative limit shows all entries
+func (m *TreeMultiMap[K, V]) SetPreviewLimit(limit int) {
+	m.limit.Set(limit)
+}
+
+// String converts to string, only the preview limit of entries are shown
+func (m *TreeMultiMap[K, V]) String() string {
+	return m.format(m.limit.Get(-1))
+}
+
+// Format implements [fmt.Formatter], %v prints the preview as String does, %+v and %#v print all entries
+func (m *TreeMultiMap[K, V]) Format(f fmt.State, verb rune) {
+	preview.Format(f, verb, m.format, m.limit.Get(-1))
+}
+
+func (m *TreeMultiMap[K, V]) format(limit int) string {
+	entries := m.entries()
+	keys := make([]K, len(entries))
+	values := make([]string, len(entries))
+	size := 0
+	for index, entry := range entries {
+		items := make([]string, len(entry.Values))
+		for i, value := range entry.Values {
+			items[i] = preview.Value(value)
+		}
+		keys[index] = entry.Key
+		values[index] = "[" + strings.Join(items, ", ") + "]"
+		size += len(entry.Values)
+	}
+	return preview.Entries(fmt.Sprintf("TreeMultiMap[%T, %T](len=%d)", *new(K), *new(V), size), keys, values, limit)
+}
+
+// LogValue implements [slog.LogValuer], only the preview limit of keys are logged with all their values
+func (m *TreeMultiMap[K, V]) LogValue() slog.Value {
+	entries := m.entries()
+	keys := make([]K, len(entries))
+	values := make([][]V, len(entries))
+	size := 0
+	for index, entry := range entries {
+		keys[index] = entry.Key
+		values[index] = entry.Values
+		size += len(entry.Values)
+	}
+	return preview.LogEntries(fmt.Sprintf("TreeMultiMap[%T, %T]", *new(K), *new(V)), size, keys, values, m.limit.Get(preview.DefaultLimit))
+}
+
+// Clone clones the map
+func (m *TreeMultiMap[K, V]) Clone() *TreeMultiMap[K, V] {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	mm := new(TreeMultiMap[K, V])
+	mm.keys = m.keys.Clone()
+	mm.items = make(map[K][]V, len(m.items))
+	for key, values := range m.items {
+		mm.items[key] = slices.Clone(values)
+	}
+	mm.size = m.size
+	return mm
+}
+
+// DeepClone clones the map and its values, see [collection.CloneValue], the keys are not cloned
+func (m *TreeMultiMap[K, V]) DeepClone() *TreeMultiMap[K, V] {
+	mm := m.Clone()
+	for _, values := range mm.items {
+		for index, value := range values {
+			values[index] = collection.CloneValue(value)
+		}
+	}
+	return mm
+}
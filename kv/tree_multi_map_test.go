@@ -0,0 +1,217 @@
+package kv
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+type _intCmp struct{}
+
+func (c _intCmp) Compare(a, b int) int {
+	if a < b {
+		return -1
+	} else if a > b {
+		return 1
+	}
+	return 0
+}
+
+func _newTreeMultiMap() *TreeMultiMap[int, string] {
+	m := NewTreeMultiMap[int, string](_intCmp{})
+	m.Put(3, "c1", "c2")
+	m.Put(1, "a1")
+	m.Put(2, "b1")
+	m.Put(1, "a2")
+	return m
+}
+
+func TestTreeMultiMap_Count(t *testing.T) {
+	m := _newTreeMultiMap()
+	assert.EqualValues(t, 5, m.Count())
+	assert.True(t, m.IsNotEmpty())
+	m.Put(4)
+	assert.EqualValues(t, 5, m.Count())
+	assert.False(t, m.ContainsKey(4))
+}
+
+func TestTreeMultiMap_Get(t *testing.T) {
+	m := _newTreeMultiMap()
+	value, ok := m.Get(1)
+	assert.True(t, ok)
+	assert.Equal(t, "a1", value)
+	value, ok = m.Get(4)
+	assert.False(t, ok)
+	assert.Equal(t, "", value)
+}
+
+func TestTreeMultiMap_GetAll(t *testing.T) {
+	m := _newTreeMultiMap()
+	assert.Equal(t, []string{"a1", "a2"}, m.GetAll(1))
+	assert.Nil(t, m.GetAll(4))
+}
+
+func TestTreeMultiMap_Remove(t *testing.T) {
+	m := _newTreeMultiMap()
+	m.Remove(3)
+	m.Remove(4)
+	assert.EqualValues(t, 3, m.Count())
+	assert.Equal(t, []int{1, 2}, m.Keys())
+}
+
+func TestTreeMultiMap_RemoveValue(t *testing.T) {
+	m := _newTreeMultiMap()
+	assert.True(t, m.RemoveValue(1, "a1"))
+	assert.False(t, m.RemoveValue(1, "a1"))
+	assert.Equal(t, []string{"a2"}, m.GetAll(1))
+	assert.True(t, m.RemoveValue(2, "b1"))
+	assert.False(t, m.ContainsKey(2))
+	assert.Equal(t, []int{1, 3}, m.Keys())
+	assert.EqualValues(t, 3, m.Count())
+}
+
+func TestTreeMultiMap_Keys(t *testing.T) {
+	assert.Equal(t, []int{1, 2, 3}, _newTreeMultiMap().Keys())
+}
+
+func TestTreeMultiMap_Values(t *testing.T) {
+	assert.Equal(t, []string{"a1", "a2", "b1", "c1", "c2"}, _newTreeMultiMap().Values())
+}
+
+func TestTreeMultiMap_Clear(t *testing.T) {
+	m := _newTreeMultiMap()
+	m.Clear()
+	assert.True(t, m.IsEmpty())
+	assert.Empty(t, m.Keys())
+}
+
+func TestTreeMultiMap_Contains(t *testing.T) {
+	m := _newTreeMultiMap()
+	assert.True(t, m.Contains("b1"))
+	assert.False(t, m.Contains("d1"))
+}
+
+func TestTreeMultiMap_Each(t *testing.T) {
+	m := _newTreeMultiMap()
+	var keys []int
+	var values []string
+	m.Each(func(key int, value string) bool {
+		keys = append(keys, key)
+		values = append(values, value)
+		m.Remove(key)
+		return value != "b1"
+	})
+	assert.Equal(t, []int{1, 1, 2}, keys)
+	assert.Equal(t, []string{"a1", "a2", "b1"}, values)
+	assert.Equal(t, []int{3}, m.Keys())
+}
+
+func TestTreeMultiMap_ToJSON(t *testing.T) {
+	m := _newTreeMultiMap()
+	jsonBytes, err := m.ToJSON()
+	assert.Nil(t, err)
+	assert.JSONEq(t, `[{"key":1,"values":["a1","a2"]},{"key":2,"values":["b1"]},{"key":3,"values":["c1","c2"]}]`, string(jsonBytes))
+}
+
+func TestTreeMultiMap_MarshalJSON(t *testing.T) {
+	m := _newTreeMultiMap()
+	jsonBytes, err := json.Marshal(m)
+	assert.Nil(t, err)
+	assert.JSONEq(t, `[{"key":1,"values":["a1","a2"]},{"key":2,"values":["b1"]},{"key":3,"values":["c1","c2"]}]`, string(jsonBytes))
+}
+
+func TestTreeMultiMap_UnmarshalJSON(t *testing.T) {
+	m := NewTreeMultiMap[int, string](_intCmp{})
+	err := json.Unmarshal([]byte(`[{"key":2,"values":["b1"]},{"key":1,"values":["a1","a2"]},{"key":3,"values":[]}]`), m)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1, 2}, m.Keys())
+	assert.Equal(t, []string{"a1", "a2", "b1"}, m.Values())
+	assert.EqualValues(t, 3, m.Count())
+}
+
+func TestTreeMultiMap_EncodeJSON(t *testing.T) {
+	m := _newTreeMultiMap()
+	buf := new(bytes.Buffer)
+	assert.Nil(t, m.EncodeJSON(buf))
+	expected, _ := m.ToJSON()
+	assert.JSONEq(t, string(expected), buf.String())
+}
+
+func TestTreeMultiMap_DecodeJSON(t *testing.T) {
+	m := _newTreeMultiMap()
+	err := m.DecodeJSON(strings.NewReader(`[{"key":2,"values":["b1"]},{"key":1,"values":["a1","a2"]},{"key":3,"values":[]}]`))
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1, 2}, m.Keys())
+	assert.Equal(t, []string{"a1", "a2", "b1"}, m.Values())
+	assert.EqualValues(t, 3, m.Count())
+}
+
+func TestTreeMultiMap_String(t *testing.T) {
+	m := _newTreeMultiMap()
+	assert.Equal(t, "TreeMultiMap[int, string](len=5){\n\t1: [a1, a2],\n\t2: [b1],\n\t3: [c1, c2],\n}", m.String())
+}
+
+func TestTreeMultiMap_Clone(t *testing.T) {
+	m := _newTreeMultiMap()
+	clone := m.Clone()
+	m.Put(1, "a3")
+	assert.Equal(t, []string{"a1", "a2"}, clone.GetAll(1))
+	assert.Equal(t, []int{1, 2, 3}, clone.Keys())
+	assert.EqualValues(t, 5, clone.Count())
+}
+
+func TestTreeMultiMap_DeepClone(t *testing.T) {
+	inner := NewMap[int, int]()
+	m := NewTreeMultiMap[int, *Map[int, int]](_intCmp{})
+	m.Put(1, inner)
+	clone := m.DeepClone()
+	assert.NotSame(t, inner, clone.GetAll(1)[0])
+	inner.Set(1, 1)
+	assert.True(t, clone.GetAll(1)[0].IsEmpty())
+}
+
+func TestTreeMultiMap_Concurrent(t *testing.T) {
+	m := NewTreeMultiMap[int, int](_intCmp{})
+	wg := new(sync.WaitGroup)
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			m.Put(i%10, i)
+		}(i)
+	}
+	wg.Wait()
+	assert.EqualValues(t, 100, m.Count())
+	assert.Equal(t, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, m.Keys())
+}
+
+func TestTreeMultiMap_MarshalCBOR(t *testing.T) {
+	value := NewTreeMultiMap[int, string](_intCmp{})
+	value.Put(2, "b1")
+	value.Put(1, "a1", "a2")
+	data, err := cbor.Marshal(value)
+	assert.Nil(t, err)
+	decoded := NewTreeMultiMap[int, string](_intCmp{})
+	err = cbor.Unmarshal(data, decoded)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1, 2}, decoded.Keys())
+	assert.Equal(t, []string{"a1", "a2", "b1"}, decoded.Values())
+}
+
+func TestTreeMultiMap_MarshalBinary(t *testing.T) {
+	value := NewTreeMultiMap[int, string](_intCmp{})
+	value.Put(2, "b1")
+	value.Put(1, "a1", "a2")
+	data, err := value.MarshalBinary()
+	assert.Nil(t, err)
+	decoded := NewTreeMultiMap[int, string](_intCmp{})
+	err = decoded.UnmarshalBinary(data)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1, 2}, decoded.Keys())
+	assert.Equal(t, []string{"a1", "a2", "b1"}, decoded.Values())
+}
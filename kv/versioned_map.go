@@ -0,0 +1,237 @@
+package kv
+
+import (
+	"encoding/json"
+	"fmt"
+	"iter"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gopi-frame/collection/internal/hash"
+	"github.com/gopi-frame/collection/internal/preview"
+)
+
+// NewVersionedMap new versioned map
+func NewVersionedMap[K comparable, V any]() *VersionedMap[K, V] {
+	m := new(VersionedMap[K, V])
+	m.current.Store(new(MapView[K, V]))
+	return m
+}
+
+// VersionedMap map with multi-version reads, all methods are safe for concurrent use.
+// Every change creates a new generation of the map, a hash array mapped trie which shares all but the changed path
+// with the previous generation, so a change takes O(log32 n) time and never copies the whole map.
+// Reads never lock, and View pins the current generation, so a long scan sees a consistent map while the writes go on,
+// without the stop-the-world copy of Clone. The writers are serialized by a lock.
+type VersionedMap[K comparable, V any] struct {
+	lock    sync.Mutex
+	current atomic.Pointer[MapView[K, V]]
+	limit   preview.Limit
+}
+
+// MapView immutable generation of a [VersionedMap], it is safe for concurrent use
+type MapView[K comparable, V any] struct {
+	root    *hamtNode[K, V]
+	size    int64
+	version uint64
+}
+
+// View returns the current generation of the map, it is not affected by later changes
+func (m *VersionedMap[K, V]) View() *MapView[K, V] {
+	return m.current.Load()
+}
+
+// update creates the next generation from the current one by the change, which returns the new root and the size difference
+func (m *VersionedMap[K, V]) update(change func(view *MapView[K, V]) (*hamtNode[K, V], int64)) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	view := m.current.Load()
+	root, diff := change(view)
+	if root == view.root && diff == 0 {
+		return
+	}
+	m.current.Store(&MapView[K, V]{root: root, size: view.size + diff, version: view.version + 1})
+}
+
+// Set sets the value of the key
+func (m *VersionedMap[K, V]) Set(key K, value V) {
+	h := hash.Value(key)
+	m.update(func(view *MapView[K, V]) (*hamtNode[K, V], int64) {
+		root, added := view.root.set(h, 0, key, value)
+		if added {
+			return root, 1
+		}
+		return root, 0
+	})
+}
+
+// Remove removes the key
+func (m *VersionedMap[K, V]) Remove(key K) {
+	h := hash.Value(key)
+	m.update(func(view *MapView[K, V]) (*hamtNode[K, V], int64) {
+		root, removed := view.root.remove(h, 0, key)
+		if removed {
+			return root, -1
+		}
+		return root, 0
+	})
+}
+
+// Clear removes all keys
+func (m *VersionedMap[K, V]) Clear() {
+	m.update(func(view *MapView[K, V]) (*hamtNode[K, V], int64) {
+		return nil, -view.size
+	})
+}
+
+// Get returns the value of the key in the current generation
+func (m *VersionedMap[K, V]) Get(key K) (V, bool) {
+	return m.View().Get(key)
+}
+
+// ContainsKey returns whether the current generation contains the key
+func (m *VersionedMap[K, V]) ContainsKey(key K) bool {
+	return m.View().ContainsKey(key)
+}
+
+// Count returns the size of the current generation
+func (m *VersionedMap[K, V]) Count() int64 {
+	return m.View().Count()
+}
+
+// IsEmpty returns whether the current generation is empty
+func (m *VersionedMap[K, V]) IsEmpty() bool {
+	return m.Count() == 0
+}
+
+// IsNotEmpty returns whether the current generation is not empty
+func (m *VersionedMap[K, V]) IsNotEmpty() bool {
+	return !m.IsEmpty()
+}
+
+// All returns an iterator over the keys and the values of the current generation
+func (m *VersionedMap[K, V]) All() iter.Seq2[K, V] {
+	return m.View().All()
+}
+
+// ToMap converts the current generation to map, the returned map is a copy, so modifying it does not affect the map
+func (m *VersionedMap[K, V]) ToMap() map[K]V {
+	return m.View().ToMap()
+}
+
+// ToJSON converts to json
+func (m *VersionedMap[K, V]) ToJSON() ([]byte, error) {
+	return json.Marshal(m.ToMap())
+}
+
+// MarshalJSON implements [json.Marshaller]
+func (m *VersionedMap[K, V]) MarshalJSON() ([]byte, error) {
+	return m.ToJSON()
+}
+
+// UnmarshalJSON implements [json.Unmarshaller], the entries replace the entries of the map in a new generation
+func (m *VersionedMap[K, V]) UnmarshalJSON(data []byte) error {
+	values := map[K]V{}
+	if err := json.Unmarshal(data, &values); err != nil {
+		return err
+	}
+	var root *hamtNode[K, V]
+	for key, value := range values {
+		root, _ = root.set(hash.Value(key), 0, key, value)
+	}
+	m.update(func(view *MapView[K, V]) (*hamtNode[K, V], int64) {
+		return root, int64(len(values)) - view.size
+	})
+	return nil
+}
+
+// SetPreviewLimit sets the number of entries shown by String, %v and LogValue, a negative limit shows all entries
+func (m *VersionedMap[K, V]) SetPreviewLimit(limit int) {
+	m.limit.Set(limit)
+}
+
+// String converts to string, only the preview limit of entries are shown
+func (m *VersionedMap[K, V]) String() string {
+	return m.format(m.limit.Get(-1))
+}
+
+// Format implements [fmt.Formatter], %v prints the preview as String does, %+v and %#v print all entries
+func (m *VersionedMap[K, V]) Format(f fmt.State, verb rune) {
+	preview.Format(f, verb, m.format, m.limit.Get(-1))
+}
+
+func (m *VersionedMap[K, V]) format(limit int) string {
+	keys, values := m.View().entries()
+	return preview.Entries(fmt.Sprintf("VersionedMap[%T, %T](len=%d)", *new(K), *new(V), len(keys)), keys, values, limit)
+}
+
+// LogValue implements [slog.LogValuer], only the preview limit of entries are logged
+func (m *VersionedMap[K, V]) LogValue() slog.Value {
+	keys, values := m.View().entries()
+	return preview.LogEntries(fmt.Sprintf("VersionedMap[%T, %T]", *new(K), *new(V)), len(keys), keys, values, m.limit.Get(preview.DefaultLimit))
+}
+
+// Version returns the number of changes made to the map before this generation
+func (v *MapView[K, V]) Version() uint64 {
+	return v.version
+}
+
+// Get returns the value of the key
+func (v *MapView[K, V]) Get(key K) (V, bool) {
+	return v.root.get(hash.Value(key), 0, key)
+}
+
+// ContainsKey returns whether the generation contains the key
+func (v *MapView[K, V]) ContainsKey(key K) bool {
+	_, ok := v.Get(key)
+	return ok
+}
+
+// Count returns the size of the generation
+func (v *MapView[K, V]) Count() int64 {
+	return v.size
+}
+
+// IsEmpty returns whether the generation is empty
+func (v *MapView[K, V]) IsEmpty() bool {
+	return v.size == 0
+}
+
+// Keys returns all keys
+func (v *MapView[K, V]) Keys() []K {
+	keys, _ := v.entries()
+	return keys
+}
+
+// Values returns all values
+func (v *MapView[K, V]) Values() []V {
+	_, values := v.entries()
+	return values
+}
+
+// All returns an iterator over the keys and the values
+func (v *MapView[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		v.root.each(yield)
+	}
+}
+
+// ToMap converts to map, the returned map is a copy, so modifying it does not affect the generation
+func (v *MapView[K, V]) ToMap() map[K]V {
+	values := make(map[K]V, v.size)
+	for key, value := range v.All() {
+		values[key] = value
+	}
+	return values
+}
+
+func (v *MapView[K, V]) entries() ([]K, []V) {
+	keys := make([]K, 0, v.size)
+	values := make([]V, 0, v.size)
+	for key, value := range v.All() {
+		keys = append(keys, key)
+		values = append(values, value)
+	}
+	return keys, values
+}
@@ -0,0 +1,103 @@
+package kv
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVersionedMap_Set(t *testing.T) {
+	m := NewVersionedMap[string, int]()
+	m.Set("a", 1)
+	m.Set("b", 2)
+	m.Set("a", 10)
+	value, ok := m.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 10, value)
+	assert.EqualValues(t, 2, m.Count())
+	assert.EqualValues(t, 3, m.View().Version())
+	assert.Equal(t, map[string]int{"a": 10, "b": 2}, m.ToMap())
+}
+
+func TestVersionedMap_Remove(t *testing.T) {
+	m := NewVersionedMap[string, int]()
+	m.Set("a", 1)
+	m.Remove("b")
+	assert.EqualValues(t, 1, m.View().Version())
+	m.Remove("a")
+	assert.True(t, m.IsEmpty())
+	assert.False(t, m.ContainsKey("a"))
+	m.Set("c", 1)
+	m.Clear()
+	assert.True(t, m.IsEmpty())
+	assert.EqualValues(t, 4, m.View().Version())
+}
+
+func TestVersionedMap_View(t *testing.T) {
+	m := NewVersionedMap[int, int]()
+	for i := range 1000 {
+		m.Set(i, i)
+	}
+	view := m.View()
+	for i := range 1000 {
+		if i%2 == 0 {
+			m.Remove(i)
+		} else {
+			m.Set(i, -i)
+		}
+	}
+	m.Set(1000, 1000)
+	assert.EqualValues(t, 1000, view.Count())
+	assert.Len(t, view.Keys(), 1000)
+	for key, value := range view.All() {
+		assert.Equal(t, key, value)
+	}
+	value, ok := view.Get(2)
+	assert.True(t, ok)
+	assert.Equal(t, 2, value)
+	assert.EqualValues(t, 501, m.Count())
+	value, _ = m.Get(3)
+	assert.Equal(t, -3, value)
+}
+
+func TestVersionedMap_Concurrent(t *testing.T) {
+	m := NewVersionedMap[int, int]()
+	wg := new(sync.WaitGroup)
+	for worker := range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 500 {
+				m.Set(worker*1000+i, i)
+			}
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for range 100 {
+			view := m.View()
+			assert.EqualValues(t, view.Count(), len(view.ToMap()))
+		}
+	}()
+	wg.Wait()
+	assert.EqualValues(t, 2000, m.Count())
+	assert.EqualValues(t, 2000, m.View().Version())
+}
+
+func TestVersionedMap_JSON(t *testing.T) {
+	m := NewVersionedMap[string, int]()
+	m.Set("a", 1)
+	data, err := json.Marshal(m)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"a":1}`, string(data))
+	assert.Nil(t, json.Unmarshal([]byte(`{"b":2,"c":3}`), m))
+	assert.Equal(t, map[string]int{"b": 2, "c": 3}, m.ToMap())
+	assert.EqualValues(t, 2, m.Count())
+	assert.Equal(t, "VersionedMap[string, int](len=1){\n\tb: 2,\n}", func() string {
+		m.Remove("c")
+		return m.String()
+	}())
+}
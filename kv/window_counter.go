@@ -0,0 +1,197 @@
+package kv
+
+import (
+	"encoding/json"
+	"fmt"
+	"iter"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/gopi-frame/collection/internal/preview"
+)
+
+// defaultWindowBuckets is the number of buckets of a window counter created with buckets <= 0
+const defaultWindowBuckets = 10
+
+// NewWindowCounter new window counter which counts over the window with the number of buckets, 10 when buckets <= 0.
+// It panics when the window is too short to be divided into the buckets.
+func NewWindowCounter[K comparable](window time.Duration, buckets int) *WindowCounter[K] {
+	return NewWindowCounterWithClock[K](window, buckets, time.Now)
+}
+
+// NewWindowCounterWithClock new window counter which reads the time from the clock, such as a fake clock in tests
+func NewWindowCounterWithClock[K comparable](window time.Duration, buckets int, now func() time.Time) *WindowCounter[K] {
+	if buckets <= 0 {
+		buckets = defaultWindowBuckets
+	}
+	width := window / time.Duration(buckets)
+	if width <= 0 {
+		panic(fmt.Errorf("kv: window %s is too short for %d buckets", window, buckets))
+	}
+	return &WindowCounter[K]{
+		buckets: make([]windowBucket[K], buckets),
+		width:   width,
+		now:     now,
+	}
+}
+
+// WindowCounter counts per key over a sliding window, for rate limiting and anomaly detection, all methods are safe for concurrent use.
+// The window is divided into a ring of buckets which are reused as the clock advances,
+// so a count covers the current bucket and the buckets before it up to the window, it slides one bucket at a time.
+// The memory is bounded by the keys counted within the window.
+type WindowCounter[K comparable] struct {
+	lock    sync.RWMutex
+	buckets []windowBucket[K]
+	width   time.Duration
+	now     func() time.Time
+	limit   preview.Limit
+}
+
+type windowBucket[K comparable] struct {
+	epoch  int64
+	counts map[K]int64
+}
+
+// epoch returns the number of bucket widths since the unix epoch
+func (c *WindowCounter[K]) epoch() int64 {
+	return c.now().UnixNano() / int64(c.width)
+}
+
+// live calls the callback with the buckets within the window ending at the epoch
+func (c *WindowCounter[K]) live(epoch int64, callback func(counts map[K]int64)) {
+	for index := range c.buckets {
+		if bucket := &c.buckets[index]; bucket.counts != nil && epoch-bucket.epoch < int64(len(c.buckets)) {
+			callback(bucket.counts)
+		}
+	}
+}
+
+// Incr adds the delta to the count of the key in the current bucket and returns the count of the key in the window
+func (c *WindowCounter[K]) Incr(key K, delta int64) int64 {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	epoch := c.epoch()
+	size := int64(len(c.buckets))
+	bucket := &c.buckets[(epoch%size+size)%size]
+	if bucket.counts == nil || bucket.epoch != epoch {
+		bucket.epoch = epoch
+		bucket.counts = make(map[K]int64)
+	}
+	bucket.counts[key] += delta
+	return c.count(key, epoch)
+}
+
+// CountInWindow returns the count of the key in the window, it is 0 when the key is not counted in the window
+func (c *WindowCounter[K]) CountInWindow(key K) int64 {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.count(key, c.epoch())
+}
+
+func (c *WindowCounter[K]) count(key K, epoch int64) int64 {
+	var sum int64
+	c.live(epoch, func(counts map[K]int64) {
+		sum += counts[key]
+	})
+	return sum
+}
+
+// Window returns the duration covered by the counts
+func (c *WindowCounter[K]) Window() time.Duration {
+	return c.width * time.Duration(len(c.buckets))
+}
+
+// Clear removes all counts
+func (c *WindowCounter[K]) Clear() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	clear(c.buckets)
+}
+
+// Count returns the number of keys counted in the window
+func (c *WindowCounter[K]) Count() int64 {
+	return int64(len(c.Snapshot()))
+}
+
+// IsEmpty returns whether no key is counted in the window
+func (c *WindowCounter[K]) IsEmpty() bool {
+	return c.Count() == 0
+}
+
+// IsNotEmpty returns whether any key is counted in the window
+func (c *WindowCounter[K]) IsNotEmpty() bool {
+	return !c.IsEmpty()
+}
+
+// Snapshot returns the counts of the keys in the window, the returned map is a copy, so modifying it does not affect the counter
+func (c *WindowCounter[K]) Snapshot() map[K]int64 {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	sums := make(map[K]int64)
+	c.live(c.epoch(), func(counts map[K]int64) {
+		for key, count := range counts {
+			sums[key] += count
+		}
+	})
+	return sums
+}
+
+// All returns an iterator over the keys and their counts in the window
+func (c *WindowCounter[K]) All() iter.Seq2[K, int64] {
+	sums := c.Snapshot()
+	return func(yield func(K, int64) bool) {
+		for key, sum := range sums {
+			if !yield(key, sum) {
+				return
+			}
+		}
+	}
+}
+
+// ToJSON converts to json, an object of the counts in the window
+func (c *WindowCounter[K]) ToJSON() ([]byte, error) {
+	return json.Marshal(c.Snapshot())
+}
+
+// MarshalJSON implements [json.Marshaller]
+func (c *WindowCounter[K]) MarshalJSON() ([]byte, error) {
+	return c.ToJSON()
+}
+
+func (c *WindowCounter[K]) snapshot() ([]K, []int64) {
+	sums := c.Snapshot()
+	keys := make([]K, 0, len(sums))
+	values := make([]int64, 0, len(sums))
+	for key, sum := range sums {
+		keys = append(keys, key)
+		values = append(values, sum)
+	}
+	return keys, values
+}
+
+// SetPreviewLimit sets the number of entries shown by String, %v and LogValue, a negative limit shows all entries
+func (c *WindowCounter[K]) SetPreviewLimit(limit int) {
+	c.limit.Set(limit)
+}
+
+// String converts to string, only the preview limit of entries are shown
+func (c *WindowCounter[K]) String() string {
+	return c.format(c.limit.Get(-1))
+}
+
+// Format implements [fmt.Formatter], %v prints the preview as String does, %+v and %#v print all entries
+func (c *WindowCounter[K]) Format(f fmt.State, verb rune) {
+	preview.Format(f, verb, c.format, c.limit.Get(-1))
+}
+
+func (c *WindowCounter[K]) format(limit int) string {
+	keys, values := c.snapshot()
+	return preview.Entries(fmt.Sprintf("WindowCounter[%T](len=%d)", *new(K), len(keys)), keys, values, limit)
+}
+
+// LogValue implements [slog.LogValuer], only the preview limit of entries are logged
+func (c *WindowCounter[K]) LogValue() slog.Value {
+	keys, values := c.snapshot()
+	return preview.LogEntries(fmt.Sprintf("WindowCounter[%T]", *new(K)), len(keys), keys, values, c.limit.Get(preview.DefaultLimit))
+}
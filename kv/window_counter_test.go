@@ -0,0 +1,69 @@
+package kv
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type _clock struct {
+	now time.Time
+}
+
+func (c *_clock) Now() time.Time {
+	return c.now
+}
+
+func TestNewWindowCounter(t *testing.T) {
+	counter := NewWindowCounter[string](time.Minute, 0)
+	assert.Equal(t, time.Minute, counter.Window())
+	assert.Len(t, counter.buckets, 10)
+	assert.PanicsWithError(t, "kv: window 5ns is too short for 10 buckets", func() {
+		NewWindowCounter[string](5, 10)
+	})
+}
+
+func TestWindowCounter_Incr(t *testing.T) {
+	clock := &_clock{now: time.Unix(1000, 0)}
+	counter := NewWindowCounterWithClock[string](time.Minute, 6, clock.Now)
+	assert.EqualValues(t, 1, counter.Incr("a", 1))
+	assert.EqualValues(t, 3, counter.Incr("a", 2))
+	clock.now = clock.now.Add(25 * time.Second)
+	assert.EqualValues(t, 4, counter.Incr("a", 1))
+	assert.EqualValues(t, 1, counter.Incr("b", 1))
+	assert.EqualValues(t, 4, counter.CountInWindow("a"))
+
+	clock.now = clock.now.Add(40 * time.Second)
+	assert.EqualValues(t, 1, counter.CountInWindow("a"))
+	assert.Equal(t, map[string]int64{"a": 1, "b": 1}, counter.Snapshot())
+
+	clock.now = clock.now.Add(time.Minute)
+	assert.EqualValues(t, 0, counter.CountInWindow("a"))
+	assert.True(t, counter.IsEmpty())
+	assert.EqualValues(t, 1, counter.Incr("a", 1))
+}
+
+func TestWindowCounter_Clear(t *testing.T) {
+	counter := NewWindowCounter[int](time.Hour, 4)
+	counter.Incr(1, 5)
+	counter.Incr(2, 1)
+	assert.EqualValues(t, 2, counter.Count())
+	counter.Clear()
+	assert.EqualValues(t, 0, counter.CountInWindow(1))
+	assert.True(t, counter.IsEmpty())
+}
+
+func TestWindowCounter_JSON(t *testing.T) {
+	counter := NewWindowCounter[string](time.Hour, 4)
+	counter.Incr("a", 2)
+	data, err := json.Marshal(counter)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"a":2}`, string(data))
+	assert.Equal(t, "WindowCounter[string](len=1){\n\ta: 2,\n}", counter.String())
+	for key, count := range counter.All() {
+		assert.Equal(t, "a", key)
+		assert.EqualValues(t, 2, count)
+	}
+}
@@ -0,0 +1,144 @@
+package list
+
+import "sync"
+
+// element is an element of a chain, it mirrors [listlib.Element] without boxing the value
+type element[E any] struct {
+	next, prev *element[E]
+	chain      *chain[E]
+	Value      E
+}
+
+// Next returns the next element or nil
+func (e *element[E]) Next() *element[E] {
+	if p := e.next; e.chain != nil && p != &e.chain.root {
+		return p
+	}
+	return nil
+}
+
+// Prev returns the previous element or nil
+func (e *element[E]) Prev() *element[E] {
+	if p := e.prev; e.chain != nil && p != &e.chain.root {
+		return p
+	}
+	return nil
+}
+
+// chain is a doubly linked list of E, it mirrors [listlib.List].
+// When the pool is set, the elements are taken from it and given back to it when they are removed.
+type chain[E any] struct {
+	root element[E]
+	len  int
+	pool *sync.Pool
+}
+
+func newChain[E any](pool *sync.Pool) *chain[E] {
+	c := &chain[E]{pool: pool}
+	return c.Init()
+}
+
+// Init clears the chain, the elements are not given back to the pool, see release
+func (c *chain[E]) Init() *chain[E] {
+	c.root.next = &c.root
+	c.root.prev = &c.root
+	c.len = 0
+	return c
+}
+
+// Len returns the number of elements
+func (c *chain[E]) Len() int {
+	return c.len
+}
+
+// Front returns the first element or nil
+func (c *chain[E]) Front() *element[E] {
+	if c.len == 0 {
+		return nil
+	}
+	return c.root.next
+}
+
+// Back returns the last element or nil
+func (c *chain[E]) Back() *element[E] {
+	if c.len == 0 {
+		return nil
+	}
+	return c.root.prev
+}
+
+func (c *chain[E]) alloc(value E) *element[E] {
+	if c.pool == nil {
+		return &element[E]{Value: value}
+	}
+	e := c.pool.Get().(*element[E])
+	e.Value = value
+	return e
+}
+
+func (c *chain[E]) free(e *element[E]) {
+	*e = element[E]{}
+	if c.pool != nil {
+		c.pool.Put(e)
+	}
+}
+
+func (c *chain[E]) insert(e, at *element[E]) *element[E] {
+	e.prev = at
+	e.next = at.next
+	e.prev.next = e
+	e.next.prev = e
+	e.chain = c
+	c.len++
+	return e
+}
+
+// PushBack inserts the value at the back
+func (c *chain[E]) PushBack(value E) *element[E] {
+	return c.insert(c.alloc(value), c.root.prev)
+}
+
+// PushFront inserts the value at the front
+func (c *chain[E]) PushFront(value E) *element[E] {
+	return c.insert(c.alloc(value), &c.root)
+}
+
+// InsertBefore inserts the value before mark
+func (c *chain[E]) InsertBefore(value E, mark *element[E]) *element[E] {
+	return c.insert(c.alloc(value), mark.prev)
+}
+
+// PushBackList inserts the values of other at the back
+func (c *chain[E]) PushBackList(other *chain[E]) {
+	for e := other.Front(); e != nil; e = e.Next() {
+		c.PushBack(e.Value)
+	}
+}
+
+// Remove removes the element and returns its value, the element must not be used afterwards
+func (c *chain[E]) Remove(e *element[E]) E {
+	value := e.Value
+	if e.chain == c {
+		e.prev.next = e.next
+		e.next.prev = e.prev
+		c.len--
+		c.free(e)
+	}
+	return value
+}
+
+// release clears the chain and gives the elements back to the pool
+func (c *chain[E]) release() {
+	for e := c.root.next; e != &c.root; {
+		next := e.next
+		c.free(e)
+		e = next
+	}
+	c.Init()
+}
+
+func newElementPool[E any]() *sync.Pool {
+	return &sync.Pool{New: func() any {
+		return new(element[E])
+	}}
+}
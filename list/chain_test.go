@@ -0,0 +1,33 @@
+package list
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChain_Remove(t *testing.T) {
+	c := newChain[int](newElementPool[int]())
+	first := c.PushBack(1)
+	c.PushBack(2)
+	c.PushFront(0)
+	assert.Equal(t, 3, c.Len())
+	assert.Equal(t, 1, c.Remove(first))
+	assert.Equal(t, 2, c.Len())
+	assert.Equal(t, 0, c.Front().Value)
+	assert.Equal(t, 2, c.Front().Next().Value)
+	assert.Nil(t, c.Back().Next())
+	assert.Nil(t, c.Front().Prev())
+}
+
+func TestChain_Release(t *testing.T) {
+	c := newChain[int](newElementPool[int]())
+	c.PushBack(1)
+	c.PushBack(2)
+	c.release()
+	assert.Equal(t, 0, c.Len())
+	assert.Nil(t, c.Front())
+	c.InsertBefore(0, c.PushBack(1))
+	assert.Equal(t, 0, c.Front().Value)
+	assert.Equal(t, 1, c.Back().Value)
+}
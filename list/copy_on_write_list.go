@@ -0,0 +1,329 @@
+package list
+
+import (
+	"encoding/json"
+	"fmt"
+	"iter"
+	"log/slog"
+	"reflect"
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gopi-frame/collection"
+	"github.com/gopi-frame/collection/internal/preview"
+)
+
+// NewCopyOnWriteList new copy-on-write list
+func NewCopyOnWriteList[E any](values ...E) *CopyOnWriteList[E] {
+	instance := new(CopyOnWriteList[E])
+	instance.Push(values...)
+	return instance
+}
+
+// CopyOnWriteList list for read-heavy workloads like CopyOnWriteArrayList of java, all methods are safe for concurrent use.
+// Each mutation copies the elements, modifies the copy and publishes it, so the readers read the published elements
+// without locking and never block, and an iteration sees the elements as they were when it started.
+// The writers are serialized and each of them takes O(n) time, use [List] when the list is modified often.
+// The callbacks of the mutations run while the writers are locked, so they must not modify the same list.
+type CopyOnWriteList[E any] struct {
+	lock  sync.Mutex
+	items atomic.Pointer[[]E]
+	limit preview.Limit
+}
+
+// snapshot returns the published elements, they must not be modified
+func (list *CopyOnWriteList[E]) snapshot() []E {
+	if items := list.items.Load(); items != nil {
+		return *items
+	}
+	return nil
+}
+
+// modify publishes the elements returned by the callback, which is called with a copy of the published elements
+func (list *CopyOnWriteList[E]) modify(callback func(items []E) []E) {
+	list.lock.Lock()
+	defer list.lock.Unlock()
+	items := callback(slices.Clone(list.snapshot()))
+	list.items.Store(&items)
+}
+
+// Count returns the size of the list
+func (list *CopyOnWriteList[E]) Count() int64 {
+	return int64(len(list.snapshot()))
+}
+
+// IsEmpty returns whether the list is empty.
+func (list *CopyOnWriteList[E]) IsEmpty() bool {
+	return list.Count() == 0
+}
+
+// IsNotEmpty returns whether the list is not empty.
+func (list *CopyOnWriteList[E]) IsNotEmpty() bool {
+	return !list.IsEmpty()
+}
+
+// Contains returns whether the list contains the specific element.
+func (list *CopyOnWriteList[E]) Contains(value E) bool {
+	return list.IndexOf(value) >= 0
+}
+
+// ContainsWhere returns whether the list contains specific elements by callback.
+func (list *CopyOnWriteList[E]) ContainsWhere(callback func(value E) bool) bool {
+	return slices.ContainsFunc(list.snapshot(), callback)
+}
+
+// Push pushes elements into the list.
+func (list *CopyOnWriteList[E]) Push(values ...E) {
+	list.modify(func(items []E) []E {
+		return append(items, values...)
+	})
+}
+
+// PushIfAbsent pushes the element when the list does not contain it, and reports whether it was pushed.
+func (list *CopyOnWriteList[E]) PushIfAbsent(value E) bool {
+	pushed := false
+	list.modify(func(items []E) []E {
+		if slices.ContainsFunc(items, func(item E) bool {
+			return reflect.DeepEqual(value, item)
+		}) {
+			return items
+		}
+		pushed = true
+		return append(items, value)
+	})
+	return pushed
+}
+
+// Remove removes the specific element.
+func (list *CopyOnWriteList[E]) Remove(value E) {
+	list.RemoveWhere(func(item E) bool {
+		return reflect.DeepEqual(value, item)
+	})
+}
+
+// RemoveWhere removes specific elements by callback.
+func (list *CopyOnWriteList[E]) RemoveWhere(callback func(item E) bool) {
+	list.modify(func(items []E) []E {
+		return slices.DeleteFunc(items, callback)
+	})
+}
+
+// RemoveAt removes the element on the specific index.
+func (list *CopyOnWriteList[E]) RemoveAt(index int) {
+	list.modify(func(items []E) []E {
+		return slices.Delete(items, index, index+1)
+	})
+}
+
+// InsertAt inserts the elements at the specific index, the elements from the index on are moved after them.
+// It panics when the index is out of [0, Count()].
+func (list *CopyOnWriteList[E]) InsertAt(index int, values ...E) {
+	list.modify(func(items []E) []E {
+		return slices.Insert(items, index, values...)
+	})
+}
+
+// Swap swaps the elements on the specific indexes.
+func (list *CopyOnWriteList[E]) Swap(i, j int) {
+	list.modify(func(items []E) []E {
+		items[i], items[j] = items[j], items[i]
+		return items
+	})
+}
+
+// Clear clears the list.
+func (list *CopyOnWriteList[E]) Clear() {
+	list.lock.Lock()
+	defer list.lock.Unlock()
+	list.items.Store(&[]E{})
+}
+
+// Get returns the element on the specific index.
+func (list *CopyOnWriteList[E]) Get(index int) E {
+	return list.snapshot()[index]
+}
+
+// Set sets element on the specific index.
+func (list *CopyOnWriteList[E]) Set(index int, value E) {
+	list.modify(func(items []E) []E {
+		items[index] = value
+		return items
+	})
+}
+
+// First returns the first element of the list.
+// it will return a zero value and false when the list is empty.
+func (list *CopyOnWriteList[E]) First() (E, bool) {
+	items := list.snapshot()
+	if len(items) == 0 {
+		return *new(E), false
+	}
+	return items[0], true
+}
+
+// FirstOr returns the first element of the list, it will return the default value when the list is empty.
+func (list *CopyOnWriteList[E]) FirstOr(value E) E {
+	if v, ok := list.First(); ok {
+		return v
+	}
+	return value
+}
+
+// Last returns the last element of the list.
+// It will return a zero value and false when the list is empty.
+func (list *CopyOnWriteList[E]) Last() (E, bool) {
+	items := list.snapshot()
+	if len(items) == 0 {
+		return *new(E), false
+	}
+	return items[len(items)-1], true
+}
+
+// LastOr returns the last element of the list, it will return the default value when the list is empty.
+func (list *CopyOnWriteList[E]) LastOr(value E) E {
+	if v, ok := list.Last(); ok {
+		return v
+	}
+	return value
+}
+
+// Pop removes the last element of the list and returns it.
+// It will return a zero value and false when the list is empty.
+func (list *CopyOnWriteList[E]) Pop() (E, bool) {
+	var value E
+	var ok bool
+	list.modify(func(items []E) []E {
+		if len(items) == 0 {
+			return items
+		}
+		value, ok = items[len(items)-1], true
+		return items[:len(items)-1]
+	})
+	return value, ok
+}
+
+// Shift removes the first element of the list and returns it.
+// It will return a zero value and false when the list is empty.
+func (list *CopyOnWriteList[E]) Shift() (E, bool) {
+	var value E
+	var ok bool
+	list.modify(func(items []E) []E {
+		if len(items) == 0 {
+			return items
+		}
+		value, ok = items[0], true
+		return items[1:]
+	})
+	return value, ok
+}
+
+// Unshift puts elements to the head of the list.
+func (list *CopyOnWriteList[E]) Unshift(values ...E) {
+	list.InsertAt(0, values...)
+}
+
+// IndexOf returns the index of the specific element.
+func (list *CopyOnWriteList[E]) IndexOf(value E) int {
+	return list.IndexOfWhere(func(item E) bool {
+		return reflect.DeepEqual(value, item)
+	})
+}
+
+// IndexOfWhere returns the index of the first element which matches the callback.
+func (list *CopyOnWriteList[E]) IndexOfWhere(callback func(item E) bool) int {
+	return slices.IndexFunc(list.snapshot(), callback)
+}
+
+// Sort sorts the list by the callback
+func (list *CopyOnWriteList[E]) Sort(callback func(a, b E) int) {
+	list.modify(func(items []E) []E {
+		slices.SortFunc(items, callback)
+		return items
+	})
+}
+
+// Each travers the list, if the callback returns false then break.
+// The callback runs on the elements published when Each is called, so it is allowed to modify the list.
+func (list *CopyOnWriteList[E]) Each(callback func(index int, value E) bool) {
+	for index, value := range list.snapshot() {
+		if !callback(index, value) {
+			break
+		}
+	}
+}
+
+// All returns an iterator over the elements published when it is called, without copying them,
+// so the loop body is allowed to modify the list.
+func (list *CopyOnWriteList[E]) All() iter.Seq[E] {
+	return slices.Values(list.snapshot())
+}
+
+// Clone clones the list
+func (list *CopyOnWriteList[E]) Clone() *CopyOnWriteList[E] {
+	return NewCopyOnWriteList(list.snapshot()...)
+}
+
+// DeepClone clones the list and its elements, see [collection.CloneValue]
+func (list *CopyOnWriteList[E]) DeepClone() *CopyOnWriteList[E] {
+	items := list.ToArray()
+	for index, item := range items {
+		items[index] = collection.CloneValue(item)
+	}
+	clone := new(CopyOnWriteList[E])
+	clone.items.Store(&items)
+	return clone
+}
+
+// SetPreviewLimit sets the number of elements shown by String, %v and LogValue, a negative limit shows all elements
+func (list *CopyOnWriteList[E]) SetPreviewLimit(limit int) {
+	list.limit.Set(limit)
+}
+
+// String converts to string, only the preview limit of elements are shown
+func (list *CopyOnWriteList[E]) String() string {
+	return list.format(list.limit.Get(preview.DefaultLimit))
+}
+
+// Format implements [fmt.Formatter], %v prints the preview as String does, %+v and %#v print all elements
+func (list *CopyOnWriteList[E]) Format(f fmt.State, verb rune) {
+	preview.Format(f, verb, list.format, list.limit.Get(preview.DefaultLimit))
+}
+
+func (list *CopyOnWriteList[E]) format(limit int) string {
+	items := list.snapshot()
+	return preview.Elements(fmt.Sprintf("CopyOnWriteList[%T](len=%d)", *new(E), len(items)), items, limit)
+}
+
+// LogValue implements [slog.LogValuer], only the preview limit of elements are logged
+func (list *CopyOnWriteList[E]) LogValue() slog.Value {
+	return preview.LogElements(fmt.Sprintf("CopyOnWriteList[%T]", *new(E)), list.snapshot(), list.limit.Get(preview.DefaultLimit))
+}
+
+// ToJSON converts to json
+func (list *CopyOnWriteList[E]) ToJSON() ([]byte, error) {
+	return json.Marshal(list.snapshot())
+}
+
+// ToArray converts to array, the returned slice is a copy, so modifying it does not affect the collection
+func (list *CopyOnWriteList[E]) ToArray() []E {
+	return slices.Clone(list.snapshot())
+}
+
+// MarshalJSON implements [json.Marshaller]
+func (list *CopyOnWriteList[E]) MarshalJSON() ([]byte, error) {
+	return list.ToJSON()
+}
+
+// UnmarshalJSON implements [json.Unmarshaller]
+func (list *CopyOnWriteList[E]) UnmarshalJSON(data []byte) error {
+	var items []E
+	err := json.Unmarshal(data, &items)
+	if err != nil {
+		return err
+	}
+	list.lock.Lock()
+	defer list.lock.Unlock()
+	list.items.Store(&items)
+	return nil
+}
@@ -0,0 +1,145 @@
+package list
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCopyOnWriteList_Push(t *testing.T) {
+	list := NewCopyOnWriteList(1, 2)
+	list.Push(3)
+	assert.Equal(t, []int{1, 2, 3}, list.ToArray())
+	assert.EqualValues(t, 3, list.Count())
+	assert.True(t, list.IsNotEmpty())
+}
+
+func TestCopyOnWriteList_PushIfAbsent(t *testing.T) {
+	list := NewCopyOnWriteList(1, 2)
+	assert.False(t, list.PushIfAbsent(2))
+	assert.True(t, list.PushIfAbsent(3))
+	assert.Equal(t, []int{1, 2, 3}, list.ToArray())
+}
+
+func TestCopyOnWriteList_Remove(t *testing.T) {
+	list := NewCopyOnWriteList(1, 2, 3, 2)
+	list.Remove(2)
+	assert.Equal(t, []int{1, 3}, list.ToArray())
+	list.RemoveAt(0)
+	assert.Equal(t, []int{3}, list.ToArray())
+	list.Clear()
+	assert.True(t, list.IsEmpty())
+}
+
+func TestCopyOnWriteList_InsertAt(t *testing.T) {
+	list := NewCopyOnWriteList(1, 4)
+	list.InsertAt(1, 2, 3)
+	list.Unshift(0)
+	assert.Equal(t, []int{0, 1, 2, 3, 4}, list.ToArray())
+	list.Swap(0, 4)
+	list.Set(1, 9)
+	assert.Equal(t, []int{4, 9, 2, 3, 0}, list.ToArray())
+	assert.Equal(t, 2, list.Get(2))
+	assert.Equal(t, 3, list.IndexOf(3))
+	assert.True(t, list.Contains(9))
+	list.Sort(cmp.Compare[int])
+	assert.Equal(t, []int{0, 2, 3, 4, 9}, list.ToArray())
+}
+
+func TestCopyOnWriteList_Pop(t *testing.T) {
+	list := NewCopyOnWriteList(1, 2, 3)
+	value, ok := list.Pop()
+	assert.True(t, ok)
+	assert.Equal(t, 3, value)
+	value, ok = list.Shift()
+	assert.True(t, ok)
+	assert.Equal(t, 1, value)
+	assert.Equal(t, 2, list.FirstOr(0))
+	assert.Equal(t, 2, list.LastOr(0))
+	list.Pop()
+	_, ok = list.Pop()
+	assert.False(t, ok)
+	_, ok = list.Shift()
+	assert.False(t, ok)
+	_, ok = list.First()
+	assert.False(t, ok)
+}
+
+func TestCopyOnWriteList_All(t *testing.T) {
+	list := NewCopyOnWriteList(1, 2, 3)
+	var values []int
+	for value := range list.All() {
+		list.Push(value * 10)
+		values = append(values, value)
+	}
+	assert.Equal(t, []int{1, 2, 3}, values)
+	assert.Equal(t, []int{1, 2, 3, 10, 20, 30}, list.ToArray())
+	values = nil
+	list.Each(func(index int, value int) bool {
+		list.RemoveAt(0)
+		values = append(values, value)
+		return index < 3
+	})
+	assert.Equal(t, []int{1, 2, 3, 10}, values)
+	assert.Equal(t, []int{20, 30}, list.ToArray())
+}
+
+func TestCopyOnWriteList_Clone(t *testing.T) {
+	list := NewCopyOnWriteList(1, 2)
+	clone := list.Clone()
+	list.Push(3)
+	assert.Equal(t, []int{1, 2}, clone.ToArray())
+}
+
+func TestCopyOnWriteList_DeepClone(t *testing.T) {
+	inner := NewList(1, 2)
+	list := NewCopyOnWriteList(inner)
+	clone := list.DeepClone()
+	assert.NotSame(t, inner, clone.Get(0))
+	inner.Push(3)
+	assert.Equal(t, []int{1, 2}, clone.Get(0).ToArray())
+}
+
+func TestCopyOnWriteList_MarshalJSON(t *testing.T) {
+	list := NewCopyOnWriteList(1, 2, 3)
+	data, err := json.Marshal(list)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `[1,2,3]`, string(data))
+	decoded := new(CopyOnWriteList[int])
+	assert.NoError(t, json.Unmarshal(data, decoded))
+	assert.Equal(t, []int{1, 2, 3}, decoded.ToArray())
+}
+
+func TestCopyOnWriteList_String(t *testing.T) {
+	list := NewCopyOnWriteList(1, 2)
+	assert.Equal(t, "CopyOnWriteList[int](len=2){\n\t1,\n\t2,\n}", list.String())
+	assert.Equal(t, "CopyOnWriteList[int](len=2){\n\t1,\n\t2,\n}", fmt.Sprint(list))
+}
+
+func TestCopyOnWriteList_Concurrent(t *testing.T) {
+	list := new(CopyOnWriteList[int])
+	var wg sync.WaitGroup
+	for i := range 4 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := range 100 {
+				list.Push(i*100 + j)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				for value := range list.All() {
+					_ = value
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	assert.EqualValues(t, 400, list.Count())
+}
@@ -0,0 +1,240 @@
+package list
+
+import (
+	"io"
+	"reflect"
+	"slices"
+)
+
+// editor list whose mutations are expressed as edits, the edits are passed to onEdit while the list is locked.
+// It is embedded by the lists which record their mutations, such as [HistoryList] and [JournalList].
+type editor[E any] struct {
+	*List[E]
+	onEdit func(e edit[E])
+}
+
+// edit replaces the removed elements at index with the inserted elements.
+// The slices of an edit are never shared with the items of the list, so the edit can be applied in place.
+type edit[E any] struct {
+	index    int
+	removed  []E
+	inserted []E
+}
+
+func (e edit[E]) apply(items []E) []E {
+	return slices.Replace(items, e.index, e.index+len(e.removed), e.inserted...)
+}
+
+func (e edit[E]) invert() edit[E] {
+	return edit[E]{index: e.index, removed: e.inserted, inserted: e.removed}
+}
+
+// do applies the edit and passes it to onEdit
+func (l *editor[E]) do(e edit[E]) {
+	l.items = e.apply(l.items)
+	l.notify(e)
+}
+
+// replace replaces the items with the given items which must not be shared, and passes the edit to onEdit
+func (l *editor[E]) replace(items []E) {
+	e := edit[E]{removed: l.items, inserted: items}
+	l.items = slices.Clone(items)
+	l.notify(e)
+}
+
+// notify passes the edit to onEdit unless it changes nothing
+func (l *editor[E]) notify(e edit[E]) {
+	if len(e.removed) > 0 || len(e.inserted) > 0 {
+		l.onEdit(e)
+	}
+}
+
+// Push pushes elements into the list.
+func (l *editor[E]) Push(values ...E) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.do(edit[E]{index: len(l.items), inserted: slices.Clone(values)})
+}
+
+// Remove removes the specific element.
+func (l *editor[E]) Remove(value E) {
+	l.RemoveWhere(func(item E) bool {
+		return reflect.DeepEqual(value, item)
+	})
+}
+
+// RemoveWhere removes specific elements by callback.
+func (l *editor[E]) RemoveWhere(callback func(item E) bool) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if slices.ContainsFunc(l.items, callback) {
+		l.replace(slices.DeleteFunc(slices.Clone(l.items), callback))
+	}
+}
+
+// RemoveAt removes the element on the specific index.
+func (l *editor[E]) RemoveAt(index int) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.do(edit[E]{index: index, removed: []E{l.items[index]}})
+}
+
+// Clear clears the list.
+func (l *editor[E]) Clear() {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.replace([]E{})
+}
+
+// Set sets element on the specific index.
+func (l *editor[E]) Set(index int, value E) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.do(edit[E]{index: index, removed: []E{l.items[index]}, inserted: []E{value}})
+}
+
+// InsertAt inserts the elements at the specific index.
+func (l *editor[E]) InsertAt(index int, values ...E) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.do(edit[E]{index: index, inserted: slices.Clone(values)})
+}
+
+// Splice removes at most deleteCount elements from the start index, inserts the values there,
+// and returns the removed elements.
+func (l *editor[E]) Splice(start, deleteCount int, values ...E) []E {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	end := start + min(max(deleteCount, 0), len(l.items)-start)
+	removed := slices.Clone(l.items[start:end])
+	l.do(edit[E]{index: start, removed: removed, inserted: slices.Clone(values)})
+	return slices.Clone(removed)
+}
+
+// Swap swaps the elements on the specific indexes.
+func (l *editor[E]) Swap(i, j int) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	lo, hi := min(i, j), max(i, j)
+	removed := slices.Clone(l.items[lo : hi+1])
+	if lo == hi {
+		return
+	}
+	inserted := slices.Clone(removed)
+	inserted[0], inserted[len(inserted)-1] = inserted[len(inserted)-1], inserted[0]
+	l.do(edit[E]{index: lo, removed: removed, inserted: inserted})
+}
+
+// Pop removes the last element of the list and returns it.
+// It will return a zero value and false when the list is empty.
+func (l *editor[E]) Pop() (E, bool) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if len(l.items) == 0 {
+		return *new(E), false
+	}
+	value := l.items[len(l.items)-1]
+	l.do(edit[E]{index: len(l.items) - 1, removed: []E{value}})
+	return value, true
+}
+
+// Shift removes the first element of the list and returns it.
+// It will return a zero value and false when the list is empty.
+func (l *editor[E]) Shift() (E, bool) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if len(l.items) == 0 {
+		return *new(E), false
+	}
+	value := l.items[0]
+	l.do(edit[E]{removed: []E{value}})
+	return value, true
+}
+
+// Unshift puts elements to the head of the list.
+func (l *editor[E]) Unshift(values ...E) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.do(edit[E]{inserted: slices.Clone(values)})
+}
+
+// Compact makes the list more compact
+func (l *editor[E]) Compact(callback func(a, b E) bool) {
+	if callback == nil {
+		callback = func(a, b E) bool {
+			return reflect.DeepEqual(a, b)
+		}
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	items := slices.CompactFunc(slices.Clone(l.items), callback)
+	if len(items) != len(l.items) {
+		l.replace(items)
+	}
+}
+
+// Sort sorts the list
+func (l *editor[E]) Sort(callback func(a, b E) int) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	items := slices.Clone(l.items)
+	slices.SortFunc(items, callback)
+	l.replace(items)
+}
+
+// Reverse reverses the list
+func (l *editor[E]) Reverse() {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	items := slices.Clone(l.items)
+	slices.Reverse(items)
+	l.replace(items)
+}
+
+// decode decodes into a new list and records the replacement of the elements with its elements
+func (l *editor[E]) decode(decode func(list *List[E]) error) error {
+	list := NewList[E]()
+	if err := decode(list); err != nil {
+		return err
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.replace(list.items)
+	return nil
+}
+
+// UnmarshalJSON implements [json.Unmarshaller], the replacement of the elements is an edit
+func (l *editor[E]) UnmarshalJSON(data []byte) error {
+	return l.decode(func(list *List[E]) error {
+		return list.UnmarshalJSON(data)
+	})
+}
+
+// DecodeJSON reads a json array from r one element at a time and replaces the elements of the list,
+// the replacement is an edit
+func (l *editor[E]) DecodeJSON(r io.Reader) error {
+	return l.decode(func(list *List[E]) error {
+		return list.DecodeJSON(r)
+	})
+}
+
+// Scan implements [database/sql.Scanner], the replacement of the elements is an edit
+func (l *editor[E]) Scan(src any) error {
+	return l.decode(func(list *List[E]) error {
+		return list.Scan(src)
+	})
+}
+
+// UnmarshalCBOR implements [cbor.Unmarshaler], the replacement of the elements is an edit
+func (l *editor[E]) UnmarshalCBOR(data []byte) error {
+	return l.decode(func(list *List[E]) error {
+		return list.UnmarshalCBOR(data)
+	})
+}
+
+// UnmarshalBinary implements [encoding.BinaryUnmarshaler], the replacement of the elements is an edit
+func (l *editor[E]) UnmarshalBinary(data []byte) error {
+	return l.decode(func(list *List[E]) error {
+		return list.UnmarshalBinary(data)
+	})
+}
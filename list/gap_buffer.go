@@ -0,0 +1,663 @@
+package list
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+	"log/slog"
+	"reflect"
+	"slices"
+	"sync"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/gopi-frame/collection"
+	"github.com/gopi-frame/collection/internal/codec"
+	"github.com/gopi-frame/collection/internal/jsonstream"
+	"github.com/gopi-frame/collection/internal/preview"
+	"github.com/gopi-frame/collection/internal/sqljson"
+	"github.com/gopi-frame/exception"
+)
+
+// minGap is the least number of free slots a gap buffer grows by
+const minGap = 16
+
+// NewGapBuffer new gap buffer, the cursor is at the end of the values
+func NewGapBuffer[E any](values ...E) *GapBuffer[E] {
+	instance := new(GapBuffer[E])
+	instance.reset(slices.Clone(values))
+	instance.cursor = len(values)
+	return instance
+}
+
+// GapBuffer list which keeps a gap of free slots at a cursor, like the buffer of a text editor, all methods are safe for concurrent use.
+// Insert, Delete and Backspace edit at the cursor in amortized O(1) time per element,
+// moving the cursor by d positions takes O(d) time, so editing around a moving position is far faster than with List or LinkedList.
+// Elements inserted or removed before the cursor by other methods move the cursor, so it stays in front of the same element.
+// The callbacks run while the list is locked, so they must not call methods of the same list,
+// except the callback of Each which runs on a snapshot.
+type GapBuffer[E any] struct {
+	lock   sync.RWMutex
+	items  []E
+	start  int
+	end    int
+	cursor int
+	limit  preview.Limit
+}
+
+// length returns the number of elements, which is the buffer without the gap
+func (b *GapBuffer[E]) length() int {
+	return len(b.items) - (b.end - b.start)
+}
+
+// at returns the element on the index
+func (b *GapBuffer[E]) at(index int) *E {
+	if index < b.start {
+		return &b.items[index]
+	}
+	return &b.items[index+b.end-b.start]
+}
+
+// check panics when the index is out of range
+func (b *GapBuffer[E]) check(index int) {
+	if index < 0 || index >= b.length() {
+		panic(exception.NewRangeException(0, b.length()-1))
+	}
+}
+
+// moveGap moves the gap in front of the element on the position, the elements between are copied across the gap
+func (b *GapBuffer[E]) moveGap(position int) {
+	if position < b.start {
+		moved := b.start - position
+		copy(b.items[b.end-moved:b.end], b.items[position:b.start])
+		clear(b.items[position:min(b.start, b.end-moved)])
+		b.start, b.end = position, b.end-moved
+	} else if position > b.start {
+		moved := position - b.start
+		copy(b.items[b.start:], b.items[b.end:b.end+moved])
+		clear(b.items[max(b.end, b.start+moved) : b.end+moved])
+		b.start, b.end = position, b.end+moved
+	}
+}
+
+// reserve grows the gap to at least n free slots
+func (b *GapBuffer[E]) reserve(n int) {
+	if b.end-b.start >= n {
+		return
+	}
+	tail := len(b.items) - b.end
+	items := make([]E, max(2*len(b.items), b.length()+n+minGap))
+	copy(items, b.items[:b.start])
+	copy(items[len(items)-tail:], b.items[b.end:])
+	b.items = items
+	b.end = len(items) - tail
+}
+
+// insert inserts the values in front of the element on the position
+func (b *GapBuffer[E]) insert(position int, values ...E) {
+	if len(values) == 0 {
+		return
+	}
+	if position == b.length() && b.start != position {
+		// the elements after the gap end the buffer, so appending keeps the gap where it is
+		b.items = append(b.items, values...)
+	} else {
+		b.moveGap(position)
+		b.reserve(len(values))
+		copy(b.items[b.start:], values)
+		b.start += len(values)
+	}
+	if position < b.cursor {
+		b.cursor += len(values)
+	}
+}
+
+// remove removes n elements from the position
+func (b *GapBuffer[E]) remove(position, n int) {
+	if n <= 0 {
+		return
+	}
+	b.moveGap(position)
+	clear(b.items[b.end : b.end+n])
+	b.end += n
+	if position+n <= b.cursor {
+		b.cursor -= n
+	} else if position < b.cursor {
+		b.cursor = position
+	}
+}
+
+// values returns a copy of the elements in order
+func (b *GapBuffer[E]) values() []E {
+	items := make([]E, 0, b.length())
+	items = append(items, b.items[:b.start]...)
+	return append(items, b.items[b.end:]...)
+}
+
+// reset replaces the elements, the cursor keeps its index unless it is beyond the end
+func (b *GapBuffer[E]) reset(items []E) {
+	b.items = items
+	b.start, b.end = len(items), len(items)
+	b.cursor = min(b.cursor, len(items))
+}
+
+// Cursor returns the index of the element the cursor is in front of, which is the count when it is at the end
+func (b *GapBuffer[E]) Cursor() int {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+	return b.cursor
+}
+
+// MoveCursor moves the cursor in front of the element on the index, the count moves it to the end.
+// It panics when the index is out of range.
+func (b *GapBuffer[E]) MoveCursor(index int) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if index < 0 || index > b.length() {
+		panic(exception.NewRangeException(0, b.length()))
+	}
+	b.cursor = index
+	b.moveGap(index)
+}
+
+// Insert inserts elements at the cursor and moves the cursor behind them
+func (b *GapBuffer[E]) Insert(values ...E) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	position := b.cursor
+	b.insert(position, values...)
+	b.cursor = position + len(values)
+}
+
+// Delete removes at most n elements after the cursor, it returns the number of removed elements
+func (b *GapBuffer[E]) Delete(n int) int {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	n = max(min(n, b.length()-b.cursor), 0)
+	b.remove(b.cursor, n)
+	return n
+}
+
+// Backspace removes at most n elements before the cursor, it returns the number of removed elements
+func (b *GapBuffer[E]) Backspace(n int) int {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	n = max(min(n, b.cursor), 0)
+	b.remove(b.cursor-n, n)
+	return n
+}
+
+// Count returns the size of the list
+func (b *GapBuffer[E]) Count() int64 {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+	return int64(b.length())
+}
+
+// IsEmpty returns whether the list is empty.
+func (b *GapBuffer[E]) IsEmpty() bool {
+	return b.Count() == 0
+}
+
+// IsNotEmpty returns whether the list is not empty.
+func (b *GapBuffer[E]) IsNotEmpty() bool {
+	return !b.IsEmpty()
+}
+
+// Contains returns whether the list contains the specific element.
+func (b *GapBuffer[E]) Contains(value E) bool {
+	return b.ContainsWhere(func(item E) bool {
+		return reflect.DeepEqual(item, value)
+	})
+}
+
+// ContainsWhere returns whether the list contains specific elements by callback.
+func (b *GapBuffer[E]) ContainsWhere(callback func(value E) bool) bool {
+	return b.IndexOfWhere(callback) >= 0
+}
+
+// Push pushes elements into the end of the list, the cursor stays where it is.
+func (b *GapBuffer[E]) Push(values ...E) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.insert(b.length(), values...)
+}
+
+// Remove removes the specific element.
+func (b *GapBuffer[E]) Remove(value E) {
+	b.RemoveWhere(func(item E) bool {
+		return reflect.DeepEqual(item, value)
+	})
+}
+
+// RemoveWhere removes specific elements by callback.
+func (b *GapBuffer[E]) RemoveWhere(callback func(item E) bool) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	items := make([]E, 0, b.length())
+	cursor := b.cursor
+	for index := 0; index < b.length(); index++ {
+		if item := *b.at(index); !callback(item) {
+			items = append(items, item)
+		} else if index < b.cursor {
+			cursor--
+		}
+	}
+	b.reset(items)
+	b.cursor = cursor
+}
+
+// RemoveAt removes the element on the specific index.
+func (b *GapBuffer[E]) RemoveAt(index int) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.check(index)
+	b.remove(index, 1)
+}
+
+// Clear clears the list and moves the cursor to the start.
+func (b *GapBuffer[E]) Clear() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.reset(nil)
+}
+
+// Get returns the element on the specific index.
+func (b *GapBuffer[E]) Get(index int) E {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+	b.check(index)
+	return *b.at(index)
+}
+
+// Set sets element on the specific index.
+func (b *GapBuffer[E]) Set(index int, value E) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.check(index)
+	*b.at(index) = value
+}
+
+// First returns the first element of the list.
+// it will return a zero value and false when the list is empty.
+func (b *GapBuffer[E]) First() (E, bool) {
+	return b.FirstWhere(func(E) bool {
+		return true
+	})
+}
+
+// FirstOr returns the first element of the list, it will return the default value when the list is empty.
+func (b *GapBuffer[E]) FirstOr(value E) E {
+	if v, ok := b.First(); ok {
+		return v
+	}
+	return value
+}
+
+// FirstWhere returns the first element which matches the callback.
+// It will return a zero value and false when none matches.
+func (b *GapBuffer[E]) FirstWhere(callback func(item E) bool) (E, bool) {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+	for index := 0; index < b.length(); index++ {
+		if item := *b.at(index); callback(item) {
+			return item, true
+		}
+	}
+	return *new(E), false
+}
+
+// FirstWhereOr returns the first element which matches the callback, it will return the default value when none matches.
+func (b *GapBuffer[E]) FirstWhereOr(callback func(item E) bool, value E) E {
+	if v, ok := b.FirstWhere(callback); ok {
+		return v
+	}
+	return value
+}
+
+// Last returns the last element of the list.
+// It will return a zero value and false when the list is empty.
+func (b *GapBuffer[E]) Last() (E, bool) {
+	return b.LastWhere(func(E) bool {
+		return true
+	})
+}
+
+// LastOr returns the last element of the list, it will return the default value when the list is empty.
+func (b *GapBuffer[E]) LastOr(value E) E {
+	if v, ok := b.Last(); ok {
+		return v
+	}
+	return value
+}
+
+// LastWhere returns the last element which matches the callback.
+// It will return a zero value and false when none matches.
+func (b *GapBuffer[E]) LastWhere(callback func(item E) bool) (E, bool) {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+	for index := b.length() - 1; index >= 0; index-- {
+		if item := *b.at(index); callback(item) {
+			return item, true
+		}
+	}
+	return *new(E), false
+}
+
+// LastWhereOr returns the last element which matches the callback, it will return the default value when none matches.
+func (b *GapBuffer[E]) LastWhereOr(callback func(item E) bool, value E) E {
+	if v, ok := b.LastWhere(callback); ok {
+		return v
+	}
+	return value
+}
+
+// Pop removes the last element of the list and returns it.
+// It will return a zero value and false when the list is empty.
+func (b *GapBuffer[E]) Pop() (E, bool) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.length() == 0 {
+		return *new(E), false
+	}
+	value := *b.at(b.length() - 1)
+	b.remove(b.length()-1, 1)
+	return value, true
+}
+
+// Shift removes the first element of the list and returns it.
+// It will return a zero value and false when the list is empty.
+func (b *GapBuffer[E]) Shift() (E, bool) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.length() == 0 {
+		return *new(E), false
+	}
+	value := *b.at(0)
+	b.remove(0, 1)
+	return value, true
+}
+
+// Unshift puts elements to the head of the list.
+func (b *GapBuffer[E]) Unshift(values ...E) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.insert(0, values...)
+}
+
+// IndexOf returns the index of the specific element.
+func (b *GapBuffer[E]) IndexOf(value E) int {
+	return b.IndexOfWhere(func(item E) bool {
+		return reflect.DeepEqual(item, value)
+	})
+}
+
+// IndexOfWhere returns the index of the first element which matches the callback.
+func (b *GapBuffer[E]) IndexOfWhere(callback func(item E) bool) int {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+	for index := 0; index < b.length(); index++ {
+		if callback(*b.at(index)) {
+			return index
+		}
+	}
+	return -1
+}
+
+// Sub returns the sub list with given range, the cursor of the sub list is at its end
+func (b *GapBuffer[E]) Sub(from, to int) *GapBuffer[E] {
+	return NewGapBuffer(b.ToArray()[from:to]...)
+}
+
+// Where returns the sub list with elements which matches the callback
+func (b *GapBuffer[E]) Where(callback func(item E) bool) *GapBuffer[E] {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+	var items []E
+	for index := 0; index < b.length(); index++ {
+		if item := *b.at(index); callback(item) {
+			items = append(items, item)
+		}
+	}
+	return NewGapBuffer(items...)
+}
+
+// Compact makes the list more compact
+func (b *GapBuffer[E]) Compact(callback func(a, b E) bool) {
+	if callback == nil {
+		callback = func(a, b E) bool {
+			return reflect.DeepEqual(a, b)
+		}
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	items := make([]E, 0, b.length())
+	cursor := b.cursor
+	for index := 0; index < b.length(); index++ {
+		if item := *b.at(index); len(items) == 0 || !callback(item, items[len(items)-1]) {
+			items = append(items, item)
+		} else if index < b.cursor {
+			cursor--
+		}
+	}
+	b.reset(items)
+	b.cursor = cursor
+}
+
+// Min returns the min element
+func (b *GapBuffer[E]) Min(callback func(a, b E) int) E {
+	return slices.MinFunc(b.ToArray(), callback)
+}
+
+// Max returns the max element
+func (b *GapBuffer[E]) Max(callback func(a, b E) int) E {
+	return slices.MaxFunc(b.ToArray(), callback)
+}
+
+// Sort sorts the list, the cursor keeps its index
+func (b *GapBuffer[E]) Sort(callback func(a, b E) int) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	items := b.values()
+	slices.SortFunc(items, callback)
+	b.reset(items)
+}
+
+// Chunk splits list into multiply parts by given size
+func (b *GapBuffer[E]) Chunk(size int) *GapBuffer[*GapBuffer[any]] {
+	chunks := NewGapBuffer[*GapBuffer[any]]()
+	chunk := NewGapBuffer[any]()
+	for _, item := range b.ToArray() {
+		if chunk.length() < size {
+			chunk.Push(item)
+		} else {
+			chunks.Push(chunk)
+			chunk = NewGapBuffer[any](item)
+		}
+	}
+	chunks.Push(chunk)
+	return chunks
+}
+
+// Each travers the list, if the callback returns false then break.
+// The callback runs on a snapshot of the list, so it is allowed to modify the list.
+func (b *GapBuffer[E]) Each(callback func(index int, value E) bool) {
+	for index, value := range b.ToArray() {
+		if !callback(index, value) {
+			break
+		}
+	}
+}
+
+// All returns an iterator over the elements
+func (b *GapBuffer[E]) All() iter.Seq[E] {
+	return slices.Values(b.ToArray())
+}
+
+// Reverse reverses the list, the cursor keeps its index
+func (b *GapBuffer[E]) Reverse() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	items := b.values()
+	slices.Reverse(items)
+	b.reset(items)
+}
+
+// Clone clones the list with its cursor
+func (b *GapBuffer[E]) Clone() *GapBuffer[E] {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+	clone := NewGapBuffer(b.values()...)
+	clone.cursor = b.cursor
+	return clone
+}
+
+// DeepClone clones the list and its elements with its cursor, see [collection.CloneValue]
+func (b *GapBuffer[E]) DeepClone() *GapBuffer[E] {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+	items := b.values()
+	for index, item := range items {
+		items[index] = collection.CloneValue(item)
+	}
+	clone := NewGapBuffer(items...)
+	clone.cursor = b.cursor
+	return clone
+}
+
+// SetPreviewLimit sets the number of elements shown by String, %v and LogValue, a negative limit shows all elements
+func (b *GapBuffer[E]) SetPreviewLimit(limit int) {
+	b.limit.Set(limit)
+}
+
+// String converts to string, only the preview limit of elements are shown
+func (b *GapBuffer[E]) String() string {
+	return b.format(b.limit.Get(preview.DefaultLimit))
+}
+
+// Format implements [fmt.Formatter], %v prints the preview as String does, %+v and %#v print all elements
+func (b *GapBuffer[E]) Format(f fmt.State, verb rune) {
+	preview.Format(f, verb, b.format, b.limit.Get(preview.DefaultLimit))
+}
+
+func (b *GapBuffer[E]) format(limit int) string {
+	b.lock.RLock()
+	items, cursor := b.values(), b.cursor
+	b.lock.RUnlock()
+	return preview.Elements(fmt.Sprintf("GapBuffer[%T](len=%d, cursor=%d)", *new(E), len(items), cursor), items, limit)
+}
+
+// LogValue implements [slog.LogValuer], only the preview limit of elements are logged
+func (b *GapBuffer[E]) LogValue() slog.Value {
+	return preview.LogElements(fmt.Sprintf("GapBuffer[%T]", *new(E)), b.ToArray(), b.limit.Get(preview.DefaultLimit))
+}
+
+// ToJSON converts to json
+func (b *GapBuffer[E]) ToJSON() ([]byte, error) {
+	return json.Marshal(b.ToArray())
+}
+
+// ToArray converts to array, the returned slice is a copy, so modifying it does not affect the collection
+func (b *GapBuffer[E]) ToArray() []E {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+	return b.values()
+}
+
+// MarshalJSON implements [json.Marshaller]
+func (b *GapBuffer[E]) MarshalJSON() ([]byte, error) {
+	return b.ToJSON()
+}
+
+// UnmarshalJSON implements [json.Unmarshaller], it replaces the elements of the list
+func (b *GapBuffer[E]) UnmarshalJSON(data []byte) error {
+	var items []E
+	if err := json.Unmarshal(data, &items); err != nil {
+		return err
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.reset(items)
+	return nil
+}
+
+// EncodeJSON writes the list to w as a json array one element at a time, the list is read locked while writing
+func (b *GapBuffer[E]) EncodeJSON(w io.Writer) error {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+	return jsonstream.Encode(w, func(yield func(E) bool) {
+		for index := 0; index < b.length(); index++ {
+			if !yield(*b.at(index)) {
+				return
+			}
+		}
+	})
+}
+
+// DecodeJSON reads a json array from r one element at a time and replaces the elements of the list
+func (b *GapBuffer[E]) DecodeJSON(r io.Reader) error {
+	var items []E
+	if err := jsonstream.Decode(r, func(value E) {
+		items = append(items, value)
+	}); err != nil {
+		return err
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.reset(items)
+	return nil
+}
+
+// Value implements [driver.Valuer], the list is stored as a json array
+func (b *GapBuffer[E]) Value() (driver.Value, error) {
+	return sqljson.Value(b.ToJSON())
+}
+
+// Scan implements [database/sql.Scanner], it reads a json array and replaces the elements of the list, NULL is read as empty
+func (b *GapBuffer[E]) Scan(src any) error {
+	data, err := sqljson.Bytes(src)
+	if err != nil {
+		return err
+	}
+	return b.UnmarshalJSON(data)
+}
+
+// ToCBOR converts to cbor
+func (b *GapBuffer[E]) ToCBOR() ([]byte, error) {
+	return cbor.Marshal(b.ToArray())
+}
+
+// MarshalCBOR implements [cbor.Marshaler]
+func (b *GapBuffer[E]) MarshalCBOR() ([]byte, error) {
+	return b.ToCBOR()
+}
+
+// UnmarshalCBOR implements [cbor.Unmarshaler], it replaces the elements of the list
+func (b *GapBuffer[E]) UnmarshalCBOR(data []byte) error {
+	var items []E
+	if err := cbor.Unmarshal(data, &items); err != nil {
+		return err
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.reset(items)
+	return nil
+}
+
+// MarshalBinary implements [encoding.BinaryMarshaler], the elements are encoded by their own encoders
+func (b *GapBuffer[E]) MarshalBinary() ([]byte, error) {
+	return codec.EncodeValues(b.ToArray())
+}
+
+// UnmarshalBinary implements [encoding.BinaryUnmarshaler], it replaces the elements of the list
+func (b *GapBuffer[E]) UnmarshalBinary(data []byte) error {
+	items, err := codec.DecodeValues[E](data)
+	if err != nil {
+		return err
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.reset(items)
+	return nil
+}
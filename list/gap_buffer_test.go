@@ -0,0 +1,185 @@
+package list
+
+import (
+	"encoding/json"
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGapBuffer_Insert(t *testing.T) {
+	b := NewGapBuffer[rune]([]rune("held")...)
+	assert.Equal(t, 4, b.Cursor())
+	b.MoveCursor(3)
+	b.Insert([]rune("lo worl")...)
+	assert.Equal(t, "hello world", string(b.ToArray()))
+	assert.Equal(t, 10, b.Cursor())
+	b.MoveCursor(0)
+	b.Insert('>', ' ')
+	assert.Equal(t, "> hello world", string(b.ToArray()))
+	assert.Equal(t, 2, b.Cursor())
+	assert.Panics(t, func() {
+		b.MoveCursor(14)
+	})
+}
+
+func TestGapBuffer_Delete(t *testing.T) {
+	b := NewGapBuffer([]rune("hello world")...)
+	b.MoveCursor(5)
+	assert.Equal(t, 6, b.Delete(10))
+	assert.Equal(t, "hello", string(b.ToArray()))
+	assert.Equal(t, 2, b.Backspace(2))
+	assert.Equal(t, "hel", string(b.ToArray()))
+	assert.Equal(t, 3, b.Cursor())
+	assert.Equal(t, 0, b.Delete(1))
+	assert.Equal(t, 3, b.Backspace(5))
+	assert.True(t, b.IsEmpty())
+}
+
+func TestGapBuffer_Cursor(t *testing.T) {
+	b := NewGapBuffer(1, 2, 3, 4)
+	b.MoveCursor(2)
+	b.Unshift(0)
+	assert.Equal(t, 3, b.Cursor())
+	b.Push(5)
+	assert.Equal(t, 3, b.Cursor())
+	b.RemoveAt(0)
+	assert.Equal(t, 2, b.Cursor())
+	b.RemoveWhere(func(item int) bool {
+		return item%2 == 1
+	})
+	assert.Equal(t, []int{2, 4}, b.ToArray())
+	assert.Equal(t, 1, b.Cursor())
+	b.Clear()
+	assert.Equal(t, 0, b.Cursor())
+}
+
+func TestGapBuffer_Random(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	b := NewGapBuffer[int]()
+	var expected []int
+	cursor := 0
+	for i := 0; i < 2000; i++ {
+		switch random.Intn(6) {
+		case 0:
+			cursor = random.Intn(len(expected) + 1)
+			b.MoveCursor(cursor)
+		case 1, 2:
+			values := []int{i, -i}
+			b.Insert(values...)
+			expected = slices.Insert(expected, cursor, values...)
+			cursor += len(values)
+		case 3:
+			n := min(random.Intn(3), len(expected)-cursor)
+			assert.Equal(t, n, b.Delete(n))
+			expected = slices.Delete(expected, cursor, cursor+n)
+		case 4:
+			n := min(random.Intn(3), cursor)
+			assert.Equal(t, n, b.Backspace(n))
+			expected = slices.Delete(expected, cursor-n, cursor)
+			cursor -= n
+		case 5:
+			b.Push(i)
+			expected = append(expected, i)
+		}
+		assert.Equal(t, cursor, b.Cursor())
+	}
+	assert.Equal(t, expected, b.ToArray())
+	for index, value := range expected {
+		assert.Equal(t, value, b.Get(index))
+	}
+}
+
+func TestGapBuffer_Get(t *testing.T) {
+	b := NewGapBuffer(1, 2, 3)
+	b.MoveCursor(1)
+	b.Insert(9)
+	assert.Equal(t, 9, b.Get(1))
+	assert.Equal(t, 3, b.Get(3))
+	b.Set(3, 4)
+	assert.Equal(t, []int{1, 9, 2, 4}, b.ToArray())
+	assert.Panics(t, func() {
+		b.Get(4)
+	})
+}
+
+func TestGapBuffer_Pop(t *testing.T) {
+	b := NewGapBuffer(1, 2, 3)
+	b.MoveCursor(1)
+	v, ok := b.Pop()
+	assert.True(t, ok)
+	assert.Equal(t, 3, v)
+	v, ok = b.Shift()
+	assert.True(t, ok)
+	assert.Equal(t, 1, v)
+	assert.Equal(t, 0, b.Cursor())
+	assert.Equal(t, 2, b.FirstOr(0))
+	assert.Equal(t, 2, b.LastOr(0))
+	b.Clear()
+	_, ok = b.Pop()
+	assert.False(t, ok)
+	_, ok = b.First()
+	assert.False(t, ok)
+}
+
+func TestGapBuffer_IndexOf(t *testing.T) {
+	b := NewGapBuffer(1, 2, 3, 2)
+	b.MoveCursor(2)
+	assert.Equal(t, 1, b.IndexOf(2))
+	assert.Equal(t, -1, b.IndexOf(5))
+	assert.True(t, b.Contains(3))
+	v, ok := b.LastWhere(func(item int) bool {
+		return item < 3
+	})
+	assert.True(t, ok)
+	assert.Equal(t, 2, v)
+	assert.Equal(t, []int{2, 3}, b.Sub(1, 3).ToArray())
+	assert.Equal(t, []int{2, 2}, b.Where(func(item int) bool {
+		return item == 2
+	}).ToArray())
+}
+
+func TestGapBuffer_Compact(t *testing.T) {
+	b := NewGapBuffer(1, 1, 2, 2, 3)
+	b.MoveCursor(4)
+	b.Compact(nil)
+	assert.Equal(t, []int{1, 2, 3}, b.ToArray())
+	assert.Equal(t, 2, b.Cursor())
+	b.Sort(func(a, b int) int {
+		return b - a
+	})
+	assert.Equal(t, []int{3, 2, 1}, b.ToArray())
+	b.Reverse()
+	assert.Equal(t, []int{1, 2, 3}, b.ToArray())
+	assert.Equal(t, 3, b.Max(func(a, b int) int { return a - b }))
+	assert.Equal(t, []any{3}, b.Chunk(2).Get(1).ToArray())
+}
+
+func TestGapBuffer_Clone(t *testing.T) {
+	b := NewGapBuffer(1, 2, 3)
+	b.MoveCursor(1)
+	clone := b.Clone()
+	clone.Insert(5)
+	assert.Equal(t, []int{1, 2, 3}, b.ToArray())
+	assert.Equal(t, []int{1, 5, 2, 3}, clone.ToArray())
+	assert.Equal(t, 1, b.DeepClone().Cursor())
+}
+
+func TestGapBuffer_MarshalJSON(t *testing.T) {
+	b := NewGapBuffer(1, 2, 3)
+	b.MoveCursor(1)
+	data, err := json.Marshal(b)
+	assert.Nil(t, err)
+	assert.JSONEq(t, `[1,2,3]`, string(data))
+	decoded := NewGapBuffer(9)
+	assert.Nil(t, json.Unmarshal(data, decoded))
+	assert.Equal(t, []int{1, 2, 3}, decoded.ToArray())
+	assert.Equal(t, 1, decoded.Cursor())
+}
+
+func TestGapBuffer_String(t *testing.T) {
+	b := NewGapBuffer(1, 2)
+	assert.Equal(t, "GapBuffer[int](len=2, cursor=2){\n\t1,\n\t2,\n}", b.String())
+}
@@ -0,0 +1,79 @@
+package list
+
+import "slices"
+
+// NewHistoryList new history list which keeps at most depth mutations to undo, a depth <= 0 keeps all of them
+func NewHistoryList[E any](depth int, values ...E) *HistoryList[E] {
+	list := &HistoryList[E]{depth: depth}
+	list.editor = editor[E]{List: NewList(values...), onEdit: list.record}
+	return list
+}
+
+// HistoryList list which records its mutations, so they can be undone and redone as in an editor.
+// Each mutation is recorded as an edit, a new mutation discards the mutations which are undone.
+// All methods are safe for concurrent use, the methods which are not listed here are the ones of [List].
+// Mutations through SortInterface are not recorded, use Sort instead.
+type HistoryList[E any] struct {
+	editor[E]
+	depth int
+	undo  []edit[E]
+	redo  []edit[E]
+}
+
+// Undo undoes the last mutation, it returns false when there is nothing to undo
+func (h *HistoryList[E]) Undo() bool {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if len(h.undo) == 0 {
+		return false
+	}
+	e := h.undo[len(h.undo)-1]
+	h.undo = h.undo[:len(h.undo)-1]
+	h.items = e.invert().apply(h.items)
+	h.redo = append(h.redo, e)
+	return true
+}
+
+// Redo redoes the last undone mutation, it returns false when there is nothing to redo
+func (h *HistoryList[E]) Redo() bool {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if len(h.redo) == 0 {
+		return false
+	}
+	e := h.redo[len(h.redo)-1]
+	h.redo = h.redo[:len(h.redo)-1]
+	h.items = e.apply(h.items)
+	h.undo = append(h.undo, e)
+	return true
+}
+
+// CanUndo returns whether there is a mutation to undo
+func (h *HistoryList[E]) CanUndo() bool {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+	return len(h.undo) > 0
+}
+
+// CanRedo returns whether there is a mutation to redo
+func (h *HistoryList[E]) CanRedo() bool {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+	return len(h.redo) > 0
+}
+
+// ClearHistory forgets the mutations, the elements are kept
+func (h *HistoryList[E]) ClearHistory() {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.undo = nil
+	h.redo = nil
+}
+
+func (h *HistoryList[E]) record(e edit[E]) {
+	h.undo = append(h.undo, e)
+	if h.depth > 0 && len(h.undo) > h.depth {
+		h.undo = slices.Delete(h.undo, 0, len(h.undo)-h.depth)
+	}
+	h.redo = nil
+}
@@ -0,0 +1,137 @@
+package list
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistoryList_Undo(t *testing.T) {
+	list := NewHistoryList(0, 1, 2)
+	assert.False(t, list.CanUndo())
+	list.Push(3, 4)
+	list.Unshift(0)
+	list.Set(1, 10)
+	list.RemoveAt(2)
+	v, ok := list.Pop()
+	assert.True(t, ok)
+	assert.Equal(t, 4, v)
+	v, ok = list.Shift()
+	assert.True(t, ok)
+	assert.Equal(t, 0, v)
+	assert.Equal(t, []int{10, 3}, list.ToArray())
+
+	expected := [][]int{
+		{0, 10, 3},
+		{0, 10, 3, 4},
+		{0, 10, 2, 3, 4},
+		{0, 1, 2, 3, 4},
+		{1, 2, 3, 4},
+		{1, 2},
+	}
+	for _, items := range expected {
+		assert.True(t, list.Undo())
+		assert.Equal(t, items, list.ToArray())
+	}
+	assert.False(t, list.Undo())
+	assert.True(t, list.CanRedo())
+}
+
+func TestHistoryList_Redo(t *testing.T) {
+	list := NewHistoryList(0, 3, 1, 2, 1)
+	list.Remove(1)
+	list.Push(5)
+	list.Sort(func(a, b int) int { return b - a })
+	list.Reverse()
+	list.Clear()
+	assert.True(t, list.IsEmpty())
+	for list.Undo() {
+	}
+	assert.Equal(t, []int{3, 1, 2, 1}, list.ToArray())
+	expected := [][]int{
+		{3, 2},
+		{3, 2, 5},
+		{5, 3, 2},
+		{2, 3, 5},
+		{},
+	}
+	for _, items := range expected {
+		assert.True(t, list.Redo())
+		assert.Equal(t, items, list.ToArray())
+	}
+	assert.False(t, list.Redo())
+}
+
+func TestHistoryList_Replay(t *testing.T) {
+	list := NewHistoryList(0, 1, 2, 3)
+	assert.Nil(t, list.Replay(Op[int]{Index: 1, Delete: 1, Insert: []int{4, 5}}, Op[int]{Index: 0, Delete: 1}))
+	assert.Equal(t, []int{4, 5, 3}, list.ToArray())
+	assert.True(t, list.Undo())
+	assert.Equal(t, []int{1, 4, 5, 3}, list.ToArray())
+	assert.True(t, list.Undo())
+	assert.Equal(t, []int{1, 2, 3}, list.ToArray())
+	assert.False(t, list.CanUndo())
+
+	err := list.Replay(Op[int]{Index: 0, Delete: 1}, Op[int]{Index: 2, Delete: 1})
+	assert.EqualError(t, err, "list: op 1 replaces [2, 3) out of range of length 2")
+	assert.Equal(t, []int{1, 2, 3}, list.ToArray())
+	assert.False(t, list.CanUndo())
+}
+
+func TestHistoryList_Depth(t *testing.T) {
+	list := NewHistoryList[int](2)
+	list.Push(1)
+	list.Push(2)
+	list.Push(3)
+	assert.True(t, list.Undo())
+	assert.True(t, list.Undo())
+	assert.False(t, list.Undo())
+	assert.Equal(t, []int{1}, list.ToArray())
+	list.Push(4)
+	assert.False(t, list.CanRedo())
+	list.ClearHistory()
+	assert.False(t, list.CanUndo())
+	assert.Equal(t, []int{1, 4}, list.ToArray())
+}
+
+func TestHistoryList_UnmarshalJSON(t *testing.T) {
+	list := NewHistoryList(0, 1)
+	assert.Nil(t, json.Unmarshal([]byte(`[2,3]`), list))
+	assert.Equal(t, []int{2, 3}, list.ToArray())
+	assert.True(t, list.Undo())
+	assert.Equal(t, []int{1}, list.ToArray())
+	assert.NotNil(t, list.Scan(1))
+	assert.True(t, list.CanRedo())
+	assert.Nil(t, list.Scan("[4]"))
+	assert.False(t, list.CanRedo())
+	assert.Equal(t, []int{4}, list.ToArray())
+}
+
+func TestHistoryList_Compact(t *testing.T) {
+	list := NewHistoryList(0, 1, 1, 2)
+	list.Compact(nil)
+	list.Compact(nil)
+	assert.Equal(t, []int{1, 2}, list.ToArray())
+	assert.True(t, list.Undo())
+	assert.False(t, list.Undo())
+	assert.Equal(t, []int{1, 1, 2}, list.ToArray())
+}
+
+func TestHistoryList_Splice(t *testing.T) {
+	list := NewHistoryList(0, 1, 2, 3, 4)
+	list.InsertAt(1, 5)
+	assert.Equal(t, []int{5, 2}, list.Splice(1, 2, 6))
+	list.Swap(0, 3)
+	assert.Equal(t, []int{4, 6, 3, 1}, list.ToArray())
+
+	expected := [][]int{
+		{1, 6, 3, 4},
+		{1, 5, 2, 3, 4},
+		{1, 2, 3, 4},
+	}
+	for _, items := range expected {
+		assert.True(t, list.Undo())
+		assert.Equal(t, items, list.ToArray())
+	}
+}
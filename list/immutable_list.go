@@ -0,0 +1,214 @@
+package list
+
+import (
+	"encoding/json"
+	"fmt"
+	"iter"
+	"log/slog"
+	"reflect"
+	"slices"
+
+	"github.com/gopi-frame/collection/internal/preview"
+)
+
+// NewImmutableList new immutable list
+func NewImmutableList[E any](values ...E) *ImmutableList[E] {
+	return &ImmutableList[E]{vector: NewPersistentVector(values...)}
+}
+
+// ImmutableList list whose mutation methods return a new list and leave the list itself unchanged,
+// so it can be shared across goroutines without locks or defensive copies.
+// It is backed by a [PersistentVector], Set, Push and Pop take O(log32 n) time and share all but the changed path
+// with the previous list, the other mutations rebuild the list in O(n) time.
+type ImmutableList[E any] struct {
+	vector *PersistentVector[E]
+	limit  preview.Limit
+}
+
+// with returns a new list backed by the vector
+func (list *ImmutableList[E]) with(vector *PersistentVector[E]) *ImmutableList[E] {
+	return &ImmutableList[E]{vector: vector}
+}
+
+// Count returns the size of the list
+func (list *ImmutableList[E]) Count() int64 {
+	return list.vector.Count()
+}
+
+// IsEmpty returns whether the list is empty.
+func (list *ImmutableList[E]) IsEmpty() bool {
+	return list.Count() == 0
+}
+
+// IsNotEmpty returns whether the list is not empty.
+func (list *ImmutableList[E]) IsNotEmpty() bool {
+	return !list.IsEmpty()
+}
+
+// Get returns the element on the specific index, it panics when the index is out of range
+func (list *ImmutableList[E]) Get(index int) E {
+	return list.vector.Get(index)
+}
+
+// First returns the first element of the list.
+// it will return a zero value and false when the list is empty.
+func (list *ImmutableList[E]) First() (E, bool) {
+	if list.IsEmpty() {
+		return *new(E), false
+	}
+	return list.vector.Get(0), true
+}
+
+// Last returns the last element of the list.
+// It will return a zero value and false when the list is empty.
+func (list *ImmutableList[E]) Last() (E, bool) {
+	if list.IsEmpty() {
+		return *new(E), false
+	}
+	return list.vector.Get(int(list.Count()) - 1), true
+}
+
+// Contains returns whether the list contains the specific element.
+func (list *ImmutableList[E]) Contains(value E) bool {
+	return list.IndexOf(value) >= 0
+}
+
+// IndexOf returns the index of the specific element.
+func (list *ImmutableList[E]) IndexOf(value E) int {
+	return list.IndexOfWhere(func(item E) bool {
+		return reflect.DeepEqual(value, item)
+	})
+}
+
+// IndexOfWhere returns the index of the first element which matches the callback.
+func (list *ImmutableList[E]) IndexOfWhere(callback func(item E) bool) int {
+	index := 0
+	for value := range list.vector.All() {
+		if callback(value) {
+			return index
+		}
+		index++
+	}
+	return -1
+}
+
+// Push returns a new list with the elements appended
+func (list *ImmutableList[E]) Push(values ...E) *ImmutableList[E] {
+	return list.with(list.vector.Push(values...))
+}
+
+// Set returns a new list with the element on the specific index replaced, it panics when the index is out of range
+func (list *ImmutableList[E]) Set(index int, value E) *ImmutableList[E] {
+	list.vector.check(index)
+	return list.with(list.vector.Set(index, value))
+}
+
+// Pop returns a new list without the last element and the last element,
+// it returns the list itself, a zero value and false when the list is empty
+func (list *ImmutableList[E]) Pop() (*ImmutableList[E], E, bool) {
+	vector, value, ok := list.vector.Pop()
+	if !ok {
+		return list, value, false
+	}
+	return list.with(vector), value, true
+}
+
+// InsertAt returns a new list with the elements inserted at the specific index.
+// It panics when the index is out of [0, Count()].
+func (list *ImmutableList[E]) InsertAt(index int, values ...E) *ImmutableList[E] {
+	return NewImmutableList(slices.Insert(list.ToArray(), index, values...)...)
+}
+
+// RemoveAt returns a new list without the element on the specific index
+func (list *ImmutableList[E]) RemoveAt(index int) *ImmutableList[E] {
+	return NewImmutableList(slices.Delete(list.ToArray(), index, index+1)...)
+}
+
+// Remove returns a new list without the specific element
+func (list *ImmutableList[E]) Remove(value E) *ImmutableList[E] {
+	return list.Where(func(item E) bool {
+		return !reflect.DeepEqual(value, item)
+	})
+}
+
+// Where returns a new list with the elements which match the callback
+func (list *ImmutableList[E]) Where(callback func(item E) bool) *ImmutableList[E] {
+	vector := new(PersistentVector[E])
+	for value := range list.vector.All() {
+		if callback(value) {
+			vector = vector.Push(value)
+		}
+	}
+	return list.with(vector)
+}
+
+// Each travers the list, if the callback returns false then break.
+func (list *ImmutableList[E]) Each(callback func(index int, value E) bool) {
+	index := 0
+	for value := range list.vector.All() {
+		if !callback(index, value) {
+			break
+		}
+		index++
+	}
+}
+
+// All returns an iterator over the elements
+func (list *ImmutableList[E]) All() iter.Seq[E] {
+	return list.vector.All()
+}
+
+// ToArray converts to array, the returned slice is a copy, so modifying it does not affect the collection
+func (list *ImmutableList[E]) ToArray() []E {
+	return list.vector.ToArray()
+}
+
+// ToList converts to a mutable [List] of the elements
+func (list *ImmutableList[E]) ToList() *List[E] {
+	return &List[E]{items: list.ToArray()}
+}
+
+// ToJSON converts to json
+func (list *ImmutableList[E]) ToJSON() ([]byte, error) {
+	return json.Marshal(list.ToArray())
+}
+
+// MarshalJSON implements [json.Marshaler]
+func (list *ImmutableList[E]) MarshalJSON() ([]byte, error) {
+	return list.ToJSON()
+}
+
+// UnmarshalJSON implements [json.Unmarshaler], it decodes into the list itself, so it must not be shared yet
+func (list *ImmutableList[E]) UnmarshalJSON(data []byte) error {
+	values := make([]E, 0)
+	if err := json.Unmarshal(data, &values); err != nil {
+		return err
+	}
+	list.vector = NewPersistentVector(values...)
+	return nil
+}
+
+// SetPreviewLimit sets the number of elements shown by String, %v and LogValue, a negative limit shows all elements
+func (list *ImmutableList[E]) SetPreviewLimit(limit int) {
+	list.limit.Set(limit)
+}
+
+// String converts to string, only the preview limit of elements are shown
+func (list *ImmutableList[E]) String() string {
+	return list.format(list.limit.Get(preview.DefaultLimit))
+}
+
+// Format implements [fmt.Formatter], %v prints the preview as String does, %+v and %#v print all elements
+func (list *ImmutableList[E]) Format(f fmt.State, verb rune) {
+	preview.Format(f, verb, list.format, list.limit.Get(preview.DefaultLimit))
+}
+
+func (list *ImmutableList[E]) format(limit int) string {
+	items := list.ToArray()
+	return preview.Elements(fmt.Sprintf("ImmutableList[%T](len=%d)", *new(E), len(items)), items, limit)
+}
+
+// LogValue implements [slog.LogValuer], only the preview limit of elements are logged
+func (list *ImmutableList[E]) LogValue() slog.Value {
+	return preview.LogElements(fmt.Sprintf("ImmutableList[%T]", *new(E)), list.ToArray(), list.limit.Get(preview.DefaultLimit))
+}
@@ -0,0 +1,105 @@
+package list
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImmutableList_Push(t *testing.T) {
+	list := NewImmutableList(1, 2)
+	pushed := list.Push(3, 4)
+	assert.Equal(t, []int{1, 2}, list.ToArray())
+	assert.Equal(t, []int{1, 2, 3, 4}, pushed.ToArray())
+	assert.EqualValues(t, 4, pushed.Count())
+	assert.True(t, NewImmutableList[int]().IsEmpty())
+}
+
+func TestImmutableList_Set(t *testing.T) {
+	list := NewImmutableList(1, 2, 3)
+	set := list.Set(1, 9)
+	assert.Equal(t, []int{1, 2, 3}, list.ToArray())
+	assert.Equal(t, []int{1, 9, 3}, set.ToArray())
+	assert.Panics(t, func() {
+		list.Set(3, 4)
+	})
+}
+
+func TestImmutableList_Pop(t *testing.T) {
+	list := NewImmutableList(1, 2)
+	popped, value, ok := list.Pop()
+	assert.True(t, ok)
+	assert.Equal(t, 2, value)
+	assert.Equal(t, []int{1}, popped.ToArray())
+	assert.Equal(t, []int{1, 2}, list.ToArray())
+	empty := NewImmutableList[int]()
+	same, _, ok := empty.Pop()
+	assert.False(t, ok)
+	assert.Same(t, empty, same)
+}
+
+func TestImmutableList_InsertAt(t *testing.T) {
+	list := NewImmutableList(1, 4)
+	inserted := list.InsertAt(1, 2, 3)
+	assert.Equal(t, []int{1, 2, 3, 4}, inserted.ToArray())
+	assert.Equal(t, []int{1, 3, 4}, inserted.RemoveAt(1).ToArray())
+	assert.Equal(t, []int{1, 2, 4}, inserted.Remove(3).ToArray())
+	assert.Equal(t, []int{2, 4}, inserted.Where(func(item int) bool {
+		return item%2 == 0
+	}).ToArray())
+	assert.Equal(t, []int{1, 4}, list.ToArray())
+}
+
+func TestImmutableList_Get(t *testing.T) {
+	list := NewImmutableList(1, 2, 3)
+	assert.Equal(t, 2, list.Get(1))
+	first, _ := list.First()
+	last, _ := list.Last()
+	assert.Equal(t, 1, first)
+	assert.Equal(t, 3, last)
+	assert.Equal(t, 2, list.IndexOf(3))
+	assert.True(t, list.Contains(1))
+	assert.False(t, list.Contains(4))
+	_, ok := NewImmutableList[int]().Last()
+	assert.False(t, ok)
+}
+
+func TestImmutableList_Each(t *testing.T) {
+	list := NewImmutableList(1, 2, 3)
+	var indexes []int
+	list.Each(func(index int, value int) bool {
+		indexes = append(indexes, index)
+		return value < 2
+	})
+	assert.Equal(t, []int{0, 1}, indexes)
+	assert.Equal(t, []int{1, 2, 3}, list.ToList().ToArray())
+}
+
+func TestImmutableList_MarshalJSON(t *testing.T) {
+	data, err := json.Marshal(NewImmutableList(1, 2, 3))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `[1,2,3]`, string(data))
+	decoded := new(ImmutableList[int])
+	assert.NoError(t, json.Unmarshal(data, decoded))
+	assert.Equal(t, []int{1, 2, 3}, decoded.ToArray())
+}
+
+func TestImmutableList_String(t *testing.T) {
+	assert.Equal(t, "ImmutableList[int](len=1){\n\t1,\n}", NewImmutableList(1).String())
+}
+
+func TestImmutableList_Concurrent(t *testing.T) {
+	list := NewImmutableList(1, 2, 3)
+	var wg sync.WaitGroup
+	for i := range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.EqualValues(t, 4, list.Push(i).Count())
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, []int{1, 2, 3}, list.ToArray())
+}
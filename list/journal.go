@@ -0,0 +1,76 @@
+package list
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Op mutation of a list recorded by [JournalList], it replaces Delete elements at Index with the Insert elements.
+// Ops can be serialized, and replayed onto another list with [List.Replay].
+type Op[E any] struct {
+	Index  int `json:"index"`
+	Delete int `json:"delete"`
+	Insert []E `json:"insert,omitempty"`
+}
+
+// NewJournalList new journal list, journal is called with the op of each mutation in the order they are made
+func NewJournalList[E any](journal func(op Op[E]), values ...E) *JournalList[E] {
+	return &JournalList[E]{
+		editor: editor[E]{
+			List: NewList(values...),
+			onEdit: func(e edit[E]) {
+				journal(Op[E]{Index: e.index, Delete: len(e.removed), Insert: e.inserted})
+			},
+		},
+	}
+}
+
+// JournalList list which records its mutations as ops, so they can be replayed onto another list,
+// which has the same elements as the journal list had when the journal started.
+// The journal is called while the list is locked, so it must not call methods of the same list.
+// All methods are safe for concurrent use, the methods which are not listed here are the ones of [List].
+// Mutations through SortInterface are not recorded, use Sort instead.
+type JournalList[E any] struct {
+	editor[E]
+}
+
+// Replay applies the ops in order, the list is left unchanged when an op is out of range
+func (list *List[E]) Replay(ops ...Op[E]) error {
+	list.lock.Lock()
+	defer list.lock.Unlock()
+	if err := checkOps(len(list.items), ops); err != nil {
+		return err
+	}
+	items := slices.Clone(list.items)
+	for _, op := range ops {
+		items = slices.Replace(items, op.Index, op.Index+op.Delete, op.Insert...)
+	}
+	list.items = items
+	return nil
+}
+
+// Replay applies the ops in order as edits, so a [HistoryList] can undo them and a [JournalList] records them,
+// the list is left unchanged when an op is out of range
+func (l *editor[E]) Replay(ops ...Op[E]) error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if err := checkOps(len(l.items), ops); err != nil {
+		return err
+	}
+	for _, op := range ops {
+		removed := slices.Clone(l.items[op.Index : op.Index+op.Delete])
+		l.do(edit[E]{index: op.Index, removed: removed, inserted: slices.Clone(op.Insert)})
+	}
+	return nil
+}
+
+// checkOps returns an error for the first op which is out of range of a list of the length after the ops before it
+func checkOps[E any](length int, ops []Op[E]) error {
+	for index, op := range ops {
+		if op.Index < 0 || op.Delete < 0 || op.Index+op.Delete > length {
+			return fmt.Errorf("list: op %d replaces [%d, %d) out of range of length %d", index, op.Index, op.Index+op.Delete, length)
+		}
+		length += len(op.Insert) - op.Delete
+	}
+	return nil
+}
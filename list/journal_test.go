@@ -0,0 +1,55 @@
+package list
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJournalList_Replay(t *testing.T) {
+	var ops []Op[int]
+	list := NewJournalList(func(op Op[int]) {
+		ops = append(ops, op)
+	}, 1, 2)
+	list.Push(3)
+	list.Unshift(0)
+	list.Set(2, 20)
+	list.RemoveAt(0)
+	list.Pop()
+	list.Reverse()
+	list.Clear()
+	list.Push(5, 6)
+	list.Sort(func(a, b int) int { return b - a })
+	assert.Len(t, ops, 9)
+
+	data, err := json.Marshal(ops)
+	assert.Nil(t, err)
+	var decoded []Op[int]
+	assert.Nil(t, json.Unmarshal(data, &decoded))
+	replica := NewList(1, 2)
+	assert.Nil(t, replica.Replay(decoded...))
+	assert.Equal(t, list.ToArray(), replica.ToArray())
+	assert.Equal(t, []int{6, 5}, replica.ToArray())
+
+	t.Run("journal list", func(t *testing.T) {
+		var replayed []Op[int]
+		replica := NewJournalList(func(op Op[int]) {
+			replayed = append(replayed, op)
+		}, 1, 2)
+		assert.Nil(t, replica.Replay(decoded...))
+		assert.Equal(t, []int{6, 5}, replica.ToArray())
+		assert.Equal(t, decoded, replayed)
+		assert.Error(t, replica.Replay(Op[int]{Index: 3}))
+		assert.Len(t, replayed, len(decoded))
+	})
+}
+
+func TestList_Replay(t *testing.T) {
+	list := NewList(1, 2, 3)
+	assert.Nil(t, list.Replay(Op[int]{Index: 1, Delete: 1, Insert: []int{4, 5}}))
+	assert.Equal(t, []int{1, 4, 5, 3}, list.ToArray())
+	err := list.Replay(Op[int]{Index: 0, Delete: 1}, Op[int]{Index: 2, Delete: 2})
+	assert.EqualError(t, err, "list: op 1 replaces [2, 4) out of range of length 3")
+	assert.Equal(t, []int{1, 4, 5, 3}, list.ToArray())
+}
@@ -1,15 +1,22 @@
 package list
 
 import (
-	listlib "container/list"
+	"database/sql/driver"
 	"encoding/json"
 	"fmt"
+	"io"
+	"iter"
+	"log/slog"
 	"reflect"
 	"slices"
-	"strings"
 	"sync"
 
-	"github.com/gopi-frame/contract"
+	"github.com/fxamacker/cbor/v2"
+	"github.com/gopi-frame/collection"
+	"github.com/gopi-frame/collection/internal/codec"
+	"github.com/gopi-frame/collection/internal/jsonstream"
+	"github.com/gopi-frame/collection/internal/preview"
+	"github.com/gopi-frame/collection/internal/sqljson"
 	"github.com/gopi-frame/exception"
 )
 
@@ -20,39 +27,45 @@ func NewLinkedList[E any](values ...E) *LinkedList[E] {
 	return instance
 }
 
-// LinkedList linked list
+// LinkedList linked list, all methods are safe for concurrent use.
+// The callbacks run while the list is locked, so they must not call methods of the same list,
+// except the callback of Each which runs on a snapshot.
 type LinkedList[E any] struct {
-	sync.RWMutex
-	list *listlib.List
+	lock  sync.RWMutex
+	once  sync.Once
+	list  *chain[E]
+	pool  *sync.Pool
+	limit preview.Limit
 }
 
 func (l *LinkedList[E]) init() {
-	if l.list == nil {
-		l.list = listlib.New()
-	}
+	l.once.Do(func() {
+		if l.list == nil {
+			l.list = newChain[E](nil)
+		}
+	})
 }
 
 // Count returns the size of the list
 func (l *LinkedList[E]) Count() int64 {
 	l.init()
+	l.lock.RLock()
+	defer l.lock.RUnlock()
 	return int64(l.list.Len())
 }
 
 // IsEmpty returns whether the list is empty.
 func (l *LinkedList[E]) IsEmpty() bool {
-	l.init()
 	return l.Count() == 0
 }
 
 // IsNotEmpty returns whether the list is not empty.
 func (l *LinkedList[E]) IsNotEmpty() bool {
-	l.init()
 	return !l.IsEmpty()
 }
 
 // Contains returns whether the list contains the specific element.
 func (l *LinkedList[E]) Contains(value E) bool {
-	l.init()
 	return l.ContainsWhere(func(item E) bool {
 		return reflect.DeepEqual(item, value)
 	})
@@ -61,8 +74,10 @@ func (l *LinkedList[E]) Contains(value E) bool {
 // ContainsWhere returns whether the list contains specific elements by callback.
 func (l *LinkedList[E]) ContainsWhere(callback func(value E) bool) bool {
 	l.init()
+	l.lock.RLock()
+	defer l.lock.RUnlock()
 	for e := l.list.Front(); e != nil; e = e.Next() {
-		if callback(e.Value.(E)) {
+		if callback(e.Value) {
 			return true
 		}
 	}
@@ -72,6 +87,8 @@ func (l *LinkedList[E]) ContainsWhere(callback func(value E) bool) bool {
 // Push pushes elements into the list.
 func (l *LinkedList[E]) Push(values ...E) {
 	l.init()
+	l.lock.Lock()
+	defer l.lock.Unlock()
 	for _, value := range values {
 		l.list.PushBack(value)
 	}
@@ -87,10 +104,12 @@ func (l *LinkedList[E]) Remove(value E) {
 // RemoveWhere removes specific elements by callback.
 func (l *LinkedList[E]) RemoveWhere(callback func(item E) bool) {
 	l.init()
-	var next *listlib.Element
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	var next *element[E]
 	for e := l.list.Front(); e != nil; e = next {
 		next = e.Next()
-		if callback(e.Value.(E)) {
+		if callback(e.Value) {
 			l.list.Remove(e)
 		}
 	}
@@ -99,7 +118,9 @@ func (l *LinkedList[E]) RemoveWhere(callback func(item E) bool) {
 // RemoveAt removes the element on the specific index.
 func (l *LinkedList[E]) RemoveAt(index int) {
 	l.init()
-	var next *listlib.Element
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	var next *element[E]
 	for e, i := l.list.Front(), 0; e != nil; e, i = next, i+1 {
 		next = e.Next()
 		if i == index {
@@ -109,21 +130,116 @@ func (l *LinkedList[E]) RemoveAt(index int) {
 	}
 }
 
+// InsertAt inserts the elements at the specific index, the elements from the index on are moved after them.
+// It panics when the index is out of [0, Count()].
+func (l *LinkedList[E]) InsertAt(index int, values ...E) {
+	l.init()
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.insertBefore(l.at(index, l.list.Len()), values...)
+}
+
+// Splice removes at most deleteCount elements from the start index, inserts the values there,
+// and returns the removed elements, like the splice method of javascript arrays.
+// It panics when the start index is out of [0, Count()].
+func (l *LinkedList[E]) Splice(start, deleteCount int, values ...E) []E {
+	l.init()
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	e := l.at(start, l.list.Len())
+	var removed []E
+	for len(removed) < deleteCount && e != nil {
+		next := e.Next()
+		removed = append(removed, l.list.Remove(e))
+		e = next
+	}
+	l.insertBefore(e, values...)
+	return removed
+}
+
+// Swap swaps the elements on the specific indexes.
+// It panics when an index is out of [0, Count()-1].
+func (l *LinkedList[E]) Swap(i, j int) {
+	l.init()
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	a, b := l.at(i, l.list.Len()-1), l.at(j, l.list.Len()-1)
+	a.Value, b.Value = b.Value, a.Value
+}
+
+// at returns the element on the index, walking from the nearer end, or nil when the index is the length.
+// It panics when the index is out of [0, last].
+func (l *LinkedList[E]) at(index, last int) *element[E] {
+	if index < 0 || index > last {
+		panic(exception.NewRangeException(0, last))
+	}
+	if index == l.list.Len() {
+		return nil
+	}
+	if index < l.list.Len()/2 {
+		e := l.list.Front()
+		for ; index > 0; index-- {
+			e = e.Next()
+		}
+		return e
+	}
+	e := l.list.Back()
+	for i := l.list.Len() - 1; i > index; i-- {
+		e = e.Prev()
+	}
+	return e
+}
+
+// insertBefore inserts the values in order before the mark, or at the back when the mark is nil
+func (l *LinkedList[E]) insertBefore(mark *element[E], values ...E) {
+	for _, value := range values {
+		if mark == nil {
+			l.list.PushBack(value)
+		} else {
+			l.list.InsertBefore(value, mark)
+		}
+	}
+}
+
 // Clear clears the list.
 func (l *LinkedList[E]) Clear() {
 	l.init()
+	l.lock.Lock()
+	defer l.lock.Unlock()
 	l.list.Init()
 }
 
+// EnableNodePool makes the list reuse its nodes through a [sync.Pool],
+// the removed nodes are given back to the pool so high churn workloads allocate less
+func (l *LinkedList[E]) EnableNodePool() {
+	l.init()
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.pool == nil {
+		l.pool = newElementPool[E]()
+		l.list.pool = l.pool
+	}
+}
+
+// Release clears the list and gives its nodes back to the pool, it works as Clear when the pool is not enabled
+func (l *LinkedList[E]) Release() {
+	l.init()
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.list.release()
+}
+
 // Get returns the element on the specific index.
 func (l *LinkedList[E]) Get(index int) E {
 	l.init()
+	l.lock.RLock()
+	defer l.lock.RUnlock()
 	if index < 0 || index >= l.list.Len() {
 		panic(exception.NewRangeException(0, l.list.Len()-1))
 	}
 	for i, e := 0, l.list.Front(); e != nil; i, e = i+1, e.Next() {
 		if i == index {
-			return e.Value.(E)
+			return e.Value
 		}
 	}
 	return *new(E)
@@ -132,6 +248,8 @@ func (l *LinkedList[E]) Get(index int) E {
 // Set sets element on the specific index.
 func (l *LinkedList[E]) Set(index int, value E) {
 	l.init()
+	l.lock.Lock()
+	defer l.lock.Unlock()
 	for i, e := 0, l.list.Front(); e != nil; i, e = i+1, e.Next() {
 		if i == index {
 			e.Value = value
@@ -143,28 +261,34 @@ func (l *LinkedList[E]) Set(index int, value E) {
 // it will return a zero value and false when the list is empty.
 func (l *LinkedList[E]) First() (E, bool) {
 	l.init()
+	l.lock.RLock()
+	defer l.lock.RUnlock()
 	if l.list.Len() == 0 {
 		return *new(E), false
 	}
-	return l.list.Front().Value.(E), true
+	return l.list.Front().Value, true
 }
 
 // FirstOr returns the first element of the list, it will return the default value when the list is empty.
 func (l *LinkedList[E]) FirstOr(value E) E {
 	l.init()
+	l.lock.RLock()
+	defer l.lock.RUnlock()
 	if l.list.Len() == 0 {
 		return value
 	}
-	return l.list.Front().Value.(E)
+	return l.list.Front().Value
 }
 
 // FirstWhere returns the first element of the list which matches the callback.
 // It will return a zero value and false when none matches the callback.
 func (l *LinkedList[E]) FirstWhere(callback func(item E) bool) (E, bool) {
 	l.init()
+	l.lock.RLock()
+	defer l.lock.RUnlock()
 	for e := l.list.Front(); e != nil; e = e.Next() {
-		if callback(e.Value.(E)) {
-			return e.Value.(E), true
+		if callback(e.Value) {
+			return e.Value, true
 		}
 	}
 	return *new(E), false
@@ -174,9 +298,11 @@ func (l *LinkedList[E]) FirstWhere(callback func(item E) bool) (E, bool) {
 // It will return the default value when none matches the callback.
 func (l *LinkedList[E]) FirstWhereOr(callback func(item E) bool, value E) E {
 	l.init()
+	l.lock.RLock()
+	defer l.lock.RUnlock()
 	for e := l.list.Front(); e != nil; e = e.Next() {
-		if callback(e.Value.(E)) {
-			return e.Value.(E)
+		if callback(e.Value) {
+			return e.Value
 		}
 	}
 	return value
@@ -186,29 +312,35 @@ func (l *LinkedList[E]) FirstWhereOr(callback func(item E) bool, value E) E {
 // It will return a zero value and false when the list is empty.
 func (l *LinkedList[E]) Last() (E, bool) {
 	l.init()
+	l.lock.RLock()
+	defer l.lock.RUnlock()
 	if l.list.Len() == 0 {
 		return *new(E), false
 	}
-	return l.list.Back().Value.(E), true
+	return l.list.Back().Value, true
 }
 
 // LastOr returns the last element of the list.
 // It will return the default value when the list is empty.
 func (l *LinkedList[E]) LastOr(value E) E {
 	l.init()
+	l.lock.RLock()
+	defer l.lock.RUnlock()
 	if l.list.Back() == nil {
 		return value
 	}
-	return l.list.Back().Value.(E)
+	return l.list.Back().Value
 }
 
 // LastWhere returns the last element of the list which matches the callback.
 // It will return a zero value and false when none matches the callback.
 func (l *LinkedList[E]) LastWhere(callback func(item E) bool) (E, bool) {
 	l.init()
+	l.lock.RLock()
+	defer l.lock.RUnlock()
 	for e := l.list.Back(); e != nil; e = e.Prev() {
-		if callback(e.Value.(E)) {
-			return e.Value.(E), true
+		if callback(e.Value) {
+			return e.Value, true
 		}
 	}
 	return *new(E), false
@@ -217,7 +349,6 @@ func (l *LinkedList[E]) LastWhere(callback func(item E) bool) (E, bool) {
 // LastWhereOr returns the last element of the list which matches the callback.
 // It will return the default value when none matches the callback.
 func (l *LinkedList[E]) LastWhereOr(callback func(item E) bool, value E) E {
-	l.init()
 	if v, ok := l.LastWhere(callback); ok {
 		return v
 	}
@@ -228,29 +359,31 @@ func (l *LinkedList[E]) LastWhereOr(callback func(item E) bool, value E) E {
 // It will return a zero value and false when the list is empty.
 func (l *LinkedList[E]) Pop() (E, bool) {
 	l.init()
+	l.lock.Lock()
+	defer l.lock.Unlock()
 	if l.list.Len() == 0 {
 		return *new(E), false
 	}
-	item := l.list.Back()
-	l.list.Remove(item)
-	return item.Value.(E), true
+	return l.list.Remove(l.list.Back()), true
 }
 
 // Shift removes the first element of the list and returns it.
 // It will return a zero value and false when the list is empty.
 func (l *LinkedList[E]) Shift() (E, bool) {
 	l.init()
+	l.lock.Lock()
+	defer l.lock.Unlock()
 	if l.list.Len() == 0 {
 		return *new(E), false
 	}
-	item := l.list.Front()
-	l.list.Remove(item)
-	return item.Value.(E), true
+	return l.list.Remove(l.list.Front()), true
 }
 
 // Unshift puts elements to the head of the list.
 func (l *LinkedList[E]) Unshift(values ...E) {
 	l.init()
+	l.lock.Lock()
+	defer l.lock.Unlock()
 	for _, value := range values {
 		l.list.PushFront(value)
 	}
@@ -258,7 +391,6 @@ func (l *LinkedList[E]) Unshift(values ...E) {
 
 // IndexOf returns the index of the specific element.
 func (l *LinkedList[E]) IndexOf(value E) int {
-	l.init()
 	return l.IndexOfWhere(func(item E) bool {
 		return reflect.DeepEqual(item, value)
 	})
@@ -267,8 +399,10 @@ func (l *LinkedList[E]) IndexOf(value E) int {
 // IndexOfWhere returns the index of the first element which matches the callback.
 func (l *LinkedList[E]) IndexOfWhere(callback func(item E) bool) int {
 	l.init()
+	l.lock.RLock()
+	defer l.lock.RUnlock()
 	for i, e := 0, l.list.Front(); e != nil; i, e = i+1, e.Next() {
-		if callback(e.Value.(E)) {
+		if callback(e.Value) {
 			return i
 		}
 	}
@@ -278,12 +412,14 @@ func (l *LinkedList[E]) IndexOfWhere(callback func(item E) bool) int {
 // Sub returns the sub list with given range
 func (l *LinkedList[E]) Sub(from, to int) *LinkedList[E] {
 	l.init()
+	l.lock.RLock()
+	defer l.lock.RUnlock()
 	linked := NewLinkedList[E]()
 	for i, e := 0, l.list.Front(); e != nil; i, e = i+1, e.Next() {
 		if i < from {
 			continue
 		} else if i >= from && i < to {
-			linked.Push(e.Value.(E))
+			linked.Push(e.Value)
 		} else {
 			break
 		}
@@ -294,10 +430,12 @@ func (l *LinkedList[E]) Sub(from, to int) *LinkedList[E] {
 // Where returns the sub list with elements which matches the callback
 func (l *LinkedList[E]) Where(callback func(item E) bool) *LinkedList[E] {
 	l.init()
+	l.lock.RLock()
+	defer l.lock.RUnlock()
 	linked := &LinkedList[E]{}
 	for e := l.list.Front(); e != nil; e = e.Next() {
-		if callback(e.Value.(E)) {
-			linked.Push(e.Value.(E))
+		if callback(e.Value) {
+			linked.Push(e.Value)
 		}
 	}
 	return linked
@@ -306,6 +444,8 @@ func (l *LinkedList[E]) Where(callback func(item E) bool) *LinkedList[E] {
 // Compact makes the list more compact
 func (l *LinkedList[E]) Compact(callback func(a, b E) bool) {
 	l.init()
+	l.lock.Lock()
+	defer l.lock.Unlock()
 	if l.list.Len() < 2 {
 		return
 	}
@@ -314,10 +454,10 @@ func (l *LinkedList[E]) Compact(callback func(a, b E) bool) {
 			return reflect.DeepEqual(a, b)
 		}
 	}
-	var next *listlib.Element
+	var next *element[E]
 	for e := l.list.Front().Next(); e != nil; e = next {
 		next = e.Next()
-		if callback(e.Value.(E), e.Prev().Value.(E)) {
+		if callback(e.Value, e.Prev().Value) {
 			l.list.Remove(e)
 		}
 	}
@@ -325,24 +465,24 @@ func (l *LinkedList[E]) Compact(callback func(a, b E) bool) {
 
 // Min returns the min element
 func (l *LinkedList[E]) Min(callback func(a, b E) int) E {
-	l.init()
 	return slices.MinFunc(l.ToArray(), callback)
 }
 
 // Max returns the max element
 func (l *LinkedList[E]) Max(callback func(a, b E) int) E {
-	l.init()
 	return slices.MaxFunc(l.ToArray(), callback)
 }
 
 // Sort sorts the list
 func (l *LinkedList[E]) Sort(callback func(a, b E) int) {
 	l.init()
-	var newList = listlib.New()
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	var newList = newChain[E](l.pool)
 	for e := l.list.Front(); e != nil; e = e.Next() {
 		node := newList.Front()
 		for node != nil {
-			if callback(e.Value.(E), node.Value.(E)) < 0 {
+			if callback(e.Value, node.Value) < 0 {
 				newList.InsertBefore(e.Value, node)
 				break
 			}
@@ -352,40 +492,50 @@ func (l *LinkedList[E]) Sort(callback func(a, b E) int) {
 			newList.PushBack(e.Value)
 		}
 	}
+	l.list.release()
 	l.list = newList
 }
 
 // Chunk splits list into multiply parts by given size
 func (l *LinkedList[E]) Chunk(size int) *LinkedList[*LinkedList[any]] {
 	l.init()
+	l.lock.RLock()
+	defer l.lock.RUnlock()
 	chunks := NewLinkedList[*LinkedList[any]]()
 	chunk := NewLinkedList[any]()
 	for e := l.list.Front(); e != nil; e = e.Next() {
 		if chunk.list.Len() < size {
-			chunk.Push(e.Value.(E))
+			chunk.Push(e.Value)
 		} else {
 			chunks.Push(chunk)
-			chunk = NewLinkedList(e.Value)
+			chunk = NewLinkedList[any](e.Value)
 		}
 	}
 	chunks.Push(chunk)
 	return chunks
 }
 
-// Each travers the list, if the callback returns false then break
+// Each travers the list, if the callback returns false then break.
+// The callback runs on a snapshot of the list, so it is allowed to modify the list.
 func (l *LinkedList[E]) Each(callback func(index int, value E) bool) {
-	l.init()
-	for e, i := l.list.Front(), 0; e != nil; e, i = e.Next(), i+1 {
-		if !callback(i, e.Value.(E)) {
+	for index, value := range l.ToArray() {
+		if !callback(index, value) {
 			break
 		}
 	}
 }
 
+// All returns an iterator over a snapshot of the elements, so the loop body is allowed to modify the list.
+func (l *LinkedList[E]) All() iter.Seq[E] {
+	return slices.Values(l.ToArray())
+}
+
 // Reverse reverses the list
 func (l *LinkedList[E]) Reverse() {
 	l.init()
-	var next *listlib.Element
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	var next *element[E]
 	for e := l.list.Front(); e != nil; e = next {
 		next = e.Next()
 		l.list.PushFront(e.Value)
@@ -396,68 +546,181 @@ func (l *LinkedList[E]) Reverse() {
 // Clone clones the list
 func (l *LinkedList[E]) Clone() *LinkedList[E] {
 	l.init()
+	l.lock.RLock()
+	defer l.lock.RUnlock()
 	linked := &LinkedList[E]{}
 	for e := l.list.Front(); e != nil; e = e.Next() {
-		linked.Push(e.Value.(E))
+		linked.Push(e.Value)
 	}
 	return linked
 }
 
-// String convert to string
-func (l *LinkedList[E]) String() string {
-	l.init()
-	str := new(strings.Builder)
-	str.WriteString(fmt.Sprintf("LinkedList[%T](len=%d)", *new(E), l.Count()))
-	str.WriteByte('{')
-	str.WriteByte('\n')
-	l.Each(func(index int, value E) bool {
-		str.WriteByte('\t')
-		if v, ok := any(value).(contract.Stringable); ok {
-			str.WriteString(v.String())
-		} else {
-			str.WriteString(fmt.Sprintf("%v", value))
-		}
-		str.WriteByte(',')
-		str.WriteByte('\n')
-		return index < 4
-	})
-	if l.list.Len() > 5 {
-		str.WriteString("\t...\n")
+// DeepClone clones the list and its elements, see [collection.CloneValue]
+func (l *LinkedList[E]) DeepClone() *LinkedList[E] {
+	linked := &LinkedList[E]{}
+	for _, item := range l.ToArray() {
+		linked.Push(collection.CloneValue(item))
 	}
-	str.WriteByte('}')
-	return str.String()
+	return linked
+}
+
+// SetPreviewLimit sets the number of elements shown by String, %v and LogValue, a negative limit shows all elements
+func (l *LinkedList[E]) SetPreviewLimit(limit int) {
+	l.limit.Set(limit)
+}
+
+// String converts to string, only the preview limit of elements are shown
+func (l *LinkedList[E]) String() string {
+	return l.format(l.limit.Get(preview.DefaultLimit))
+}
+
+// Format implements [fmt.Formatter], %v prints the preview as String does, %+v and %#v print all elements
+func (l *LinkedList[E]) Format(f fmt.State, verb rune) {
+	preview.Format(f, verb, l.format, l.limit.Get(preview.DefaultLimit))
+}
+
+func (l *LinkedList[E]) format(limit int) string {
+	items := l.ToArray()
+	return preview.Elements(fmt.Sprintf("LinkedList[%T](len=%d)", *new(E), len(items)), items, limit)
+}
+
+// LogValue implements [slog.LogValuer], only the preview limit of elements are logged
+func (l *LinkedList[E]) LogValue() slog.Value {
+	return preview.LogElements(fmt.Sprintf("LinkedList[%T]", *new(E)), l.ToArray(), l.limit.Get(preview.DefaultLimit))
 }
 
 // ToJSON converts to json
 func (l *LinkedList[E]) ToJSON() ([]byte, error) {
-	l.init()
 	return json.Marshal(l.ToArray())
 }
 
-// ToArray converts to array
+// ToArray converts to array, the returned slice is a copy, so modifying it does not affect the collection
 func (l *LinkedList[E]) ToArray() []E {
 	l.init()
+	l.lock.RLock()
+	defer l.lock.RUnlock()
 	var items []E
 	for e := l.list.Front(); e != nil; e = e.Next() {
-		items = append(items, e.Value.(E))
+		items = append(items, e.Value)
 	}
 	return items
 }
 
 // MarshalJSON implements [json.Marshaller]
 func (l *LinkedList[E]) MarshalJSON() ([]byte, error) {
-	l.init()
 	return l.ToJSON()
 }
 
 // UnmarshalJSON implements [json.Unmarshaller]
 func (l *LinkedList[E]) UnmarshalJSON(data []byte) error {
-	l.init()
 	items := []E{}
 	err := json.Unmarshal(data, &items)
 	if err != nil {
 		return err
 	}
+	l.init()
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	for _, item := range items {
+		l.list.PushBack(item)
+	}
+	return nil
+}
+
+// EncodeJSON writes the list to w as a json array one element at a time, the list is read locked while writing
+func (l *LinkedList[E]) EncodeJSON(w io.Writer) error {
+	l.init()
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	return jsonstream.Encode(w, func(yield func(E) bool) {
+		for e := l.list.Front(); e != nil; e = e.Next() {
+			if !yield(e.Value) {
+				return
+			}
+		}
+	})
+}
+
+// DecodeJSON reads a json array from r one element at a time and appends the elements to the list
+func (l *LinkedList[E]) DecodeJSON(r io.Reader) error {
+	items := newChain[E](nil)
+	if err := jsonstream.Decode(r, func(value E) {
+		items.PushBack(value)
+	}); err != nil {
+		return err
+	}
+	l.init()
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.list.PushBackList(items)
+	return nil
+}
+
+// Value implements [driver.Valuer], the list is stored as a json array
+func (l *LinkedList[E]) Value() (driver.Value, error) {
+	return sqljson.Value(l.ToJSON())
+}
+
+// Scan implements [database/sql.Scanner], it reads a json array and replaces the elements of the list, NULL is read as empty
+func (l *LinkedList[E]) Scan(src any) error {
+	data, err := sqljson.Bytes(src)
+	if err != nil {
+		return err
+	}
+	var items []E
+	if err := json.Unmarshal(data, &items); err != nil {
+		return err
+	}
+	l.init()
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.list.release()
+	for _, item := range items {
+		l.list.PushBack(item)
+	}
+	return nil
+}
+
+// ToCBOR converts to cbor
+func (l *LinkedList[E]) ToCBOR() ([]byte, error) {
+	return cbor.Marshal(l.ToArray())
+}
+
+// MarshalCBOR implements [cbor.Marshaler]
+func (l *LinkedList[E]) MarshalCBOR() ([]byte, error) {
+	return l.ToCBOR()
+}
+
+// UnmarshalCBOR implements [cbor.Unmarshaler]
+func (l *LinkedList[E]) UnmarshalCBOR(data []byte) error {
+	items := []E{}
+	err := cbor.Unmarshal(data, &items)
+	if err != nil {
+		return err
+	}
+	l.init()
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	for _, item := range items {
+		l.list.PushBack(item)
+	}
+	return nil
+}
+
+// MarshalBinary implements [encoding.BinaryMarshaler], the elements are encoded by their own encoders
+func (l *LinkedList[E]) MarshalBinary() ([]byte, error) {
+	return codec.EncodeValues(l.ToArray())
+}
+
+// UnmarshalBinary implements [encoding.BinaryUnmarshaler]
+func (l *LinkedList[E]) UnmarshalBinary(data []byte) error {
+	items, err := codec.DecodeValues[E](data)
+	if err != nil {
+		return err
+	}
+	l.init()
+	l.lock.Lock()
+	defer l.lock.Unlock()
 	for _, item := range items {
 		l.list.PushBack(item)
 	}
@@ -1,12 +1,16 @@
 package list
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"github.com/gopi-frame/exception"
 	"regexp"
+	"strings"
+	"sync"
 	"testing"
 
+	"github.com/fxamacker/cbor/v2"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -32,12 +36,61 @@ func TestLinkedList_RemoveAt(t *testing.T) {
 	assert.False(t, list.Contains(1))
 }
 
+func TestLinkedList_InsertAt(t *testing.T) {
+	list := NewLinkedList(1, 4)
+	list.InsertAt(1, 2, 3)
+	list.InsertAt(4, 5)
+	list.InsertAt(0, 0)
+	assert.Equal(t, []int{0, 1, 2, 3, 4, 5}, list.ToArray())
+	assert.PanicsWithError(t, exception.NewRangeException(0, 6).Error(), func() {
+		list.InsertAt(7, 7)
+	})
+}
+
+func TestLinkedList_Splice(t *testing.T) {
+	list := NewLinkedList(1, 2, 3, 4, 5)
+	assert.Equal(t, []int{2, 3}, list.Splice(1, 2, 6, 7, 8))
+	assert.Equal(t, []int{1, 6, 7, 8, 4, 5}, list.ToArray())
+	assert.Equal(t, []int{4, 5}, list.Splice(4, 10))
+	assert.Empty(t, list.Splice(0, -1, 0))
+	assert.Equal(t, []int{0, 1, 6, 7, 8}, list.ToArray())
+}
+
+func TestLinkedList_Swap(t *testing.T) {
+	list := NewLinkedList(1, 2, 3, 4, 5)
+	list.Swap(0, 4)
+	list.Swap(3, 1)
+	list.Swap(2, 2)
+	assert.Equal(t, []int{5, 4, 3, 2, 1}, list.ToArray())
+	assert.PanicsWithError(t, exception.NewRangeException(0, 4).Error(), func() {
+		list.Swap(0, 5)
+	})
+}
+
 func TestLinkedList_Clear(t *testing.T) {
 	list := NewLinkedList(1, 2, 3)
 	list.Clear()
 	assert.True(t, list.IsEmpty())
 }
 
+func TestLinkedList_Release(t *testing.T) {
+	list := NewLinkedList(1, 2, 3)
+	list.EnableNodePool()
+	list.Release()
+	assert.True(t, list.IsEmpty())
+	list.Push(4, 5)
+	list.Unshift(3)
+	v, ok := list.Shift()
+	assert.True(t, ok)
+	assert.Equal(t, 3, v)
+	v, ok = list.Pop()
+	assert.True(t, ok)
+	assert.Equal(t, 5, v)
+	list.Push(6)
+	list.Sort(func(a, b int) int { return b - a })
+	assert.Equal(t, []int{6, 4}, list.ToArray())
+}
+
 func TestLinkedList_Get(t *testing.T) {
 	list := NewLinkedList(1, 2, 3)
 	assert.Equal(t, 2, list.Get(1))
@@ -267,6 +320,29 @@ func TestLinkedList_Each(t *testing.T) {
 	assert.Equal(t, []int{1, 2, 3}, items)
 }
 
+func TestLinkedList_Each_Modify(t *testing.T) {
+	list := NewLinkedList(1, 2, 3, 4)
+	items := []int{}
+	list.Each(func(index, value int) bool {
+		items = append(items, value)
+		if value%2 == 0 {
+			list.Remove(value)
+		}
+		list.Push(value * 10)
+		return true
+	})
+	assert.Equal(t, []int{1, 2, 3, 4}, items)
+	assert.Equal(t, []int{1, 3, 10, 20, 30, 40}, list.ToArray())
+
+	items = items[:0]
+	for value := range list.All() {
+		list.Clear()
+		items = append(items, value)
+	}
+	assert.Equal(t, []int{1, 3, 10, 20, 30, 40}, items)
+	assert.True(t, list.IsEmpty())
+}
+
 func TestLinkedList_Reverse(t *testing.T) {
 	list := NewLinkedList(1, 2, 3)
 	list.Reverse()
@@ -278,6 +354,15 @@ func TestLinkedList_Clone(t *testing.T) {
 	assert.Equal(t, []int{1, 2, 3}, list.Clone().ToArray())
 }
 
+func TestLinkedList_DeepClone(t *testing.T) {
+	inner := NewLinkedList(1, 2)
+	list := NewLinkedList(inner)
+	clone := list.DeepClone()
+	assert.NotSame(t, inner, clone.Get(0))
+	inner.Push(3)
+	assert.Equal(t, []int{1, 2}, clone.Get(0).ToArray())
+}
+
 func TestLinkedList_String(t *testing.T) {
 	list := NewLinkedList(1, 2, 3, 4, 5, 6)
 	str := list.String()
@@ -305,3 +390,71 @@ func TestLinkedList_UnmarshalJSON(t *testing.T) {
 	assert.Equal(t, []int{1, 2, 3}, list.ToArray())
 	assert.Nil(t, err)
 }
+
+func TestLinkedList_Value(t *testing.T) {
+	value, err := NewLinkedList(1, 2, 3).Value()
+	assert.Nil(t, err)
+	assert.Equal(t, "[1,2,3]", value)
+}
+
+func TestLinkedList_Scan(t *testing.T) {
+	list := NewLinkedList(0)
+	assert.Nil(t, list.Scan("[1,2,3]"))
+	assert.Equal(t, []int{1, 2, 3}, list.ToArray())
+	assert.Nil(t, list.Scan(nil))
+	assert.True(t, list.IsEmpty())
+}
+
+func TestLinkedList_EncodeJSON(t *testing.T) {
+	value := NewLinkedList(1, 2, 3)
+	buf := new(bytes.Buffer)
+	assert.Nil(t, value.EncodeJSON(buf))
+	expected, _ := value.ToJSON()
+	assert.JSONEq(t, string(expected), buf.String())
+}
+
+func TestLinkedList_DecodeJSON(t *testing.T) {
+	value := NewLinkedList(1, 2, 3)
+	assert.Nil(t, value.DecodeJSON(strings.NewReader(`[4, 5]`)))
+	assert.Equal(t, []int{1, 2, 3, 4, 5}, value.ToArray())
+	assert.Error(t, value.DecodeJSON(strings.NewReader(`[6, "a"]`)))
+	assert.Equal(t, []int{1, 2, 3, 4, 5}, value.ToArray())
+}
+
+func TestLinkedList_Concurrent(t *testing.T) {
+	list := NewLinkedList[int]()
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			list.Push(i)
+		}(i)
+		go func() {
+			defer wg.Done()
+			_ = list.String()
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int64(100), list.Count())
+}
+
+func TestLinkedList_MarshalCBOR(t *testing.T) {
+	value := NewLinkedList(1, 2, 3)
+	data, err := cbor.Marshal(value)
+	assert.Nil(t, err)
+	decoded := NewLinkedList[int]()
+	err = cbor.Unmarshal(data, decoded)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1, 2, 3}, decoded.ToArray())
+}
+
+func TestLinkedList_MarshalBinary(t *testing.T) {
+	value := NewLinkedList(1, 2, 3)
+	data, err := value.MarshalBinary()
+	assert.Nil(t, err)
+	decoded := NewLinkedList[int]()
+	err = decoded.UnmarshalBinary(data)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1, 2, 3}, decoded.ToArray())
+}
@@ -1,14 +1,23 @@
 package list
 
 import (
+	"database/sql/driver"
 	"encoding/json"
 	"fmt"
+	"io"
+	"iter"
+	"log/slog"
 	"reflect"
 	"slices"
-	"strings"
 	"sync"
 
-	"github.com/gopi-frame/contract"
+	"github.com/fxamacker/cbor/v2"
+	"github.com/gopi-frame/collection"
+	"github.com/gopi-frame/collection/internal/codec"
+	"github.com/gopi-frame/collection/internal/hash"
+	"github.com/gopi-frame/collection/internal/jsonstream"
+	"github.com/gopi-frame/collection/internal/preview"
+	"github.com/gopi-frame/collection/internal/sqljson"
 )
 
 // NewList new list
@@ -18,14 +27,19 @@ func NewList[E any](values ...E) *List[E] {
 	return instance
 }
 
-// List list
+// List list, all methods are safe for concurrent use.
+// The callbacks run while the list is locked, so they must not call methods of the same list,
+// except the callback of Each which runs on a snapshot.
 type List[E any] struct {
-	sync.RWMutex
+	lock  sync.RWMutex
 	items []E
+	limit preview.Limit
 }
 
 // Count returns the size of the list
 func (list *List[E]) Count() int64 {
+	list.lock.RLock()
+	defer list.lock.RUnlock()
 	return int64(len(list.items))
 }
 
@@ -48,11 +62,15 @@ func (list *List[E]) Contains(value E) bool {
 
 // ContainsWhere returns whether the list contains specific elements by callback.
 func (list *List[E]) ContainsWhere(callback func(value E) bool) bool {
+	list.lock.RLock()
+	defer list.lock.RUnlock()
 	return slices.ContainsFunc(list.items, callback)
 }
 
 // Push pushes elements into the list.
 func (list *List[E]) Push(values ...E) {
+	list.lock.Lock()
+	defer list.lock.Unlock()
 	list.items = append(list.items, values...)
 }
 
@@ -65,32 +83,71 @@ func (list *List[E]) Remove(value E) {
 
 // RemoveWhere removes specific elements by callback.
 func (list *List[E]) RemoveWhere(callback func(item E) bool) {
+	list.lock.Lock()
+	defer list.lock.Unlock()
 	list.items = slices.DeleteFunc(list.items, callback)
 }
 
 // RemoveAt removes the element on the specific index.
 func (list *List[E]) RemoveAt(index int) {
+	list.lock.Lock()
+	defer list.lock.Unlock()
 	list.items = slices.Delete(list.items, index, index+1)
 }
 
+// InsertAt inserts the elements at the specific index, the elements from the index on are moved after them.
+// It panics when the index is out of [0, Count()].
+func (list *List[E]) InsertAt(index int, values ...E) {
+	list.lock.Lock()
+	defer list.lock.Unlock()
+	list.items = slices.Insert(list.items, index, values...)
+}
+
+// Splice removes at most deleteCount elements from the start index, inserts the values there,
+// and returns the removed elements, like the splice method of javascript arrays.
+// It panics when the start index is out of [0, Count()].
+func (list *List[E]) Splice(start, deleteCount int, values ...E) []E {
+	list.lock.Lock()
+	defer list.lock.Unlock()
+	end := start + min(max(deleteCount, 0), len(list.items)-start)
+	removed := slices.Clone(list.items[start:end])
+	list.items = slices.Replace(list.items, start, end, values...)
+	return removed
+}
+
+// Swap swaps the elements on the specific indexes.
+func (list *List[E]) Swap(i, j int) {
+	list.lock.Lock()
+	defer list.lock.Unlock()
+	list.items[i], list.items[j] = list.items[j], list.items[i]
+}
+
 // Clear clears the list.
 func (list *List[E]) Clear() {
+	list.lock.Lock()
+	defer list.lock.Unlock()
 	list.items = []E{}
 }
 
 // Get returns the element on the specific index.
 func (list *List[E]) Get(index int) E {
+	list.lock.RLock()
+	defer list.lock.RUnlock()
 	return list.items[index]
 }
 
 // Set sets element on the specific index.
 func (list *List[E]) Set(index int, value E) {
+	list.lock.Lock()
+	defer list.lock.Unlock()
 	list.items[index] = value
 }
 
 // First returns the first element of the list.
 // it will return a zero value and false when the list is empty.
 func (list *List[E]) First() (E, bool) {
+	list.lock.RLock()
+	defer list.lock.RUnlock()
 	if len(list.items) == 0 {
 		return *new(E), false
 	}
@@ -108,6 +165,8 @@ func (list *List[E]) FirstOr(value E) E {
 // FirstWhere returns the first element of the list which matches the callback.
 // It will return a zero value and false when none matches the callback.
 func (list *List[E]) FirstWhere(callback func(item E) bool) (E, bool) {
+	list.lock.RLock()
+	defer list.lock.RUnlock()
 	for _, item := range list.items {
 		if callback(item) {
 			return item, true
@@ -128,6 +187,8 @@ func (list *List[E]) FirstWhereOr(callback func(item E) bool, value E) E {
 // Last returns the last element of the list.
 // It will return a zero value and false when the list is empty.
 func (list *List[E]) Last() (E, bool) {
+	list.lock.RLock()
+	defer list.lock.RUnlock()
 	length := len(list.items)
 	if length == 0 {
 		return *new(E), false
@@ -147,6 +208,8 @@ func (list *List[E]) LastOr(value E) E {
 // LastWhere returns the last element of the list which matches the callback.
 // It will return a zero value and false when none matches the callback.
 func (list *List[E]) LastWhere(callback func(item E) bool) (E, bool) {
+	list.lock.RLock()
+	defer list.lock.RUnlock()
 	length := len(list.items)
 	for index := range list.items {
 		if value := list.items[length-index-1]; callback(value) {
@@ -168,6 +231,8 @@ func (list *List[E]) LastWhereOr(callback func(item E) bool, value E) E {
 // Pop removes the last element of the list and returns it.
 // It will return a zero value and false when the list is empty.
 func (list *List[E]) Pop() (E, bool) {
+	list.lock.Lock()
+	defer list.lock.Unlock()
 	length := len(list.items)
 	if length == 0 {
 		return *new(E), false
@@ -180,6 +245,8 @@ func (list *List[E]) Pop() (E, bool) {
 // Shift removes the first element of the list and returns it.
 // It will return a zero value and false when the list is empty.
 func (list *List[E]) Shift() (E, bool) {
+	list.lock.Lock()
+	defer list.lock.Unlock()
 	if len(list.items) == 0 {
 		return *new(E), false
 	}
@@ -190,6 +257,8 @@ func (list *List[E]) Shift() (E, bool) {
 
 // Unshift puts elements to the head of the list.
 func (list *List[E]) Unshift(values ...E) {
+	list.lock.Lock()
+	defer list.lock.Unlock()
 	list.items = slices.Insert(list.items, 0, values...)
 }
 
@@ -202,16 +271,22 @@ func (list *List[E]) IndexOf(value E) int {
 
 // IndexOfWhere returns the index of the first element which matches the callback.
 func (list *List[E]) IndexOfWhere(callback func(item E) bool) int {
+	list.lock.RLock()
+	defer list.lock.RUnlock()
 	return slices.IndexFunc(list.items, callback)
 }
 
 // Sub returns the sub list with given range
 func (list *List[E]) Sub(from, to int) *List[E] {
-	return &List[E]{items: list.items[from:to]}
+	list.lock.RLock()
+	defer list.lock.RUnlock()
+	return &List[E]{items: slices.Clone(list.items[from:to])}
 }
 
 // Where returns the sub list with elements which matches the callback
 func (list *List[E]) Where(callback func(item E) bool) *List[E] {
+	list.lock.RLock()
+	defer list.lock.RUnlock()
 	l := &List[E]{}
 	for _, item := range list.items {
 		if callback(item) {
@@ -228,26 +303,36 @@ func (list *List[E]) Compact(callback func(a, b E) bool) {
 			return reflect.DeepEqual(a, b)
 		}
 	}
+	list.lock.Lock()
+	defer list.lock.Unlock()
 	list.items = slices.CompactFunc(list.items, callback)
 }
 
 // Min returns the min element
 func (list *List[E]) Min(callback func(a, b E) int) E {
+	list.lock.RLock()
+	defer list.lock.RUnlock()
 	return slices.MinFunc(list.items, callback)
 }
 
 // Max returns the max element
 func (list *List[E]) Max(callback func(a, b E) int) E {
+	list.lock.RLock()
+	defer list.lock.RUnlock()
 	return slices.MaxFunc(list.items, callback)
 }
 
 // Sort sorts the list
 func (list *List[E]) Sort(callback func(a, b E) int) {
+	list.lock.Lock()
+	defer list.lock.Unlock()
 	slices.SortFunc(list.items, callback)
 }
 
 // Chunk splits list into multiply parts by given size
 func (list *List[E]) Chunk(size int) *List[*List[any]] {
+	list.lock.RLock()
+	defer list.lock.RUnlock()
 	chunks := NewList[*List[any]]()
 	chunk := NewList[any]()
 	for _, item := range list.items {
@@ -262,60 +347,102 @@ func (list *List[E]) Chunk(size int) *List[*List[any]] {
 	return chunks
 }
 
-// Each travers the list, if the callback returns false then break
+// Each travers the list, if the callback returns false then break.
+// The callback runs on a snapshot of the list, so it is allowed to modify the list.
 func (list *List[E]) Each(callback func(index int, value E) bool) {
-	for index, value := range list.items {
+	for index, value := range list.ToArray() {
 		if !callback(index, value) {
 			break
 		}
 	}
 }
 
+// All returns an iterator over a snapshot of the elements, so the loop body is allowed to modify the list.
+func (list *List[E]) All() iter.Seq[E] {
+	return slices.Values(list.ToArray())
+}
+
 // Reverse reverses the list
 func (list *List[E]) Reverse() {
+	list.lock.Lock()
+	defer list.lock.Unlock()
 	slices.Reverse(list.items)
 }
 
 // Clone clones the list
 func (list *List[E]) Clone() *List[E] {
-	list.items = slices.Clone(list.items)
-	return list
+	return &List[E]{items: list.ToArray()}
 }
 
-// String convert to string
-func (list *List[E]) String() string {
-	str := new(strings.Builder)
-	str.WriteString(fmt.Sprintf("List[%T](len=%d)", *new(E), list.Count()))
-	str.WriteByte('{')
-	str.WriteByte('\n')
-	for index, value := range list.items {
-		str.WriteByte('\t')
-		if v, ok := any(value).(contract.Stringable); ok {
-			str.WriteString(v.String())
-		} else {
-			str.WriteString(fmt.Sprintf("%v", value))
-		}
-		str.WriteByte(',')
-		str.WriteByte('\n')
-		if index >= 4 {
-			break
-		}
+// DeepClone clones the list and its elements, see [collection.CloneValue]
+func (list *List[E]) DeepClone() *List[E] {
+	items := list.ToArray()
+	for index, item := range items {
+		items[index] = collection.CloneValue(item)
+	}
+	return &List[E]{items: items}
+}
+
+// Equal returns whether the lists have equal elements in the same order,
+// the elements are compared with their Equal method or [reflect.DeepEqual]
+func (list *List[E]) Equal(other *List[E]) bool {
+	if list == other {
+		return true
+	}
+	if other == nil {
+		return false
 	}
-	if list.Count() > 5 {
-		str.WriteString("\t...\n")
+	return slices.EqualFunc(list.ToArray(), other.ToArray(), hash.Equal[E])
+}
+
+// Hash returns the hash of the elements in order, lists which are equal have the same hash.
+// Elements with an Equal method must have a Hash method consistent with it.
+func (list *List[E]) Hash() uint64 {
+	items := list.ToArray()
+	hashes := make([]uint64, len(items))
+	for index, item := range items {
+		hashes[index] = hash.Deep(item)
 	}
-	str.WriteByte('}')
-	return str.String()
+	return hash.Ordered(hashes...)
+}
+
+// SetPreviewLimit sets the number of elements shown by String, %v and LogValue, a negative limit shows all elements
+func (list *List[E]) SetPreviewLimit(limit int) {
+	list.limit.Set(limit)
+}
+
+// String converts to string, only the preview limit of elements are shown
+func (list *List[E]) String() string {
+	return list.format(list.limit.Get(preview.DefaultLimit))
+}
+
+// Format implements [fmt.Formatter], %v prints the preview as String does, %+v and %#v print all elements
+func (list *List[E]) Format(f fmt.State, verb rune) {
+	preview.Format(f, verb, list.format, list.limit.Get(preview.DefaultLimit))
+}
+
+func (list *List[E]) format(limit int) string {
+	items := list.ToArray()
+	return preview.Elements(fmt.Sprintf("List[%T](len=%d)", *new(E), len(items)), items, limit)
+}
+
+// LogValue implements [slog.LogValuer], only the preview limit of elements are logged
+func (list *List[E]) LogValue() slog.Value {
+	return preview.LogElements(fmt.Sprintf("List[%T]", *new(E)), list.ToArray(), list.limit.Get(preview.DefaultLimit))
 }
 
 // ToJSON converts to json
 func (list *List[E]) ToJSON() ([]byte, error) {
+	list.lock.RLock()
+	defer list.lock.RUnlock()
 	return json.Marshal(list.items)
 }
 
-// ToArray converts to array
+// ToArray converts to array, the returned slice is a copy, so modifying it does not affect the collection
 func (list *List[E]) ToArray() []E {
-	return list.items
+	list.lock.RLock()
+	defer list.lock.RUnlock()
+	return slices.Clone(list.items)
 }
 
 // MarshalJSON implements [json.Marshaller]
@@ -330,6 +457,85 @@ func (list *List[E]) UnmarshalJSON(data []byte) error {
 	if err != nil {
 		return err
 	}
+	list.lock.Lock()
+	defer list.lock.Unlock()
+	list.items = items
+	return nil
+}
+
+// EncodeJSON writes the list to w as a json array one element at a time, the list is read locked while writing
+func (list *List[E]) EncodeJSON(w io.Writer) error {
+	list.lock.RLock()
+	defer list.lock.RUnlock()
+	return jsonstream.Encode(w, slices.Values(list.items))
+}
+
+// DecodeJSON reads a json array from r one element at a time and replaces the elements of the list
+func (list *List[E]) DecodeJSON(r io.Reader) error {
+	var items []E
+	if err := jsonstream.Decode(r, func(value E) {
+		items = append(items, value)
+	}); err != nil {
+		return err
+	}
+	list.lock.Lock()
+	defer list.lock.Unlock()
+	list.items = items
+	return nil
+}
+
+// Value implements [driver.Valuer], the list is stored as a json array
+func (list *List[E]) Value() (driver.Value, error) {
+	return sqljson.Value(list.ToJSON())
+}
+
+// Scan implements [database/sql.Scanner], it reads a json array and replaces the elements of the list, NULL is read as empty
+func (list *List[E]) Scan(src any) error {
+	data, err := sqljson.Bytes(src)
+	if err != nil {
+		return err
+	}
+	return list.UnmarshalJSON(data)
+}
+
+// ToCBOR converts to cbor
+func (list *List[E]) ToCBOR() ([]byte, error) {
+	list.lock.RLock()
+	defer list.lock.RUnlock()
+	return cbor.Marshal(list.items)
+}
+
+// MarshalCBOR implements [cbor.Marshaler]
+func (list *List[E]) MarshalCBOR() ([]byte, error) {
+	return list.ToCBOR()
+}
+
+// UnmarshalCBOR implements [cbor.Unmarshaler]
+func (list *List[E]) UnmarshalCBOR(data []byte) error {
+	var items []E
+	err := cbor.Unmarshal(data, &items)
+	if err != nil {
+		return err
+	}
+	list.lock.Lock()
+	defer list.lock.Unlock()
+	list.items = items
+	return nil
+}
+
+// MarshalBinary implements [encoding.BinaryMarshaler], the elements are encoded by their own encoders
+func (list *List[E]) MarshalBinary() ([]byte, error) {
+	return codec.EncodeValues(list.ToArray())
+}
+
+// UnmarshalBinary implements [encoding.BinaryUnmarshaler]
+func (list *List[E]) UnmarshalBinary(data []byte) error {
+	items, err := codec.DecodeValues[E](data)
+	if err != nil {
+		return err
+	}
+	list.lock.Lock()
+	defer list.lock.Unlock()
 	list.items = items
 	return nil
 }
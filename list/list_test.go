@@ -1,11 +1,16 @@
 package list
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"regexp"
+	"strings"
+	"sync"
 	"testing"
 
+	"github.com/fxamacker/cbor/v2"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -31,6 +36,33 @@ func TestList_RemoveAt(t *testing.T) {
 	assert.False(t, list.Contains(1))
 }
 
+func TestList_InsertAt(t *testing.T) {
+	list := NewList(1, 4)
+	list.InsertAt(1, 2, 3)
+	list.InsertAt(4, 5)
+	list.InsertAt(0, 0)
+	assert.Equal(t, []int{0, 1, 2, 3, 4, 5}, list.ToArray())
+	assert.Panics(t, func() {
+		list.InsertAt(7, 7)
+	})
+}
+
+func TestList_Splice(t *testing.T) {
+	list := NewList(1, 2, 3, 4, 5)
+	assert.Equal(t, []int{2, 3}, list.Splice(1, 2, 6, 7, 8))
+	assert.Equal(t, []int{1, 6, 7, 8, 4, 5}, list.ToArray())
+	assert.Equal(t, []int{4, 5}, list.Splice(4, 10))
+	assert.Equal(t, []int{}, list.Splice(0, -1, 0))
+	assert.Equal(t, []int{0, 1, 6, 7, 8}, list.ToArray())
+}
+
+func TestList_Swap(t *testing.T) {
+	list := NewList(1, 2, 3)
+	list.Swap(0, 2)
+	list.Swap(1, 1)
+	assert.Equal(t, []int{3, 2, 1}, list.ToArray())
+}
+
 func TestList_Clear(t *testing.T) {
 	list := NewList(1, 2, 3)
 	list.Clear()
@@ -246,6 +278,29 @@ func TestList_Each(t *testing.T) {
 	assert.Equal(t, []int{1, 2, 3}, items)
 }
 
+func TestList_Each_Modify(t *testing.T) {
+	list := NewList(1, 2, 3, 4)
+	items := []int{}
+	list.Each(func(index, value int) bool {
+		items = append(items, value)
+		if value%2 == 0 {
+			list.Remove(value)
+		}
+		list.Push(value * 10)
+		return true
+	})
+	assert.Equal(t, []int{1, 2, 3, 4}, items)
+	assert.Equal(t, []int{1, 3, 10, 20, 30, 40}, list.ToArray())
+
+	items = items[:0]
+	for value := range list.All() {
+		list.Clear()
+		items = append(items, value)
+	}
+	assert.Equal(t, []int{1, 3, 10, 20, 30, 40}, items)
+	assert.True(t, list.IsEmpty())
+}
+
 func TestList_Reverse(t *testing.T) {
 	list := NewList(1, 2, 3)
 	list.Reverse()
@@ -254,7 +309,35 @@ func TestList_Reverse(t *testing.T) {
 
 func TestList_Clone(t *testing.T) {
 	list := NewList(1, 2, 3)
-	assert.Equal(t, []int{1, 2, 3}, list.Clone().ToArray())
+	clone := list.Clone()
+	assert.Equal(t, []int{1, 2, 3}, clone.ToArray())
+	clone.Push(4)
+	assert.Equal(t, []int{1, 2, 3}, list.ToArray())
+}
+
+func TestList_DeepClone(t *testing.T) {
+	inner := NewList(1, 2)
+	list := NewList(inner)
+	clone := list.DeepClone()
+	assert.NotSame(t, inner, clone.Get(0))
+	inner.Push(3)
+	assert.Equal(t, []int{1, 2}, clone.Get(0).ToArray())
+}
+
+func TestList_Equal(t *testing.T) {
+	list := NewList(1, 2, 3)
+	assert.True(t, list.Equal(list))
+	assert.True(t, list.Equal(NewList(1, 2, 3)))
+	assert.False(t, list.Equal(NewList(3, 2, 1)))
+	assert.False(t, list.Equal(NewList(1, 2)))
+	assert.False(t, list.Equal(nil))
+	assert.True(t, NewList(NewList(1)).Equal(NewList(NewList(1))))
+}
+
+func TestList_Hash(t *testing.T) {
+	assert.Equal(t, NewList(1, 2, 3).Hash(), NewList(1, 2, 3).Hash())
+	assert.NotEqual(t, NewList(1, 2, 3).Hash(), NewList(3, 2, 1).Hash())
+	assert.Equal(t, NewList(NewList(1)).Hash(), NewList(NewList(1)).Hash())
 }
 
 func TestList_String(t *testing.T) {
@@ -284,3 +367,108 @@ func TestList_UnmarshalJSON(t *testing.T) {
 	assert.Equal(t, []int{1, 2, 3}, list.ToArray())
 	assert.Nil(t, err)
 }
+
+func TestList_Value(t *testing.T) {
+	value, err := NewList(1, 2, 3).Value()
+	assert.Nil(t, err)
+	assert.Equal(t, "[1,2,3]", value)
+}
+
+func TestList_Scan(t *testing.T) {
+	list := NewList(0)
+	assert.Nil(t, list.Scan([]byte("[1,2,3]")))
+	assert.Equal(t, []int{1, 2, 3}, list.ToArray())
+	assert.Nil(t, list.Scan(nil))
+	assert.True(t, list.IsEmpty())
+	assert.EqualError(t, list.Scan(1), "sqljson: cannot scan int into a collection")
+}
+
+func TestList_EncodeJSON(t *testing.T) {
+	value := NewList(1, 2, 3)
+	buf := new(bytes.Buffer)
+	assert.Nil(t, value.EncodeJSON(buf))
+	expected, _ := value.ToJSON()
+	assert.JSONEq(t, string(expected), buf.String())
+}
+
+func TestList_DecodeJSON(t *testing.T) {
+	value := NewList(1, 2, 3)
+	assert.Nil(t, value.DecodeJSON(strings.NewReader(`[4, 5]`)))
+	assert.Equal(t, []int{4, 5}, value.ToArray())
+	assert.Error(t, value.DecodeJSON(strings.NewReader(`[6, "a"]`)))
+	assert.Equal(t, []int{4, 5}, value.ToArray())
+}
+
+func TestList_Concurrent(t *testing.T) {
+	list := NewList[int]()
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			list.Push(i)
+		}(i)
+		go func() {
+			defer wg.Done()
+			_ = list.String()
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int64(100), list.Count())
+}
+
+func TestList_MarshalCBOR(t *testing.T) {
+	value := NewList(1, 2, 3)
+	data, err := cbor.Marshal(value)
+	assert.Nil(t, err)
+	decoded := NewList[int]()
+	err = cbor.Unmarshal(data, decoded)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1, 2, 3}, decoded.ToArray())
+}
+
+func TestList_MarshalBinary(t *testing.T) {
+	value := NewList(1, 2, 3)
+	data, err := value.MarshalBinary()
+	assert.Nil(t, err)
+	decoded := NewList[int]()
+	err = decoded.UnmarshalBinary(data)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1, 2, 3}, decoded.ToArray())
+}
+
+func TestList_Format(t *testing.T) {
+	list := NewList(1, 2, 3, 4, 5, 6, 7)
+	assert.Equal(t, list.String(), fmt.Sprintf("%v", list))
+	pattern := regexp.MustCompile(`List\[int\]\(len=7\)\{\n(\t\d+,\n){7}\}`)
+	assert.True(t, pattern.MatchString(fmt.Sprintf("%+v", list)))
+	assert.True(t, pattern.MatchString(fmt.Sprintf("%#v", list)))
+}
+
+func TestList_SetPreviewLimit(t *testing.T) {
+	list := NewList(1, 2, 3, 4, 5, 6, 7)
+	list.SetPreviewLimit(2)
+	assert.Equal(t, "List[int](len=7){\n\t1,\n\t2,\n\t...\n}", list.String())
+	list.SetPreviewLimit(-1)
+	assert.Equal(t, fmt.Sprintf("%+v", list), list.String())
+}
+
+func TestList_LogValue(t *testing.T) {
+	list := NewList(1, 2, 3, 4, 5, 6, 7)
+	buf := new(bytes.Buffer)
+	logger := slog.New(slog.NewJSONHandler(buf, nil))
+	logger.Info("list", "list", list)
+	var record struct {
+		List struct {
+			Type      string `json:"type"`
+			Len       int    `json:"len"`
+			Elements  []int  `json:"elements"`
+			Truncated bool   `json:"truncated"`
+		} `json:"list"`
+	}
+	assert.Nil(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, "List[int]", record.List.Type)
+	assert.Equal(t, 7, record.List.Len)
+	assert.Equal(t, []int{1, 2, 3, 4, 5}, record.List.Elements)
+	assert.True(t, record.List.Truncated)
+}
@@ -0,0 +1,293 @@
+package list
+
+import (
+	"encoding/json"
+	"fmt"
+	"iter"
+	"log/slog"
+	"slices"
+
+	"github.com/gopi-frame/collection/internal/preview"
+	"github.com/gopi-frame/exception"
+)
+
+const (
+	vectorBits  = 5
+	vectorWidth = 1 << vectorBits
+	vectorMask  = vectorWidth - 1
+)
+
+// NewPersistentVector new persistent vector
+func NewPersistentVector[E any](values ...E) *PersistentVector[E] {
+	return new(PersistentVector[E]).Push(values...)
+}
+
+// PersistentVector immutable vector, a 32-way trie with a tail as the vector of Clojure, it is safe for concurrent use.
+// Set, Push and Pop return a new version in O(log32 n) time, which shares all but the changed path with the previous one,
+// so snapshots are kept for free instead of copying a whole List, and the previous versions stay unchanged.
+type PersistentVector[E any] struct {
+	size  int
+	shift uint
+	root  *vectorNode[E]
+	tail  []E
+	limit preview.Limit
+}
+
+// vectorNode node of the trie, the leaves hold the values and the other nodes hold the children, it is immutable once built
+type vectorNode[E any] struct {
+	children []*vectorNode[E]
+	values   []E
+}
+
+// tailOffset returns the index of the first element of the tail
+func (v *PersistentVector[E]) tailOffset() int {
+	if v.size < vectorWidth {
+		return 0
+	}
+	return (v.size - 1) >> vectorBits << vectorBits
+}
+
+// leaf returns the values of the leaf or the tail which holds the index
+func (v *PersistentVector[E]) leaf(index int) []E {
+	if index >= v.tailOffset() {
+		return v.tail
+	}
+	node := v.root
+	for level := v.shift; level > 0; level -= vectorBits {
+		node = node.children[(index>>level)&vectorMask]
+	}
+	return node.values
+}
+
+// check panics when the index is out of range
+func (v *PersistentVector[E]) check(index int) {
+	if index < 0 || index >= v.size {
+		panic(exception.NewRangeException(0, v.size-1))
+	}
+}
+
+// Count returns the size of the vector
+func (v *PersistentVector[E]) Count() int64 {
+	return int64(v.size)
+}
+
+// IsEmpty returns whether the vector is empty
+func (v *PersistentVector[E]) IsEmpty() bool {
+	return v.size == 0
+}
+
+// IsNotEmpty returns whether the vector is not empty
+func (v *PersistentVector[E]) IsNotEmpty() bool {
+	return !v.IsEmpty()
+}
+
+// Get returns the element on the index, it panics when the index is out of range
+func (v *PersistentVector[E]) Get(index int) E {
+	v.check(index)
+	return v.leaf(index)[index&vectorMask]
+}
+
+// Set returns a new version with the element on the index replaced, the count appends the element.
+// It panics when the index is out of range.
+func (v *PersistentVector[E]) Set(index int, value E) *PersistentVector[E] {
+	if index == v.size {
+		return v.Push(value)
+	}
+	v.check(index)
+	vector := &PersistentVector[E]{size: v.size, shift: v.shift, root: v.root, tail: v.tail}
+	if index >= v.tailOffset() {
+		vector.tail = slices.Clone(v.tail)
+		vector.tail[index&vectorMask] = value
+		return vector
+	}
+	vector.root = assocVector(v.root, v.shift, index, value)
+	return vector
+}
+
+// assocVector returns a copy of the path to the index with the value replaced
+func assocVector[E any](node *vectorNode[E], level uint, index int, value E) *vectorNode[E] {
+	if level == 0 {
+		values := slices.Clone(node.values)
+		values[index&vectorMask] = value
+		return &vectorNode[E]{values: values}
+	}
+	children := slices.Clone(node.children)
+	slot := (index >> level) & vectorMask
+	children[slot] = assocVector(children[slot], level-vectorBits, index, value)
+	return &vectorNode[E]{children: children}
+}
+
+// Push returns a new version with the elements appended
+func (v *PersistentVector[E]) Push(values ...E) *PersistentVector[E] {
+	vector := &PersistentVector[E]{size: v.size, shift: v.shift, root: v.root}
+	if vector.root == nil {
+		vector.shift = vectorBits
+		vector.root = new(vectorNode[E])
+	}
+	tail := make([]E, len(v.tail), vectorWidth)
+	copy(tail, v.tail)
+	for _, value := range values {
+		if len(tail) == vectorWidth {
+			vector.pushLeaf(&vectorNode[E]{values: tail})
+			tail = make([]E, 0, vectorWidth)
+		}
+		tail = append(tail, value)
+		vector.size++
+	}
+	vector.tail = tail
+	return vector
+}
+
+// pushLeaf adds the full tail as the last leaf of the trie, the size still counts the tail
+func (v *PersistentVector[E]) pushLeaf(leaf *vectorNode[E]) {
+	if v.size>>vectorBits > 1<<v.shift {
+		v.root = &vectorNode[E]{children: []*vectorNode[E]{v.root, newVectorPath(v.shift, leaf)}}
+		v.shift += vectorBits
+		return
+	}
+	v.root = pushVectorLeaf(v.root, v.shift, v.size-1, leaf)
+}
+
+// pushVectorLeaf returns a copy of the path to the last index with the leaf added
+func pushVectorLeaf[E any](node *vectorNode[E], level uint, last int, leaf *vectorNode[E]) *vectorNode[E] {
+	children := slices.Clone(node.children)
+	slot := (last >> level) & vectorMask
+	child := leaf
+	if level > vectorBits {
+		if slot < len(children) {
+			child = pushVectorLeaf(children[slot], level-vectorBits, last, leaf)
+		} else {
+			child = newVectorPath(level-vectorBits, leaf)
+		}
+	}
+	if slot < len(children) {
+		children[slot] = child
+	} else {
+		children = append(children, child)
+	}
+	return &vectorNode[E]{children: children}
+}
+
+// newVectorPath returns a path of single children from the level down to the leaf
+func newVectorPath[E any](level uint, leaf *vectorNode[E]) *vectorNode[E] {
+	if level == 0 {
+		return leaf
+	}
+	return &vectorNode[E]{children: []*vectorNode[E]{newVectorPath(level-vectorBits, leaf)}}
+}
+
+// Pop returns a new version without the last element and the last element,
+// it returns the vector itself, zero value and false when the vector is empty
+func (v *PersistentVector[E]) Pop() (*PersistentVector[E], E, bool) {
+	if v.size == 0 {
+		return v, *new(E), false
+	}
+	last := v.Get(v.size - 1)
+	if v.size == 1 {
+		return new(PersistentVector[E]), last, true
+	}
+	vector := &PersistentVector[E]{size: v.size - 1, shift: v.shift, root: v.root}
+	if len(v.tail) > 1 {
+		vector.tail = v.tail[:len(v.tail)-1]
+		return vector, last, true
+	}
+	vector.tail = v.leaf(v.size - 2)
+	vector.root = popVectorLeaf(v.root, v.shift, v.size-2)
+	if vector.root == nil {
+		vector.root = new(vectorNode[E])
+	} else if vector.shift > vectorBits && len(vector.root.children) == 1 {
+		vector.root = vector.root.children[0]
+		vector.shift -= vectorBits
+	}
+	return vector, last, true
+}
+
+// popVectorLeaf returns a copy of the path to the index without the leaf which holds it, or nil when nothing is left
+func popVectorLeaf[E any](node *vectorNode[E], level uint, index int) *vectorNode[E] {
+	slot := (index >> level) & vectorMask
+	if level > vectorBits {
+		child := popVectorLeaf(node.children[slot], level-vectorBits, index)
+		if child == nil && slot == 0 {
+			return nil
+		}
+		children := slices.Clone(node.children[:slot+1])
+		if child == nil {
+			children = children[:slot]
+		} else {
+			children[slot] = child
+		}
+		return &vectorNode[E]{children: children}
+	}
+	if slot == 0 {
+		return nil
+	}
+	return &vectorNode[E]{children: slices.Clone(node.children[:slot])}
+}
+
+// All returns an iterator over the elements
+func (v *PersistentVector[E]) All() iter.Seq[E] {
+	return func(yield func(E) bool) {
+		for index := 0; index < v.size; index += vectorWidth {
+			for _, value := range v.leaf(index) {
+				if !yield(value) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// ToArray converts to array, the returned slice is a copy, so modifying it does not affect the collection
+func (v *PersistentVector[E]) ToArray() []E {
+	values := make([]E, 0, v.size)
+	for index := 0; index < v.size; index += vectorWidth {
+		values = append(values, v.leaf(index)...)
+	}
+	return values
+}
+
+// ToJSON converts to json
+func (v *PersistentVector[E]) ToJSON() ([]byte, error) {
+	return json.Marshal(v.ToArray())
+}
+
+// MarshalJSON implements [json.Marshaler]
+func (v *PersistentVector[E]) MarshalJSON() ([]byte, error) {
+	return v.ToJSON()
+}
+
+// UnmarshalJSON implements [json.Unmarshaler], it decodes into the vector itself, so it must not be shared yet
+func (v *PersistentVector[E]) UnmarshalJSON(data []byte) error {
+	values := make([]E, 0)
+	if err := json.Unmarshal(data, &values); err != nil {
+		return err
+	}
+	vector := NewPersistentVector(values...)
+	v.size, v.shift, v.root, v.tail = vector.size, vector.shift, vector.root, vector.tail
+	return nil
+}
+
+// SetPreviewLimit sets the number of elements shown by String, %v and LogValue, a negative limit shows all elements
+func (v *PersistentVector[E]) SetPreviewLimit(limit int) {
+	v.limit.Set(limit)
+}
+
+// String converts to string, only the preview limit of elements are shown
+func (v *PersistentVector[E]) String() string {
+	return v.format(v.limit.Get(preview.DefaultLimit))
+}
+
+// Format implements [fmt.Formatter], %v prints the preview as String does, %+v and %#v print all elements
+func (v *PersistentVector[E]) Format(f fmt.State, verb rune) {
+	preview.Format(f, verb, v.format, v.limit.Get(preview.DefaultLimit))
+}
+
+func (v *PersistentVector[E]) format(limit int) string {
+	items := v.ToArray()
+	return preview.Elements(fmt.Sprintf("PersistentVector[%T](len=%d)", *new(E), len(items)), items, limit)
+}
+
+// LogValue implements [slog.LogValuer], only the preview limit of elements are logged
+func (v *PersistentVector[E]) LogValue() slog.Value {
+	return preview.LogElements(fmt.Sprintf("PersistentVector[%T]", *new(E)), v.ToArray(), v.limit.Get(preview.DefaultLimit))
+}
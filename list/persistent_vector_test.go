@@ -0,0 +1,120 @@
+package list
+
+import (
+	"encoding/json"
+	"math/rand/v2"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPersistentVector_Push(t *testing.T) {
+	empty := NewPersistentVector[int]()
+	assert.True(t, empty.IsEmpty())
+	values := make([]int, 5000)
+	for i := range values {
+		values[i] = i
+	}
+	v := empty.Push(values...)
+	assert.EqualValues(t, 5000, v.Count())
+	assert.Equal(t, values, v.ToArray())
+	assert.Equal(t, values, slices.Collect(v.All()))
+	assert.Equal(t, 4321, v.Get(4321))
+	assert.True(t, empty.IsEmpty())
+	assert.Panics(t, func() {
+		v.Get(5000)
+	})
+
+	one := empty
+	for i := range 1100 {
+		one = one.Push(i)
+	}
+	assert.Equal(t, values[:1100], one.ToArray())
+}
+
+func TestPersistentVector_Set(t *testing.T) {
+	v := NewPersistentVector(slices.Repeat([]int{0}, 100)...)
+	updated := v.Set(3, 3).Set(99, 99).Set(100, 100)
+	assert.Equal(t, 3, updated.Get(3))
+	assert.Equal(t, 99, updated.Get(99))
+	assert.Equal(t, 100, updated.Get(100))
+	assert.Equal(t, 0, v.Get(3))
+	assert.EqualValues(t, 100, v.Count())
+	assert.Panics(t, func() {
+		v.Set(-1, 0)
+	})
+}
+
+func TestPersistentVector_Pop(t *testing.T) {
+	_, _, ok := NewPersistentVector[int]().Pop()
+	assert.False(t, ok)
+	v := NewPersistentVector[int]()
+	for i := range 1100 {
+		v = v.Push(i)
+	}
+	popped := v
+	for i := 1099; i >= 0; i-- {
+		var value int
+		popped, value, ok = popped.Pop()
+		assert.True(t, ok)
+		assert.Equal(t, i, value)
+		assert.EqualValues(t, i, popped.Count())
+	}
+	assert.True(t, popped.IsEmpty())
+	assert.EqualValues(t, 1100, v.Count())
+	assert.Equal(t, 1099, v.Get(1099))
+}
+
+func TestPersistentVector_Versions(t *testing.T) {
+	v := NewPersistentVector[int]()
+	var expected []int
+	versions := []*PersistentVector[int]{v}
+	snapshots := [][]int{nil}
+	for i := range 3000 {
+		switch rand.IntN(4) {
+		case 0, 1:
+			v = v.Push(i)
+			expected = append(expected, i)
+		case 2:
+			if len(expected) > 0 {
+				index := rand.IntN(len(expected))
+				v = v.Set(index, -i)
+				expected[index] = -i
+			}
+		case 3:
+			var ok bool
+			v, _, ok = v.Pop()
+			if ok {
+				expected = expected[:len(expected)-1]
+			}
+		}
+		if i%100 == 0 {
+			versions = append(versions, v)
+			snapshots = append(snapshots, slices.Clone(expected))
+		}
+	}
+	assert.Equal(t, expected, v.ToArray())
+	for index, version := range versions {
+		assert.Equal(t, len(snapshots[index]), int(version.Count()))
+		if len(snapshots[index]) > 0 {
+			assert.Equal(t, snapshots[index], version.ToArray())
+		}
+	}
+}
+
+func TestPersistentVector_JSON(t *testing.T) {
+	v := NewPersistentVector(1, 2, 3)
+	data, err := json.Marshal(v)
+	assert.Nil(t, err)
+	assert.Equal(t, `[1,2,3]`, string(data))
+	var decoded PersistentVector[int]
+	assert.Nil(t, json.Unmarshal([]byte(`[4,5]`), &decoded))
+	assert.Equal(t, []int{4, 5, 6}, decoded.Push(6).ToArray())
+	assert.Equal(t, []int{4, 5}, decoded.ToArray())
+}
+
+func TestPersistentVector_String(t *testing.T) {
+	v := NewPersistentVector(1, 2, 3)
+	assert.Equal(t, "PersistentVector[int](len=3){\n\t1,\n\t2,\n\t3,\n}", v.String())
+}
@@ -0,0 +1,328 @@
+package list
+
+import (
+	"encoding/json"
+	"fmt"
+	"iter"
+	"log/slog"
+	"math/rand/v2"
+	"slices"
+	"sync"
+
+	"github.com/gopi-frame/collection/internal/preview"
+	"github.com/gopi-frame/exception"
+)
+
+// ropeChunk is the most elements a leaf chunk of a rope takes when elements are appended to it
+const ropeChunk = 512
+
+// NewRope new rope
+func NewRope[E any](values ...E) *Rope[E] {
+	return &Rope[E]{root: buildRope(values)}
+}
+
+// Rope sequence for very large texts or byte streams, such as Rope[rune] or Rope[byte], all methods are safe for concurrent use.
+// The elements are kept in chunks in a balanced tree, so Insert, Delete, Sub and Concat take O(log n) time
+// whatever the size and the position, where List copies the elements behind the position.
+// The tree is never modified in place, every change rebuilds the path to the changed chunks only,
+// so Sub, Concat and Clone share the chunks with the original rope instead of copying them,
+// and All walks the rope as it was when the iteration started without holding the lock.
+type Rope[E any] struct {
+	lock  sync.RWMutex
+	root  *ropeNode[E]
+	limit preview.Limit
+}
+
+// ropeNode node of a treap ordered by position and heap ordered by priority, it is immutable once built
+type ropeNode[E any] struct {
+	chunk    []E
+	left     *ropeNode[E]
+	right    *ropeNode[E]
+	priority uint64
+	size     int
+}
+
+func newRopeNode[E any](chunk []E, left, right *ropeNode[E], priority uint64) *ropeNode[E] {
+	return &ropeNode[E]{
+		chunk:    slices.Clip(chunk),
+		left:     left,
+		right:    right,
+		priority: priority,
+		size:     left.getSize() + len(chunk) + right.getSize(),
+	}
+}
+
+func (n *ropeNode[E]) getSize() int {
+	if n == nil {
+		return 0
+	}
+	return n.size
+}
+
+// with returns a copy of the node with the children
+func (n *ropeNode[E]) with(left, right *ropeNode[E]) *ropeNode[E] {
+	return newRopeNode(n.chunk, left, right, n.priority)
+}
+
+// buildRope builds a rope of the values cut into chunks
+func buildRope[E any](values []E) *ropeNode[E] {
+	var root *ropeNode[E]
+	for chunk := range slices.Chunk(slices.Clone(values), ropeChunk) {
+		root = mergeRope(root, newRopeNode(chunk, nil, nil, rand.Uint64()))
+	}
+	return root
+}
+
+// splitRope splits the rope into the elements before the position and the ones from it on,
+// a chunk which spans the position is cut into two nodes
+func splitRope[E any](n *ropeNode[E], position int) (*ropeNode[E], *ropeNode[E]) {
+	if n == nil {
+		return nil, nil
+	}
+	leftSize := n.left.getSize()
+	if position <= leftSize {
+		left, right := splitRope(n.left, position)
+		return left, n.with(right, n.right)
+	}
+	if position >= leftSize+len(n.chunk) {
+		left, right := splitRope(n.right, position-leftSize-len(n.chunk))
+		return n.with(n.left, left), right
+	}
+	offset := position - leftSize
+	return newRopeNode(n.chunk[:offset], n.left, nil, n.priority), newRopeNode(n.chunk[offset:], nil, n.right, n.priority)
+}
+
+// mergeRope returns the rope of the elements of a followed by the elements of b
+func mergeRope[E any](a, b *ropeNode[E]) *ropeNode[E] {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	if a.priority > b.priority {
+		return a.with(a.left, mergeRope(a.right, b))
+	}
+	return b.with(mergeRope(a, b.left), b.right)
+}
+
+// appendRope returns the rope with the values appended, they are added to the last chunk when it has room for them,
+// so elements added one by one do not make a chunk each
+func appendRope[E any](n *ropeNode[E], values []E) *ropeNode[E] {
+	if n == nil || len(values) == 0 {
+		return mergeRope(n, buildRope(values))
+	}
+	if n.right != nil {
+		return n.with(n.left, appendRope(n.right, values))
+	}
+	if len(n.chunk)+len(values) <= ropeChunk {
+		return newRopeNode(append(n.chunk, values...), n.left, nil, n.priority)
+	}
+	return mergeRope(n, buildRope(values))
+}
+
+// each calls the callback with the chunks in order until it returns false
+func (n *ropeNode[E]) each(callback func(chunk []E) bool) bool {
+	if n == nil {
+		return true
+	}
+	return n.left.each(callback) && callback(n.chunk) && n.right.each(callback)
+}
+
+// values returns the elements of the rope
+func (n *ropeNode[E]) values() []E {
+	values := make([]E, 0, n.getSize())
+	n.each(func(chunk []E) bool {
+		values = append(values, chunk...)
+		return true
+	})
+	return values
+}
+
+// check panics when the range is not within the rope
+func (r *Rope[E]) check(from, to int) {
+	size := r.root.getSize()
+	if from < 0 || from > size {
+		panic(exception.NewRangeException(0, size))
+	}
+	if to < from || to > size {
+		panic(exception.NewRangeException(from, size))
+	}
+}
+
+// Count returns the size of the rope
+func (r *Rope[E]) Count() int64 {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return int64(r.root.getSize())
+}
+
+// IsEmpty returns whether the rope is empty
+func (r *Rope[E]) IsEmpty() bool {
+	return r.Count() == 0
+}
+
+// IsNotEmpty returns whether the rope is not empty
+func (r *Rope[E]) IsNotEmpty() bool {
+	return !r.IsEmpty()
+}
+
+// Get returns the element on the index, it panics when the index is out of range
+func (r *Rope[E]) Get(index int) E {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	if index < 0 || index >= r.root.getSize() {
+		panic(exception.NewRangeException(0, r.root.getSize()-1))
+	}
+	n := r.root
+	for {
+		leftSize := n.left.getSize()
+		if index < leftSize {
+			n = n.left
+		} else if index < leftSize+len(n.chunk) {
+			return n.chunk[index-leftSize]
+		} else {
+			index -= leftSize + len(n.chunk)
+			n = n.right
+		}
+	}
+}
+
+// Push appends elements to the end of the rope
+func (r *Rope[E]) Push(values ...E) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.root = appendRope(r.root, values)
+}
+
+// Insert inserts elements in front of the element on the index, the count appends them.
+// It panics when the index is out of range.
+func (r *Rope[E]) Insert(index int, values ...E) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.check(index, index)
+	left, right := splitRope(r.root, index)
+	r.root = mergeRope(appendRope(left, values), right)
+}
+
+// Delete removes the elements in the range [from, to), it panics when the range is out of range
+func (r *Rope[E]) Delete(from, to int) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.check(from, to)
+	left, rest := splitRope(r.root, from)
+	_, right := splitRope(rest, to-from)
+	r.root = mergeRope(left, right)
+}
+
+// Sub returns a new rope with the elements in the range [from, to), it shares the chunks with the rope.
+// It panics when the range is out of range.
+func (r *Rope[E]) Sub(from, to int) *Rope[E] {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	r.check(from, to)
+	_, rest := splitRope(r.root, from)
+	sub, _ := splitRope(rest, to-from)
+	return &Rope[E]{root: sub}
+}
+
+// Concat appends the elements of the other ropes, the chunks are shared, so the other ropes are left unchanged
+func (r *Rope[E]) Concat(others ...*Rope[E]) {
+	roots := make([]*ropeNode[E], 0, len(others))
+	for _, other := range others {
+		other.lock.RLock()
+		roots = append(roots, other.root)
+		other.lock.RUnlock()
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for _, root := range roots {
+		r.root = mergeRope(r.root, root)
+	}
+}
+
+// Clear clears the rope
+func (r *Rope[E]) Clear() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.root = nil
+}
+
+// Clone returns a copy of the rope in O(1) time, the chunks are shared as they are never modified
+func (r *Rope[E]) Clone() *Rope[E] {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return &Rope[E]{root: r.root}
+}
+
+// All returns an iterator over the elements of the rope as it was when the iteration started
+func (r *Rope[E]) All() iter.Seq[E] {
+	r.lock.RLock()
+	root := r.root
+	r.lock.RUnlock()
+	return func(yield func(E) bool) {
+		root.each(func(chunk []E) bool {
+			for _, value := range chunk {
+				if !yield(value) {
+					return false
+				}
+			}
+			return true
+		})
+	}
+}
+
+// ToArray converts to array, the returned slice is a copy, so modifying it does not affect the collection
+func (r *Rope[E]) ToArray() []E {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.root.values()
+}
+
+// ToJSON converts to json
+func (r *Rope[E]) ToJSON() ([]byte, error) {
+	return json.Marshal(r.ToArray())
+}
+
+// MarshalJSON implements [json.Marshaler]
+func (r *Rope[E]) MarshalJSON() ([]byte, error) {
+	return r.ToJSON()
+}
+
+// UnmarshalJSON implements [json.Unmarshaler]
+func (r *Rope[E]) UnmarshalJSON(data []byte) error {
+	values := make([]E, 0)
+	if err := json.Unmarshal(data, &values); err != nil {
+		return err
+	}
+	root := buildRope(values)
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.root = root
+	return nil
+}
+
+// SetPreviewLimit sets the number of elements shown by String, %v and LogValue, a negative limit shows all elements
+func (r *Rope[E]) SetPreviewLimit(limit int) {
+	r.limit.Set(limit)
+}
+
+// String converts to string, only the preview limit of elements are shown
+func (r *Rope[E]) String() string {
+	return r.format(r.limit.Get(preview.DefaultLimit))
+}
+
+// Format implements [fmt.Formatter], %v prints the preview as String does, %+v and %#v print all elements
+func (r *Rope[E]) Format(f fmt.State, verb rune) {
+	preview.Format(f, verb, r.format, r.limit.Get(preview.DefaultLimit))
+}
+
+func (r *Rope[E]) format(limit int) string {
+	items := r.ToArray()
+	return preview.Elements(fmt.Sprintf("Rope[%T](len=%d)", *new(E), len(items)), items, limit)
+}
+
+// LogValue implements [slog.LogValuer], only the preview limit of elements are logged
+func (r *Rope[E]) LogValue() slog.Value {
+	return preview.LogElements(fmt.Sprintf("Rope[%T]", *new(E)), r.ToArray(), r.limit.Get(preview.DefaultLimit))
+}
@@ -0,0 +1,121 @@
+package list
+
+import (
+	"encoding/json"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRope_Insert(t *testing.T) {
+	r := NewRope([]rune("hello world")...)
+	r.Insert(5, []rune(",")...)
+	r.Insert(0, []rune("> ")...)
+	r.Insert(int(r.Count()), '!')
+	assert.Equal(t, "> hello, world!", string(r.ToArray()))
+	assert.Equal(t, 'h', r.Get(2))
+	assert.Panics(t, func() {
+		r.Insert(100, 'x')
+	})
+	assert.Panics(t, func() {
+		r.Get(-1)
+	})
+}
+
+func TestRope_Delete(t *testing.T) {
+	r := NewRope([]byte("hello cruel world")...)
+	r.Delete(5, 11)
+	assert.Equal(t, "hello world", string(r.ToArray()))
+	r.Delete(0, 0)
+	assert.EqualValues(t, 11, r.Count())
+	assert.Panics(t, func() {
+		r.Delete(5, 4)
+	})
+	r.Delete(0, 11)
+	assert.True(t, r.IsEmpty())
+}
+
+func TestRope_Sub(t *testing.T) {
+	r := NewRope([]byte(strings.Repeat("abcdefghij", 200))...)
+	sub := r.Sub(1995, 2000)
+	assert.Equal(t, "fghij", string(sub.ToArray()))
+	r.Delete(0, 2000)
+	assert.Equal(t, "fghij", string(sub.ToArray()))
+	assert.Panics(t, func() {
+		r.Sub(0, 1)
+	})
+}
+
+func TestRope_Concat(t *testing.T) {
+	a := NewRope(1, 2)
+	b := NewRope(3)
+	a.Concat(b, a)
+	assert.Equal(t, []int{1, 2, 3, 1, 2}, a.ToArray())
+	assert.Equal(t, []int{3}, b.ToArray())
+
+	clone := a.Clone()
+	a.Push(6)
+	assert.Equal(t, []int{1, 2, 3, 1, 2}, clone.ToArray())
+	assert.Equal(t, []int{1, 2, 3, 1, 2, 6}, slices.Collect(a.All()))
+	a.Clear()
+	assert.True(t, a.IsEmpty())
+	assert.EqualValues(t, 5, clone.Count())
+}
+
+func TestRope_Random(t *testing.T) {
+	r := NewRope[int]()
+	var expected []int
+	for i := range 3000 {
+		index := rand.IntN(len(expected) + 1)
+		switch rand.IntN(4) {
+		case 0, 1:
+			values := []int{i, i, i}
+			r.Insert(index, values...)
+			expected = slices.Insert(expected, index, values...)
+		case 2:
+			to := min(len(expected), index+rand.IntN(5))
+			r.Delete(index, to)
+			expected = slices.Delete(expected, index, to)
+		case 3:
+			r.Push(i)
+			expected = append(expected, i)
+		}
+	}
+	assert.Equal(t, expected, r.ToArray())
+	assert.EqualValues(t, len(expected), r.Count())
+	for i := range expected {
+		assert.Equal(t, expected[i], r.Get(i))
+	}
+}
+
+func TestRope_All(t *testing.T) {
+	r := NewRope([]int{1, 2, 3, 4}...)
+	var values []int
+	for value := range r.All() {
+		r.Push(value)
+		if value == 3 {
+			break
+		}
+		values = append(values, value)
+	}
+	assert.Equal(t, []int{1, 2}, values)
+	assert.Equal(t, []int{1, 2, 3, 4, 1, 2, 3}, r.ToArray())
+}
+
+func TestRope_JSON(t *testing.T) {
+	r := NewRope(1, 2, 3)
+	data, err := json.Marshal(r)
+	assert.Nil(t, err)
+	assert.Equal(t, `[1,2,3]`, string(data))
+	decoded := NewRope[int]()
+	assert.Nil(t, json.Unmarshal([]byte(`[4,5]`), decoded))
+	assert.Equal(t, []int{4, 5}, decoded.ToArray())
+}
+
+func TestRope_String(t *testing.T) {
+	r := NewRope(1, 2, 3, 4, 5, 6)
+	assert.Equal(t, "Rope[int](len=6){\n\t1,\n\t2,\n\t3,\n\t4,\n\t5,\n\t...\n}", r.String())
+}
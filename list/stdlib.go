@@ -0,0 +1,62 @@
+package list
+
+import (
+	listlib "container/list"
+	"container/ring"
+	"sort"
+)
+
+// NewListFromRing creates a list with the values of the ring starting at r,
+// it panics when a value is not an E
+func NewListFromRing[E any](r *ring.Ring) *List[E] {
+	list := &List[E]{items: make([]E, 0, r.Len())}
+	r.Do(func(value any) {
+		list.items = append(list.items, valueOf[E](value))
+	})
+	return list
+}
+
+// NewLinkedListFromList creates a linked list with the values of the [listlib.List] from front to back,
+// it panics when a value is not an E
+func NewLinkedListFromList[E any](l *listlib.List) *LinkedList[E] {
+	linked := NewLinkedList[E]()
+	for e := l.Front(); e != nil; e = e.Next() {
+		linked.list.PushBack(valueOf[E](e.Value))
+	}
+	return linked
+}
+
+func valueOf[E any](value any) E {
+	if value == nil {
+		return *new(E)
+	}
+	return value.(E)
+}
+
+// SortInterface adapts the list to [sort.Interface] ordered by less, so it can be sorted by the sort package.
+// Each method locks the list, but sort calls them many times in a row,
+// so the list must not be modified concurrently while it is sorted. Sort is faster when the sort package is not required.
+func (list *List[E]) SortInterface(less func(a, b E) bool) sort.Interface {
+	return &sortAdapter[E]{list: list, less: less}
+}
+
+type sortAdapter[E any] struct {
+	list *List[E]
+	less func(a, b E) bool
+}
+
+func (s *sortAdapter[E]) Len() int {
+	return int(s.list.Count())
+}
+
+func (s *sortAdapter[E]) Less(i, j int) bool {
+	s.list.lock.RLock()
+	defer s.list.lock.RUnlock()
+	return s.less(s.list.items[i], s.list.items[j])
+}
+
+func (s *sortAdapter[E]) Swap(i, j int) {
+	s.list.lock.Lock()
+	defer s.list.lock.Unlock()
+	s.list.items[i], s.list.items[j] = s.list.items[j], s.list.items[i]
+}
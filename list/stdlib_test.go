@@ -0,0 +1,47 @@
+package list
+
+import (
+	listlib "container/list"
+	"container/ring"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewListFromRing(t *testing.T) {
+	r := ring.New(3)
+	for i := 1; i <= 3; i++ {
+		r.Value = i
+		r = r.Next()
+	}
+	assert.Equal(t, []int{1, 2, 3}, NewListFromRing[int](r).ToArray())
+	assert.Equal(t, []int{2, 3, 1}, NewListFromRing[int](r.Next()).ToArray())
+	assert.Equal(t, []*int{nil}, NewListFromRing[*int](ring.New(1)).ToArray())
+	assert.Panics(t, func() {
+		NewListFromRing[string](r)
+	})
+}
+
+func TestNewLinkedListFromList(t *testing.T) {
+	l := listlib.New()
+	l.PushBack(1)
+	l.PushBack(2)
+	l.PushFront(0)
+	linked := NewLinkedListFromList[int](l)
+	assert.Equal(t, []int{0, 1, 2}, linked.ToArray())
+	linked.Push(3)
+	assert.Equal(t, 3, l.Len())
+}
+
+func TestList_SortInterface(t *testing.T) {
+	list := NewList(3, 1, 2, 5, 4)
+	sort.Sort(list.SortInterface(func(a, b int) bool {
+		return a < b
+	}))
+	assert.Equal(t, []int{1, 2, 3, 4, 5}, list.ToArray())
+	sort.Stable(sort.Reverse(list.SortInterface(func(a, b int) bool {
+		return a < b
+	})))
+	assert.Equal(t, []int{5, 4, 3, 2, 1}, list.ToArray())
+}
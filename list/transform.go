@@ -0,0 +1,92 @@
+package list
+
+import (
+	"iter"
+
+	"github.com/gopi-frame/collection"
+)
+
+// Map returns a new list with the results of fn on each element of the collection in order, see MapSeq to map lazily
+func Map[E, R any](c collection.Iterable[E], fn func(value E) R) *List[R] {
+	return collection.Collect(NewList[R](), MapSeq(c.All(), fn))
+}
+
+// FlatMap returns a new list with the results of fn on each element of the collection concatenated in order,
+// see FlatMapSeq to map lazily
+func FlatMap[E, R any](c collection.Iterable[E], fn func(value E) []R) *List[R] {
+	return collection.Collect(NewList[R](), FlatMapSeq(c.All(), fn))
+}
+
+// MapSeq returns an iterator over the results of fn on each element of seq, fn is called as the iterator is consumed
+func MapSeq[E, R any](seq iter.Seq[E], fn func(value E) R) iter.Seq[R] {
+	return func(yield func(R) bool) {
+		for value := range seq {
+			if !yield(fn(value)) {
+				return
+			}
+		}
+	}
+}
+
+// FlatMapSeq returns an iterator over the results of fn on each element of seq concatenated,
+// fn is called as the iterator is consumed
+func FlatMapSeq[E, R any](seq iter.Seq[E], fn func(value E) []R) iter.Seq[R] {
+	return func(yield func(R) bool) {
+		for value := range seq {
+			for _, result := range fn(value) {
+				if !yield(result) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// FilterSeq returns an iterator over the elements of seq which match fn, it is the lazy variant of Where
+func FilterSeq[E any](seq iter.Seq[E], fn func(value E) bool) iter.Seq[E] {
+	return func(yield func(E) bool) {
+		for value := range seq {
+			if fn(value) && !yield(value) {
+				return
+			}
+		}
+	}
+}
+
+// Reduce folds the elements of seq in order into initial with fn and returns the result,
+// it consumes an iterator, so it ends the lazy pipelines as well as l.All()
+func Reduce[E, R any](seq iter.Seq[E], initial R, fn func(result R, value E) R) R {
+	result := initial
+	for value := range seq {
+		result = fn(result, value)
+	}
+	return result
+}
+
+// GroupBy groups the elements of seq by the key of fn, each group keeps the elements in order
+func GroupBy[E any, K comparable](seq iter.Seq[E], fn func(value E) K) map[K]*List[E] {
+	groups := make(map[K]*List[E])
+	for value := range seq {
+		key := fn(value)
+		group, ok := groups[key]
+		if !ok {
+			group = NewList[E]()
+			groups[key] = group
+		}
+		group.items = append(group.items, value)
+	}
+	return groups
+}
+
+// Partition splits the elements of seq into the ones which match fn and the rest, both keep the elements in order
+func Partition[E any](seq iter.Seq[E], fn func(value E) bool) (matched *List[E], rest *List[E]) {
+	matched, rest = NewList[E](), NewList[E]()
+	for value := range seq {
+		if fn(value) {
+			matched.items = append(matched.items, value)
+		} else {
+			rest.items = append(rest.items, value)
+		}
+	}
+	return matched, rest
+}
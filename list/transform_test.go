@@ -0,0 +1,92 @@
+package list
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMap(t *testing.T) {
+	l := Map(NewList(1, 2, 3), strconv.Itoa)
+	assert.Equal(t, []string{"1", "2", "3"}, l.ToArray())
+
+	linked := Map(NewLinkedList(1, 2, 3), func(value int) int {
+		return value * value
+	})
+	assert.Equal(t, []int{1, 4, 9}, linked.ToArray())
+}
+
+func TestFlatMap(t *testing.T) {
+	l := FlatMap(NewLinkedList("a b", "", "c"), strings.Fields)
+	assert.Equal(t, []string{"a", "b", "c"}, l.ToArray())
+}
+
+func TestMapSeq(t *testing.T) {
+	calls := 0
+	seq := MapSeq(NewList(1, 2, 3, 4).All(), func(value int) int {
+		calls++
+		return value * 10
+	})
+	assert.Equal(t, 0, calls)
+	for value := range seq {
+		assert.Equal(t, 10, value)
+		break
+	}
+	assert.Equal(t, 1, calls)
+}
+
+func TestFlatMapSeq(t *testing.T) {
+	seq := FlatMapSeq(NewList(1, 2, 3).All(), func(value int) []int {
+		return []int{value, value}
+	})
+	var values []int
+	for value := range seq {
+		if len(values) == 3 {
+			break
+		}
+		values = append(values, value)
+	}
+	assert.Equal(t, []int{1, 1, 2}, values)
+}
+
+func TestFilterSeq(t *testing.T) {
+	seq := FilterSeq(NewLinkedList(1, 2, 3, 4, 5).All(), func(value int) bool {
+		return value%2 == 1
+	})
+	squares := MapSeq(seq, func(value int) int {
+		return value * value
+	})
+	assert.Equal(t, 35, Reduce(squares, 0, func(sum, value int) int {
+		return sum + value
+	}))
+}
+
+func TestReduce(t *testing.T) {
+	result := Reduce(NewList("a", "b", "c").All(), "", func(result string, value string) string {
+		return result + value
+	})
+	assert.Equal(t, "abc", result)
+	assert.Equal(t, 7, Reduce(NewList[int]().All(), 7, func(result, value int) int {
+		return result + value
+	}))
+}
+
+func TestGroupBy(t *testing.T) {
+	groups := GroupBy(NewList("apple", "bob", "avocado", "banana", "cherry").All(), func(value string) byte {
+		return value[0]
+	})
+	assert.Len(t, groups, 3)
+	assert.Equal(t, []string{"apple", "avocado"}, groups['a'].ToArray())
+	assert.Equal(t, []string{"bob", "banana"}, groups['b'].ToArray())
+	assert.Equal(t, []string{"cherry"}, groups['c'].ToArray())
+}
+
+func TestPartition(t *testing.T) {
+	even, odd := Partition(NewLinkedList(1, 2, 3, 4, 5).All(), func(value int) bool {
+		return value%2 == 0
+	})
+	assert.Equal(t, []int{2, 4}, even.ToArray())
+	assert.Equal(t, []int{1, 3, 5}, odd.ToArray())
+}
@@ -0,0 +1,198 @@
+// Package metrics registers collections by name and exposes their sizes and operation counters,
+// either through expvar or through a pluggable sink, so the growth of long-running collections can be observed.
+//
+// The sizes are sampled with Count when the stats are read, the operation counters are counted by the caller:
+//
+//	entry, _ := metrics.DefaultRegistry.Register("jobs", q)
+//	q.Enqueue(job)
+//	entry.Inc("enqueue")
+package metrics
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrDuplicateName is returned when a collection is registered with a name which is already in use
+var ErrDuplicateName = errors.New("metrics: duplicate name")
+
+// DefaultRegistry is the registry used by the package level functions
+var DefaultRegistry = NewRegistry()
+
+// Sized collection whose size can be sampled, all collections of this module are sized
+type Sized interface {
+	Count() int64
+}
+
+// Stats stats of a registered collection
+type Stats struct {
+	// Size is the size of the collection when the stats are read
+	Size int64 `json:"size"`
+	// Peak is the largest size ever read
+	Peak int64 `json:"peak"`
+	// Ops are the operation counters
+	Ops map[string]int64 `json:"ops,omitempty"`
+}
+
+// Sink receives the stats of the registered collections
+type Sink interface {
+	Report(name string, stats Stats)
+}
+
+// SinkFunc adapts a function to [Sink]
+type SinkFunc func(name string, stats Stats)
+
+// Report calls the function
+func (f SinkFunc) Report(name string, stats Stats) {
+	f(name, stats)
+}
+
+// Entry registered collection
+type Entry struct {
+	name       string
+	collection Sized
+	peak       atomic.Int64
+	ops        sync.Map
+}
+
+// Name returns the name of the entry
+func (e *Entry) Name() string {
+	return e.name
+}
+
+// Inc increases the counter of the operation by one
+func (e *Entry) Inc(op string) {
+	e.Add(op, 1)
+}
+
+// Add increases the counter of the operation by delta
+func (e *Entry) Add(op string, delta int64) {
+	counter, ok := e.ops.Load(op)
+	if !ok {
+		counter, _ = e.ops.LoadOrStore(op, new(atomic.Int64))
+	}
+	counter.(*atomic.Int64).Add(delta)
+}
+
+// Stats samples the size of the collection and returns the stats
+func (e *Entry) Stats() Stats {
+	size := e.collection.Count()
+	peak := e.peak.Load()
+	for size > peak && !e.peak.CompareAndSwap(peak, size) {
+		peak = e.peak.Load()
+	}
+	stats := Stats{Size: size, Peak: max(size, peak)}
+	e.ops.Range(func(op, counter any) bool {
+		if stats.Ops == nil {
+			stats.Ops = make(map[string]int64)
+		}
+		stats.Ops[op.(string)] = counter.(*atomic.Int64).Load()
+		return true
+	})
+	return stats
+}
+
+// NewRegistry new registry
+func NewRegistry() *Registry {
+	return &Registry{entries: make(map[string]*Entry)}
+}
+
+// Registry registry of named collections, all methods are safe for concurrent use.
+type Registry struct {
+	lock    sync.RWMutex
+	entries map[string]*Entry
+}
+
+// Register registers the collection with the name and returns its entry,
+// it returns [ErrDuplicateName] when the name is already registered
+func (r *Registry) Register(name string, collection Sized) (*Entry, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if _, ok := r.entries[name]; ok {
+		return nil, fmt.Errorf("%w: %s", ErrDuplicateName, name)
+	}
+	entry := &Entry{name: name, collection: collection}
+	r.entries[name] = entry
+	return entry, nil
+}
+
+// Unregister removes the collection registered with the name
+func (r *Registry) Unregister(name string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	delete(r.entries, name)
+}
+
+// Get returns the entry registered with the name
+func (r *Registry) Get(name string) (*Entry, bool) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	entry, ok := r.entries[name]
+	return entry, ok
+}
+
+// Stats returns the stats of all registered collections keyed by name
+func (r *Registry) Stats() map[string]Stats {
+	stats := make(map[string]Stats)
+	for _, entry := range r.snapshot() {
+		stats[entry.name] = entry.Stats()
+	}
+	return stats
+}
+
+// Report sends the stats of all registered collections to the sink in the order of the names
+func (r *Registry) Report(sink Sink) {
+	for _, entry := range r.snapshot() {
+		sink.Report(entry.name, entry.Stats())
+	}
+}
+
+// ReportEvery sends the stats to the sink at every interval until the context is done
+func (r *Registry) ReportEvery(ctx context.Context, sink Sink, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.Report(sink)
+		}
+	}
+}
+
+// Publish publishes the stats of the registry as an expvar variable with the name,
+// like [expvar.Publish] it panics when the name is already published
+func (r *Registry) Publish(name string) {
+	expvar.Publish(name, expvar.Func(func() any {
+		return r.Stats()
+	}))
+}
+
+func (r *Registry) snapshot() []*Entry {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	names := slices.Sorted(maps.Keys(r.entries))
+	entries := make([]*Entry, len(names))
+	for index, name := range names {
+		entries[index] = r.entries[name]
+	}
+	return entries
+}
+
+// Register registers the collection in the default registry
+func Register(name string, collection Sized) (*Entry, error) {
+	return DefaultRegistry.Register(name, collection)
+}
+
+// Unregister removes the collection from the default registry
+func Unregister(name string) {
+	DefaultRegistry.Unregister(name)
+}
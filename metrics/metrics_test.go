@@ -0,0 +1,105 @@
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"expvar"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gopi-frame/collection/list"
+	"github.com/gopi-frame/collection/queue"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry_Register(t *testing.T) {
+	registry := NewRegistry()
+	entry, err := registry.Register("list", list.NewList[int]())
+	assert.Nil(t, err)
+	assert.Equal(t, "list", entry.Name())
+
+	_, err = registry.Register("list", list.NewList[int]())
+	assert.ErrorIs(t, err, ErrDuplicateName)
+
+	got, ok := registry.Get("list")
+	assert.True(t, ok)
+	assert.Same(t, entry, got)
+
+	registry.Unregister("list")
+	_, ok = registry.Get("list")
+	assert.False(t, ok)
+}
+
+func TestRegistry_Stats(t *testing.T) {
+	registry := NewRegistry()
+	l := list.NewList(1, 2, 3)
+	entry, _ := registry.Register("list", l)
+	entry.Inc("push")
+	entry.Add("push", 2)
+	assert.Equal(t, map[string]Stats{
+		"list": {Size: 3, Peak: 3, Ops: map[string]int64{"push": 3}},
+	}, registry.Stats())
+
+	l.Clear()
+	assert.Equal(t, Stats{Size: 0, Peak: 3, Ops: map[string]int64{"push": 3}}, entry.Stats())
+}
+
+func TestRegistry_Report(t *testing.T) {
+	registry := NewRegistry()
+	_, _ = registry.Register("b", list.NewList(1))
+	_, _ = registry.Register("a", queue.NewQueue[int]())
+	var names []string
+	registry.Report(SinkFunc(func(name string, stats Stats) {
+		names = append(names, name)
+	}))
+	assert.Equal(t, []string{"a", "b"}, names)
+}
+
+func TestRegistry_ReportEvery(t *testing.T) {
+	registry := NewRegistry()
+	_, _ = registry.Register("list", list.NewList(1))
+	ctx, cancel := context.WithCancel(context.Background())
+	reported := make(chan Stats, 1)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		registry.ReportEvery(ctx, SinkFunc(func(name string, stats Stats) {
+			select {
+			case reported <- stats:
+			default:
+			}
+		}), time.Millisecond)
+	}()
+	assert.Equal(t, int64(1), (<-reported).Size)
+	cancel()
+	wg.Wait()
+}
+
+func TestRegistry_Publish(t *testing.T) {
+	registry := NewRegistry()
+	entry, _ := registry.Register("queue", queue.NewQueue(1, 2))
+	entry.Inc("enqueue")
+	registry.Publish("TestRegistry_Publish")
+	var stats map[string]Stats
+	assert.Nil(t, json.Unmarshal([]byte(expvar.Get("TestRegistry_Publish").String()), &stats))
+	assert.Equal(t, Stats{Size: 2, Peak: 2, Ops: map[string]int64{"enqueue": 1}}, stats["queue"])
+}
+
+func TestEntry_Concurrent(t *testing.T) {
+	entry, _ := NewRegistry().Register("list", list.NewList[int]())
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				entry.Inc("push")
+				entry.Stats()
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int64(1000), entry.Stats().Ops["push"])
+}
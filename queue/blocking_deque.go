@@ -0,0 +1,402 @@
+package queue
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/gopi-frame/collection/internal/codec"
+	"github.com/gopi-frame/collection/internal/jsonstream"
+	"github.com/gopi-frame/collection/internal/preview"
+	"github.com/gopi-frame/collection/list"
+)
+
+// NewBlockingDeque new blocking deque with the capacity, a negative capacity makes it unbounded
+func NewBlockingDeque[E any](cap int) *BlockingDeque[E] {
+	deque := new(BlockingDeque[E])
+	deque.items = list.NewLinkedList[E]()
+	deque.takeLock = sync.NewCond(&deque.lock)
+	deque.putLock = sync.NewCond(&deque.lock)
+	deque.cap = cap
+	return deque
+}
+
+// BlockingDeque blocking double-ended queue, the pushes block while the deque is full
+// and the pops block while it is empty, at either end. All methods are safe for concurrent use.
+// The callbacks run while the deque is locked, so they must not call methods of the same deque.
+// Close ends the stream of elements, see Close.
+type BlockingDeque[E any] struct {
+	lock     sync.RWMutex
+	items    *list.LinkedList[E]
+	cap      int
+	takeLock *sync.Cond
+	putLock  *sync.Cond
+	limit    preview.Limit
+	closed   bool
+}
+
+// Count returns the size of deque
+func (d *BlockingDeque[E]) Count() int64 {
+	d.lock.RLock()
+	defer d.lock.RUnlock()
+	return d.items.Count()
+}
+
+// IsEmpty returns whether the deque is empty
+func (d *BlockingDeque[E]) IsEmpty() bool {
+	return d.Count() == 0
+}
+
+// IsNotEmpty returns whether the deque is not empty
+func (d *BlockingDeque[E]) IsNotEmpty() bool {
+	return !d.IsEmpty()
+}
+
+// Clear clears the deque
+func (d *BlockingDeque[E]) Clear() {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.items.Clear()
+	d.putLock.Broadcast()
+}
+
+// PeekFront returns the front element, it returns zero value and false when the deque is empty
+func (d *BlockingDeque[E]) PeekFront() (E, bool) {
+	d.lock.RLock()
+	defer d.lock.RUnlock()
+	return d.items.First()
+}
+
+// PeekBack returns the back element, it returns zero value and false when the deque is empty
+func (d *BlockingDeque[E]) PeekBack() (E, bool) {
+	d.lock.RLock()
+	defer d.lock.RUnlock()
+	return d.items.Last()
+}
+
+// TryPushFront pushes the element to the front of the deque, it will return false if the size is up to the capacity or the deque is closed
+func (d *BlockingDeque[E]) TryPushFront(value E) bool {
+	return d.PushFrontTimeout(value, 0)
+}
+
+// TryPushBack pushes the element to the back of the deque, it will return false if the size is up to the capacity or the deque is closed
+func (d *BlockingDeque[E]) TryPushBack(value E) bool {
+	return d.PushBackTimeout(value, 0)
+}
+
+// TryPopFront removes the front element and returns it, it returns zero value and false when the deque is empty
+func (d *BlockingDeque[E]) TryPopFront() (E, bool) {
+	return d.PopFrontTimeout(0)
+}
+
+// TryPopBack removes the back element and returns it, it returns zero value and false when the deque is empty
+func (d *BlockingDeque[E]) TryPopBack() (E, bool) {
+	return d.PopBackTimeout(0)
+}
+
+// PushFront pushes the element to the front of the deque, it will block if the size is up to capacity
+// and returns false when the deque is closed
+func (d *BlockingDeque[E]) PushFront(value E) bool {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if !d.waitRoom(time.Time{}) {
+		return false
+	}
+	d.pushFront(value)
+	return true
+}
+
+// PushBack pushes the element to the back of the deque, it will block if the size is up to capacity
+// and returns false when the deque is closed
+func (d *BlockingDeque[E]) PushBack(value E) bool {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if !d.waitRoom(time.Time{}) {
+		return false
+	}
+	d.pushBack(value)
+	return true
+}
+
+// PopFront removes the front element and returns it, it will block if the deque is empty
+// and returns zero value and false once the deque is closed and drained
+func (d *BlockingDeque[E]) PopFront() (E, bool) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if !d.waitItem(time.Time{}) {
+		return *new(E), false
+	}
+	return d.shift()
+}
+
+// PopBack removes the back element and returns it, it will block if the deque is empty
+// and returns zero value and false once the deque is closed and drained
+func (d *BlockingDeque[E]) PopBack() (E, bool) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if !d.waitItem(time.Time{}) {
+		return *new(E), false
+	}
+	return d.pop()
+}
+
+// PushFrontTimeout pushes the element to the front of the deque.
+// It will block when the size of deque is up to capacity.
+// It will return true if the element is successfully pushed or false when time is out or the deque is closed
+func (d *BlockingDeque[E]) PushFrontTimeout(value E, duration time.Duration) bool {
+	deadline := time.Now().Add(duration)
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if !d.waitRoom(deadline) {
+		return false
+	}
+	d.pushFront(value)
+	return true
+}
+
+// PushBackTimeout pushes the element to the back of the deque.
+// It will block when the size of deque is up to capacity.
+// It will return true if the element is successfully pushed or false when time is out or the deque is closed
+func (d *BlockingDeque[E]) PushBackTimeout(value E, duration time.Duration) bool {
+	deadline := time.Now().Add(duration)
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if !d.waitRoom(deadline) {
+		return false
+	}
+	d.pushBack(value)
+	return true
+}
+
+// PopFrontTimeout removes the front element and returns it.
+// It will block when the deque is empty.
+// It will return zero value and false when time is out or the deque is closed and drained
+func (d *BlockingDeque[E]) PopFrontTimeout(duration time.Duration) (E, bool) {
+	deadline := time.Now().Add(duration)
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if !d.waitItem(deadline) {
+		return *new(E), false
+	}
+	return d.shift()
+}
+
+// PopBackTimeout removes the back element and returns it.
+// It will block when the deque is empty.
+// It will return zero value and false when time is out or the deque is closed and drained
+func (d *BlockingDeque[E]) PopBackTimeout(duration time.Duration) (E, bool) {
+	deadline := time.Now().Add(duration)
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if !d.waitItem(deadline) {
+		return *new(E), false
+	}
+	return d.pop()
+}
+
+func (d *BlockingDeque[E]) full() bool {
+	return d.cap >= 0 && d.items.Count() >= int64(d.cap)
+}
+
+// waitRoom waits until the deque is not full, it returns false when the deadline passes first or the deque is closed.
+// A zero deadline waits without a limit.
+func (d *BlockingDeque[E]) waitRoom(deadline time.Time) bool {
+	return wait(d.putLock, func() bool {
+		return d.full() && !d.closed
+	}, deadline) && !d.closed
+}
+
+// waitItem waits until the deque is not empty, it returns false when the deadline passes first or the deque is closed and drained.
+// A zero deadline waits without a limit.
+func (d *BlockingDeque[E]) waitItem(deadline time.Time) bool {
+	return wait(d.takeLock, func() bool {
+		return d.items.IsEmpty() && !d.closed
+	}, deadline) && d.items.IsNotEmpty()
+}
+
+// Close closes the deque, so it works as the end of a stream for a graceful shutdown:
+// the pushes return false and the blocked producers are woken up with the same result,
+// while the pops take the remaining elements and return zero value and false once the deque is drained.
+// Closing it again does nothing, it always returns nil and implements [io.Closer].
+func (d *BlockingDeque[E]) Close() error {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.closed = true
+	d.takeLock.Broadcast()
+	d.putLock.Broadcast()
+	return nil
+}
+
+// IsClosed returns whether the deque is closed
+func (d *BlockingDeque[E]) IsClosed() bool {
+	d.lock.RLock()
+	defer d.lock.RUnlock()
+	return d.closed
+}
+
+func (d *BlockingDeque[E]) pushFront(value E) {
+	d.items.Unshift(value)
+	d.takeLock.Broadcast()
+}
+
+func (d *BlockingDeque[E]) pushBack(value E) {
+	d.items.Push(value)
+	d.takeLock.Broadcast()
+}
+
+func (d *BlockingDeque[E]) shift() (E, bool) {
+	value, ok := d.items.Shift()
+	d.putLock.Broadcast()
+	return value, ok
+}
+
+func (d *BlockingDeque[E]) pop() (E, bool) {
+	value, ok := d.items.Pop()
+	d.putLock.Broadcast()
+	return value, ok
+}
+
+// pushAll pushes the values to the back of the deque, it blocks while the deque is full
+// and returns [ErrClosed] once the deque is closed
+func (d *BlockingDeque[E]) pushAll(values []E) error {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	for _, value := range values {
+		if !d.waitRoom(time.Time{}) {
+			return ErrClosed
+		}
+		d.pushBack(value)
+	}
+	return nil
+}
+
+// Remove removes the specific element
+func (d *BlockingDeque[E]) Remove(value E) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.items.Remove(value)
+	d.putLock.Broadcast()
+}
+
+// RemoveWhere removes elements which matches the callback
+func (d *BlockingDeque[E]) RemoveWhere(callback func(E) bool) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.items.RemoveWhere(callback)
+	d.putLock.Broadcast()
+}
+
+// All returns an iterator over the elements from the front to the back
+func (d *BlockingDeque[E]) All() iter.Seq[E] {
+	return slices.Values(d.ToArray())
+}
+
+// ToArray converts to array from the front to the back, the returned slice is a copy, so modifying it does not affect the collection
+func (d *BlockingDeque[E]) ToArray() []E {
+	d.lock.RLock()
+	defer d.lock.RUnlock()
+	return d.items.ToArray()
+}
+
+// ToJSON converts to json
+func (d *BlockingDeque[E]) ToJSON() ([]byte, error) {
+	return json.Marshal(d.ToArray())
+}
+
+// MarshalJSON implements [json.Marshaller]
+func (d *BlockingDeque[E]) MarshalJSON() ([]byte, error) {
+	return d.ToJSON()
+}
+
+// UnmarshalJSON implements [json.Unmarshaller], the elements are pushed to the back,
+// it blocks while the deque is full and returns [ErrClosed] once the deque is closed
+func (d *BlockingDeque[E]) UnmarshalJSON(data []byte) error {
+	values := make([]E, 0)
+	if err := json.Unmarshal(data, &values); err != nil {
+		return err
+	}
+	return d.pushAll(values)
+}
+
+// EncodeJSON writes the deque to w as a json array one element at a time, the deque is read locked while writing
+func (d *BlockingDeque[E]) EncodeJSON(w io.Writer) error {
+	d.lock.RLock()
+	defer d.lock.RUnlock()
+	return d.items.EncodeJSON(w)
+}
+
+// DecodeJSON reads a json array from r one element at a time and pushes each element to the back,
+// it blocks while the deque is full, so the elements can be consumed while they are decoded.
+// It stops and returns [ErrClosed] once the deque is closed.
+func (d *BlockingDeque[E]) DecodeJSON(r io.Reader) error {
+	return jsonstream.DecodeFunc(r, func(value E) error {
+		if !d.PushBack(value) {
+			return ErrClosed
+		}
+		return nil
+	})
+}
+
+// ToCBOR converts to cbor
+func (d *BlockingDeque[E]) ToCBOR() ([]byte, error) {
+	return cbor.Marshal(d.ToArray())
+}
+
+// MarshalCBOR implements [cbor.Marshaler]
+func (d *BlockingDeque[E]) MarshalCBOR() ([]byte, error) {
+	return d.ToCBOR()
+}
+
+// UnmarshalCBOR implements [cbor.Unmarshaler], see UnmarshalJSON
+func (d *BlockingDeque[E]) UnmarshalCBOR(data []byte) error {
+	values := make([]E, 0)
+	if err := cbor.Unmarshal(data, &values); err != nil {
+		return err
+	}
+	return d.pushAll(values)
+}
+
+// MarshalBinary implements [encoding.BinaryMarshaler], the elements are encoded by their own encoders
+func (d *BlockingDeque[E]) MarshalBinary() ([]byte, error) {
+	return codec.EncodeValues(d.ToArray())
+}
+
+// UnmarshalBinary implements [encoding.BinaryUnmarshaler], see UnmarshalJSON
+func (d *BlockingDeque[E]) UnmarshalBinary(data []byte) error {
+	values, err := codec.DecodeValues[E](data)
+	if err != nil {
+		return err
+	}
+	return d.pushAll(values)
+}
+
+// SetPreviewLimit sets the number of elements shown by String, %v and LogValue, a negative limit shows all elements
+func (d *BlockingDeque[E]) SetPreviewLimit(limit int) {
+	d.limit.Set(limit)
+}
+
+// String converts to string, only the preview limit of elements are shown
+func (d *BlockingDeque[E]) String() string {
+	return d.format(d.limit.Get(preview.DefaultLimit))
+}
+
+// Format implements [fmt.Formatter], %v prints the preview as String does, %+v and %#v print all elements
+func (d *BlockingDeque[E]) Format(f fmt.State, verb rune) {
+	preview.Format(f, verb, d.format, d.limit.Get(preview.DefaultLimit))
+}
+
+func (d *BlockingDeque[E]) format(limit int) string {
+	items := d.ToArray()
+	return preview.Elements(fmt.Sprintf("BlockingDeque[%T](len=%d)", *new(E), len(items)), items, limit)
+}
+
+// LogValue implements [slog.LogValuer], only the preview limit of elements are logged
+func (d *BlockingDeque[E]) LogValue() slog.Value {
+	return preview.LogElements(fmt.Sprintf("BlockingDeque[%T]", *new(E)), d.ToArray(), d.limit.Get(preview.DefaultLimit))
+}
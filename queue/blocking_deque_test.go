@@ -0,0 +1,155 @@
+package queue
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBlockingDeque_TryPush(t *testing.T) {
+	deque := NewBlockingDeque[int](2)
+	assert.True(t, deque.TryPushBack(2))
+	assert.True(t, deque.TryPushFront(1))
+	assert.False(t, deque.TryPushBack(3))
+	assert.False(t, deque.TryPushFront(0))
+	assert.Equal(t, []int{1, 2}, deque.ToArray())
+}
+
+func TestBlockingDeque_TryPop(t *testing.T) {
+	deque := NewBlockingDeque[int](-1)
+	_, ok := deque.TryPopFront()
+	assert.False(t, ok)
+	_, ok = deque.TryPopBack()
+	assert.False(t, ok)
+	deque.PushBack(1)
+	deque.PushBack(2)
+	deque.PushBack(3)
+	v, ok := deque.TryPopBack()
+	assert.True(t, ok)
+	assert.Equal(t, 3, v)
+	v, ok = deque.TryPopFront()
+	assert.True(t, ok)
+	assert.Equal(t, 1, v)
+	v, _ = deque.PeekFront()
+	assert.Equal(t, 2, v)
+	v, _ = deque.PeekBack()
+	assert.Equal(t, 2, v)
+}
+
+func TestBlockingDeque_Push(t *testing.T) {
+	deque := NewBlockingDeque[int](1)
+	deque.PushBack(1)
+	done := make(chan struct{})
+	go func() {
+		deque.PushFront(0)
+		close(done)
+	}()
+	select {
+	case <-done:
+		t.Fatal("push did not block on a full deque")
+	case <-time.After(20 * time.Millisecond):
+	}
+	v, ok := deque.PopBack()
+	assert.True(t, ok)
+	assert.Equal(t, 1, v)
+	<-done
+	assert.Equal(t, []int{0}, deque.ToArray())
+}
+
+func TestBlockingDeque_Pop(t *testing.T) {
+	deque := NewBlockingDeque[int](-1)
+	wg := new(sync.WaitGroup)
+	results := make(chan int, 2)
+	for _, pop := range []func() (int, bool){deque.PopFront, deque.PopBack} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, ok := pop()
+			assert.True(t, ok)
+			results <- v
+		}()
+	}
+	deque.PushBack(1)
+	deque.PushFront(2)
+	wg.Wait()
+	close(results)
+	var values []int
+	for v := range results {
+		values = append(values, v)
+	}
+	assert.ElementsMatch(t, []int{1, 2}, values)
+	assert.True(t, deque.IsEmpty())
+}
+
+func TestBlockingDeque_Timeout(t *testing.T) {
+	deque := NewBlockingDeque[int](1)
+	_, ok := deque.PopFrontTimeout(10 * time.Millisecond)
+	assert.False(t, ok)
+	_, ok = deque.PopBackTimeout(10 * time.Millisecond)
+	assert.False(t, ok)
+	assert.True(t, deque.PushBackTimeout(1, 10*time.Millisecond))
+	assert.False(t, deque.PushFrontTimeout(0, 10*time.Millisecond))
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		deque.Clear()
+	}()
+	assert.True(t, deque.PushFrontTimeout(2, time.Second))
+	v, ok := deque.PopBackTimeout(time.Second)
+	assert.True(t, ok)
+	assert.Equal(t, 2, v)
+}
+
+func TestBlockingDeque_Close(t *testing.T) {
+	deque := NewBlockingDeque[int](2)
+	deque.PushBack(1)
+	deque.PushBack(2)
+	producer := make(chan bool)
+	go func() {
+		producer <- deque.PushFront(0)
+	}()
+	consumer := make(chan bool)
+	empty := NewBlockingDeque[int](1)
+	go func() {
+		_, ok := empty.PopBack()
+		consumer <- ok
+	}()
+	time.Sleep(10 * time.Millisecond)
+	assert.Nil(t, deque.Close())
+	assert.Nil(t, empty.Close())
+	assert.True(t, deque.IsClosed())
+	assert.False(t, <-producer)
+	assert.False(t, <-consumer)
+	assert.False(t, deque.TryPushBack(3))
+	assert.False(t, deque.PushBackTimeout(3, time.Second))
+
+	v, ok := deque.PopFront()
+	assert.True(t, ok)
+	assert.Equal(t, 1, v)
+	v, ok = deque.PopBackTimeout(time.Second)
+	assert.True(t, ok)
+	assert.Equal(t, 2, v)
+	_, ok = deque.PopFront()
+	assert.False(t, ok)
+	_, ok = deque.PopBackTimeout(time.Second)
+	assert.False(t, ok)
+	assert.ErrorIs(t, json.Unmarshal([]byte("[3]"), deque), ErrClosed)
+	assert.ErrorIs(t, deque.DecodeJSON(strings.NewReader("[3]")), ErrClosed)
+	assert.Nil(t, deque.Close())
+}
+
+func TestBlockingDeque_JSON(t *testing.T) {
+	deque := NewBlockingDeque[int](3)
+	deque.PushBack(2)
+	deque.PushFront(1)
+	data, err := json.Marshal(deque)
+	assert.Nil(t, err)
+	assert.Equal(t, "[1,2]", string(data))
+	restored := NewBlockingDeque[int](3)
+	assert.Nil(t, json.Unmarshal(data, restored))
+	assert.Equal(t, []int{1, 2}, restored.ToArray())
+	assert.Equal(t, "BlockingDeque[int](len=2){\n\t1,\n\t2,\n}", restored.String())
+}
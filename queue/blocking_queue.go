@@ -1,17 +1,21 @@
 package queue
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"iter"
+	"log/slog"
 	"reflect"
-	"strings"
+	"slices"
 	"sync"
 	"time"
 
-	"github.com/gopi-frame/contract"
-	"github.com/gopi-frame/exception"
-	"github.com/gopi-frame/future"
-	"github.com/gopi-frame/util/catch"
+	"github.com/fxamacker/cbor/v2"
+	"github.com/gopi-frame/collection/internal/codec"
+	"github.com/gopi-frame/collection/internal/jsonstream"
+	"github.com/gopi-frame/collection/internal/preview"
 )
 
 // NewBlockingQueue new blocking queue
@@ -19,20 +23,23 @@ func NewBlockingQueue[E any](cap int64) *BlockingQueue[E] {
 	queue := new(BlockingQueue[E])
 	queue.items = []E{}
 	queue.cap = cap
-	queue.lock = new(sync.RWMutex)
-	queue.takeLock = sync.NewCond(queue.lock)
-	queue.putLock = sync.NewCond(queue.lock)
+	queue.takeLock = sync.NewCond(&queue.lock)
+	queue.putLock = sync.NewCond(&queue.lock)
 	return queue
 }
 
-// BlockingQueue blocking queue
+// BlockingQueue blocking queue, all methods are safe for concurrent use.
+// The callbacks run while the queue is locked, so they must not call methods of the same queue.
 type BlockingQueue[E any] struct {
+	lock     sync.RWMutex
 	items    []E
 	size     int64
 	cap      int64
 	takeLock *sync.Cond
 	putLock  *sync.Cond
-	lock     *sync.RWMutex
+	closed   bool
+	limit    preview.Limit
+	spill    *spill[E]
 }
 
 // Count returns the size of queue
@@ -58,12 +65,17 @@ func (q *BlockingQueue[E]) Clear() {
 	defer q.lock.Unlock()
 	q.items = nil
 	q.size = 0
+	if q.spill != nil {
+		q.spill.reset()
+		q.release()
+	}
+	q.putLock.Broadcast()
 }
 
 // Peek returns the first element of the queue
 func (q *BlockingQueue[E]) Peek() (E, bool) {
 	q.lock.RLock()
-	q.lock.RUnlock()
+	defer q.lock.RUnlock()
 	if q.size == 0 {
 		return *new(E), false
 	}
@@ -74,16 +86,66 @@ func (q *BlockingQueue[E]) Peek() (E, bool) {
 func (q *BlockingQueue[E]) TryEnqueue(value E) bool {
 	q.lock.Lock()
 	defer q.lock.Unlock()
+	if q.closed || q.full() {
+		return false
+	}
+	q.push(value)
+	return true
+}
 
-	if q.cap == q.size {
+// TryEnqueueAll enqueues all the values at once, or none of them when they do not fit into the remaining capacity,
+// so the parts of a message are never split by a full queue. A spilling queue takes them all until its spill file fails.
+func (q *BlockingQueue[E]) TryEnqueueAll(values ...E) bool {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if q.closed {
 		return false
 	}
-	q.items = append(q.items, value)
-	q.size++
-	q.takeLock.Broadcast()
+	if q.cap >= 0 && q.cap-q.size < int64(len(values)) && (q.spill == nil || q.spill.err != nil) {
+		return false
+	}
+	for _, value := range values {
+		q.push(value)
+	}
 	return true
 }
 
+// EnqueueAll enqueues the values in order, it takes as many as fit into the remaining capacity at each lock
+// and blocks while the queue is full until all of them are enqueued, so consumers may dequeue the first values before the rest
+func (q *BlockingQueue[E]) EnqueueAll(values ...E) bool {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	for len(values) > 0 {
+		for q.full() && !q.closed {
+			q.putLock.Wait()
+		}
+		if q.closed {
+			return false
+		}
+		for len(values) > 0 && !q.full() {
+			q.push(values[0])
+			values = values[1:]
+		}
+	}
+	return true
+}
+
+// DrainTo dequeues up to limit elements into dst under one lock and returns the number of them, it never blocks.
+// A limit less than 0 or greater than len(dst) is len(dst).
+func (q *BlockingQueue[E]) DrainTo(dst []E, limit int) int {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if limit < 0 || limit > len(dst) {
+		limit = len(dst)
+	}
+	count := 0
+	for count < limit && q.size > 0 {
+		dst[count] = q.shift()
+		count++
+	}
+	return count
+}
+
 // TryDequeue dequeues the first element of the queue and returns it.
 // The empty value of the element type and false will be returned when the queue is empty
 func (q *BlockingQueue[E]) TryDequeue() (E, bool) {
@@ -92,134 +154,262 @@ func (q *BlockingQueue[E]) TryDequeue() (E, bool) {
 	if q.size == 0 {
 		return *new(E), false
 	}
-	value := q.items[0]
-	q.items = q.items[1:]
-	q.size--
-	q.putLock.Broadcast()
-	return value, true
+	return q.shift(), true
 }
 
 // Enqueue enqueues a new element into the queue, it will block if the size is up to capacity
+// and returns false when the queue is closed
 func (q *BlockingQueue[E]) Enqueue(value E) bool {
-	q.lock.TryLock()
+	q.lock.Lock()
 	defer q.lock.Unlock()
-	for q.cap == q.size {
+	for q.full() && !q.closed {
 		q.putLock.Wait()
 	}
-	q.items = append(q.items, value)
-	q.size++
-	q.takeLock.Broadcast()
+	if q.closed {
+		return false
+	}
+	q.push(value)
 	return true
 }
 
 // Dequeue dequeues the first element of queue, it will block if the queue is empty
+// and returns zero value and false once the queue is closed and drained
 func (q *BlockingQueue[E]) Dequeue() (E, bool) {
-	q.lock.TryLock()
+	q.lock.Lock()
 	defer q.lock.Unlock()
-	for q.size == 0 {
+	for q.size == 0 && !q.closed {
 		q.takeLock.Wait()
 	}
-	value := q.items[0]
-	q.items = q.items[1:]
-	q.size--
+	if q.size == 0 {
+		return *new(E), false
+	}
+	return q.shift(), true
+}
+
+// Close closes the queue, so it works as the end of a stream for a graceful shutdown:
+// the enqueues return false, or [ErrClosed] from EnqueueContext, and the blocked producers are woken up with the same result,
+// while the dequeues take the remaining elements and return zero value and false, or [ErrClosed], once the queue is drained.
+// A spilling queue keeps its spilled elements readable and removes its spill file once they are dequeued,
+// it returns the error of removing the file when nothing is spilled. Closing it again does nothing.
+func (q *BlockingQueue[E]) Close() error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	q.closed = true
+	q.takeLock.Broadcast()
+	q.putLock.Broadcast()
+	return q.release()
+}
+
+// Discard closes the queue and drops the remaining elements, a spilling queue removes its spill file at once,
+// it returns the error of removing the file
+func (q *BlockingQueue[E]) Discard() error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	q.closed = true
+	q.items = nil
+	q.size = 0
+	if q.spill != nil {
+		q.spill.reset()
+	}
+	q.takeLock.Broadcast()
 	q.putLock.Broadcast()
-	return value, true
+	return q.release()
+}
+
+// IsClosed returns whether the queue is closed
+func (q *BlockingQueue[E]) IsClosed() bool {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+	return q.closed
 }
 
 // EnqueueTimeout enqueues element into the queue.
 // It will block when the size of queue is up to capacity.
 // It will return true if the element is successfully enqueued or false when time is out
 func (q *BlockingQueue[E]) EnqueueTimeout(value E, duration time.Duration) bool {
-	var ok bool
-	catch.Try(func() {
-		done := make(chan struct{})
-		ok = future.Timeout(func() bool {
-			future.Void(func() {
-				q.lock.TryLock()
-				defer q.lock.Unlock()
-				for q.cap == q.size {
-					q.putLock.Wait()
-				}
-				done <- struct{}{}
-			})
-			<-done
-			q.items = append(q.items, value)
-			q.size++
-			q.takeLock.Broadcast()
-			return true
-		}, duration).Complete(func() {
-			close(done)
-		}).Await()
-	}).Catch(new(exception.TimeoutException), func(err error) {
-		ok = false
-	}).Run()
-	return ok
+	deadline := time.Now().Add(duration)
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	for q.full() && !q.closed {
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return false
+		}
+		waitFor(q.putLock, remaining)
+	}
+	if q.closed {
+		return false
+	}
+	q.push(value)
+	return true
 }
 
 // DequeueTimeout removes the first element and returns it.
 // It will block when the queue is empty.
 // It will return zero value and false when time is out
 func (q *BlockingQueue[E]) DequeueTimeout(duration time.Duration) (E, bool) {
-	var value E
-	var ok bool
-	catch.Try(func() {
-		done := make(chan struct{})
-		ok = future.Timeout(func() bool {
-			future.Void(func() {
-				q.lock.TryLock()
-				defer q.lock.Unlock()
-				for q.size == 0 {
-					q.takeLock.Wait()
-				}
-				done <- struct{}{}
-			})
-			<-done
-			value = q.items[0]
-			q.items = q.items[1:]
-			q.size--
-			q.putLock.Broadcast()
-			return true
-		}, duration).Complete(func() {
-			close(done)
-		}).Await()
-	}).Catch(new(exception.TimeoutException), func(err error) {
-	}).Run()
-	return value, ok
+	deadline := time.Now().Add(duration)
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	for q.size == 0 && !q.closed {
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return *new(E), false
+		}
+		waitFor(q.takeLock, remaining)
+	}
+	if q.size == 0 {
+		return *new(E), false
+	}
+	return q.shift(), true
 }
 
-// Remove removes the specific element
-func (q *BlockingQueue[E]) Remove(value E) {
-	q.lock.TryLock()
+// EnqueueContext enqueues element into the queue.
+// It will block when the size of queue is up to capacity.
+// It returns nil when the element is successfully enqueued or the error of ctx when ctx is done first
+func (q *BlockingQueue[E]) EnqueueContext(ctx context.Context, value E) error {
+	q.lock.Lock()
 	defer q.lock.Unlock()
-	var items []E
-	for _, item := range q.items {
-		if !reflect.DeepEqual(item, value) {
-			items = append(items, item)
+	if err := waitContext(ctx, q.putLock, func() bool {
+		return q.full() && !q.closed
+	}); err != nil {
+		return err
+	}
+	if q.closed {
+		return ErrClosed
+	}
+	q.push(value)
+	return nil
+}
+
+// DequeueContext removes the first element and returns it.
+// It will block when the queue is empty.
+// It returns zero value and the error of ctx when ctx is done first
+func (q *BlockingQueue[E]) DequeueContext(ctx context.Context) (E, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if err := waitContext(ctx, q.takeLock, func() bool {
+		return q.size == 0 && !q.closed
+	}); err != nil {
+		return *new(E), err
+	}
+	if q.size == 0 {
+		return *new(E), ErrClosed
+	}
+	return q.shift(), nil
+}
+
+func (q *BlockingQueue[E]) push(value E) {
+	if q.spill != nil && (int64(len(q.items)) >= q.cap || q.spill.len() > 0) {
+		q.spill.push(value)
+	} else {
+		q.items = append(q.items, value)
+	}
+	q.size++
+	q.takeLock.Broadcast()
+}
+
+func (q *BlockingQueue[E]) shift() E {
+	value := q.items[0]
+	// the slot is cleared so the backing array does not keep the dequeued element alive until it is reallocated
+	q.items[0] = *new(E)
+	q.items = q.items[1:]
+	q.size--
+	if q.spill != nil {
+		if spilled, ok := q.spill.shift(); ok {
+			q.items = append(q.items, spilled)
 		}
+		q.size = int64(len(q.items)) + q.spill.len()
+		q.release()
 	}
-	q.items = items
-	q.size = int64(len(items))
+	q.putLock.Broadcast()
+	return value
+}
+
+// Remove removes the specific element
+func (q *BlockingQueue[E]) Remove(value E) {
+	q.RemoveWhere(func(item E) bool {
+		return reflect.DeepEqual(item, value)
+	})
 }
 
 // RemoveWhere removes elements which matches the callback
 func (q *BlockingQueue[E]) RemoveWhere(callback func(E) bool) {
-	q.lock.TryLock()
+	q.lock.Lock()
 	defer q.lock.Unlock()
 	var items []E
-	for _, item := range q.items {
+	for _, item := range q.values() {
 		if !callback(item) {
 			items = append(items, item)
 		}
 	}
-	q.items = items
-	q.size = int64(len(items))
+	q.replace(items)
+	q.putLock.Broadcast()
 }
 
-// ToArray converts to array
+// Snapshot returns the pending elements and the capacity of the queue, both captured at the same time,
+// so they can be persisted on shutdown and given back to Restore on startup
+func (q *BlockingQueue[E]) Snapshot() ([]E, SnapshotMeta) {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+	return q.values(), SnapshotMeta{Cap: q.cap}
+}
+
+// Restore replaces the elements and the capacity of the queue with a snapshot, blocked callers are woken up,
+// so they see the restored elements. It returns an error and leaves the queue unchanged when the elements exceed the capacity,
+// a spilling queue takes any number of elements but needs a positive capacity.
+func (q *BlockingQueue[E]) Restore(items []E, meta SnapshotMeta) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if q.spill != nil && meta.Cap <= 0 {
+		return fmt.Errorf("queue: spilling queue needs a positive capacity, got %d", meta.Cap)
+	}
+	if q.spill == nil {
+		if err := checkRestore(len(items), meta.Cap); err != nil {
+			return err
+		}
+	}
+	q.cap = meta.Cap
+	q.replace(slices.Clone(items))
+	q.takeLock.Broadcast()
+	q.putLock.Broadcast()
+	return nil
+}
+
+// values returns the elements in memory followed by the spilled ones, the returned slice is a copy
+func (q *BlockingQueue[E]) values() []E {
+	if q.spill == nil {
+		return slices.Clone(q.items)
+	}
+	return append(slices.Clone(q.items), q.spill.values()...)
+}
+
+// replace replaces the elements of the queue, the ones which do not fit into memory are spilled again
+func (q *BlockingQueue[E]) replace(items []E) {
+	if q.spill == nil {
+		q.items = items
+		q.size = int64(len(items))
+		return
+	}
+	q.spill.reset()
+	q.items = items[:min(int64(len(items)), q.cap)]
+	for _, item := range items[len(q.items):] {
+		q.spill.push(item)
+	}
+	q.size = int64(len(q.items)) + q.spill.len()
+	q.release()
+}
+
+// All returns an iterator over the elements
+func (q *BlockingQueue[E]) All() iter.Seq[E] {
+	return slices.Values(q.ToArray())
+}
+
+// ToArray converts to array, the returned slice is a copy, so modifying it does not affect the collection
 func (q *BlockingQueue[E]) ToArray() []E {
-	q.lock.TryRLock()
+	q.lock.RLock()
 	defer q.lock.RUnlock()
-	return q.items
+	return q.values()
 }
 
 // ToJSON converts to json
@@ -232,49 +422,121 @@ func (q *BlockingQueue[E]) MarshalJSON() ([]byte, error) {
 	return q.ToJSON()
 }
 
-// UnmarshalJSON implements [json.Unmarshaler]
+// UnmarshalJSON implements [json.Unmarshaler], it blocks while the queue is full and returns [ErrClosed] once the queue is closed
 func (q *BlockingQueue[E]) UnmarshalJSON(data []byte) error {
-	q.lock.TryLock()
-	defer q.lock.Unlock()
 	values := make([]E, 0)
 	if err := json.Unmarshal(data, &values); err != nil {
 		return err
 	}
+	q.lock.Lock()
+	defer q.lock.Unlock()
 	for _, value := range values {
-		for q.size == q.cap {
+		for q.full() && !q.closed {
 			q.putLock.Wait()
 		}
-		q.items = append(q.items, value)
-		q.size++
-		q.takeLock.Broadcast()
+		if q.closed {
+			return ErrClosed
+		}
+		q.push(value)
 	}
 	return nil
 }
 
-// String converts to string
-func (q *BlockingQueue[E]) String() string {
-	q.lock.TryRLock()
+// EncodeJSON writes the queue to w as a json array one element at a time, the queue is read locked while writing
+func (q *BlockingQueue[E]) EncodeJSON(w io.Writer) error {
+	q.lock.RLock()
 	defer q.lock.RUnlock()
-	str := new(strings.Builder)
-	str.WriteString(fmt.Sprintf("BlockingQueue[%T](len=%d)", *new(E), q.size))
-	str.WriteByte('{')
-	str.WriteByte('\n')
-	for index, value := range q.items {
-		str.WriteByte('\t')
-		if v, ok := any(value).(contract.Stringable); ok {
-			str.WriteString(v.String())
-		} else {
-			str.WriteString(fmt.Sprintf("%v", value))
+	return jsonstream.Encode(w, slices.Values(q.values()))
+}
+
+// DecodeJSON reads a json array from r one element at a time and enqueues each element after the existing ones,
+// it blocks while the queue is full, so the elements can be consumed while they are decoded.
+// It stops and returns [ErrClosed] once the queue is closed.
+func (q *BlockingQueue[E]) DecodeJSON(r io.Reader) error {
+	return jsonstream.DecodeFunc(r, func(value E) error {
+		if !q.Enqueue(value) {
+			return ErrClosed
 		}
-		str.WriteByte(',')
-		str.WriteByte('\n')
-		if index >= 4 {
-			break
+		return nil
+	})
+}
+
+// ToCBOR converts to cbor
+func (q *BlockingQueue[E]) ToCBOR() ([]byte, error) {
+	return cbor.Marshal(q.ToArray())
+}
+
+// MarshalCBOR implements [cbor.Marshaler]
+func (q *BlockingQueue[E]) MarshalCBOR() ([]byte, error) {
+	return q.ToCBOR()
+}
+
+// UnmarshalCBOR implements [cbor.Unmarshaler], it blocks while the queue is full and returns [ErrClosed] once the queue is closed
+func (q *BlockingQueue[E]) UnmarshalCBOR(data []byte) error {
+	values := make([]E, 0)
+	if err := cbor.Unmarshal(data, &values); err != nil {
+		return err
+	}
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	for _, value := range values {
+		for q.full() && !q.closed {
+			q.putLock.Wait()
+		}
+		if q.closed {
+			return ErrClosed
 		}
+		q.push(value)
+	}
+	return nil
+}
+
+// MarshalBinary implements [encoding.BinaryMarshaler], the elements are encoded by their own encoders
+func (q *BlockingQueue[E]) MarshalBinary() ([]byte, error) {
+	return codec.EncodeValues(q.ToArray())
+}
+
+// UnmarshalBinary implements [encoding.BinaryUnmarshaler], it blocks while the queue is full and returns [ErrClosed] once the queue is closed
+func (q *BlockingQueue[E]) UnmarshalBinary(data []byte) error {
+	values, err := codec.DecodeValues[E](data)
+	if err != nil {
+		return err
 	}
-	if q.size > 5 {
-		str.WriteString("\t...\n")
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	for _, value := range values {
+		for q.full() && !q.closed {
+			q.putLock.Wait()
+		}
+		if q.closed {
+			return ErrClosed
+		}
+		q.push(value)
 	}
-	str.WriteByte('}')
-	return str.String()
+	return nil
+}
+
+// SetPreviewLimit sets the number of elements shown by String, %v and LogValue, a negative limit shows all elements
+func (q *BlockingQueue[E]) SetPreviewLimit(limit int) {
+	q.limit.Set(limit)
+}
+
+// String converts to string, only the preview limit of elements are shown
+func (q *BlockingQueue[E]) String() string {
+	return q.format(q.limit.Get(preview.DefaultLimit))
+}
+
+// Format implements [fmt.Formatter], %v prints the preview as String does, %+v and %#v print all elements
+func (q *BlockingQueue[E]) Format(f fmt.State, verb rune) {
+	preview.Format(f, verb, q.format, q.limit.Get(preview.DefaultLimit))
+}
+
+func (q *BlockingQueue[E]) format(limit int) string {
+	items := q.ToArray()
+	return preview.Elements(fmt.Sprintf("BlockingQueue[%T](len=%d)", *new(E), len(items)), items, limit)
+}
+
+// LogValue implements [slog.LogValuer], only the preview limit of elements are logged
+func (q *BlockingQueue[E]) LogValue() slog.Value {
+	return preview.LogElements(fmt.Sprintf("BlockingQueue[%T]", *new(E)), q.ToArray(), q.limit.Get(preview.DefaultLimit))
 }
@@ -1,12 +1,17 @@
 package queue
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"regexp"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/fxamacker/cbor/v2"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -75,6 +80,90 @@ func TestBlockingQueue_TryEnqueue(t *testing.T) {
 	})
 }
 
+func TestBlockingQueue_TryEnqueueAll(t *testing.T) {
+	queue := NewBlockingQueue[int](5)
+	assert.True(t, queue.TryEnqueueAll(1, 2, 3))
+	assert.False(t, queue.TryEnqueueAll(4, 5, 6))
+	assert.Equal(t, []int{1, 2, 3}, queue.ToArray())
+	assert.True(t, queue.TryEnqueueAll(4, 5))
+	assert.True(t, queue.TryEnqueueAll())
+	assert.False(t, queue.TryEnqueueAll(6))
+
+	spilling, err := NewSpillingBlockingQueue[int](1, t.TempDir())
+	assert.Nil(t, err)
+	defer spilling.Close()
+	assert.True(t, spilling.TryEnqueueAll(1, 2, 3))
+	assert.Equal(t, []int{1, 2, 3}, spilling.ToArray())
+}
+
+func TestBlockingQueue_EnqueueAll(t *testing.T) {
+	queue := NewBlockingQueue[int](2)
+	done := make(chan bool)
+	go func() {
+		done <- queue.EnqueueAll(1, 2, 3, 4, 5)
+	}()
+	var values []int
+	for len(values) < 5 {
+		value, _ := queue.Dequeue()
+		values = append(values, value)
+	}
+	assert.True(t, <-done)
+	assert.Equal(t, []int{1, 2, 3, 4, 5}, values)
+	assert.True(t, queue.EnqueueAll())
+}
+
+func TestBlockingQueue_DrainTo(t *testing.T) {
+	queue := NewBlockingQueue[int](5)
+	queue.TryEnqueueAll(1, 2, 3, 4, 5)
+	dst := make([]int, 3)
+	assert.Equal(t, 2, queue.DrainTo(dst, 2))
+	assert.Equal(t, []int{1, 2, 0}, dst)
+	assert.Equal(t, 3, queue.DrainTo(dst, -1))
+	assert.Equal(t, []int{3, 4, 5}, dst)
+	assert.Equal(t, 0, queue.DrainTo(dst, 10))
+	assert.True(t, queue.IsEmpty())
+
+	t.Run("wakes producers", func(t *testing.T) {
+		queue := NewBlockingQueue[int](1)
+		queue.Enqueue(1)
+		done := make(chan bool)
+		go func() {
+			done <- queue.Enqueue(2)
+		}()
+		dst := make([]int, 4)
+		assert.Equal(t, 1, queue.DrainTo(dst, 1))
+		assert.True(t, <-done)
+		assert.Equal(t, 1, queue.DrainTo(dst, -1))
+		assert.Equal(t, 2, dst[0])
+	})
+}
+
+func TestBlockingQueue_Close(t *testing.T) {
+	queue := NewBlockingQueue[int](2)
+	queue.Enqueue(1)
+	producer := make(chan bool)
+	go func() {
+		producer <- queue.EnqueueAll(2, 3, 4)
+	}()
+	assert.Eventually(t, func() bool {
+		return queue.Count() == 2
+	}, time.Second, time.Millisecond)
+	assert.Nil(t, queue.Close())
+	assert.True(t, queue.IsClosed())
+	assert.False(t, <-producer)
+	assert.False(t, queue.TryEnqueue(5))
+	assert.ErrorIs(t, queue.EnqueueContext(context.Background(), 5), ErrClosed)
+
+	dst := make([]int, 3)
+	assert.Equal(t, 2, queue.DrainTo(dst, -1))
+	assert.Equal(t, []int{1, 2}, dst[:2])
+	_, ok := queue.DequeueTimeout(time.Second)
+	assert.False(t, ok)
+	_, err := queue.DequeueContext(context.Background())
+	assert.ErrorIs(t, err, ErrClosed)
+	assert.Nil(t, queue.Close())
+}
+
 func TestBlockingQueue_TryDequeue(t *testing.T) {
 	queue := NewBlockingQueue[int](5)
 	_, ok := queue.TryDequeue()
@@ -119,6 +208,17 @@ func TestBlockingQueue_Dequeue(t *testing.T) {
 	time.Sleep(time.Second)
 }
 
+func TestBlockingQueue_DequeueClearsSlot(t *testing.T) {
+	queue := NewBlockingQueue[*int](5)
+	value := new(int)
+	queue.Enqueue(value)
+	items := queue.items
+	v, ok := queue.TryDequeue()
+	assert.True(t, ok)
+	assert.Same(t, value, v)
+	assert.Nil(t, items[0])
+}
+
 func TestBlockingQueue_EnqueueTimeout(t *testing.T) {
 	queue := NewBlockingQueue[int](5)
 	for i := 0; i < 5; i++ {
@@ -144,6 +244,10 @@ func TestBlockingQueue_ToArray(t *testing.T) {
 		queue.Enqueue(i)
 	}
 	assert.Equal(t, []int{0, 1, 2, 3, 4}, queue.ToArray())
+	items := queue.ToArray()
+	items[0] = 10
+	v, _ := queue.Peek()
+	assert.Equal(t, 0, v)
 }
 
 func TestBlockingQueue_ToJSON(t *testing.T) {
@@ -171,6 +275,68 @@ func TestBlockingQueue_UnmarshalJSON(t *testing.T) {
 	err := json.Unmarshal([]byte(`[0,1,2,3,4]`), queue)
 	assert.Nil(t, err)
 	assert.Equal(t, []int{0, 1, 2, 3, 4}, queue.ToArray())
+
+	t.Run("closed", func(t *testing.T) {
+		queue := NewBlockingQueue[int](1)
+		done := make(chan error)
+		go func() {
+			done <- json.Unmarshal([]byte(`[0,1,2]`), queue)
+		}()
+		assert.Eventually(t, queue.IsNotEmpty, time.Second, time.Millisecond)
+		assert.Nil(t, queue.Close())
+		assert.ErrorIs(t, <-done, ErrClosed)
+		assert.Equal(t, []int{0}, queue.ToArray())
+		assert.ErrorIs(t, json.Unmarshal([]byte(`[1]`), queue), ErrClosed)
+	})
+}
+
+func TestBlockingQueue_EncodeJSON(t *testing.T) {
+	queue := NewBlockingQueue[int](2)
+	queue.Enqueue(1)
+	queue.Enqueue(2)
+	buf := new(bytes.Buffer)
+	assert.Nil(t, queue.EncodeJSON(buf))
+	assert.JSONEq(t, `[1,2]`, buf.String())
+}
+
+func TestBlockingQueue_DecodeJSON(t *testing.T) {
+	queue := NewBlockingQueue[int](2)
+	done := make(chan error)
+	go func() {
+		done <- queue.DecodeJSON(strings.NewReader(`[1, 2, 3, 4]`))
+	}()
+	var values []int
+	for i := 0; i < 4; i++ {
+		value, _ := queue.Dequeue()
+		values = append(values, value)
+	}
+	assert.Nil(t, <-done)
+	assert.ElementsMatch(t, []int{1, 2, 3, 4}, values)
+
+	t.Run("existing", func(t *testing.T) {
+		queue := NewBlockingQueue[int](1)
+		queue.Enqueue(0)
+		go func() {
+			done <- queue.DecodeJSON(strings.NewReader(`[1]`))
+		}()
+		value, _ := queue.Dequeue()
+		assert.Equal(t, 0, value)
+		value, _ = queue.Dequeue()
+		assert.Equal(t, 1, value)
+		assert.Nil(t, <-done)
+	})
+
+	t.Run("closed", func(t *testing.T) {
+		queue := NewBlockingQueue[int](1)
+		go func() {
+			done <- queue.DecodeJSON(strings.NewReader(`[1, 2, 3]`))
+		}()
+		assert.Eventually(t, queue.IsNotEmpty, time.Second, time.Millisecond)
+		assert.Nil(t, queue.Close())
+		assert.ErrorIs(t, <-done, ErrClosed)
+		assert.Equal(t, []int{1}, queue.ToArray())
+		assert.ErrorIs(t, queue.DecodeJSON(strings.NewReader(`[4]`)), ErrClosed)
+	})
 }
 
 func TestBlockingQueue_String(t *testing.T) {
@@ -204,3 +370,104 @@ func TestBlockingQueue_RemoveWhere(t *testing.T) {
 	assert.Equal(t, int64(3), queue.Count())
 	assert.Equal(t, []int{0, 2, 4}, queue.ToArray())
 }
+
+func TestBlockingQueue_Concurrent(t *testing.T) {
+	queue := NewBlockingQueue[int](2)
+	var wg sync.WaitGroup
+	var sum int
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			value, ok := queue.Dequeue()
+			assert.True(t, ok)
+			sum += value
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		queue.Enqueue(i)
+	}
+	wg.Wait()
+	assert.Equal(t, 4950, sum)
+	assert.True(t, queue.IsEmpty())
+}
+
+func TestBlockingQueue_DequeueTimeout_Wakeup(t *testing.T) {
+	queue := NewBlockingQueue[int](1)
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		queue.Enqueue(1)
+	}()
+	value, ok := queue.DequeueTimeout(time.Second)
+	assert.True(t, ok)
+	assert.Equal(t, 1, value)
+}
+
+func TestBlockingQueue_MarshalCBOR(t *testing.T) {
+	value := NewBlockingQueue[int](5)
+	value.Enqueue(1)
+	value.Enqueue(2)
+	value.Enqueue(3)
+	data, err := cbor.Marshal(value)
+	assert.Nil(t, err)
+	decoded := NewBlockingQueue[int](5)
+	err = cbor.Unmarshal(data, decoded)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1, 2, 3}, decoded.ToArray())
+	assert.Nil(t, decoded.Close())
+	assert.ErrorIs(t, cbor.Unmarshal(data, decoded), ErrClosed)
+}
+
+func TestBlockingQueue_MarshalBinary(t *testing.T) {
+	value := NewBlockingQueue[int](5)
+	value.Enqueue(1)
+	value.Enqueue(2)
+	value.Enqueue(3)
+	data, err := value.MarshalBinary()
+	assert.Nil(t, err)
+	decoded := NewBlockingQueue[int](5)
+	err = decoded.UnmarshalBinary(data)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1, 2, 3}, decoded.ToArray())
+	assert.Nil(t, decoded.Close())
+	assert.ErrorIs(t, decoded.UnmarshalBinary(data), ErrClosed)
+}
+
+func TestBlockingQueue_EnqueueContext(t *testing.T) {
+	queue := NewBlockingQueue[int](1)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.Nil(t, queue.EnqueueContext(ctx, 1))
+	assert.ErrorIs(t, queue.EnqueueContext(ctx, 2), context.DeadlineExceeded)
+
+	ctx, cancel = context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+	}()
+	assert.ErrorIs(t, queue.EnqueueContext(ctx, 2), context.Canceled)
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		queue.Dequeue()
+	}()
+	assert.Nil(t, queue.EnqueueContext(context.Background(), 3))
+	assert.Equal(t, []int{3}, queue.ToArray())
+}
+
+func TestBlockingQueue_DequeueContext(t *testing.T) {
+	queue := NewBlockingQueue[int](1)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	value, err := queue.DequeueContext(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, 0, value)
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		queue.Enqueue(1)
+	}()
+	value, err = queue.DequeueContext(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 1, value)
+}
@@ -0,0 +1,17 @@
+//go:build collectiondebug
+
+package queue
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPriorityQueue_Debug(t *testing.T) {
+	queue := NewPriorityQueue(_comparator{}, 1, 2, 3)
+	queue.items[0] = 5
+	assert.PanicsWithError(t, "*queue.PriorityQueue[int] is corrupted, it may be used without synchronization: element 2 at 1 is less than its parent 5 at 0", func() {
+		queue.Enqueue(4)
+	})
+}
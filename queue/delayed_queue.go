@@ -1,28 +1,185 @@
 package queue
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"iter"
+	"log/slog"
 	"reflect"
-	"strings"
+	"slices"
 	"sync"
 	"time"
 
+	"github.com/fxamacker/cbor/v2"
+	"github.com/gopi-frame/collection/comparator"
+	"github.com/gopi-frame/collection/internal/codec"
+	"github.com/gopi-frame/collection/internal/jsonstream"
+	"github.com/gopi-frame/collection/internal/preview"
 	"github.com/gopi-frame/contract"
 )
 
 // NewDelayedQueue new delayed queue
 func NewDelayedQueue[Q contract.Delayable[T], T any]() *DelayedQueue[Q, T] {
 	queue := new(DelayedQueue[Q, T])
-	queue.items = NewPriorityQueue[Q](queue)
-	queue.takeLock = sync.NewCond(queue.items)
+	queue.items = NewPriorityQueue(comparator.Func[*delayedEntry[Q]](func(a, b *delayedEntry[Q]) int {
+		return a.until.Compare(b.until)
+	}))
+	queue.takeLock = sync.NewCond(&queue.lock)
 	return queue
 }
 
-// DelayedQueue delayed queue
+// DelayedQueue delayed queue, all methods are safe for concurrent use.
+// The callbacks run while the queue is locked, so they must not call methods of the same queue.
+// Close ends the stream of elements, see Close.
 type DelayedQueue[Q contract.Delayable[T], T any] struct {
-	items    *PriorityQueue[Q]
+	lock     sync.RWMutex
+	items    *PriorityQueue[*delayedEntry[Q]]
 	takeLock *sync.Cond
+	// cancelled is the number of the entries cancelled by their handles which are still in items,
+	// they are skipped when they reach the top and compacted away once they outnumber the pending ones
+	cancelled int64
+	limit     preview.Limit
+	maxSize   int64
+	policy    DropPolicy
+	onDrop    func(item Q, reason DropPolicy)
+	closed    bool
+}
+
+// delayedEntry element of a delayed queue with the time it is due, which is the Until of the element
+// unless it is rescheduled by its handle
+type delayedEntry[Q any] struct {
+	value Q
+	until time.Time
+	// done is set once the element leaves the queue or is cancelled, so its handle can no longer change it
+	done bool
+}
+
+func newDelayedEntry[Q contract.Delayable[T], T any](value Q) *delayedEntry[Q] {
+	return &delayedEntry[Q]{value: value, until: value.Until()}
+}
+
+// DelayedHandle handle of an element scheduled by Schedule, it cancels or reschedules the element
+// without looking it up, so it costs O(log n) however many elements are equal to it
+type DelayedHandle[Q contract.Delayable[T], T any] struct {
+	queue *DelayedQueue[Q, T]
+	entry *delayedEntry[Q]
+}
+
+// Value returns the element of the handle
+func (h *DelayedHandle[Q, T]) Value() Q {
+	return h.entry.value
+}
+
+// Until returns the time the element is due, it reflects the reschedules
+func (h *DelayedHandle[Q, T]) Until() time.Time {
+	h.queue.lock.RLock()
+	defer h.queue.lock.RUnlock()
+	return h.entry.until
+}
+
+// Pending returns whether the element is still in the queue, it is not once it is dequeued, removed, dropped or cancelled
+func (h *DelayedHandle[Q, T]) Pending() bool {
+	h.queue.lock.RLock()
+	defer h.queue.lock.RUnlock()
+	return !h.entry.done
+}
+
+// Cancel removes the element from the queue, it returns false when the element is no longer pending
+func (h *DelayedHandle[Q, T]) Cancel() bool {
+	q := h.queue
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if h.entry.done {
+		return false
+	}
+	q.cancel(h.entry)
+	q.takeLock.Broadcast()
+	return true
+}
+
+// Reschedule moves the element to the time, it returns false when the element is no longer pending.
+// The element keeps its own Until, the queue dequeues it at the time instead,
+// and the serialized forms and the snapshots keep the Until of the element.
+func (h *DelayedHandle[Q, T]) Reschedule(until time.Time) bool {
+	q := h.queue
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if h.entry.done {
+		return false
+	}
+	q.cancel(h.entry)
+	h.entry = &delayedEntry[Q]{value: h.entry.value, until: until}
+	q.items.enqueue(h.entry)
+	q.takeLock.Broadcast()
+	return true
+}
+
+// cancel marks the pending entry as cancelled, the cancelled entries are compacted away once they outnumber the pending ones
+func (q *DelayedQueue[Q, T]) cancel(entry *delayedEntry[Q]) {
+	entry.done = true
+	q.cancelled++
+	if q.cancelled*2 > q.items.size {
+		q.compact()
+	}
+}
+
+// compact removes the cancelled entries from the heap
+func (q *DelayedQueue[Q, T]) compact() {
+	if q.cancelled > 0 {
+		q.items.removeWhere(func(entry *delayedEntry[Q]) bool {
+			return entry.done
+		})
+		q.cancelled = 0
+	}
+}
+
+// peek returns the first pending entry, the cancelled entries on the top are removed first
+func (q *DelayedQueue[Q, T]) peek() (*delayedEntry[Q], bool) {
+	for {
+		entry, ok := q.items.peek()
+		if !ok || !entry.done {
+			return entry, ok
+		}
+		q.items.dequeue()
+		q.cancelled--
+	}
+}
+
+// dequeue removes the first pending entry and returns its element
+func (q *DelayedQueue[Q, T]) dequeue() (Q, bool) {
+	if _, ok := q.peek(); !ok {
+		return *new(Q), false
+	}
+	entry, _ := q.items.dequeue()
+	entry.done = true
+	return entry.value, true
+}
+
+// count returns the number of the pending elements
+func (q *DelayedQueue[Q, T]) count() int64 {
+	return q.items.size - q.cancelled
+}
+
+// pending returns the pending elements in heap order
+func (q *DelayedQueue[Q, T]) pending() []Q {
+	values := make([]Q, 0, q.count())
+	for _, entry := range q.items.items {
+		if !entry.done {
+			values = append(values, entry.value)
+		}
+	}
+	return values
+}
+
+// clear removes all entries, their handles are no longer pending
+func (q *DelayedQueue[Q, T]) clear() {
+	for _, entry := range q.items.items {
+		entry.done = true
+	}
+	q.items.clear()
+	q.cancelled = 0
 }
 
 func (q *DelayedQueue[Q, T]) Compare(a, b Q) int {
@@ -36,45 +193,61 @@ func (q *DelayedQueue[Q, T]) Compare(a, b Q) int {
 }
 
 func (q *DelayedQueue[Q, T]) Count() int64 {
-	q.items.RLock()
-	defer q.items.RUnlock()
-	return q.items.Count()
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+	return q.count()
 }
 
 func (q *DelayedQueue[Q, T]) IsEmpty() bool {
-	q.items.Lock()
-	defer q.items.Unlock()
-	return q.items.IsEmpty()
+	return q.Count() == 0
 }
 
 func (q *DelayedQueue[Q, T]) IsNotEmpty() bool {
-	q.items.Lock()
-	defer q.items.Unlock()
-	return q.items.IsNotEmpty()
+	return !q.IsEmpty()
 }
 
 func (q *DelayedQueue[Q, T]) Clear() {
-	q.items.Lock()
-	defer q.items.Unlock()
-	q.items.Clear()
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	q.clear()
 }
 
 func (q *DelayedQueue[Q, T]) Peek() (Q, bool) {
-	q.items.Lock()
-	defer q.items.Unlock()
-	return q.items.Peek()
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if entry, ok := q.peek(); ok {
+		return entry.value, true
+	}
+	return *new(Q), false
 }
 
 func (q *DelayedQueue[Q, T]) TryEnqueue(value Q) bool {
 	return q.Enqueue(value)
 }
 
+// Enqueue enqueues the element, it returns false when the queue is closed or the drop policy drops the element
 func (q *DelayedQueue[Q, T]) Enqueue(value Q) bool {
-	q.items.Lock()
-	defer q.items.Unlock()
-	ok := q.items.Enqueue(value)
-	q.takeLock.Broadcast()
-	return ok
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if q.closed {
+		return false
+	}
+	return q.push(newDelayedEntry(value))
+}
+
+// Schedule enqueues the element and returns its handle, which cancels or reschedules the element later,
+// such as the timeout of a request which completes or is extended.
+// It returns false when the queue is closed or the drop policy of a full queue drops the element, its handle is not pending then.
+func (q *DelayedQueue[Q, T]) Schedule(value Q) (*DelayedHandle[Q, T], bool) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	entry := newDelayedEntry(value)
+	if q.closed {
+		entry.done = true
+		return &DelayedHandle[Q, T]{queue: q, entry: entry}, false
+	}
+	ok := q.push(entry)
+	return &DelayedHandle[Q, T]{queue: q, entry: entry}, ok
 }
 
 func (q *DelayedQueue[Q, T]) EnqueueTimeout(value Q, _ time.Duration) bool {
@@ -82,52 +255,167 @@ func (q *DelayedQueue[Q, T]) EnqueueTimeout(value Q, _ time.Duration) bool {
 }
 
 func (q *DelayedQueue[Q, T]) TryDequeue() (Q, bool) {
-	q.items.Lock()
-	defer q.items.Unlock()
-	if v, ok := q.items.Peek(); ok && v.Until().Before(time.Now()) {
-		return q.items.Dequeue()
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if entry, ok := q.peek(); ok && entry.until.Before(time.Now()) {
+		return q.dequeue()
 	}
 	return *new(Q), false
 }
 
+// Dequeue removes the element whose delay expires first and returns it, it will block until the delay of an element expires
+// and returns zero value and false once the queue is closed and drained
 func (q *DelayedQueue[Q, T]) Dequeue() (Q, bool) {
-	q.items.Lock()
-	defer q.items.Unlock()
-	for q.items.IsEmpty() {
-		q.takeLock.Wait()
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	for {
+		entry, ok := q.peek()
+		if !ok {
+			if q.closed {
+				return *new(Q), false
+			}
+			q.takeLock.Wait()
+			continue
+		}
+		delay := time.Until(entry.until)
+		if delay <= 0 {
+			return q.dequeue()
+		}
+		// an element with an earlier deadline may be enqueued while waiting
+		waitFor(q.takeLock, delay)
 	}
-	v, _ := q.items.Peek()
-	timer := time.NewTimer(time.Until(v.Until()))
-	defer timer.Stop()
-	<-timer.C
-	return q.items.Dequeue()
 }
 
 func (q *DelayedQueue[Q, T]) DequeueTimeout(duration time.Duration) (Q, bool) {
-	timeout := time.After(duration)
-	done := make(chan struct{})
-	go func() {
-		q.items.Lock()
-		defer q.items.Unlock()
-		for q.items.IsEmpty() {
-			q.takeLock.Wait()
+	deadline := time.Now().Add(duration)
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	for {
+		remaining := time.Until(deadline)
+		entry, ok := q.peek()
+		if ok && !entry.until.After(time.Now()) {
+			return q.dequeue()
 		}
-		if v, ok := q.items.Peek(); ok {
-			timer := time.NewTimer(time.Until(v.Until()))
-			defer timer.Stop()
-			<-timer.C
-			close(done)
+		if remaining <= 0 || !ok && q.closed {
+			return *new(Q), false
 		}
+		if ok {
+			remaining = min(remaining, time.Until(entry.until))
+		}
+		waitFor(q.takeLock, remaining)
+	}
+}
 
-	}()
-	select {
-	case <-timeout:
-		return *new(Q), false
-	case <-done:
-		return q.items.Dequeue()
+// EnqueueContext enqueues the element, it never blocks as the queue is unbounded unless SetMaxSize bounds it.
+// It returns the error of ctx when ctx is already done, [ErrClosed] when the queue is closed,
+// and [ErrDropped] when the drop policy drops the element.
+func (q *DelayedQueue[Q, T]) EnqueueContext(ctx context.Context, value Q) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if q.closed {
+		return ErrClosed
+	}
+	if !q.push(newDelayedEntry(value)) {
+		return ErrDropped
+	}
+	return nil
+}
+
+// DequeueContext removes the element whose delay expires first and returns it.
+// It will block until the delay of an element expires.
+// It returns zero value and the error of ctx when ctx is done first, or [ErrClosed] once the queue is closed and drained
+func (q *DelayedQueue[Q, T]) DequeueContext(ctx context.Context) (Q, error) {
+	entry, err := q.take(ctx)
+	if err != nil {
+		return *new(Q), err
+	}
+	return entry.value, nil
+}
+
+// take removes the first entry once it is due and returns it, it returns the error of ctx when ctx is done first
+// and [ErrClosed] once the queue is closed and drained
+func (q *DelayedQueue[Q, T]) take(ctx context.Context) (*delayedEntry[Q], error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	defer wakeOnDone(ctx, q.takeLock)()
+	for {
+		entry, ok := q.peek()
+		if ok && !entry.until.After(time.Now()) {
+			q.items.dequeue()
+			entry.done = true
+			return entry, nil
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if !ok && q.closed {
+			return nil, ErrClosed
+		}
+		if ok {
+			waitFor(q.takeLock, time.Until(entry.until))
+		} else {
+			q.takeLock.Wait()
+		}
 	}
 }
 
+// Close closes the queue, so it works as the end of a stream for a graceful shutdown:
+// the enqueues return false, or [ErrClosed] from EnqueueContext, while the dequeues still wait for the remaining elements
+// to be due and return zero value and false, or [ErrClosed], once the queue is drained, which also closes the channels of Chan.
+// The blocked consumers are woken up, closing it again does nothing, it always returns nil and implements [io.Closer].
+func (q *DelayedQueue[Q, T]) Close() error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	q.closed = true
+	q.takeLock.Broadcast()
+	return nil
+}
+
+// IsClosed returns whether the queue is closed
+func (q *DelayedQueue[Q, T]) IsClosed() bool {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+	return q.closed
+}
+
+// putBack puts the entry removed by take back as it was, so it keeps its rescheduled time and its handle is pending again.
+// It skips the drop policy, the entry was counted in the queue when it was taken.
+func (q *DelayedQueue[Q, T]) putBack(entry *delayedEntry[Q]) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	entry.done = false
+	q.items.enqueue(entry)
+	q.takeLock.Broadcast()
+}
+
+// Chan returns a channel which receives the elements as their delays expire, so a worker can select on the queue
+// alongside other channels. The channel is unbuffered and closed once ctx is done,
+// an element taken from the queue but not received by then is put back with its time and its handle.
+// The channel is closed as well once the queue is closed and drained.
+// Each call starts its own goroutine, the elements are shared among the channels of several calls.
+func (q *DelayedQueue[Q, T]) Chan(ctx context.Context) <-chan Q {
+	ch := make(chan Q)
+	go func() {
+		defer close(ch)
+		for {
+			entry, err := q.take(ctx)
+			if err != nil {
+				return
+			}
+			select {
+			case ch <- entry.value:
+			case <-ctx.Done():
+				q.putBack(entry)
+				return
+			}
+		}
+	}()
+	return ch
+}
+
 func (q *DelayedQueue[Q, T]) Remove(value Q) {
 	q.RemoveWhere(func(v Q) bool {
 		return reflect.DeepEqual(v.Value(), value.Value()) && v.Until() == value.Until()
@@ -135,21 +423,65 @@ func (q *DelayedQueue[Q, T]) Remove(value Q) {
 }
 
 func (q *DelayedQueue[Q, T]) RemoveWhere(callback func(value Q) bool) {
-	q.items.Lock()
-	defer q.items.Unlock()
-	q.items.RemoveWhere(callback)
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	q.items.removeWhere(func(entry *delayedEntry[Q]) bool {
+		if !entry.done && callback(entry.value) {
+			entry.done = true
+			return true
+		}
+		return entry.done
+	})
+	q.cancelled = 0
+}
+
+// Snapshot returns the pending elements of the queue, the capacity of the meta is the max size or -1 when the queue is unbounded.
+// The elements keep their deadlines, so the ones which are due when they are restored are available at once.
+func (q *DelayedQueue[Q, T]) Snapshot() ([]Q, SnapshotMeta) {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+	if q.maxSize > 0 {
+		return q.pending(), SnapshotMeta{Cap: q.maxSize}
+	}
+	return q.pending(), SnapshotMeta{Cap: -1}
+}
+
+// Restore replaces the elements of the queue with a snapshot and wakes up the blocked callers,
+// the capacity of the meta becomes the max size, a capacity which is not positive makes the queue unbounded,
+// and the drop policy of the queue is kept. It returns an error and keeps the queue as it was
+// when the snapshot has more elements than its capacity.
+func (q *DelayedQueue[Q, T]) Restore(items []Q, meta SnapshotMeta) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if meta.Cap > 0 {
+		if err := checkRestore(len(items), meta.Cap); err != nil {
+			return err
+		}
+		q.maxSize = meta.Cap
+	} else {
+		q.maxSize = 0
+	}
+	q.clear()
+	for _, item := range items {
+		q.push(newDelayedEntry(item))
+	}
+	q.takeLock.Broadcast()
+	return nil
+}
+
+// All returns an iterator over the elements
+func (q *DelayedQueue[Q, T]) All() iter.Seq[Q] {
+	return slices.Values(q.ToArray())
 }
 
 func (q *DelayedQueue[Q, T]) ToArray() []Q {
-	q.items.Lock()
-	defer q.items.Unlock()
-	return q.items.ToArray()
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+	return q.pending()
 }
 
 func (q *DelayedQueue[Q, T]) ToJSON() ([]byte, error) {
-	q.items.Lock()
-	defer q.items.Unlock()
-	return json.Marshal(q.items.ToArray())
+	return json.Marshal(q.ToArray())
 }
 
 func (q *DelayedQueue[Q, T]) MarshalJSON() ([]byte, error) {
@@ -157,42 +489,106 @@ func (q *DelayedQueue[Q, T]) MarshalJSON() ([]byte, error) {
 }
 
 func (q *DelayedQueue[Q, T]) UnmarshalJSON(data []byte) error {
-	q.items.Lock()
-	defer q.items.Unlock()
 	var items []Q
 	err := json.Unmarshal(data, &items)
 	if err != nil {
 		return err
 	}
+	return q.pushAll(items)
+}
+
+// pushAll enqueues the items under one lock, it returns [ErrClosed] when the queue is closed
+func (q *DelayedQueue[Q, T]) pushAll(items []Q) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if q.closed {
+		return ErrClosed
+	}
 	for _, item := range items {
-		q.items.Enqueue(item)
+		q.push(newDelayedEntry(item))
 	}
-	q.takeLock.Broadcast()
 	return nil
 }
 
+func (q *DelayedQueue[Q, T]) EncodeJSON(w io.Writer) error {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+	return jsonstream.Encode(w, slices.Values(q.pending()))
+}
+
+// DecodeJSON reads a json array from r one element at a time and enqueues each element,
+// it stops and returns [ErrClosed] once the queue is closed
+func (q *DelayedQueue[Q, T]) DecodeJSON(r io.Reader) error {
+	return jsonstream.DecodeFunc(r, func(value Q) error {
+		q.lock.Lock()
+		defer q.lock.Unlock()
+		if q.closed {
+			return ErrClosed
+		}
+		q.push(newDelayedEntry(value))
+		return nil
+	})
+}
+
+func (q *DelayedQueue[Q, T]) ToCBOR() ([]byte, error) {
+	return cbor.Marshal(q.ToArray())
+}
+
+func (q *DelayedQueue[Q, T]) MarshalCBOR() ([]byte, error) {
+	return q.ToCBOR()
+}
+
+func (q *DelayedQueue[Q, T]) UnmarshalCBOR(data []byte) error {
+	var items []Q
+	err := cbor.Unmarshal(data, &items)
+	if err != nil {
+		return err
+	}
+	return q.pushAll(items)
+}
+
+func (q *DelayedQueue[Q, T]) MarshalBinary() ([]byte, error) {
+	return codec.EncodeValues(q.ToArray())
+}
+
+func (q *DelayedQueue[Q, T]) UnmarshalBinary(data []byte) error {
+	items, err := codec.DecodeValues[Q](data)
+	if err != nil {
+		return err
+	}
+	return q.pushAll(items)
+}
+
+func (q *DelayedQueue[Q, T]) SetPreviewLimit(limit int) {
+	q.limit.Set(limit)
+}
+
 func (q *DelayedQueue[Q, T]) String() string {
-	q.items.Lock()
-	defer q.items.Unlock()
-	str := new(strings.Builder)
-	str.WriteString(fmt.Sprintf("DelayedQueue[%T](len=%d)", *new(T), q.items.Count()))
-	str.WriteByte('{')
-	str.WriteByte('\n')
+	return q.format(q.limit.Get(preview.DefaultLimit))
+}
+
+func (q *DelayedQueue[Q, T]) Format(f fmt.State, verb rune) {
+	preview.Format(f, verb, q.format, q.limit.Get(preview.DefaultLimit))
+}
+
+func (q *DelayedQueue[Q, T]) format(limit int) string {
+	values := q.values()
+	return preview.Elements(fmt.Sprintf("DelayedQueue[%T](len=%d)", *new(T), len(values)), values, limit)
+}
+
+func (q *DelayedQueue[Q, T]) LogValue() slog.Value {
+	return preview.LogElements(fmt.Sprintf("DelayedQueue[%T]", *new(T)), q.values(), q.limit.Get(preview.DefaultLimit))
+}
+
+func (q *DelayedQueue[Q, T]) values() []string {
 	items := q.ToArray()
+	values := make([]string, len(items))
 	for index, item := range items {
-		str.WriteByte('\t')
 		if v, ok := any(item).(contract.Stringable); ok {
-			str.WriteString(v.String())
+			values[index] = v.String()
 		} else {
-			str.WriteString(fmt.Sprintf("value: %v, unitl: %v", item.Value(), item.Until().Format("2006-01-02 15:04:05")))
+			values[index] = fmt.Sprintf("value: %v, until: %v", item.Value(), item.Until().Format("2006-01-02 15:04:05"))
 		}
-		if index >= 4 {
-			break
-		}
-	}
-	if q.Count() > 5 {
-		str.WriteString("\t...\n")
 	}
-	str.WriteByte('}')
-	return str.String()
+	return values
 }
@@ -1,6 +1,7 @@
 package queue
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"testing"
@@ -117,6 +118,85 @@ func TestDelayedQueue_Enqueue(t *testing.T) {
 	assert.Equal(t, int64(5), queue.Count())
 }
 
+func TestDelayedQueue_Schedule(t *testing.T) {
+	queue := NewDelayedQueue[*_delay]()
+	now := time.Now()
+	first, ok := queue.Schedule(&_delay{value: 1, until: now.Add(time.Hour)})
+	assert.True(t, ok)
+	second, _ := queue.Schedule(&_delay{value: 2, until: now.Add(2 * time.Hour)})
+	assert.Equal(t, int64(2), queue.Count())
+	assert.True(t, first.Pending())
+	assert.Equal(t, 1, first.Value().Value())
+
+	t.Run("cancel", func(t *testing.T) {
+		assert.True(t, first.Cancel())
+		assert.False(t, first.Cancel())
+		assert.False(t, first.Pending())
+		assert.Equal(t, int64(1), queue.Count())
+		value, _ := queue.Peek()
+		assert.Equal(t, 2, value.Value())
+		assert.False(t, first.Reschedule(now))
+	})
+
+	t.Run("reschedule", func(t *testing.T) {
+		assert.True(t, second.Reschedule(now.Add(-time.Second)))
+		assert.Equal(t, now.Add(-time.Second), second.Until())
+		assert.Equal(t, int64(1), queue.Count())
+		value, ok := queue.TryDequeue()
+		assert.True(t, ok)
+		assert.Equal(t, 2, value.Value())
+		assert.False(t, second.Pending())
+		assert.False(t, second.Cancel())
+		assert.True(t, queue.IsEmpty())
+	})
+
+	t.Run("wakes dequeue", func(t *testing.T) {
+		handle, _ := queue.Schedule(&_delay{value: 3, until: time.Now().Add(time.Hour)})
+		go func() {
+			time.Sleep(50 * time.Millisecond)
+			handle.Reschedule(time.Now())
+		}()
+		value, ok := queue.DequeueTimeout(time.Second)
+		assert.True(t, ok)
+		assert.Equal(t, 3, value.Value())
+	})
+
+	t.Run("dropped", func(t *testing.T) {
+		queue := NewDelayedQueue[*_delay]()
+		queue.SetMaxSize(1, DropLatest)
+		earliest, _ := queue.Schedule(&_delay{value: 1, until: now.Add(time.Hour)})
+		latest, ok := queue.Schedule(&_delay{value: 2, until: now.Add(2 * time.Hour)})
+		assert.False(t, ok)
+		assert.False(t, latest.Pending())
+		assert.True(t, earliest.Cancel())
+		_, ok = queue.Schedule(&_delay{value: 3, until: now.Add(3 * time.Hour)})
+		assert.True(t, ok)
+		assert.Equal(t, int64(1), queue.Count())
+	})
+
+	t.Run("cleared", func(t *testing.T) {
+		handle, _ := queue.Schedule(&_delay{value: 4, until: now})
+		queue.Clear()
+		assert.False(t, handle.Pending())
+		assert.False(t, handle.Cancel())
+	})
+
+	t.Run("many cancelled", func(t *testing.T) {
+		queue := NewDelayedQueue[*_delay]()
+		var handles []*DelayedHandle[*_delay, int]
+		for i := range 100 {
+			handle, _ := queue.Schedule(&_delay{value: i, until: now.Add(time.Duration(i) * time.Second)})
+			handles = append(handles, handle)
+		}
+		for _, handle := range handles[10:] {
+			handle.Cancel()
+		}
+		assert.Equal(t, int64(10), queue.Count())
+		assert.Less(t, queue.items.size, int64(100))
+		assert.Len(t, queue.ToArray(), 10)
+	})
+}
+
 func TestDelayedQueue_Dequeue(t *testing.T) {
 	queue := NewDelayedQueue[*_delay]()
 	queue.Enqueue(&_delay{value: 1, until: time.Now().Add(time.Second)})
@@ -293,3 +373,130 @@ func TestDelayedQueue_UnmarshalJSON(t *testing.T) {
 
 	assert.ElementsMatch(t, expect, actual)
 }
+
+func TestDelayedQueue_EnqueueContext(t *testing.T) {
+	queue := NewDelayedQueue[*_delay, int]()
+	queue.SetMaxSize(1, DropReject)
+	assert.Nil(t, queue.EnqueueContext(context.Background(), &_delay{value: 1, until: time.Now()}))
+	assert.ErrorIs(t, queue.EnqueueContext(context.Background(), &_delay{value: 2, until: time.Now()}), ErrDropped)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, queue.EnqueueContext(ctx, &_delay{value: 3, until: time.Now()}), context.Canceled)
+	assert.EqualValues(t, 1, queue.Count())
+}
+
+func TestDelayedQueue_DequeueContext(t *testing.T) {
+	queue := NewDelayedQueue[*_delay, int]()
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := queue.DequeueContext(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	queue.Enqueue(&_delay{value: 1, until: time.Now().Add(time.Hour)})
+	ctx, cancel = context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+	}()
+	_, err = queue.DequeueContext(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	queue.Enqueue(&_delay{value: 2, until: time.Now().Add(50 * time.Millisecond)})
+	start := time.Now()
+	value, err := queue.DequeueContext(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 2, value.Value())
+	assert.GreaterOrEqual(t, time.Since(start), 40*time.Millisecond)
+}
+
+func TestDelayedQueue_Chan(t *testing.T) {
+	queue := NewDelayedQueue[*_delay, int]()
+	now := time.Now()
+	queue.Enqueue(&_delay{value: 2, until: now.Add(100 * time.Millisecond)})
+	queue.Enqueue(&_delay{value: 1, until: now.Add(50 * time.Millisecond)})
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := queue.Chan(ctx)
+	for _, expected := range []int{1, 2} {
+		select {
+		case item := <-ch:
+			assert.Equal(t, expected, item.Value())
+			assert.False(t, time.Now().Before(item.Until()))
+		case <-time.After(time.Second):
+			t.Fatal("no element received")
+		}
+	}
+
+	queue.Enqueue(&_delay{value: 3, until: time.Now()})
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	time.Sleep(50 * time.Millisecond)
+	_, ok := <-ch
+	assert.False(t, ok)
+	assert.EqualValues(t, 1, queue.Count())
+
+	t.Run("put back", func(t *testing.T) {
+		queue := NewDelayedQueue[*_delay, int]()
+		queue.SetMaxSize(1, DropLatest)
+		handle, _ := queue.Schedule(&_delay{value: 1, until: time.Now().Add(time.Hour)})
+		until := time.Now()
+		assert.True(t, handle.Reschedule(until))
+		ctx, cancel := context.WithCancel(context.Background())
+		queue.Chan(ctx)
+		assert.Eventually(t, func() bool {
+			return !handle.Pending()
+		}, time.Second, time.Millisecond)
+		assert.True(t, queue.Enqueue(&_delay{value: 2, until: time.Now().Add(time.Hour)}))
+		cancel()
+		assert.Eventually(t, handle.Pending, time.Second, time.Millisecond)
+		assert.Equal(t, until, handle.Until())
+		assert.EqualValues(t, 2, queue.Count())
+		item, ok := queue.TryDequeue()
+		assert.True(t, ok)
+		assert.Equal(t, 1, item.Value())
+	})
+}
+
+func TestDelayedQueue_Close(t *testing.T) {
+	queue := NewDelayedQueue[*_delay, int]()
+	consumer := make(chan bool)
+	go func() {
+		_, ok := queue.Dequeue()
+		consumer <- ok
+	}()
+	time.Sleep(10 * time.Millisecond)
+	assert.Nil(t, queue.Close())
+	assert.True(t, queue.IsClosed())
+	assert.False(t, <-consumer)
+	assert.False(t, queue.Enqueue(&_delay{value: 1, until: time.Now()}))
+	handle, ok := queue.Schedule(&_delay{value: 1, until: time.Now()})
+	assert.False(t, ok)
+	assert.False(t, handle.Pending())
+	assert.ErrorIs(t, queue.EnqueueContext(context.Background(), &_delay{value: 1, until: time.Now()}), ErrClosed)
+	assert.ErrorIs(t, json.Unmarshal([]byte(`[{"value":1}]`), queue), ErrClosed)
+	assert.True(t, queue.IsEmpty())
+
+	t.Run("drain", func(t *testing.T) {
+		queue := NewDelayedQueue[*_delay, int]()
+		queue.Enqueue(&_delay{value: 1, until: time.Now().Add(50 * time.Millisecond)})
+		queue.Enqueue(&_delay{value: 2, until: time.Now().Add(100 * time.Millisecond)})
+		ch := queue.Chan(context.Background())
+		assert.Nil(t, queue.Close())
+		for _, expected := range []int{1, 2} {
+			item, ok := <-ch
+			assert.True(t, ok)
+			assert.Equal(t, expected, item.Value())
+			assert.False(t, time.Now().Before(item.Until()))
+		}
+		select {
+		case _, ok := <-ch:
+			assert.False(t, ok)
+		case <-time.After(time.Second):
+			t.Fatal("channel not closed")
+		}
+		_, ok := queue.DequeueTimeout(time.Second)
+		assert.False(t, ok)
+		_, err := queue.DequeueContext(context.Background())
+		assert.ErrorIs(t, err, ErrClosed)
+	})
+}
@@ -0,0 +1,172 @@
+package queue
+
+import (
+	"fmt"
+	"io"
+	"iter"
+	"log/slog"
+	"slices"
+
+	"github.com/gopi-frame/collection/internal/preview"
+	"github.com/gopi-frame/collection/list"
+)
+
+// NewDeque new deque, the first value is the front element
+func NewDeque[E any](values ...E) *Deque[E] {
+	deque := new(Deque[E])
+	deque.items = list.NewLinkedList(values...)
+	return deque
+}
+
+// Deque double-ended queue, the elements are pushed and popped at both ends in O(1) time,
+// all methods are safe for concurrent use.
+// The callbacks run while the deque is locked, so they must not call methods of the same deque.
+type Deque[E any] struct {
+	items *list.LinkedList[E]
+	limit preview.Limit
+}
+
+// Count returns the size of deque
+func (d *Deque[E]) Count() int64 {
+	return d.items.Count()
+}
+
+// IsEmpty returns whether the deque is empty
+func (d *Deque[E]) IsEmpty() bool {
+	return d.items.IsEmpty()
+}
+
+// IsNotEmpty returns whether the deque is not empty
+func (d *Deque[E]) IsNotEmpty() bool {
+	return d.items.IsNotEmpty()
+}
+
+// Clear clears the deque
+func (d *Deque[E]) Clear() {
+	d.items.Clear()
+}
+
+// PushFront pushes the element to the front of the deque
+func (d *Deque[E]) PushFront(value E) {
+	d.items.Unshift(value)
+}
+
+// PushBack pushes the element to the back of the deque
+func (d *Deque[E]) PushBack(value E) {
+	d.items.Push(value)
+}
+
+// PopFront removes the front element and returns it, it returns zero value and false when the deque is empty
+func (d *Deque[E]) PopFront() (E, bool) {
+	return d.items.Shift()
+}
+
+// PopBack removes the back element and returns it, it returns zero value and false when the deque is empty
+func (d *Deque[E]) PopBack() (E, bool) {
+	return d.items.Pop()
+}
+
+// PeekFront returns the front element, it returns zero value and false when the deque is empty
+func (d *Deque[E]) PeekFront() (E, bool) {
+	return d.items.First()
+}
+
+// PeekBack returns the back element, it returns zero value and false when the deque is empty
+func (d *Deque[E]) PeekBack() (E, bool) {
+	return d.items.Last()
+}
+
+// Remove removes the specific element
+func (d *Deque[E]) Remove(value E) {
+	d.items.Remove(value)
+}
+
+// RemoveWhere removes elements which matches the callback
+func (d *Deque[E]) RemoveWhere(callback func(value E) bool) {
+	d.items.RemoveWhere(callback)
+}
+
+// All returns an iterator over the elements from the front to the back
+func (d *Deque[E]) All() iter.Seq[E] {
+	return slices.Values(d.ToArray())
+}
+
+// ToArray converts to array from the front to the back, the returned slice is a copy, so modifying it does not affect the collection
+func (d *Deque[E]) ToArray() []E {
+	return d.items.ToArray()
+}
+
+// ToJSON converts to json
+func (d *Deque[E]) ToJSON() ([]byte, error) {
+	return d.items.MarshalJSON()
+}
+
+// MarshalJSON implements [json.Marshaller]
+func (d *Deque[E]) MarshalJSON() ([]byte, error) {
+	return d.ToJSON()
+}
+
+// UnmarshalJSON implements [json.Unmarshaller]
+func (d *Deque[E]) UnmarshalJSON(data []byte) error {
+	return d.items.UnmarshalJSON(data)
+}
+
+// EncodeJSON writes the deque to w as a json array one element at a time, the deque is read locked while writing
+func (d *Deque[E]) EncodeJSON(w io.Writer) error {
+	return d.items.EncodeJSON(w)
+}
+
+// DecodeJSON reads a json array from r one element at a time, see UnmarshalJSON
+func (d *Deque[E]) DecodeJSON(r io.Reader) error {
+	return d.items.DecodeJSON(r)
+}
+
+// ToCBOR converts to cbor
+func (d *Deque[E]) ToCBOR() ([]byte, error) {
+	return d.items.MarshalCBOR()
+}
+
+// MarshalCBOR implements [cbor.Marshaler]
+func (d *Deque[E]) MarshalCBOR() ([]byte, error) {
+	return d.ToCBOR()
+}
+
+// UnmarshalCBOR implements [cbor.Unmarshaler]
+func (d *Deque[E]) UnmarshalCBOR(data []byte) error {
+	return d.items.UnmarshalCBOR(data)
+}
+
+// MarshalBinary implements [encoding.BinaryMarshaler], the elements are encoded by their own encoders
+func (d *Deque[E]) MarshalBinary() ([]byte, error) {
+	return d.items.MarshalBinary()
+}
+
+// UnmarshalBinary implements [encoding.BinaryUnmarshaler]
+func (d *Deque[E]) UnmarshalBinary(data []byte) error {
+	return d.items.UnmarshalBinary(data)
+}
+
+// SetPreviewLimit sets the number of elements shown by String, %v and LogValue, a negative limit shows all elements
+func (d *Deque[E]) SetPreviewLimit(limit int) {
+	d.limit.Set(limit)
+}
+
+// String converts to string, only the preview limit of elements are shown
+func (d *Deque[E]) String() string {
+	return d.format(d.limit.Get(preview.DefaultLimit))
+}
+
+// Format implements [fmt.Formatter], %v prints the preview as String does, %+v and %#v print all elements
+func (d *Deque[E]) Format(f fmt.State, verb rune) {
+	preview.Format(f, verb, d.format, d.limit.Get(preview.DefaultLimit))
+}
+
+func (d *Deque[E]) format(limit int) string {
+	items := d.ToArray()
+	return preview.Elements(fmt.Sprintf("Deque[%T](len=%d)", *new(E), len(items)), items, limit)
+}
+
+// LogValue implements [slog.LogValuer], only the preview limit of elements are logged
+func (d *Deque[E]) LogValue() slog.Value {
+	return preview.LogElements(fmt.Sprintf("Deque[%T]", *new(E)), d.ToArray(), d.limit.Get(preview.DefaultLimit))
+}
@@ -0,0 +1,76 @@
+package queue
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeque_Push(t *testing.T) {
+	deque := NewDeque(2, 3)
+	deque.PushFront(1)
+	deque.PushBack(4)
+	assert.Equal(t, int64(4), deque.Count())
+	assert.Equal(t, []int{1, 2, 3, 4}, deque.ToArray())
+}
+
+func TestDeque_Pop(t *testing.T) {
+	deque := NewDeque(1, 2, 3)
+	v, ok := deque.PopFront()
+	assert.True(t, ok)
+	assert.Equal(t, 1, v)
+	v, ok = deque.PopBack()
+	assert.True(t, ok)
+	assert.Equal(t, 3, v)
+	assert.Equal(t, []int{2}, deque.ToArray())
+	deque.Clear()
+	_, ok = deque.PopFront()
+	assert.False(t, ok)
+	_, ok = deque.PopBack()
+	assert.False(t, ok)
+	assert.True(t, deque.IsEmpty())
+}
+
+func TestDeque_Peek(t *testing.T) {
+	deque := NewDeque[int]()
+	_, ok := deque.PeekFront()
+	assert.False(t, ok)
+	_, ok = deque.PeekBack()
+	assert.False(t, ok)
+	deque.PushBack(1)
+	deque.PushBack(2)
+	v, _ := deque.PeekFront()
+	assert.Equal(t, 1, v)
+	v, _ = deque.PeekBack()
+	assert.Equal(t, 2, v)
+	assert.True(t, deque.IsNotEmpty())
+	assert.Equal(t, int64(2), deque.Count())
+}
+
+func TestDeque_RemoveWhere(t *testing.T) {
+	deque := NewDeque(1, 2, 3, 4)
+	deque.Remove(1)
+	deque.RemoveWhere(func(value int) bool {
+		return value%2 == 0
+	})
+	assert.Equal(t, []int{3}, deque.ToArray())
+}
+
+func TestDeque_JSON(t *testing.T) {
+	deque := NewDeque(1, 2, 3)
+	data, err := json.Marshal(deque)
+	assert.Nil(t, err)
+	assert.Equal(t, "[1,2,3]", string(data))
+	restored := NewDeque[int]()
+	assert.Nil(t, json.Unmarshal(data, restored))
+	assert.Equal(t, []int{1, 2, 3}, restored.ToArray())
+}
+
+func TestDeque_String(t *testing.T) {
+	deque := NewDeque(1, 2, 3, 4, 5, 6, 7)
+	pattern := regexp.MustCompile(fmt.Sprintf(`Deque\[int\]\(len=%d\)\{\n(\t\d+,\n){5}\t(\.){3}\n\}`, deque.Count()))
+	assert.True(t, pattern.MatchString(deque.String()))
+}
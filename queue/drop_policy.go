@@ -0,0 +1,86 @@
+package queue
+
+import "errors"
+
+// ErrDropped is returned by EnqueueContext of [DelayedQueue] when the drop policy drops the enqueued element
+var ErrDropped = errors.New("queue: element dropped by the drop policy")
+
+// DropPolicy decides which element a full [DelayedQueue] drops when an element is enqueued
+type DropPolicy int
+
+const (
+	// DropReject rejects the enqueued element
+	DropReject DropPolicy = iota
+	// DropLatest drops the element with the latest deadline, which may be the enqueued element,
+	// it is found among the leaves of the heap in O(n) time
+	DropLatest
+	// DropEarliest drops the element with the earliest deadline, which may be the enqueued element
+	DropEarliest
+)
+
+// String returns the name of the policy
+func (p DropPolicy) String() string {
+	switch p {
+	case DropReject:
+		return "reject"
+	case DropLatest:
+		return "drop-latest"
+	case DropEarliest:
+		return "drop-earliest"
+	}
+	return "unknown"
+}
+
+// SetMaxSize bounds the queue to the max size, an element enqueued while the queue is full is handled by the policy,
+// so the queue sheds work instead of growing without limit. A max size <= 0 makes the queue unbounded again.
+// The elements already in the queue are kept when the max size is lowered.
+func (q *DelayedQueue[Q, T]) SetMaxSize(maxSize int64, policy DropPolicy) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	q.maxSize = maxSize
+	q.policy = policy
+}
+
+// OnDrop sets the callback called with each element dropped by the policy and the policy as the reason,
+// so the dropped work can be accounted for. It runs while the queue is locked.
+func (q *DelayedQueue[Q, T]) OnDrop(callback func(item Q, reason DropPolicy)) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	q.onDrop = callback
+}
+
+// push enqueues the value and applies the drop policy when the queue is full,
+// it returns false when the value itself is rejected or dropped
+func (q *DelayedQueue[Q, T]) push(entry *delayedEntry[Q]) bool {
+	if q.maxSize > 0 && q.count() >= q.maxSize {
+		q.compact()
+		var dropped *delayedEntry[Q]
+		switch q.policy {
+		case DropLatest:
+			if !entry.until.Before(q.items.items[q.items.lastIndex()].until) {
+				return q.drop(entry)
+			}
+			dropped, _ = q.items.dequeueLast()
+		case DropEarliest:
+			if !entry.until.After(q.items.items[0].until) {
+				return q.drop(entry)
+			}
+			dropped, _ = q.items.dequeue()
+		default:
+			return q.drop(entry)
+		}
+		q.drop(dropped)
+	}
+	q.items.enqueue(entry)
+	q.takeLock.Broadcast()
+	return true
+}
+
+// drop marks the entry as done, calls the drop callback with its element and returns false
+func (q *DelayedQueue[Q, T]) drop(entry *delayedEntry[Q]) bool {
+	entry.done = true
+	if q.onDrop != nil {
+		q.onDrop(entry.value, q.policy)
+	}
+	return false
+}
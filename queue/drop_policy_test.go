@@ -0,0 +1,81 @@
+package queue
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDelayedQueue_SetMaxSize(t *testing.T) {
+	now := time.Now().Add(time.Hour)
+	newQueue := func(policy DropPolicy) (*DelayedQueue[*_delay, int], *[]int) {
+		queue := NewDelayedQueue[*_delay]()
+		queue.SetMaxSize(3, policy)
+		dropped := new([]int)
+		queue.OnDrop(func(item *_delay, reason DropPolicy) {
+			assert.Equal(t, policy, reason)
+			*dropped = append(*dropped, item.value)
+		})
+		for _, i := range []int{2, 1, 3} {
+			assert.True(t, queue.Enqueue(&_delay{i, now.Add(time.Duration(i) * time.Second)}))
+		}
+		return queue, dropped
+	}
+	values := func(queue *DelayedQueue[*_delay, int]) []int {
+		var values []int
+		for _, item := range queue.ToArray() {
+			values = append(values, item.value)
+		}
+		return values
+	}
+
+	t.Run("reject", func(t *testing.T) {
+		queue, dropped := newQueue(DropReject)
+		assert.False(t, queue.Enqueue(&_delay{0, now}))
+		assert.Equal(t, []int{0}, *dropped)
+		assert.Equal(t, int64(3), queue.Count())
+	})
+
+	t.Run("drop latest", func(t *testing.T) {
+		queue, dropped := newQueue(DropLatest)
+		assert.True(t, queue.Enqueue(&_delay{0, now}))
+		assert.False(t, queue.Enqueue(&_delay{4, now.Add(4 * time.Second)}))
+		assert.Equal(t, []int{3, 4}, *dropped)
+		assert.ElementsMatch(t, []int{0, 1, 2}, values(queue))
+	})
+
+	t.Run("drop earliest", func(t *testing.T) {
+		queue, dropped := newQueue(DropEarliest)
+		assert.True(t, queue.Enqueue(&_delay{4, now.Add(4 * time.Second)}))
+		assert.False(t, queue.Enqueue(&_delay{0, now}))
+		assert.Equal(t, []int{1, 0}, *dropped)
+		assert.ElementsMatch(t, []int{2, 3, 4}, values(queue))
+	})
+
+	t.Run("unbounded", func(t *testing.T) {
+		queue, dropped := newQueue(DropReject)
+		queue.SetMaxSize(0, DropReject)
+		assert.True(t, queue.Enqueue(&_delay{0, now}))
+		assert.Empty(t, *dropped)
+		assert.Equal(t, int64(4), queue.Count())
+	})
+
+	t.Run("snapshot", func(t *testing.T) {
+		queue, _ := newQueue(DropReject)
+		items, meta := queue.Snapshot()
+		assert.Equal(t, int64(3), meta.Cap)
+		restored := NewDelayedQueue[*_delay]()
+		restored.SetMaxSize(2, DropLatest)
+		assert.Nil(t, restored.Restore(items, meta))
+		assert.ElementsMatch(t, []int{1, 2, 3}, values(restored))
+		_, meta = restored.Snapshot()
+		assert.Equal(t, int64(3), meta.Cap)
+	})
+}
+
+func TestDropPolicy_String(t *testing.T) {
+	assert.Equal(t, "reject", DropReject.String())
+	assert.Equal(t, "drop-latest", DropLatest.String())
+	assert.Equal(t, "drop-earliest", DropEarliest.String())
+}
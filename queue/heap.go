@@ -0,0 +1,346 @@
+package queue
+
+import (
+	"cmp"
+	"container/heap"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"log/slog"
+	"slices"
+
+	"github.com/gopi-frame/collection/comparator"
+	"github.com/gopi-frame/collection/internal/preview"
+	"github.com/gopi-frame/contract"
+	"github.com/gopi-frame/exception"
+)
+
+// HeapInterface adapts the queue to [heap.Interface], so it can be used with the functions of container/heap.
+// The queue and the heap functions keep the same heap order, so both can be used on the queue.
+// Each method locks the queue, but the heap functions call several methods in a row,
+// so the queue must not be modified concurrently while they run.
+func (q *PriorityQueue[E]) HeapInterface() heap.Interface {
+	return &heapAdapter[E]{queue: q}
+}
+
+type heapAdapter[E any] struct {
+	queue *PriorityQueue[E]
+}
+
+func (h *heapAdapter[E]) Len() int {
+	return int(h.queue.Count())
+}
+
+func (h *heapAdapter[E]) Less(i, j int) bool {
+	h.queue.lock.RLock()
+	defer h.queue.lock.RUnlock()
+	return h.queue.less(int64(i), int64(j))
+}
+
+func (h *heapAdapter[E]) Swap(i, j int) {
+	h.queue.lock.Lock()
+	defer h.queue.lock.Unlock()
+	h.queue.swap(int64(i), int64(j))
+}
+
+// Push appends the value with its sequence number without restoring the heap order, as [heap.Interface] requires.
+// A full bounded queue evicts by its policy first, or drops the value when it is the worst.
+func (h *heapAdapter[E]) Push(value any) {
+	q := h.queue
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if q.bounded && q.size >= q.cap && !q.evict(value.(E)) {
+		return
+	}
+	q.items = append(q.items, value.(E))
+	if q.stable {
+		q.seqs = append(q.seqs, q.seq)
+	}
+	q.seq++
+	q.size++
+}
+
+// Pop removes the last value without restoring the heap order, as [heap.Interface] requires
+func (h *heapAdapter[E]) Pop() any {
+	q := h.queue
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	value := q.items[q.size-1]
+	q.truncate()
+	return value
+}
+
+// NewHeap new binary heap whose top is the least element in comparator order, the values are heapified in O(n)
+func NewHeap[E any](comparator contract.Comparator[E], values ...E) *Heap[E] {
+	h := &Heap[E]{comparator: comparator}
+	h.Init(slices.Clone(values))
+	return h
+}
+
+// NewHeapOrdered new binary heap of ordered elements, the smallest element is on the top
+func NewHeapOrdered[E cmp.Ordered](values ...E) *Heap[E] {
+	return NewHeap(comparator.Ordered[E](), values...)
+}
+
+// Heap binary min heap in comparator order with the index based operations of container/heap,
+// such as Fix and Remove at an index, which [PriorityQueue] hides behind its queue methods.
+// The elements are stored in heap order, the element at i is not greater than the elements at 2i+1 and 2i+2.
+// Heap is not safe for concurrent use, the indexes would change under the caller, see [PriorityQueue].
+type Heap[E any] struct {
+	items      []E
+	comparator contract.Comparator[E]
+	limit      preview.Limit
+}
+
+func (h *Heap[E]) less(i, j int) bool {
+	return h.comparator.Compare(h.items[i], h.items[j]) < 0
+}
+
+// up moves the element at the index up while it is less than its parent
+func (h *Heap[E]) up(index int) {
+	for index > 0 {
+		parent := (index - 1) / 2
+		if !h.less(index, parent) {
+			break
+		}
+		h.items[index], h.items[parent] = h.items[parent], h.items[index]
+		index = parent
+	}
+}
+
+// down moves the element at the index down while one of its children is less than it, it returns whether it moved
+func (h *Heap[E]) down(index int) bool {
+	start := index
+	for {
+		child := index*2 + 1
+		if child >= len(h.items) {
+			break
+		}
+		if right := child + 1; right < len(h.items) && h.less(right, child) {
+			child = right
+		}
+		if !h.less(child, index) {
+			break
+		}
+		h.items[index], h.items[child] = h.items[child], h.items[index]
+		index = child
+	}
+	return index > start
+}
+
+// check panics when the index is out of range
+func (h *Heap[E]) check(index int) {
+	if index < 0 || index >= len(h.items) {
+		panic(exception.NewRangeException(0, len(h.items)-1))
+	}
+}
+
+// Init replaces the elements of the heap with the values and heapifies them in O(n),
+// the heap takes the slice over as its storage, so the caller must not use it afterwards
+func (h *Heap[E]) Init(values []E) {
+	h.items = values
+	for index := len(h.items)/2 - 1; index >= 0; index-- {
+		h.down(index)
+	}
+}
+
+// Comparator returns the ordering of the heap
+func (h *Heap[E]) Comparator() contract.Comparator[E] {
+	return h.comparator
+}
+
+// Len returns the size of heap as an int, for the index based methods
+func (h *Heap[E]) Len() int {
+	return len(h.items)
+}
+
+// Count returns the size of heap
+func (h *Heap[E]) Count() int64 {
+	return int64(len(h.items))
+}
+
+// IsEmpty returns whether the heap is empty
+func (h *Heap[E]) IsEmpty() bool {
+	return len(h.items) == 0
+}
+
+// IsNotEmpty returns whether the heap is not empty
+func (h *Heap[E]) IsNotEmpty() bool {
+	return !h.IsEmpty()
+}
+
+// Get returns the element at the index in heap order, the top is at 0.
+// It panics when the index is out of range.
+func (h *Heap[E]) Get(index int) E {
+	h.check(index)
+	return h.items[index]
+}
+
+// Peek returns the top element, it returns zero value and false when the heap is empty
+func (h *Heap[E]) Peek() (E, bool) {
+	if len(h.items) == 0 {
+		return *new(E), false
+	}
+	return h.items[0], true
+}
+
+// Push pushes the elements in O(log n) time each
+func (h *Heap[E]) Push(values ...E) {
+	for _, value := range values {
+		h.items = append(h.items, value)
+		h.up(len(h.items) - 1)
+	}
+}
+
+// Pop removes the top element and returns it, it returns zero value and false when the heap is empty
+func (h *Heap[E]) Pop() (E, bool) {
+	if len(h.items) == 0 {
+		return *new(E), false
+	}
+	return h.Remove(0), true
+}
+
+// PushPop pushes the value and pops the top element, which is the value itself when it is not greater than the top.
+// It sifts down once instead of up and down, so it suits keeping the k greatest elements of a stream.
+func (h *Heap[E]) PushPop(value E) E {
+	if len(h.items) == 0 || h.comparator.Compare(value, h.items[0]) <= 0 {
+		return value
+	}
+	top := h.items[0]
+	h.items[0] = value
+	h.down(0)
+	return top
+}
+
+// Replace pops the top element and pushes the value, it returns the popped element.
+// Unlike PushPop the value is pushed even when it is less than the top.
+// It pushes the value and returns zero value and false when the heap is empty.
+func (h *Heap[E]) Replace(value E) (E, bool) {
+	if len(h.items) == 0 {
+		h.items = append(h.items, value)
+		return *new(E), false
+	}
+	top := h.items[0]
+	h.items[0] = value
+	h.down(0)
+	return top, true
+}
+
+// Fix restores the heap order after the element at the index is changed in place, such as through a pointer,
+// it takes O(log n) time. It panics when the index is out of range.
+func (h *Heap[E]) Fix(index int) {
+	h.check(index)
+	if !h.down(index) {
+		h.up(index)
+	}
+}
+
+// Update replaces the element at the index with the value and moves it to its new place in O(log n) time.
+// It panics when the index is out of range.
+func (h *Heap[E]) Update(index int, value E) {
+	h.check(index)
+	h.items[index] = value
+	h.Fix(index)
+}
+
+// Remove removes the element at the index and returns it in O(log n) time.
+// It panics when the index is out of range.
+func (h *Heap[E]) Remove(index int) E {
+	h.check(index)
+	last := len(h.items) - 1
+	value := h.items[index]
+	h.items[index] = h.items[last]
+	h.items[last] = *new(E)
+	h.items = h.items[:last]
+	if index < last {
+		h.Fix(index)
+	}
+	return value
+}
+
+// Meld moves all elements of the other heap into the heap, which leaves the other heap empty.
+// The elements are appended and heapified in O(n+m) time, or pushed one by one when the other heap is much smaller.
+// The other heap must have the same ordering.
+func (h *Heap[E]) Meld(other *Heap[E]) {
+	if h == other {
+		return
+	}
+	items := other.items
+	other.items = nil
+	if len(items) < len(h.items)/8 {
+		h.Push(items...)
+		return
+	}
+	h.Init(append(h.items, items...))
+}
+
+// Clear clears the heap
+func (h *Heap[E]) Clear() {
+	h.items = nil
+}
+
+// Clone returns a copy of the heap with the same ordering
+func (h *Heap[E]) Clone() *Heap[E] {
+	return &Heap[E]{items: slices.Clone(h.items), comparator: h.comparator}
+}
+
+// Sorted returns the elements in comparator order in O(n log n) time, the heap is unchanged
+func (h *Heap[E]) Sorted() []E {
+	items := slices.Clone(h.items)
+	slices.SortFunc(items, h.comparator.Compare)
+	return items
+}
+
+// All returns an iterator over the elements in heap order
+func (h *Heap[E]) All() iter.Seq[E] {
+	return slices.Values(h.items)
+}
+
+// ToArray converts to array in heap order, the returned slice is a copy, so modifying it does not affect the collection
+func (h *Heap[E]) ToArray() []E {
+	return slices.Clone(h.items)
+}
+
+// ToJSON converts to json, the elements are encoded in heap order
+func (h *Heap[E]) ToJSON() ([]byte, error) {
+	return json.Marshal(h.items)
+}
+
+// MarshalJSON implements [json.Marshaller]
+func (h *Heap[E]) MarshalJSON() ([]byte, error) {
+	return h.ToJSON()
+}
+
+// UnmarshalJSON implements [json.Unmarshaller], the elements are heapified, so the heap must be created with a comparator
+func (h *Heap[E]) UnmarshalJSON(data []byte) error {
+	items := make([]E, 0)
+	if err := json.Unmarshal(data, &items); err != nil {
+		return err
+	}
+	h.Init(items)
+	return nil
+}
+
+// SetPreviewLimit sets the number of elements shown by String, %v and LogValue, a negative limit shows all elements
+func (h *Heap[E]) SetPreviewLimit(limit int) {
+	h.limit.Set(limit)
+}
+
+// String converts to string, only the preview limit of elements are shown
+func (h *Heap[E]) String() string {
+	return h.format(h.limit.Get(preview.DefaultLimit))
+}
+
+// Format implements [fmt.Formatter], %v prints the preview as String does, %+v and %#v print all elements
+func (h *Heap[E]) Format(f fmt.State, verb rune) {
+	preview.Format(f, verb, h.format, h.limit.Get(preview.DefaultLimit))
+}
+
+func (h *Heap[E]) format(limit int) string {
+	return preview.Elements(fmt.Sprintf("Heap[%T](len=%d)", *new(E), len(h.items)), h.items, limit)
+}
+
+// LogValue implements [slog.LogValuer], only the preview limit of elements are logged
+func (h *Heap[E]) LogValue() slog.Value {
+	return preview.LogElements(fmt.Sprintf("Heap[%T]", *new(E)), h.items, h.limit.Get(preview.DefaultLimit))
+}
@@ -0,0 +1,243 @@
+package queue
+
+import (
+	"container/heap"
+	"encoding/json"
+	"math/rand/v2"
+	"slices"
+	"testing"
+
+	"github.com/gopi-frame/collection/comparator"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPriorityQueue_HeapInterface(t *testing.T) {
+	queue := NewPriorityQueue[int](_comparator{}, 5, 3)
+	h := queue.HeapInterface()
+	heap.Push(h, 4)
+	heap.Push(h, 1)
+	assert.EqualValues(t, 4, queue.Count())
+
+	value, ok := queue.Dequeue()
+	assert.True(t, ok)
+	assert.Equal(t, 1, value)
+
+	queue.Enqueue(2)
+	assert.Equal(t, 2, heap.Pop(h))
+	assert.Equal(t, 3, heap.Pop(h))
+
+	heap.Push(h, 6)
+	heap.Remove(h, 0)
+	var values []int
+	for !queue.IsEmpty() {
+		value, _ := queue.Dequeue()
+		values = append(values, value)
+	}
+	assert.Equal(t, []int{5, 6}, values)
+}
+
+func TestPriorityQueue_HeapInterface_Stable(t *testing.T) {
+	type job struct {
+		priority int
+		name     string
+	}
+	queue := NewStablePriorityQueue(comparator.Func[job](func(a, b job) int {
+		return a.priority - b.priority
+	}), job{2, "a"})
+	h := queue.HeapInterface()
+	heap.Push(h, job{1, "b"})
+	heap.Push(h, job{2, "c"})
+	heap.Push(h, job{1, "d"})
+	assert.Equal(t, job{1, "b"}, heap.Pop(h))
+	var names []string
+	for queue.IsNotEmpty() {
+		value, _ := queue.Dequeue()
+		names = append(names, value.name)
+	}
+	assert.Equal(t, []string{"d", "a", "c"}, names)
+}
+
+func TestPriorityQueue_HeapInterface_Bounded(t *testing.T) {
+	t.Run("keep smallest", func(t *testing.T) {
+		queue := NewBoundedPriorityQueue[int](_comparator{}, 3, KeepSmallest)
+		h := queue.HeapInterface()
+		for _, value := range []int{5, 1, 4, 2, 9} {
+			heap.Push(h, value)
+		}
+		assert.EqualValues(t, 3, queue.Count())
+		assert.Equal(t, []int{1, 2, 4}, drain[int](queue))
+	})
+
+	t.Run("keep largest", func(t *testing.T) {
+		queue := NewBoundedPriorityQueue[int](_comparator{}, 3, KeepLargest)
+		h := queue.HeapInterface()
+		for _, value := range []int{5, 1, 4, 2, 0} {
+			heap.Push(h, value)
+		}
+		assert.Equal(t, 2, heap.Pop(h))
+		heap.Push(h, 3)
+		assert.Equal(t, []int{3, 4, 5}, drain[int](queue))
+	})
+}
+
+func TestNewHeap(t *testing.T) {
+	values := []int{5, 3, 8, 1, 9, 2}
+	h := NewHeap[int](_comparator{}, values...)
+	assert.Equal(t, []int{5, 3, 8, 1, 9, 2}, values)
+	assert.Equal(t, 6, h.Len())
+	assert.Equal(t, []int{1, 2, 3, 5, 8, 9}, h.Sorted())
+	top, ok := h.Peek()
+	assert.True(t, ok)
+	assert.Equal(t, 1, top)
+}
+
+func TestHeap_Init(t *testing.T) {
+	h := NewHeapOrdered[int]()
+	h.Init([]int{4, 2, 7, 1})
+	var values []int
+	for h.IsNotEmpty() {
+		value, _ := h.Pop()
+		values = append(values, value)
+	}
+	assert.Equal(t, []int{1, 2, 4, 7}, values)
+	_, ok := h.Pop()
+	assert.False(t, ok)
+}
+
+func TestHeap_Push(t *testing.T) {
+	h := NewHeapOrdered[int]()
+	h.Push(3, 1, 2)
+	assert.Equal(t, int64(3), h.Count())
+	assert.Equal(t, 1, h.Get(0))
+	assert.Panics(t, func() {
+		h.Get(3)
+	})
+}
+
+func TestHeap_PushPop(t *testing.T) {
+	h := NewHeapOrdered(3, 5, 7)
+	assert.Equal(t, 1, h.PushPop(1))
+	assert.Equal(t, 3, h.PushPop(3))
+	assert.Equal(t, 3, h.PushPop(6))
+	assert.Equal(t, []int{5, 6, 7}, h.Sorted())
+	assert.Equal(t, 4, NewHeapOrdered[int]().PushPop(4))
+}
+
+func TestHeap_Replace(t *testing.T) {
+	h := NewHeapOrdered(3, 5, 7)
+	top, ok := h.Replace(1)
+	assert.True(t, ok)
+	assert.Equal(t, 3, top)
+	assert.Equal(t, []int{1, 5, 7}, h.Sorted())
+
+	empty := NewHeapOrdered[int]()
+	_, ok = empty.Replace(4)
+	assert.False(t, ok)
+	assert.Equal(t, []int{4}, empty.ToArray())
+}
+
+type task struct {
+	name     string
+	priority int
+}
+
+func TestHeap_Fix(t *testing.T) {
+	h := NewHeap(comparator.ByKey(func(t *task) int { return t.priority }), &task{"a", 1}, &task{"b", 5}, &task{"c", 9})
+	index := slices.IndexFunc(h.ToArray(), func(t *task) bool { return t.name == "c" })
+	h.Get(index).priority = 0
+	h.Fix(index)
+	top, _ := h.Peek()
+	assert.Equal(t, "c", top.name)
+
+	h.Get(0).priority = 10
+	h.Fix(0)
+	top, _ = h.Peek()
+	assert.Equal(t, "a", top.name)
+	assert.Panics(t, func() {
+		h.Fix(-1)
+	})
+}
+
+func TestHeap_Update(t *testing.T) {
+	h := NewHeapOrdered(1, 4, 6, 8)
+	h.Update(h.Len()-1, 0)
+	assert.Equal(t, 0, h.Get(0))
+	h.Update(0, 10)
+	assert.Equal(t, []int{1, 4, 6, 10}, h.Sorted())
+}
+
+func TestHeap_Remove(t *testing.T) {
+	h := NewHeapOrdered(1, 2, 3, 4, 5, 6, 7)
+	for h.Len() > 0 {
+		index := rand.IntN(h.Len())
+		value := h.Get(index)
+		assert.Equal(t, value, h.Remove(index))
+		assert.True(t, isHeap(h))
+	}
+	assert.Panics(t, func() {
+		h.Remove(0)
+	})
+}
+
+func TestHeap_Meld(t *testing.T) {
+	h := NewHeapOrdered(5, 1, 9)
+	other := NewHeapOrdered(4, 2, 8, 6)
+	h.Meld(other)
+	assert.True(t, other.IsEmpty())
+	assert.Equal(t, []int{1, 2, 4, 5, 6, 8, 9}, h.Sorted())
+	assert.True(t, isHeap(h))
+
+	t.Run("small other", func(t *testing.T) {
+		h := NewHeapOrdered[int]()
+		for value := range 100 {
+			h.Push(100 - value)
+		}
+		h.Meld(NewHeapOrdered(0, 50))
+		assert.Equal(t, 102, h.Len())
+		assert.True(t, isHeap(h))
+		assert.Equal(t, 0, h.Get(0))
+	})
+
+	t.Run("itself", func(t *testing.T) {
+		h.Meld(h)
+		assert.Equal(t, 7, h.Len())
+	})
+}
+
+func TestHeap_Clone(t *testing.T) {
+	h := NewHeapOrdered(2, 1)
+	clone := h.Clone()
+	clone.Pop()
+	assert.Equal(t, 2, h.Len())
+	assert.Equal(t, 1, clone.Len())
+}
+
+func TestHeap_All(t *testing.T) {
+	h := NewHeapOrdered(3, 1, 2)
+	assert.ElementsMatch(t, []int{1, 2, 3}, slices.Collect(h.All()))
+	h.Clear()
+	assert.True(t, h.IsEmpty())
+}
+
+func TestHeap_UnmarshalJSON(t *testing.T) {
+	data, err := json.Marshal(NewHeapOrdered(3, 1, 2))
+	assert.NoError(t, err)
+	h := NewHeapOrdered[int]()
+	assert.NoError(t, json.Unmarshal(data, h))
+	assert.Equal(t, []int{1, 2, 3}, h.Sorted())
+	assert.Error(t, json.Unmarshal([]byte(`{}`), h))
+}
+
+func TestHeap_String(t *testing.T) {
+	assert.Equal(t, "Heap[int](len=2){\n\t1,\n\t2,\n}", NewHeapOrdered(2, 1).String())
+}
+
+// isHeap returns whether no element of the heap is less than its parent
+func isHeap[E any](h *Heap[E]) bool {
+	for index := 1; index < h.Len(); index++ {
+		if h.Comparator().Compare(h.Get(index), h.Get((index-1)/2)) < 0 {
+			return false
+		}
+	}
+	return true
+}
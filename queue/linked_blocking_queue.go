@@ -1,73 +1,107 @@
 package queue
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"strings"
+	"io"
+	"iter"
+	"log/slog"
+	"slices"
 	"sync"
 	"time"
 
+	"github.com/fxamacker/cbor/v2"
+	"github.com/gopi-frame/collection/internal/codec"
+	"github.com/gopi-frame/collection/internal/jsonstream"
+	"github.com/gopi-frame/collection/internal/preview"
 	"github.com/gopi-frame/collection/list"
-	"github.com/gopi-frame/contract"
-	"github.com/gopi-frame/exception"
-	"github.com/gopi-frame/future"
-	"github.com/gopi-frame/util/catch"
 )
 
 // NewLinkedBlockingQueue new linked blocking queue
 func NewLinkedBlockingQueue[E any](cap int) *LinkedBlockingQueue[E] {
 	queue := new(LinkedBlockingQueue[E])
 	queue.items = list.NewLinkedList[E]()
-	queue.takeLock = sync.NewCond(queue.items)
-	queue.putLock = sync.NewCond(queue.items)
+	queue.takeLock = sync.NewCond(&queue.lock)
+	queue.putLock = sync.NewCond(&queue.lock)
 	queue.cap = cap
 	return queue
 }
 
-// LinkedBlockingQueue linked blocking queue
+// LinkedBlockingQueue linked blocking queue, all methods are safe for concurrent use.
+// The callbacks run while the queue is locked, so they must not call methods of the same queue.
 type LinkedBlockingQueue[E any] struct {
+	lock     sync.RWMutex
 	items    *list.LinkedList[E]
 	cap      int
 	takeLock *sync.Cond
 	putLock  *sync.Cond
+	closed   bool
+	limit    preview.Limit
 }
 
 // Count returns the size of queue
 func (q *LinkedBlockingQueue[E]) Count() int64 {
-	q.items.RLock()
-	defer q.items.RUnlock()
+	q.lock.RLock()
+	defer q.lock.RUnlock()
 	return q.items.Count()
 }
 
 // IsEmpty returns whether the queue is empty
 func (q *LinkedBlockingQueue[E]) IsEmpty() bool {
-	if q.items.TryRLock() {
-		defer q.items.RUnlock()
-	}
-	return q.items.IsEmpty()
+	return q.Count() == 0
 }
 
 // IsNotEmpty returns whether the queue is not empty
 func (q *LinkedBlockingQueue[E]) IsNotEmpty() bool {
-	if q.items.TryRLock() {
-		defer q.items.RUnlock()
-	}
-	return q.items.IsNotEmpty()
+	return !q.IsEmpty()
+}
+
+// Close closes the queue, so it works as the end of a stream for a graceful shutdown:
+// the enqueues return false, or [ErrClosed] from EnqueueContext, and the blocked producers are woken up with the same result,
+// while the dequeues take the remaining elements and return zero value and false, or [ErrClosed], once the queue is drained.
+// Closing it again does nothing, it always returns nil and implements [io.Closer].
+func (q *LinkedBlockingQueue[E]) Close() error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	q.closed = true
+	q.takeLock.Broadcast()
+	q.putLock.Broadcast()
+	return nil
+}
+
+// IsClosed returns whether the queue is closed
+func (q *LinkedBlockingQueue[E]) IsClosed() bool {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+	return q.closed
 }
 
 // Clear clears the queue
 func (q *LinkedBlockingQueue[E]) Clear() {
-	if q.items.TryLock() {
-		defer q.items.Unlock()
-	}
+	q.lock.Lock()
+	defer q.lock.Unlock()
 	q.items.Clear()
+	q.putLock.Broadcast()
+}
+
+// EnableNodePool makes the queue reuse its nodes through a [sync.Pool], see [list.LinkedList.EnableNodePool]
+func (q *LinkedBlockingQueue[E]) EnableNodePool() {
+	q.items.EnableNodePool()
+}
+
+// Release clears the queue and gives its nodes back to the pool, it works as Clear when the pool is not enabled
+func (q *LinkedBlockingQueue[E]) Release() {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	q.items.Release()
+	q.putLock.Broadcast()
 }
 
 // Peek returns the first element of the queue
 func (q *LinkedBlockingQueue[E]) Peek() (E, bool) {
-	if q.items.TryRLock() {
-		defer q.items.RUnlock()
-	}
+	q.lock.RLock()
+	defer q.lock.RUnlock()
 	if q.items.IsEmpty() {
 		return *new(E), false
 	}
@@ -76,144 +110,255 @@ func (q *LinkedBlockingQueue[E]) Peek() (E, bool) {
 
 // TryEnqueue enqueues a new element into the queue, it will return false if the size is up to the capacity
 func (q *LinkedBlockingQueue[E]) TryEnqueue(value E) bool {
-	if q.items.TryLock() {
-		defer q.items.Unlock()
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if q.closed || q.full() {
+		return false
+	}
+	q.push(value)
+	return true
+}
+
+// TryEnqueueAll enqueues all the values at once, or none of them when they do not fit into the remaining capacity,
+// so the parts of a message are never split by a full queue
+func (q *LinkedBlockingQueue[E]) TryEnqueueAll(values ...E) bool {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if q.closed {
+		return false
 	}
-	if int64(q.cap) == q.items.Count() {
+	if q.cap >= 0 && int64(q.cap)-q.items.Count() < int64(len(values)) {
 		return false
 	}
-	q.items.Push(value)
-	q.takeLock.Broadcast()
+	for _, value := range values {
+		q.push(value)
+	}
 	return true
 }
 
+// EnqueueAll enqueues the values in order, it takes as many as fit into the remaining capacity at each lock
+// and blocks while the queue is full until all of them are enqueued, so consumers may dequeue the first values before the rest
+func (q *LinkedBlockingQueue[E]) EnqueueAll(values ...E) bool {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	for len(values) > 0 {
+		for q.full() && !q.closed {
+			q.putLock.Wait()
+		}
+		if q.closed {
+			return false
+		}
+		for len(values) > 0 && !q.full() {
+			q.push(values[0])
+			values = values[1:]
+		}
+	}
+	return true
+}
+
+// DrainTo dequeues up to limit elements into dst under one lock and returns the number of them, it never blocks.
+// A limit less than 0 or greater than len(dst) is len(dst).
+func (q *LinkedBlockingQueue[E]) DrainTo(dst []E, limit int) int {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if limit < 0 || limit > len(dst) {
+		limit = len(dst)
+	}
+	count := 0
+	for count < limit && q.items.IsNotEmpty() {
+		dst[count], _ = q.shift()
+		count++
+	}
+	return count
+}
+
 // TryDequeue dequeues the first element of the queue and returns it.
 // The empty value of the element type and false will be returned when the queue is empty
 func (q *LinkedBlockingQueue[E]) TryDequeue() (E, bool) {
-	if q.items.TryLock() {
-		defer q.items.Unlock()
-	}
+	q.lock.Lock()
+	defer q.lock.Unlock()
 	if q.items.IsEmpty() {
 		return *new(E), false
 	}
-	value, ok := q.items.Shift()
-	q.putLock.Broadcast()
-	return value, ok
+	return q.shift()
 }
 
 // Enqueue enqueues a new element into the queue, it will block if the size is up to capacity
+// and returns false when the queue is closed
 func (q *LinkedBlockingQueue[E]) Enqueue(value E) bool {
-	if q.items.TryLock() {
-		defer q.items.Unlock()
-	}
-	for int64(q.cap) == q.items.Count() {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	for q.full() && !q.closed {
 		q.putLock.Wait()
 	}
-	q.items.Push(value)
-	q.takeLock.Broadcast()
+	if q.closed {
+		return false
+	}
+	q.push(value)
 	return true
 }
 
 // Dequeue dequeues the first element of queue, it will block if the queue is empty
+// and returns zero value and false once the queue is closed and drained
 func (q *LinkedBlockingQueue[E]) Dequeue() (E, bool) {
-	if q.items.TryLock() {
-		defer q.items.Unlock()
-	}
-	for q.items.IsEmpty() {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	for q.items.IsEmpty() && !q.closed {
 		q.takeLock.Wait()
 	}
-	value, ok := q.items.Shift()
-	q.putLock.Broadcast()
-	return value, ok
+	if q.items.IsEmpty() {
+		return *new(E), false
+	}
+	return q.shift()
 }
 
 // EnqueueTimeout enqueues element into the queue.
 // It will block when the size of queue is up to capacity.
 // It will return true if the element is successfully enqueued or false when time is out
 func (q *LinkedBlockingQueue[E]) EnqueueTimeout(value E, duration time.Duration) bool {
-	var ok bool
-	catch.Try(func() {
-		done := make(chan struct{})
-		ok = future.Timeout(func() bool {
-			future.Void(func() {
-				q.items.Lock()
-				defer q.items.Unlock()
-				for int64(q.cap) == q.items.Count() {
-					q.putLock.Wait()
-				}
-				done <- struct{}{}
-			})
-			<-done
-			q.items.Push(value)
-			q.takeLock.Broadcast()
-			return true
-		}, duration).Complete(func() {
-			close(done)
-		}).Await()
-	}).Catch(new(exception.TimeoutException), func(err error) {
-	}).Run()
-	return ok
+	deadline := time.Now().Add(duration)
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	for q.full() && !q.closed {
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return false
+		}
+		waitFor(q.putLock, remaining)
+	}
+	if q.closed {
+		return false
+	}
+	q.push(value)
+	return true
 }
 
 // DequeueTimeout removes the first element and returns it.
 // It will block when the queue is empty.
 // It will return zero value and false when time is out
 func (q *LinkedBlockingQueue[E]) DequeueTimeout(duration time.Duration) (E, bool) {
-	var value E
-	var ok bool
-	catch.Try(func() {
-		done := make(chan struct{})
-		future.Timeout(func() bool {
-			future.Void(func() {
-				q.items.Lock()
-				defer q.items.Unlock()
-				for q.items.IsEmpty() {
-					q.takeLock.Wait()
-				}
-				done <- struct{}{}
-			})
-			<-done
-			value, ok = q.items.Shift()
-			q.putLock.Broadcast()
-			return ok
-		}, duration).Complete(func() {
-			close(done)
-		}).Await()
-	}).Catch(new(exception.TimeoutException), func(err error) {
-	}).Run()
+	deadline := time.Now().Add(duration)
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	for q.items.IsEmpty() && !q.closed {
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return *new(E), false
+		}
+		waitFor(q.takeLock, remaining)
+	}
+	if q.items.IsEmpty() {
+		return *new(E), false
+	}
+	return q.shift()
+}
+
+// EnqueueContext enqueues element into the queue.
+// It will block when the size of queue is up to capacity.
+// It returns nil when the element is successfully enqueued or the error of ctx when ctx is done first
+func (q *LinkedBlockingQueue[E]) EnqueueContext(ctx context.Context, value E) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if err := waitContext(ctx, q.putLock, func() bool {
+		return q.full() && !q.closed
+	}); err != nil {
+		return err
+	}
+	if q.closed {
+		return ErrClosed
+	}
+	q.push(value)
+	return nil
+}
+
+// DequeueContext removes the first element and returns it.
+// It will block when the queue is empty.
+// It returns zero value and the error of ctx when ctx is done first
+func (q *LinkedBlockingQueue[E]) DequeueContext(ctx context.Context) (E, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if err := waitContext(ctx, q.takeLock, func() bool {
+		return q.items.IsEmpty() && !q.closed
+	}); err != nil {
+		return *new(E), err
+	}
+	if q.items.IsEmpty() {
+		return *new(E), ErrClosed
+	}
+	value, _ := q.shift()
+	return value, nil
+}
+
+func (q *LinkedBlockingQueue[E]) full() bool {
+	return q.items.Count() == int64(q.cap)
+}
+
+func (q *LinkedBlockingQueue[E]) push(value E) {
+	q.items.Push(value)
+	q.takeLock.Broadcast()
+}
+
+func (q *LinkedBlockingQueue[E]) shift() (E, bool) {
+	value, ok := q.items.Shift()
+	q.putLock.Broadcast()
 	return value, ok
 }
 
 // Remove removes the specific element
 func (q *LinkedBlockingQueue[E]) Remove(value E) {
-	if q.items.TryLock() {
-		defer q.items.Unlock()
-	}
+	q.lock.Lock()
+	defer q.lock.Unlock()
 	q.items.Remove(value)
+	q.putLock.Broadcast()
 }
 
 // RemoveWhere removes elements which matches the callback
 func (q *LinkedBlockingQueue[E]) RemoveWhere(callback func(E) bool) {
-	if q.items.TryLock() {
-		defer q.items.Unlock()
-	}
+	q.lock.Lock()
+	defer q.lock.Unlock()
 	q.items.RemoveWhere(callback)
+	q.putLock.Broadcast()
 }
 
-// ToArray converts to array
-func (q *LinkedBlockingQueue[E]) ToArray() []E {
-	if q.items.TryRLock() {
-		defer q.items.RUnlock()
+// Snapshot returns the pending elements and the capacity of the queue, both captured at the same time,
+// so they can be persisted on shutdown and given back to Restore on startup
+func (q *LinkedBlockingQueue[E]) Snapshot() ([]E, SnapshotMeta) {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+	return q.items.ToArray(), SnapshotMeta{Cap: int64(q.cap)}
+}
+
+// Restore replaces the elements and the capacity of the queue with a snapshot, blocked callers are woken up,
+// so they see the restored elements. It returns an error and leaves the queue unchanged when the elements exceed the capacity.
+func (q *LinkedBlockingQueue[E]) Restore(items []E, meta SnapshotMeta) error {
+	if err := checkRestore(len(items), meta.Cap); err != nil {
+		return err
 	}
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	q.items.Clear()
+	q.items.Push(items...)
+	q.cap = int(meta.Cap)
+	q.takeLock.Broadcast()
+	q.putLock.Broadcast()
+	return nil
+}
+
+// All returns an iterator over the elements
+func (q *LinkedBlockingQueue[E]) All() iter.Seq[E] {
+	return slices.Values(q.ToArray())
+}
+
+// ToArray converts to array, the returned slice is a copy, so modifying it does not affect the collection
+func (q *LinkedBlockingQueue[E]) ToArray() []E {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
 	return q.items.ToArray()
 }
 
 // ToJSON converts to json
 func (q *LinkedBlockingQueue[E]) ToJSON() ([]byte, error) {
-	if q.items.TryRLock() {
-		defer q.items.RUnlock()
-	}
-	return q.items.MarshalJSON()
+	return json.Marshal(q.ToArray())
 }
 
 // MarshalJSON implements [json.Marshaller]
@@ -221,48 +366,121 @@ func (q *LinkedBlockingQueue[E]) MarshalJSON() ([]byte, error) {
 	return q.ToJSON()
 }
 
-// UnmarshalJSON implements [json.Unmarshaller]
+// UnmarshalJSON implements [json.Unmarshaller], it blocks while the queue is full and returns [ErrClosed] once the queue is closed
 func (q *LinkedBlockingQueue[E]) UnmarshalJSON(data []byte) error {
-	if q.items.TryLock() {
-		defer q.items.Unlock()
-	}
 	values := make([]E, 0)
 	if err := json.Unmarshal(data, &values); err != nil {
 		return err
 	}
+	q.lock.Lock()
+	defer q.lock.Unlock()
 	for _, value := range values {
-		for q.items.Count() == int64(q.cap) {
+		for q.full() && !q.closed {
 			q.putLock.Wait()
 		}
-		q.items.Push(value)
-		q.takeLock.Broadcast()
+		if q.closed {
+			return ErrClosed
+		}
+		q.push(value)
 	}
 	return nil
 }
 
-// String converts to string
-func (q *LinkedBlockingQueue[E]) String() string {
-	if q.items.TryRLock() {
-		defer q.items.RUnlock()
-	}
-	str := new(strings.Builder)
-	str.WriteString(fmt.Sprintf("LinkedBlockingQueue[%T](len=%d)", *new(E), q.items.Count()))
-	str.WriteByte('{')
-	str.WriteByte('\n')
-	q.items.Each(func(index int, value E) bool {
-		str.WriteByte('\t')
-		if v, ok := any(value).(contract.Stringable); ok {
-			str.WriteString(v.String())
-		} else {
-			str.WriteString(fmt.Sprintf("%v", value))
+// EncodeJSON writes the queue to w as a json array one element at a time, the queue is read locked while writing
+func (q *LinkedBlockingQueue[E]) EncodeJSON(w io.Writer) error {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+	return q.items.EncodeJSON(w)
+}
+
+// DecodeJSON reads a json array from r one element at a time and enqueues each element after the existing ones,
+// it blocks while the queue is full, so the elements can be consumed while they are decoded.
+// It stops and returns [ErrClosed] once the queue is closed.
+func (q *LinkedBlockingQueue[E]) DecodeJSON(r io.Reader) error {
+	return jsonstream.DecodeFunc(r, func(value E) error {
+		if !q.Enqueue(value) {
+			return ErrClosed
 		}
-		str.WriteByte(',')
-		str.WriteByte('\n')
-		return index < 4
+		return nil
 	})
-	if q.items.Count() > 5 {
-		str.WriteString("\t...\n")
+}
+
+// ToCBOR converts to cbor
+func (q *LinkedBlockingQueue[E]) ToCBOR() ([]byte, error) {
+	return cbor.Marshal(q.ToArray())
+}
+
+// MarshalCBOR implements [cbor.Marshaler]
+func (q *LinkedBlockingQueue[E]) MarshalCBOR() ([]byte, error) {
+	return q.ToCBOR()
+}
+
+// UnmarshalCBOR implements [cbor.Unmarshaler], it blocks while the queue is full and returns [ErrClosed] once the queue is closed
+func (q *LinkedBlockingQueue[E]) UnmarshalCBOR(data []byte) error {
+	values := make([]E, 0)
+	if err := cbor.Unmarshal(data, &values); err != nil {
+		return err
 	}
-	str.WriteByte('}')
-	return str.String()
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	for _, value := range values {
+		for q.full() && !q.closed {
+			q.putLock.Wait()
+		}
+		if q.closed {
+			return ErrClosed
+		}
+		q.push(value)
+	}
+	return nil
+}
+
+// MarshalBinary implements [encoding.BinaryMarshaler], the elements are encoded by their own encoders
+func (q *LinkedBlockingQueue[E]) MarshalBinary() ([]byte, error) {
+	return codec.EncodeValues(q.ToArray())
+}
+
+// UnmarshalBinary implements [encoding.BinaryUnmarshaler], it blocks while the queue is full and returns [ErrClosed] once the queue is closed
+func (q *LinkedBlockingQueue[E]) UnmarshalBinary(data []byte) error {
+	values, err := codec.DecodeValues[E](data)
+	if err != nil {
+		return err
+	}
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	for _, value := range values {
+		for q.full() && !q.closed {
+			q.putLock.Wait()
+		}
+		if q.closed {
+			return ErrClosed
+		}
+		q.push(value)
+	}
+	return nil
+}
+
+// SetPreviewLimit sets the number of elements shown by String, %v and LogValue, a negative limit shows all elements
+func (q *LinkedBlockingQueue[E]) SetPreviewLimit(limit int) {
+	q.limit.Set(limit)
+}
+
+// String converts to string, only the preview limit of elements are shown
+func (q *LinkedBlockingQueue[E]) String() string {
+	return q.format(q.limit.Get(preview.DefaultLimit))
+}
+
+// Format implements [fmt.Formatter], %v prints the preview as String does, %+v and %#v print all elements
+func (q *LinkedBlockingQueue[E]) Format(f fmt.State, verb rune) {
+	preview.Format(f, verb, q.format, q.limit.Get(preview.DefaultLimit))
+}
+
+func (q *LinkedBlockingQueue[E]) format(limit int) string {
+	items := q.ToArray()
+	return preview.Elements(fmt.Sprintf("LinkedBlockingQueue[%T](len=%d)", *new(E), len(items)), items, limit)
+}
+
+// LogValue implements [slog.LogValuer], only the preview limit of elements are logged
+func (q *LinkedBlockingQueue[E]) LogValue() slog.Value {
+	return preview.LogElements(fmt.Sprintf("LinkedBlockingQueue[%T]", *new(E)), q.ToArray(), q.limit.Get(preview.DefaultLimit))
 }
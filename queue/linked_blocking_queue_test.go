@@ -1,12 +1,16 @@
 package queue
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"regexp"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/fxamacker/cbor/v2"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -61,6 +65,83 @@ func TestLinkedBlockingQueue_TryEnqueue(t *testing.T) {
 	assert.False(t, ok)
 }
 
+func TestLinkedBlockingQueue_TryEnqueueAll(t *testing.T) {
+	queue := NewLinkedBlockingQueue[int](3)
+	assert.True(t, queue.TryEnqueueAll(1, 2))
+	assert.False(t, queue.TryEnqueueAll(3, 4))
+	assert.Equal(t, []int{1, 2}, queue.ToArray())
+	assert.True(t, queue.TryEnqueueAll(3))
+	assert.True(t, NewLinkedBlockingQueue[int](-1).TryEnqueueAll(1, 2, 3))
+}
+
+func TestLinkedBlockingQueue_EnqueueAll(t *testing.T) {
+	queue := NewLinkedBlockingQueue[int](2)
+	done := make(chan bool)
+	go func() {
+		done <- queue.EnqueueAll(1, 2, 3, 4, 5)
+	}()
+	var values []int
+	for len(values) < 5 {
+		value, _ := queue.Dequeue()
+		values = append(values, value)
+	}
+	assert.True(t, <-done)
+	assert.Equal(t, []int{1, 2, 3, 4, 5}, values)
+	assert.True(t, queue.EnqueueAll())
+}
+
+func TestLinkedBlockingQueue_DrainTo(t *testing.T) {
+	queue := NewLinkedBlockingQueue[int](5)
+	queue.TryEnqueueAll(1, 2, 3, 4, 5)
+	dst := make([]int, 3)
+	assert.Equal(t, 2, queue.DrainTo(dst, 2))
+	assert.Equal(t, []int{1, 2, 0}, dst)
+	assert.Equal(t, 3, queue.DrainTo(dst, -1))
+	assert.Equal(t, []int{3, 4, 5}, dst)
+	assert.Equal(t, 0, queue.DrainTo(dst, 10))
+	assert.True(t, queue.IsEmpty())
+
+	t.Run("wakes producers", func(t *testing.T) {
+		queue := NewLinkedBlockingQueue[int](1)
+		queue.Enqueue(1)
+		done := make(chan bool)
+		go func() {
+			done <- queue.Enqueue(2)
+		}()
+		dst := make([]int, 4)
+		assert.Equal(t, 1, queue.DrainTo(dst, 1))
+		assert.True(t, <-done)
+		assert.Equal(t, 1, queue.DrainTo(dst, -1))
+		assert.Equal(t, 2, dst[0])
+	})
+}
+
+func TestLinkedBlockingQueue_Close(t *testing.T) {
+	queue := NewLinkedBlockingQueue[int](2)
+	queue.Enqueue(1)
+	consumer := make(chan []int)
+	go func() {
+		var values []int
+		for {
+			value, ok := queue.Dequeue()
+			if !ok {
+				break
+			}
+			values = append(values, value)
+		}
+		consumer <- values
+	}()
+	assert.Eventually(t, queue.IsEmpty, time.Second, time.Millisecond)
+	assert.Nil(t, queue.Close())
+	assert.True(t, queue.IsClosed())
+	assert.Equal(t, []int{1}, <-consumer)
+	assert.False(t, queue.TryEnqueueAll(2, 3))
+	start := time.Now()
+	assert.False(t, queue.EnqueueTimeout(2, time.Second))
+	assert.Less(t, time.Since(start), time.Second)
+	assert.True(t, queue.IsEmpty())
+}
+
 func TestLinkedBlockingQueue_TryDequeue(t *testing.T) {
 	queue := NewLinkedBlockingQueue[int](5)
 	_, ok := queue.TryDequeue()
@@ -150,6 +231,67 @@ func TestLinkedBlockingQueue_UnmarshalJSON(t *testing.T) {
 	err := json.Unmarshal([]byte(`[0,1,2,3,4]`), queue)
 	assert.Nil(t, err)
 	assert.Equal(t, []int{0, 1, 2, 3, 4}, queue.ToArray())
+
+	t.Run("closed", func(t *testing.T) {
+		queue := NewLinkedBlockingQueue[int](1)
+		done := make(chan error)
+		go func() {
+			done <- json.Unmarshal([]byte(`[0,1,2]`), queue)
+		}()
+		assert.Eventually(t, queue.IsNotEmpty, time.Second, time.Millisecond)
+		assert.Nil(t, queue.Close())
+		assert.ErrorIs(t, <-done, ErrClosed)
+		assert.Equal(t, []int{0}, queue.ToArray())
+	})
+}
+
+func TestLinkedBlockingQueue_EncodeJSON(t *testing.T) {
+	queue := NewLinkedBlockingQueue[int](2)
+	queue.Enqueue(1)
+	queue.Enqueue(2)
+	buf := new(bytes.Buffer)
+	assert.Nil(t, queue.EncodeJSON(buf))
+	assert.JSONEq(t, `[1,2]`, buf.String())
+}
+
+func TestLinkedBlockingQueue_DecodeJSON(t *testing.T) {
+	queue := NewLinkedBlockingQueue[int](2)
+	done := make(chan error)
+	go func() {
+		done <- queue.DecodeJSON(strings.NewReader(`[1, 2, 3, 4]`))
+	}()
+	var values []int
+	for i := 0; i < 4; i++ {
+		value, _ := queue.Dequeue()
+		values = append(values, value)
+	}
+	assert.Nil(t, <-done)
+	assert.ElementsMatch(t, []int{1, 2, 3, 4}, values)
+
+	t.Run("existing", func(t *testing.T) {
+		queue := NewLinkedBlockingQueue[int](1)
+		queue.Enqueue(0)
+		go func() {
+			done <- queue.DecodeJSON(strings.NewReader(`[1]`))
+		}()
+		value, _ := queue.Dequeue()
+		assert.Equal(t, 0, value)
+		value, _ = queue.Dequeue()
+		assert.Equal(t, 1, value)
+		assert.Nil(t, <-done)
+	})
+
+	t.Run("closed", func(t *testing.T) {
+		queue := NewLinkedBlockingQueue[int](1)
+		go func() {
+			done <- queue.DecodeJSON(strings.NewReader(`[1, 2, 3]`))
+		}()
+		assert.Eventually(t, queue.IsNotEmpty, time.Second, time.Millisecond)
+		assert.Nil(t, queue.Close())
+		assert.ErrorIs(t, <-done, ErrClosed)
+		assert.Equal(t, []int{1}, queue.ToArray())
+		assert.ErrorIs(t, queue.DecodeJSON(strings.NewReader(`[4]`)), ErrClosed)
+	})
 }
 
 func TestLinkedBlockingQueue_String(t *testing.T) {
@@ -183,3 +325,72 @@ func TestLinkedBlockingQueue_RemoveWhere(t *testing.T) {
 	assert.Equal(t, int64(2), queue.Count())
 	assert.Equal(t, []int{1, 3}, queue.ToArray())
 }
+
+func TestLinkedBlockingQueue_MarshalCBOR(t *testing.T) {
+	value := NewLinkedBlockingQueue[int](5)
+	value.Enqueue(1)
+	value.Enqueue(2)
+	value.Enqueue(3)
+	data, err := cbor.Marshal(value)
+	assert.Nil(t, err)
+	decoded := NewLinkedBlockingQueue[int](5)
+	err = cbor.Unmarshal(data, decoded)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1, 2, 3}, decoded.ToArray())
+	assert.Nil(t, decoded.Close())
+	assert.ErrorIs(t, cbor.Unmarshal(data, decoded), ErrClosed)
+}
+
+func TestLinkedBlockingQueue_MarshalBinary(t *testing.T) {
+	value := NewLinkedBlockingQueue[int](5)
+	value.Enqueue(1)
+	value.Enqueue(2)
+	value.Enqueue(3)
+	data, err := value.MarshalBinary()
+	assert.Nil(t, err)
+	decoded := NewLinkedBlockingQueue[int](5)
+	err = decoded.UnmarshalBinary(data)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1, 2, 3}, decoded.ToArray())
+	assert.Nil(t, decoded.Close())
+	assert.ErrorIs(t, decoded.UnmarshalBinary(data), ErrClosed)
+}
+
+func TestLinkedBlockingQueue_EnqueueContext(t *testing.T) {
+	queue := NewLinkedBlockingQueue[int](1)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.Nil(t, queue.EnqueueContext(ctx, 1))
+	assert.ErrorIs(t, queue.EnqueueContext(ctx, 2), context.DeadlineExceeded)
+
+	ctx, cancel = context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+	}()
+	assert.ErrorIs(t, queue.EnqueueContext(ctx, 2), context.Canceled)
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		queue.Dequeue()
+	}()
+	assert.Nil(t, queue.EnqueueContext(context.Background(), 3))
+	assert.Equal(t, []int{3}, queue.ToArray())
+}
+
+func TestLinkedBlockingQueue_DequeueContext(t *testing.T) {
+	queue := NewLinkedBlockingQueue[int](1)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	value, err := queue.DequeueContext(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, 0, value)
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		queue.Enqueue(1)
+	}()
+	value, err = queue.DequeueContext(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 1, value)
+}
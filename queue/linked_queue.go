@@ -2,10 +2,13 @@ package queue
 
 import (
 	"fmt"
-	"strings"
+	"io"
+	"iter"
+	"log/slog"
+	"slices"
 
+	"github.com/gopi-frame/collection/internal/preview"
 	"github.com/gopi-frame/collection/list"
-	"github.com/gopi-frame/contract"
 )
 
 // NewLinkedQueue new linked queue
@@ -15,39 +18,11 @@ func NewLinkedQueue[E any](values ...E) *LinkedQueue[E] {
 	return queue
 }
 
-// LinkedQueue linked queue
+// LinkedQueue linked queue, all methods are safe for concurrent use.
+// The callbacks run while the queue is locked, so they must not call methods of the same queue.
 type LinkedQueue[E any] struct {
 	items *list.LinkedList[E]
-}
-
-// Lock locks the queue
-func (q *LinkedQueue[E]) Lock() {
-	q.items.Lock()
-}
-
-// Unlock unlocks the queue
-func (q *LinkedQueue[E]) Unlock() {
-	q.items.Unlock()
-}
-
-// TryLock tries to lock the queue
-func (q *LinkedQueue[E]) TryLock() bool {
-	return q.items.TryLock()
-}
-
-// RLock locks the read lock for the queue
-func (q *LinkedQueue[E]) RLock() {
-	q.items.RLock()
-}
-
-// RUnlock unlocks the read lock for the queue
-func (q *LinkedQueue[E]) RUnlock() {
-	q.items.RUnlock()
-}
-
-// TryRLock tries to lock the read lock for the queue
-func (q *LinkedQueue[E]) TryRLock() bool {
-	return q.items.TryRLock()
+	limit preview.Limit
 }
 
 // Count returns the size of queue
@@ -70,22 +45,29 @@ func (q *LinkedQueue[E]) Clear() {
 	q.items.Clear()
 }
 
+// EnableNodePool makes the queue reuse its nodes through a [sync.Pool], see [list.LinkedList.EnableNodePool]
+func (q *LinkedQueue[E]) EnableNodePool() {
+	q.items.EnableNodePool()
+}
+
+// Release clears the queue and gives its nodes back to the pool, it works as Clear when the pool is not enabled
+func (q *LinkedQueue[E]) Release() {
+	q.items.Release()
+}
+
 // Peek returns the first element of the queue
 func (q *LinkedQueue[E]) Peek() (E, bool) {
 	return q.items.First()
 }
 
-// Enqueue enqueues a new element into the queue, it will block if the size is up to capacity
+// Enqueue enqueues a new element into the queue, the queue is unbounded, so it never blocks and always returns true
 func (q *LinkedQueue[E]) Enqueue(value E) bool {
 	q.items.Push(value)
 	return true
 }
 
-// Dequeue dequeues the first element of queue, it will block if the queue is empty
+// Dequeue dequeues the first element of queue, it never blocks and returns zero value and false when the queue is empty
 func (q *LinkedQueue[E]) Dequeue() (value E, ok bool) {
-	if q.items.IsEmpty() {
-		return
-	}
 	return q.items.Shift()
 }
 
@@ -99,7 +81,12 @@ func (q *LinkedQueue[E]) RemoveWhere(callback func(value E) bool) {
 	q.items.RemoveWhere(callback)
 }
 
-// ToArray converts to array
+// All returns an iterator over the elements
+func (q *LinkedQueue[E]) All() iter.Seq[E] {
+	return slices.Values(q.ToArray())
+}
+
+// ToArray converts to array, the returned slice is a copy, so modifying it does not affect the collection
 func (q *LinkedQueue[E]) ToArray() []E {
 	return q.items.ToArray()
 }
@@ -119,26 +106,62 @@ func (q *LinkedQueue[E]) UnmarshalJSON(data []byte) error {
 	return q.items.UnmarshalJSON(data)
 }
 
-// String converts to string
+// EncodeJSON writes the queue to w as a json array one element at a time, the queue is read locked while writing
+func (q *LinkedQueue[E]) EncodeJSON(w io.Writer) error {
+	return q.items.EncodeJSON(w)
+}
+
+// DecodeJSON reads a json array from r one element at a time, see UnmarshalJSON
+func (q *LinkedQueue[E]) DecodeJSON(r io.Reader) error {
+	return q.items.DecodeJSON(r)
+}
+
+// ToCBOR converts to cbor
+func (q *LinkedQueue[E]) ToCBOR() ([]byte, error) {
+	return q.items.MarshalCBOR()
+}
+
+// MarshalCBOR implements [cbor.Marshaler]
+func (q *LinkedQueue[E]) MarshalCBOR() ([]byte, error) {
+	return q.ToCBOR()
+}
+
+// UnmarshalCBOR implements [cbor.Unmarshaler]
+func (q *LinkedQueue[E]) UnmarshalCBOR(data []byte) error {
+	return q.items.UnmarshalCBOR(data)
+}
+
+// MarshalBinary implements [encoding.BinaryMarshaler], the elements are encoded by their own encoders
+func (q *LinkedQueue[E]) MarshalBinary() ([]byte, error) {
+	return q.items.MarshalBinary()
+}
+
+// UnmarshalBinary implements [encoding.BinaryUnmarshaler]
+func (q *LinkedQueue[E]) UnmarshalBinary(data []byte) error {
+	return q.items.UnmarshalBinary(data)
+}
+
+// SetPreviewLimit sets the number of elements shown by String, %v and LogValue, a negative limit shows all elements
+func (q *LinkedQueue[E]) SetPreviewLimit(limit int) {
+	q.limit.Set(limit)
+}
+
+// String converts to string, only the preview limit of elements are shown
 func (q *LinkedQueue[E]) String() string {
-	str := new(strings.Builder)
-	str.WriteString(fmt.Sprintf("LinkedQueue[%T](len=%d)", *new(E), q.Count()))
-	str.WriteByte('{')
-	str.WriteByte('\n')
-	q.items.Each(func(index int, value E) bool {
-		str.WriteByte('\t')
-		if v, ok := any(value).(contract.Stringable); ok {
-			str.WriteString(v.String())
-		} else {
-			str.WriteString(fmt.Sprintf("%v", value))
-		}
-		str.WriteByte(',')
-		str.WriteByte('\n')
-		return index < 4
-	})
-	if q.Count() > 5 {
-		str.WriteString("\t...\n")
-	}
-	str.WriteByte('}')
-	return str.String()
+	return q.format(q.limit.Get(preview.DefaultLimit))
+}
+
+// Format implements [fmt.Formatter], %v prints the preview as String does, %+v and %#v print all elements
+func (q *LinkedQueue[E]) Format(f fmt.State, verb rune) {
+	preview.Format(f, verb, q.format, q.limit.Get(preview.DefaultLimit))
+}
+
+func (q *LinkedQueue[E]) format(limit int) string {
+	items := q.ToArray()
+	return preview.Elements(fmt.Sprintf("LinkedQueue[%T](len=%d)", *new(E), len(items)), items, limit)
+}
+
+// LogValue implements [slog.LogValuer], only the preview limit of elements are logged
+func (q *LinkedQueue[E]) LogValue() slog.Value {
+	return preview.LogElements(fmt.Sprintf("LinkedQueue[%T]", *new(E)), q.ToArray(), q.limit.Get(preview.DefaultLimit))
 }
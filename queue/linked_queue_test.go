@@ -1,12 +1,15 @@
 package queue
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"regexp"
+	"strings"
 	"sync"
 	"testing"
 
+	"github.com/fxamacker/cbor/v2"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -31,6 +34,20 @@ func TestLinkedQueue_Clear(t *testing.T) {
 	assert.True(t, queue.IsEmpty())
 }
 
+func TestLinkedQueue_Release(t *testing.T) {
+	queue := NewLinkedQueue[int]()
+	queue.EnableNodePool()
+	for i := 0; i < 1000; i++ {
+		queue.Enqueue(i)
+		v, ok := queue.Dequeue()
+		assert.True(t, ok)
+		assert.Equal(t, i, v)
+	}
+	queue.Enqueue(1)
+	queue.Release()
+	assert.True(t, queue.IsEmpty())
+}
+
 func TestLinkedQueue_Peek(t *testing.T) {
 	queue := NewLinkedQueue(1, 2, 3)
 	v, ok := queue.Peek()
@@ -50,8 +67,6 @@ func TestLinkedQueue_Enqueue(t *testing.T) {
 
 	t.Run("multi-coroutines", func(t *testing.T) {
 		queue := NewLinkedQueue[int]()
-		queue.Lock()
-		defer queue.Unlock()
 		var expected []int
 		var wg sync.WaitGroup
 		for i := 0; i < 10; i++ {
@@ -97,6 +112,22 @@ func TestLinkedQueue_UnmarshalJSON(t *testing.T) {
 	assert.EqualValues(t, []int{1, 2, 3}, queue.ToArray())
 }
 
+func TestLinkedQueue_EncodeJSON(t *testing.T) {
+	value := NewLinkedQueue(1, 2, 3)
+	buf := new(bytes.Buffer)
+	assert.Nil(t, value.EncodeJSON(buf))
+	expected, _ := value.ToJSON()
+	assert.JSONEq(t, string(expected), buf.String())
+}
+
+func TestLinkedQueue_DecodeJSON(t *testing.T) {
+	value := NewLinkedQueue(1, 2, 3)
+	assert.Nil(t, value.DecodeJSON(strings.NewReader(`[4, 5]`)))
+	assert.Equal(t, []int{1, 2, 3, 4, 5}, value.ToArray())
+	assert.Error(t, value.DecodeJSON(strings.NewReader(`[6, "a"]`)))
+	assert.Equal(t, []int{1, 2, 3, 4, 5}, value.ToArray())
+}
+
 func TestLinkedQueue_String(t *testing.T) {
 	queue := NewLinkedQueue(1, 2, 3, 4, 5, 6, 7)
 	str := queue.String()
@@ -119,3 +150,23 @@ func TestLinkedQueue_RemoveWhere(t *testing.T) {
 	assert.Equal(t, int64(3), queue.Count())
 	assert.Equal(t, []int{2, 4, 6}, queue.ToArray())
 }
+
+func TestLinkedQueue_MarshalCBOR(t *testing.T) {
+	value := NewLinkedQueue(1, 2, 3)
+	data, err := cbor.Marshal(value)
+	assert.Nil(t, err)
+	decoded := NewLinkedQueue[int]()
+	err = cbor.Unmarshal(data, decoded)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1, 2, 3}, decoded.ToArray())
+}
+
+func TestLinkedQueue_MarshalBinary(t *testing.T) {
+	value := NewLinkedQueue(1, 2, 3)
+	data, err := value.MarshalBinary()
+	assert.Nil(t, err)
+	decoded := NewLinkedQueue[int]()
+	err = decoded.UnmarshalBinary(data)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1, 2, 3}, decoded.ToArray())
+}
@@ -0,0 +1,112 @@
+package queue
+
+import (
+	"math/bits"
+	"sync/atomic"
+)
+
+// NewMPMCQueue new lock-free bounded queue, the capacity is rounded up to a power of two of at least 2
+func NewMPMCQueue[E any](cap int) *MPMCQueue[E] {
+	size := 2
+	if cap > 2 {
+		size = 1 << bits.Len(uint(cap-1))
+	}
+	queue := &MPMCQueue[E]{cells: make([]mpmcCell[E], size), mask: uint64(size - 1)}
+	for index := range queue.cells {
+		queue.cells[index].seq.Store(uint64(index))
+	}
+	return queue
+}
+
+// MPMCQueue lock-free bounded queue for many producers and many consumers, all methods are safe for concurrent use.
+// It is a ring buffer whose cells carry sequence numbers, a producer claims the tail and a consumer claims the head
+// with a compare and swap and the sequence of the cell tells them whether it is filled, so TryEnqueue and TryDequeue
+// never take a lock and never block, where every operation of a [BlockingQueue] contends on its mutex.
+// The elements enqueued by one producer are dequeued in their order, and Count is only a hint while others modify the queue.
+type MPMCQueue[E any] struct {
+	_     [64]byte
+	tail  atomic.Uint64
+	_     [56]byte
+	head  atomic.Uint64
+	_     [56]byte
+	cells []mpmcCell[E]
+	mask  uint64
+}
+
+// mpmcCell cell of an mpmc queue, its sequence is its position while it is free for the producer of the position
+// and the position plus one while it is filled for the consumer of the position
+type mpmcCell[E any] struct {
+	seq   atomic.Uint64
+	value E
+}
+
+// Cap returns the capacity of the queue
+func (q *MPMCQueue[E]) Cap() int {
+	return len(q.cells)
+}
+
+// Count returns the number of the elements, it is only a hint while others modify the queue
+func (q *MPMCQueue[E]) Count() int64 {
+	for {
+		tail := q.tail.Load()
+		head := q.head.Load()
+		if tail == q.tail.Load() {
+			return int64(min(tail-min(head, tail), uint64(len(q.cells))))
+		}
+	}
+}
+
+// IsEmpty returns whether the queue is empty
+func (q *MPMCQueue[E]) IsEmpty() bool {
+	return q.Count() == 0
+}
+
+// IsNotEmpty returns whether the queue is not empty
+func (q *MPMCQueue[E]) IsNotEmpty() bool {
+	return !q.IsEmpty()
+}
+
+// TryEnqueue enqueues a new element into the queue, it returns false when the queue is full
+func (q *MPMCQueue[E]) TryEnqueue(value E) bool {
+	pos := q.tail.Load()
+	for {
+		cell := &q.cells[pos&q.mask]
+		seq := cell.seq.Load()
+		switch {
+		case seq == pos:
+			if q.tail.CompareAndSwap(pos, pos+1) {
+				cell.value = value
+				cell.seq.Store(pos + 1)
+				return true
+			}
+			pos = q.tail.Load()
+		case seq < pos:
+			return false
+		default:
+			pos = q.tail.Load()
+		}
+	}
+}
+
+// TryDequeue dequeues the first element of the queue, it returns zero value and false when the queue is empty
+func (q *MPMCQueue[E]) TryDequeue() (E, bool) {
+	pos := q.head.Load()
+	for {
+		cell := &q.cells[pos&q.mask]
+		seq := cell.seq.Load()
+		switch {
+		case seq == pos+1:
+			if q.head.CompareAndSwap(pos, pos+1) {
+				value := cell.value
+				cell.value = *new(E)
+				cell.seq.Store(pos + q.mask + 1)
+				return value, true
+			}
+			pos = q.head.Load()
+		case seq < pos+1:
+			return *new(E), false
+		default:
+			pos = q.head.Load()
+		}
+	}
+}
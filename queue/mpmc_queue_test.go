@@ -0,0 +1,92 @@
+package queue
+
+import (
+	"runtime"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewMPMCQueue(t *testing.T) {
+	assert.Equal(t, 2, NewMPMCQueue[int](0).Cap())
+	assert.Equal(t, 2, NewMPMCQueue[int](1).Cap())
+	assert.Equal(t, 8, NewMPMCQueue[int](5).Cap())
+	assert.Equal(t, 8, NewMPMCQueue[int](8).Cap())
+}
+
+func TestMPMCQueue_TryEnqueue(t *testing.T) {
+	queue := NewMPMCQueue[int](4)
+	for i := range 4 {
+		assert.True(t, queue.TryEnqueue(i))
+	}
+	assert.False(t, queue.TryEnqueue(4))
+	assert.Equal(t, int64(4), queue.Count())
+	value, ok := queue.TryDequeue()
+	assert.True(t, ok)
+	assert.Equal(t, 0, value)
+	assert.True(t, queue.TryEnqueue(4))
+}
+
+func TestMPMCQueue_TryDequeue(t *testing.T) {
+	queue := NewMPMCQueue[int](2)
+	_, ok := queue.TryDequeue()
+	assert.False(t, ok)
+	for round := range 10 {
+		assert.True(t, queue.TryEnqueue(round))
+		assert.True(t, queue.TryEnqueue(-round))
+		first, _ := queue.TryDequeue()
+		second, _ := queue.TryDequeue()
+		assert.Equal(t, []int{round, -round}, []int{first, second})
+	}
+	assert.True(t, queue.IsEmpty())
+
+	t.Run("concurrent", func(t *testing.T) {
+		queue := NewMPMCQueue[int](64)
+		const producers, count = 4, 1000
+		var wg sync.WaitGroup
+		for producer := range producers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for index := range count {
+					for !queue.TryEnqueue(producer*count + index) {
+						runtime.Gosched()
+					}
+				}
+			}()
+		}
+		results := make(chan []int, producers)
+		for range producers {
+			go func() {
+				var values []int
+				for len(values) < count {
+					if value, ok := queue.TryDequeue(); ok {
+						values = append(values, value)
+					} else {
+						runtime.Gosched()
+					}
+				}
+				results <- values
+			}()
+		}
+		wg.Wait()
+		var values []int
+		for range producers {
+			consumed := <-results
+			for producer := range producers {
+				// the elements of each producer are dequeued in their order
+				assert.True(t, slices.IsSorted(slices.DeleteFunc(slices.Clone(consumed), func(value int) bool {
+					return value/count != producer
+				})))
+			}
+			values = append(values, consumed...)
+		}
+		slices.Sort(values)
+		for index, value := range values {
+			assert.Equal(t, index, value)
+		}
+		assert.True(t, queue.IsEmpty())
+	})
+}
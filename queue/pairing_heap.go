@@ -0,0 +1,317 @@
+package queue
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"log/slog"
+	"reflect"
+	"slices"
+	"sync"
+
+	"github.com/gopi-frame/collection/comparator"
+	"github.com/gopi-frame/collection/internal/debug"
+	"github.com/gopi-frame/collection/internal/preview"
+	"github.com/gopi-frame/contract"
+)
+
+// NewPairingHeap new pairing heap whose least element in comparator order is dequeued first
+func NewPairingHeap[E any](comparator contract.Comparator[E], values ...E) *PairingHeap[E] {
+	heap := &PairingHeap[E]{comparator: comparator}
+	for _, value := range values {
+		heap.enqueue(value)
+	}
+	return heap
+}
+
+// NewPairingHeapOrdered new pairing heap of ordered elements, the smallest element is dequeued first
+func NewPairingHeapOrdered[E cmp.Ordered](values ...E) *PairingHeap[E] {
+	return NewPairingHeap(comparator.Ordered[E](), values...)
+}
+
+// PairingHeap meldable priority queue based on a pairing heap, all methods are safe for concurrent use.
+// Enqueue and Merge take O(1) time and Dequeue takes amortized O(log n) time, so a scheduler can merge
+// the queues of its workers without copying them, where merging two [PriorityQueue]s moves every element.
+// The elements of equal priority are dequeued in any order.
+// The callbacks run while the heap is locked, so they must not call methods of the same heap.
+type PairingHeap[E any] struct {
+	lock       sync.RWMutex
+	root       *pairingNode[E]
+	size       int64
+	comparator contract.Comparator[E]
+	limit      preview.Limit
+}
+
+// pairingNode node of a pairing heap, the children of a node are linked through their siblings
+type pairingNode[E any] struct {
+	value   E
+	child   *pairingNode[E]
+	sibling *pairingNode[E]
+}
+
+// meld links the roots of two heaps, the greater one becomes the first child of the other
+func (h *PairingHeap[E]) meld(a, b *pairingNode[E]) *pairingNode[E] {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	if h.comparator.Compare(b.value, a.value) < 0 {
+		a, b = b, a
+	}
+	b.sibling = a.child
+	a.child = b
+	return a
+}
+
+// pair melds the children of a removed root in two passes, first in pairs from left to right
+// and then the pairs from right to left, which keeps the amortized cost of Dequeue logarithmic
+func (h *PairingHeap[E]) pair(first *pairingNode[E]) *pairingNode[E] {
+	var pairs []*pairingNode[E]
+	for first != nil {
+		a, b := first, first.sibling
+		if b == nil {
+			a.sibling = nil
+			pairs = append(pairs, a)
+			break
+		}
+		first = b.sibling
+		a.sibling, b.sibling = nil, nil
+		pairs = append(pairs, h.meld(a, b))
+	}
+	var root *pairingNode[E]
+	for index := len(pairs) - 1; index >= 0; index-- {
+		root = h.meld(pairs[index], root)
+	}
+	return root
+}
+
+// nodes returns the nodes of the heap in pre-order
+func (h *PairingHeap[E]) nodes() []*pairingNode[E] {
+	nodes := make([]*pairingNode[E], 0, h.size)
+	var stack []*pairingNode[E]
+	if h.root != nil {
+		stack = append(stack, h.root)
+	}
+	for len(stack) > 0 {
+		node := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		nodes = append(nodes, node)
+		if node.sibling != nil {
+			stack = append(stack, node.sibling)
+		}
+		if node.child != nil {
+			stack = append(stack, node.child)
+		}
+	}
+	return nodes
+}
+
+// validate checks the size counter and that no element is less than its parent
+func (h *PairingHeap[E]) validate() error {
+	if h.root != nil && h.root.sibling != nil {
+		return fmt.Errorf("heap root %v has a sibling", h.root.value)
+	}
+	count := int64(0)
+	for _, node := range h.nodes() {
+		count++
+		for child := node.child; child != nil; child = child.sibling {
+			if h.comparator.Compare(child.value, node.value) < 0 {
+				return fmt.Errorf("element %v is less than its parent %v", child.value, node.value)
+			}
+		}
+	}
+	if h.size != count {
+		return fmt.Errorf("heap has size %d, expected %d", h.size, count)
+	}
+	return nil
+}
+
+// unlock unlocks the heap after a mutation, the invariants are checked first in debug builds
+func (h *PairingHeap[E]) unlock() {
+	if debug.Enabled {
+		debug.Check(h, h.validate())
+	}
+	h.lock.Unlock()
+}
+
+// Count returns the size of heap
+func (h *PairingHeap[E]) Count() int64 {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+	return h.size
+}
+
+// IsEmpty returns whether the heap is empty
+func (h *PairingHeap[E]) IsEmpty() bool {
+	return h.Count() == 0
+}
+
+// IsNotEmpty returns whether the heap is not empty
+func (h *PairingHeap[E]) IsNotEmpty() bool {
+	return !h.IsEmpty()
+}
+
+// Comparator returns the ordering of the heap
+func (h *PairingHeap[E]) Comparator() contract.Comparator[E] {
+	return h.comparator
+}
+
+// Clear clears the heap
+func (h *PairingHeap[E]) Clear() {
+	h.lock.Lock()
+	defer h.unlock()
+	h.root = nil
+	h.size = 0
+}
+
+// Peek returns the least element, it returns zero value and false when the heap is empty
+func (h *PairingHeap[E]) Peek() (E, bool) {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+	if h.root == nil {
+		return *new(E), false
+	}
+	return h.root.value, true
+}
+
+// Enqueue enqueues a new element into the heap in O(1) time, the heap is unbounded, so it always returns true
+func (h *PairingHeap[E]) Enqueue(value E) bool {
+	h.lock.Lock()
+	defer h.unlock()
+	return h.enqueue(value)
+}
+
+func (h *PairingHeap[E]) enqueue(value E) bool {
+	h.root = h.meld(h.root, &pairingNode[E]{value: value})
+	h.size++
+	return true
+}
+
+// Dequeue dequeues the least element in amortized O(log n) time, it never blocks
+// and returns zero value and false when the heap is empty
+func (h *PairingHeap[E]) Dequeue() (E, bool) {
+	h.lock.Lock()
+	defer h.unlock()
+	if h.root == nil {
+		return *new(E), false
+	}
+	value := h.root.value
+	h.root = h.pair(h.root.child)
+	h.size--
+	return value, true
+}
+
+// Merge moves all elements of the other heap into the heap in O(1) time, which leaves the other heap empty.
+// The other heap must have the same ordering.
+func (h *PairingHeap[E]) Merge(other *PairingHeap[E]) {
+	if h == other {
+		return
+	}
+	other.lock.Lock()
+	root, size := other.root, other.size
+	other.root = nil
+	other.size = 0
+	other.unlock()
+	h.lock.Lock()
+	defer h.unlock()
+	h.root = h.meld(h.root, root)
+	h.size += size
+}
+
+// Remove removes the specific element
+func (h *PairingHeap[E]) Remove(value E) {
+	h.RemoveWhere(func(e E) bool {
+		return reflect.DeepEqual(e, value)
+	})
+}
+
+// RemoveWhere removes elements which matches the callback, the heap is rebuilt from the other elements in O(n) time
+func (h *PairingHeap[E]) RemoveWhere(callback func(E) bool) {
+	h.lock.Lock()
+	defer h.unlock()
+	nodes := h.nodes()
+	h.root = nil
+	h.size = 0
+	for _, node := range nodes {
+		if callback(node.value) {
+			continue
+		}
+		node.child, node.sibling = nil, nil
+		h.root = h.meld(h.root, node)
+		h.size++
+	}
+}
+
+// All returns an iterator over the elements in no particular order
+func (h *PairingHeap[E]) All() iter.Seq[E] {
+	return slices.Values(h.ToArray())
+}
+
+// ToArray converts to array in no particular order but with the least element first,
+// the returned slice is a copy, so modifying it does not affect the collection
+func (h *PairingHeap[E]) ToArray() []E {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+	return h.toArray()
+}
+
+func (h *PairingHeap[E]) toArray() []E {
+	nodes := h.nodes()
+	items := make([]E, len(nodes))
+	for index, node := range nodes {
+		items[index] = node.value
+	}
+	return items
+}
+
+// ToJSON converts to json
+func (h *PairingHeap[E]) ToJSON() ([]byte, error) {
+	return json.Marshal(h.ToArray())
+}
+
+// MarshalJSON implements [json.Marshaller]
+func (h *PairingHeap[E]) MarshalJSON() ([]byte, error) {
+	return h.ToJSON()
+}
+
+// UnmarshalJSON implements [json.Unmarshaller], the elements are enqueued, so the heap must be created with a comparator
+func (h *PairingHeap[E]) UnmarshalJSON(data []byte) error {
+	items := make([]E, 0)
+	if err := json.Unmarshal(data, &items); err != nil {
+		return err
+	}
+	h.lock.Lock()
+	defer h.unlock()
+	for _, item := range items {
+		h.enqueue(item)
+	}
+	return nil
+}
+
+// SetPreviewLimit sets the number of elements shown by String, %v and LogValue, a negative limit shows all elements
+func (h *PairingHeap[E]) SetPreviewLimit(limit int) {
+	h.limit.Set(limit)
+}
+
+// String converts to string, only the preview limit of elements are shown
+func (h *PairingHeap[E]) String() string {
+	return h.format(h.limit.Get(preview.DefaultLimit))
+}
+
+// Format implements [fmt.Formatter], %v prints the preview as String does, %+v and %#v print all elements
+func (h *PairingHeap[E]) Format(f fmt.State, verb rune) {
+	preview.Format(f, verb, h.format, h.limit.Get(preview.DefaultLimit))
+}
+
+func (h *PairingHeap[E]) format(limit int) string {
+	items := h.ToArray()
+	return preview.Elements(fmt.Sprintf("PairingHeap[%T](len=%d)", *new(E), len(items)), items, limit)
+}
+
+// LogValue implements [slog.LogValuer], only the preview limit of elements are logged
+func (h *PairingHeap[E]) LogValue() slog.Value {
+	return preview.LogElements(fmt.Sprintf("PairingHeap[%T]", *new(E)), h.ToArray(), h.limit.Get(preview.DefaultLimit))
+}
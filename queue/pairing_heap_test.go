@@ -0,0 +1,149 @@
+package queue
+
+import (
+	"encoding/json"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// drain dequeues all elements of the queue in order
+func drain[E any](queue Interface[E]) []E {
+	var values []E
+	for {
+		value, ok := queue.Dequeue()
+		if !ok {
+			return values
+		}
+		values = append(values, value)
+	}
+}
+
+func TestPairingHeap_Enqueue(t *testing.T) {
+	heap := NewPairingHeap[int](_comparator{}, 5, 3)
+	assert.True(t, heap.Enqueue(4))
+	assert.True(t, heap.Enqueue(1))
+	assert.Equal(t, int64(4), heap.Count())
+	value, ok := heap.Peek()
+	assert.True(t, ok)
+	assert.Equal(t, 1, value)
+}
+
+func TestPairingHeap_Dequeue(t *testing.T) {
+	values := rand.Perm(1000)
+	heap := NewPairingHeapOrdered(values...)
+	slices.Sort(values)
+	assert.Equal(t, values, drain[int](heap))
+	assert.True(t, heap.IsEmpty())
+	_, ok := heap.Dequeue()
+	assert.False(t, ok)
+	_, ok = heap.Peek()
+	assert.False(t, ok)
+
+	t.Run("interleaved", func(t *testing.T) {
+		heap := NewPairingHeapOrdered[int]()
+		queue := NewPriorityQueueOrdered[int]()
+		for range 2000 {
+			if rand.IntN(3) == 0 {
+				a, _ := heap.Dequeue()
+				b, _ := queue.Dequeue()
+				assert.Equal(t, b, a)
+				continue
+			}
+			value := rand.IntN(100)
+			heap.Enqueue(value)
+			queue.Enqueue(value)
+		}
+		assert.NoError(t, heap.validate())
+		assert.Equal(t, drain[int](queue), drain[int](heap))
+	})
+}
+
+func TestPairingHeap_Merge(t *testing.T) {
+	heap := NewPairingHeapOrdered(5, 1, 9)
+	other := NewPairingHeapOrdered(4, 2, 8)
+	heap.Merge(other)
+	assert.True(t, other.IsEmpty())
+	assert.Equal(t, int64(6), heap.Count())
+	assert.Equal(t, []int{1, 2, 4, 5, 8, 9}, drain[int](heap))
+
+	t.Run("empty", func(t *testing.T) {
+		heap := NewPairingHeapOrdered[int]()
+		heap.Merge(NewPairingHeapOrdered(2, 1))
+		heap.Merge(NewPairingHeapOrdered[int]())
+		assert.Equal(t, []int{1, 2}, drain[int](heap))
+	})
+
+	t.Run("itself", func(t *testing.T) {
+		heap := NewPairingHeapOrdered(2, 1)
+		heap.Merge(heap)
+		assert.Equal(t, int64(2), heap.Count())
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		heap := NewPairingHeapOrdered[int]()
+		var wg sync.WaitGroup
+		for worker := range 8 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				local := NewPairingHeapOrdered[int]()
+				for index := range 100 {
+					local.Enqueue(worker*100 + index)
+				}
+				heap.Merge(local)
+			}()
+		}
+		wg.Wait()
+		values := drain[int](heap)
+		assert.Len(t, values, 800)
+		assert.True(t, slices.IsSorted(values))
+	})
+}
+
+func TestPairingHeap_Remove(t *testing.T) {
+	heap := NewPairingHeapOrdered(3, 1, 2, 3)
+	heap.Remove(3)
+	assert.Equal(t, []int{1, 2}, drain[int](heap))
+}
+
+func TestPairingHeap_RemoveWhere(t *testing.T) {
+	heap := NewPairingHeapOrdered(1, 2, 3, 4, 5, 6)
+	heap.RemoveWhere(func(value int) bool {
+		return value%2 == 0
+	})
+	assert.Equal(t, int64(3), heap.Count())
+	assert.Equal(t, []int{1, 3, 5}, drain[int](heap))
+}
+
+func TestPairingHeap_Clear(t *testing.T) {
+	heap := NewPairingHeapOrdered(1, 2)
+	heap.Clear()
+	assert.True(t, heap.IsEmpty())
+	assert.Empty(t, heap.ToArray())
+}
+
+func TestPairingHeap_ToArray(t *testing.T) {
+	heap := NewPairingHeapOrdered(3, 1, 2)
+	items := heap.ToArray()
+	assert.Equal(t, 1, items[0])
+	assert.ElementsMatch(t, []int{1, 2, 3}, items)
+	assert.ElementsMatch(t, items, slices.Collect(heap.All()))
+}
+
+func TestPairingHeap_UnmarshalJSON(t *testing.T) {
+	data, err := json.Marshal(NewPairingHeapOrdered(3, 1, 2))
+	assert.NoError(t, err)
+	heap := NewPairingHeapOrdered[int]()
+	assert.NoError(t, json.Unmarshal(data, heap))
+	assert.Equal(t, []int{1, 2, 3}, drain[int](heap))
+	assert.Error(t, json.Unmarshal([]byte(`{}`), heap))
+}
+
+func TestPairingHeap_String(t *testing.T) {
+	heap := NewPairingHeapOrdered(1)
+	assert.Equal(t, "PairingHeap[int](len=1){\n\t1,\n}", heap.String())
+}
@@ -1,12 +1,23 @@
 package queue
 
 import (
+	"cmp"
+	"context"
 	"encoding/json"
 	"fmt"
-	"strings"
+	"io"
+	"iter"
+	"log/slog"
+	"reflect"
+	"slices"
 	"sync"
 	"time"
 
+	"github.com/fxamacker/cbor/v2"
+	"github.com/gopi-frame/collection/comparator"
+	"github.com/gopi-frame/collection/internal/codec"
+	"github.com/gopi-frame/collection/internal/jsonstream"
+	"github.com/gopi-frame/collection/internal/preview"
 	"github.com/gopi-frame/contract"
 )
 
@@ -14,190 +25,306 @@ import (
 func NewPriorityBlockingQueue[E any](comparator contract.Comparator[E], cap int64) *PriorityBlockingQueue[E] {
 	queue := new(PriorityBlockingQueue[E])
 	queue.items = NewPriorityQueue(comparator)
-	queue.takeLock = sync.NewCond(queue.items)
-	queue.putLock = sync.NewCond(queue.items)
+	queue.takeLock = sync.NewCond(&queue.lock)
+	queue.putLock = sync.NewCond(&queue.lock)
 	queue.cap = cap
 	return queue
 }
 
-// PriorityBlockingQueue priority blocking queue
+// NewPriorityBlockingQueueOrdered new priority blocking queue of ordered elements, the smallest element is dequeued first
+func NewPriorityBlockingQueueOrdered[E cmp.Ordered](cap int64) *PriorityBlockingQueue[E] {
+	return NewPriorityBlockingQueue(comparator.Ordered[E](), cap)
+}
+
+// NewStablePriorityBlockingQueue new priority blocking queue which dequeues the elements of equal priority
+// in the order they are enqueued, see NewStablePriorityQueue
+func NewStablePriorityBlockingQueue[E any](comparator contract.Comparator[E], cap int64) *PriorityBlockingQueue[E] {
+	queue := NewPriorityBlockingQueue(comparator, cap)
+	queue.items.stable = true
+	return queue
+}
+
+// PriorityBlockingQueue priority blocking queue, all methods are safe for concurrent use.
+// The callbacks run while the queue is locked, so they must not call methods of the same queue.
 type PriorityBlockingQueue[E any] struct {
+	lock     sync.RWMutex
 	items    *PriorityQueue[E]
 	cap      int64
 	takeLock *sync.Cond
 	putLock  *sync.Cond
+	closed   bool
+	limit    preview.Limit
 }
 
 // Count returns the size of queue
 func (q *PriorityBlockingQueue[E]) Count() int64 {
-	if q.items.TryRLock() {
-		defer q.items.RUnlock()
-	}
-	return q.items.Count()
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+	return q.items.size
 }
 
 // IsEmpty returns whether the queue is empty
 func (q *PriorityBlockingQueue[E]) IsEmpty() bool {
-	if q.items.TryRLock() {
-		defer q.items.RUnlock()
-	}
-	return q.items.IsEmpty()
+	return q.Count() == 0
 }
 
 // IsNotEmpty returns whether the queue is not empty
 func (q *PriorityBlockingQueue[E]) IsNotEmpty() bool {
-	if q.items.TryRLock() {
-		defer q.items.RUnlock()
-	}
-	return q.items.IsNotEmpty()
+	return !q.IsEmpty()
+}
+
+// Close closes the queue, so it works as the end of a stream for a graceful shutdown:
+// the enqueues return false, or [ErrClosed] from EnqueueContext, and the blocked producers are woken up with the same result,
+// while the dequeues take the remaining elements and return zero value and false, or [ErrClosed], once the queue is drained.
+// Closing it again does nothing, it always returns nil and implements [io.Closer].
+func (q *PriorityBlockingQueue[E]) Close() error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	q.closed = true
+	q.takeLock.Broadcast()
+	q.putLock.Broadcast()
+	return nil
+}
+
+// IsClosed returns whether the queue is closed
+func (q *PriorityBlockingQueue[E]) IsClosed() bool {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+	return q.closed
 }
 
 // Clear clears the queue
 func (q *PriorityBlockingQueue[E]) Clear() {
-	if q.items.TryLock() {
-		defer q.items.Unlock()
-	}
-	q.items.Clear()
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	q.items.clear()
+	q.putLock.Broadcast()
 }
 
 // Peek returns the first element of the queue
 func (q *PriorityBlockingQueue[E]) Peek() (E, bool) {
-	if q.items.TryRLock() {
-		defer q.items.RUnlock()
-	}
-	return q.items.Peek()
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+	return q.items.peek()
 }
 
 // TryEnqueue enqueues a new element into the queue, it will return false if the size is up to the capacity
 func (q *PriorityBlockingQueue[E]) TryEnqueue(value E) bool {
-	q.items.RLock()
-	defer q.items.RUnlock()
-	if q.cap == q.items.Count() {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if q.closed || q.cap == q.items.size {
 		return false
 	}
-	ok := q.items.Enqueue(value)
-	q.takeLock.Broadcast()
-	return ok
+	return q.push(value)
+}
+
+// TryEnqueueAll enqueues all the values at once, or none of them when they do not fit into the remaining capacity,
+// so the parts of a message are never split by a full queue
+func (q *PriorityBlockingQueue[E]) TryEnqueueAll(values ...E) bool {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if q.closed {
+		return false
+	}
+	if q.cap >= 0 && q.cap-q.items.size < int64(len(values)) {
+		return false
+	}
+	for _, value := range values {
+		q.push(value)
+	}
+	return true
 }
 
 // TryDequeue dequeues the first element of the queue and returns it.
 // The empty value of the element type and false will be returned when the queue is empty
 func (q *PriorityBlockingQueue[E]) TryDequeue() (E, bool) {
-	if q.items.TryLock() {
-		defer q.items.Unlock()
-	}
-	if q.items.Count() == 0 {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if q.items.size == 0 {
 		return *new(E), false
 	}
-	value, ok := q.items.Dequeue()
-	q.putLock.Broadcast()
-	return value, ok
+	return q.shift()
 }
 
 // Enqueue enqueues a new element into the queue, it will block if the size is up to capacity
+// and returns false when the queue is closed
 func (q *PriorityBlockingQueue[E]) Enqueue(value E) bool {
-	if q.items.TryLock() {
-		defer q.items.Unlock()
-	}
-	for q.cap == q.items.Count() {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	for q.cap == q.items.size && !q.closed {
 		q.putLock.Wait()
 	}
-	ok := q.items.Enqueue(value)
-	q.takeLock.Broadcast()
-	return ok
+	if q.closed {
+		return false
+	}
+	return q.push(value)
 }
 
 // Dequeue dequeues the first element of queue, it will block if the queue is empty
+// and returns zero value and false once the queue is closed and drained
 func (q *PriorityBlockingQueue[E]) Dequeue() (E, bool) {
-	if q.items.TryLock() {
-		defer q.items.Unlock()
-	}
-	for q.items.IsEmpty() {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	for q.items.size == 0 && !q.closed {
 		q.takeLock.Wait()
 	}
-	value, ok := q.items.Dequeue()
-	q.putLock.Broadcast()
-	return value, ok
+	if q.items.size == 0 {
+		return *new(E), false
+	}
+	return q.shift()
 }
 
 // EnqueueTimeout enqueues element into the queue.
 // It will block when the size of queue is up to capacity.
 // It will return true if the element is successfully enqueued or false when time is out
 func (q *PriorityBlockingQueue[E]) EnqueueTimeout(value E, duration time.Duration) bool {
-	timeout := time.After(duration)
-	done := make(chan struct{})
-	go func() {
-		q.items.Lock()
-		defer q.items.Unlock()
-		for int64(q.cap) == q.items.Count() {
-			q.putLock.Wait()
+	deadline := time.Now().Add(duration)
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	for q.cap == q.items.size && !q.closed {
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return false
 		}
-		close(done)
-	}()
-	select {
-	case <-done:
-		ok := q.items.Enqueue(value)
-		q.takeLock.Broadcast()
-		return ok
-	case <-timeout:
+		waitFor(q.putLock, remaining)
+	}
+	if q.closed {
 		return false
 	}
+	return q.push(value)
 }
 
 // DequeueTimeout removes the first element and returns it.
 // It will block when the queue is empty.
 // It will return zero value and false when time is out
-func (q *PriorityBlockingQueue[E]) DequeueTimeout(duration time.Duration) (value E, ok bool) {
-	timeout := time.After(duration)
-	done := make(chan struct{})
-	go func() {
-		q.items.Lock()
-		defer q.items.Unlock()
-		for q.items.IsEmpty() {
-			q.takeLock.Wait()
+func (q *PriorityBlockingQueue[E]) DequeueTimeout(duration time.Duration) (E, bool) {
+	deadline := time.Now().Add(duration)
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	for q.items.size == 0 && !q.closed {
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return *new(E), false
 		}
-		close(done)
-	}()
-	select {
-	case <-done:
-		value, ok = q.items.Dequeue()
-		q.putLock.Broadcast()
-		return
-	case <-timeout:
-		return
+		waitFor(q.takeLock, remaining)
+	}
+	if q.items.size == 0 {
+		return *new(E), false
+	}
+	return q.shift()
+}
+
+// EnqueueContext enqueues element into the queue.
+// It will block when the size of queue is up to capacity.
+// It returns nil when the element is successfully enqueued or the error of ctx when ctx is done first
+func (q *PriorityBlockingQueue[E]) EnqueueContext(ctx context.Context, value E) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if err := waitContext(ctx, q.putLock, func() bool {
+		return q.cap == q.items.size && !q.closed
+	}); err != nil {
+		return err
 	}
+	if q.closed {
+		return ErrClosed
+	}
+	q.push(value)
+	return nil
+}
+
+// DequeueContext removes the first element and returns it.
+// It will block when the queue is empty.
+// It returns zero value and the error of ctx when ctx is done first
+func (q *PriorityBlockingQueue[E]) DequeueContext(ctx context.Context) (E, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if err := waitContext(ctx, q.takeLock, func() bool {
+		return q.items.size == 0 && !q.closed
+	}); err != nil {
+		return *new(E), err
+	}
+	if q.items.size == 0 {
+		return *new(E), ErrClosed
+	}
+	value, _ := q.shift()
+	return value, nil
+}
+
+func (q *PriorityBlockingQueue[E]) push(value E) bool {
+	ok := q.items.enqueue(value)
+	q.takeLock.Broadcast()
+	return ok
+}
+
+func (q *PriorityBlockingQueue[E]) shift() (E, bool) {
+	value, ok := q.items.dequeue()
+	q.putLock.Broadcast()
+	return value, ok
+}
+
+// Update replaces the first element which is deeply equal to old with the updated one, see [PriorityQueue.Update]
+func (q *PriorityBlockingQueue[E]) Update(old, updated E) bool {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return q.items.update(old, updated)
 }
 
 // Remove removes the specific element
 func (q *PriorityBlockingQueue[E]) Remove(value E) {
-	if q.items.TryLock() {
-		defer q.items.Unlock()
-	}
-	q.items.Remove(value)
+	q.RemoveWhere(func(e E) bool {
+		return reflect.DeepEqual(e, value)
+	})
 }
 
 // RemoveWhere removes elements which matches the callback
 func (q *PriorityBlockingQueue[E]) RemoveWhere(callback func(E) bool) {
-	if q.items.TryLock() {
-		defer q.items.Unlock()
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	q.items.removeWhere(callback)
+	q.putLock.Broadcast()
+}
+
+// Snapshot returns the pending elements and the capacity of the queue, both captured at the same time,
+// so they can be persisted on shutdown and given back to Restore on startup
+func (q *PriorityBlockingQueue[E]) Snapshot() ([]E, SnapshotMeta) {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+	return slices.Clone(q.items.items), SnapshotMeta{Cap: q.cap}
+}
+
+// Restore replaces the elements and the capacity of the queue with a snapshot, blocked callers are woken up,
+// so they see the restored elements. It returns an error and leaves the queue unchanged when the elements exceed the capacity.
+func (q *PriorityBlockingQueue[E]) Restore(items []E, meta SnapshotMeta) error {
+	if err := checkRestore(len(items), meta.Cap); err != nil {
+		return err
+	}
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	q.items.clear()
+	for _, item := range items {
+		q.items.enqueue(item)
 	}
-	q.items.RemoveWhere(callback)
+	q.cap = meta.Cap
+	q.takeLock.Broadcast()
+	q.putLock.Broadcast()
+	return nil
+}
+
+// All returns an iterator over the elements
+func (q *PriorityBlockingQueue[E]) All() iter.Seq[E] {
+	return slices.Values(q.ToArray())
 }
 
-// ToArray converts to array
+// ToArray converts to array, the returned slice is a copy, so modifying it does not affect the collection
 func (q *PriorityBlockingQueue[E]) ToArray() []E {
-	if q.items.TryRLock() {
-		defer q.items.RUnlock()
-	}
-	return q.items.ToArray()
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+	return slices.Clone(q.items.items)
 }
 
 // ToJSON converts to json
 func (q *PriorityBlockingQueue[E]) ToJSON() ([]byte, error) {
-	if q.items.TryLock() {
-		defer q.items.Unlock()
-	}
-	return q.items.ToJSON()
+	return json.Marshal(q.ToArray())
 }
 
 // MarshalJSON implements [json.Marshaller]
@@ -205,51 +332,124 @@ func (q *PriorityBlockingQueue[E]) MarshalJSON() ([]byte, error) {
 	return q.ToJSON()
 }
 
-// UnmarshalJSON implements [json.Unmarshaller]
+// UnmarshalJSON implements [json.Unmarshaller], it blocks while the queue is full and returns [ErrClosed] once the queue is closed
 func (q *PriorityBlockingQueue[E]) UnmarshalJSON(data []byte) error {
-	if q.items.TryLock() {
-		defer q.items.Unlock()
-	}
 	values := make([]E, 0)
 	if err := json.Unmarshal(data, &values); err != nil {
 		return err
 	}
-	q.items.Clear()
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	q.items.clear()
 	for _, value := range values {
-		for q.cap == q.items.Count() {
+		for q.cap == q.items.size && !q.closed {
 			q.putLock.Wait()
 		}
-		q.items.Enqueue(value)
-		q.takeLock.Broadcast()
+		if q.closed {
+			return ErrClosed
+		}
+		q.push(value)
 	}
 	return nil
 }
 
-// String converts to string
-func (q *PriorityBlockingQueue[E]) String() string {
-	if q.items.TryLock() {
-		defer q.items.Unlock()
-	}
-	str := new(strings.Builder)
-	str.WriteString(fmt.Sprintf("PriorityBlockingQueue[%T](len=%d)", *new(E), q.items.Count()))
-	str.WriteByte('{')
-	str.WriteByte('\n')
-	for index, value := range q.items.items {
-		str.WriteByte('\t')
-		if v, ok := any(value).(contract.Stringable); ok {
-			str.WriteString(v.String())
-		} else {
-			str.WriteString(fmt.Sprintf("%v", value))
+// EncodeJSON writes the queue to w as a json array one element at a time, the queue is read locked while writing
+func (q *PriorityBlockingQueue[E]) EncodeJSON(w io.Writer) error {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+	return jsonstream.Encode(w, slices.Values(q.items.items))
+}
+
+// DecodeJSON reads a json array from r one element at a time and enqueues each element after the existing ones,
+// it blocks while the queue is full, so the elements can be consumed while they are decoded.
+// It stops and returns [ErrClosed] once the queue is closed.
+func (q *PriorityBlockingQueue[E]) DecodeJSON(r io.Reader) error {
+	return jsonstream.DecodeFunc(r, func(value E) error {
+		if !q.Enqueue(value) {
+			return ErrClosed
 		}
-		str.WriteByte(',')
-		str.WriteByte('\n')
-		if index >= 4 {
-			break
+		return nil
+	})
+}
+
+// ToCBOR converts to cbor
+func (q *PriorityBlockingQueue[E]) ToCBOR() ([]byte, error) {
+	return cbor.Marshal(q.ToArray())
+}
+
+// MarshalCBOR implements [cbor.Marshaler]
+func (q *PriorityBlockingQueue[E]) MarshalCBOR() ([]byte, error) {
+	return q.ToCBOR()
+}
+
+// UnmarshalCBOR implements [cbor.Unmarshaler], it blocks while the queue is full and returns [ErrClosed] once the queue is closed
+func (q *PriorityBlockingQueue[E]) UnmarshalCBOR(data []byte) error {
+	values := make([]E, 0)
+	if err := cbor.Unmarshal(data, &values); err != nil {
+		return err
+	}
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	q.items.clear()
+	for _, value := range values {
+		for q.cap == q.items.size && !q.closed {
+			q.putLock.Wait()
 		}
+		if q.closed {
+			return ErrClosed
+		}
+		q.push(value)
 	}
-	if q.items.Count() > 5 {
-		str.WriteString("\t...\n")
+	return nil
+}
+
+// MarshalBinary implements [encoding.BinaryMarshaler], the elements are encoded by their own encoders
+func (q *PriorityBlockingQueue[E]) MarshalBinary() ([]byte, error) {
+	return codec.EncodeValues(q.ToArray())
+}
+
+// UnmarshalBinary implements [encoding.BinaryUnmarshaler], it blocks while the queue is full and returns [ErrClosed] once the queue is closed
+func (q *PriorityBlockingQueue[E]) UnmarshalBinary(data []byte) error {
+	values, err := codec.DecodeValues[E](data)
+	if err != nil {
+		return err
+	}
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	q.items.clear()
+	for _, value := range values {
+		for q.cap == q.items.size && !q.closed {
+			q.putLock.Wait()
+		}
+		if q.closed {
+			return ErrClosed
+		}
+		q.push(value)
 	}
-	str.WriteByte('}')
-	return str.String()
+	return nil
+}
+
+// SetPreviewLimit sets the number of elements shown by String, %v and LogValue, a negative limit shows all elements
+func (q *PriorityBlockingQueue[E]) SetPreviewLimit(limit int) {
+	q.limit.Set(limit)
+}
+
+// String converts to string, only the preview limit of elements are shown
+func (q *PriorityBlockingQueue[E]) String() string {
+	return q.format(q.limit.Get(preview.DefaultLimit))
+}
+
+// Format implements [fmt.Formatter], %v prints the preview as String does, %+v and %#v print all elements
+func (q *PriorityBlockingQueue[E]) Format(f fmt.State, verb rune) {
+	preview.Format(f, verb, q.format, q.limit.Get(preview.DefaultLimit))
+}
+
+func (q *PriorityBlockingQueue[E]) format(limit int) string {
+	items := q.ToArray()
+	return preview.Elements(fmt.Sprintf("PriorityBlockingQueue[%T](len=%d)", *new(E), len(items)), items, limit)
+}
+
+// LogValue implements [slog.LogValuer], only the preview limit of elements are logged
+func (q *PriorityBlockingQueue[E]) LogValue() slog.Value {
+	return preview.LogElements(fmt.Sprintf("PriorityBlockingQueue[%T]", *new(E)), q.ToArray(), q.limit.Get(preview.DefaultLimit))
 }
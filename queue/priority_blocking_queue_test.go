@@ -1,12 +1,17 @@
 package queue
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"regexp"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/fxamacker/cbor/v2"
+	"github.com/gopi-frame/collection/comparator"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -69,6 +74,41 @@ func TestPriorityBlockingQueue_TryEnqueue(t *testing.T) {
 	})
 }
 
+func TestPriorityBlockingQueue_TryEnqueueAll(t *testing.T) {
+	queue := NewPriorityBlockingQueue[int](_comparator{}, 3)
+	assert.True(t, queue.TryEnqueueAll(3, 1))
+	assert.False(t, queue.TryEnqueueAll(2, 4))
+	assert.EqualValues(t, 2, queue.Count())
+	assert.True(t, queue.TryEnqueueAll(2))
+	value, _ := queue.TryDequeue()
+	assert.Equal(t, 1, value)
+}
+
+func TestPriorityBlockingQueue_Close(t *testing.T) {
+	queue := NewPriorityBlockingQueue[int](_comparator{}, 3)
+	queue.Enqueue(3)
+	queue.Enqueue(1)
+	queue.Enqueue(2)
+	producer := make(chan bool)
+	go func() {
+		producer <- queue.Enqueue(0)
+	}()
+	assert.Nil(t, queue.Close())
+	assert.True(t, queue.IsClosed())
+	assert.False(t, <-producer)
+	assert.False(t, queue.TryEnqueue(0))
+
+	for _, expected := range []int{1, 2, 3} {
+		value, err := queue.DequeueContext(context.Background())
+		assert.Nil(t, err)
+		assert.Equal(t, expected, value)
+	}
+	_, ok := queue.Dequeue()
+	assert.False(t, ok)
+	_, err := queue.DequeueContext(context.Background())
+	assert.ErrorIs(t, err, ErrClosed)
+}
+
 func TestPriorityBlockingQueue_TryDequeue(t *testing.T) {
 	t.Run("empty", func(t *testing.T) {
 		queue := NewPriorityBlockingQueue[int](_comparator{}, 5)
@@ -190,6 +230,67 @@ func TestPriorityBlockingQueue_UnmarshalJSON(t *testing.T) {
 	err := json.Unmarshal([]byte(`[0,1,2,3,4]`), queue)
 	assert.Nil(t, err)
 	assert.Equal(t, []int{0, 1, 2, 3, 4}, queue.ToArray())
+
+	t.Run("closed", func(t *testing.T) {
+		queue := NewPriorityBlockingQueue[int](_comparator{}, 1)
+		done := make(chan error)
+		go func() {
+			done <- json.Unmarshal([]byte(`[0,1,2]`), queue)
+		}()
+		assert.Eventually(t, queue.IsNotEmpty, time.Second, time.Millisecond)
+		assert.Nil(t, queue.Close())
+		assert.ErrorIs(t, <-done, ErrClosed)
+		assert.Equal(t, []int{0}, queue.ToArray())
+	})
+}
+
+func TestPriorityBlockingQueue_EncodeJSON(t *testing.T) {
+	queue := NewPriorityBlockingQueue[int](_comparator{}, 2)
+	queue.Enqueue(1)
+	queue.Enqueue(2)
+	buf := new(bytes.Buffer)
+	assert.Nil(t, queue.EncodeJSON(buf))
+	assert.JSONEq(t, `[1,2]`, buf.String())
+}
+
+func TestPriorityBlockingQueue_DecodeJSON(t *testing.T) {
+	queue := NewPriorityBlockingQueue[int](_comparator{}, 2)
+	done := make(chan error)
+	go func() {
+		done <- queue.DecodeJSON(strings.NewReader(`[1, 2, 3, 4]`))
+	}()
+	var values []int
+	for i := 0; i < 4; i++ {
+		value, _ := queue.Dequeue()
+		values = append(values, value)
+	}
+	assert.Nil(t, <-done)
+	assert.ElementsMatch(t, []int{1, 2, 3, 4}, values)
+
+	t.Run("existing", func(t *testing.T) {
+		queue := NewPriorityBlockingQueue[int](_comparator{}, 1)
+		queue.Enqueue(0)
+		go func() {
+			done <- queue.DecodeJSON(strings.NewReader(`[1]`))
+		}()
+		value, _ := queue.Dequeue()
+		assert.Equal(t, 0, value)
+		value, _ = queue.Dequeue()
+		assert.Equal(t, 1, value)
+		assert.Nil(t, <-done)
+	})
+
+	t.Run("closed", func(t *testing.T) {
+		queue := NewPriorityBlockingQueue[int](_comparator{}, 1)
+		go func() {
+			done <- queue.DecodeJSON(strings.NewReader(`[1, 2, 3]`))
+		}()
+		assert.Eventually(t, queue.IsNotEmpty, time.Second, time.Millisecond)
+		assert.Nil(t, queue.Close())
+		assert.ErrorIs(t, <-done, ErrClosed)
+		assert.Equal(t, []int{1}, queue.ToArray())
+		assert.ErrorIs(t, queue.DecodeJSON(strings.NewReader(`[4]`)), ErrClosed)
+	})
 }
 
 func TestPriorityBlockingQueue_String(t *testing.T) {
@@ -223,3 +324,107 @@ func TestPriorityBlockingQueue_RemoveWhere(t *testing.T) {
 	assert.Equal(t, int64(2), queue.Count())
 	assert.Equal(t, []int{1, 3}, queue.ToArray())
 }
+
+func TestPriorityBlockingQueue_MarshalCBOR(t *testing.T) {
+	value := NewPriorityBlockingQueue[int](_comparator{}, 5)
+	value.Enqueue(3)
+	value.Enqueue(1)
+	value.Enqueue(2)
+	data, err := cbor.Marshal(value)
+	assert.Nil(t, err)
+	decoded := NewPriorityBlockingQueue[int](_comparator{}, 5)
+	err = cbor.Unmarshal(data, decoded)
+	assert.Nil(t, err)
+	first, _ := decoded.Dequeue()
+	assert.Equal(t, 1, first)
+	assert.EqualValues(t, 2, decoded.Count())
+	assert.Nil(t, decoded.Close())
+	assert.ErrorIs(t, cbor.Unmarshal(data, decoded), ErrClosed)
+}
+
+func TestPriorityBlockingQueue_MarshalBinary(t *testing.T) {
+	value := NewPriorityBlockingQueue[int](_comparator{}, 5)
+	value.Enqueue(3)
+	value.Enqueue(1)
+	value.Enqueue(2)
+	data, err := value.MarshalBinary()
+	assert.Nil(t, err)
+	decoded := NewPriorityBlockingQueue[int](_comparator{}, 5)
+	err = decoded.UnmarshalBinary(data)
+	assert.Nil(t, err)
+	first, _ := decoded.Dequeue()
+	assert.Equal(t, 1, first)
+	assert.EqualValues(t, 2, decoded.Count())
+	assert.Nil(t, decoded.Close())
+	assert.ErrorIs(t, decoded.UnmarshalBinary(data), ErrClosed)
+}
+
+func TestPriorityBlockingQueue_EnqueueContext(t *testing.T) {
+	queue := NewPriorityBlockingQueue[int](_comparator{}, 1)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.Nil(t, queue.EnqueueContext(ctx, 1))
+	assert.ErrorIs(t, queue.EnqueueContext(ctx, 2), context.DeadlineExceeded)
+
+	ctx, cancel = context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+	}()
+	assert.ErrorIs(t, queue.EnqueueContext(ctx, 2), context.Canceled)
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		queue.Dequeue()
+	}()
+	assert.Nil(t, queue.EnqueueContext(context.Background(), 3))
+	assert.Equal(t, []int{3}, queue.ToArray())
+}
+
+func TestPriorityBlockingQueue_DequeueContext(t *testing.T) {
+	queue := NewPriorityBlockingQueue[int](_comparator{}, 1)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	value, err := queue.DequeueContext(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, 0, value)
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		queue.Enqueue(1)
+	}()
+	value, err = queue.DequeueContext(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 1, value)
+}
+
+func TestPriorityBlockingQueue_Ordered(t *testing.T) {
+	queue := NewPriorityBlockingQueueOrdered[string](-1)
+	queue.Enqueue("b")
+	queue.Enqueue("a")
+	value, ok := queue.TryDequeue()
+	assert.True(t, ok)
+	assert.Equal(t, "a", value)
+}
+
+func TestPriorityBlockingQueue_Stable(t *testing.T) {
+	queue := NewStablePriorityBlockingQueue(comparator.ByKey(func(value string) byte { return value[0] }), -1)
+	for _, value := range []string{"b1", "a1", "b2", "a2", "b3"} {
+		queue.Enqueue(value)
+	}
+	var values []string
+	for value, ok := queue.TryDequeue(); ok; value, ok = queue.TryDequeue() {
+		values = append(values, value)
+	}
+	assert.Equal(t, []string{"a1", "a2", "b1", "b2", "b3"}, values)
+}
+
+func TestPriorityBlockingQueue_Update(t *testing.T) {
+	queue := NewPriorityBlockingQueueOrdered[int](-1)
+	queue.Enqueue(2)
+	queue.Enqueue(3)
+	assert.True(t, queue.Update(3, 1))
+	value, ok := queue.TryDequeue()
+	assert.True(t, ok)
+	assert.Equal(t, 1, value)
+}
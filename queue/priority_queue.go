@@ -1,13 +1,22 @@
 package queue
 
 import (
+	"cmp"
 	"encoding/json"
 	"fmt"
+	"io"
+	"iter"
+	"log/slog"
 	"reflect"
 	"slices"
-	"strings"
 	"sync"
 
+	"github.com/fxamacker/cbor/v2"
+	"github.com/gopi-frame/collection/comparator"
+	"github.com/gopi-frame/collection/internal/codec"
+	"github.com/gopi-frame/collection/internal/debug"
+	"github.com/gopi-frame/collection/internal/jsonstream"
+	"github.com/gopi-frame/collection/internal/preview"
 	"github.com/gopi-frame/contract"
 )
 
@@ -16,29 +25,175 @@ func NewPriorityQueue[E any](comparator contract.Comparator[E], values ...E) *Pr
 	queue := new(PriorityQueue[E])
 	queue.comparator = comparator
 	for _, value := range values {
-		queue.Enqueue(value)
+		queue.enqueue(value)
 	}
 	return queue
 }
 
-// PriorityQueue priority queue
+// NewPriorityQueueOrdered new priority queue of ordered elements, the smallest element is dequeued first
+func NewPriorityQueueOrdered[E cmp.Ordered](values ...E) *PriorityQueue[E] {
+	return NewPriorityQueue(comparator.Ordered[E](), values...)
+}
+
+// NewStablePriorityQueue new priority queue which dequeues the elements of equal priority in the order they are enqueued,
+// such as the jobs of the same priority in a scheduler. The decoded elements are sequenced in the order of the array.
+func NewStablePriorityQueue[E any](comparator contract.Comparator[E], values ...E) *PriorityQueue[E] {
+	queue := new(PriorityQueue[E])
+	queue.comparator = comparator
+	queue.stable = true
+	for _, value := range values {
+		queue.enqueue(value)
+	}
+	return queue
+}
+
+// Keep decides which elements a bounded [PriorityQueue] keeps when it overflows
+type Keep int
+
+const (
+	// KeepSmallest keeps the least elements in comparator order and evicts the greatest one,
+	// which is found among the leaves of the heap in O(k) time
+	KeepSmallest Keep = iota
+	// KeepLargest keeps the greatest elements in comparator order and evicts the least one, the first of the queue, in O(log k) time
+	KeepLargest
+)
+
+// String returns the name of the policy
+func (k Keep) String() string {
+	switch k {
+	case KeepSmallest:
+		return "keep-smallest"
+	case KeepLargest:
+		return "keep-largest"
+	}
+	return "unknown"
+}
+
+// NewBoundedPriorityQueue new priority queue which keeps at most k elements, an element enqueued while it is full
+// evicts the worst element by the policy, which may be the enqueued element itself, so the top k of a stream
+// are computed without keeping the stream. The queue still dequeues the least element first.
+// A queue which keeps the smallest elements finds the greatest one in O(k) time on each overflow,
+// a [TopK] with the reversed comparator keeps them in O(log k) time.
+func NewBoundedPriorityQueue[E any](comparator contract.Comparator[E], k int, keep Keep) *PriorityQueue[E] {
+	queue := NewPriorityQueue(comparator)
+	queue.bounded = true
+	queue.cap = int64(max(k, 0))
+	queue.keep = keep
+	return queue
+}
+
+// PriorityQueue priority queue, all methods are safe for concurrent use.
+// The elements of equal priority are dequeued in any order, unless the queue is stable, see NewStablePriorityQueue.
+// The callbacks run while the queue is locked, so they must not call methods of the same queue.
 type PriorityQueue[E any] struct {
-	sync.RWMutex
+	lock       sync.RWMutex
 	size       int64
 	items      []E
 	comparator contract.Comparator[E]
+	limit      preview.Limit
+	// stable queues tag each element with the sequence number of its enqueue in seqs, which is parallel to items,
+	// the earlier one of two elements of equal priority is the less one
+	stable bool
+	seqs   []uint64
+	seq    uint64
+	// bounded queues keep at most cap elements and evict by keep, see NewBoundedPriorityQueue
+	bounded bool
+	cap     int64
+	keep    Keep
 }
 
 func (q *PriorityQueue[E]) less(i, j int64) bool {
-	return q.comparator.Compare(q.items[i], q.items[j]) < 0
+	c := q.comparator.Compare(q.items[i], q.items[j])
+	if c == 0 && q.stable {
+		return q.seqs[i] < q.seqs[j]
+	}
+	return c < 0
 }
 
 func (q *PriorityQueue[E]) swap(i, j int64) {
 	q.items[i], q.items[j] = q.items[j], q.items[i]
+	if q.stable {
+		q.seqs[i], q.seqs[j] = q.seqs[j], q.seqs[i]
+	}
+}
+
+// push appends the element with its sequence number and moves it up to its place
+func (q *PriorityQueue[E]) push(value E, seq uint64) {
+	q.items = append(q.items, value)
+	if q.stable {
+		q.seqs = append(q.seqs, seq)
+	}
+	q.size++
+	q.up(q.size - 1)
+}
+
+// up moves the element at the index up while it is less than its parent
+func (q *PriorityQueue[E]) up(index int64) {
+	for ; q.less(index, (index-1)/2); index = (index - 1) / 2 {
+		q.swap(index, (index-1)/2)
+	}
+}
+
+// down moves the element at the index down while one of its children is less than it
+func (q *PriorityQueue[E]) down(index int64) {
+	lastIndex := q.size - 1
+	for {
+		leftIndex := index*2 + 1
+		if leftIndex > lastIndex || leftIndex < 0 {
+			break
+		}
+		swapIndex := leftIndex
+		if rightIndex := leftIndex + 1; rightIndex <= lastIndex && q.less(rightIndex, leftIndex) {
+			swapIndex = rightIndex
+		}
+		if !q.less(swapIndex, index) {
+			break
+		}
+		q.swap(swapIndex, index)
+		index = swapIndex
+	}
+}
+
+// truncate removes the last element of the heap
+func (q *PriorityQueue[E]) truncate() {
+	q.items = q.items[:q.size-1]
+	if q.stable {
+		q.seqs = q.seqs[:q.size-1]
+	}
+	q.size--
+}
+
+// validate checks the size counter and that no element is less than its parent
+func (q *PriorityQueue[E]) validate() error {
+	if q.size != int64(len(q.items)) {
+		return fmt.Errorf("queue has size %d, expected %d", q.size, len(q.items))
+	}
+	if q.bounded && q.size > q.cap {
+		return fmt.Errorf("queue has size %d over its capacity %d", q.size, q.cap)
+	}
+	if q.stable && len(q.seqs) != len(q.items) {
+		return fmt.Errorf("queue has %d sequence numbers, expected %d", len(q.seqs), len(q.items))
+	}
+	for index := int64(1); index < q.size; index++ {
+		if q.less(index, (index-1)/2) {
+			return fmt.Errorf("element %v at %d is less than its parent %v at %d", q.items[index], index, q.items[(index-1)/2], (index-1)/2)
+		}
+	}
+	return nil
+}
+
+// unlock unlocks the queue after a mutation, the invariants are checked first in debug builds
+func (q *PriorityQueue[E]) unlock() {
+	if debug.Enabled {
+		debug.Check(q, q.validate())
+	}
+	q.lock.Unlock()
 }
 
 // Count returns the size of queue
 func (q *PriorityQueue[E]) Count() int64 {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
 	return q.size
 }
 
@@ -54,56 +209,144 @@ func (q *PriorityQueue[E]) IsNotEmpty() bool {
 
 // Clear clears the queue
 func (q *PriorityQueue[E]) Clear() {
+	q.lock.Lock()
+	defer q.unlock()
+	q.clear()
+}
+
+func (q *PriorityQueue[E]) clear() {
 	q.items = make([]E, 0)
+	q.seqs = nil
 	q.size = 0
 }
 
 // Peek returns the first element of the queue
 func (q *PriorityQueue[E]) Peek() (E, bool) {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+	return q.peek()
+}
+
+func (q *PriorityQueue[E]) peek() (E, bool) {
 	if q.size == 0 {
 		return *new(E), false
 	}
 	return q.items[0], true
 }
 
-// Enqueue enqueues a new element into the queue, it will block if the size is up to capacity
+// Enqueue enqueues a new element into the queue, it never blocks and always returns true unless the queue is bounded,
+// a full bounded queue returns false when it evicts the enqueued element itself
 func (q *PriorityQueue[E]) Enqueue(value E) bool {
-	q.items = append(q.items, value)
-	q.size++
-	for index := q.size - 1; q.less(index, (index-1)/2); index = (index - 1) / 2 {
-		q.swap(index, (index-1)/2)
+	q.lock.Lock()
+	defer q.unlock()
+	return q.enqueue(value)
+}
+
+func (q *PriorityQueue[E]) enqueue(value E) bool {
+	if q.bounded && q.size >= q.cap {
+		if !q.evict(value) {
+			return false
+		}
 	}
+	q.push(value, q.seq)
+	q.seq++
 	return true
 }
 
-// Dequeue dequeues the first element of queue, it will block if the queue is empty
-func (q *PriorityQueue[E]) Dequeue() (value E, ok bool) {
+// evict removes the worst element of a full bounded queue to make room for the value,
+// it returns false and removes nothing when the value is the worst, a value equal to the worst is the worse as it comes later
+func (q *PriorityQueue[E]) evict(value E) bool {
+	if q.size == 0 {
+		return false
+	}
+	switch q.keep {
+	case KeepLargest:
+		if q.comparator.Compare(value, q.items[0]) <= 0 {
+			return false
+		}
+		q.dequeue()
+	default:
+		if q.comparator.Compare(value, q.items[q.lastIndex()]) >= 0 {
+			return false
+		}
+		q.dequeueLast()
+	}
+	return true
+}
+
+// Cap returns the number of elements a bounded queue keeps at most, it is -1 for an unbounded queue
+func (q *PriorityQueue[E]) Cap() int64 {
+	if !q.bounded {
+		return -1
+	}
+	return q.cap
+}
+
+// Dequeue dequeues the first element of queue, it never blocks and returns zero value and false when the queue is empty
+func (q *PriorityQueue[E]) Dequeue() (E, bool) {
+	q.lock.Lock()
+	defer q.unlock()
+	return q.dequeue()
+}
+
+func (q *PriorityQueue[E]) dequeue() (value E, ok bool) {
 	if q.size == 0 {
 		return *new(E), false
 	}
 	value = q.items[0]
 	ok = true
 	q.swap(0, q.size-1)
-	q.items = q.items[:q.size-1]
-	q.size--
-	index := int64(0)
-	lastIndex := q.size - 1
-	for {
-		leftIndex := index*2 + 1
-		if leftIndex > lastIndex || leftIndex < 0 {
-			break
-		}
-		swapIndex := leftIndex
-		if rightIndex := leftIndex + 1; rightIndex <= lastIndex && q.less(rightIndex, leftIndex) {
-			swapIndex = rightIndex
-		}
-		if !q.less(swapIndex, index) {
-			break
+	q.truncate()
+	q.down(0)
+	return
+}
+
+// lastIndex returns the index of the greatest element, it is one of the leaves as no element is less than its parent
+func (q *PriorityQueue[E]) lastIndex() int64 {
+	last := q.size / 2
+	for index := last + 1; index < q.size; index++ {
+		if q.less(last, index) {
+			last = index
 		}
-		q.swap(swapIndex, index)
-		index = swapIndex
 	}
-	return
+	return last
+}
+
+// dequeueLast removes the greatest element and returns it
+func (q *PriorityQueue[E]) dequeueLast() (E, bool) {
+	if q.size == 0 {
+		return *new(E), false
+	}
+	last := q.lastIndex()
+	value := q.items[last]
+	q.swap(last, q.size-1)
+	q.truncate()
+	if last < q.size {
+		q.up(last)
+	}
+	return value, true
+}
+
+// Update replaces the first element which is deeply equal to old with the updated one and moves it to its new place,
+// such as decreasing the distance of a node in Dijkstra's algorithm. It returns false when there is no such element.
+// The element keeps its sequence number in a stable queue, so it stays before the elements of equal priority enqueued after it.
+func (q *PriorityQueue[E]) Update(old, updated E) bool {
+	q.lock.Lock()
+	defer q.unlock()
+	return q.update(old, updated)
+}
+
+func (q *PriorityQueue[E]) update(old, updated E) bool {
+	index := slices.IndexFunc(q.items, func(item E) bool {
+		return reflect.DeepEqual(item, old)
+	})
+	if index < 0 {
+		return false
+	}
+	q.items[index] = updated
+	q.up(int64(index))
+	q.down(int64(index))
+	return true
 }
 
 // Remove removes the specific element
@@ -115,13 +358,37 @@ func (q *PriorityQueue[E]) Remove(value E) {
 
 // RemoveWhere removes elements which matches the callback
 func (q *PriorityQueue[E]) RemoveWhere(callback func(E) bool) {
-	q.items = slices.DeleteFunc(q.items, callback)
-	q.size = int64(len(q.items))
+	q.lock.Lock()
+	defer q.unlock()
+	q.removeWhere(callback)
+}
+
+// removeWhere rebuilds the heap from the elements which do not match the callback, they keep their sequence numbers
+func (q *PriorityQueue[E]) removeWhere(callback func(E) bool) {
+	items, seqs := q.items, q.seqs
+	q.clear()
+	for index, item := range items {
+		if callback(item) {
+			continue
+		}
+		if q.stable {
+			q.push(item, seqs[index])
+		} else {
+			q.push(item, 0)
+		}
+	}
 }
 
-// ToArray converts to array
+// All returns an iterator over the elements
+func (q *PriorityQueue[E]) All() iter.Seq[E] {
+	return slices.Values(q.ToArray())
+}
+
+// ToArray converts to array, the returned slice is a copy, so modifying it does not affect the collection
 func (q *PriorityQueue[E]) ToArray() []E {
-	return q.items
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+	return slices.Clone(q.items)
 }
 
 // ToJSON converts to json
@@ -139,37 +406,110 @@ func (q *PriorityQueue[E]) UnmarshalJSON(data []byte) error {
 	items := []E{}
 	err := json.Unmarshal(data, &items)
 	if err != nil {
-		return nil
+		return err
 	}
-	q.Clear()
+	q.lock.Lock()
+	defer q.unlock()
+	q.clear()
 	for _, item := range items {
-		q.Enqueue(item)
+		q.enqueue(item)
 	}
 	return nil
 }
 
-// String converts to string
-func (q *PriorityQueue[E]) String() string {
-	str := new(strings.Builder)
-	str.WriteString(fmt.Sprintf("PriorityQueue[%T](len=%d)", *new(E), q.Count()))
-	str.WriteByte('{')
-	str.WriteByte('\n')
-	for index, value := range q.items {
-		str.WriteByte('\t')
-		if v, ok := any(value).(contract.Stringable); ok {
-			str.WriteString(v.String())
-		} else {
-			str.WriteString(fmt.Sprintf("%v", value))
-		}
-		str.WriteByte(',')
-		str.WriteByte('\n')
-		if index >= 4 {
-			break
-		}
+// EncodeJSON writes the queue to w as a json array one element at a time, the queue is read locked while writing
+func (q *PriorityQueue[E]) EncodeJSON(w io.Writer) error {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+	return jsonstream.Encode(w, slices.Values(q.items))
+}
+
+// DecodeJSON reads a json array from r one element at a time and replaces the elements of the queue
+func (q *PriorityQueue[E]) DecodeJSON(r io.Reader) error {
+	decoded := NewPriorityQueue(q.comparator)
+	decoded.stable = q.stable
+	decoded.bounded, decoded.cap, decoded.keep = q.bounded, q.cap, q.keep
+	if err := jsonstream.Decode(r, func(value E) {
+		decoded.enqueue(value)
+	}); err != nil {
+		return err
 	}
-	if q.Count() > 5 {
-		str.WriteString("\t...\n")
+	q.lock.Lock()
+	defer q.unlock()
+	q.items = decoded.items
+	q.seqs = decoded.seqs
+	q.seq = decoded.seq
+	q.size = decoded.size
+	return nil
+}
+
+// ToCBOR converts to cbor
+func (q *PriorityQueue[E]) ToCBOR() ([]byte, error) {
+	return cbor.Marshal(q.ToArray())
+}
+
+// MarshalCBOR implements [cbor.Marshaler]
+func (q *PriorityQueue[E]) MarshalCBOR() ([]byte, error) {
+	return q.ToCBOR()
+}
+
+// UnmarshalCBOR implements [cbor.Unmarshaler]
+func (q *PriorityQueue[E]) UnmarshalCBOR(data []byte) error {
+	items := []E{}
+	err := cbor.Unmarshal(data, &items)
+	if err != nil {
+		return err
 	}
-	str.WriteByte('}')
-	return str.String()
+	q.lock.Lock()
+	defer q.unlock()
+	q.clear()
+	for _, item := range items {
+		q.enqueue(item)
+	}
+	return nil
+}
+
+// MarshalBinary implements [encoding.BinaryMarshaler], the elements are encoded by their own encoders
+func (q *PriorityQueue[E]) MarshalBinary() ([]byte, error) {
+	return codec.EncodeValues(q.ToArray())
+}
+
+// UnmarshalBinary implements [encoding.BinaryUnmarshaler]
+func (q *PriorityQueue[E]) UnmarshalBinary(data []byte) error {
+	items, err := codec.DecodeValues[E](data)
+	if err != nil {
+		return err
+	}
+	q.lock.Lock()
+	defer q.unlock()
+	q.clear()
+	for _, item := range items {
+		q.enqueue(item)
+	}
+	return nil
+}
+
+// SetPreviewLimit sets the number of elements shown by String, %v and LogValue, a negative limit shows all elements
+func (q *PriorityQueue[E]) SetPreviewLimit(limit int) {
+	q.limit.Set(limit)
+}
+
+// String converts to string, only the preview limit of elements are shown
+func (q *PriorityQueue[E]) String() string {
+	return q.format(q.limit.Get(preview.DefaultLimit))
+}
+
+// Format implements [fmt.Formatter], %v prints the preview as String does, %+v and %#v print all elements
+func (q *PriorityQueue[E]) Format(f fmt.State, verb rune) {
+	preview.Format(f, verb, q.format, q.limit.Get(preview.DefaultLimit))
+}
+
+func (q *PriorityQueue[E]) format(limit int) string {
+	items := q.ToArray()
+	return preview.Elements(fmt.Sprintf("PriorityQueue[%T](len=%d)", *new(E), len(items)), items, limit)
+}
+
+// LogValue implements [slog.LogValuer], only the preview limit of elements are logged
+func (q *PriorityQueue[E]) LogValue() slog.Value {
+	return preview.LogElements(fmt.Sprintf("PriorityQueue[%T]", *new(E)), q.ToArray(), q.limit.Get(preview.DefaultLimit))
 }
@@ -1,11 +1,16 @@
 package queue
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"math/rand/v2"
 	"regexp"
+	"strings"
 	"testing"
 
+	"github.com/fxamacker/cbor/v2"
+	"github.com/gopi-frame/collection/comparator"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -65,6 +70,16 @@ func TestPriorityQueue_Dequeue(t *testing.T) {
 	assert.EqualValues(t, []int{2, 3}, queue.ToArray())
 }
 
+func TestPriorityQueue_ToArray(t *testing.T) {
+	queue := NewPriorityQueue(_comparator{}, 2, 1, 3)
+	items := queue.ToArray()
+	assert.ElementsMatch(t, []int{1, 2, 3}, items)
+	items[0] = 10
+	v, ok := queue.Dequeue()
+	assert.True(t, ok)
+	assert.Equal(t, 1, v)
+}
+
 func TestPriorityQueue_ToJSON(t *testing.T) {
 	queue := NewPriorityQueue(_comparator{}, 1, 2, 3)
 	jsonBytes, err := queue.ToJSON()
@@ -86,9 +101,153 @@ func TestPriorityQueue_UnmarshalJSON(t *testing.T) {
 	assert.EqualValues(t, []int{1, 2, 3}, queue.ToArray())
 }
 
+func TestPriorityQueue_EncodeJSON(t *testing.T) {
+	value := NewPriorityQueue[int](_comparator{}, 3, 1, 2)
+	buf := new(bytes.Buffer)
+	assert.Nil(t, value.EncodeJSON(buf))
+	expected, _ := value.ToJSON()
+	assert.JSONEq(t, string(expected), buf.String())
+}
+
+func TestPriorityQueue_DecodeJSON(t *testing.T) {
+	value := NewPriorityQueue[int](_comparator{}, 3, 1, 2)
+	assert.Nil(t, value.DecodeJSON(strings.NewReader(`[4, 5]`)))
+	assert.ElementsMatch(t, []int{4, 5}, value.ToArray())
+	assert.Error(t, value.DecodeJSON(strings.NewReader(`[6, "a"]`)))
+	assert.ElementsMatch(t, []int{4, 5}, value.ToArray())
+}
+
 func TestPriorityQueue_String(t *testing.T) {
 	queue := NewPriorityQueue(_comparator{}, 1, 2, 3, 4, 5, 6, 7)
 	str := queue.String()
 	pattern := regexp.MustCompile(fmt.Sprintf(`PriorityQueue\[int\]\(len=%d\)\{\n(\t\d+,\n){5}\t(\.){3}\n\}`, queue.Count()))
 	assert.True(t, pattern.Match([]byte(str)))
 }
+
+func TestPriorityQueue_MarshalCBOR(t *testing.T) {
+	value := NewPriorityQueue(_comparator{}, 3, 1, 2)
+	data, err := cbor.Marshal(value)
+	assert.Nil(t, err)
+	decoded := NewPriorityQueue[int](_comparator{})
+	err = cbor.Unmarshal(data, decoded)
+	assert.Nil(t, err)
+	first, _ := decoded.Dequeue()
+	assert.Equal(t, 1, first)
+	assert.EqualValues(t, 2, decoded.Count())
+}
+
+func TestPriorityQueue_MarshalBinary(t *testing.T) {
+	value := NewPriorityQueue(_comparator{}, 3, 1, 2)
+	data, err := value.MarshalBinary()
+	assert.Nil(t, err)
+	decoded := NewPriorityQueue[int](_comparator{})
+	err = decoded.UnmarshalBinary(data)
+	assert.Nil(t, err)
+	first, _ := decoded.Dequeue()
+	assert.Equal(t, 1, first)
+	assert.EqualValues(t, 2, decoded.Count())
+}
+
+func TestPriorityQueue_Ordered(t *testing.T) {
+	queue := NewPriorityQueueOrdered("b", "c", "a")
+	assert.Equal(t, []string{"a", "b", "c"}, drainPriorityQueue(queue))
+	reversed := NewPriorityQueue(comparator.Reverse(comparator.Ordered[int]()), 1, 3, 2)
+	assert.Equal(t, []int{3, 2, 1}, drainPriorityQueue(reversed))
+}
+
+func drainPriorityQueue[E any](queue *PriorityQueue[E]) []E {
+	var values []E
+	for value, ok := queue.Dequeue(); ok; value, ok = queue.Dequeue() {
+		values = append(values, value)
+	}
+	return values
+}
+
+type _job struct {
+	priority int
+	name     string
+}
+
+func TestPriorityQueue_Stable(t *testing.T) {
+	byPriority := comparator.ByKey(func(job _job) int { return job.priority })
+	queue := NewStablePriorityQueue(byPriority)
+	for index := range 20 {
+		queue.Enqueue(_job{index % 3, fmt.Sprint(index)})
+	}
+	queue.RemoveWhere(func(job _job) bool { return job.name == "3" })
+	var names []string
+	for job, ok := queue.Dequeue(); ok; job, ok = queue.Dequeue() {
+		names = append(names, job.name)
+	}
+	assert.Equal(t, strings.Fields("0 6 9 12 15 18 1 4 7 10 13 16 19 2 5 8 11 14 17"), names)
+}
+
+func TestPriorityQueue_Stable_DecodeJSON(t *testing.T) {
+	queue := NewStablePriorityQueue(comparator.ByKey(func(value float64) int { return int(value) }))
+	assert.NoError(t, queue.DecodeJSON(strings.NewReader(`[1.3, 0.5, 1.1, 0.2, 1.2]`)))
+	queue.Enqueue(0.1)
+	assert.Equal(t, []float64{0.5, 0.2, 0.1, 1.3, 1.1, 1.2}, drainPriorityQueue(queue))
+}
+
+func TestPriorityQueue_Update(t *testing.T) {
+	queue := NewPriorityQueue(_comparator{}, 5, 3, 8, 1, 9, 7)
+	assert.True(t, queue.Update(8, 0))
+	assert.True(t, queue.Update(1, 10))
+	assert.False(t, queue.Update(4, 2))
+	assert.Equal(t, []int{0, 3, 5, 7, 9, 10}, drainPriorityQueue(queue))
+}
+
+func TestPriorityQueue_Update_Stable(t *testing.T) {
+	queue := NewStablePriorityQueue(comparator.ByKey(func(job _job) int { return job.priority }))
+	queue.Enqueue(_job{1, "a"})
+	queue.Enqueue(_job{0, "b"})
+	queue.Enqueue(_job{1, "c"})
+	assert.True(t, queue.Update(_job{1, "c"}, _job{0, "c"}))
+	assert.True(t, queue.Update(_job{1, "a"}, _job{0, "a"}))
+	var names []string
+	for job, ok := queue.Dequeue(); ok; job, ok = queue.Dequeue() {
+		names = append(names, job.name)
+	}
+	assert.Equal(t, []string{"a", "b", "c"}, names)
+}
+
+func TestPriorityQueue_Bounded(t *testing.T) {
+	values := rand.Perm(1000)
+	largest := NewBoundedPriorityQueue[int](_comparator{}, 5, KeepLargest)
+	smallest := NewBoundedPriorityQueue[int](_comparator{}, 5, KeepSmallest)
+	for _, value := range values {
+		largest.Enqueue(value)
+		smallest.Enqueue(value)
+	}
+	assert.Equal(t, int64(5), largest.Cap())
+	assert.Equal(t, []int{995, 996, 997, 998, 999}, drain[int](largest))
+	assert.Equal(t, []int{0, 1, 2, 3, 4}, drain[int](smallest))
+	assert.Equal(t, int64(-1), NewPriorityQueue[int](_comparator{}).Cap())
+
+	t.Run("evicts enqueued", func(t *testing.T) {
+		queue := NewBoundedPriorityQueue[int](_comparator{}, 2, KeepSmallest)
+		assert.True(t, queue.Enqueue(3))
+		assert.True(t, queue.Enqueue(1))
+		assert.False(t, queue.Enqueue(3))
+		assert.True(t, queue.Enqueue(2))
+		assert.Equal(t, []int{1, 2}, drain[int](queue))
+
+		queue = NewBoundedPriorityQueue[int](_comparator{}, 0, KeepLargest)
+		assert.False(t, queue.Enqueue(1))
+		assert.True(t, queue.IsEmpty())
+	})
+
+	t.Run("decoded", func(t *testing.T) {
+		queue := NewBoundedPriorityQueue[int](_comparator{}, 2, KeepLargest)
+		assert.NoError(t, json.Unmarshal([]byte(`[5,1,4,2]`), queue))
+		assert.Equal(t, []int{4, 5}, drain[int](queue))
+		assert.NoError(t, queue.DecodeJSON(strings.NewReader(`[3,9,1]`)))
+		assert.Equal(t, []int{3, 9}, drain[int](queue))
+	})
+}
+
+func TestKeep_String(t *testing.T) {
+	assert.Equal(t, "keep-smallest", KeepSmallest.String())
+	assert.Equal(t, "keep-largest", KeepLargest.String())
+	assert.Equal(t, "unknown", Keep(-1).String())
+}
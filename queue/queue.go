@@ -1,14 +1,34 @@
 package queue
 
 import (
-	"encoding/json"
 	"fmt"
-	"strings"
+	"io"
+	"iter"
+	"log/slog"
+	"slices"
 
+	"github.com/gopi-frame/collection/internal/preview"
 	"github.com/gopi-frame/collection/list"
-	"github.com/gopi-frame/contract"
 )
 
+// Interface queue, it is implemented by the queues of this package which take and return one element at a time,
+// such as [Queue], [LinkedQueue], [PriorityQueue] and [PairingHeap].
+// Enqueue and Dequeue never block, the blocking queues provide the waiting variants besides them.
+type Interface[E any] interface {
+	// Enqueue enqueues the element, it returns false when the queue refuses it, such as a full bounded queue
+	Enqueue(value E) bool
+	// Dequeue removes the first element and returns it, it returns zero value and false when the queue is empty
+	Dequeue() (E, bool)
+	// Peek returns the first element, it returns zero value and false when the queue is empty
+	Peek() (E, bool)
+	// Count returns the size of the queue
+	Count() int64
+	// Clear clears the queue
+	Clear()
+	// ToArray returns the elements
+	ToArray() []E
+}
+
 // NewQueue new queue
 func NewQueue[E any](values ...E) *Queue[E] {
 	queue := new(Queue[E])
@@ -16,39 +36,11 @@ func NewQueue[E any](values ...E) *Queue[E] {
 	return queue
 }
 
-// Queue array queue
+// Queue array queue, all methods are safe for concurrent use.
+// The callbacks run while the queue is locked, so they must not call methods of the same queue.
 type Queue[E any] struct {
 	items *list.List[E]
-}
-
-// Lock locks the queue
-func (q *Queue[E]) Lock() {
-	q.items.Lock()
-}
-
-// Unlock unlocks the queue
-func (q *Queue[E]) Unlock() {
-	q.items.Unlock()
-}
-
-// TryLock tries to lock the queue
-func (q *Queue[E]) TryLock() bool {
-	return q.items.TryLock()
-}
-
-// RLock locks the read lock for the queue
-func (q *Queue[E]) RLock() {
-	q.items.RLock()
-}
-
-// TryRLock tries to lock the read lock for the queue
-func (q *Queue[E]) TryRLock() bool {
-	return q.items.TryRLock()
-}
-
-// RUnlock unlocks the read lock for the queue
-func (q *Queue[E]) RUnlock() {
-	q.items.RUnlock()
+	limit preview.Limit
 }
 
 // Count returns the size of queue
@@ -76,13 +68,13 @@ func (q *Queue[E]) Peek() (E, bool) {
 	return q.items.First()
 }
 
-// Enqueue enqueues a new element into the queue, it will block if the size is up to capacity
+// Enqueue enqueues a new element into the queue, the queue is unbounded, so it never blocks and always returns true
 func (q *Queue[E]) Enqueue(value E) bool {
 	q.items.Push(value)
 	return true
 }
 
-// Dequeue dequeues the first element of queue, it will block if the queue is empty
+// Dequeue dequeues the first element of queue, it never blocks and returns zero value and false when the queue is empty
 func (q *Queue[E]) Dequeue() (E, bool) {
 	return q.items.Shift()
 }
@@ -97,7 +89,12 @@ func (q *Queue[E]) RemoveWhere(callback func(value E) bool) {
 	q.items.RemoveWhere(callback)
 }
 
-// ToArray converts to array
+// All returns an iterator over the elements
+func (q *Queue[E]) All() iter.Seq[E] {
+	return slices.Values(q.ToArray())
+}
+
+// ToArray converts to array, the returned slice is a copy, so modifying it does not affect the collection
 func (q *Queue[E]) ToArray() []E {
 	return q.items.ToArray()
 }
@@ -114,35 +111,65 @@ func (q *Queue[E]) MarshalJSON() ([]byte, error) {
 
 // UnmarshalJSON implements [json.Unmarshaller]
 func (q *Queue[E]) UnmarshalJSON(data []byte) error {
-	var values []E
-	err := json.Unmarshal(data, &values)
-	if err != nil {
-		return err
-	}
-	q.items = list.NewList[E](values...)
-	return nil
+	return q.items.UnmarshalJSON(data)
+}
+
+// EncodeJSON writes the queue to w as a json array one element at a time, the queue is read locked while writing
+func (q *Queue[E]) EncodeJSON(w io.Writer) error {
+	return q.items.EncodeJSON(w)
+}
+
+// DecodeJSON reads a json array from r one element at a time, see UnmarshalJSON
+func (q *Queue[E]) DecodeJSON(r io.Reader) error {
+	return q.items.DecodeJSON(r)
+}
+
+// ToCBOR converts to cbor
+func (q *Queue[E]) ToCBOR() ([]byte, error) {
+	return q.items.ToCBOR()
+}
+
+// MarshalCBOR implements [cbor.Marshaler]
+func (q *Queue[E]) MarshalCBOR() ([]byte, error) {
+	return q.ToCBOR()
 }
 
-// String converts to string
+// UnmarshalCBOR implements [cbor.Unmarshaler]
+func (q *Queue[E]) UnmarshalCBOR(data []byte) error {
+	return q.items.UnmarshalCBOR(data)
+}
+
+// MarshalBinary implements [encoding.BinaryMarshaler], the elements are encoded by their own encoders
+func (q *Queue[E]) MarshalBinary() ([]byte, error) {
+	return q.items.MarshalBinary()
+}
+
+// UnmarshalBinary implements [encoding.BinaryUnmarshaler]
+func (q *Queue[E]) UnmarshalBinary(data []byte) error {
+	return q.items.UnmarshalBinary(data)
+}
+
+// SetPreviewLimit sets the number of elements shown by String, %v and LogValue, a negative limit shows all elements
+func (q *Queue[E]) SetPreviewLimit(limit int) {
+	q.limit.Set(limit)
+}
+
+// String converts to string, only the preview limit of elements are shown
 func (q *Queue[E]) String() string {
-	str := new(strings.Builder)
-	str.WriteString(fmt.Sprintf("Queue[%T](len=%d)", *new(E), q.Count()))
-	str.WriteByte('{')
-	str.WriteByte('\n')
-	q.items.Each(func(index int, value E) bool {
-		str.WriteByte('\t')
-		if v, ok := any(value).(contract.Stringable); ok {
-			str.WriteString(v.String())
-		} else {
-			str.WriteString(fmt.Sprintf("%v", value))
-		}
-		str.WriteByte(',')
-		str.WriteByte('\n')
-		return index < 4
-	})
-	if q.Count() > 5 {
-		str.WriteString("\t...\n")
-	}
-	str.WriteByte('}')
-	return str.String()
+	return q.format(q.limit.Get(preview.DefaultLimit))
+}
+
+// Format implements [fmt.Formatter], %v prints the preview as String does, %+v and %#v print all elements
+func (q *Queue[E]) Format(f fmt.State, verb rune) {
+	preview.Format(f, verb, q.format, q.limit.Get(preview.DefaultLimit))
+}
+
+func (q *Queue[E]) format(limit int) string {
+	items := q.ToArray()
+	return preview.Elements(fmt.Sprintf("Queue[%T](len=%d)", *new(E), len(items)), items, limit)
+}
+
+// LogValue implements [slog.LogValuer], only the preview limit of elements are logged
+func (q *Queue[E]) LogValue() slog.Value {
+	return preview.LogElements(fmt.Sprintf("Queue[%T]", *new(E)), q.ToArray(), q.limit.Get(preview.DefaultLimit))
 }
@@ -1,14 +1,28 @@
 package queue
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"github.com/fxamacker/cbor/v2"
 	"github.com/stretchr/testify/assert"
 	"regexp"
+	"strings"
 	"sync"
 	"testing"
 )
 
+var (
+	_ Interface[int] = (*Queue[int])(nil)
+	_ Interface[int] = (*LinkedQueue[int])(nil)
+	_ Interface[int] = (*BlockingQueue[int])(nil)
+	_ Interface[int] = (*LinkedBlockingQueue[int])(nil)
+	_ Interface[int] = (*PriorityQueue[int])(nil)
+	_ Interface[int] = (*PriorityBlockingQueue[int])(nil)
+	_ Interface[int] = (*RingQueue[int])(nil)
+	_ Interface[int] = (*PairingHeap[int])(nil)
+)
+
 func TestQueue_Count(t *testing.T) {
 	queue := NewQueue(1, 2, 3)
 	assert.Equal(t, int64(3), queue.Count())
@@ -55,9 +69,7 @@ func TestQueue_Enqueue(t *testing.T) {
 			wg.Add(1)
 			expected = append(expected, i)
 			go func(i int) {
-				queue.Lock()
 				assert.True(t, queue.Enqueue(i))
-				queue.Unlock()
 				wg.Done()
 			}(i)
 		}
@@ -95,6 +107,22 @@ func TestQueue_UnmarshalJSON(t *testing.T) {
 	assert.EqualValues(t, []int{1, 2, 3}, queue.ToArray())
 }
 
+func TestQueue_EncodeJSON(t *testing.T) {
+	value := NewQueue(1, 2, 3)
+	buf := new(bytes.Buffer)
+	assert.Nil(t, value.EncodeJSON(buf))
+	expected, _ := value.ToJSON()
+	assert.JSONEq(t, string(expected), buf.String())
+}
+
+func TestQueue_DecodeJSON(t *testing.T) {
+	value := NewQueue(1, 2, 3)
+	assert.Nil(t, value.DecodeJSON(strings.NewReader(`[4, 5]`)))
+	assert.Equal(t, []int{4, 5}, value.ToArray())
+	assert.Error(t, value.DecodeJSON(strings.NewReader(`[6, "a"]`)))
+	assert.Equal(t, []int{4, 5}, value.ToArray())
+}
+
 func TestQueue_String(t *testing.T) {
 	queue := NewQueue(1, 2, 3, 4, 5, 6, 7)
 	str := queue.String()
@@ -117,3 +145,23 @@ func TestQueue_RemoveWhere(t *testing.T) {
 	assert.Equal(t, int64(2), queue.Count())
 	assert.Equal(t, []int{2, 4}, queue.ToArray())
 }
+
+func TestQueue_MarshalCBOR(t *testing.T) {
+	value := NewQueue(1, 2, 3)
+	data, err := cbor.Marshal(value)
+	assert.Nil(t, err)
+	decoded := NewQueue[int]()
+	err = cbor.Unmarshal(data, decoded)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1, 2, 3}, decoded.ToArray())
+}
+
+func TestQueue_MarshalBinary(t *testing.T) {
+	value := NewQueue(1, 2, 3)
+	data, err := value.MarshalBinary()
+	assert.Nil(t, err)
+	decoded := NewQueue[int]()
+	err = decoded.UnmarshalBinary(data)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1, 2, 3}, decoded.ToArray())
+}
@@ -0,0 +1,225 @@
+package queue
+
+import (
+	"encoding/json"
+	"fmt"
+	"iter"
+	"log/slog"
+	"reflect"
+	"slices"
+	"sync"
+
+	"github.com/gopi-frame/collection/internal/preview"
+)
+
+// NewRingQueue new ring queue with the fixed capacity, Enqueue refuses elements while it is full
+func NewRingQueue[E any](cap int) *RingQueue[E] {
+	return &RingQueue[E]{items: make([]E, max(cap, 0))}
+}
+
+// NewOverwritingRingQueue new ring queue with the fixed capacity, Enqueue overwrites the oldest element while it is full,
+// so the queue keeps the latest elements, such as the recent events of a log
+func NewOverwritingRingQueue[E any](cap int) *RingQueue[E] {
+	queue := NewRingQueue[E](cap)
+	queue.overwrite = true
+	return queue
+}
+
+// RingQueue bounded queue on a circular buffer, all methods are safe for concurrent use.
+// The buffer is allocated once with the capacity, Enqueue and Dequeue take O(1) time and never move the elements,
+// and the slot of a dequeued element is cleared, so the queue never keeps more than the capacity of elements alive.
+// The callbacks run while the queue is locked, so they must not call methods of the same queue.
+type RingQueue[E any] struct {
+	lock      sync.RWMutex
+	items     []E
+	head      int
+	size      int
+	overwrite bool
+	limit     preview.Limit
+}
+
+// Cap returns the capacity of the queue
+func (q *RingQueue[E]) Cap() int {
+	return len(q.items)
+}
+
+// Count returns the size of queue
+func (q *RingQueue[E]) Count() int64 {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+	return int64(q.size)
+}
+
+// IsEmpty returns whether the queue is empty
+func (q *RingQueue[E]) IsEmpty() bool {
+	return q.Count() == 0
+}
+
+// IsNotEmpty returns whether the queue is not empty
+func (q *RingQueue[E]) IsNotEmpty() bool {
+	return !q.IsEmpty()
+}
+
+// IsFull returns whether the size of the queue is up to the capacity
+func (q *RingQueue[E]) IsFull() bool {
+	return q.Count() == int64(len(q.items))
+}
+
+// Clear clears the queue
+func (q *RingQueue[E]) Clear() {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	clear(q.items)
+	q.head = 0
+	q.size = 0
+}
+
+// Peek returns the first element of the queue
+func (q *RingQueue[E]) Peek() (E, bool) {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+	if q.size == 0 {
+		return *new(E), false
+	}
+	return q.items[q.head], true
+}
+
+// Enqueue enqueues a new element into the queue, it returns false when the queue is full,
+// unless the queue overwrites the oldest element then. It always returns false when the capacity is 0.
+func (q *RingQueue[E]) Enqueue(value E) bool {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return q.push(value)
+}
+
+// Dequeue dequeues the first element of queue, it returns zero value and false when the queue is empty
+func (q *RingQueue[E]) Dequeue() (E, bool) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if q.size == 0 {
+		return *new(E), false
+	}
+	return q.shift(), true
+}
+
+func (q *RingQueue[E]) push(value E) bool {
+	if len(q.items) == 0 {
+		return false
+	}
+	if q.size == len(q.items) {
+		if !q.overwrite {
+			return false
+		}
+		q.shift()
+	}
+	q.items[(q.head+q.size)%len(q.items)] = value
+	q.size++
+	return true
+}
+
+func (q *RingQueue[E]) shift() E {
+	value := q.items[q.head]
+	q.items[q.head] = *new(E)
+	q.head = (q.head + 1) % len(q.items)
+	q.size--
+	return value
+}
+
+// values returns the elements from the oldest to the latest
+func (q *RingQueue[E]) values() []E {
+	values := make([]E, 0, q.size)
+	tail := q.head + q.size
+	if tail <= len(q.items) {
+		return append(values, q.items[q.head:tail]...)
+	}
+	values = append(values, q.items[q.head:]...)
+	return append(values, q.items[:tail-len(q.items)]...)
+}
+
+// replace replaces the elements of the queue, they are laid out from the start of the buffer again
+func (q *RingQueue[E]) replace(items []E) {
+	clear(q.items)
+	q.head = 0
+	q.size = copy(q.items, items)
+}
+
+// Remove removes the specific element
+func (q *RingQueue[E]) Remove(value E) {
+	q.RemoveWhere(func(item E) bool {
+		return reflect.DeepEqual(item, value)
+	})
+}
+
+// RemoveWhere removes elements which matches the callback
+func (q *RingQueue[E]) RemoveWhere(callback func(value E) bool) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	q.replace(slices.DeleteFunc(q.values(), callback))
+}
+
+// All returns an iterator over the elements
+func (q *RingQueue[E]) All() iter.Seq[E] {
+	return slices.Values(q.ToArray())
+}
+
+// ToArray converts to array, the returned slice is a copy, so modifying it does not affect the collection
+func (q *RingQueue[E]) ToArray() []E {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+	return q.values()
+}
+
+// ToJSON converts to json
+func (q *RingQueue[E]) ToJSON() ([]byte, error) {
+	return json.Marshal(q.ToArray())
+}
+
+// MarshalJSON implements [json.Marshaller]
+func (q *RingQueue[E]) MarshalJSON() ([]byte, error) {
+	return q.ToJSON()
+}
+
+// UnmarshalJSON implements [json.Unmarshaller], the elements replace the elements of the queue.
+// An overwriting queue keeps the latest elements which fit into the capacity,
+// otherwise it returns an error and leaves the queue unchanged when the elements exceed the capacity.
+func (q *RingQueue[E]) UnmarshalJSON(data []byte) error {
+	values := make([]E, 0)
+	if err := json.Unmarshal(data, &values); err != nil {
+		return err
+	}
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if len(values) > len(q.items) {
+		if !q.overwrite {
+			return fmt.Errorf("queue: cannot decode %d elements into capacity %d", len(values), len(q.items))
+		}
+		values = values[len(values)-len(q.items):]
+	}
+	q.replace(values)
+	return nil
+}
+
+// SetPreviewLimit sets the number of elements shown by String, %v and LogValue, a negative limit shows all elements
+func (q *RingQueue[E]) SetPreviewLimit(limit int) {
+	q.limit.Set(limit)
+}
+
+// String converts to string, only the preview limit of elements are shown
+func (q *RingQueue[E]) String() string {
+	return q.format(q.limit.Get(preview.DefaultLimit))
+}
+
+// Format implements [fmt.Formatter], %v prints the preview as String does, %+v and %#v print all elements
+func (q *RingQueue[E]) Format(f fmt.State, verb rune) {
+	preview.Format(f, verb, q.format, q.limit.Get(preview.DefaultLimit))
+}
+
+func (q *RingQueue[E]) format(limit int) string {
+	items := q.ToArray()
+	return preview.Elements(fmt.Sprintf("RingQueue[%T](len=%d)", *new(E), len(items)), items, limit)
+}
+
+// LogValue implements [slog.LogValuer], only the preview limit of elements are logged
+func (q *RingQueue[E]) LogValue() slog.Value {
+	return preview.LogElements(fmt.Sprintf("RingQueue[%T]", *new(E)), q.ToArray(), q.limit.Get(preview.DefaultLimit))
+}
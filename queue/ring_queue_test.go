@@ -0,0 +1,88 @@
+package queue
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRingQueue_Enqueue(t *testing.T) {
+	queue := NewRingQueue[int](3)
+	assert.Equal(t, 3, queue.Cap())
+	for i := 1; i <= 3; i++ {
+		assert.True(t, queue.Enqueue(i))
+	}
+	assert.True(t, queue.IsFull())
+	assert.False(t, queue.Enqueue(4))
+	assert.Equal(t, []int{1, 2, 3}, queue.ToArray())
+
+	assert.False(t, NewRingQueue[int](0).Enqueue(1))
+	assert.False(t, NewOverwritingRingQueue[int](-1).Enqueue(1))
+}
+
+func TestRingQueue_Dequeue(t *testing.T) {
+	queue := NewRingQueue[int](3)
+	for i := 0; i < 10; i++ {
+		assert.True(t, queue.Enqueue(i))
+		assert.True(t, queue.Enqueue(i))
+		v, ok := queue.Dequeue()
+		assert.True(t, ok)
+		assert.Equal(t, i, v)
+		v, _ = queue.Peek()
+		assert.Equal(t, i, v)
+		v, _ = queue.Dequeue()
+		assert.Equal(t, i, v)
+	}
+	_, ok := queue.Dequeue()
+	assert.False(t, ok)
+	_, ok = queue.Peek()
+	assert.False(t, ok)
+	assert.Equal(t, make([]int, 3), queue.items)
+}
+
+func TestRingQueue_Overwrite(t *testing.T) {
+	queue := NewOverwritingRingQueue[int](3)
+	for i := 1; i <= 5; i++ {
+		assert.True(t, queue.Enqueue(i))
+	}
+	assert.Equal(t, int64(3), queue.Count())
+	assert.Equal(t, []int{3, 4, 5}, queue.ToArray())
+	v, _ := queue.Dequeue()
+	assert.Equal(t, 3, v)
+	queue.Clear()
+	assert.True(t, queue.IsEmpty())
+}
+
+func TestRingQueue_RemoveWhere(t *testing.T) {
+	queue := NewOverwritingRingQueue[int](4)
+	for i := 1; i <= 6; i++ {
+		queue.Enqueue(i)
+	}
+	queue.Remove(3)
+	queue.RemoveWhere(func(value int) bool {
+		return value == 5
+	})
+	assert.Equal(t, []int{4, 6}, queue.ToArray())
+	assert.True(t, queue.Enqueue(7))
+	assert.Equal(t, []int{4, 6, 7}, queue.ToArray())
+}
+
+func TestRingQueue_JSON(t *testing.T) {
+	queue := NewOverwritingRingQueue[int](2)
+	queue.Enqueue(1)
+	queue.Enqueue(2)
+	queue.Enqueue(3)
+	data, err := json.Marshal(queue)
+	assert.Nil(t, err)
+	assert.Equal(t, "[2,3]", string(data))
+
+	assert.Nil(t, json.Unmarshal([]byte("[4,5,6]"), queue))
+	assert.Equal(t, []int{5, 6}, queue.ToArray())
+
+	bounded := NewRingQueue[int](2)
+	bounded.Enqueue(1)
+	assert.EqualError(t, json.Unmarshal([]byte("[4,5,6]"), bounded), "queue: cannot decode 3 elements into capacity 2")
+	assert.Equal(t, []int{1}, bounded.ToArray())
+	assert.Equal(t, "RingQueue[int](len=1){\n\t1,\n}", bounded.String())
+}
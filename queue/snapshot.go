@@ -0,0 +1,17 @@
+package queue
+
+import "fmt"
+
+// SnapshotMeta settings of a queue captured with its pending elements by Snapshot, it is given back to Restore.
+// Cap is the capacity of the queue, a negative capacity means the queue is unbounded.
+type SnapshotMeta struct {
+	Cap int64 `json:"cap"`
+}
+
+// checkRestore returns an error when the number of elements does not fit into the capacity
+func checkRestore(size int, cap int64) error {
+	if cap >= 0 && int64(size) > cap {
+		return fmt.Errorf("queue: cannot restore %d elements into capacity %d", size, cap)
+	}
+	return nil
+}
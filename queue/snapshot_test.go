@@ -0,0 +1,140 @@
+package queue
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBlockingQueue_Snapshot(t *testing.T) {
+	q := NewBlockingQueue[int](3)
+	q.Enqueue(1)
+	q.Enqueue(2)
+	items, meta := q.Snapshot()
+	assert.Equal(t, []int{1, 2}, items)
+	assert.Equal(t, SnapshotMeta{Cap: 3}, meta)
+
+	data, err := json.Marshal(meta)
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"cap":3}`, string(data))
+
+	restored := NewBlockingQueue[int](0)
+	assert.Nil(t, restored.Restore(items, meta))
+	assert.Equal(t, []int{1, 2}, restored.ToArray())
+	assert.True(t, restored.TryEnqueue(3))
+	assert.False(t, restored.TryEnqueue(4))
+
+	err = restored.Restore([]int{1, 2, 3}, SnapshotMeta{Cap: 2})
+	assert.EqualError(t, err, "queue: cannot restore 3 elements into capacity 2")
+	assert.Equal(t, []int{1, 2, 3}, restored.ToArray())
+}
+
+func TestBlockingQueue_Restore(t *testing.T) {
+	q := NewBlockingQueue[int](1)
+	done := make(chan int)
+	go func() {
+		v, _ := q.Dequeue()
+		done <- v
+	}()
+	time.Sleep(10 * time.Millisecond)
+	assert.Nil(t, q.Restore([]int{7}, SnapshotMeta{Cap: 1}))
+	assert.Equal(t, 7, <-done)
+
+	q.Enqueue(1)
+	enqueued := make(chan bool)
+	go func() {
+		enqueued <- q.Enqueue(2)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	assert.Nil(t, q.Restore([]int{1}, SnapshotMeta{Cap: 2}))
+	assert.True(t, <-enqueued)
+	assert.Equal(t, []int{1, 2}, q.ToArray())
+
+	assert.Nil(t, q.Restore(nil, SnapshotMeta{Cap: -1}))
+	assert.True(t, q.TryEnqueue(1))
+	assert.True(t, q.EnqueueTimeout(2, time.Millisecond))
+	assert.Equal(t, []int{1, 2}, q.ToArray())
+}
+
+func TestLinkedBlockingQueue_Snapshot(t *testing.T) {
+	q := NewLinkedBlockingQueue[int](2)
+	q.Enqueue(1)
+	items, meta := q.Snapshot()
+	assert.Equal(t, []int{1}, items)
+	assert.Equal(t, SnapshotMeta{Cap: 2}, meta)
+
+	done := make(chan int)
+	restored := NewLinkedBlockingQueue[int](0)
+	go func() {
+		v, _ := restored.Dequeue()
+		done <- v
+	}()
+	time.Sleep(10 * time.Millisecond)
+	assert.Nil(t, restored.Restore(items, meta))
+	assert.Equal(t, 1, <-done)
+	assert.Error(t, restored.Restore([]int{1, 2, 3}, meta))
+}
+
+func TestPriorityBlockingQueue_Snapshot(t *testing.T) {
+	q := NewPriorityBlockingQueue[int](_comparator{}, 3)
+	q.Enqueue(3)
+	q.Enqueue(1)
+	q.Enqueue(2)
+	items, meta := q.Snapshot()
+	assert.ElementsMatch(t, []int{1, 2, 3}, items)
+	assert.Equal(t, SnapshotMeta{Cap: 3}, meta)
+
+	restored := NewPriorityBlockingQueue[int](_comparator{}, 0)
+	assert.Nil(t, restored.Restore(items, meta))
+	for _, expected := range []int{1, 2, 3} {
+		v, ok := restored.TryDequeue()
+		assert.True(t, ok)
+		assert.Equal(t, expected, v)
+	}
+}
+
+func TestDelayedQueue_Snapshot(t *testing.T) {
+	q := NewDelayedQueue[*_delay]()
+	q.Enqueue(&_delay{1, time.Now().Add(-time.Second)})
+	q.Enqueue(&_delay{2, time.Now().Add(time.Hour)})
+	items, meta := q.Snapshot()
+	assert.Len(t, items, 2)
+	assert.Equal(t, SnapshotMeta{Cap: -1}, meta)
+
+	restored := NewDelayedQueue[*_delay]()
+	assert.Nil(t, restored.Restore(items, meta))
+	v, ok := restored.TryDequeue()
+	assert.True(t, ok)
+	assert.Equal(t, 1, v.Value())
+	_, ok = restored.TryDequeue()
+	assert.False(t, ok)
+}
+
+func TestDelayedQueue_Restore(t *testing.T) {
+	q := NewDelayedQueue[*_delay]()
+	q.SetMaxSize(2, DropReject)
+	q.Enqueue(&_delay{1, time.Now().Add(-time.Second)})
+	items, meta := q.Snapshot()
+	assert.Equal(t, SnapshotMeta{Cap: 2}, meta)
+
+	restored := NewDelayedQueue[*_delay]()
+	assert.Nil(t, restored.Restore(items, meta))
+	_, meta = restored.Snapshot()
+	assert.Equal(t, SnapshotMeta{Cap: 2}, meta)
+	assert.True(t, restored.Enqueue(&_delay{2, time.Now()}))
+	assert.False(t, restored.Enqueue(&_delay{3, time.Now()}))
+	assert.EqualValues(t, 2, restored.Count())
+
+	err := restored.Restore([]*_delay{{4, time.Now()}, {5, time.Now()}, {6, time.Now()}}, meta)
+	assert.Error(t, err)
+	assert.EqualValues(t, 2, restored.Count())
+
+	assert.Nil(t, restored.Restore(nil, SnapshotMeta{Cap: -1}))
+	_, meta = restored.Snapshot()
+	assert.Equal(t, SnapshotMeta{Cap: -1}, meta)
+	assert.True(t, restored.Enqueue(&_delay{7, time.Now()}))
+	assert.True(t, restored.Enqueue(&_delay{8, time.Now()}))
+	assert.True(t, restored.Enqueue(&_delay{9, time.Now()}))
+}
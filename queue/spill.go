@@ -0,0 +1,184 @@
+package queue
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/gopi-frame/collection/internal/codec"
+)
+
+// NewSpillingBlockingQueue new blocking queue which keeps at most cap elements in memory,
+// the elements enqueued while the memory is full are written to a temporary file in dir instead of blocking the producers,
+// and they are read back in order as the queue drains. An empty dir uses the default directory for temporary files.
+// The elements are encoded as MarshalBinary does. The spill file is not durable, use Snapshot to persist the queue,
+// and it is removed once the queue is closed and drained, or at once by Discard.
+func NewSpillingBlockingQueue[E any](cap int64, dir string) (*BlockingQueue[E], error) {
+	if cap <= 0 {
+		return nil, fmt.Errorf("queue: spilling queue needs a positive capacity, got %d", cap)
+	}
+	file, err := os.CreateTemp(dir, "blocking-queue-*.spill")
+	if err != nil {
+		return nil, err
+	}
+	queue := NewBlockingQueue[E](cap)
+	queue.spill = &spill[E]{file: file}
+	return queue, nil
+}
+
+// Spilled returns the number of elements which are kept out of memory, it is 0 for a queue which does not spill
+func (q *BlockingQueue[E]) Spilled() int64 {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+	if q.spill == nil {
+		return 0
+	}
+	return q.spill.len()
+}
+
+// SpillErr returns the first error of the spill file, it is nil for a queue which does not spill.
+// After an error the queue blocks the producers when it is full as a queue which does not spill does,
+// the elements which cannot be written are kept in memory, and the elements which cannot be read back are dropped.
+func (q *BlockingQueue[E]) SpillErr() error {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+	if q.spill == nil {
+		return nil
+	}
+	return q.spill.err
+}
+
+// release removes the spill file of a closed queue once no element is spilled, so the spilled elements stay readable
+// until they are dequeued. The error of removing the file is kept as the error of the spill file.
+func (q *BlockingQueue[E]) release() error {
+	if !q.closed || q.spill == nil || q.spill.file == nil || q.spill.len() > 0 {
+		return nil
+	}
+	return q.spill.close()
+}
+
+// full returns whether the producers have to wait, a spilling queue is never full until its spill file fails
+func (q *BlockingQueue[E]) full() bool {
+	return q.cap >= 0 && q.size >= q.cap && (q.spill == nil || q.spill.err != nil)
+}
+
+// spill the elements of a blocking queue which do not fit into memory.
+// The file holds the elements in order as length-prefixed records between the read and the write offsets,
+// it is truncated whenever it is drained, and the tail holds the elements which came after a failure of the file.
+type spill[E any] struct {
+	file  *os.File
+	read  int64
+	write int64
+	count int64
+	tail  []E
+	err   error
+}
+
+// len returns the number of spilled elements
+func (s *spill[E]) len() int64 {
+	return s.count + int64(len(s.tail))
+}
+
+// push spills the element after the spilled ones
+func (s *spill[E]) push(value E) {
+	if s.err == nil && s.file != nil && len(s.tail) == 0 {
+		e := new(codec.Encoder)
+		err := codec.Encode(e, value)
+		if err == nil {
+			_, err = s.file.WriteAt(e.Bytes(), s.write)
+		}
+		if err == nil {
+			s.write += int64(len(e.Bytes()))
+			s.count++
+			return
+		}
+		s.err = err
+	}
+	s.tail = append(s.tail, value)
+}
+
+// shift removes the first spilled element and returns it, the elements left in the file are dropped when it cannot be read
+func (s *spill[E]) shift() (E, bool) {
+	if s.count > 0 {
+		value, size, err := s.record(s.read)
+		if err == nil {
+			s.read += size
+			s.count--
+			if s.count == 0 {
+				s.truncate()
+			}
+			return value, true
+		}
+		s.err = err
+		s.count = 0
+	}
+	if len(s.tail) == 0 {
+		return *new(E), false
+	}
+	value := s.tail[0]
+	s.tail = s.tail[1:]
+	return value, true
+}
+
+// values returns the spilled elements without removing them, the reading stops at the first record which cannot be read
+func (s *spill[E]) values() []E {
+	values := make([]E, 0, s.len())
+	offset := s.read
+	for range s.count {
+		value, size, err := s.record(offset)
+		if err != nil {
+			break
+		}
+		values = append(values, value)
+		offset += size
+	}
+	return append(values, s.tail...)
+}
+
+// record reads the record at the offset and returns its element and size
+func (s *spill[E]) record(offset int64) (E, int64, error) {
+	header := make([]byte, binary.MaxVarintLen64)
+	n, err := s.file.ReadAt(header, offset)
+	if n == 0 {
+		return *new(E), 0, err
+	}
+	length, size := binary.Uvarint(header[:n])
+	if size <= 0 || offset+int64(size)+int64(length) > s.write {
+		return *new(E), 0, fmt.Errorf("%w: malformed spill record", codec.ErrInvalidData)
+	}
+	data := make([]byte, size+int(length))
+	if _, err := s.file.ReadAt(data, offset); err != nil && !errors.Is(err, io.EOF) {
+		return *new(E), 0, err
+	}
+	value, err := codec.Decode[E](codec.NewDecoder(data))
+	return value, int64(len(data)), err
+}
+
+// reset drops the spilled elements, the error of the file is kept
+func (s *spill[E]) reset() {
+	s.count = 0
+	s.tail = nil
+	s.truncate()
+}
+
+func (s *spill[E]) truncate() {
+	s.read, s.write = 0, 0
+	if s.file == nil {
+		return
+	}
+	if err := s.file.Truncate(0); err != nil && s.err == nil {
+		s.err = err
+	}
+}
+
+// close closes and removes the file, the spill keeps working in memory afterwards
+func (s *spill[E]) close() error {
+	err := errors.Join(s.file.Close(), os.Remove(s.file.Name()))
+	s.file = nil
+	if s.err == nil {
+		s.err = err
+	}
+	return err
+}
@@ -0,0 +1,142 @@
+package queue
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSpillingBlockingQueue(t *testing.T) {
+	_, err := NewSpillingBlockingQueue[int](0, t.TempDir())
+	assert.EqualError(t, err, "queue: spilling queue needs a positive capacity, got 0")
+	_, err = NewSpillingBlockingQueue[int](2, "/nonexistent/spill")
+	assert.Error(t, err)
+}
+
+func TestBlockingQueue_Spill(t *testing.T) {
+	queue, err := NewSpillingBlockingQueue[string](2, t.TempDir())
+	assert.Nil(t, err)
+	defer queue.Close()
+	for _, value := range []string{"a", "b", "c", "d", "e"} {
+		assert.True(t, queue.EnqueueTimeout(value, time.Millisecond))
+	}
+	assert.True(t, queue.TryEnqueue("f"))
+	assert.EqualValues(t, 6, queue.Count())
+	assert.EqualValues(t, 4, queue.Spilled())
+	assert.Equal(t, []string{"a", "b", "c", "d", "e", "f"}, queue.ToArray())
+	assert.Contains(t, queue.String(), "(len=6)")
+
+	var values []string
+	for range 4 {
+		value, ok := queue.Dequeue()
+		assert.True(t, ok)
+		values = append(values, value)
+	}
+	assert.Equal(t, []string{"a", "b", "c", "d"}, values)
+	assert.EqualValues(t, 0, queue.Spilled())
+	info, err := queue.spill.file.Stat()
+	assert.Nil(t, err)
+	assert.EqualValues(t, 0, info.Size())
+
+	queue.Enqueue("g")
+	value, ok := queue.Peek()
+	assert.True(t, ok)
+	assert.Equal(t, "e", value)
+	assert.Equal(t, []string{"e", "f", "g"}, queue.ToArray())
+	assert.Nil(t, queue.SpillErr())
+}
+
+func TestBlockingQueue_Spill_RemoveWhere(t *testing.T) {
+	queue, err := NewSpillingBlockingQueue[int](2, t.TempDir())
+	assert.Nil(t, err)
+	defer queue.Close()
+	for i := range 6 {
+		queue.Enqueue(i)
+	}
+	queue.RemoveWhere(func(value int) bool {
+		return value%3 == 0
+	})
+	assert.Equal(t, []int{1, 2, 4, 5}, queue.ToArray())
+	assert.EqualValues(t, 2, queue.Spilled())
+
+	items, meta := queue.Snapshot()
+	assert.Equal(t, []int{1, 2, 4, 5}, items)
+	assert.Nil(t, queue.Restore([]int{7, 8, 9}, meta))
+	assert.Equal(t, []int{7, 8, 9}, queue.ToArray())
+	assert.EqualValues(t, 1, queue.Spilled())
+	assert.EqualError(t, queue.Restore(nil, SnapshotMeta{Cap: -1}), "queue: spilling queue needs a positive capacity, got -1")
+
+	queue.Clear()
+	assert.True(t, queue.IsEmpty())
+	assert.EqualValues(t, 0, queue.Spilled())
+}
+
+func TestBlockingQueue_SpillErr(t *testing.T) {
+	type item struct {
+		ID   int
+		Name string
+	}
+	queue, err := NewSpillingBlockingQueue[item](1, t.TempDir())
+	assert.Nil(t, err)
+	defer queue.Close()
+	queue.Enqueue(item{1, "a"})
+	queue.Enqueue(item{2, "b"})
+	assert.Nil(t, queue.spill.file.Close())
+	queue.Enqueue(item{3, "c"})
+	assert.Error(t, queue.SpillErr())
+	assert.False(t, queue.TryEnqueue(item{4, "d"}))
+	assert.EqualValues(t, 3, queue.Count())
+
+	value, _ := queue.Dequeue()
+	assert.Equal(t, item{1, "a"}, value)
+	value, _ = queue.Dequeue()
+	assert.Equal(t, item{3, "c"}, value)
+	assert.True(t, queue.IsEmpty())
+}
+
+func TestBlockingQueue_Spill_Close(t *testing.T) {
+	queue, err := NewSpillingBlockingQueue[int](1, t.TempDir())
+	assert.Nil(t, err)
+	for i := range 3 {
+		queue.Enqueue(i)
+	}
+	name := queue.spill.file.Name()
+	assert.Nil(t, queue.Close())
+	assert.Equal(t, []int{0, 1, 2}, queue.ToArray())
+	assert.False(t, queue.TryEnqueue(3))
+	for i := range 2 {
+		_, err = os.Stat(name)
+		assert.Nil(t, err)
+		value, ok := queue.Dequeue()
+		assert.True(t, ok)
+		assert.Equal(t, i, value)
+	}
+	// the last spilled element is read back into memory, so the file is no longer needed
+	_, err = os.Stat(name)
+	assert.True(t, os.IsNotExist(err))
+	value, ok := queue.Dequeue()
+	assert.True(t, ok)
+	assert.Equal(t, 2, value)
+	_, ok = queue.Dequeue()
+	assert.False(t, ok)
+	assert.Nil(t, queue.SpillErr())
+	assert.Nil(t, queue.Close())
+}
+
+func TestBlockingQueue_Discard(t *testing.T) {
+	queue, err := NewSpillingBlockingQueue[int](1, t.TempDir())
+	assert.Nil(t, err)
+	queue.Enqueue(1)
+	queue.Enqueue(2)
+	name := queue.spill.file.Name()
+	assert.Nil(t, queue.Discard())
+	_, err = os.Stat(name)
+	assert.True(t, os.IsNotExist(err))
+	assert.True(t, queue.IsClosed())
+	assert.True(t, queue.IsEmpty())
+	assert.EqualValues(t, 0, queue.Spilled())
+	assert.Nil(t, queue.Close())
+	assert.Nil(t, NewBlockingQueue[int](1).Discard())
+}
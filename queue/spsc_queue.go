@@ -0,0 +1,131 @@
+package queue
+
+import (
+	"math/bits"
+	"sync/atomic"
+)
+
+// NewSPSCQueue new wait-free bounded queue for a single producer and a single consumer,
+// the capacity is rounded up to a power of two of at least 1
+func NewSPSCQueue[E any](cap int) *SPSCQueue[E] {
+	size := 1
+	if cap > 1 {
+		size = 1 << bits.Len(uint(cap-1))
+	}
+	return &SPSCQueue[E]{items: make([]E, size), mask: uint64(size - 1)}
+}
+
+// SPSCQueue wait-free bounded queue on a ring buffer for a single producer and a single consumer,
+// such as the stages of a pipeline. TryEnqueue and TryDequeue finish in a bounded number of steps without a lock
+// or a compare and swap, the producer owns the tail and the consumer owns the head, each on its own cache line,
+// and each side caches the index of the other so it reads the shared one only when the cache says full or empty.
+// The batch methods move many elements with a single publish.
+// Only one goroutine may enqueue and only one goroutine may dequeue at a time, the read-only methods are safe for any goroutine.
+type SPSCQueue[E any] struct {
+	_ [64]byte
+	// tail is written by the producer, cachedHead is the head the producer saw last
+	tail       atomic.Uint64
+	cachedHead uint64
+	_          [48]byte
+	// head is written by the consumer, cachedTail is the tail the consumer saw last
+	head       atomic.Uint64
+	cachedTail uint64
+	_          [48]byte
+	items      []E
+	mask       uint64
+}
+
+// Cap returns the capacity of the queue
+func (q *SPSCQueue[E]) Cap() int {
+	return len(q.items)
+}
+
+// Count returns the number of the elements, it is only a hint while the producer or the consumer modifies the queue
+func (q *SPSCQueue[E]) Count() int64 {
+	head := q.head.Load()
+	return int64(q.tail.Load() - head)
+}
+
+// IsEmpty returns whether the queue is empty
+func (q *SPSCQueue[E]) IsEmpty() bool {
+	return q.Count() == 0
+}
+
+// IsNotEmpty returns whether the queue is not empty
+func (q *SPSCQueue[E]) IsNotEmpty() bool {
+	return !q.IsEmpty()
+}
+
+// free returns the number of the free cells for the producer, the head is loaded only when the cached one leaves too few
+func (q *SPSCQueue[E]) free(tail uint64, want int) int {
+	size := uint64(len(q.items))
+	if free := size - (tail - q.cachedHead); free >= uint64(want) {
+		return int(free)
+	}
+	q.cachedHead = q.head.Load()
+	return int(size - (tail - q.cachedHead))
+}
+
+// filled returns the number of the filled cells for the consumer, the tail is loaded only when the cached one leaves too few
+func (q *SPSCQueue[E]) filled(head uint64, want int) int {
+	if filled := q.cachedTail - head; filled >= uint64(want) {
+		return int(filled)
+	}
+	q.cachedTail = q.tail.Load()
+	return int(q.cachedTail - head)
+}
+
+// TryEnqueue enqueues a new element into the queue, it returns false when the queue is full.
+// It must be called by the producer only.
+func (q *SPSCQueue[E]) TryEnqueue(value E) bool {
+	tail := q.tail.Load()
+	if q.free(tail, 1) == 0 {
+		return false
+	}
+	q.items[tail&q.mask] = value
+	q.tail.Store(tail + 1)
+	return true
+}
+
+// TryDequeue dequeues the first element of the queue, it returns zero value and false when the queue is empty.
+// It must be called by the consumer only.
+func (q *SPSCQueue[E]) TryDequeue() (E, bool) {
+	head := q.head.Load()
+	if q.filled(head, 1) == 0 {
+		return *new(E), false
+	}
+	value := q.items[head&q.mask]
+	q.items[head&q.mask] = *new(E)
+	q.head.Store(head + 1)
+	return value, true
+}
+
+// EnqueueBatch enqueues as many of the values as fit in order and returns the number enqueued,
+// they are published to the consumer at once. It must be called by the producer only.
+func (q *SPSCQueue[E]) EnqueueBatch(values []E) int {
+	tail := q.tail.Load()
+	n := min(q.free(tail, len(values)), len(values))
+	for index := range n {
+		q.items[(tail+uint64(index))&q.mask] = values[index]
+	}
+	if n > 0 {
+		q.tail.Store(tail + uint64(n))
+	}
+	return n
+}
+
+// DequeueBatch dequeues up to len(dst) elements into dst in order and returns the number dequeued.
+// It must be called by the consumer only.
+func (q *SPSCQueue[E]) DequeueBatch(dst []E) int {
+	head := q.head.Load()
+	n := min(q.filled(head, len(dst)), len(dst))
+	for index := range n {
+		cell := &q.items[(head+uint64(index))&q.mask]
+		dst[index] = *cell
+		*cell = *new(E)
+	}
+	if n > 0 {
+		q.head.Store(head + uint64(n))
+	}
+	return n
+}
@@ -0,0 +1,113 @@
+package queue
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSPSCQueue(t *testing.T) {
+	assert.Equal(t, 1, NewSPSCQueue[int](0).Cap())
+	assert.Equal(t, 1, NewSPSCQueue[int](1).Cap())
+	assert.Equal(t, 4, NewSPSCQueue[int](3).Cap())
+	assert.Equal(t, 4, NewSPSCQueue[int](4).Cap())
+}
+
+func TestSPSCQueue_TryEnqueue(t *testing.T) {
+	queue := NewSPSCQueue[int](2)
+	assert.True(t, queue.TryEnqueue(1))
+	assert.True(t, queue.TryEnqueue(2))
+	assert.False(t, queue.TryEnqueue(3))
+	assert.Equal(t, int64(2), queue.Count())
+	value, _ := queue.TryDequeue()
+	assert.Equal(t, 1, value)
+	assert.True(t, queue.TryEnqueue(3))
+}
+
+func TestSPSCQueue_TryDequeue(t *testing.T) {
+	queue := NewSPSCQueue[int](1)
+	_, ok := queue.TryDequeue()
+	assert.False(t, ok)
+	for round := range 5 {
+		assert.True(t, queue.TryEnqueue(round))
+		value, ok := queue.TryDequeue()
+		assert.True(t, ok)
+		assert.Equal(t, round, value)
+	}
+	assert.True(t, queue.IsEmpty())
+
+	t.Run("concurrent", func(t *testing.T) {
+		queue := NewSPSCQueue[int](16)
+		const count = 10000
+		go func() {
+			for index := range count {
+				for !queue.TryEnqueue(index) {
+					runtime.Gosched()
+				}
+			}
+		}()
+		for index := 0; index < count; {
+			value, ok := queue.TryDequeue()
+			if !ok {
+				runtime.Gosched()
+				continue
+			}
+			assert.Equal(t, index, value)
+			index++
+		}
+	})
+}
+
+func TestSPSCQueue_EnqueueBatch(t *testing.T) {
+	queue := NewSPSCQueue[int](4)
+	assert.Equal(t, 3, queue.EnqueueBatch([]int{1, 2, 3}))
+	assert.Equal(t, 1, queue.EnqueueBatch([]int{4, 5}))
+	assert.Equal(t, 0, queue.EnqueueBatch([]int{5}))
+	assert.Equal(t, 0, queue.EnqueueBatch(nil))
+	dst := make([]int, 8)
+	assert.Equal(t, 4, queue.DequeueBatch(dst))
+	assert.Equal(t, []int{1, 2, 3, 4}, dst[:4])
+}
+
+func TestSPSCQueue_DequeueBatch(t *testing.T) {
+	queue := NewSPSCQueue[int](4)
+	dst := make([]int, 3)
+	assert.Equal(t, 0, queue.DequeueBatch(dst))
+	queue.EnqueueBatch([]int{1, 2, 3, 4})
+	assert.Equal(t, 3, queue.DequeueBatch(dst))
+	assert.Equal(t, []int{1, 2, 3}, dst)
+	assert.Equal(t, 3, queue.EnqueueBatch([]int{5, 6, 7}))
+	assert.Equal(t, 3, queue.DequeueBatch(dst))
+	assert.Equal(t, []int{4, 5, 6}, dst)
+	assert.Equal(t, int64(1), queue.Count())
+
+	t.Run("concurrent", func(t *testing.T) {
+		queue := NewSPSCQueue[int](16)
+		const count = 10000
+		go func() {
+			batch := make([]int, 0, 7)
+			for index := range count {
+				batch = append(batch, index)
+				if len(batch) == cap(batch) || index == count-1 {
+					for sent := 0; sent < len(batch); {
+						sent += queue.EnqueueBatch(batch[sent:])
+						runtime.Gosched()
+					}
+					batch = batch[:0]
+				}
+			}
+		}()
+		dst := make([]int, 5)
+		for index := 0; index < count; {
+			n := queue.DequeueBatch(dst)
+			for _, value := range dst[:n] {
+				assert.Equal(t, index, value)
+				index++
+			}
+			if n == 0 {
+				runtime.Gosched()
+			}
+		}
+	})
+}
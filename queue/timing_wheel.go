@@ -0,0 +1,316 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gopi-frame/collection/internal/debug"
+)
+
+// DefaultWheelSizes the slots of each wheel of a timing wheel created without sizes,
+// which spans about 4.5 days with a millisecond tick
+var DefaultWheelSizes = []int{256, 64, 64, 64, 64}
+
+// NewTimingWheel new hierarchical timing wheel of the tick and the number of slots of each wheel from the finest,
+// a tick of zero or less is a millisecond, sizes less than 2 are 2, and no sizes are [DefaultWheelSizes]
+func NewTimingWheel[E any](tick time.Duration, sizes ...int) *TimingWheel[E] {
+	if tick <= 0 {
+		tick = time.Millisecond
+	}
+	if len(sizes) == 0 {
+		sizes = DefaultWheelSizes
+	}
+	wheel := &TimingWheel[E]{tick: tick}
+	span := int64(1)
+	for _, size := range sizes {
+		size = max(size, 2)
+		wheel.wheels = append(wheel.wheels, timerWheel[E]{span: span, slots: make([]timerSlot[E], size)})
+		span *= int64(size)
+	}
+	wheel.span = span
+	return wheel
+}
+
+// TimingWheel hierarchical timing wheel which expires elements after their delays, all methods are safe for concurrent use.
+// Each wheel is a ring of slots, a slot of the finest wheel spans a tick and a slot of each coarser wheel spans a whole
+// round of the finer one. Schedule puts an element in the slot of the coarsest wheel it needs and Cancel unlinks it,
+// both in O(1) time, and the elements fall to the finer wheels as the time reaches their slots,
+// so tens of thousands of timers cost much less than the O(log n) of a [DelayedQueue].
+// The elements expire at tick precision, the delays are counted from the current tick, so an element expires
+// within a tick of its delay when Run drives the wheel.
+// Delays beyond the span of all wheels are kept in the coarsest wheel and placed again each time it turns.
+type TimingWheel[E any] struct {
+	lock    sync.Mutex
+	tick    time.Duration
+	wheels  []timerWheel[E]
+	span    int64
+	current int64
+	size    int64
+}
+
+// timerWheel ring of slots, each slot spans the ticks of span
+type timerWheel[E any] struct {
+	span  int64
+	slots []timerSlot[E]
+}
+
+// timerSlot doubly linked list of the timers of a slot
+type timerSlot[E any] struct {
+	head *timer[E]
+}
+
+func (s *timerSlot[E]) push(t *timer[E]) {
+	t.slot = s
+	t.prev = nil
+	t.next = s.head
+	if s.head != nil {
+		s.head.prev = t
+	}
+	s.head = t
+}
+
+func (s *timerSlot[E]) unlink(t *timer[E]) {
+	if t.prev != nil {
+		t.prev.next = t.next
+	} else {
+		s.head = t.next
+	}
+	if t.next != nil {
+		t.next.prev = t.prev
+	}
+	t.slot, t.prev, t.next = nil, nil, nil
+}
+
+// take unlinks all timers of the slot and returns the first one, the returned timers are still linked by next
+func (s *timerSlot[E]) take() *timer[E] {
+	head := s.head
+	s.head = nil
+	return head
+}
+
+// timer element of a timing wheel with the tick it expires at
+type timer[E any] struct {
+	value    E
+	deadline int64
+	slot     *timerSlot[E]
+	prev     *timer[E]
+	next     *timer[E]
+}
+
+// TimerHandle handle of an element scheduled on a timing wheel, it cancels the element before it expires
+type TimerHandle[E any] struct {
+	wheel *TimingWheel[E]
+	timer *timer[E]
+}
+
+// Value returns the element of the handle
+func (h *TimerHandle[E]) Value() E {
+	return h.timer.value
+}
+
+// Pending returns whether the element is still on the wheel, it is not once it expires or is cancelled
+func (h *TimerHandle[E]) Pending() bool {
+	h.wheel.lock.Lock()
+	defer h.wheel.lock.Unlock()
+	return h.timer.slot != nil
+}
+
+// Cancel removes the element from the wheel in O(1) time, it returns false when the element is no longer pending
+func (h *TimerHandle[E]) Cancel() bool {
+	w := h.wheel
+	w.lock.Lock()
+	defer w.unlock()
+	if h.timer.slot == nil {
+		return false
+	}
+	h.timer.slot.unlink(h.timer)
+	w.size--
+	return true
+}
+
+// Tick returns the duration of a tick
+func (w *TimingWheel[E]) Tick() time.Duration {
+	return w.tick
+}
+
+// Span returns the delay the wheels span, longer delays are placed again each time the coarsest wheel turns
+func (w *TimingWheel[E]) Span() time.Duration {
+	return time.Duration(w.span) * w.tick
+}
+
+// Count returns the number of pending elements
+func (w *TimingWheel[E]) Count() int64 {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.size
+}
+
+// IsEmpty returns whether no element is pending
+func (w *TimingWheel[E]) IsEmpty() bool {
+	return w.Count() == 0
+}
+
+// IsNotEmpty returns whether any element is pending
+func (w *TimingWheel[E]) IsNotEmpty() bool {
+	return !w.IsEmpty()
+}
+
+// Clear removes all pending elements, their handles are no longer pending
+func (w *TimingWheel[E]) Clear() {
+	w.lock.Lock()
+	defer w.unlock()
+	for index := range w.wheels {
+		for slot := range w.wheels[index].slots {
+			for t := w.wheels[index].slots[slot].take(); t != nil; {
+				next := t.next
+				t.slot, t.prev, t.next = nil, nil, nil
+				t = next
+			}
+		}
+	}
+	w.size = 0
+}
+
+// Schedule schedules the element to expire after the delay and returns its handle, the delay is rounded up to ticks
+// and counted from the current tick, a delay of zero or less expires on the next tick
+func (w *TimingWheel[E]) Schedule(value E, delay time.Duration) *TimerHandle[E] {
+	w.lock.Lock()
+	defer w.unlock()
+	ticks := int64(1)
+	if delay > 0 {
+		ticks = int64((delay + w.tick - 1) / w.tick)
+	}
+	t := &timer[E]{value: value, deadline: w.current + ticks}
+	w.place(t)
+	w.size++
+	return &TimerHandle[E]{wheel: w, timer: t}
+}
+
+// place links the timer into the slot of the coarsest wheel it needs, the wheel whose next coarser one
+// would reach its slot only after the deadline, a deadline beyond the span stays in the coarsest wheel
+func (w *TimingWheel[E]) place(t *timer[E]) {
+	deadline := min(t.deadline, w.current+w.span-1)
+	delta := deadline - w.current
+	level := 0
+	for level < len(w.wheels)-1 && delta >= w.wheels[level+1].span {
+		level++
+	}
+	wheel := &w.wheels[level]
+	wheel.slots[(deadline/wheel.span)%int64(len(wheel.slots))].push(t)
+}
+
+// Advance moves the wheel forward by the ticks and returns the expired elements in the order of their deadlines,
+// which lets a caller drive the wheel with its own clock
+func (w *TimingWheel[E]) Advance(ticks int64) []E {
+	w.lock.Lock()
+	defer w.unlock()
+	var expired []E
+	for range max(ticks, 0) {
+		if w.size == 0 {
+			w.current += ticks
+			break
+		}
+		ticks--
+		expired = w.advance(expired)
+	}
+	return expired
+}
+
+// advance moves the wheel to the next tick, the slots reached by the coarser wheels fall to the finer ones first
+// and then the slot of the tick on the finest wheel expires
+func (w *TimingWheel[E]) advance(expired []E) []E {
+	w.current++
+	for level := len(w.wheels) - 1; level > 0; level-- {
+		wheel := &w.wheels[level]
+		if w.current%wheel.span != 0 {
+			continue
+		}
+		for t := wheel.slots[(w.current/wheel.span)%int64(len(wheel.slots))].take(); t != nil; {
+			next := t.next
+			w.place(t)
+			t = next
+		}
+	}
+	wheel := &w.wheels[0]
+	for t := wheel.slots[w.current%int64(len(wheel.slots))].take(); t != nil; {
+		next := t.next
+		t.slot, t.prev, t.next = nil, nil, nil
+		expired = append(expired, t.value)
+		w.size--
+		t = next
+	}
+	return expired
+}
+
+// Run drives the wheel with the wall clock until ctx is done and calls the callback with each expired element,
+// the ticks missed while the callback runs are caught up on the next tick. The callback runs without the wheel locked,
+// so it may schedule and cancel elements.
+func (w *TimingWheel[E]) Run(ctx context.Context, callback func(E)) {
+	ticker := time.NewTicker(w.tick)
+	defer ticker.Stop()
+	start := time.Now()
+	elapsed := int64(0)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			ticks := int64(now.Sub(start) / w.tick)
+			for _, value := range w.Advance(ticks - elapsed) {
+				callback(value)
+			}
+			elapsed = ticks
+		}
+	}
+}
+
+// Chan returns a channel which receives the elements as they expire, it drives the wheel as Run does until ctx is done.
+// The channel is unbuffered and closed once ctx is done, the elements expired but not received by then are discarded.
+func (w *TimingWheel[E]) Chan(ctx context.Context) <-chan E {
+	ch := make(chan E)
+	go func() {
+		defer close(ch)
+		w.Run(ctx, func(value E) {
+			select {
+			case ch <- value:
+			case <-ctx.Done():
+			}
+		})
+	}()
+	return ch
+}
+
+// validate checks that the size counter matches the linked timers and that each timer is in the slot it links to
+func (w *TimingWheel[E]) validate() error {
+	count := int64(0)
+	for level := range w.wheels {
+		for index := range w.wheels[level].slots {
+			slot := &w.wheels[level].slots[index]
+			var prev *timer[E]
+			for t := slot.head; t != nil; t = t.next {
+				if t.slot != slot || t.prev != prev {
+					return fmt.Errorf("timer %v is linked into slot %d of wheel %d but does not link back", t.value, index, level)
+				}
+				if t.deadline <= w.current {
+					return fmt.Errorf("timer %v expired at tick %d but is pending at tick %d", t.value, t.deadline, w.current)
+				}
+				prev = t
+				count++
+			}
+		}
+	}
+	if count != w.size {
+		return fmt.Errorf("wheel has size %d, expected %d", w.size, count)
+	}
+	return nil
+}
+
+// unlock unlocks the wheel after a mutation, the invariants are checked first in debug builds
+func (w *TimingWheel[E]) unlock() {
+	if debug.Enabled {
+		debug.Check(w, w.validate())
+	}
+	w.lock.Unlock()
+}
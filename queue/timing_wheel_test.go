@@ -0,0 +1,136 @@
+package queue
+
+import (
+	"context"
+	"math/rand/v2"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewTimingWheel(t *testing.T) {
+	wheel := NewTimingWheel[int](time.Millisecond)
+	assert.Equal(t, time.Millisecond, wheel.Tick())
+	assert.Equal(t, time.Duration(256*64*64*64*64)*time.Millisecond, wheel.Span())
+
+	wheel = NewTimingWheel[int](0, 8, 1)
+	assert.Equal(t, time.Millisecond, wheel.Tick())
+	assert.Equal(t, 16*time.Millisecond, wheel.Span())
+}
+
+func TestTimingWheel_Schedule(t *testing.T) {
+	wheel := NewTimingWheel[string](time.Second, 4, 4)
+	wheel.Schedule("b", 3*time.Second)
+	wheel.Schedule("a", time.Second)
+	wheel.Schedule("c", 2500*time.Millisecond)
+	wheel.Schedule("now", 0)
+	assert.Equal(t, int64(4), wheel.Count())
+	assert.Equal(t, []string{"now", "a"}, wheel.Advance(1))
+	assert.Empty(t, wheel.Advance(1))
+	assert.ElementsMatch(t, []string{"b", "c"}, wheel.Advance(1))
+	assert.True(t, wheel.IsEmpty())
+
+	t.Run("cascades", func(t *testing.T) {
+		wheel := NewTimingWheel[int](time.Second, 4, 4, 4)
+		wheel.Schedule(1, 5*time.Second)
+		wheel.Schedule(2, 21*time.Second)
+		assert.Empty(t, wheel.Advance(4))
+		assert.Equal(t, []int{1}, wheel.Advance(1))
+		assert.Empty(t, wheel.Advance(15))
+		assert.Equal(t, []int{2}, wheel.Advance(1))
+	})
+
+	t.Run("beyond span", func(t *testing.T) {
+		wheel := NewTimingWheel[int](time.Second, 4, 4)
+		wheel.Schedule(1, 40*time.Second)
+		assert.Empty(t, wheel.Advance(39))
+		assert.Equal(t, []int{1}, wheel.Advance(1))
+	})
+
+	t.Run("random delays", func(t *testing.T) {
+		wheel := NewTimingWheel[int](time.Millisecond, 8, 8, 8)
+		expected := map[int][]int{}
+		for index := range 2000 {
+			delay := 1 + rand.IntN(1000)
+			wheel.Schedule(index, time.Duration(delay)*time.Millisecond)
+			expected[delay] = append(expected[delay], index)
+		}
+		assert.NoError(t, wheel.validate())
+		for tick := 1; tick <= 1000; tick++ {
+			assert.ElementsMatch(t, expected[tick], wheel.Advance(1), "tick %d", tick)
+		}
+		assert.True(t, wheel.IsEmpty())
+	})
+}
+
+func TestTimingWheel_Cancel(t *testing.T) {
+	wheel := NewTimingWheel[int](time.Second, 4, 4)
+	first := wheel.Schedule(1, 2*time.Second)
+	second := wheel.Schedule(2, 2*time.Second)
+	third := wheel.Schedule(3, 10*time.Second)
+	assert.True(t, first.Pending())
+	assert.True(t, first.Cancel())
+	assert.False(t, first.Cancel())
+	assert.False(t, first.Pending())
+	assert.True(t, third.Cancel())
+	assert.Equal(t, int64(1), wheel.Count())
+	assert.Equal(t, []int{2}, wheel.Advance(2))
+	assert.False(t, second.Pending())
+	assert.False(t, second.Cancel())
+	assert.Equal(t, 2, second.Value())
+	assert.Empty(t, wheel.Advance(10))
+}
+
+func TestTimingWheel_Advance(t *testing.T) {
+	wheel := NewTimingWheel[int](time.Second, 4, 4)
+	assert.Empty(t, wheel.Advance(1000))
+	wheel.Schedule(1, time.Second)
+	assert.Empty(t, wheel.Advance(0))
+	assert.Empty(t, wheel.Advance(-1))
+	assert.Equal(t, []int{1}, wheel.Advance(1))
+}
+
+func TestTimingWheel_Clear(t *testing.T) {
+	wheel := NewTimingWheel[int](time.Second)
+	handle := wheel.Schedule(1, time.Second)
+	wheel.Schedule(2, time.Hour)
+	wheel.Clear()
+	assert.True(t, wheel.IsEmpty())
+	assert.False(t, handle.Pending())
+	assert.Empty(t, wheel.Advance(3600))
+}
+
+func TestTimingWheel_Run(t *testing.T) {
+	wheel := NewTimingWheel[int](5 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	expired := make(chan int, 2)
+	go wheel.Run(ctx, func(value int) {
+		expired <- value
+		if value == 1 {
+			wheel.Schedule(2, 10*time.Millisecond)
+		}
+	})
+	start := time.Now()
+	wheel.Schedule(1, 20*time.Millisecond)
+	assert.Equal(t, 1, <-expired)
+	assert.GreaterOrEqual(t, time.Since(start), 15*time.Millisecond)
+	assert.Equal(t, 2, <-expired)
+}
+
+func TestTimingWheel_Chan(t *testing.T) {
+	wheel := NewTimingWheel[int](5 * time.Millisecond)
+	ctx, cancel := context.WithCancel(context.Background())
+	items := wheel.Chan(ctx)
+	wheel.Schedule(1, 10*time.Millisecond)
+	select {
+	case value := <-items:
+		assert.Equal(t, 1, value)
+	case <-time.After(time.Second):
+		assert.Fail(t, "timer did not expire")
+	}
+	cancel()
+	for range items {
+	}
+}
@@ -0,0 +1,156 @@
+package queue
+
+import (
+	"encoding/json"
+	"fmt"
+	"iter"
+	"log/slog"
+	"slices"
+	"sync"
+
+	"github.com/gopi-frame/collection/internal/debug"
+	"github.com/gopi-frame/collection/internal/preview"
+	"github.com/gopi-frame/contract"
+)
+
+// NewTopK new top k which keeps the k greatest elements in comparator order
+func NewTopK[E any](k int, comparator contract.Comparator[E]) *TopK[E] {
+	return &TopK[E]{
+		k:    max(k, 0),
+		heap: NewPriorityQueue(comparator),
+	}
+}
+
+// TopK bounded collector which keeps only the k greatest elements offered so far,
+// each offer takes O(log k) time, so the top results of a stream are aggregated without keeping the stream.
+// All methods are safe for concurrent use.
+type TopK[E any] struct {
+	lock  sync.RWMutex
+	k     int
+	heap  *PriorityQueue[E]
+	limit preview.Limit
+}
+
+// K returns the number of elements kept at most
+func (t *TopK[E]) K() int {
+	return t.k
+}
+
+// Count returns the number of elements kept
+func (t *TopK[E]) Count() int64 {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	return t.heap.size
+}
+
+// IsEmpty returns whether no element is kept
+func (t *TopK[E]) IsEmpty() bool {
+	return t.Count() == 0
+}
+
+// IsNotEmpty returns whether any element is kept
+func (t *TopK[E]) IsNotEmpty() bool {
+	return !t.IsEmpty()
+}
+
+// Offer offers the element, it returns whether the element is kept.
+// When k elements are kept, the element replaces the least of them if it is greater.
+func (t *TopK[E]) Offer(value E) bool {
+	t.lock.Lock()
+	defer t.unlock()
+	return t.offer(value)
+}
+
+func (t *TopK[E]) offer(value E) bool {
+	if t.heap.size < int64(t.k) {
+		return t.heap.enqueue(value)
+	}
+	if t.k == 0 || t.heap.comparator.Compare(value, t.heap.items[0]) <= 0 {
+		return false
+	}
+	t.heap.dequeue()
+	return t.heap.enqueue(value)
+}
+
+// Push offers the elements, so the top k collects with [collection.Collect]
+func (t *TopK[E]) Push(values ...E) {
+	t.lock.Lock()
+	defer t.unlock()
+	for _, value := range values {
+		t.offer(value)
+	}
+}
+
+// Threshold returns the least element kept, which an element must exceed to be kept once k elements are kept.
+// It returns a zero value and false when no element is kept.
+func (t *TopK[E]) Threshold() (E, bool) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	return t.heap.peek()
+}
+
+// Clear clears the elements
+func (t *TopK[E]) Clear() {
+	t.lock.Lock()
+	defer t.unlock()
+	t.heap.clear()
+}
+
+// ToArray returns the elements kept from the greatest to the least, the returned slice is a copy
+func (t *TopK[E]) ToArray() []E {
+	t.lock.RLock()
+	items := slices.Clone(t.heap.items)
+	t.lock.RUnlock()
+	slices.SortFunc(items, func(a, b E) int {
+		return t.heap.comparator.Compare(b, a)
+	})
+	return items
+}
+
+// All returns an iterator over the elements kept from the greatest to the least
+func (t *TopK[E]) All() iter.Seq[E] {
+	return slices.Values(t.ToArray())
+}
+
+// ToJSON converts to json
+func (t *TopK[E]) ToJSON() ([]byte, error) {
+	return json.Marshal(t.ToArray())
+}
+
+// MarshalJSON implements [json.Marshaller]
+func (t *TopK[E]) MarshalJSON() ([]byte, error) {
+	return t.ToJSON()
+}
+
+// unlock unlocks the top k after a mutation, the invariants are checked first in debug builds
+func (t *TopK[E]) unlock() {
+	if debug.Enabled {
+		debug.Check(t, t.heap.validate())
+	}
+	t.lock.Unlock()
+}
+
+// SetPreviewLimit sets the number of elements shown by String, %v and LogValue, a negative limit shows all elements
+func (t *TopK[E]) SetPreviewLimit(limit int) {
+	t.limit.Set(limit)
+}
+
+// String converts to string, only the preview limit of elements are shown
+func (t *TopK[E]) String() string {
+	return t.format(t.limit.Get(preview.DefaultLimit))
+}
+
+// Format implements [fmt.Formatter], %v prints the preview as String does, %+v and %#v print all elements
+func (t *TopK[E]) Format(f fmt.State, verb rune) {
+	preview.Format(f, verb, t.format, t.limit.Get(preview.DefaultLimit))
+}
+
+func (t *TopK[E]) format(limit int) string {
+	items := t.ToArray()
+	return preview.Elements(fmt.Sprintf("TopK[%T](k=%d, len=%d)", *new(E), t.k, len(items)), items, limit)
+}
+
+// LogValue implements [slog.LogValuer], only the preview limit of elements are logged
+func (t *TopK[E]) LogValue() slog.Value {
+	return preview.LogElements(fmt.Sprintf("TopK[%T]", *new(E)), t.ToArray(), t.limit.Get(preview.DefaultLimit))
+}
@@ -0,0 +1,61 @@
+package queue
+
+import (
+	"encoding/json"
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTopK_Offer(t *testing.T) {
+	top := NewTopK[int](3, _comparator{})
+	assert.True(t, top.Offer(5))
+	assert.True(t, top.Offer(1))
+	assert.True(t, top.Offer(3))
+	assert.False(t, top.Offer(0))
+	assert.True(t, top.Offer(4))
+	assert.False(t, top.Offer(3))
+	assert.EqualValues(t, 3, top.Count())
+	assert.Equal(t, []int{5, 4, 3}, top.ToArray())
+	v, ok := top.Threshold()
+	assert.True(t, ok)
+	assert.Equal(t, 3, v)
+}
+
+func TestTopK_Push(t *testing.T) {
+	values := rand.Perm(1000)
+	top := NewTopK[int](10, _comparator{})
+	top.Push(values...)
+	slices.Sort(values)
+	slices.Reverse(values)
+	assert.Equal(t, values[:10], top.ToArray())
+	assert.Equal(t, 10, top.K())
+}
+
+func TestTopK_Clear(t *testing.T) {
+	top := NewTopK[int](0, _comparator{})
+	assert.False(t, top.Offer(1))
+	assert.True(t, top.IsEmpty())
+	top = NewTopK[int](2, _comparator{})
+	top.Push(1, 2, 3)
+	top.Clear()
+	assert.True(t, top.IsEmpty())
+	_, ok := top.Threshold()
+	assert.False(t, ok)
+}
+
+func TestTopK_MarshalJSON(t *testing.T) {
+	top := NewTopK[int](2, _comparator{})
+	top.Push(1, 3, 2)
+	data, err := json.Marshal(top)
+	assert.Nil(t, err)
+	assert.JSONEq(t, `[3,2]`, string(data))
+}
+
+func TestTopK_String(t *testing.T) {
+	top := NewTopK[int](2, _comparator{})
+	top.Push(1, 3, 2)
+	assert.Equal(t, "TopK[int](k=2, len=2){\n\t3,\n\t2,\n}", top.String())
+}
@@ -0,0 +1,64 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrClosed is returned by EnqueueContext and the decoding methods of a closed blocking queue,
+// and by DequeueContext once it is drained as well
+var ErrClosed = errors.New("queue: closed")
+
+// waitFor waits on the cond for at most the given duration, the locker of the cond must be held.
+// Like [sync.Cond.Wait] it may return early, so the caller must check its condition in a loop.
+func waitFor(cond *sync.Cond, duration time.Duration) {
+	timer := time.AfterFunc(duration, func() {
+		cond.L.Lock()
+		defer cond.L.Unlock()
+		cond.Broadcast()
+	})
+	defer timer.Stop()
+	cond.Wait()
+}
+
+// wait waits on the cond while blocked returns true, it returns false when the deadline passes first.
+// A zero deadline waits without a limit. The locker of the cond must be held.
+func wait(cond *sync.Cond, blocked func() bool, deadline time.Time) bool {
+	for blocked() {
+		if deadline.IsZero() {
+			cond.Wait()
+			continue
+		}
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return false
+		}
+		waitFor(cond, remaining)
+	}
+	return true
+}
+
+// waitContext waits on the cond while blocked returns true, it returns the error of ctx when ctx is done first.
+// The locker of the cond must be held.
+func waitContext(ctx context.Context, cond *sync.Cond, blocked func() bool) error {
+	defer wakeOnDone(ctx, cond)()
+	for blocked() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		cond.Wait()
+	}
+	return nil
+}
+
+// wakeOnDone broadcasts the cond when ctx is done, so its waiters observe ctx.Err,
+// the returned func stops it as [context.AfterFunc] does
+func wakeOnDone(ctx context.Context, cond *sync.Cond) func() bool {
+	return context.AfterFunc(ctx, func() {
+		cond.L.Lock()
+		defer cond.L.Unlock()
+		cond.Broadcast()
+	})
+}
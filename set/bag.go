@@ -0,0 +1,301 @@
+package set
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"log/slog"
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/gopi-frame/collection/internal/preview"
+)
+
+// Occurrence an element of a bag with the number of its occurrences
+type Occurrence[E comparable] struct {
+	Value E     `json:"value"`
+	Count int64 `json:"count"`
+}
+
+// NewBag new bag, each value is added once
+func NewBag[E comparable](values ...E) *Bag[E] {
+	bag := &Bag[E]{items: make(map[E]int64)}
+	for _, value := range values {
+		bag.add(value, 1)
+	}
+	return bag
+}
+
+// Bag multiset which counts the occurrences of its elements, such as the frequencies of words,
+// all methods are safe for concurrent use.
+// Count returns the number of elements with their occurrences and Distinct the number of distinct elements,
+// the algebra follows multiset semantics: Union keeps the larger count of each element, Intersect the smaller one,
+// Subtract subtracts the counts and Sum adds them up.
+type Bag[E comparable] struct {
+	lock  sync.RWMutex
+	items map[E]int64
+	size  int64
+	limit preview.Limit
+}
+
+// Count returns the number of elements in the bag, each element counts as many times as it occurs
+func (b *Bag[E]) Count() int64 {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+	return b.size
+}
+
+// Distinct returns the number of distinct elements in the bag
+func (b *Bag[E]) Distinct() int64 {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+	return int64(len(b.items))
+}
+
+// IsEmpty returns whether the bag is empty
+func (b *Bag[E]) IsEmpty() bool {
+	return b.Count() == 0
+}
+
+// IsNotEmpty returns whether the bag is not empty
+func (b *Bag[E]) IsNotEmpty() bool {
+	return !b.IsEmpty()
+}
+
+// CountOf returns the number of occurrences of the value, it is 0 when the bag does not contain it
+func (b *Bag[E]) CountOf(value E) int64 {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+	return b.items[value]
+}
+
+// Contains returns whether the bag contains the value
+func (b *Bag[E]) Contains(value E) bool {
+	return b.CountOf(value) > 0
+}
+
+// Add adds n occurrences of the value and returns the new number of its occurrences,
+// a negative n removes occurrences, the value is removed when none is left
+func (b *Bag[E]) Add(value E, n int64) int64 {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.add(value, n)
+}
+
+func (b *Bag[E]) add(value E, n int64) int64 {
+	count := max(b.items[value]+n, 0)
+	b.size += count - b.items[value]
+	if count == 0 {
+		delete(b.items, value)
+	} else {
+		b.items[value] = count
+	}
+	return count
+}
+
+// Push adds one occurrence of each value
+func (b *Bag[E]) Push(values ...E) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	for _, value := range values {
+		b.add(value, 1)
+	}
+}
+
+// Remove removes all occurrences of the value
+func (b *Bag[E]) Remove(value E) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.size -= b.items[value]
+	delete(b.items, value)
+}
+
+// Clear clears the bag
+func (b *Bag[E]) Clear() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.items = make(map[E]int64)
+	b.size = 0
+}
+
+// MostCommon returns the n elements which occur most often with their counts, from the most common one.
+// A negative n returns all elements, the elements of equal counts are in any order.
+func (b *Bag[E]) MostCommon(n int) []Occurrence[E] {
+	occurrences := b.occurrences()
+	slices.SortStableFunc(occurrences, func(x, y Occurrence[E]) int {
+		return cmp.Compare(y.Count, x.Count)
+	})
+	if n >= 0 && n < len(occurrences) {
+		occurrences = occurrences[:n]
+	}
+	return occurrences
+}
+
+func (b *Bag[E]) occurrences() []Occurrence[E] {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+	occurrences := make([]Occurrence[E], 0, len(b.items))
+	for value, count := range b.items {
+		occurrences = append(occurrences, Occurrence[E]{Value: value, Count: count})
+	}
+	return occurrences
+}
+
+// Union returns a new bag in which each element occurs as many times as in the bag or the other one, whichever is more
+func (b *Bag[E]) Union(other *Bag[E]) *Bag[E] {
+	return b.combine(other, func(x, y int64) int64 {
+		return max(x, y)
+	})
+}
+
+// Intersect returns a new bag in which each element occurs as many times as in the bag or the other one, whichever is less
+func (b *Bag[E]) Intersect(other *Bag[E]) *Bag[E] {
+	return b.combine(other, func(x, y int64) int64 {
+		return min(x, y)
+	})
+}
+
+// Subtract returns a new bag with the occurrences of the bag which are not in the other one,
+// the elements which occur more often in the other bag are left out
+func (b *Bag[E]) Subtract(other *Bag[E]) *Bag[E] {
+	return b.combine(other, func(x, y int64) int64 {
+		return x - y
+	})
+}
+
+// Sum returns a new bag in which each element occurs as many times as in both bags together
+func (b *Bag[E]) Sum(other *Bag[E]) *Bag[E] {
+	return b.combine(other, func(x, y int64) int64 {
+		return x + y
+	})
+}
+
+// combine returns a new bag with the counts of fn on the counts of both bags, the other bag is snapshotted first
+func (b *Bag[E]) combine(other *Bag[E], fn func(x, y int64) int64) *Bag[E] {
+	counts := other.ToMap()
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+	result := NewBag[E]()
+	for value, count := range b.items {
+		result.add(value, fn(count, counts[value]))
+	}
+	for value, count := range counts {
+		if _, ok := b.items[value]; !ok {
+			result.add(value, fn(0, count))
+		}
+	}
+	return result
+}
+
+// Each ranges the distinct elements with their counts, it will break the loop when the callback returns false.
+// The callback runs on a snapshot of the bag, so it is allowed to modify the bag.
+func (b *Bag[E]) Each(callback func(value E, count int64) bool) {
+	for value, count := range b.ToMap() {
+		if !callback(value, count) {
+			break
+		}
+	}
+}
+
+// All returns an iterator over the elements, each element is yielded as many times as it occurs
+func (b *Bag[E]) All() iter.Seq[E] {
+	return func(yield func(E) bool) {
+		for value, count := range b.ToMap() {
+			for ; count > 0; count-- {
+				if !yield(value) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// ToMap converts to the map of the elements to their counts,
+// the returned map is a copy, so modifying it does not affect the bag
+func (b *Bag[E]) ToMap() map[E]int64 {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+	return maps.Clone(b.items)
+}
+
+// ToJSON converts to json, the bag is encoded as an object of the elements to their counts
+func (b *Bag[E]) ToJSON() ([]byte, error) {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+	return json.Marshal(b.items)
+}
+
+// MarshalJSON implements [json.Marshaller]
+func (b *Bag[E]) MarshalJSON() ([]byte, error) {
+	return b.ToJSON()
+}
+
+// UnmarshalJSON implements [json.Unmarshaller], the counts replace the elements of the bag,
+// the elements whose counts are not positive are skipped
+func (b *Bag[E]) UnmarshalJSON(data []byte) error {
+	counts := map[E]int64{}
+	if err := json.Unmarshal(data, &counts); err != nil {
+		return err
+	}
+	var size int64
+	for value, count := range counts {
+		if count <= 0 {
+			delete(counts, value)
+			continue
+		}
+		size += count
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.items = counts
+	b.size = size
+	return nil
+}
+
+// SetPreviewLimit sets the number of distinct elements shown by String, %v and LogValue,
+// a negative limit shows all elements
+func (b *Bag[E]) SetPreviewLimit(limit int) {
+	b.limit.Set(limit)
+}
+
+// String converts to string, only the preview limit of distinct elements are shown with their counts
+func (b *Bag[E]) String() string {
+	return b.format(b.limit.Get(-1))
+}
+
+// Format implements [fmt.Formatter], %v prints the preview as String does, %+v and %#v print all elements
+func (b *Bag[E]) Format(f fmt.State, verb rune) {
+	preview.Format(f, verb, b.format, b.limit.Get(-1))
+}
+
+func (b *Bag[E]) format(limit int) string {
+	values, counts, size := b.snapshot()
+	return preview.Entries(fmt.Sprintf("Bag[%T](len=%d)", *new(E), size), values, counts, limit)
+}
+
+// LogValue implements [slog.LogValuer], only the preview limit of distinct elements are logged with their counts
+func (b *Bag[E]) LogValue() slog.Value {
+	values, counts, size := b.snapshot()
+	return preview.LogEntries(fmt.Sprintf("Bag[%T]", *new(E)), int(size), values, counts, b.limit.Get(preview.DefaultLimit))
+}
+
+func (b *Bag[E]) snapshot() ([]E, []int64, int64) {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+	values := make([]E, 0, len(b.items))
+	counts := make([]int64, 0, len(b.items))
+	for value, count := range b.items {
+		values = append(values, value)
+		counts = append(counts, count)
+	}
+	return values, counts, b.size
+}
+
+// Clone clones the bag
+func (b *Bag[E]) Clone() *Bag[E] {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+	return &Bag[E]{items: maps.Clone(b.items), size: b.size}
+}
@@ -0,0 +1,127 @@
+package set
+
+import (
+	"encoding/json"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBag_Count(t *testing.T) {
+	bag := NewBag("a", "b", "a")
+	assert.EqualValues(t, 3, bag.Count())
+	assert.EqualValues(t, 2, bag.Distinct())
+	assert.EqualValues(t, 2, bag.CountOf("a"))
+	assert.EqualValues(t, 0, bag.CountOf("c"))
+	assert.True(t, bag.Contains("b"))
+	assert.False(t, bag.Contains("c"))
+}
+
+func TestBag_Add(t *testing.T) {
+	bag := NewBag[string]()
+	assert.EqualValues(t, 3, bag.Add("a", 3))
+	assert.EqualValues(t, 1, bag.Add("a", -2))
+	assert.EqualValues(t, 0, bag.Add("a", -5))
+	assert.False(t, bag.Contains("a"))
+	assert.True(t, bag.IsEmpty())
+	bag.Push("b", "b")
+	assert.EqualValues(t, 2, bag.Count())
+}
+
+func TestBag_Remove(t *testing.T) {
+	bag := NewBag("a", "b", "a")
+	bag.Remove("a")
+	bag.Remove("c")
+	assert.EqualValues(t, 1, bag.Count())
+	bag.Clear()
+	assert.True(t, bag.IsEmpty())
+	assert.Zero(t, bag.Distinct())
+}
+
+func TestBag_MostCommon(t *testing.T) {
+	bag := NewBag[string]()
+	bag.Add("a", 1)
+	bag.Add("b", 3)
+	bag.Add("c", 2)
+	assert.Equal(t, []Occurrence[string]{{"b", 3}, {"c", 2}}, bag.MostCommon(2))
+	assert.Equal(t, []Occurrence[string]{{"b", 3}, {"c", 2}, {"a", 1}}, bag.MostCommon(-1))
+	assert.Empty(t, bag.MostCommon(0))
+}
+
+func TestBag_Algebra(t *testing.T) {
+	a := NewBag(1, 1, 1, 2, 3)
+	b := NewBag(1, 2, 2, 4)
+	assert.Equal(t, map[int]int64{1: 3, 2: 2, 3: 1, 4: 1}, a.Union(b).ToMap())
+	assert.Equal(t, map[int]int64{1: 1, 2: 1}, a.Intersect(b).ToMap())
+	assert.Equal(t, map[int]int64{1: 2, 3: 1}, a.Subtract(b).ToMap())
+	assert.Equal(t, map[int]int64{1: 4, 2: 3, 3: 1, 4: 1}, a.Sum(b).ToMap())
+	assert.EqualValues(t, 3, a.Subtract(b).Count())
+	assert.EqualValues(t, 5, a.Count())
+}
+
+func TestBag_All(t *testing.T) {
+	bag := NewBag(1, 2, 1)
+	values := slices.Collect(bag.All())
+	slices.Sort(values)
+	assert.Equal(t, []int{1, 1, 2}, values)
+	for value := range bag.All() {
+		bag.Remove(value)
+		break
+	}
+	assert.EqualValues(t, 1, bag.Distinct())
+}
+
+func TestBag_Each(t *testing.T) {
+	bag := NewBag(1, 2, 1)
+	counts := map[int]int64{}
+	bag.Each(func(value int, count int64) bool {
+		counts[value] = count
+		bag.Remove(value)
+		return true
+	})
+	assert.Equal(t, map[int]int64{1: 2, 2: 1}, counts)
+	assert.True(t, bag.IsEmpty())
+}
+
+func TestBag_MarshalJSON(t *testing.T) {
+	data, err := json.Marshal(NewBag("a", "b", "a"))
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"a":2,"b":1}`, string(data))
+}
+
+func TestBag_UnmarshalJSON(t *testing.T) {
+	bag := NewBag("c")
+	assert.Nil(t, json.Unmarshal([]byte(`{"a":2,"b":0,"d":-1}`), bag))
+	assert.Equal(t, map[string]int64{"a": 2}, bag.ToMap())
+	assert.EqualValues(t, 2, bag.Count())
+}
+
+func TestBag_String(t *testing.T) {
+	bag := NewBag("a", "a")
+	assert.Equal(t, "Bag[string](len=2){\n\ta: 2,\n}", bag.String())
+}
+
+func TestBag_Clone(t *testing.T) {
+	bag := NewBag("a", "a")
+	clone := bag.Clone()
+	bag.Add("a", 1)
+	assert.EqualValues(t, 2, clone.CountOf("a"))
+	assert.EqualValues(t, 2, clone.Count())
+}
+
+func TestBag_Concurrent(t *testing.T) {
+	bag := NewBag[int]()
+	wg := new(sync.WaitGroup)
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			bag.Add(i%10, 2)
+		}(i)
+	}
+	wg.Wait()
+	assert.EqualValues(t, 200, bag.Count())
+	assert.EqualValues(t, 20, bag.CountOf(3))
+}
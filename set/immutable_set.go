@@ -0,0 +1,183 @@
+package set
+
+import (
+	"encoding/json"
+	"fmt"
+	"iter"
+	"log/slog"
+
+	"github.com/gopi-frame/collection/internal/preview"
+	"github.com/gopi-frame/collection/kv"
+)
+
+// NewImmutableSet new immutable set
+func NewImmutableSet[E comparable](values ...E) *ImmutableSet[E] {
+	set := &ImmutableSet[E]{elements: kv.NewImmutableMap[E, struct{}]()}
+	return set.Push(values...)
+}
+
+// ImmutableSet set whose mutation methods return a new set and leave the set itself unchanged,
+// so it can be shared across goroutines without locks or defensive copies.
+// It is backed by a [kv.ImmutableMap], Push and Remove take O(log32 n) time per element
+// and share all but the changed paths with the previous set.
+type ImmutableSet[E comparable] struct {
+	elements *kv.ImmutableMap[E, struct{}]
+	limit    preview.Limit
+}
+
+// with returns a new set backed by the map
+func (s *ImmutableSet[E]) with(elements *kv.ImmutableMap[E, struct{}]) *ImmutableSet[E] {
+	return &ImmutableSet[E]{elements: elements}
+}
+
+// Count returns the size of set
+func (s *ImmutableSet[E]) Count() int64 {
+	return s.elements.Count()
+}
+
+// IsEmpty returns whether the set is empty
+func (s *ImmutableSet[E]) IsEmpty() bool {
+	return s.Count() == 0
+}
+
+// IsNotEmpty returns whether the set is not empty
+func (s *ImmutableSet[E]) IsNotEmpty() bool {
+	return !s.IsEmpty()
+}
+
+// Contains returns whether the set contains the specific element
+func (s *ImmutableSet[E]) Contains(value E) bool {
+	return s.elements.ContainsKey(value)
+}
+
+// Push returns a new set with the elements added, it returns the set itself when no element is new
+func (s *ImmutableSet[E]) Push(values ...E) *ImmutableSet[E] {
+	elements := s.elements
+	for _, value := range values {
+		if !elements.ContainsKey(value) {
+			elements = elements.Set(value, struct{}{})
+		}
+	}
+	if elements == s.elements {
+		return s
+	}
+	return s.with(elements)
+}
+
+// Remove returns a new set without the elements, it returns the set itself when it has none of them
+func (s *ImmutableSet[E]) Remove(values ...E) *ImmutableSet[E] {
+	elements := s.elements
+	for _, value := range values {
+		elements = elements.Remove(value)
+	}
+	if elements == s.elements {
+		return s
+	}
+	return s.with(elements)
+}
+
+// RemoveWhere returns a new set without the elements which match the callback
+func (s *ImmutableSet[E]) RemoveWhere(callback func(E) bool) *ImmutableSet[E] {
+	var removed []E
+	for value := range s.All() {
+		if callback(value) {
+			removed = append(removed, value)
+		}
+	}
+	return s.Remove(removed...)
+}
+
+// Union returns a new set with the elements of either set
+func (s *ImmutableSet[E]) Union(other *ImmutableSet[E]) *ImmutableSet[E] {
+	if s.Count() < other.Count() {
+		s, other = other, s
+	}
+	return s.Push(other.ToArray()...)
+}
+
+// Intersect returns a new set with the elements of both sets
+func (s *ImmutableSet[E]) Intersect(other *ImmutableSet[E]) *ImmutableSet[E] {
+	return s.RemoveWhere(func(value E) bool {
+		return !other.Contains(value)
+	})
+}
+
+// Subtract returns a new set with the elements which are not in the other set
+func (s *ImmutableSet[E]) Subtract(other *ImmutableSet[E]) *ImmutableSet[E] {
+	return s.Remove(other.ToArray()...)
+}
+
+// Each runs callback for each element, it breaks when callback false
+func (s *ImmutableSet[E]) Each(callback func(_ int, item E) bool) {
+	for value := range s.All() {
+		if !callback(-1, value) {
+			break
+		}
+	}
+}
+
+// All returns an iterator over the elements
+func (s *ImmutableSet[E]) All() iter.Seq[E] {
+	return func(yield func(E) bool) {
+		for value := range s.elements.All() {
+			if !yield(value) {
+				return
+			}
+		}
+	}
+}
+
+// ToArray converts to array, the returned slice is a copy, so modifying it does not affect the collection
+func (s *ImmutableSet[E]) ToArray() []E {
+	return s.elements.Keys()
+}
+
+// ToSet converts to a mutable [Set] of the elements
+func (s *ImmutableSet[E]) ToSet() *Set[E] {
+	return NewSet(s.ToArray()...)
+}
+
+// ToJSON converts to json
+func (s *ImmutableSet[E]) ToJSON() ([]byte, error) {
+	return json.Marshal(s.ToArray())
+}
+
+// MarshalJSON implements [json.Marshaller]
+func (s *ImmutableSet[E]) MarshalJSON() ([]byte, error) {
+	return s.ToJSON()
+}
+
+// UnmarshalJSON implements [json.Unmarshaller], it decodes into the set itself, so it must not be shared yet
+func (s *ImmutableSet[E]) UnmarshalJSON(data []byte) error {
+	var items []E
+	if err := json.Unmarshal(data, &items); err != nil {
+		return err
+	}
+	s.elements = NewImmutableSet(items...).elements
+	return nil
+}
+
+// SetPreviewLimit sets the number of elements shown by String, %v and LogValue, a negative limit shows all elements
+func (s *ImmutableSet[E]) SetPreviewLimit(limit int) {
+	s.limit.Set(limit)
+}
+
+// String converts to string, only the preview limit of elements are shown
+func (s *ImmutableSet[E]) String() string {
+	return s.format(s.limit.Get(preview.DefaultLimit))
+}
+
+// Format implements [fmt.Formatter], %v prints the preview as String does, %+v and %#v print all elements
+func (s *ImmutableSet[E]) Format(f fmt.State, verb rune) {
+	preview.Format(f, verb, s.format, s.limit.Get(preview.DefaultLimit))
+}
+
+func (s *ImmutableSet[E]) format(limit int) string {
+	items := s.ToArray()
+	return preview.Elements(fmt.Sprintf("ImmutableSet[%T](len=%d)", *new(E), len(items)), items, limit)
+}
+
+// LogValue implements [slog.LogValuer], only the preview limit of elements are logged
+func (s *ImmutableSet[E]) LogValue() slog.Value {
+	return preview.LogElements(fmt.Sprintf("ImmutableSet[%T]", *new(E)), s.ToArray(), s.limit.Get(preview.DefaultLimit))
+}
@@ -0,0 +1,54 @@
+package set
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImmutableSet_Push(t *testing.T) {
+	s := NewImmutableSet(1, 2)
+	pushed := s.Push(2, 3)
+	assert.ElementsMatch(t, []int{1, 2}, s.ToArray())
+	assert.ElementsMatch(t, []int{1, 2, 3}, pushed.ToArray())
+	assert.Same(t, pushed, pushed.Push(1, 3))
+	assert.EqualValues(t, 3, pushed.Count())
+	assert.True(t, pushed.Contains(3))
+	assert.True(t, NewImmutableSet[int]().IsEmpty())
+}
+
+func TestImmutableSet_Remove(t *testing.T) {
+	s := NewImmutableSet(1, 2, 3)
+	removed := s.Remove(1, 4)
+	assert.ElementsMatch(t, []int{2, 3}, removed.ToArray())
+	assert.ElementsMatch(t, []int{1, 2, 3}, s.ToArray())
+	assert.Same(t, removed, removed.Remove(1))
+	assert.ElementsMatch(t, []int{1, 3}, s.RemoveWhere(func(value int) bool {
+		return value%2 == 0
+	}).ToArray())
+}
+
+func TestImmutableSet_Union(t *testing.T) {
+	a := NewImmutableSet(1, 2, 3)
+	b := NewImmutableSet(3, 4)
+	assert.ElementsMatch(t, []int{1, 2, 3, 4}, a.Union(b).ToArray())
+	assert.ElementsMatch(t, []int{1, 2, 3, 4}, b.Union(a).ToArray())
+	assert.ElementsMatch(t, []int{3}, a.Intersect(b).ToArray())
+	assert.ElementsMatch(t, []int{1, 2}, a.Subtract(b).ToArray())
+	assert.ElementsMatch(t, []int{1, 2, 3}, a.ToArray())
+}
+
+func TestImmutableSet_MarshalJSON(t *testing.T) {
+	data, err := json.Marshal(NewImmutableSet(1))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `[1]`, string(data))
+	decoded := NewImmutableSet[int]()
+	assert.NoError(t, json.Unmarshal([]byte(`[1,2,2]`), decoded))
+	assert.ElementsMatch(t, []int{1, 2}, decoded.ToArray())
+	assert.ElementsMatch(t, []int{1, 2}, decoded.ToSet().ToArray())
+}
+
+func TestImmutableSet_String(t *testing.T) {
+	assert.Equal(t, "ImmutableSet[int](len=1){\n\t1,\n}", NewImmutableSet(1).String())
+}
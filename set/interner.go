@@ -0,0 +1,91 @@
+package set
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// NewInterner new interner, a positive bound limits the number of canonical values kept, 0 keeps all of them
+func NewInterner[E comparable](bound int) *Interner[E] {
+	return &Interner[E]{values: make(map[E]E), bound: bound}
+}
+
+// Interner deduplicates equal values, such as the repeated keys met by parsers and loaders, all methods are safe for concurrent use.
+// Intern returns the first instance of each value it kept, so the copies can be released and only one of them stays in memory.
+// Strings are cloned before they are kept, so a key sliced from a large buffer does not keep the whole buffer alive.
+// Once the bound is reached the values already kept stay canonical and new values are returned as they are.
+type Interner[E comparable] struct {
+	lock   sync.RWMutex
+	values map[E]E
+	bound  int
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+// InternerStats statistics of an interner
+type InternerStats struct {
+	// Size is the number of canonical values kept
+	Size int64
+	// Hits is the number of values which were already kept
+	Hits int64
+	// Misses is the number of values which were not kept yet, including the ones beyond the bound
+	Misses int64
+}
+
+// Intern returns the canonical instance of the value, the value itself is kept as canonical when it is new
+func (i *Interner[E]) Intern(value E) E {
+	i.lock.RLock()
+	canonical, ok := i.values[value]
+	i.lock.RUnlock()
+	if ok {
+		i.hits.Add(1)
+		return canonical
+	}
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	if canonical, ok := i.values[value]; ok {
+		i.hits.Add(1)
+		return canonical
+	}
+	i.misses.Add(1)
+	if i.bound > 0 && len(i.values) >= i.bound {
+		return value
+	}
+	if s, ok := any(value).(string); ok {
+		value = any(strings.Clone(s)).(E)
+	}
+	i.values[value] = value
+	return value
+}
+
+// Contains returns whether the value has a canonical instance, it does not count as a hit or a miss
+func (i *Interner[E]) Contains(value E) bool {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+	_, ok := i.values[value]
+	return ok
+}
+
+// Count returns the number of canonical values kept
+func (i *Interner[E]) Count() int64 {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+	return int64(len(i.values))
+}
+
+// Clear forgets the canonical values, the stats are kept
+func (i *Interner[E]) Clear() {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	i.values = make(map[E]E)
+}
+
+// Stats returns the statistics of the interner
+func (i *Interner[E]) Stats() InternerStats {
+	return InternerStats{
+		Size:   i.Count(),
+		Hits:   i.hits.Load(),
+		Misses: i.misses.Load(),
+	}
+}
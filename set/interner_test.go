@@ -0,0 +1,53 @@
+package set
+
+import (
+	"sync"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInterner_Intern(t *testing.T) {
+	interner := NewInterner[string](0)
+	buf := []byte("user_id,user_id")
+	first := interner.Intern(string(buf[:7]))
+	second := interner.Intern(string(buf[8:]))
+	assert.Equal(t, "user_id", second)
+	assert.Equal(t, unsafe.StringData(first), unsafe.StringData(second))
+	assert.True(t, interner.Contains("user_id"))
+	assert.False(t, interner.Contains("name"))
+	assert.Equal(t, InternerStats{Size: 1, Hits: 1, Misses: 1}, interner.Stats())
+
+	interner.Clear()
+	assert.EqualValues(t, 0, interner.Count())
+	assert.EqualValues(t, 1, interner.Stats().Hits)
+}
+
+func TestInterner_Bound(t *testing.T) {
+	interner := NewInterner[int](2)
+	interner.Intern(1)
+	interner.Intern(2)
+	assert.Equal(t, 3, interner.Intern(3))
+	assert.Equal(t, 1, interner.Intern(1))
+	assert.False(t, interner.Contains(3))
+	assert.Equal(t, InternerStats{Size: 2, Hits: 1, Misses: 3}, interner.Stats())
+}
+
+func TestInterner_Concurrent(t *testing.T) {
+	interner := NewInterner[string](0)
+	wg := new(sync.WaitGroup)
+	results := make([]string, 8)
+	for index := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[index] = interner.Intern(string([]byte("key")))
+		}()
+	}
+	wg.Wait()
+	for _, result := range results {
+		assert.Equal(t, unsafe.StringData(results[0]), unsafe.StringData(result))
+	}
+	assert.Equal(t, InternerStats{Size: 1, Hits: 7, Misses: 1}, interner.Stats())
+}
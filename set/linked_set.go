@@ -1,13 +1,22 @@
 package set
 
 import (
+	"database/sql/driver"
 	"encoding/json"
 	"fmt"
-	"strings"
+	"io"
+	"iter"
+	"log/slog"
+	"slices"
 	"sync"
 
+	"github.com/fxamacker/cbor/v2"
+	"github.com/gopi-frame/collection"
+	"github.com/gopi-frame/collection/internal/codec"
+	"github.com/gopi-frame/collection/internal/jsonstream"
+	"github.com/gopi-frame/collection/internal/preview"
+	"github.com/gopi-frame/collection/internal/sqljson"
 	"github.com/gopi-frame/collection/list"
-	"github.com/gopi-frame/contract"
 )
 
 // NewLinkedSet creates a new linked hash set
@@ -15,20 +24,25 @@ func NewLinkedSet[E comparable](values ...E) *LinkedSet[E] {
 	set := new(LinkedSet[E])
 	set.elements = map[E]struct{}{}
 	set.link = list.NewLinkedList[E]()
-	set.Push(values...)
+	set.push(values...)
 	return set
 }
 
-// LinkedSet linked hash set
+// LinkedSet linked hash set, all methods are safe for concurrent use.
+// The callbacks run while the set is locked, so they must not call methods of the same set,
+// except the callback of Each which runs on a snapshot.
 type LinkedSet[E comparable] struct {
-	sync.RWMutex
+	lock     sync.RWMutex
 	elements map[E]struct{}
 	link     *list.LinkedList[E]
+	limit    preview.Limit
 }
 
 // Count returns the size of set
 func (s *LinkedSet[E]) Count() int64 {
-	return s.link.Count()
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return int64(len(s.elements))
 }
 
 // IsEmpty returns whether the set is empty
@@ -43,19 +57,29 @@ func (s *LinkedSet[E]) IsNotEmpty() bool {
 
 // Contains returns whether the set contains the specific element
 func (s *LinkedSet[E]) Contains(value E) bool {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
 	_, contains := s.elements[value]
 	return contains
 }
 
 // ContainsWhere returns whether the set contains elements which matches the callback
 func (s *LinkedSet[E]) ContainsWhere(callback func(E) bool) bool {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
 	return s.link.ContainsWhere(callback)
 }
 
 // Push pushes elements into the set
 func (s *LinkedSet[E]) Push(values ...E) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.push(values...)
+}
+
+func (s *LinkedSet[E]) push(values ...E) {
 	for _, value := range values {
-		if s.Contains(value) {
+		if _, contains := s.elements[value]; contains {
 			continue
 		}
 		s.elements[value] = struct{}{}
@@ -72,24 +96,37 @@ func (s *LinkedSet[E]) Remove(value E) {
 
 // RemoveWhere removes elements which matches the callback
 func (s *LinkedSet[E]) RemoveWhere(callback func(E) bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.removeWhere(callback)
+}
+
+func (s *LinkedSet[E]) removeWhere(callback func(E) bool) {
 	s.link = s.link.Where(func(item E) bool {
 		return !callback(item)
 	})
 	s.elements = make(map[E]struct{})
-	s.link.Each(func(index int, value E) bool {
+	for _, value := range s.link.ToArray() {
 		s.elements[value] = struct{}{}
-		return true
-	})
+	}
 }
 
 // Clear clears the set
 func (s *LinkedSet[E]) Clear() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.elements = map[E]struct{}{}
 	s.link.Clear()
 }
 
-// Each runs callback for each element, it breaks when callback false
+// Each runs callback for each element, it breaks when callback false.
+// The callback runs on a snapshot of the set, so it is allowed to modify the set.
 func (s *LinkedSet[E]) Each(callback func(int, E) bool) {
-	s.link.Each(callback)
+	for index, value := range s.ToArray() {
+		if !callback(index, value) {
+			break
+		}
+	}
 }
 
 // Clone clones the set
@@ -97,8 +134,24 @@ func (s *LinkedSet[E]) Clone() *LinkedSet[E] {
 	return NewLinkedSet(s.ToArray()...)
 }
 
-// ToArray converts to array
+// DeepClone clones the set and its elements, see [collection.CloneValue]
+func (s *LinkedSet[E]) DeepClone() *LinkedSet[E] {
+	items := s.ToArray()
+	for index, item := range items {
+		items[index] = collection.CloneValue(item)
+	}
+	return NewLinkedSet(items...)
+}
+
+// All returns an iterator over the elements
+func (s *LinkedSet[E]) All() iter.Seq[E] {
+	return slices.Values(s.ToArray())
+}
+
+// ToArray converts to array, the returned slice is a copy, so modifying it does not affect the collection
 func (s *LinkedSet[E]) ToArray() []E {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
 	return s.link.ToArray()
 }
 
@@ -114,35 +167,120 @@ func (s *LinkedSet[E]) MarshalJSON() ([]byte, error) {
 
 // UnmarshalJSON implements [json.Unmarshaller]
 func (s *LinkedSet[E]) UnmarshalJSON(data []byte) error {
-	items := list.NewLinkedList[E]()
-	err := json.Unmarshal(data, items)
+	var items []E
+	err := json.Unmarshal(data, &items)
 	if err != nil {
 		return err
 	}
-	s.link = items
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.elements = map[E]struct{}{}
+	s.link = list.NewLinkedList[E]()
+	s.push(items...)
 	return nil
 }
 
-// String converts to string
-func (s *LinkedSet[E]) String() string {
-	str := new(strings.Builder)
-	str.WriteString(fmt.Sprintf("LinkedSet[%T](len=%d)", *new(E), s.Count()))
-	str.WriteByte('{')
-	str.WriteByte('\n')
-	s.link.Each(func(index int, value E) bool {
-		str.WriteByte('\t')
-		if v, ok := any(value).(contract.Stringable); ok {
-			str.WriteString(v.String())
-		} else {
-			str.WriteString(fmt.Sprintf("%v", value))
-		}
-		str.WriteByte(',')
-		str.WriteByte('\n')
-		return index < 4
-	})
-	if s.Count() > 5 {
-		str.WriteString("\t...\n")
+// EncodeJSON writes the set to w as a json array one element at a time, the set is read locked while writing
+func (s *LinkedSet[E]) EncodeJSON(w io.Writer) error {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.link.EncodeJSON(w)
+}
+
+// DecodeJSON reads a json array from r one element at a time and replaces the elements of the set
+func (s *LinkedSet[E]) DecodeJSON(r io.Reader) error {
+	decoded := NewLinkedSet[E]()
+	if err := jsonstream.Decode(r, func(value E) {
+		decoded.push(value)
+	}); err != nil {
+		return err
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.elements = decoded.elements
+	s.link = decoded.link
+	return nil
+}
+
+// Value implements [driver.Valuer], the set is stored as a json array
+func (s *LinkedSet[E]) Value() (driver.Value, error) {
+	return sqljson.Value(s.ToJSON())
+}
+
+// Scan implements [database/sql.Scanner], it reads a json array and replaces the elements of the set, NULL is read as empty
+func (s *LinkedSet[E]) Scan(src any) error {
+	data, err := sqljson.Bytes(src)
+	if err != nil {
+		return err
+	}
+	return s.UnmarshalJSON(data)
+}
+
+// ToCBOR converts to cbor
+func (s *LinkedSet[E]) ToCBOR() ([]byte, error) {
+	return cbor.Marshal(s.ToArray())
+}
+
+// MarshalCBOR implements [cbor.Marshaler]
+func (s *LinkedSet[E]) MarshalCBOR() ([]byte, error) {
+	return s.ToCBOR()
+}
+
+// UnmarshalCBOR implements [cbor.Unmarshaler]
+func (s *LinkedSet[E]) UnmarshalCBOR(data []byte) error {
+	var items []E
+	err := cbor.Unmarshal(data, &items)
+	if err != nil {
+		return err
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.elements = map[E]struct{}{}
+	s.link = list.NewLinkedList[E]()
+	s.push(items...)
+	return nil
+}
+
+// MarshalBinary implements [encoding.BinaryMarshaler], the elements are encoded by their own encoders
+func (s *LinkedSet[E]) MarshalBinary() ([]byte, error) {
+	return codec.EncodeValues(s.ToArray())
+}
+
+// UnmarshalBinary implements [encoding.BinaryUnmarshaler]
+func (s *LinkedSet[E]) UnmarshalBinary(data []byte) error {
+	items, err := codec.DecodeValues[E](data)
+	if err != nil {
+		return err
 	}
-	str.WriteByte('}')
-	return str.String()
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.elements = map[E]struct{}{}
+	s.link = list.NewLinkedList[E]()
+	s.push(items...)
+	return nil
+}
+
+// SetPreviewLimit sets the number of elements shown by String, %v and LogValue, a negative limit shows all elements
+func (s *LinkedSet[E]) SetPreviewLimit(limit int) {
+	s.limit.Set(limit)
+}
+
+// String converts to string, only the preview limit of elements are shown
+func (s *LinkedSet[E]) String() string {
+	return s.format(s.limit.Get(preview.DefaultLimit))
+}
+
+// Format implements [fmt.Formatter], %v prints the preview as String does, %+v and %#v print all elements
+func (s *LinkedSet[E]) Format(f fmt.State, verb rune) {
+	preview.Format(f, verb, s.format, s.limit.Get(preview.DefaultLimit))
+}
+
+func (s *LinkedSet[E]) format(limit int) string {
+	items := s.ToArray()
+	return preview.Elements(fmt.Sprintf("LinkedSet[%T](len=%d)", *new(E), len(items)), items, limit)
+}
+
+// LogValue implements [slog.LogValuer], only the preview limit of elements are logged
+func (s *LinkedSet[E]) LogValue() slog.Value {
+	return preview.LogElements(fmt.Sprintf("LinkedSet[%T]", *new(E)), s.ToArray(), s.limit.Get(preview.DefaultLimit))
 }
@@ -1,11 +1,16 @@
 package set
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"regexp"
+	"strings"
+	"sync"
 	"testing"
 
+	"github.com/fxamacker/cbor/v2"
+	"github.com/gopi-frame/collection/list"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -79,6 +84,16 @@ func TestLinkedSet_Clone(t *testing.T) {
 	assert.Equal(t, set.elements, set2.elements)
 }
 
+func TestLinkedSet_DeepClone(t *testing.T) {
+	inner := list.NewList(1, 2)
+	set := NewLinkedSet(inner)
+	clone := set.DeepClone()
+	assert.EqualValues(t, 1, clone.Count())
+	assert.False(t, clone.Contains(inner))
+	inner.Push(3)
+	assert.Equal(t, []int{1, 2}, clone.ToArray()[0].ToArray())
+}
+
 func TestLinkedSet_ToArray(t *testing.T) {
 	set := NewLinkedSet(1, 2, 3)
 	assert.Equal(t, []int{1, 2, 3}, set.ToArray())
@@ -105,9 +120,77 @@ func TestLinkedSet_UnmarshalJSON(t *testing.T) {
 	assert.Equal(t, []int{1, 2, 3}, set.ToArray())
 }
 
+func TestLinkedSet_Value(t *testing.T) {
+	value, err := NewLinkedSet(2, 1).Value()
+	assert.Nil(t, err)
+	assert.Equal(t, "[2,1]", value)
+}
+
+func TestLinkedSet_Scan(t *testing.T) {
+	set := NewLinkedSet(0)
+	assert.Nil(t, set.Scan("[2,1,2]"))
+	assert.Equal(t, []int{2, 1}, set.ToArray())
+	assert.Nil(t, set.Scan(nil))
+	assert.True(t, set.IsEmpty())
+}
+
+func TestLinkedSet_EncodeJSON(t *testing.T) {
+	value := NewLinkedSet(1, 2, 3)
+	buf := new(bytes.Buffer)
+	assert.Nil(t, value.EncodeJSON(buf))
+	expected, _ := value.ToJSON()
+	assert.JSONEq(t, string(expected), buf.String())
+}
+
+func TestLinkedSet_DecodeJSON(t *testing.T) {
+	value := NewLinkedSet(1, 2, 3)
+	assert.Nil(t, value.DecodeJSON(strings.NewReader(`[4, 5]`)))
+	assert.Equal(t, []int{4, 5}, value.ToArray())
+	assert.Error(t, value.DecodeJSON(strings.NewReader(`[6, "a"]`)))
+	assert.Equal(t, []int{4, 5}, value.ToArray())
+}
+
 func TestLinkedSet_String(t *testing.T) {
 	set := NewLinkedSet(1, 2, 3)
 	str := set.String()
 	pattern := regexp.MustCompile(fmt.Sprintf(`LinkedSet\[int]\(len=%d\)\{\n(\t\d+,\n){3}\}`, set.Count()))
 	assert.True(t, pattern.MatchString(str))
 }
+
+func TestLinkedSet_Concurrent(t *testing.T) {
+	set := NewLinkedSet[int]()
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			set.Push(i)
+		}(i)
+		go func() {
+			defer wg.Done()
+			_ = set.String()
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int64(100), set.Count())
+}
+
+func TestLinkedSet_MarshalCBOR(t *testing.T) {
+	value := NewLinkedSet(3, 1, 2)
+	data, err := cbor.Marshal(value)
+	assert.Nil(t, err)
+	decoded := NewLinkedSet[int]()
+	err = cbor.Unmarshal(data, decoded)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{3, 1, 2}, decoded.ToArray())
+}
+
+func TestLinkedSet_MarshalBinary(t *testing.T) {
+	value := NewLinkedSet(3, 1, 2)
+	data, err := value.MarshalBinary()
+	assert.Nil(t, err)
+	decoded := NewLinkedSet[int]()
+	err = decoded.UnmarshalBinary(data)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{3, 1, 2}, decoded.ToArray())
+}
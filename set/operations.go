@@ -0,0 +1,329 @@
+package set
+
+import (
+	"cmp"
+	"maps"
+	"slices"
+
+	"github.com/gopi-frame/collection"
+)
+
+// UnionAll returns a new set with the elements of any of the sets.
+// The result is sized up front for all the elements, so it is not grown while the sets are added.
+// Each set is read locked in turn, so the result is not an atomic snapshot of the sets which are modified meanwhile.
+func UnionAll[E comparable](sets ...*Set[E]) *Set[E] {
+	size := 0
+	for _, s := range sets {
+		size += int(s.Count())
+	}
+	elements := make(map[E]struct{}, size)
+	for _, s := range sets {
+		s.lock.RLock()
+		for element := range s.elements {
+			elements[element] = struct{}{}
+		}
+		s.lock.RUnlock()
+	}
+	return &Set[E]{elements: elements}
+}
+
+// IntersectAll returns a new set with the elements of all the sets, it is empty when no set is given.
+// The smallest set is copied first and the elements missing from the other sets are dropped,
+// so the work is bounded by the size of the smallest set rather than the largest.
+// Each set is read locked in turn, so the result is not an atomic snapshot of the sets which are modified meanwhile.
+func IntersectAll[E comparable](sets ...*Set[E]) *Set[E] {
+	if len(sets) == 0 {
+		return NewSet[E]()
+	}
+	sets = slices.Clone(sets)
+	slices.SortFunc(sets, func(a, b *Set[E]) int {
+		return cmp.Compare(a.Count(), b.Count())
+	})
+	sets[0].lock.RLock()
+	elements := maps.Clone(sets[0].elements)
+	sets[0].lock.RUnlock()
+	for _, s := range sets[1:] {
+		if len(elements) == 0 {
+			break
+		}
+		s.lock.RLock()
+		for element := range elements {
+			if _, ok := s.elements[element]; !ok {
+				delete(elements, element)
+			}
+		}
+		s.lock.RUnlock()
+	}
+	return &Set[E]{elements: elements}
+}
+
+// valuesOf returns a snapshot of the elements of the collection,
+// it is taken before the set is locked, so the collection may be the set itself
+func valuesOf[E comparable](c collection.Iterable[E]) ([]E, map[E]struct{}) {
+	values := slices.Collect(c.All())
+	elements := make(map[E]struct{}, len(values))
+	for _, value := range values {
+		elements[value] = struct{}{}
+	}
+	return values, elements
+}
+
+// Union returns a new set with the elements of the set and the collection, such as another Set or a LinkedSet
+func (s *Set[E]) Union(c collection.Iterable[E]) *Set[E] {
+	values, _ := valuesOf(c)
+	union := s.Clone()
+	union.Push(values...)
+	return union
+}
+
+// Intersect returns a new set with the elements of the set which the collection contains too
+func (s *Set[E]) Intersect(c collection.Iterable[E]) *Set[E] {
+	_, others := valuesOf(c)
+	return s.filter(func(value E) bool {
+		_, ok := others[value]
+		return ok
+	})
+}
+
+// Diff returns a new set with the elements of the set which the collection does not contain
+func (s *Set[E]) Diff(c collection.Iterable[E]) *Set[E] {
+	_, others := valuesOf(c)
+	return s.filter(func(value E) bool {
+		_, ok := others[value]
+		return !ok
+	})
+}
+
+// SymmetricDiff returns a new set with the elements of either the set or the collection but not both
+func (s *Set[E]) SymmetricDiff(c collection.Iterable[E]) *Set[E] {
+	_, others := valuesOf(c)
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	elements := make(map[E]struct{})
+	for value := range s.elements {
+		if _, ok := others[value]; !ok {
+			elements[value] = struct{}{}
+		}
+	}
+	for value := range others {
+		if _, ok := s.elements[value]; !ok {
+			elements[value] = struct{}{}
+		}
+	}
+	return &Set[E]{elements: elements}
+}
+
+func (s *Set[E]) filter(callback func(value E) bool) *Set[E] {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	elements := make(map[E]struct{})
+	for value := range s.elements {
+		if callback(value) {
+			elements[value] = struct{}{}
+		}
+	}
+	return &Set[E]{elements: elements}
+}
+
+// UnionWith adds the elements of the collection to the set
+func (s *Set[E]) UnionWith(c collection.Iterable[E]) {
+	values, _ := valuesOf(c)
+	s.Push(values...)
+}
+
+// IntersectWith removes the elements which the collection does not contain from the set
+func (s *Set[E]) IntersectWith(c collection.Iterable[E]) {
+	_, others := valuesOf(c)
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	maps.DeleteFunc(s.elements, func(value E, _ struct{}) bool {
+		_, ok := others[value]
+		return !ok
+	})
+}
+
+// DiffWith removes the elements which the collection contains from the set
+func (s *Set[E]) DiffWith(c collection.Iterable[E]) {
+	_, others := valuesOf(c)
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for value := range others {
+		delete(s.elements, value)
+	}
+}
+
+// SymmetricDiffWith removes the elements which the collection contains from the set and adds the others of the collection
+func (s *Set[E]) SymmetricDiffWith(c collection.Iterable[E]) {
+	_, others := valuesOf(c)
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for value := range others {
+		if _, ok := s.elements[value]; ok {
+			delete(s.elements, value)
+		} else {
+			s.elements[value] = struct{}{}
+		}
+	}
+}
+
+// IsSubsetOf returns whether the collection contains all elements of the set
+func (s *Set[E]) IsSubsetOf(c collection.Iterable[E]) bool {
+	_, others := valuesOf(c)
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return isSubset(s.elements, others)
+}
+
+// IsSupersetOf returns whether the set contains all elements of the collection
+func (s *Set[E]) IsSupersetOf(c collection.Iterable[E]) bool {
+	_, others := valuesOf(c)
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return isSubset(others, s.elements)
+}
+
+// IsDisjointWith returns whether the set and the collection have no element in common
+func (s *Set[E]) IsDisjointWith(c collection.Iterable[E]) bool {
+	_, others := valuesOf(c)
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return isDisjoint(s.elements, others)
+}
+
+// Union returns a new set with the elements of the set followed by the new elements of the collection in its order
+func (s *LinkedSet[E]) Union(c collection.Iterable[E]) *LinkedSet[E] {
+	values, _ := valuesOf(c)
+	return NewLinkedSet(append(s.ToArray(), values...)...)
+}
+
+// Intersect returns a new set with the elements of the set which the collection contains too, in the order of the set
+func (s *LinkedSet[E]) Intersect(c collection.Iterable[E]) *LinkedSet[E] {
+	_, others := valuesOf(c)
+	return NewLinkedSet(slices.DeleteFunc(s.ToArray(), func(value E) bool {
+		_, ok := others[value]
+		return !ok
+	})...)
+}
+
+// Diff returns a new set with the elements of the set which the collection does not contain, in the order of the set
+func (s *LinkedSet[E]) Diff(c collection.Iterable[E]) *LinkedSet[E] {
+	_, others := valuesOf(c)
+	return NewLinkedSet(slices.DeleteFunc(s.ToArray(), func(value E) bool {
+		_, ok := others[value]
+		return ok
+	})...)
+}
+
+// SymmetricDiff returns a new set with the elements of either the set or the collection but not both,
+// the ones of the set come first in its order
+func (s *LinkedSet[E]) SymmetricDiff(c collection.Iterable[E]) *LinkedSet[E] {
+	values, others := valuesOf(c)
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	diff := NewLinkedSet[E]()
+	for value := range s.link.All() {
+		if _, ok := others[value]; !ok {
+			diff.push(value)
+		}
+	}
+	for _, value := range values {
+		if _, ok := s.elements[value]; !ok {
+			diff.push(value)
+		}
+	}
+	return diff
+}
+
+// UnionWith appends the new elements of the collection to the set in its order
+func (s *LinkedSet[E]) UnionWith(c collection.Iterable[E]) {
+	values, _ := valuesOf(c)
+	s.Push(values...)
+}
+
+// IntersectWith removes the elements which the collection does not contain from the set
+func (s *LinkedSet[E]) IntersectWith(c collection.Iterable[E]) {
+	_, others := valuesOf(c)
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.removeWhere(func(value E) bool {
+		_, ok := others[value]
+		return !ok
+	})
+}
+
+// DiffWith removes the elements which the collection contains from the set
+func (s *LinkedSet[E]) DiffWith(c collection.Iterable[E]) {
+	_, others := valuesOf(c)
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.removeWhere(func(value E) bool {
+		_, ok := others[value]
+		return ok
+	})
+}
+
+// SymmetricDiffWith removes the elements which the collection contains from the set
+// and appends the others of the collection in its order
+func (s *LinkedSet[E]) SymmetricDiffWith(c collection.Iterable[E]) {
+	values, others := valuesOf(c)
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	elements := maps.Clone(s.elements)
+	s.removeWhere(func(value E) bool {
+		_, ok := others[value]
+		return ok
+	})
+	for _, value := range values {
+		if _, ok := elements[value]; !ok {
+			s.push(value)
+		}
+	}
+}
+
+// IsSubsetOf returns whether the collection contains all elements of the set
+func (s *LinkedSet[E]) IsSubsetOf(c collection.Iterable[E]) bool {
+	_, others := valuesOf(c)
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return isSubset(s.elements, others)
+}
+
+// IsSupersetOf returns whether the set contains all elements of the collection
+func (s *LinkedSet[E]) IsSupersetOf(c collection.Iterable[E]) bool {
+	_, others := valuesOf(c)
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return isSubset(others, s.elements)
+}
+
+// IsDisjointWith returns whether the set and the collection have no element in common
+func (s *LinkedSet[E]) IsDisjointWith(c collection.Iterable[E]) bool {
+	_, others := valuesOf(c)
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return isDisjoint(s.elements, others)
+}
+
+func isSubset[E comparable](a, b map[E]struct{}) bool {
+	if len(a) > len(b) {
+		return false
+	}
+	for value := range a {
+		if _, ok := b[value]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func isDisjoint[E comparable](a, b map[E]struct{}) bool {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	for value := range a {
+		if _, ok := b[value]; ok {
+			return false
+		}
+	}
+	return true
+}
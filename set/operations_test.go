@@ -0,0 +1,119 @@
+package set
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnionAll(t *testing.T) {
+	a := NewSet(1, 2, 3)
+	b := NewSet(3, 4)
+	c := NewSet(5)
+	union := UnionAll(a, b, c)
+	assert.ElementsMatch(t, []int{1, 2, 3, 4, 5}, union.ToArray())
+	union.Push(6)
+	assert.EqualValues(t, 3, a.Count())
+	assert.True(t, UnionAll[int]().IsEmpty())
+	assert.ElementsMatch(t, []int{1, 2, 3}, UnionAll(a, a).ToArray())
+}
+
+func TestIntersectAll(t *testing.T) {
+	a := NewSet(1, 2, 3, 4, 5)
+	b := NewSet(2, 3, 4)
+	c := NewSet(4, 3, 9)
+	assert.ElementsMatch(t, []int{3, 4}, IntersectAll(a, b, c).ToArray())
+	assert.True(t, IntersectAll(a, NewSet[int]()).IsEmpty())
+	assert.True(t, IntersectAll[int]().IsEmpty())
+
+	single := IntersectAll(b)
+	single.Push(10)
+	assert.ElementsMatch(t, []int{2, 3, 4}, b.ToArray())
+}
+
+func TestSet_Union(t *testing.T) {
+	a := NewSet(1, 2, 3)
+	assert.ElementsMatch(t, []int{1, 2, 3, 4}, a.Union(NewLinkedSet(3, 4)).ToArray())
+	assert.ElementsMatch(t, []int{1, 2, 3}, a.Union(a).ToArray())
+	assert.EqualValues(t, 3, a.Count())
+	a.UnionWith(NewSet(5))
+	assert.ElementsMatch(t, []int{1, 2, 3, 5}, a.ToArray())
+}
+
+func TestSet_Intersect(t *testing.T) {
+	a := NewSet(1, 2, 3)
+	assert.ElementsMatch(t, []int{2, 3}, a.Intersect(NewLinkedSet(3, 2, 9)).ToArray())
+	a.IntersectWith(NewSet(1, 3))
+	assert.ElementsMatch(t, []int{1, 3}, a.ToArray())
+	a.IntersectWith(a)
+	assert.ElementsMatch(t, []int{1, 3}, a.ToArray())
+}
+
+func TestSet_Diff(t *testing.T) {
+	a := NewSet(1, 2, 3)
+	assert.ElementsMatch(t, []int{1}, a.Diff(NewSet(2, 3, 4)).ToArray())
+	a.DiffWith(NewLinkedSet(1))
+	assert.ElementsMatch(t, []int{2, 3}, a.ToArray())
+	a.DiffWith(a)
+	assert.True(t, a.IsEmpty())
+}
+
+func TestSet_SymmetricDiff(t *testing.T) {
+	a := NewSet(1, 2, 3)
+	assert.ElementsMatch(t, []int{1, 4}, a.SymmetricDiff(NewSet(2, 3, 4)).ToArray())
+	a.SymmetricDiffWith(NewLinkedSet(3, 4, 4))
+	assert.ElementsMatch(t, []int{1, 2, 4}, a.ToArray())
+}
+
+func TestSet_IsSubsetOf(t *testing.T) {
+	a := NewSet(1, 2)
+	assert.True(t, a.IsSubsetOf(NewLinkedSet(1, 2, 3)))
+	assert.True(t, a.IsSubsetOf(a))
+	assert.False(t, a.IsSubsetOf(NewSet(1)))
+	assert.True(t, a.IsSupersetOf(NewLinkedSet(2)))
+	assert.False(t, a.IsSupersetOf(NewSet(2, 5)))
+	assert.True(t, a.IsDisjointWith(NewSet(3, 4)))
+	assert.False(t, a.IsDisjointWith(NewLinkedSet(4, 2)))
+	assert.True(t, NewSet[int]().IsDisjointWith(NewSet[int]()))
+}
+
+func TestLinkedSet_Union(t *testing.T) {
+	a := NewLinkedSet(3, 1, 2)
+	assert.Equal(t, []int{3, 1, 2, 5, 4}, a.Union(NewLinkedSet(5, 1, 4)).ToArray())
+	a.UnionWith(NewLinkedSet(0, 3))
+	assert.Equal(t, []int{3, 1, 2, 0}, a.ToArray())
+}
+
+func TestLinkedSet_Intersect(t *testing.T) {
+	a := NewLinkedSet(3, 1, 2)
+	assert.Equal(t, []int{3, 2}, a.Intersect(NewSet(2, 3)).ToArray())
+	a.IntersectWith(NewLinkedSet(2, 1))
+	assert.Equal(t, []int{1, 2}, a.ToArray())
+}
+
+func TestLinkedSet_Diff(t *testing.T) {
+	a := NewLinkedSet(3, 1, 2)
+	assert.Equal(t, []int{3, 2}, a.Diff(NewSet(1)).ToArray())
+	a.DiffWith(NewLinkedSet(3))
+	assert.Equal(t, []int{1, 2}, a.ToArray())
+	assert.True(t, a.Contains(1))
+	assert.False(t, a.Contains(3))
+}
+
+func TestLinkedSet_SymmetricDiff(t *testing.T) {
+	a := NewLinkedSet(3, 1, 2)
+	assert.Equal(t, []int{3, 2, 5, 4}, a.SymmetricDiff(NewLinkedSet(5, 1, 4)).ToArray())
+	a.SymmetricDiffWith(NewLinkedSet(5, 1, 4, 5))
+	assert.Equal(t, []int{3, 2, 5, 4}, a.ToArray())
+	a.SymmetricDiffWith(a)
+	assert.True(t, a.IsEmpty())
+}
+
+func TestLinkedSet_IsSubsetOf(t *testing.T) {
+	a := NewLinkedSet(1, 2)
+	assert.True(t, a.IsSubsetOf(NewSet(1, 2, 3)))
+	assert.False(t, a.IsSubsetOf(NewLinkedSet(2)))
+	assert.True(t, a.IsSupersetOf(NewSet(1)))
+	assert.True(t, a.IsDisjointWith(NewSet(3)))
+	assert.False(t, a.IsDisjointWith(a))
+}
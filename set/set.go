@@ -1,10 +1,23 @@
 package set
 
 import (
+	"database/sql/driver"
 	"encoding/json"
 	"fmt"
-	"strings"
+	"io"
+	"iter"
+	"log/slog"
+	"maps"
+	"slices"
 	"sync"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/gopi-frame/collection"
+	"github.com/gopi-frame/collection/internal/codec"
+	"github.com/gopi-frame/collection/internal/hash"
+	"github.com/gopi-frame/collection/internal/jsonstream"
+	"github.com/gopi-frame/collection/internal/preview"
+	"github.com/gopi-frame/collection/internal/sqljson"
 )
 
 // NewSet new set
@@ -18,14 +31,19 @@ func NewSet[E comparable](values ...E) *Set[E] {
 	return set
 }
 
-// Set hash set
+// Set hash set, all methods are safe for concurrent use.
+// The callbacks run while the set is locked, so they must not call methods of the same set,
+// except the callback of Each which runs on a snapshot.
 type Set[E comparable] struct {
-	sync.RWMutex
+	lock     sync.RWMutex
 	elements map[E]struct{}
+	limit    preview.Limit
 }
 
 // Count returns the size of set
 func (s *Set[E]) Count() int64 {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
 	return int64(len(s.elements))
 }
 
@@ -41,12 +59,16 @@ func (s *Set[E]) IsNotEmpty() bool {
 
 // Contains returns whether the set contains the specific element
 func (s *Set[E]) Contains(value E) bool {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
 	_, contains := s.elements[value]
 	return contains
 }
 
 // ContainsWhere returns whether the set contains elements which matches the callback
 func (s *Set[E]) ContainsWhere(callback func(E) bool) bool {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
 	for item := range s.elements {
 		if callback(item) {
 			return true
@@ -57,21 +79,24 @@ func (s *Set[E]) ContainsWhere(callback func(E) bool) bool {
 
 // Push pushes elements into the set
 func (s *Set[E]) Push(values ...E) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
 	for _, value := range values {
-		if s.Contains(value) {
-			continue
-		}
 		s.elements[value] = struct{}{}
 	}
 }
 
 // Remove removes the specific element
 func (s *Set[E]) Remove(value E) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
 	delete(s.elements, value)
 }
 
 // RemoveWhere removes elements which matches the callback
 func (s *Set[E]) RemoveWhere(callback func(E) bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
 	items := map[E]struct{}{}
 	for item := range s.elements {
 		if callback(item) {
@@ -82,9 +107,10 @@ func (s *Set[E]) RemoveWhere(callback func(E) bool) {
 	s.elements = items
 }
 
-// Each runs callback for each element, it breaks when callback false
+// Each runs callback for each element, it breaks when callback false.
+// The callback runs on a snapshot of the set, so it is allowed to modify the set.
 func (s *Set[E]) Each(callback func(_ int, item E) bool) {
-	for item := range s.elements {
+	for _, item := range s.ToArray() {
 		if !callback(-1, item) {
 			break
 		}
@@ -93,18 +119,70 @@ func (s *Set[E]) Each(callback func(_ int, item E) bool) {
 
 // Clear clears the set
 func (s *Set[E]) Clear() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
 	s.elements = map[E]struct{}{}
 }
 
 // Clone clones the set
 func (s *Set[E]) Clone() *Set[E] {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
 	return &Set[E]{
-		elements: s.elements,
+		elements: maps.Clone(s.elements),
+	}
+}
+
+// DeepClone clones the set and its elements, see [collection.CloneValue]
+func (s *Set[E]) DeepClone() *Set[E] {
+	items := s.ToArray()
+	for index, item := range items {
+		items[index] = collection.CloneValue(item)
+	}
+	return NewSet(items...)
+}
+
+// Equal returns whether the sets have the same elements
+func (s *Set[E]) Equal(other *Set[E]) bool {
+	if s == other {
+		return true
+	}
+	if other == nil {
+		return false
+	}
+	items := s.ToArray()
+	other.lock.RLock()
+	defer other.lock.RUnlock()
+	if len(items) != len(other.elements) {
+		return false
+	}
+	for _, item := range items {
+		if _, ok := other.elements[item]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Hash returns the hash of the elements regardless of their order, sets which are equal have the same hash
+func (s *Set[E]) Hash() uint64 {
+	items := s.ToArray()
+	hashes := make([]uint64, len(items))
+	for index, item := range items {
+		hashes[index] = hash.Value(item)
 	}
+	return hash.Unordered(hashes...)
 }
 
-// ToArray converts to array
+// All returns an iterator over the elements
+func (s *Set[E]) All() iter.Seq[E] {
+	return slices.Values(s.ToArray())
+}
+
+// ToArray converts to array, the returned slice is a copy, so modifying it does not affect the collection
 func (s *Set[E]) ToArray() []E {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
 	var values []E
 	for item := range s.elements {
 		values = append(values, item)
@@ -129,35 +207,117 @@ func (s *Set[E]) UnmarshalJSON(data []byte) error {
 	if err != nil {
 		return err
 	}
-	s.Clear()
-	s.Push(items...)
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.elements = make(map[E]struct{}, len(items))
+	for _, item := range items {
+		s.elements[item] = struct{}{}
+	}
 	return nil
 }
 
-// String converts to string
-func (s *Set[E]) String() string {
-	str := new(strings.Builder)
-	str.WriteString(fmt.Sprintf("Set[%T](len=%d)", *new(E), len(s.elements)))
-	str.WriteByte('{')
-	str.WriteByte('\n')
-	index := 0
-	for item := range s.elements {
-		index++
-		str.WriteByte('\t')
-		if v, ok := any(item).(fmt.Stringer); ok {
-			str.WriteString(v.String())
-		} else {
-			str.WriteString(fmt.Sprintf("%v", item))
-		}
-		str.WriteByte(',')
-		str.WriteByte('\n')
-		if index >= 4 {
-			break
-		}
+// EncodeJSON writes the set to w as a json array one element at a time, the set is read locked while writing
+func (s *Set[E]) EncodeJSON(w io.Writer) error {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return jsonstream.Encode(w, maps.Keys(s.elements))
+}
+
+// DecodeJSON reads a json array from r one element at a time and replaces the elements of the set
+func (s *Set[E]) DecodeJSON(r io.Reader) error {
+	elements := make(map[E]struct{})
+	if err := jsonstream.Decode(r, func(value E) {
+		elements[value] = struct{}{}
+	}); err != nil {
+		return err
 	}
-	if len(s.elements) > 5 {
-		str.WriteString("\t...\n")
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.elements = elements
+	return nil
+}
+
+// Value implements [driver.Valuer], the set is stored as a json array
+func (s *Set[E]) Value() (driver.Value, error) {
+	return sqljson.Value(s.ToJSON())
+}
+
+// Scan implements [database/sql.Scanner], it reads a json array and replaces the elements of the set, NULL is read as empty
+func (s *Set[E]) Scan(src any) error {
+	data, err := sqljson.Bytes(src)
+	if err != nil {
+		return err
 	}
-	str.WriteByte('}')
-	return str.String()
+	return s.UnmarshalJSON(data)
+}
+
+// ToCBOR converts to cbor
+func (s *Set[E]) ToCBOR() ([]byte, error) {
+	return cbor.Marshal(s.ToArray())
+}
+
+// MarshalCBOR implements [cbor.Marshaler]
+func (s *Set[E]) MarshalCBOR() ([]byte, error) {
+	return s.ToCBOR()
+}
+
+// UnmarshalCBOR implements [cbor.Unmarshaler]
+func (s *Set[E]) UnmarshalCBOR(data []byte) error {
+	var items []E
+	err := cbor.Unmarshal(data, &items)
+	if err != nil {
+		return err
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.elements = make(map[E]struct{}, len(items))
+	for _, item := range items {
+		s.elements[item] = struct{}{}
+	}
+	return nil
+}
+
+// MarshalBinary implements [encoding.BinaryMarshaler], the elements are encoded by their own encoders
+func (s *Set[E]) MarshalBinary() ([]byte, error) {
+	return codec.EncodeValues(s.ToArray())
+}
+
+// UnmarshalBinary implements [encoding.BinaryUnmarshaler]
+func (s *Set[E]) UnmarshalBinary(data []byte) error {
+	items, err := codec.DecodeValues[E](data)
+	if err != nil {
+		return err
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.elements = make(map[E]struct{}, len(items))
+	for _, item := range items {
+		s.elements[item] = struct{}{}
+	}
+	return nil
+}
+
+// SetPreviewLimit sets the number of elements shown by String, %v and LogValue, a negative limit shows all elements
+func (s *Set[E]) SetPreviewLimit(limit int) {
+	s.limit.Set(limit)
+}
+
+// String converts to string, only the preview limit of elements are shown
+func (s *Set[E]) String() string {
+	return s.format(s.limit.Get(preview.DefaultLimit))
+}
+
+// Format implements [fmt.Formatter], %v prints the preview as String does, %+v and %#v print all elements
+func (s *Set[E]) Format(f fmt.State, verb rune) {
+	preview.Format(f, verb, s.format, s.limit.Get(preview.DefaultLimit))
+}
+
+func (s *Set[E]) format(limit int) string {
+	items := s.ToArray()
+	return preview.Elements(fmt.Sprintf("Set[%T](len=%d)", *new(E), len(items)), items, limit)
+}
+
+// LogValue implements [slog.LogValuer], only the preview limit of elements are logged
+func (s *Set[E]) LogValue() slog.Value {
+	return preview.LogElements(fmt.Sprintf("Set[%T]", *new(E)), s.ToArray(), s.limit.Get(preview.DefaultLimit))
 }
@@ -1,11 +1,16 @@
 package set
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"regexp"
+	"strings"
+	"sync"
 	"testing"
 
+	"github.com/fxamacker/cbor/v2"
+	"github.com/gopi-frame/collection/list"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -79,6 +84,32 @@ func TestSet_Clone(t *testing.T) {
 	assert.Equal(t, set.elements, set2.elements)
 }
 
+func TestSet_DeepClone(t *testing.T) {
+	inner := list.NewList(1, 2)
+	set := NewSet(inner)
+	clone := set.DeepClone()
+	assert.EqualValues(t, 1, clone.Count())
+	assert.False(t, clone.Contains(inner))
+	inner.Push(3)
+	assert.Equal(t, []int{1, 2}, clone.ToArray()[0].ToArray())
+}
+
+func TestSet_Equal(t *testing.T) {
+	set := NewSet(1, 2, 3)
+	assert.True(t, set.Equal(set))
+	assert.True(t, set.Equal(NewSet(3, 2, 1)))
+	assert.False(t, set.Equal(NewSet(1, 2)))
+	assert.False(t, set.Equal(NewSet(1, 2, 4)))
+	assert.False(t, set.Equal(nil))
+}
+
+func TestSet_Hash(t *testing.T) {
+	assert.Equal(t, NewSet(1, 2, 3).Hash(), NewSet(3, 1, 2).Hash())
+	assert.NotEqual(t, NewSet(1, 2, 3).Hash(), NewSet(1, 2).Hash())
+	keys := NewSet[uint64](NewSet("a", "b").Hash(), NewSet("b", "a").Hash())
+	assert.EqualValues(t, 1, keys.Count())
+}
+
 func TestSet_ToArray(t *testing.T) {
 	set := NewSet[int](1, 2, 3)
 	assert.ElementsMatch(t, []int{1, 2, 3}, set.ToArray())
@@ -115,9 +146,78 @@ func TestSet_UnmarshalJSON(t *testing.T) {
 	assert.ElementsMatch(t, []int{1, 2, 3}, set.ToArray())
 }
 
+func TestSet_Value(t *testing.T) {
+	value, err := NewSet(1).Value()
+	assert.Nil(t, err)
+	assert.Equal(t, "[1]", value)
+}
+
+func TestSet_Scan(t *testing.T) {
+	set := NewSet(0)
+	assert.Nil(t, set.Scan([]byte("[1,2,1]")))
+	assert.ElementsMatch(t, []int{1, 2}, set.ToArray())
+	assert.Nil(t, set.Scan(nil))
+	assert.True(t, set.IsEmpty())
+}
+
+func TestSet_EncodeJSON(t *testing.T) {
+	value := NewSet(1, 2, 3)
+	buf := new(bytes.Buffer)
+	assert.Nil(t, value.EncodeJSON(buf))
+	var decoded []int
+	assert.Nil(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.ElementsMatch(t, []int{1, 2, 3}, decoded)
+}
+
+func TestSet_DecodeJSON(t *testing.T) {
+	value := NewSet(1, 2, 3)
+	assert.Nil(t, value.DecodeJSON(strings.NewReader(`[4, 5]`)))
+	assert.ElementsMatch(t, []int{4, 5}, value.ToArray())
+	assert.Error(t, value.DecodeJSON(strings.NewReader(`[6, "a"]`)))
+	assert.ElementsMatch(t, []int{4, 5}, value.ToArray())
+}
+
 func TestSet_String(t *testing.T) {
 	set := NewSet[int](1, 2, 3)
 	str := set.String()
 	pattern := regexp.MustCompile(fmt.Sprintf(`Set\[int\]\(len=%d\)\{\n(\t\d+,\n){3}\}`, set.Count()))
 	assert.True(t, pattern.MatchString(str))
 }
+
+func TestSet_Concurrent(t *testing.T) {
+	set := NewSet[int]()
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			set.Push(i)
+		}(i)
+		go func() {
+			defer wg.Done()
+			_ = set.String()
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int64(100), set.Count())
+}
+
+func TestSet_MarshalCBOR(t *testing.T) {
+	value := NewSet(1, 2, 3)
+	data, err := cbor.Marshal(value)
+	assert.Nil(t, err)
+	decoded := NewSet[int]()
+	err = cbor.Unmarshal(data, decoded)
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []int{1, 2, 3}, decoded.ToArray())
+}
+
+func TestSet_MarshalBinary(t *testing.T) {
+	value := NewSet(1, 2, 3)
+	data, err := value.MarshalBinary()
+	assert.Nil(t, err)
+	decoded := NewSet[int]()
+	err = decoded.UnmarshalBinary(data)
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []int{1, 2, 3}, decoded.ToArray())
+}
@@ -0,0 +1,134 @@
+package set
+
+import (
+	"encoding/json"
+	"fmt"
+	"iter"
+	"log/slog"
+
+	"github.com/gopi-frame/collection/internal/preview"
+	"github.com/gopi-frame/collection/internal/skiplist"
+	"github.com/gopi-frame/contract"
+)
+
+// NewSkipListSet new sorted set ordered by the comparator
+func NewSkipListSet[E any](comparator contract.Comparator[E], values ...E) *SkipListSet[E] {
+	set := &SkipListSet[E]{list: skiplist.New(comparator.Compare)}
+	for _, value := range values {
+		set.Add(value)
+	}
+	return set
+}
+
+// SkipListSet concurrent sorted set based on a lazy skip list, all methods are safe for concurrent use.
+// Add and Remove only lock the nodes next to the element, and Contains and the iterations take no lock at all,
+// so it scales with the number of goroutines where the trees serialize every change behind a single lock.
+// The iterations are weakly consistent: they see the elements in order, and an element added or removed meanwhile
+// may or may not be seen. Count is exact once the changes are done.
+type SkipListSet[E any] struct {
+	list  *skiplist.List[E]
+	limit preview.Limit
+}
+
+// Add adds the value, it returns false when the set already contains an equal value
+func (s *SkipListSet[E]) Add(value E) bool {
+	_, added := s.list.Add(value)
+	return added
+}
+
+// Remove removes the value, it returns false when the set does not contain it
+func (s *SkipListSet[E]) Remove(value E) bool {
+	return s.list.Remove(value)
+}
+
+// Contains returns whether the set contains the value, it takes no lock
+func (s *SkipListSet[E]) Contains(value E) bool {
+	_, ok := s.list.Get(value)
+	return ok
+}
+
+// Count returns the size of the set
+func (s *SkipListSet[E]) Count() int64 {
+	return s.list.Count()
+}
+
+// IsEmpty returns whether the set is empty
+func (s *SkipListSet[E]) IsEmpty() bool {
+	return s.Count() == 0
+}
+
+// IsNotEmpty returns whether the set is not empty
+func (s *SkipListSet[E]) IsNotEmpty() bool {
+	return !s.IsEmpty()
+}
+
+// First returns the smallest element, it returns zero value and false when the set is empty
+func (s *SkipListSet[E]) First() (E, bool) {
+	return s.list.First()
+}
+
+// All returns an iterator over the elements in order, it is weakly consistent
+func (s *SkipListSet[E]) All() iter.Seq[E] {
+	return s.list.All()
+}
+
+// Range returns an iterator over the elements in the range [from, to) in order, it is weakly consistent
+func (s *SkipListSet[E]) Range(from, to E) iter.Seq[E] {
+	return s.list.Range(from, to)
+}
+
+// ToArray converts to array in order, the returned slice is a copy, so modifying it does not affect the collection
+func (s *SkipListSet[E]) ToArray() []E {
+	items := make([]E, 0, s.Count())
+	for value := range s.All() {
+		items = append(items, value)
+	}
+	return items
+}
+
+// ToJSON converts to json
+func (s *SkipListSet[E]) ToJSON() ([]byte, error) {
+	return json.Marshal(s.ToArray())
+}
+
+// MarshalJSON implements [json.Marshaller]
+func (s *SkipListSet[E]) MarshalJSON() ([]byte, error) {
+	return s.ToJSON()
+}
+
+// UnmarshalJSON implements [json.Unmarshaller], the elements are added to the set, so it must be created with a comparator
+func (s *SkipListSet[E]) UnmarshalJSON(data []byte) error {
+	var items []E
+	if err := json.Unmarshal(data, &items); err != nil {
+		return err
+	}
+	for _, item := range items {
+		s.Add(item)
+	}
+	return nil
+}
+
+// SetPreviewLimit sets the number of elements shown by String, %v and LogValue, a negative limit shows all elements
+func (s *SkipListSet[E]) SetPreviewLimit(limit int) {
+	s.limit.Set(limit)
+}
+
+// String converts to string, only the preview limit of elements are shown
+func (s *SkipListSet[E]) String() string {
+	return s.format(s.limit.Get(preview.DefaultLimit))
+}
+
+// Format implements [fmt.Formatter], %v prints the preview as String does, %+v and %#v print all elements
+func (s *SkipListSet[E]) Format(f fmt.State, verb rune) {
+	preview.Format(f, verb, s.format, s.limit.Get(preview.DefaultLimit))
+}
+
+func (s *SkipListSet[E]) format(limit int) string {
+	items := s.ToArray()
+	return preview.Elements(fmt.Sprintf("SkipListSet[%T](len=%d)", *new(E), len(items)), items, limit)
+}
+
+// LogValue implements [slog.LogValuer], only the preview limit of elements are logged
+func (s *SkipListSet[E]) LogValue() slog.Value {
+	return preview.LogElements(fmt.Sprintf("SkipListSet[%T]", *new(E)), s.ToArray(), s.limit.Get(preview.DefaultLimit))
+}
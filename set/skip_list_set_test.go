@@ -0,0 +1,114 @@
+package set
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type _cmp struct{}
+
+func (c _cmp) Compare(a, b int) int {
+	if a < b {
+		return -1
+	} else if a > b {
+		return 1
+	}
+	return 0
+}
+
+func TestSkipListSet_Add(t *testing.T) {
+	set := NewSkipListSet[int](_cmp{}, 5, 3, 9)
+	assert.True(t, set.Add(1))
+	assert.False(t, set.Add(5))
+	assert.EqualValues(t, 4, set.Count())
+	assert.Equal(t, []int{1, 3, 5, 9}, set.ToArray())
+	first, ok := set.First()
+	assert.True(t, ok)
+	assert.Equal(t, 1, first)
+}
+
+func TestSkipListSet_Remove(t *testing.T) {
+	set := NewSkipListSet[int](_cmp{}, 1, 2, 3)
+	assert.True(t, set.Remove(2))
+	assert.False(t, set.Remove(2))
+	assert.False(t, set.Remove(10))
+	assert.False(t, set.Contains(2))
+	assert.True(t, set.Contains(3))
+	assert.Equal(t, []int{1, 3}, set.ToArray())
+	set.Remove(1)
+	set.Remove(3)
+	assert.True(t, set.IsEmpty())
+	_, ok := set.First()
+	assert.False(t, ok)
+}
+
+func TestSkipListSet_Range(t *testing.T) {
+	set := NewSkipListSet[int](_cmp{})
+	for i := 20; i > 0; i-- {
+		set.Add(i * 5)
+	}
+	assert.Equal(t, []int{15, 20, 25}, slices.Collect(set.Range(12, 30)))
+	assert.Equal(t, []int{100}, slices.Collect(set.Range(100, 200)))
+	assert.Empty(t, slices.Collect(set.Range(30, 30)))
+	var items []int
+	for value := range set.All() {
+		if value > 15 {
+			break
+		}
+		items = append(items, value)
+	}
+	assert.Equal(t, []int{5, 10, 15}, items)
+}
+
+func TestSkipListSet_JSON(t *testing.T) {
+	set := NewSkipListSet[int](_cmp{}, 3, 1, 2)
+	data, err := json.Marshal(set)
+	assert.Nil(t, err)
+	assert.Equal(t, `[1,2,3]`, string(data))
+	decoded := NewSkipListSet[int](_cmp{})
+	assert.Nil(t, json.Unmarshal([]byte(`[4,2,4]`), decoded))
+	assert.Equal(t, []int{2, 4}, decoded.ToArray())
+}
+
+func TestSkipListSet_String(t *testing.T) {
+	set := NewSkipListSet[int](_cmp{}, 7, 6, 5, 4, 3, 2, 1)
+	assert.Equal(t, "SkipListSet[int](len=7){\n\t1,\n\t2,\n\t3,\n\t4,\n\t5,\n\t...\n}", set.String())
+	assert.Contains(t, fmt.Sprintf("%+v", set), "\t7,\n")
+}
+
+func TestSkipListSet_Concurrent(t *testing.T) {
+	set := NewSkipListSet[int](_cmp{})
+	wg := new(sync.WaitGroup)
+	for worker := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 500 {
+				set.Add(i)
+				if i%2 == worker%2 {
+					set.Remove(i)
+				}
+				set.Contains(i)
+			}
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for range 50 {
+			assert.True(t, slices.IsSorted(set.ToArray()))
+		}
+	}()
+	wg.Wait()
+	items := set.ToArray()
+	assert.True(t, slices.IsSorted(items))
+	assert.EqualValues(t, len(items), set.Count())
+	for i := range 500 {
+		assert.Equal(t, slices.Contains(items, i), set.Contains(i))
+	}
+}
@@ -0,0 +1,176 @@
+package set
+
+import (
+	"cmp"
+	"fmt"
+	"iter"
+	"log/slog"
+
+	"github.com/gopi-frame/collection/comparator"
+	"github.com/gopi-frame/collection/internal/preview"
+	"github.com/gopi-frame/collection/tree"
+	"github.com/gopi-frame/contract"
+)
+
+// NewTreeSet new sorted set ordered by the comparator
+func NewTreeSet[E any](comparator contract.Comparator[E], values ...E) *TreeSet[E] {
+	return &TreeSet[E]{tree: tree.NewRBTreeWithPolicy(comparator, tree.IgnoreDuplicates, values...)}
+}
+
+// NewTreeSetOrdered new sorted set of ordered elements, the elements are compared with [cmp.Compare]
+func NewTreeSetOrdered[E cmp.Ordered](values ...E) *TreeSet[E] {
+	return NewTreeSet[E](comparator.Ordered[E](), values...)
+}
+
+// TreeSet sorted set based on a red-black tree, all methods are safe for concurrent use.
+// The elements are kept in comparator order, two elements are the same element when the comparator returns 0,
+// and the navigation methods find the neighbours of any value in O(log n) time.
+type TreeSet[E any] struct {
+	tree  *tree.RBTree[E]
+	limit preview.Limit
+}
+
+// Count returns the size of set
+func (s *TreeSet[E]) Count() int64 {
+	return s.tree.Count()
+}
+
+// IsEmpty returns whether the set is empty
+func (s *TreeSet[E]) IsEmpty() bool {
+	return s.tree.IsEmpty()
+}
+
+// IsNotEmpty returns whether the set is not empty
+func (s *TreeSet[E]) IsNotEmpty() bool {
+	return s.tree.IsNotEmpty()
+}
+
+// Contains returns whether the set contains the specific element
+func (s *TreeSet[E]) Contains(value E) bool {
+	return s.tree.Contains(value)
+}
+
+// Add adds the element and reports whether it is added, it is not when the set already contains it
+func (s *TreeSet[E]) Add(value E) bool {
+	return s.tree.Add(value)
+}
+
+// Push pushes elements into the set
+func (s *TreeSet[E]) Push(values ...E) {
+	s.tree.Push(values...)
+}
+
+// Remove removes the specific element
+func (s *TreeSet[E]) Remove(value E) {
+	s.tree.Remove(value)
+}
+
+// RemoveBetween removes the elements in the range [from, to) and returns the number of removed elements
+func (s *TreeSet[E]) RemoveBetween(from, to E) int64 {
+	return s.tree.RemoveBetween(from, to)
+}
+
+// Clear clears the set
+func (s *TreeSet[E]) Clear() {
+	s.tree.Clear()
+}
+
+// First returns the smallest element, it returns zero value and false when the set is empty
+func (s *TreeSet[E]) First() (E, bool) {
+	return s.tree.First()
+}
+
+// Last returns the largest element, it returns zero value and false when the set is empty
+func (s *TreeSet[E]) Last() (E, bool) {
+	return s.tree.Last()
+}
+
+// Floor returns the largest element less than or equal to the value.
+// It returns zero value and false when there is no such element.
+func (s *TreeSet[E]) Floor(value E) (E, bool) {
+	return s.tree.Floor(value)
+}
+
+// Ceiling returns the smallest element greater than or equal to the value.
+// It returns zero value and false when there is no such element.
+func (s *TreeSet[E]) Ceiling(value E) (E, bool) {
+	return s.tree.Ceiling(value)
+}
+
+// Higher returns the smallest element greater than the value, whether or not the set contains the value.
+// It returns zero value and false when there is no such element.
+func (s *TreeSet[E]) Higher(value E) (E, bool) {
+	return s.tree.Next(value)
+}
+
+// Lower returns the largest element less than the value, whether or not the set contains the value.
+// It returns zero value and false when there is no such element.
+func (s *TreeSet[E]) Lower(value E) (E, bool) {
+	return s.tree.Prev(value)
+}
+
+// Range returns an iterator over the elements in the range [from, to) in order, it iterates over a snapshot of the range
+func (s *TreeSet[E]) Range(from, to E) iter.Seq[E] {
+	return s.tree.Sub(from, to).All()
+}
+
+// Clone clones the set
+func (s *TreeSet[E]) Clone() *TreeSet[E] {
+	return &TreeSet[E]{tree: s.tree.Clone()}
+}
+
+// DeepClone clones the set and its elements, see [collection.CloneValue].
+// The clones must be ordered as the elements they are cloned from.
+func (s *TreeSet[E]) DeepClone() *TreeSet[E] {
+	return &TreeSet[E]{tree: s.tree.DeepClone()}
+}
+
+// All returns an iterator over the elements in order
+func (s *TreeSet[E]) All() iter.Seq[E] {
+	return s.tree.All()
+}
+
+// ToArray converts to array in order, the returned slice is a copy, so modifying it does not affect the collection
+func (s *TreeSet[E]) ToArray() []E {
+	return s.tree.ToArray()
+}
+
+// ToJSON converts to json
+func (s *TreeSet[E]) ToJSON() ([]byte, error) {
+	return s.tree.ToJSON()
+}
+
+// MarshalJSON implements [json.Marshaller]
+func (s *TreeSet[E]) MarshalJSON() ([]byte, error) {
+	return s.ToJSON()
+}
+
+// UnmarshalJSON implements [json.Unmarshaller], the elements of the array replace the elements of the set
+func (s *TreeSet[E]) UnmarshalJSON(data []byte) error {
+	return s.tree.UnmarshalJSON(data)
+}
+
+// SetPreviewLimit sets the number of elements shown by String, %v and LogValue, a negative limit shows all elements
+func (s *TreeSet[E]) SetPreviewLimit(limit int) {
+	s.limit.Set(limit)
+}
+
+// String converts to string, only the preview limit of elements are shown
+func (s *TreeSet[E]) String() string {
+	return s.format(s.limit.Get(preview.DefaultLimit))
+}
+
+// Format implements [fmt.Formatter], %v prints the preview as String does, %+v and %#v print all elements
+func (s *TreeSet[E]) Format(f fmt.State, verb rune) {
+	preview.Format(f, verb, s.format, s.limit.Get(preview.DefaultLimit))
+}
+
+func (s *TreeSet[E]) format(limit int) string {
+	items := s.ToArray()
+	return preview.Elements(fmt.Sprintf("TreeSet[%T](len=%d)", *new(E), len(items)), items, limit)
+}
+
+// LogValue implements [slog.LogValuer], only the preview limit of elements are logged
+func (s *TreeSet[E]) LogValue() slog.Value {
+	return preview.LogElements(fmt.Sprintf("TreeSet[%T]", *new(E)), s.ToArray(), s.limit.Get(preview.DefaultLimit))
+}
@@ -0,0 +1,85 @@
+package set
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTreeSet_Add(t *testing.T) {
+	set := NewTreeSet[int](_cmp{}, 5, 3, 9, 3)
+	assert.EqualValues(t, 3, set.Count())
+	assert.True(t, set.Add(1))
+	assert.False(t, set.Add(5))
+	assert.Equal(t, []int{1, 3, 5, 9}, set.ToArray())
+	assert.True(t, set.Contains(9))
+	assert.False(t, set.Contains(4))
+}
+
+func TestTreeSet_Remove(t *testing.T) {
+	set := NewTreeSetOrdered(1, 2, 3, 4, 5)
+	set.Remove(2)
+	set.Remove(10)
+	assert.Equal(t, []int{1, 3, 4, 5}, set.ToArray())
+	assert.EqualValues(t, 2, set.RemoveBetween(3, 5))
+	assert.Equal(t, []int{1, 5}, set.ToArray())
+	set.Clear()
+	assert.True(t, set.IsEmpty())
+}
+
+func TestTreeSet_Navigation(t *testing.T) {
+	set := NewTreeSetOrdered(10, 20, 30)
+	first, ok := set.First()
+	assert.True(t, ok)
+	assert.Equal(t, 10, first)
+	last, _ := set.Last()
+	assert.Equal(t, 30, last)
+
+	value, _ := set.Floor(20)
+	assert.Equal(t, 20, value)
+	value, _ = set.Floor(25)
+	assert.Equal(t, 20, value)
+	value, _ = set.Ceiling(25)
+	assert.Equal(t, 30, value)
+	value, _ = set.Higher(20)
+	assert.Equal(t, 30, value)
+	value, _ = set.Lower(20)
+	assert.Equal(t, 10, value)
+
+	_, ok = set.Floor(5)
+	assert.False(t, ok)
+	_, ok = set.Higher(30)
+	assert.False(t, ok)
+	_, ok = NewTreeSetOrdered[int]().First()
+	assert.False(t, ok)
+}
+
+func TestTreeSet_Range(t *testing.T) {
+	set := NewTreeSetOrdered(1, 2, 3, 4, 5)
+	assert.Equal(t, []int{2, 3, 4}, slices.Collect(set.Range(2, 5)))
+	assert.Empty(t, slices.Collect(set.Range(4, 2)))
+	for value := range set.Range(1, 6) {
+		set.Remove(value)
+	}
+	assert.True(t, set.IsEmpty())
+}
+
+func TestTreeSet_Clone(t *testing.T) {
+	set := NewTreeSetOrdered("b", "a")
+	clone := set.Clone()
+	clone.Add("c")
+	assert.Equal(t, []string{"a", "b"}, set.ToArray())
+	assert.Equal(t, []string{"a", "b", "c"}, clone.ToArray())
+}
+
+func TestTreeSet_JSON(t *testing.T) {
+	set := NewTreeSetOrdered(3, 1)
+	data, err := json.Marshal(set)
+	assert.Nil(t, err)
+	assert.Equal(t, "[1,3]", string(data))
+	assert.Nil(t, json.Unmarshal([]byte("[5,4,5]"), set))
+	assert.Equal(t, []int{4, 5}, set.ToArray())
+	assert.Equal(t, "TreeSet[int](len=2){\n\t4,\n\t5,\n}", set.String())
+}
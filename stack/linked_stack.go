@@ -0,0 +1,142 @@
+package stack
+
+import (
+	"encoding/json"
+	"fmt"
+	"iter"
+	"log/slog"
+	"slices"
+
+	"github.com/gopi-frame/collection/internal/preview"
+)
+
+// NewLinkedStack new linked stack, the last value is the top element
+func NewLinkedStack[E any](values ...E) *LinkedStack[E] {
+	stack := new(LinkedStack[E])
+	stack.Push(values...)
+	return stack
+}
+
+// LinkedStack singly linked stack, Push and Pop take O(1) time without ever copying the elements,
+// as each element has its own node.
+// LinkedStack is not safe for concurrent use, see [NewSyncStack].
+type LinkedStack[E any] struct {
+	top   *stackNode[E]
+	size  int64
+	limit preview.Limit
+}
+
+type stackNode[E any] struct {
+	value E
+	next  *stackNode[E]
+}
+
+// Count returns the size of the stack
+func (s *LinkedStack[E]) Count() int64 {
+	return s.size
+}
+
+// IsEmpty returns whether the stack is empty
+func (s *LinkedStack[E]) IsEmpty() bool {
+	return s.size == 0
+}
+
+// IsNotEmpty returns whether the stack is not empty
+func (s *LinkedStack[E]) IsNotEmpty() bool {
+	return !s.IsEmpty()
+}
+
+// Clear clears the stack
+func (s *LinkedStack[E]) Clear() {
+	s.top = nil
+	s.size = 0
+}
+
+// Push pushes the elements onto the top of the stack in order, the last value is the top element
+func (s *LinkedStack[E]) Push(values ...E) {
+	for _, value := range values {
+		s.top = &stackNode[E]{value: value, next: s.top}
+		s.size++
+	}
+}
+
+// Pop removes the top element and returns it, it returns zero value and false when the stack is empty
+func (s *LinkedStack[E]) Pop() (E, bool) {
+	if s.top == nil {
+		return *new(E), false
+	}
+	node := s.top
+	s.top = node.next
+	s.size--
+	return node.value, true
+}
+
+// Peek returns the top element, it returns zero value and false when the stack is empty
+func (s *LinkedStack[E]) Peek() (E, bool) {
+	if s.top == nil {
+		return *new(E), false
+	}
+	return s.top.value, true
+}
+
+// All returns an iterator over the elements from the bottom to the top
+func (s *LinkedStack[E]) All() iter.Seq[E] {
+	return slices.Values(s.ToArray())
+}
+
+// ToArray converts to array from the bottom to the top, the returned slice is a copy, so modifying it does not affect the collection
+func (s *LinkedStack[E]) ToArray() []E {
+	values := make([]E, s.size)
+	index := len(values)
+	for node := s.top; node != nil; node = node.next {
+		index--
+		values[index] = node.value
+	}
+	return values
+}
+
+// ToJSON converts to json, an array of the elements from the bottom to the top
+func (s *LinkedStack[E]) ToJSON() ([]byte, error) {
+	return json.Marshal(s.ToArray())
+}
+
+// MarshalJSON implements [json.Marshaller]
+func (s *LinkedStack[E]) MarshalJSON() ([]byte, error) {
+	return s.ToJSON()
+}
+
+// UnmarshalJSON implements [json.Unmarshaller], the elements of the array replace the elements of the stack
+func (s *LinkedStack[E]) UnmarshalJSON(data []byte) error {
+	var items []E
+	if err := json.Unmarshal(data, &items); err != nil {
+		return err
+	}
+	s.Clear()
+	s.Push(items...)
+	return nil
+}
+
+// SetPreviewLimit sets the number of elements shown by String, %v and LogValue, a negative limit shows all elements
+func (s *LinkedStack[E]) SetPreviewLimit(limit int) {
+	s.limit.Set(limit)
+}
+
+// String converts to string, only the preview limit of elements are shown
+func (s *LinkedStack[E]) String() string {
+	return s.format(s.limit.Get(preview.DefaultLimit))
+}
+
+// Format implements [fmt.Formatter], %v prints the preview as String does, %+v and %#v print all elements
+func (s *LinkedStack[E]) Format(f fmt.State, verb rune) {
+	preview.Format(f, verb, s.format, s.limit.Get(preview.DefaultLimit))
+}
+
+func (s *LinkedStack[E]) format(limit int) string {
+	items := s.ToArray()
+	return preview.Elements(fmt.Sprintf("LinkedStack[%T](len=%d)", *new(E), len(items)), items, limit)
+}
+
+// LogValue implements [slog.LogValuer], only the preview limit of elements are logged
+func (s *LinkedStack[E]) LogValue() slog.Value {
+	return preview.LogElements(fmt.Sprintf("LinkedStack[%T]", *new(E)), s.ToArray(), s.limit.Get(preview.DefaultLimit))
+}
@@ -0,0 +1,78 @@
+package stack
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLinkedStack_Push(t *testing.T) {
+	stack := NewLinkedStack(1, 2)
+	stack.Push(3, 4)
+	assert.Equal(t, int64(4), stack.Count())
+	assert.Equal(t, []int{1, 2, 3, 4}, stack.ToArray())
+}
+
+func TestLinkedStack_Pop(t *testing.T) {
+	stack := NewLinkedStack(1, 2, 3)
+	for _, expected := range []int{3, 2, 1} {
+		v, ok := stack.Pop()
+		assert.True(t, ok)
+		assert.Equal(t, expected, v)
+	}
+	_, ok := stack.Pop()
+	assert.False(t, ok)
+	assert.True(t, stack.IsEmpty())
+}
+
+func TestLinkedStack_Peek(t *testing.T) {
+	stack := NewLinkedStack[int]()
+	_, ok := stack.Peek()
+	assert.False(t, ok)
+	stack.Push(1, 2)
+	v, ok := stack.Peek()
+	assert.True(t, ok)
+	assert.Equal(t, 2, v)
+	assert.Equal(t, int64(2), stack.Count())
+}
+
+func TestLinkedStack_Clear(t *testing.T) {
+	stack := NewLinkedStack(1, 2, 3)
+	assert.True(t, stack.IsNotEmpty())
+	stack.Clear()
+	assert.True(t, stack.IsEmpty())
+	stack.Push(4)
+	assert.Equal(t, []int{4}, stack.ToArray())
+}
+
+func TestLinkedStack_All(t *testing.T) {
+	stack := NewLinkedStack(1, 2, 3)
+	var values []int
+	for value := range stack.All() {
+		values = append(values, value)
+	}
+	assert.Equal(t, []int{1, 2, 3}, values)
+}
+
+func TestLinkedStack_JSON(t *testing.T) {
+	stack := NewLinkedStack(1, 2, 3)
+	data, err := json.Marshal(stack)
+	assert.Nil(t, err)
+	assert.Equal(t, "[1,2,3]", string(data))
+
+	restored := NewLinkedStack(9)
+	assert.Nil(t, json.Unmarshal(data, restored))
+	v, _ := restored.Pop()
+	assert.Equal(t, 3, v)
+	assert.Equal(t, []int{1, 2}, restored.ToArray())
+	assert.NotNil(t, json.Unmarshal([]byte(`{}`), restored))
+}
+
+func TestLinkedStack_String(t *testing.T) {
+	stack := NewLinkedStack(1, 2, 3, 4, 5, 6, 7)
+	pattern := regexp.MustCompile(fmt.Sprintf(`LinkedStack\[int\]\(len=%d\)\{\n(\t\d+,\n){5}\t(\.){3}\n\}`, stack.Count()))
+	assert.True(t, pattern.MatchString(stack.String()))
+}
@@ -0,0 +1,145 @@
+// Package stack implements last in first out collections.
+// The stacks are not synchronized, so they cost no more than a slice or a linked list,
+// wrap them with NewSyncStack to share them between goroutines.
+package stack
+
+import (
+	"encoding/json"
+	"fmt"
+	"iter"
+	"log/slog"
+	"slices"
+
+	"github.com/gopi-frame/collection/internal/preview"
+)
+
+// Interface stack, it is implemented by [Stack], [LinkedStack] and [SyncStack].
+// The elements are listed from the bottom to the top, so pushing them in order restores the stack.
+type Interface[E any] interface {
+	// Push pushes the elements onto the top of the stack in order
+	Push(values ...E)
+	// Pop removes the top element and returns it, it returns zero value and false when the stack is empty
+	Pop() (E, bool)
+	// Peek returns the top element, it returns zero value and false when the stack is empty
+	Peek() (E, bool)
+	// Count returns the size of the stack
+	Count() int64
+	// Clear clears the stack
+	Clear()
+	// ToArray returns the elements from the bottom to the top
+	ToArray() []E
+}
+
+// NewStack new stack, the last value is the top element
+func NewStack[E any](values ...E) *Stack[E] {
+	stack := new(Stack[E])
+	stack.Push(values...)
+	return stack
+}
+
+// Stack slice backed stack, Push and Pop take amortized O(1) time.
+// Stack is not safe for concurrent use, see [NewSyncStack].
+type Stack[E any] struct {
+	items []E
+	limit preview.Limit
+}
+
+// Count returns the size of the stack
+func (s *Stack[E]) Count() int64 {
+	return int64(len(s.items))
+}
+
+// IsEmpty returns whether the stack is empty
+func (s *Stack[E]) IsEmpty() bool {
+	return len(s.items) == 0
+}
+
+// IsNotEmpty returns whether the stack is not empty
+func (s *Stack[E]) IsNotEmpty() bool {
+	return !s.IsEmpty()
+}
+
+// Clear clears the stack
+func (s *Stack[E]) Clear() {
+	s.items = nil
+}
+
+// Push pushes the elements onto the top of the stack in order, the last value is the top element
+func (s *Stack[E]) Push(values ...E) {
+	s.items = append(s.items, values...)
+}
+
+// Pop removes the top element and returns it, it returns zero value and false when the stack is empty
+func (s *Stack[E]) Pop() (E, bool) {
+	if len(s.items) == 0 {
+		return *new(E), false
+	}
+	last := len(s.items) - 1
+	value := s.items[last]
+	// the slot is cleared so the slice does not keep the popped element alive
+	s.items[last] = *new(E)
+	s.items = s.items[:last]
+	return value, true
+}
+
+// Peek returns the top element, it returns zero value and false when the stack is empty
+func (s *Stack[E]) Peek() (E, bool) {
+	if len(s.items) == 0 {
+		return *new(E), false
+	}
+	return s.items[len(s.items)-1], true
+}
+
+// All returns an iterator over the elements from the bottom to the top
+func (s *Stack[E]) All() iter.Seq[E] {
+	return slices.Values(s.ToArray())
+}
+
+// ToArray converts to array from the bottom to the top, the returned slice is a copy, so modifying it does not affect the collection
+func (s *Stack[E]) ToArray() []E {
+	return slices.Clone(s.items)
+}
+
+// ToJSON converts to json, an array of the elements from the bottom to the top
+func (s *Stack[E]) ToJSON() ([]byte, error) {
+	return json.Marshal(s.items)
+}
+
+// MarshalJSON implements [json.Marshaller]
+func (s *Stack[E]) MarshalJSON() ([]byte, error) {
+	return s.ToJSON()
+}
+
+// UnmarshalJSON implements [json.Unmarshaller], the elements of the array replace the elements of the stack
+func (s *Stack[E]) UnmarshalJSON(data []byte) error {
+	var items []E
+	if err := json.Unmarshal(data, &items); err != nil {
+		return err
+	}
+	s.items = items
+	return nil
+}
+
+// SetPreviewLimit sets the number of elements shown by String, %v and LogValue, a negative limit shows all elements
+func (s *Stack[E]) SetPreviewLimit(limit int) {
+	s.limit.Set(limit)
+}
+
+// String converts to string, only the preview limit of elements are shown
+func (s *Stack[E]) String() string {
+	return s.format(s.limit.Get(preview.DefaultLimit))
+}
+
+// Format implements [fmt.Formatter], %v prints the preview as String does, %+v and %#v print all elements
+func (s *Stack[E]) Format(f fmt.State, verb rune) {
+	preview.Format(f, verb, s.format, s.limit.Get(preview.DefaultLimit))
+}
+
+func (s *Stack[E]) format(limit int) string {
+	return preview.Elements(fmt.Sprintf("Stack[%T](len=%d)", *new(E), len(s.items)), s.items, limit)
+}
+
+// LogValue implements [slog.LogValuer], only the preview limit of elements are logged
+func (s *Stack[E]) LogValue() slog.Value {
+	return preview.LogElements(fmt.Sprintf("Stack[%T]", *new(E)), s.ToArray(), s.limit.Get(preview.DefaultLimit))
+}
@@ -0,0 +1,129 @@
+package stack
+
+import (
+	"encoding/json"
+	"fmt"
+	"iter"
+	"log/slog"
+	"slices"
+	"sync"
+
+	"github.com/gopi-frame/collection/internal/preview"
+)
+
+// NewSyncStack wraps the stack so it is safe for concurrent use, the stack must not be used directly afterwards
+func NewSyncStack[E any](stack Interface[E]) *SyncStack[E] {
+	return &SyncStack[E]{stack: stack}
+}
+
+// SyncStack stack guarded by a lock, all methods are safe for concurrent use
+type SyncStack[E any] struct {
+	lock  sync.RWMutex
+	stack Interface[E]
+	limit preview.Limit
+}
+
+// Count returns the size of the stack
+func (s *SyncStack[E]) Count() int64 {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.stack.Count()
+}
+
+// IsEmpty returns whether the stack is empty
+func (s *SyncStack[E]) IsEmpty() bool {
+	return s.Count() == 0
+}
+
+// IsNotEmpty returns whether the stack is not empty
+func (s *SyncStack[E]) IsNotEmpty() bool {
+	return !s.IsEmpty()
+}
+
+// Clear clears the stack
+func (s *SyncStack[E]) Clear() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.stack.Clear()
+}
+
+// Push pushes the elements onto the top of the stack in order, no other element comes between them
+func (s *SyncStack[E]) Push(values ...E) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.stack.Push(values...)
+}
+
+// Pop removes the top element and returns it, it returns zero value and false when the stack is empty
+func (s *SyncStack[E]) Pop() (E, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.stack.Pop()
+}
+
+// Peek returns the top element, it returns zero value and false when the stack is empty
+func (s *SyncStack[E]) Peek() (E, bool) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.stack.Peek()
+}
+
+// All returns an iterator over the elements from the bottom to the top
+func (s *SyncStack[E]) All() iter.Seq[E] {
+	return slices.Values(s.ToArray())
+}
+
+// ToArray converts to array from the bottom to the top, the returned slice is a copy, so modifying it does not affect the collection
+func (s *SyncStack[E]) ToArray() []E {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.stack.ToArray()
+}
+
+// ToJSON converts to json, an array of the elements from the bottom to the top
+func (s *SyncStack[E]) ToJSON() ([]byte, error) {
+	return json.Marshal(s.ToArray())
+}
+
+// MarshalJSON implements [json.Marshaller]
+func (s *SyncStack[E]) MarshalJSON() ([]byte, error) {
+	return s.ToJSON()
+}
+
+// UnmarshalJSON implements [json.Unmarshaller], the elements of the array replace the elements of the stack
+func (s *SyncStack[E]) UnmarshalJSON(data []byte) error {
+	var items []E
+	if err := json.Unmarshal(data, &items); err != nil {
+		return err
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.stack.Clear()
+	s.stack.Push(items...)
+	return nil
+}
+
+// SetPreviewLimit sets the number of elements shown by String, %v and LogValue, a negative limit shows all elements
+func (s *SyncStack[E]) SetPreviewLimit(limit int) {
+	s.limit.Set(limit)
+}
+
+// String converts to string, only the preview limit of elements are shown
+func (s *SyncStack[E]) String() string {
+	return s.format(s.limit.Get(preview.DefaultLimit))
+}
+
+// Format implements [fmt.Formatter], %v prints the preview as String does, %+v and %#v print all elements
+func (s *SyncStack[E]) Format(f fmt.State, verb rune) {
+	preview.Format(f, verb, s.format, s.limit.Get(preview.DefaultLimit))
+}
+
+func (s *SyncStack[E]) format(limit int) string {
+	items := s.ToArray()
+	return preview.Elements(fmt.Sprintf("SyncStack[%T](len=%d)", *new(E), len(items)), items, limit)
+}
+
+// LogValue implements [slog.LogValuer], only the preview limit of elements are logged
+func (s *SyncStack[E]) LogValue() slog.Value {
+	return preview.LogElements(fmt.Sprintf("SyncStack[%T]", *new(E)), s.ToArray(), s.limit.Get(preview.DefaultLimit))
+}
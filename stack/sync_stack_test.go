@@ -0,0 +1,70 @@
+package stack
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSyncStack_Push(t *testing.T) {
+	for name, stack := range map[string]*SyncStack[int]{
+		"stack":        NewSyncStack[int](NewStack[int]()),
+		"linked stack": NewSyncStack[int](NewLinkedStack[int]()),
+	} {
+		t.Run(name, func(t *testing.T) {
+			wg := new(sync.WaitGroup)
+			for i := 0; i < 100; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					stack.Push(i, i)
+				}(i)
+			}
+			wg.Wait()
+			assert.Equal(t, int64(200), stack.Count())
+			values := stack.ToArray()
+			for i := 0; i < len(values); i += 2 {
+				assert.Equal(t, values[i], values[i+1])
+			}
+		})
+	}
+}
+
+func TestSyncStack_Pop(t *testing.T) {
+	stack := NewSyncStack[int](NewStack(1, 2, 3))
+	wg := new(sync.WaitGroup)
+	var lock sync.Mutex
+	var popped []int
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v, ok := stack.Pop(); ok {
+				lock.Lock()
+				popped = append(popped, v)
+				lock.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	assert.ElementsMatch(t, []int{1, 2, 3}, popped)
+	assert.True(t, stack.IsEmpty())
+	_, ok := stack.Peek()
+	assert.False(t, ok)
+}
+
+func TestSyncStack_JSON(t *testing.T) {
+	stack := NewSyncStack[int](NewLinkedStack(1, 2, 3))
+	data, err := stack.ToJSON()
+	assert.Nil(t, err)
+	assert.Equal(t, "[1,2,3]", string(data))
+	restored := NewSyncStack[int](NewStack[int]())
+	assert.Nil(t, json.Unmarshal(data, restored))
+	v, ok := restored.Peek()
+	assert.True(t, ok)
+	assert.Equal(t, 3, v)
+	restored.Clear()
+	assert.Equal(t, "SyncStack[int](len=0){\n}", restored.String())
+}
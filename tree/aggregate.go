@@ -0,0 +1,50 @@
+package tree
+
+import "math"
+
+// Aggregate aggregation hook of a tree, the tree keeps the aggregate of the measures of the elements in each subtree
+// and updates it as the tree is rebalanced, so AggregateBetween folds any range in O(log n) time.
+// The elements are folded in order, so Combine needs to be associative but not commutative.
+type Aggregate[E any] struct {
+	// Measure returns the measure of the element, such as a field of the payload
+	Measure func(value E) float64
+	// Combine combines two aggregates, such as the sum, the min or the max of them
+	Combine func(a, b float64) float64
+	// Identity is the aggregate of no elements, Combine(Identity, a) and Combine(a, Identity) must be a
+	Identity float64
+}
+
+// SumAggregate returns the aggregate of the sum of the measures
+func SumAggregate[E any](measure func(value E) float64) *Aggregate[E] {
+	return &Aggregate[E]{
+		Measure: measure,
+		Combine: func(a, b float64) float64 {
+			return a + b
+		},
+	}
+}
+
+// MinAggregate returns the aggregate of the min of the measures, it is +Inf for no elements
+func MinAggregate[E any](measure func(value E) float64) *Aggregate[E] {
+	return &Aggregate[E]{Measure: measure, Combine: math.Min, Identity: math.Inf(1)}
+}
+
+// MaxAggregate returns the aggregate of the max of the measures, it is -Inf for no elements
+func MaxAggregate[E any](measure func(value E) float64) *Aggregate[E] {
+	return &Aggregate[E]{Measure: measure, Combine: math.Max, Identity: math.Inf(-1)}
+}
+
+// node returns the aggregate of a node, the left aggregate is followed by the count of equal values and the right aggregate.
+// The value is combined with itself by doubling, so a node with many duplicates takes O(log count) time.
+func (a *Aggregate[E]) node(left float64, value E, count int, right float64) float64 {
+	result := left
+	for measure := a.Measure(value); count > 0; count >>= 1 {
+		if count&1 == 1 {
+			result = a.Combine(result, measure)
+		}
+		if count > 1 {
+			measure = a.Combine(measure, measure)
+		}
+	}
+	return a.Combine(result, right)
+}
@@ -0,0 +1,22 @@
+package tree
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAggregate_node(t *testing.T) {
+	calls := 0
+	sum := SumAggregate(func(value int) float64 {
+		return float64(value)
+	})
+	combine := sum.Combine
+	sum.Combine = func(a, b float64) float64 {
+		calls++
+		return combine(a, b)
+	}
+	assert.Equal(t, 1+3*1000+2.0, sum.node(1, 3, 1000, 2))
+	assert.Less(t, calls, 25)
+	assert.Equal(t, 3.0, sum.node(1, 3, 0, 2))
+}
@@ -1,6 +1,9 @@
 package tree
 
 import (
+	"fmt"
+	"math"
+
 	"github.com/gopi-frame/contract"
 )
 
@@ -10,80 +13,117 @@ type avlNode[E any] struct {
 	right  *avlNode[E]
 	height int
 	count  int
+	size   int
+	weight float64
+	total  float64
+	// aggregator is the aggregation hook of the tree, the aggregate is kept only when it is set
+	aggregator *Aggregate[E]
+	aggregate  float64
 }
 
-func (node *avlNode[E]) updateHeight() {
-	var leftHeight, rightHeight = 0, 0
-	if node.left != nil {
-		leftHeight = node.left.height
+func (node *avlNode[E]) getHeight() int {
+	if node == nil {
+		return 0
 	}
-	if node.right != nil {
-		rightHeight = node.right.height
+	return node.height
+}
+
+func (node *avlNode[E]) getSize() int {
+	if node == nil {
+		return 0
 	}
-	m := leftHeight
-	if rightHeight > leftHeight {
-		m = rightHeight
+	return node.size
+}
+
+func (node *avlNode[E]) getTotal() float64 {
+	if node == nil {
+		return 0
 	}
-	node.height = m + 1
+	return node.total
 }
 
-func (node *avlNode[E]) drop() int {
-	var leftHeight, rightHeight = 0, 0
-	if node.left != nil {
-		leftHeight = node.left.height
+func (node *avlNode[E]) getAggregate(aggregator *Aggregate[E]) float64 {
+	if node == nil {
+		return aggregator.Identity
 	}
-	if node.right != nil {
-		rightHeight = node.right.height
+	return node.aggregate
+}
+
+// update updates the height, the size, the total weight and the aggregate of the node
+func (node *avlNode[E]) update() {
+	node.height = max(node.left.getHeight(), node.right.getHeight()) + 1
+	node.size = node.left.getSize() + node.right.getSize() + node.count
+	node.total = node.left.getTotal() + node.right.getTotal() + node.weight
+	if a := node.aggregator; a != nil {
+		node.aggregate = a.node(node.left.getAggregate(a), node.value, node.count, node.right.getAggregate(a))
 	}
-	return leftHeight - rightHeight
 }
 
-func (node *avlNode[E]) insert(value E, comparator contract.Comparator[E]) *avlNode[E] {
-	if node == nil {
-		return &avlNode[E]{
-			value:  value,
-			height: 1,
-			count:  1,
+func (node *avlNode[E]) drop() int {
+	return node.left.getHeight() - node.right.getHeight()
+}
+
+// balance updates the node and rotates it when the heights of its subtrees differ by more than one
+func (node *avlNode[E]) balance() *avlNode[E] {
+	node.update()
+	drop := node.drop()
+	if drop > 1 {
+		if node.left.drop() < 0 {
+			return node.leftRightRotate()
+		}
+		return node.rightRotate()
+	} else if drop < -1 {
+		if node.right.drop() > 0 {
+			return node.rightLeftRotate()
 		}
+		return node.leftRotate()
 	}
-	if comparator.Compare(value, node.value) == 0 {
-		node.count++
-		return node
+	return node
+}
+
+// insert inserts the value with the weight into the subtree, it returns the new subtree and whether the value is inserted
+func (node *avlNode[E]) insert(value E, weight float64, comparator contract.Comparator[E], policy DuplicatePolicy, pool *nodePool[avlNode[E]], aggregator *Aggregate[E]) (*avlNode[E], bool) {
+	if node == nil {
+		node = pool.get()
+		*node = avlNode[E]{
+			value:      value,
+			count:      1,
+			weight:     weight,
+			aggregator: aggregator,
+		}
+		node.update()
+		return node, true
 	}
-	var newNode *avlNode[E]
-	if comparator.Compare(value, node.value) < 0 {
-		node.left = node.left.insert(value, comparator)
-		if node.drop() == 2 {
-			if comparator.Compare(value, node.left.value) < 0 {
-				newNode = node.rightRotate()
-			} else {
-				newNode = node.leftRightRotate()
-			}
+	var inserted bool
+	result := comparator.Compare(value, node.value)
+	if result == 0 {
+		switch policy {
+		case IgnoreDuplicates:
+			return node, false
+		case ReplaceDuplicates:
+			node.value = value
+			node.weight = weight
+			node.update()
+			return node, true
 		}
+		node.count++
+		node.weight += weight
+		node.update()
+		return node, true
+	} else if result < 0 {
+		node.left, inserted = node.left.insert(value, weight, comparator, policy, pool, aggregator)
 	} else {
-		node.right = node.right.insert(value, comparator)
-		if node.drop() == -2 {
-			if comparator.Compare(value, node.right.value) < 0 {
-				newNode = node.rightLeftRotate()
-			} else {
-				newNode = node.leftRotate()
-			}
-		}
+		node.right, inserted = node.right.insert(value, weight, comparator, policy, pool, aggregator)
 	}
-	if newNode == nil {
-		node.updateHeight()
-		return node
-	}
-	newNode.updateHeight()
-	return newNode
+	return node.balance(), inserted
 }
 
 func (node *avlNode[E]) leftRotate() *avlNode[E] {
 	pivot := node.right
 	node.right = pivot.left
 	pivot.left = node
-	node.updateHeight()
-	pivot.updateHeight()
+	node.update()
+	pivot.update()
 	return pivot
 }
 
@@ -91,8 +131,8 @@ func (node *avlNode[E]) rightRotate() *avlNode[E] {
 	pivot := node.left
 	node.left = pivot.right
 	pivot.right = node
-	node.updateHeight()
-	pivot.updateHeight()
+	node.update()
+	pivot.update()
 	return pivot
 }
 
@@ -144,55 +184,73 @@ func (node *avlNode[E]) remove(value E, comparator contract.Comparator[E]) *avlN
 	} else if result > 0 {
 		node.right = node.right.remove(value, comparator)
 	} else {
-		if node.left == nil && node.right == nil {
-			return nil
+		if node.left == nil {
+			return node.right
 		}
-		if node.left != nil && node.right != nil {
-			if node.left.height > node.right.height {
-				m := node.left.max()
-				node.value = m.value
-				node.count = m.count
-				node.left = node.left.remove(m.value, comparator)
-			} else {
-				m := node.right.min()
-				node.value = m.value
-				node.count = m.count
-				node.right = node.right.remove(m.value, comparator)
-			}
-		} else if node.left != nil {
-			node.value = node.left.value
-			node.count = node.left.count
-			node.height = 1
-			node.left = nil
-		} else {
-			node.value = node.right.value
-			node.count = node.right.count
-			node.height = 1
-			node.right = nil
+		if node.right == nil {
+			return node.left
 		}
-		return node
+		var m *avlNode[E]
+		node.right, m = node.right.removeMin()
+		m.left = node.left
+		m.right = node.right
+		return m.balance()
 	}
-	var newNode *avlNode[E]
-	drop := node.drop()
-	if drop == 2 {
-		if node.left.drop() == 1 {
-			newNode = node.rightRotate()
+	return node.balance()
+}
+
+// removeMin detaches the min node from the subtree, it returns the new subtree and the min node
+func (node *avlNode[E]) removeMin() (*avlNode[E], *avlNode[E]) {
+	if node.left == nil {
+		return node.right, node
+	}
+	var m *avlNode[E]
+	node.left, m = node.left.removeMin()
+	return node.balance(), m
+}
+
+// higher returns the node with the smallest value greater than the value
+func (node *avlNode[E]) higher(value E, comparator contract.Comparator[E]) *avlNode[E] {
+	var result *avlNode[E]
+	for current := node; current != nil; {
+		if comparator.Compare(value, current.value) < 0 {
+			result = current
+			current = current.left
 		} else {
-			newNode = node.leftRightRotate()
+			current = current.right
 		}
-	} else if drop == -2 {
-		if node.right.drop() == -1 {
-			newNode = node.leftRotate()
+	}
+	return result
+}
+
+// lower returns the node with the largest value less than the value
+func (node *avlNode[E]) lower(value E, comparator contract.Comparator[E]) *avlNode[E] {
+	var result *avlNode[E]
+	for current := node; current != nil; {
+		if comparator.Compare(value, current.value) > 0 {
+			result = current
+			current = current.right
 		} else {
-			newNode = node.rightLeftRotate()
+			current = current.left
 		}
 	}
-	if newNode == nil {
-		node.updateHeight()
-		return node
+	return result
+}
+
+// floor returns the node with the largest value less than or equal to the value
+func (node *avlNode[E]) floor(value E, comparator contract.Comparator[E]) *avlNode[E] {
+	if found := node.find(value, comparator); found != nil {
+		return found
 	}
-	newNode.updateHeight()
-	return newNode
+	return node.lower(value, comparator)
+}
+
+// ceiling returns the node with the smallest value greater than or equal to the value
+func (node *avlNode[E]) ceiling(value E, comparator contract.Comparator[E]) *avlNode[E] {
+	if found := node.find(value, comparator); found != nil {
+		return found
+	}
+	return node.higher(value, comparator)
 }
 
 func (node *avlNode[E]) inOrderRange() (nodes []*avlNode[E]) {
@@ -206,3 +264,255 @@ func (node *avlNode[E]) inOrderRange() (nodes []*avlNode[E]) {
 	nodes = append(nodes, node.right.inOrderRange()...)
 	return
 }
+
+// joinAVL joins the trees with the middle node, all elements of the left tree must be less than the middle node
+// and all elements of the right tree must be greater than the middle node
+func joinAVL[E any](left, mid, right *avlNode[E]) *avlNode[E] {
+	if left.getHeight() > right.getHeight()+1 {
+		left.right = joinAVL(left.right, mid, right)
+		return left.balance()
+	}
+	if right.getHeight() > left.getHeight()+1 {
+		right.left = joinAVL(left, mid, right.left)
+		return right.balance()
+	}
+	mid.left = left
+	mid.right = right
+	mid.update()
+	return mid
+}
+
+// mergeAVL merges the trees, all elements of the left tree must be less than the elements of the right tree
+func mergeAVL[E any](left, right *avlNode[E]) *avlNode[E] {
+	if left == nil {
+		return right
+	}
+	if right == nil {
+		return left
+	}
+	right, mid := right.removeMin()
+	return joinAVL(left, mid, right)
+}
+
+// splitAVL splits the tree into the elements less than the value and the elements greater than or equal to the value
+func splitAVL[E any](node *avlNode[E], value E, comparator contract.Comparator[E]) (*avlNode[E], *avlNode[E]) {
+	if node == nil {
+		return nil, nil
+	}
+	left, right := node.left, node.right
+	if comparator.Compare(value, node.value) <= 0 {
+		l, r := splitAVL(left, value, comparator)
+		return l, joinAVL(r, node, right)
+	}
+	l, r := splitAVL(right, value, comparator)
+	return joinAVL(left, node, l), r
+}
+
+func buildAVLNode[E any](runs []sortedRun[E], aggregator *Aggregate[E]) *avlNode[E] {
+	if len(runs) == 0 {
+		return nil
+	}
+	mid := len(runs) / 2
+	node := &avlNode[E]{
+		value:      runs[mid].value,
+		count:      runs[mid].count,
+		weight:     runs[mid].weight,
+		aggregator: aggregator,
+	}
+	node.left = buildAVLNode(runs[:mid], aggregator)
+	node.right = buildAVLNode(runs[mid+1:], aggregator)
+	node.update()
+	return node
+}
+
+func (node *avlNode[E]) render() *renderNode {
+	if node == nil {
+		return nil
+	}
+	return &renderNode{
+		label: renderLabel(node.value, node.count),
+		info:  fmt.Sprintf("bf=%d", node.drop()),
+		left:  node.left.render(),
+		right: node.right.render(),
+	}
+}
+
+func (node *avlNode[E]) children() (*avlNode[E], *avlNode[E]) {
+	return node.left, node.right
+}
+
+// values returns the distinct values of the subtree in order
+func (node *avlNode[E]) values(values []E) []E {
+	if node == nil {
+		return values
+	}
+	values = node.left.values(values)
+	values = append(values, node.value)
+	return node.right.values(values)
+}
+
+// validate checks the count, height, balance factor and size of each node in the subtree
+func (node *avlNode[E]) validate() error {
+	if node == nil {
+		return nil
+	}
+	if err := node.left.validate(); err != nil {
+		return err
+	}
+	if err := node.right.validate(); err != nil {
+		return err
+	}
+	if node.count < 1 {
+		return fmt.Errorf("%w: node %v has count %d", ErrInvalidTree, node.value, node.count)
+	}
+	if height := max(node.left.getHeight(), node.right.getHeight()) + 1; node.height != height {
+		return fmt.Errorf("%w: node %v has height %d, expected %d", ErrInvalidTree, node.value, node.height, height)
+	}
+	if drop := node.drop(); drop > 1 || drop < -1 {
+		return fmt.Errorf("%w: node %v has balance factor %d", ErrInvalidTree, node.value, drop)
+	}
+	if size := node.left.getSize() + node.right.getSize() + node.count; node.size != size {
+		return fmt.Errorf("%w: node %v has size %d, expected %d", ErrInvalidTree, node.value, node.size, size)
+	}
+	if total := node.left.getTotal() + node.right.getTotal() + node.weight; node.total != total {
+		return fmt.Errorf("%w: node %v has total weight %v, expected %v", ErrInvalidTree, node.value, node.total, total)
+	}
+	if a := node.aggregator; a != nil {
+		if aggregate := a.node(node.left.getAggregate(a), node.value, node.count, node.right.getAggregate(a)); node.aggregate != aggregate && !math.IsNaN(aggregate) {
+			return fmt.Errorf("%w: node %v has aggregate %v, expected %v", ErrInvalidTree, node.value, node.aggregate, aggregate)
+		}
+	}
+	return nil
+}
+
+// runs returns the sorted runs of the subtree
+func (node *avlNode[E]) runs(runs []sortedRun[E]) []sortedRun[E] {
+	if node == nil {
+		return runs
+	}
+	runs = node.left.runs(runs)
+	runs = append(runs, sortedRun[E]{value: node.value, count: node.count, weight: node.weight})
+	return node.right.runs(runs)
+}
+
+// rangeRuns appends the sorted runs of the values in [from, to) of the subtree to runs
+func (node *avlNode[E]) rangeRuns(from, to E, comparator contract.Comparator[E], runs []sortedRun[E]) []sortedRun[E] {
+	if node == nil {
+		return runs
+	}
+	afterFrom := comparator.Compare(node.value, from) >= 0
+	beforeTo := comparator.Compare(node.value, to) < 0
+	if afterFrom {
+		runs = node.left.rangeRuns(from, to, comparator, runs)
+	}
+	if afterFrom && beforeTo {
+		runs = append(runs, sortedRun[E]{value: node.value, count: node.count, weight: node.weight})
+	}
+	if beforeTo {
+		runs = node.right.rangeRuns(from, to, comparator, runs)
+	}
+	return runs
+}
+
+// walkIndex runs callback for each element of the subtree whose in-order position is in [from, to),
+// it returns false when callback returns false.
+func (node *avlNode[E]) walkIndex(from, to int, callback func(E) bool) bool {
+	if node == nil || from >= to {
+		return true
+	}
+	leftSize := node.left.getSize()
+	if from < leftSize && !node.left.walkIndex(from, min(to, leftSize), callback) {
+		return false
+	}
+	rightStart := leftSize + node.count
+	for i := max(from, leftSize); i < min(to, rightStart); i++ {
+		if !callback(node.value) {
+			return false
+		}
+	}
+	if to > rightStart {
+		return node.right.walkIndex(max(from-rightStart, 0), to-rightStart, callback)
+	}
+	return true
+}
+
+// countLess returns the number of elements of the subtree which are less than the value, counting duplicates,
+// it takes O(log n) time as the sizes of the skipped subtrees are added without visiting them.
+func (node *avlNode[E]) countLess(value E, comparator contract.Comparator[E]) int {
+	count := 0
+	for node != nil {
+		if comparator.Compare(value, node.value) <= 0 {
+			node = node.left
+		} else {
+			count += node.left.getSize() + node.count
+			node = node.right
+		}
+	}
+	return count
+}
+
+// weightLess returns the total weight of the elements of the subtree which are less than the value
+func (node *avlNode[E]) weightLess(value E, comparator contract.Comparator[E]) float64 {
+	var weight float64
+	for node != nil {
+		if comparator.Compare(value, node.value) <= 0 {
+			node = node.left
+		} else {
+			weight += node.left.getTotal() + node.weight
+			node = node.right
+		}
+	}
+	return weight
+}
+
+// selectWeight returns the node whose weight covers the position in the cumulative weights of the subtree,
+// it returns nil when the position is out of [0, total)
+func (node *avlNode[E]) selectWeight(position float64) *avlNode[E] {
+	for node != nil {
+		if left := node.left.getTotal(); position < left {
+			node = node.left
+		} else if position < left+node.weight {
+			return node
+		} else {
+			position -= left + node.weight
+			node = node.right
+		}
+	}
+	return nil
+}
+
+// aggregateBetween returns the aggregate of the elements of the subtree in [from, to)
+func (node *avlNode[E]) aggregateBetween(from, to E, comparator contract.Comparator[E], aggregator *Aggregate[E]) float64 {
+	for node != nil {
+		if comparator.Compare(node.value, from) < 0 {
+			node = node.right
+		} else if comparator.Compare(node.value, to) >= 0 {
+			node = node.left
+		} else {
+			return aggregator.node(node.left.aggregateFrom(from, comparator, aggregator), node.value, node.count, node.right.aggregateBefore(to, comparator, aggregator))
+		}
+	}
+	return aggregator.Identity
+}
+
+// aggregateFrom returns the aggregate of the elements of the subtree greater than or equal to the value
+func (node *avlNode[E]) aggregateFrom(value E, comparator contract.Comparator[E], aggregator *Aggregate[E]) float64 {
+	if node == nil {
+		return aggregator.Identity
+	}
+	if comparator.Compare(node.value, value) < 0 {
+		return node.right.aggregateFrom(value, comparator, aggregator)
+	}
+	return aggregator.node(node.left.aggregateFrom(value, comparator, aggregator), node.value, node.count, node.right.getAggregate(aggregator))
+}
+
+// aggregateBefore returns the aggregate of the elements of the subtree less than the value
+func (node *avlNode[E]) aggregateBefore(value E, comparator contract.Comparator[E], aggregator *Aggregate[E]) float64 {
+	if node == nil {
+		return aggregator.Identity
+	}
+	if comparator.Compare(node.value, value) >= 0 {
+		return node.left.aggregateBefore(value, comparator, aggregator)
+	}
+	return aggregator.node(node.left.getAggregate(aggregator), node.value, node.count, node.right.aggregateBefore(value, comparator, aggregator))
+}
@@ -0,0 +1,646 @@
+package tree
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"log/slog"
+	"math"
+	"slices"
+	"sync"
+
+	"github.com/gopi-frame/collection/comparator"
+	"github.com/gopi-frame/collection/internal/debug"
+	"github.com/gopi-frame/collection/internal/preview"
+	"github.com/gopi-frame/contract"
+)
+
+// defaultBTreeDegree is the degree of a b-tree created with degree < 2, a node holds up to 63 keys
+const defaultBTreeDegree = 32
+
+// Entry key value pair of a [BTree], it is also the json form of the entry
+type Entry[K, V any] struct {
+	Key   K `json:"key"`
+	Value V `json:"value"`
+}
+
+// NewBTree new b-tree ordered by the comparator of the keys, each node but the root holds from degree-1 to 2*degree-1 keys.
+// The default degree 32 is used when the degree is less than 2.
+func NewBTree[K, V any](comparator contract.Comparator[K], degree int) *BTree[K, V] {
+	if degree < 2 {
+		degree = defaultBTreeDegree
+	}
+	return &BTree[K, V]{comparator: comparator, degree: degree}
+}
+
+// NewBTreeOrdered new b-tree of ordered keys, the keys are compared with [cmp.Compare]
+func NewBTreeOrdered[K cmp.Ordered, V any](degree int) *BTree[K, V] {
+	return NewBTree[K, V](comparator.Ordered[K](), degree)
+}
+
+// NewBTreeFromSorted new b-tree from entries sorted by key, see BulkLoad
+func NewBTreeFromSorted[K, V any](comparator contract.Comparator[K], degree int, sorted []Entry[K, V]) *BTree[K, V] {
+	tree := NewBTree[K, V](comparator, degree)
+	tree.bulkLoad(sorted)
+	return tree
+}
+
+// BTree sorted map based on a b-tree, all methods are safe for concurrent use.
+// Each node holds many keys in a slice, so a lookup touches O(log n) nodes which are mostly read sequentially,
+// it is much more cache friendly than the pointer-heavy binary trees for large datasets.
+// The keys are unique, setting an existing key replaces its value.
+type BTree[K, V any] struct {
+	lock       sync.RWMutex
+	root       *bNode[K, V]
+	size       int64
+	degree     int
+	comparator contract.Comparator[K]
+	limit      preview.Limit
+}
+
+// bNode node of a b-tree, the children are nil for a leaf and one more than the keys otherwise
+type bNode[K, V any] struct {
+	keys     []K
+	values   []V
+	children []*bNode[K, V]
+}
+
+func (n *bNode[K, V]) leaf() bool {
+	return n.children == nil
+}
+
+// maxKeys returns the number of keys of a full node
+func (t *BTree[K, V]) maxKeys() int {
+	return 2*t.degree - 1
+}
+
+// search returns the index of the first key of the node not less than the key and whether it is the key
+func (t *BTree[K, V]) search(n *bNode[K, V], key K) (int, bool) {
+	return slices.BinarySearchFunc(n.keys, key, t.comparator.Compare)
+}
+
+// Count returns the size of tree
+func (t *BTree[K, V]) Count() int64 {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	return t.size
+}
+
+// IsEmpty returns whether the tree is empty
+func (t *BTree[K, V]) IsEmpty() bool {
+	return t.Count() == 0
+}
+
+// IsNotEmpty returns whether the tree is not empty
+func (t *BTree[K, V]) IsNotEmpty() bool {
+	return !t.IsEmpty()
+}
+
+// Degree returns the degree of the tree
+func (t *BTree[K, V]) Degree() int {
+	return t.degree
+}
+
+// Comparator returns the comparator of the keys
+func (t *BTree[K, V]) Comparator() contract.Comparator[K] {
+	return t.comparator
+}
+
+// Get returns the value of the specific key.
+// A zero value and false will be returned when the key is not exist.
+func (t *BTree[K, V]) Get(key K) (V, bool) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	for n := t.root; n != nil; {
+		index, found := t.search(n, key)
+		if found {
+			return n.values[index], true
+		}
+		if n.leaf() {
+			break
+		}
+		n = n.children[index]
+	}
+	return *new(V), false
+}
+
+// GetOr returns the value of the specific key, or the default value when the key is not exist
+func (t *BTree[K, V]) GetOr(key K, value V) V {
+	if v, ok := t.Get(key); ok {
+		return v
+	}
+	return value
+}
+
+// ContainsKey returns whether the tree contains the specific key
+func (t *BTree[K, V]) ContainsKey(key K) bool {
+	_, ok := t.Get(key)
+	return ok
+}
+
+// Set sets the value to the specific key, it returns true when the key is new
+func (t *BTree[K, V]) Set(key K, value V) bool {
+	t.lock.Lock()
+	defer t.unlock()
+	return t.set(key, value)
+}
+
+func (t *BTree[K, V]) set(key K, value V) bool {
+	if t.root == nil {
+		t.root = &bNode[K, V]{keys: []K{key}, values: []V{value}}
+		t.size = 1
+		return true
+	}
+	if len(t.root.keys) == t.maxKeys() {
+		t.root = &bNode[K, V]{children: []*bNode[K, V]{t.root}}
+		t.split(t.root, 0)
+	}
+	n := t.root
+	for {
+		index, found := t.search(n, key)
+		if found {
+			n.values[index] = value
+			return false
+		}
+		if n.leaf() {
+			n.keys = slices.Insert(n.keys, index, key)
+			n.values = slices.Insert(n.values, index, value)
+			t.size++
+			return true
+		}
+		if len(n.children[index].keys) == t.maxKeys() {
+			t.split(n, index)
+			switch c := t.comparator.Compare(key, n.keys[index]); {
+			case c == 0:
+				n.values[index] = value
+				return false
+			case c > 0:
+				index++
+			}
+		}
+		n = n.children[index]
+	}
+}
+
+// split splits the full child on the index of the node into two, its middle key moves up into the node
+func (t *BTree[K, V]) split(n *bNode[K, V], index int) {
+	child := n.children[index]
+	mid := t.degree - 1
+	right := &bNode[K, V]{
+		keys:   slices.Clone(child.keys[mid+1:]),
+		values: slices.Clone(child.values[mid+1:]),
+	}
+	if !child.leaf() {
+		right.children = slices.Clone(child.children[mid+1:])
+		clear(child.children[mid+1:])
+		child.children = child.children[:mid+1]
+	}
+	n.keys = slices.Insert(n.keys, index, child.keys[mid])
+	n.values = slices.Insert(n.values, index, child.values[mid])
+	n.children = slices.Insert(n.children, index+1, right)
+	clear(child.keys[mid:])
+	clear(child.values[mid:])
+	child.keys = child.keys[:mid]
+	child.values = child.values[:mid]
+}
+
+// Remove removes the specific key, it returns false when the tree does not contain it
+func (t *BTree[K, V]) Remove(key K) bool {
+	t.lock.Lock()
+	defer t.unlock()
+	if t.root == nil {
+		return false
+	}
+	removed := t.remove(t.root, key)
+	if len(t.root.keys) == 0 {
+		if t.root.leaf() {
+			t.root = nil
+		} else {
+			t.root = t.root.children[0]
+		}
+	}
+	if removed {
+		t.size--
+	}
+	return removed
+}
+
+// remove removes the key from the subtree of the node, each node it descends into has at least degree keys
+// so that a key can be taken from it without another pass
+func (t *BTree[K, V]) remove(n *bNode[K, V], key K) bool {
+	for {
+		index, found := t.search(n, key)
+		if n.leaf() {
+			if !found {
+				return false
+			}
+			n.keys = slices.Delete(n.keys, index, index+1)
+			n.values = slices.Delete(n.values, index, index+1)
+			return true
+		}
+		if found {
+			left, right := n.children[index], n.children[index+1]
+			switch {
+			case len(left.keys) >= t.degree:
+				last := t.last(left)
+				n.keys[index], n.values[index] = last.keys[len(last.keys)-1], last.values[len(last.values)-1]
+				key, n = n.keys[index], left
+			case len(right.keys) >= t.degree:
+				first := t.first(right)
+				n.keys[index], n.values[index] = first.keys[0], first.values[0]
+				key, n = n.keys[index], right
+			default:
+				t.merge(n, index)
+				n = left
+			}
+			continue
+		}
+		if len(n.children[index].keys) < t.degree {
+			index = t.fill(n, index)
+		}
+		n = n.children[index]
+	}
+}
+
+// fill gives the child on the index of the node at least degree keys, by borrowing a key from a sibling
+// or merging with one, and returns the index of the child after the change
+func (t *BTree[K, V]) fill(n *bNode[K, V], index int) int {
+	child := n.children[index]
+	switch {
+	case index > 0 && len(n.children[index-1].keys) >= t.degree:
+		left := n.children[index-1]
+		last := len(left.keys) - 1
+		child.keys = slices.Insert(child.keys, 0, n.keys[index-1])
+		child.values = slices.Insert(child.values, 0, n.values[index-1])
+		n.keys[index-1], n.values[index-1] = left.keys[last], left.values[last]
+		left.keys, left.values = left.keys[:last], left.values[:last]
+		if !left.leaf() {
+			child.children = slices.Insert(child.children, 0, left.children[last+1])
+			left.children = left.children[:last+1]
+		}
+	case index < len(n.keys) && len(n.children[index+1].keys) >= t.degree:
+		right := n.children[index+1]
+		child.keys = append(child.keys, n.keys[index])
+		child.values = append(child.values, n.values[index])
+		n.keys[index], n.values[index] = right.keys[0], right.values[0]
+		right.keys = slices.Delete(right.keys, 0, 1)
+		right.values = slices.Delete(right.values, 0, 1)
+		if !right.leaf() {
+			child.children = append(child.children, right.children[0])
+			right.children = slices.Delete(right.children, 0, 1)
+		}
+	case index < len(n.keys):
+		t.merge(n, index)
+	default:
+		t.merge(n, index-1)
+		index--
+	}
+	return index
+}
+
+// merge merges the child on the index+1 of the node and the key between them into the child on the index
+func (t *BTree[K, V]) merge(n *bNode[K, V], index int) {
+	left, right := n.children[index], n.children[index+1]
+	left.keys = append(append(left.keys, n.keys[index]), right.keys...)
+	left.values = append(append(left.values, n.values[index]), right.values...)
+	if !left.leaf() {
+		left.children = append(left.children, right.children...)
+	}
+	n.keys = slices.Delete(n.keys, index, index+1)
+	n.values = slices.Delete(n.values, index, index+1)
+	n.children = slices.Delete(n.children, index+1, index+2)
+}
+
+// first returns the leftmost leaf of the subtree
+func (t *BTree[K, V]) first(n *bNode[K, V]) *bNode[K, V] {
+	for !n.leaf() {
+		n = n.children[0]
+	}
+	return n
+}
+
+// last returns the rightmost leaf of the subtree
+func (t *BTree[K, V]) last(n *bNode[K, V]) *bNode[K, V] {
+	for !n.leaf() {
+		n = n.children[len(n.children)-1]
+	}
+	return n
+}
+
+// First returns the entry of the smallest key, it returns zero values and false when the tree is empty
+func (t *BTree[K, V]) First() (K, V, bool) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	if t.root == nil {
+		return *new(K), *new(V), false
+	}
+	n := t.first(t.root)
+	return n.keys[0], n.values[0], true
+}
+
+// Last returns the entry of the largest key, it returns zero values and false when the tree is empty
+func (t *BTree[K, V]) Last() (K, V, bool) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	if t.root == nil {
+		return *new(K), *new(V), false
+	}
+	n := t.last(t.root)
+	return n.keys[len(n.keys)-1], n.values[len(n.values)-1], true
+}
+
+// Clear clears the tree
+func (t *BTree[K, V]) Clear() {
+	t.lock.Lock()
+	defer t.unlock()
+	t.root = nil
+	t.size = 0
+}
+
+// BulkLoad replaces the entries of the tree with the entries sorted by key in O(n), the nodes are built bottom up
+// instead of splitting them as the keys are set one by one.
+// The entries are set one by one if they are not sorted in strictly increasing key order, the last value of a key wins.
+func (t *BTree[K, V]) BulkLoad(sorted []Entry[K, V]) {
+	t.lock.Lock()
+	defer t.unlock()
+	t.bulkLoad(sorted)
+}
+
+func (t *BTree[K, V]) bulkLoad(entries []Entry[K, V]) {
+	t.root, t.size = nil, 0
+	for index := 1; index < len(entries); index++ {
+		if t.comparator.Compare(entries[index-1].Key, entries[index].Key) >= 0 {
+			for _, entry := range entries {
+				t.set(entry.Key, entry.Value)
+			}
+			return
+		}
+	}
+	if len(entries) == 0 {
+		return
+	}
+	height := 0
+	for t.capacity(height) < len(entries) {
+		height++
+	}
+	t.root = t.build(entries, height, 2)
+	t.size = int64(len(entries))
+}
+
+// capacity returns the number of keys of a full subtree of the height, saturated at [math.MaxInt]
+func (t *BTree[K, V]) capacity(height int) int {
+	capacity := 1
+	for range height + 1 {
+		if capacity > math.MaxInt/(2*t.degree) {
+			return math.MaxInt
+		}
+		capacity *= 2 * t.degree
+	}
+	return capacity - 1
+}
+
+// build builds a subtree of the height from the entries, with at least minChildren children when it is not a leaf.
+// The children take as few as possible, so every child of a node gets enough entries to hold degree-1 keys per node.
+func (t *BTree[K, V]) build(entries []Entry[K, V], height, minChildren int) *bNode[K, V] {
+	n := &bNode[K, V]{}
+	if height == 0 {
+		n.keys = make([]K, len(entries))
+		n.values = make([]V, len(entries))
+		for index, entry := range entries {
+			n.keys[index], n.values[index] = entry.Key, entry.Value
+		}
+		return n
+	}
+	below := t.capacity(height - 1)
+	count := max((len(entries)+1+below)/(below+1), minChildren)
+	size, extra := (len(entries)-count+1)/count, (len(entries)-count+1)%count
+	n.keys = make([]K, 0, count-1)
+	n.values = make([]V, 0, count-1)
+	n.children = make([]*bNode[K, V], 0, count)
+	for index := range count {
+		end := size
+		if index < extra {
+			end++
+		}
+		n.children = append(n.children, t.build(entries[:end], height-1, t.degree))
+		if index < count-1 {
+			n.keys = append(n.keys, entries[end].Key)
+			n.values = append(n.values, entries[end].Value)
+			entries = entries[end+1:]
+		}
+	}
+	return n
+}
+
+// Height returns the number of levels of the tree, all leaves are on the last level
+func (t *BTree[K, V]) Height() int {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	height := 0
+	for n := t.root; n != nil; height++ {
+		if n.leaf() {
+			n = nil
+		} else {
+			n = n.children[0]
+		}
+	}
+	return height
+}
+
+// Validate checks the ordering of the keys and the invariants of the tree,
+// it returns an error wrapping [ErrInvalidTree] when the tree is broken
+func (t *BTree[K, V]) Validate() error {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	return t.validate()
+}
+
+func (t *BTree[K, V]) validate() error {
+	if t.root == nil {
+		if t.size != 0 {
+			return fmt.Errorf("%w: tree has size %d, expected 0", ErrInvalidTree, t.size)
+		}
+		return nil
+	}
+	leafDepth := -1
+	var size int64
+	var check func(n *bNode[K, V], depth int) error
+	check = func(n *bNode[K, V], depth int) error {
+		if len(n.keys) != len(n.values) {
+			return fmt.Errorf("%w: node has %d keys and %d values", ErrInvalidTree, len(n.keys), len(n.values))
+		}
+		if len(n.keys) > t.maxKeys() || (n != t.root && len(n.keys) < t.degree-1) || len(n.keys) == 0 {
+			return fmt.Errorf("%w: node has %d keys with degree %d", ErrInvalidTree, len(n.keys), t.degree)
+		}
+		size += int64(len(n.keys))
+		if n.leaf() {
+			if leafDepth == -1 {
+				leafDepth = depth
+			} else if leafDepth != depth {
+				return fmt.Errorf("%w: leaves at depth %d and %d", ErrInvalidTree, leafDepth, depth)
+			}
+			return nil
+		}
+		if len(n.children) != len(n.keys)+1 {
+			return fmt.Errorf("%w: node has %d keys and %d children", ErrInvalidTree, len(n.keys), len(n.children))
+		}
+		for _, child := range n.children {
+			if err := check(child, depth+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := check(t.root, 0); err != nil {
+		return err
+	}
+	if t.size != size {
+		return fmt.Errorf("%w: tree has size %d, expected %d", ErrInvalidTree, t.size, size)
+	}
+	keys, _ := t.entries(nil, nil)
+	for index := 1; index < len(keys); index++ {
+		if t.comparator.Compare(keys[index-1], keys[index]) >= 0 {
+			return fmt.Errorf("%w: key %v is not less than %v", ErrInvalidTree, keys[index-1], keys[index])
+		}
+	}
+	return nil
+}
+
+// unlock unlocks the tree after a mutation, the invariants are checked first in debug builds
+func (t *BTree[K, V]) unlock() {
+	if debug.Enabled {
+		debug.Check(t, t.validate())
+	}
+	t.lock.Unlock()
+}
+
+// entries returns the keys and the values in the range [from, to) in order, a nil bound is unbounded
+func (t *BTree[K, V]) entries(from, to *K) ([]K, []V) {
+	var keys []K
+	var values []V
+	var walk func(n *bNode[K, V]) bool
+	walk = func(n *bNode[K, V]) bool {
+		start := 0
+		if from != nil {
+			start, _ = t.search(n, *from)
+		}
+		for index := start; index <= len(n.keys); index++ {
+			if !n.leaf() && !walk(n.children[index]) {
+				return false
+			}
+			if index == len(n.keys) {
+				break
+			}
+			if to != nil && t.comparator.Compare(n.keys[index], *to) >= 0 {
+				return false
+			}
+			keys = append(keys, n.keys[index])
+			values = append(values, n.values[index])
+		}
+		return true
+	}
+	if t.root != nil {
+		walk(t.root)
+	}
+	return keys, values
+}
+
+// snapshot returns the keys and the values in the range [from, to) in order while the tree is read locked
+func (t *BTree[K, V]) snapshot(from, to *K) ([]K, []V) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	return t.entries(from, to)
+}
+
+// Range returns an iterator over the entries whose keys are in the range [from, to) in order.
+// It iterates over a snapshot of the range, which is collected in O(log n + k) time for k entries,
+// so the loop body is allowed to modify the tree.
+func (t *BTree[K, V]) Range(from, to K) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		if t.comparator.Compare(from, to) >= 0 {
+			return
+		}
+		keys, values := t.snapshot(&from, &to)
+		for index, key := range keys {
+			if !yield(key, values[index]) {
+				return
+			}
+		}
+	}
+}
+
+// All returns an iterator over a snapshot of the entries in order, so the loop body is allowed to modify the tree
+func (t *BTree[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		keys, values := t.snapshot(nil, nil)
+		for index, key := range keys {
+			if !yield(key, values[index]) {
+				return
+			}
+		}
+	}
+}
+
+// Keys returns the keys in order
+func (t *BTree[K, V]) Keys() []K {
+	keys, _ := t.snapshot(nil, nil)
+	return keys
+}
+
+// Values returns the values in the order of the keys
+func (t *BTree[K, V]) Values() []V {
+	_, values := t.snapshot(nil, nil)
+	return values
+}
+
+// ToJSON converts to json, the entries are encoded as an array ordered by key
+func (t *BTree[K, V]) ToJSON() ([]byte, error) {
+	keys, values := t.snapshot(nil, nil)
+	entries := make([]Entry[K, V], len(keys))
+	for index, key := range keys {
+		entries[index] = Entry[K, V]{Key: key, Value: values[index]}
+	}
+	return json.Marshal(entries)
+}
+
+// MarshalJSON implements [json.Marshaller]
+func (t *BTree[K, V]) MarshalJSON() ([]byte, error) {
+	return t.ToJSON()
+}
+
+// UnmarshalJSON implements [json.Unmarshaller], the entries replace the entries of the tree, see BulkLoad
+func (t *BTree[K, V]) UnmarshalJSON(data []byte) error {
+	entries := make([]Entry[K, V], 0)
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return err
+	}
+	t.BulkLoad(entries)
+	return nil
+}
+
+// SetPreviewLimit sets the number of entries shown by String, %v and LogValue, a negative limit shows all entries
+func (t *BTree[K, V]) SetPreviewLimit(limit int) {
+	t.limit.Set(limit)
+}
+
+// String converts to string, only the preview limit of entries are shown
+func (t *BTree[K, V]) String() string {
+	return t.format(t.limit.Get(-1))
+}
+
+// Format implements [fmt.Formatter], %v prints the preview as String does, %+v and %#v print all entries
+func (t *BTree[K, V]) Format(f fmt.State, verb rune) {
+	preview.Format(f, verb, t.format, t.limit.Get(-1))
+}
+
+func (t *BTree[K, V]) format(limit int) string {
+	keys, values := t.snapshot(nil, nil)
+	return preview.Entries(fmt.Sprintf("BTree[%T, %T](len=%d)", *new(K), *new(V), len(keys)), keys, values, limit)
+}
+
+// LogValue implements [slog.LogValuer], only the preview limit of entries are logged
+func (t *BTree[K, V]) LogValue() slog.Value {
+	keys, values := t.snapshot(nil, nil)
+	return preview.LogEntries(fmt.Sprintf("BTree[%T, %T]", *new(K), *new(V)), len(keys), keys, values, t.limit.Get(preview.DefaultLimit))
+}
@@ -0,0 +1,292 @@
+package tree
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func sortedEntries(n int) []Entry[int, string] {
+	entries := make([]Entry[int, string], n)
+	for index := range entries {
+		entries[index] = Entry[int, string]{Key: index * 2, Value: fmt.Sprint(index * 2)}
+	}
+	return entries
+}
+
+func TestNewBTree(t *testing.T) {
+	assert.Equal(t, defaultBTreeDegree, NewBTree[int, string](_cmp{}, 0).Degree())
+	assert.Equal(t, defaultBTreeDegree, NewBTree[int, string](_cmp{}, 1).Degree())
+	assert.Equal(t, 2, NewBTree[int, string](_cmp{}, 2).Degree())
+}
+
+func TestBTree_Set(t *testing.T) {
+	tree := NewBTreeOrdered[int, string](2)
+	assert.True(t, tree.Set(1, "a"))
+	assert.False(t, tree.Set(1, "b"))
+	assert.Equal(t, int64(1), tree.Count())
+	assert.Equal(t, "b", tree.GetOr(1, ""))
+
+	t.Run("random keys", func(t *testing.T) {
+		for _, degree := range []int{2, 3, 8} {
+			tree := NewBTreeOrdered[int, int](degree)
+			expected := map[int]int{}
+			for range 2000 {
+				key := rand.IntN(500)
+				_, exists := expected[key]
+				assert.Equal(t, !exists, tree.Set(key, key*10))
+				expected[key] = key * 10
+			}
+			assert.NoError(t, tree.Validate())
+			assert.Equal(t, int64(len(expected)), tree.Count())
+			for key, value := range expected {
+				assert.Equal(t, value, tree.GetOr(key, -1))
+			}
+			keys := tree.Keys()
+			assert.True(t, slices.IsSorted(keys))
+			assert.Len(t, keys, len(expected))
+		}
+	})
+}
+
+func TestBTree_Get(t *testing.T) {
+	tree := NewBTreeFromSorted(_cmp{}, 2, sortedEntries(100))
+	value, ok := tree.Get(42)
+	assert.True(t, ok)
+	assert.Equal(t, "42", value)
+	_, ok = tree.Get(43)
+	assert.False(t, ok)
+	assert.True(t, tree.ContainsKey(0))
+	assert.False(t, tree.ContainsKey(200))
+	assert.Equal(t, "none", tree.GetOr(-1, "none"))
+}
+
+func TestBTree_Remove(t *testing.T) {
+	tree := NewBTreeFromSorted(_cmp{}, 2, sortedEntries(10))
+	assert.True(t, tree.Remove(4))
+	assert.False(t, tree.Remove(4))
+	assert.False(t, tree.Remove(5))
+	assert.Equal(t, int64(9), tree.Count())
+	assert.False(t, tree.ContainsKey(4))
+
+	t.Run("random keys", func(t *testing.T) {
+		for _, degree := range []int{2, 3, 8} {
+			tree := NewBTreeOrdered[int, int](degree)
+			expected := map[int]bool{}
+			for key := range 1000 {
+				tree.Set(key, key)
+				expected[key] = true
+			}
+			for range 3000 {
+				key := rand.IntN(1200)
+				assert.Equal(t, expected[key], tree.Remove(key))
+				delete(expected, key)
+				if rand.IntN(3) == 0 {
+					key := rand.IntN(1200)
+					tree.Set(key, key)
+					expected[key] = true
+				}
+			}
+			assert.NoError(t, tree.Validate())
+			assert.Equal(t, int64(len(expected)), tree.Count())
+			for key := range 1200 {
+				assert.Equal(t, expected[key], tree.ContainsKey(key))
+			}
+		}
+	})
+
+	t.Run("remove all", func(t *testing.T) {
+		tree := NewBTreeFromSorted(_cmp{}, 2, sortedEntries(100))
+		for _, entry := range sortedEntries(100) {
+			assert.True(t, tree.Remove(entry.Key))
+		}
+		assert.True(t, tree.IsEmpty())
+		assert.Equal(t, 0, tree.Height())
+		assert.NoError(t, tree.Validate())
+		assert.False(t, tree.Remove(0))
+	})
+}
+
+func TestBTree_First(t *testing.T) {
+	tree := NewBTreeOrdered[int, string](2)
+	_, _, ok := tree.First()
+	assert.False(t, ok)
+	tree.BulkLoad(sortedEntries(50))
+	key, value, ok := tree.First()
+	assert.True(t, ok)
+	assert.Equal(t, 0, key)
+	assert.Equal(t, "0", value)
+}
+
+func TestBTree_Last(t *testing.T) {
+	tree := NewBTreeOrdered[int, string](2)
+	_, _, ok := tree.Last()
+	assert.False(t, ok)
+	tree.BulkLoad(sortedEntries(50))
+	key, value, ok := tree.Last()
+	assert.True(t, ok)
+	assert.Equal(t, 98, key)
+	assert.Equal(t, "98", value)
+}
+
+func TestBTree_Clear(t *testing.T) {
+	tree := NewBTreeFromSorted(_cmp{}, 2, sortedEntries(50))
+	tree.Clear()
+	assert.True(t, tree.IsEmpty())
+	assert.Empty(t, tree.Keys())
+}
+
+func TestBTree_BulkLoad(t *testing.T) {
+	for _, degree := range []int{2, 3, 4, 16} {
+		for _, n := range []int{0, 1, 2, 3, 4, 5, 7, 8, 15, 16, 17, 63, 64, 65, 100, 1000, 4097} {
+			tree := NewBTreeFromSorted(_cmp{}, degree, sortedEntries(n))
+			assert.NoError(t, tree.Validate(), "degree %d, %d entries", degree, n)
+			assert.Equal(t, int64(n), tree.Count())
+			keys := tree.Keys()
+			assert.Len(t, keys, n)
+			assert.True(t, slices.IsSorted(keys))
+		}
+	}
+
+	t.Run("height", func(t *testing.T) {
+		tree := NewBTreeFromSorted(_cmp{}, 2, sortedEntries(3))
+		assert.Equal(t, 1, tree.Height())
+		tree = NewBTreeFromSorted(_cmp{}, 2, sortedEntries(4))
+		assert.Equal(t, 2, tree.Height())
+	})
+
+	t.Run("unsorted", func(t *testing.T) {
+		tree := NewBTreeOrdered[int, string](2)
+		tree.BulkLoad([]Entry[int, string]{{3, "c"}, {1, "a"}, {2, "b"}, {1, "z"}})
+		assert.NoError(t, tree.Validate())
+		assert.Equal(t, []int{1, 2, 3}, tree.Keys())
+		assert.Equal(t, []string{"z", "b", "c"}, tree.Values())
+	})
+
+	t.Run("replaces entries", func(t *testing.T) {
+		tree := NewBTreeOrdered[int, string](2)
+		tree.Set(-1, "x")
+		tree.BulkLoad(sortedEntries(3))
+		assert.Equal(t, []int{0, 2, 4}, tree.Keys())
+	})
+
+	t.Run("mutate after load", func(t *testing.T) {
+		tree := NewBTreeFromSorted(_cmp{}, 3, sortedEntries(500))
+		for key := range 1000 {
+			if key%3 == 0 {
+				tree.Remove(key)
+			} else {
+				tree.Set(key, fmt.Sprint(key))
+			}
+		}
+		assert.NoError(t, tree.Validate())
+	})
+}
+
+func TestBTree_Range(t *testing.T) {
+	tree := NewBTreeFromSorted(_cmp{}, 2, sortedEntries(100))
+	var keys []int
+	var values []string
+	for key, value := range tree.Range(10, 21) {
+		keys = append(keys, key)
+		values = append(values, value)
+	}
+	assert.Equal(t, []int{10, 12, 14, 16, 18, 20}, keys)
+	assert.Equal(t, []string{"10", "12", "14", "16", "18", "20"}, values)
+
+	t.Run("bounds between keys", func(t *testing.T) {
+		var keys []int
+		for key := range tree.Range(-5, 5) {
+			keys = append(keys, key)
+		}
+		assert.Equal(t, []int{0, 2, 4}, keys)
+		keys = nil
+		for key := range tree.Range(195, 300) {
+			keys = append(keys, key)
+		}
+		assert.Equal(t, []int{196, 198}, keys)
+	})
+
+	t.Run("empty range", func(t *testing.T) {
+		for range tree.Range(10, 10) {
+			assert.Fail(t, "unexpected entry")
+		}
+		for range tree.Range(20, 10) {
+			assert.Fail(t, "unexpected entry")
+		}
+		for range tree.Range(11, 12) {
+			assert.Fail(t, "unexpected entry")
+		}
+	})
+
+	t.Run("break", func(t *testing.T) {
+		var keys []int
+		for key := range tree.Range(0, 100) {
+			keys = append(keys, key)
+			if len(keys) == 3 {
+				break
+			}
+		}
+		assert.Equal(t, []int{0, 2, 4}, keys)
+	})
+
+	t.Run("modify in loop", func(t *testing.T) {
+		tree := NewBTreeFromSorted(_cmp{}, 2, sortedEntries(20))
+		for key := range tree.Range(0, 20) {
+			tree.Remove(key)
+		}
+		assert.Equal(t, int64(10), tree.Count())
+		assert.NoError(t, tree.Validate())
+	})
+}
+
+func TestBTree_All(t *testing.T) {
+	tree := NewBTreeOrdered[int, string](2)
+	for _, key := range []int{5, 3, 9, 1} {
+		tree.Set(key, fmt.Sprint(key))
+	}
+	var keys []int
+	for key, value := range tree.All() {
+		keys = append(keys, key)
+		assert.Equal(t, fmt.Sprint(key), value)
+	}
+	assert.Equal(t, []int{1, 3, 5, 9}, keys)
+}
+
+func TestBTree_ToJSON(t *testing.T) {
+	tree := NewBTreeFromSorted(_cmp{}, 2, sortedEntries(3))
+	data, err := tree.ToJSON()
+	assert.NoError(t, err)
+	assert.JSONEq(t, `[{"key":0,"value":"0"},{"key":2,"value":"2"},{"key":4,"value":"4"}]`, string(data))
+
+	empty, err := NewBTreeOrdered[int, string](2).ToJSON()
+	assert.NoError(t, err)
+	assert.Equal(t, "[]", string(empty))
+}
+
+func TestBTree_UnmarshalJSON(t *testing.T) {
+	tree := NewBTreeFromSorted(_cmp{}, 2, sortedEntries(100))
+	data, err := json.Marshal(tree)
+	assert.NoError(t, err)
+
+	decoded := NewBTreeOrdered[int, string](2)
+	assert.NoError(t, json.Unmarshal(data, decoded))
+	assert.NoError(t, decoded.Validate())
+	assert.Equal(t, tree.Keys(), decoded.Keys())
+	assert.Equal(t, tree.Values(), decoded.Values())
+
+	t.Run("invalid json", func(t *testing.T) {
+		assert.Error(t, json.Unmarshal([]byte(`{"key":1}`), decoded))
+	})
+}
+
+func TestBTree_String(t *testing.T) {
+	tree := NewBTreeOrdered[string, int](2)
+	tree.Set("b", 2)
+	tree.Set("a", 1)
+	assert.Equal(t, "BTree[string, int](len=2){\n\ta: 1,\n\tb: 2,\n}", tree.String())
+}
@@ -0,0 +1,74 @@
+package tree
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/gopi-frame/collection/internal/codec"
+	"github.com/gopi-frame/contract"
+)
+
+// binaryVersion is the version of the binary format
+const binaryVersion = 2
+
+// encodeRuns encodes the sorted runs of a tree.
+// The version is followed by the sequence of the distinct values in order,
+// each value is followed by the number of equal elements of it.
+func encodeRuns[E any](runs []sortedRun[E]) ([]byte, error) {
+	e := new(codec.Encoder)
+	e.Uvarint(binaryVersion)
+	e.Count(len(runs))
+	for _, run := range runs {
+		if err := codec.Encode(e, run.value); err != nil {
+			return nil, err
+		}
+		e.Uvarint(uint64(run.count))
+	}
+	return e.Bytes(), nil
+}
+
+// decodeRuns decodes the sorted runs of a tree according to the duplicate policy,
+// and returns the runs with the number of elements in them.
+func decodeRuns[E any](data []byte, comparator contract.Comparator[E], policy DuplicatePolicy) ([]sortedRun[E], int, error) {
+	d := codec.NewDecoder(data)
+	version, err := d.Uvarint()
+	if err != nil {
+		return nil, 0, err
+	}
+	if version != binaryVersion {
+		return nil, 0, fmt.Errorf("%w: unsupported binary version %d", ErrInvalidTree, version)
+	}
+	n, err := d.Count()
+	if err != nil {
+		return nil, 0, err
+	}
+	values := make([]E, 0, n)
+	runs := make([]sortedRun[E], 0, n)
+	size := 0
+	for i := 0; i < n; i++ {
+		value, err := codec.Decode[E](d)
+		if err != nil {
+			return nil, 0, err
+		}
+		count, err := d.Uvarint()
+		if err != nil {
+			return nil, 0, err
+		}
+		if count < 1 || count > math.MaxInt32 {
+			return nil, 0, fmt.Errorf("%w: element %v has count %d", ErrInvalidTree, value, count)
+		}
+		if policy != AllowDuplicates {
+			count = 1
+		}
+		values = append(values, value)
+		runs = append(runs, sortedRun[E]{value: value, count: int(count), weight: float64(count)})
+		size += int(count)
+	}
+	if err := d.Finish(); err != nil {
+		return nil, 0, err
+	}
+	if err := validateOrder(values, comparator); err != nil {
+		return nil, 0, err
+	}
+	return runs, size, nil
+}
@@ -0,0 +1,25 @@
+//go:build collectiondebug
+
+package tree
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAVLTree_Debug(t *testing.T) {
+	tree := NewAVLTree(_cmp{}, 1, 2, 3)
+	tree.root.size = 10
+	assert.PanicsWithError(t, "*tree.AVLTree[int] is corrupted, it may be used without synchronization: invalid tree: node 2 has size 10, expected 3", func() {
+		tree.Remove(4)
+	})
+}
+
+func TestRBTree_Debug(t *testing.T) {
+	tree := NewRBTree(_cmp{}, 1, 2, 3)
+	tree.root.right.color = red
+	assert.PanicsWithError(t, "*tree.RBTree[int] is corrupted, it may be used without synchronization: invalid tree: node 2 has a red right child", func() {
+		tree.Remove(4)
+	})
+}
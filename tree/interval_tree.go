@@ -0,0 +1,284 @@
+package tree
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+	"log/slog"
+	"sync"
+
+	"github.com/gopi-frame/collection/internal/debug"
+	"github.com/gopi-frame/collection/internal/preview"
+)
+
+// ErrInvalidInterval is the panic of Insert when the low bound is greater than the high bound or either is NaN
+var ErrInvalidInterval = errors.New("interval must have bounds lo <= hi")
+
+// checkInterval panics with [ErrInvalidInterval] when the bounds are invalid
+func checkInterval(lo, hi float64) {
+	if !(lo <= hi) {
+		panic(fmt.Errorf("%w: [%v, %v)", ErrInvalidInterval, lo, hi))
+	}
+}
+
+// Interval half-open interval [Lo, Hi) with its value
+type Interval[E any] struct {
+	Lo    float64 `json:"lo"`
+	Hi    float64 `json:"hi"`
+	Value E       `json:"value"`
+}
+
+// String converts to string
+func (i Interval[E]) String() string {
+	return fmt.Sprintf("[%v, %v): %v", i.Lo, i.Hi, i.Value)
+}
+
+// intervalEntry element of the avl tree of an interval tree, the sequence tells equal intervals apart
+type intervalEntry[E any] struct {
+	Interval[E]
+	seq uint64
+}
+
+// intervalComparator orders the entries by the low bound, then by the high bound and the insertion order
+type intervalComparator[E any] struct{}
+
+func (intervalComparator[E]) Compare(a, b intervalEntry[E]) int {
+	if c := cmp.Compare(a.Lo, b.Lo); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(a.Hi, b.Hi); c != 0 {
+		return c
+	}
+	return cmp.Compare(a.seq, b.seq)
+}
+
+// intervalHi is the aggregate of the max high bound of each subtree, which prunes the subtrees ending before a query
+func intervalHi[E any]() *Aggregate[intervalEntry[E]] {
+	return MaxAggregate(func(entry intervalEntry[E]) float64 {
+		return entry.Hi
+	})
+}
+
+// NewIntervalTree new interval tree, the zero value is an empty tree ready to use as well
+func NewIntervalTree[E any]() *IntervalTree[E] {
+	return new(IntervalTree[E])
+}
+
+// IntervalTree tree of half-open intervals [lo, hi) with values, all methods are safe for concurrent use.
+// It is an avl tree ordered by the low bounds, whose nodes keep the max high bound of their subtrees
+// with the [Aggregate] hook of the avl tree, so Stab and Overlaps skip the subtrees which cannot match
+// and take O(log n + k) time for k matches. The bounds are float64, times are stored as their unix seconds
+// or milliseconds, and infinite bounds make open ended intervals.
+type IntervalTree[E any] struct {
+	lock      sync.RWMutex
+	root      *avlNode[intervalEntry[E]]
+	seq       uint64
+	aggregate *Aggregate[intervalEntry[E]]
+	limit     preview.Limit
+}
+
+// Count returns the number of intervals
+func (t *IntervalTree[E]) Count() int64 {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	return int64(t.root.getSize())
+}
+
+// IsEmpty returns whether the tree is empty
+func (t *IntervalTree[E]) IsEmpty() bool {
+	return t.Count() == 0
+}
+
+// IsNotEmpty returns whether the tree is not empty
+func (t *IntervalTree[E]) IsNotEmpty() bool {
+	return !t.IsEmpty()
+}
+
+// Insert inserts the interval [lo, hi) with the value, equal intervals are kept apart.
+// It panics with [ErrInvalidInterval] when lo is greater than hi or either is NaN.
+func (t *IntervalTree[E]) Insert(lo, hi float64, value E) {
+	checkInterval(lo, hi)
+	t.lock.Lock()
+	defer t.unlock()
+	t.insert(Interval[E]{Lo: lo, Hi: hi, Value: value})
+}
+
+func (t *IntervalTree[E]) insert(interval Interval[E]) {
+	if t.aggregate == nil {
+		t.aggregate = intervalHi[E]()
+	}
+	t.seq++
+	t.root, _ = t.root.insert(intervalEntry[E]{Interval: interval, seq: t.seq}, 1, intervalComparator[E]{}, AllowDuplicates, nil, t.aggregate)
+}
+
+// Remove removes the intervals [lo, hi) whose values match the callback and returns the number of removed intervals
+func (t *IntervalTree[E]) Remove(lo, hi float64, match func(value E) bool) int {
+	t.lock.Lock()
+	defer t.unlock()
+	from := intervalEntry[E]{Interval: Interval[E]{Lo: lo, Hi: hi}}
+	to := intervalEntry[E]{Interval: Interval[E]{Lo: lo, Hi: hi}, seq: t.seq + 1}
+	removed := 0
+	for _, run := range t.root.rangeRuns(from, to, intervalComparator[E]{}, nil) {
+		if match(run.value.Value) {
+			t.root = t.root.remove(run.value, intervalComparator[E]{})
+			removed++
+		}
+	}
+	return removed
+}
+
+// Stab returns the intervals containing the point ordered by their low bounds
+func (t *IntervalTree[E]) Stab(point float64) []Interval[E] {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	return stabIntervals(t.root, point, point, true, nil)
+}
+
+// Overlaps returns the intervals overlapping [lo, hi) ordered by their low bounds, such as the events conflicting with a meeting.
+// The intervals only touching the range, which end at lo or start at hi, do not overlap it.
+// It returns nothing when lo is not less than hi.
+func (t *IntervalTree[E]) Overlaps(lo, hi float64) []Interval[E] {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	if !(lo < hi) {
+		return nil
+	}
+	return stabIntervals(t.root, lo, hi, false, nil)
+}
+
+// stabIntervals appends the intervals of the subtree ending after lo and starting before hi, or at hi when closed,
+// the subtrees whose max high bound is not after lo are skipped, and so are the right subtrees starting after hi
+func stabIntervals[E any](node *avlNode[intervalEntry[E]], lo, hi float64, closed bool, intervals []Interval[E]) []Interval[E] {
+	if node == nil || node.aggregate <= lo {
+		return intervals
+	}
+	intervals = stabIntervals(node.left, lo, hi, closed, intervals)
+	if node.value.Lo > hi || (node.value.Lo == hi && !closed) {
+		return intervals
+	}
+	if node.value.Hi > lo {
+		intervals = append(intervals, node.value.Interval)
+	}
+	return stabIntervals(node.right, lo, hi, closed, intervals)
+}
+
+// Clear clears the tree
+func (t *IntervalTree[E]) Clear() {
+	t.lock.Lock()
+	defer t.unlock()
+	t.root = nil
+}
+
+// All returns an iterator over a snapshot of the intervals ordered by their low bounds,
+// so the loop body is allowed to modify the tree
+func (t *IntervalTree[E]) All() iter.Seq[Interval[E]] {
+	return func(yield func(Interval[E]) bool) {
+		for _, interval := range t.ToArray() {
+			if !yield(interval) {
+				return
+			}
+		}
+	}
+}
+
+// ToArray returns the intervals ordered by their low bounds
+func (t *IntervalTree[E]) ToArray() []Interval[E] {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	return t.toArray()
+}
+
+func (t *IntervalTree[E]) toArray() []Interval[E] {
+	intervals := make([]Interval[E], 0, t.root.getSize())
+	for _, entry := range t.root.values(nil) {
+		intervals = append(intervals, entry.Interval)
+	}
+	return intervals
+}
+
+// Height returns the number of nodes on the longest path from the root to a leaf
+func (t *IntervalTree[E]) Height() int {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	return t.root.getHeight()
+}
+
+// Validate checks the ordering of the intervals and the invariants of the tree,
+// it returns an error wrapping [ErrInvalidTree] when the tree is broken
+func (t *IntervalTree[E]) Validate() error {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	return t.validate()
+}
+
+func (t *IntervalTree[E]) validate() error {
+	if err := t.root.validate(); err != nil {
+		return err
+	}
+	return validateOrder(t.root.values(nil), intervalComparator[E]{})
+}
+
+// unlock unlocks the tree after a mutation, the invariants are checked first in debug builds
+func (t *IntervalTree[E]) unlock() {
+	if debug.Enabled {
+		debug.Check(t, t.validate())
+	}
+	t.lock.Unlock()
+}
+
+// ToJSON converts to json, the intervals are encoded as an array of objects ordered by their low bounds
+func (t *IntervalTree[E]) ToJSON() ([]byte, error) {
+	return json.Marshal(t.ToArray())
+}
+
+// MarshalJSON implements [json.Marshaller]
+func (t *IntervalTree[E]) MarshalJSON() ([]byte, error) {
+	return t.ToJSON()
+}
+
+// UnmarshalJSON implements [json.Unmarshaller], the intervals are inserted into the tree.
+// It returns an error wrapping [ErrInvalidInterval] when an interval is invalid.
+func (t *IntervalTree[E]) UnmarshalJSON(data []byte) error {
+	var intervals []Interval[E]
+	if err := json.Unmarshal(data, &intervals); err != nil {
+		return err
+	}
+	for _, interval := range intervals {
+		if !(interval.Lo <= interval.Hi) {
+			return fmt.Errorf("%w: [%v, %v)", ErrInvalidInterval, interval.Lo, interval.Hi)
+		}
+	}
+	t.lock.Lock()
+	defer t.unlock()
+	for _, interval := range intervals {
+		t.insert(interval)
+	}
+	return nil
+}
+
+// SetPreviewLimit sets the number of intervals shown by String, %v and LogValue, a negative limit shows all intervals
+func (t *IntervalTree[E]) SetPreviewLimit(limit int) {
+	t.limit.Set(limit)
+}
+
+// String converts to string, only the preview limit of intervals are shown
+func (t *IntervalTree[E]) String() string {
+	return t.format(t.limit.Get(preview.DefaultLimit))
+}
+
+// Format implements [fmt.Formatter], %v prints the preview as String does, %+v and %#v print all intervals
+func (t *IntervalTree[E]) Format(f fmt.State, verb rune) {
+	preview.Format(f, verb, t.format, t.limit.Get(preview.DefaultLimit))
+}
+
+func (t *IntervalTree[E]) format(limit int) string {
+	intervals := t.ToArray()
+	return preview.Elements(fmt.Sprintf("IntervalTree[%T](len=%d)", *new(E), len(intervals)), intervals, limit)
+}
+
+// LogValue implements [slog.LogValuer], only the preview limit of intervals are logged
+func (t *IntervalTree[E]) LogValue() slog.Value {
+	return preview.LogElements(fmt.Sprintf("IntervalTree[%T]", *new(E)), t.ToArray(), t.limit.Get(preview.DefaultLimit))
+}
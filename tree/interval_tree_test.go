@@ -0,0 +1,169 @@
+package tree
+
+import (
+	"encoding/json"
+	"math"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func meetings() *IntervalTree[string] {
+	tree := NewIntervalTree[string]()
+	tree.Insert(9, 10, "standup")
+	tree.Insert(10, 12, "review")
+	tree.Insert(11, 13, "lunch")
+	tree.Insert(14, 15, "1:1")
+	return tree
+}
+
+// intervalValues returns the values of the intervals
+func intervalValues[E any](intervals []Interval[E]) []E {
+	var values []E
+	for _, interval := range intervals {
+		values = append(values, interval.Value)
+	}
+	return values
+}
+
+func TestIntervalTree_Insert(t *testing.T) {
+	tree := meetings()
+	assert.Equal(t, int64(4), tree.Count())
+	tree.Insert(9, 10, "standup")
+	assert.Equal(t, int64(5), tree.Count())
+	assert.NoError(t, tree.Validate())
+
+	t.Run("invalid interval", func(t *testing.T) {
+		assert.PanicsWithError(t, "interval must have bounds lo <= hi: [2, 1)", func() {
+			tree.Insert(2, 1, "")
+		})
+		assert.Panics(t, func() {
+			tree.Insert(math.NaN(), 1, "")
+		})
+	})
+
+	t.Run("zero value", func(t *testing.T) {
+		var tree IntervalTree[int]
+		tree.Insert(1, 3, 1)
+		assert.Equal(t, []int{1}, intervalValues(tree.Stab(2)))
+	})
+}
+
+func TestIntervalTree_Remove(t *testing.T) {
+	tree := meetings()
+	tree.Insert(10, 12, "retro")
+	assert.Equal(t, 1, tree.Remove(10, 12, func(value string) bool {
+		return value == "review"
+	}))
+	assert.Equal(t, []string{"standup", "retro", "lunch", "1:1"}, intervalValues(tree.ToArray()))
+	assert.Equal(t, 0, tree.Remove(10, 11, func(string) bool {
+		return true
+	}))
+	assert.Equal(t, 1, tree.Remove(14, 15, func(string) bool {
+		return true
+	}))
+	assert.Equal(t, int64(3), tree.Count())
+	assert.NoError(t, tree.Validate())
+	assert.Empty(t, tree.Stab(14.5))
+}
+
+func TestIntervalTree_Stab(t *testing.T) {
+	tree := meetings()
+	assert.Equal(t, []string{"review", "lunch"}, intervalValues(tree.Stab(11.5)))
+	assert.Equal(t, []string{"review"}, intervalValues(tree.Stab(10)))
+	assert.Empty(t, tree.Stab(13))
+	assert.Empty(t, tree.Stab(8))
+
+	t.Run("open ended", func(t *testing.T) {
+		tree.Insert(12, math.Inf(1), "vacation")
+		assert.Equal(t, []string{"vacation"}, intervalValues(tree.Stab(1e9)))
+	})
+
+	t.Run("empty interval", func(t *testing.T) {
+		tree := NewIntervalTree[int]()
+		tree.Insert(1, 1, 1)
+		assert.Empty(t, tree.Stab(1))
+	})
+}
+
+func TestIntervalTree_Overlaps(t *testing.T) {
+	tree := meetings()
+	assert.Equal(t, []string{"review", "lunch"}, intervalValues(tree.Overlaps(11, 14)))
+	assert.Equal(t, []string{"standup", "review"}, intervalValues(tree.Overlaps(9.5, 11)))
+	assert.Empty(t, tree.Overlaps(13, 14))
+	assert.Empty(t, tree.Overlaps(12, 11))
+	assert.Empty(t, tree.Overlaps(12, 12))
+
+	t.Run("random intervals", func(t *testing.T) {
+		tree := NewIntervalTree[int]()
+		var intervals []Interval[int]
+		for index := range 500 {
+			lo := rand.Float64() * 1000
+			hi := lo + rand.Float64()*50
+			tree.Insert(lo, hi, index)
+			intervals = append(intervals, Interval[int]{Lo: lo, Hi: hi, Value: index})
+		}
+		assert.NoError(t, tree.Validate())
+		for range 100 {
+			lo := rand.Float64() * 1000
+			hi := lo + rand.Float64()*20
+			var expected []int
+			for _, interval := range intervals {
+				if interval.Lo < hi && lo < interval.Hi {
+					expected = append(expected, interval.Value)
+				}
+			}
+			assert.ElementsMatch(t, expected, intervalValues(tree.Overlaps(lo, hi)))
+		}
+	})
+}
+
+func TestIntervalTree_All(t *testing.T) {
+	tree := meetings()
+	var values []string
+	for interval := range tree.All() {
+		values = append(values, interval.Value)
+		tree.Remove(interval.Lo, interval.Hi, func(string) bool {
+			return true
+		})
+	}
+	assert.Equal(t, []string{"standup", "review", "lunch", "1:1"}, values)
+	assert.True(t, tree.IsEmpty())
+}
+
+func TestIntervalTree_Clear(t *testing.T) {
+	tree := meetings()
+	tree.Clear()
+	assert.True(t, tree.IsEmpty())
+	assert.Equal(t, 0, tree.Height())
+}
+
+func TestIntervalTree_ToJSON(t *testing.T) {
+	tree := NewIntervalTree[string]()
+	tree.Insert(1, 2, "a")
+	data, err := tree.ToJSON()
+	assert.NoError(t, err)
+	assert.JSONEq(t, `[{"lo":1,"hi":2,"value":"a"}]`, string(data))
+}
+
+func TestIntervalTree_UnmarshalJSON(t *testing.T) {
+	data, err := json.Marshal(meetings())
+	assert.NoError(t, err)
+	tree := new(IntervalTree[string])
+	assert.NoError(t, json.Unmarshal(data, tree))
+	assert.Equal(t, meetings().ToArray(), tree.ToArray())
+	assert.Equal(t, []string{"review", "lunch"}, intervalValues(tree.Stab(11.5)))
+
+	t.Run("invalid interval", func(t *testing.T) {
+		tree := NewIntervalTree[string]()
+		assert.ErrorIs(t, json.Unmarshal([]byte(`[{"lo":2,"hi":1,"value":"a"}]`), tree), ErrInvalidInterval)
+		assert.True(t, tree.IsEmpty())
+	})
+}
+
+func TestIntervalTree_String(t *testing.T) {
+	tree := NewIntervalTree[string]()
+	tree.Insert(1, 2, "a")
+	assert.Equal(t, "IntervalTree[string](len=1){\n\t[1, 2): a,\n}", tree.String())
+}
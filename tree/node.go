@@ -0,0 +1,304 @@
+package tree
+
+import (
+	"encoding/json"
+	"errors"
+	"iter"
+	"reflect"
+	"slices"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/gopi-frame/collection/internal/codec"
+)
+
+// ErrNodeCycle is returned when a node is moved under itself or one of its descendants
+var ErrNodeCycle = errors.New("node cannot be moved under itself or its descendants")
+
+// NewNode new node with children
+func NewNode[T any](value T, children ...*Node[T]) *Node[T] {
+	node := &Node[T]{Value: value}
+	node.Append(children...)
+	return node
+}
+
+// Node node of a general hierarchy, each node has a parent and a list of children.
+// Node is not safe for concurrent use.
+type Node[T any] struct {
+	Value    T
+	parent   *Node[T]
+	children []*Node[T]
+}
+
+// Parent returns the parent node, it returns nil for a root node
+func (n *Node[T]) Parent() *Node[T] {
+	return n.parent
+}
+
+// Children returns the child nodes
+func (n *Node[T]) Children() []*Node[T] {
+	return slices.Clone(n.children)
+}
+
+// Root returns the root node of the hierarchy
+func (n *Node[T]) Root() *Node[T] {
+	root := n
+	for root.parent != nil {
+		root = root.parent
+	}
+	return root
+}
+
+// IsRoot returns whether the node has no parent
+func (n *Node[T]) IsRoot() bool {
+	return n.parent == nil
+}
+
+// IsLeaf returns whether the node has no children
+func (n *Node[T]) IsLeaf() bool {
+	return len(n.children) == 0
+}
+
+// Depth returns the number of ancestors of the node
+func (n *Node[T]) Depth() int {
+	depth := 0
+	for parent := n.parent; parent != nil; parent = parent.parent {
+		depth++
+	}
+	return depth
+}
+
+// Count returns the number of nodes in the subtree including the node itself
+func (n *Node[T]) Count() int64 {
+	count := int64(1)
+	for _, child := range n.children {
+		count += child.Count()
+	}
+	return count
+}
+
+// IsAncestorOf returns whether the node is an ancestor of the other node
+func (n *Node[T]) IsAncestorOf(other *Node[T]) bool {
+	for parent := other.parent; parent != nil; parent = parent.parent {
+		if parent == n {
+			return true
+		}
+	}
+	return false
+}
+
+// Append appends children to the node, the children are detached from their previous parents.
+// It panics with [ErrNodeCycle] if a child is the node itself or one of its ancestors.
+func (n *Node[T]) Append(children ...*Node[T]) *Node[T] {
+	for _, child := range children {
+		if err := child.MoveTo(n); err != nil {
+			panic(err)
+		}
+	}
+	return n
+}
+
+// MoveTo detaches the node with its subtree and appends it to the children of the parent
+func (n *Node[T]) MoveTo(parent *Node[T]) error {
+	if parent == n || n.IsAncestorOf(parent) {
+		return ErrNodeCycle
+	}
+	n.Detach()
+	n.parent = parent
+	parent.children = append(parent.children, n)
+	return nil
+}
+
+// Detach detaches the node with its subtree from its parent
+func (n *Node[T]) Detach() *Node[T] {
+	if n.parent == nil {
+		return n
+	}
+	n.parent.children = slices.DeleteFunc(n.parent.children, func(child *Node[T]) bool {
+		return child == n
+	})
+	n.parent = nil
+	return n
+}
+
+// Prune removes the descendants which match the callback with their subtrees,
+// it returns the number of removed nodes.
+func (n *Node[T]) Prune(callback func(node *Node[T]) bool) int {
+	removed := 0
+	n.children = slices.DeleteFunc(n.children, func(child *Node[T]) bool {
+		if callback(child) {
+			child.parent = nil
+			removed += int(child.Count())
+			return true
+		}
+		return false
+	})
+	for _, child := range n.children {
+		removed += child.Prune(callback)
+	}
+	return removed
+}
+
+// Path returns the values of the nodes from the root to the node
+func (n *Node[T]) Path() []T {
+	var path []T
+	for node := n; node != nil; node = node.parent {
+		path = append(path, node.Value)
+	}
+	slices.Reverse(path)
+	return path
+}
+
+// Lookup returns the descendant reached by following children with the values of the path,
+// it returns nil and false when the path does not exist.
+func (n *Node[T]) Lookup(path ...T) (*Node[T], bool) {
+	node := n
+	for _, value := range path {
+		index := slices.IndexFunc(node.children, func(child *Node[T]) bool {
+			return reflect.DeepEqual(child.Value, value)
+		})
+		if index < 0 {
+			return nil, false
+		}
+		node = node.children[index]
+	}
+	return node, true
+}
+
+// Find returns the first node in depth-first order which matches the callback
+func (n *Node[T]) Find(callback func(node *Node[T]) bool) (*Node[T], bool) {
+	var found *Node[T]
+	n.EachDepthFirst(func(node *Node[T]) bool {
+		if callback(node) {
+			found = node
+			return false
+		}
+		return true
+	})
+	return found, found != nil
+}
+
+// EachDepthFirst runs callback for each node of the subtree in pre-order, it breaks when callback returns false
+func (n *Node[T]) EachDepthFirst(callback func(node *Node[T]) bool) {
+	stack := []*Node[T]{n}
+	for len(stack) > 0 {
+		node := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if !callback(node) {
+			return
+		}
+		for i := len(node.children) - 1; i >= 0; i-- {
+			stack = append(stack, node.children[i])
+		}
+	}
+}
+
+// EachBreadthFirst runs callback for each node of the subtree level by level, it breaks when callback returns false
+func (n *Node[T]) EachBreadthFirst(callback func(node *Node[T]) bool) {
+	queue := []*Node[T]{n}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		if !callback(node) {
+			return
+		}
+		queue = append(queue, node.children...)
+	}
+}
+
+// All returns an iterator over the nodes of the subtree in pre-order
+func (n *Node[T]) All() iter.Seq[*Node[T]] {
+	return n.EachDepthFirst
+}
+
+type jsonNode[T any] struct {
+	Value    T          `json:"value"`
+	Children []*Node[T] `json:"children,omitempty"`
+}
+
+// MarshalJSON implements [json.Marshaller]
+func (n *Node[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonNode[T]{Value: n.Value, Children: n.children})
+}
+
+// UnmarshalJSON implements [json.Unmarshaller]
+func (n *Node[T]) UnmarshalJSON(data []byte) error {
+	var node jsonNode[T]
+	if err := json.Unmarshal(data, &node); err != nil {
+		return err
+	}
+	n.Value = node.Value
+	n.children = slices.DeleteFunc(node.Children, func(child *Node[T]) bool {
+		return child == nil
+	})
+	for _, child := range n.children {
+		child.parent = n
+	}
+	return nil
+}
+
+// MarshalCBOR implements [cbor.Marshaler]
+func (n *Node[T]) MarshalCBOR() ([]byte, error) {
+	return cbor.Marshal(jsonNode[T]{Value: n.Value, Children: n.children})
+}
+
+// UnmarshalCBOR implements [cbor.Unmarshaler]
+func (n *Node[T]) UnmarshalCBOR(data []byte) error {
+	var node jsonNode[T]
+	if err := cbor.Unmarshal(data, &node); err != nil {
+		return err
+	}
+	n.Value = node.Value
+	n.children = slices.DeleteFunc(node.Children, func(child *Node[T]) bool {
+		return child == nil
+	})
+	for _, child := range n.children {
+		child.parent = n
+	}
+	return nil
+}
+
+// MarshalBinary implements [encoding.BinaryMarshaler],
+// the value is followed by the sequence of the children, the value is encoded by its own encoder
+func (n *Node[T]) MarshalBinary() ([]byte, error) {
+	e := new(codec.Encoder)
+	if err := codec.Encode(e, n.Value); err != nil {
+		return nil, err
+	}
+	e.Count(len(n.children))
+	for _, child := range n.children {
+		if err := codec.Encode(e, child); err != nil {
+			return nil, err
+		}
+	}
+	return e.Bytes(), nil
+}
+
+// UnmarshalBinary implements [encoding.BinaryUnmarshaler]
+func (n *Node[T]) UnmarshalBinary(data []byte) error {
+	d := codec.NewDecoder(data)
+	value, err := codec.Decode[T](d)
+	if err != nil {
+		return err
+	}
+	count, err := d.Count()
+	if err != nil {
+		return err
+	}
+	children := make([]*Node[T], 0, count)
+	for i := 0; i < count; i++ {
+		child, err := codec.Decode[*Node[T]](d)
+		if err != nil {
+			return err
+		}
+		children = append(children, child)
+	}
+	if err := d.Finish(); err != nil {
+		return err
+	}
+	n.Value = value
+	n.children = children
+	for _, child := range n.children {
+		child.parent = n
+	}
+	return nil
+}
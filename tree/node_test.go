@@ -0,0 +1,164 @@
+package tree
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func _menu() *Node[string] {
+	return NewNode("root",
+		NewNode("file",
+			NewNode("new"),
+			NewNode("open"),
+		),
+		NewNode("edit",
+			NewNode("copy"),
+			NewNode("paste"),
+		),
+	)
+}
+
+func _values(nodes []*Node[string]) []string {
+	values := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		values = append(values, node.Value)
+	}
+	return values
+}
+
+func TestNode_Relations(t *testing.T) {
+	root := _menu()
+	assert.True(t, root.IsRoot())
+	assert.False(t, root.IsLeaf())
+	assert.EqualValues(t, 7, root.Count())
+	assert.Equal(t, []string{"file", "edit"}, _values(root.Children()))
+
+	open, ok := root.Lookup("file", "open")
+	assert.True(t, ok)
+	assert.True(t, open.IsLeaf())
+	assert.Equal(t, 2, open.Depth())
+	assert.Equal(t, "file", open.Parent().Value)
+	assert.Same(t, root, open.Root())
+	assert.True(t, root.IsAncestorOf(open))
+	assert.False(t, open.IsAncestorOf(root))
+	assert.Equal(t, []string{"root", "file", "open"}, open.Path())
+
+	_, ok = root.Lookup("file", "close")
+	assert.False(t, ok)
+}
+
+func TestNode_EachDepthFirst(t *testing.T) {
+	var values []string
+	_menu().EachDepthFirst(func(node *Node[string]) bool {
+		values = append(values, node.Value)
+		return node.Value != "copy"
+	})
+	assert.Equal(t, []string{"root", "file", "new", "open", "edit", "copy"}, values)
+}
+
+func TestNode_EachBreadthFirst(t *testing.T) {
+	var values []string
+	_menu().EachBreadthFirst(func(node *Node[string]) bool {
+		values = append(values, node.Value)
+		return true
+	})
+	assert.Equal(t, []string{"root", "file", "edit", "new", "open", "copy", "paste"}, values)
+}
+
+func TestNode_Find(t *testing.T) {
+	root := _menu()
+	node, ok := root.Find(func(node *Node[string]) bool {
+		return node.Value == "paste"
+	})
+	assert.True(t, ok)
+	assert.Equal(t, []string{"root", "edit", "paste"}, node.Path())
+
+	_, ok = root.Find(func(node *Node[string]) bool {
+		return node.Value == "close"
+	})
+	assert.False(t, ok)
+}
+
+func TestNode_MoveTo(t *testing.T) {
+	root := _menu()
+	file, _ := root.Lookup("file")
+	edit, _ := root.Lookup("edit")
+	assert.Nil(t, edit.MoveTo(file))
+	assert.Equal(t, []string{"file"}, _values(root.Children()))
+	assert.Equal(t, []string{"new", "open", "edit"}, _values(file.Children()))
+	assert.Equal(t, []string{"root", "file", "edit"}, edit.Path())
+
+	assert.ErrorIs(t, file.MoveTo(edit), ErrNodeCycle)
+	assert.ErrorIs(t, file.MoveTo(file), ErrNodeCycle)
+	assert.Panics(t, func() {
+		edit.Append(root)
+	})
+}
+
+func TestNode_Detach(t *testing.T) {
+	root := _menu()
+	file, _ := root.Lookup("file")
+	assert.Same(t, file, file.Detach())
+	assert.True(t, file.IsRoot())
+	assert.EqualValues(t, 3, file.Count())
+	assert.EqualValues(t, 4, root.Count())
+}
+
+func TestNode_Prune(t *testing.T) {
+	root := _menu()
+	removed := root.Prune(func(node *Node[string]) bool {
+		return node.Value == "file" || node.Value == "copy"
+	})
+	assert.Equal(t, 4, removed)
+	assert.EqualValues(t, 3, root.Count())
+	_, ok := root.Lookup("edit", "paste")
+	assert.True(t, ok)
+}
+
+func TestNode_MarshalJSON(t *testing.T) {
+	root := NewNode("root", NewNode("a", NewNode("b")), NewNode("c"))
+	data, err := json.Marshal(root)
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"value":"root","children":[{"value":"a","children":[{"value":"b"}]},{"value":"c"}]}`, string(data))
+}
+
+func TestNode_UnmarshalJSON(t *testing.T) {
+	root := new(Node[string])
+	err := json.Unmarshal([]byte(`{"value":"root","children":[{"value":"a","children":[{"value":"b"}]},{"value":"c"}]}`), root)
+	assert.Nil(t, err)
+	b, ok := root.Lookup("a", "b")
+	assert.True(t, ok)
+	assert.Equal(t, []string{"root", "a", "b"}, b.Path())
+	assert.EqualValues(t, 4, root.Count())
+
+	assert.NotNil(t, json.Unmarshal([]byte(`[]`), root))
+}
+
+func TestNode_MarshalCBOR(t *testing.T) {
+	value := NewNode("root", NewNode("a", NewNode("b")), NewNode("c"))
+	data, err := cbor.Marshal(value)
+	assert.Nil(t, err)
+	decoded := new(Node[string])
+	err = cbor.Unmarshal(data, decoded)
+	assert.Nil(t, err)
+	b, ok := decoded.Lookup("a", "b")
+	assert.True(t, ok)
+	assert.Equal(t, []string{"root", "a", "b"}, b.Path())
+	assert.EqualValues(t, 4, decoded.Count())
+}
+
+func TestNode_MarshalBinary(t *testing.T) {
+	value := NewNode("root", NewNode("a", NewNode("b")), NewNode("c"))
+	data, err := value.MarshalBinary()
+	assert.Nil(t, err)
+	decoded := new(Node[string])
+	err = decoded.UnmarshalBinary(data)
+	assert.Nil(t, err)
+	b, ok := decoded.Lookup("a", "b")
+	assert.True(t, ok)
+	assert.Equal(t, []string{"root", "a", "b"}, b.Path())
+	assert.EqualValues(t, 4, decoded.Count())
+}
@@ -0,0 +1,44 @@
+package tree
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// walkParallel partitions the in-order positions [0, size) into a chunk per worker,
+// and walks each chunk in its own goroutine. It stops all workers on the first error and returns it.
+func walkParallel[E any](size, workers int, walk func(from, to int, callback func(E) bool) bool, fn func(E) error) error {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	workers = min(workers, size)
+	var (
+		wg      sync.WaitGroup
+		stopped atomic.Bool
+		once    sync.Once
+		result  error
+	)
+	for i := 0; i < workers; i++ {
+		from, to := i*size/workers, (i+1)*size/workers
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			walk(from, to, func(value E) bool {
+				if stopped.Load() {
+					return false
+				}
+				if err := fn(value); err != nil {
+					once.Do(func() {
+						result = err
+						stopped.Store(true)
+					})
+					return false
+				}
+				return true
+			})
+		}()
+	}
+	wg.Wait()
+	return result
+}
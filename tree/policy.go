@@ -0,0 +1,13 @@
+package tree
+
+// DuplicatePolicy controls how a tree handles an element equal to an existing element
+type DuplicatePolicy int
+
+const (
+	// AllowDuplicates keeps all equal elements, it is the default policy
+	AllowDuplicates DuplicatePolicy = iota
+	// IgnoreDuplicates keeps the existing element and drops the new one
+	IgnoreDuplicates
+	// ReplaceDuplicates replaces the existing element with the new one
+	ReplaceDuplicates
+)
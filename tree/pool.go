@@ -0,0 +1,47 @@
+package tree
+
+import "sync"
+
+// nodePool reuses the nodes of a tree, a nil pool allocates new nodes
+type nodePool[N any] struct {
+	pool sync.Pool
+}
+
+func newNodePool[N any]() *nodePool[N] {
+	return &nodePool[N]{pool: sync.Pool{New: func() any {
+		return new(N)
+	}}}
+}
+
+func (p *nodePool[N]) get() *N {
+	if p == nil {
+		return new(N)
+	}
+	return p.pool.Get().(*N)
+}
+
+func (p *nodePool[N]) put(node *N) {
+	if p == nil {
+		return
+	}
+	*node = *new(N)
+	p.pool.Put(node)
+}
+
+func (node *avlNode[E]) release(pool *nodePool[avlNode[E]]) {
+	if node == nil {
+		return
+	}
+	node.left.release(pool)
+	node.right.release(pool)
+	pool.put(node)
+}
+
+func (node *rbNode[E]) release(pool *nodePool[rbNode[E]]) {
+	if node == nil {
+		return
+	}
+	node.left.release(pool)
+	node.right.release(pool)
+	pool.put(node)
+}
@@ -0,0 +1,175 @@
+package tree
+
+import (
+	"fmt"
+	"iter"
+	"log/slog"
+	"slices"
+	"sync"
+
+	"github.com/gopi-frame/collection/internal/preview"
+)
+
+const (
+	// DefaultQuadTreeCapacity is the default number of points a node of [QuadTree] holds before it is subdivided
+	DefaultQuadTreeCapacity = 8
+	// DefaultQuadTreeMaxDepth is the default max depth of [QuadTree]
+	DefaultQuadTreeMaxDepth = 16
+)
+
+// Point is an element located in the plane
+type Point interface {
+	comparable
+	// Location returns the coordinates of the element
+	Location() (x, y float64)
+}
+
+// NewQuadTree new quad tree covering the bounds.
+// A node is subdivided when it holds more points than capacity, unless it reaches max depth.
+// [DefaultQuadTreeCapacity] and [DefaultQuadTreeMaxDepth] are used when the arguments are not positive.
+func NewQuadTree[P Point](bounds Rect, capacity, maxDepth int, points ...P) *QuadTree[P] {
+	if capacity <= 0 {
+		capacity = DefaultQuadTreeCapacity
+	}
+	if maxDepth <= 0 {
+		maxDepth = DefaultQuadTreeMaxDepth
+	}
+	tree := new(QuadTree[P])
+	tree.root = &quadNode[P]{bounds: bounds}
+	tree.capacity = capacity
+	tree.maxDepth = maxDepth
+	for _, point := range points {
+		tree.insert(point)
+	}
+	return tree
+}
+
+// QuadTree quad tree partitioning points in the plane, all methods are safe for concurrent use
+type QuadTree[P Point] struct {
+	lock     sync.RWMutex
+	root     *quadNode[P]
+	capacity int
+	maxDepth int
+	limit    preview.Limit
+}
+
+// Count returns the number of points in the tree
+func (t *QuadTree[P]) Count() int64 {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	return int64(t.root.size)
+}
+
+// IsEmpty returns whether the tree is empty
+func (t *QuadTree[P]) IsEmpty() bool {
+	return t.Count() == 0
+}
+
+// IsNotEmpty returns whether the tree is not empty
+func (t *QuadTree[P]) IsNotEmpty() bool {
+	return !t.IsEmpty()
+}
+
+// Bounds returns the region covered by the tree
+func (t *QuadTree[P]) Bounds() Rect {
+	return t.root.bounds
+}
+
+// Contains returns whether the tree contains the point
+func (t *QuadTree[P]) Contains(point P) bool {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	x, y := point.Location()
+	if !t.root.bounds.ContainsPoint(x, y) {
+		return false
+	}
+	return t.root.find(point, x, y)
+}
+
+// Insert inserts the point, it returns false if the point is out of the bounds of the tree
+func (t *QuadTree[P]) Insert(point P) bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.insert(point)
+}
+
+func (t *QuadTree[P]) insert(point P) bool {
+	x, y := point.Location()
+	if !t.root.bounds.ContainsPoint(x, y) {
+		return false
+	}
+	t.root.insert(point, x, y, t.capacity, t.maxDepth)
+	return true
+}
+
+// Remove removes the point, it returns false if the point is not found
+func (t *QuadTree[P]) Remove(point P) bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	x, y := point.Location()
+	if !t.root.bounds.ContainsPoint(x, y) {
+		return false
+	}
+	return t.root.remove(point, x, y, t.capacity)
+}
+
+// Clear clears the tree
+func (t *QuadTree[P]) Clear() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.root = &quadNode[P]{bounds: t.root.bounds}
+}
+
+// Search returns the points located in the rect
+func (t *QuadTree[P]) Search(rect Rect) []P {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	return t.root.search(rect, make([]P, 0))
+}
+
+// All returns an iterator over the elements
+func (t *QuadTree[P]) All() iter.Seq[P] {
+	return slices.Values(t.ToArray())
+}
+
+// ToArray converts to array, the returned slice is a copy, so modifying it does not affect the collection
+func (t *QuadTree[P]) ToArray() []P {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	return t.root.collect(make([]P, 0, t.root.size))
+}
+
+// Each runs callback for each point, it breaks when callback returns false.
+// The points are snapshotted before visiting, so it is allowed to modify the tree in callback.
+func (t *QuadTree[P]) Each(callback func(index int, point P) bool) {
+	for index, point := range t.ToArray() {
+		if !callback(index, point) {
+			break
+		}
+	}
+}
+
+// SetPreviewLimit sets the number of points shown by String, %v and LogValue, a negative limit shows all points
+func (t *QuadTree[P]) SetPreviewLimit(limit int) {
+	t.limit.Set(limit)
+}
+
+// String converts to string, only the preview limit of points are shown
+func (t *QuadTree[P]) String() string {
+	return t.format(t.limit.Get(preview.DefaultLimit))
+}
+
+// Format implements [fmt.Formatter], %v prints the preview as String does, %+v and %#v print all points
+func (t *QuadTree[P]) Format(f fmt.State, verb rune) {
+	preview.Format(f, verb, t.format, t.limit.Get(preview.DefaultLimit))
+}
+
+func (t *QuadTree[P]) format(limit int) string {
+	items := t.ToArray()
+	return preview.Elements(fmt.Sprintf("QuadTree[%T](len=%d)", *new(P), len(items)), items, limit)
+}
+
+// LogValue implements [slog.LogValuer], only the preview limit of points are logged
+func (t *QuadTree[P]) LogValue() slog.Value {
+	return preview.LogElements(fmt.Sprintf("QuadTree[%T]", *new(P)), t.ToArray(), t.limit.Get(preview.DefaultLimit))
+}
@@ -0,0 +1,120 @@
+package tree
+
+type quadNode[P Point] struct {
+	bounds   Rect
+	depth    int
+	size     int
+	points   []P
+	children *[4]*quadNode[P]
+}
+
+// quadrant returns the index of the child which covers the location
+func (node *quadNode[P]) quadrant(x, y float64) int {
+	index := 0
+	if x >= (node.bounds.MinX+node.bounds.MaxX)/2 {
+		index |= 1
+	}
+	if y >= (node.bounds.MinY+node.bounds.MaxY)/2 {
+		index |= 2
+	}
+	return index
+}
+
+func (node *quadNode[P]) subdivide() {
+	midX := (node.bounds.MinX + node.bounds.MaxX) / 2
+	midY := (node.bounds.MinY + node.bounds.MaxY) / 2
+	node.children = &[4]*quadNode[P]{
+		{bounds: Rect{MinX: node.bounds.MinX, MinY: node.bounds.MinY, MaxX: midX, MaxY: midY}, depth: node.depth + 1},
+		{bounds: Rect{MinX: midX, MinY: node.bounds.MinY, MaxX: node.bounds.MaxX, MaxY: midY}, depth: node.depth + 1},
+		{bounds: Rect{MinX: node.bounds.MinX, MinY: midY, MaxX: midX, MaxY: node.bounds.MaxY}, depth: node.depth + 1},
+		{bounds: Rect{MinX: midX, MinY: midY, MaxX: node.bounds.MaxX, MaxY: node.bounds.MaxY}, depth: node.depth + 1},
+	}
+	points := node.points
+	node.points = nil
+	for _, point := range points {
+		x, y := point.Location()
+		child := node.children[node.quadrant(x, y)]
+		child.points = append(child.points, point)
+		child.size++
+	}
+}
+
+func (node *quadNode[P]) insert(point P, x, y float64, capacity, maxDepth int) {
+	node.size++
+	if node.children == nil {
+		if len(node.points) < capacity || node.depth >= maxDepth {
+			node.points = append(node.points, point)
+			return
+		}
+		node.subdivide()
+	}
+	node.children[node.quadrant(x, y)].insert(point, x, y, capacity, maxDepth)
+}
+
+// remove removes the point from the subtree, the children are merged back when they fit in the node
+func (node *quadNode[P]) remove(point P, x, y float64, capacity int) bool {
+	if node.children == nil {
+		for index, p := range node.points {
+			if p == point {
+				node.points = append(node.points[:index], node.points[index+1:]...)
+				node.size--
+				return true
+			}
+		}
+		return false
+	}
+	if !node.children[node.quadrant(x, y)].remove(point, x, y, capacity) {
+		return false
+	}
+	node.size--
+	if node.size <= capacity {
+		node.points = node.collect(make([]P, 0, node.size))
+		node.children = nil
+	}
+	return true
+}
+
+func (node *quadNode[P]) find(point P, x, y float64) bool {
+	if node.children != nil {
+		return node.children[node.quadrant(x, y)].find(point, x, y)
+	}
+	for _, p := range node.points {
+		if p == point {
+			return true
+		}
+	}
+	return false
+}
+
+// collect appends the points of the subtree to points
+func (node *quadNode[P]) collect(points []P) []P {
+	if node.children == nil {
+		return append(points, node.points...)
+	}
+	for _, child := range node.children {
+		points = child.collect(points)
+	}
+	return points
+}
+
+// search appends the points of the subtree located in the rect to points
+func (node *quadNode[P]) search(rect Rect, points []P) []P {
+	if !node.bounds.Intersects(rect) {
+		return points
+	}
+	if node.children == nil {
+		for _, point := range node.points {
+			if rect.ContainsPoint(point.Location()) {
+				points = append(points, point)
+			}
+		}
+		return points
+	}
+	if rect.Contains(node.bounds) {
+		return node.collect(points)
+	}
+	for _, child := range node.children {
+		points = child.search(rect, points)
+	}
+	return points
+}
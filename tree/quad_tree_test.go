@@ -0,0 +1,149 @@
+package tree
+
+import (
+	"fmt"
+	"math/rand"
+	"regexp"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type _point struct {
+	id   int
+	x, y float64
+}
+
+func (p _point) Location() (float64, float64) {
+	return p.x, p.y
+}
+
+func _pointIDs(points []_point) []int {
+	ids := make([]int, 0, len(points))
+	for _, point := range points {
+		ids = append(ids, point.id)
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+func _gridQuadTree(n int) *QuadTree[_point] {
+	tree := NewQuadTree[_point](NewRect(0, 0, 10, 10), 2, 0)
+	for i := 0; i < n; i++ {
+		tree.Insert(_point{id: i, x: float64(i % 10), y: float64(i / 10)})
+	}
+	return tree
+}
+
+func TestQuadTree_Count(t *testing.T) {
+	tree := _gridQuadTree(100)
+	assert.EqualValues(t, 100, tree.Count())
+	assert.True(t, tree.IsNotEmpty())
+	tree.Clear()
+	assert.True(t, tree.IsEmpty())
+	assert.Equal(t, NewRect(0, 0, 10, 10), tree.Bounds())
+}
+
+func TestQuadTree_Insert(t *testing.T) {
+	tree := NewQuadTree[_point](NewRect(0, 0, 10, 10), 0, 0)
+	assert.True(t, tree.Insert(_point{id: 1, x: 10, y: 10}))
+	assert.False(t, tree.Insert(_point{id: 2, x: 11, y: 10}))
+	assert.True(t, tree.Contains(_point{id: 1, x: 10, y: 10}))
+	assert.False(t, tree.Contains(_point{id: 2, x: 11, y: 10}))
+}
+
+func TestQuadTree_MaxDepth(t *testing.T) {
+	tree := NewQuadTree[_point](NewRect(0, 0, 10, 10), 1, 3)
+	for i := 0; i < 10; i++ {
+		tree.Insert(_point{id: i, x: 1, y: 1})
+	}
+	assert.EqualValues(t, 10, tree.Count())
+	assert.Equal(t, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, _pointIDs(tree.Search(NewRect(0, 0, 1, 1))))
+}
+
+func TestQuadTree_Search(t *testing.T) {
+	tree := _gridQuadTree(100)
+	assert.Equal(t, []int{32, 33, 34, 42, 43, 44}, _pointIDs(tree.Search(NewRect(2, 3, 4.5, 4.5))))
+	assert.Equal(t, []int{}, _pointIDs(tree.Search(NewRect(20, 20, 30, 30))))
+	assert.Len(t, tree.Search(tree.Bounds()), 100)
+}
+
+func TestQuadTree_Remove(t *testing.T) {
+	tree := _gridQuadTree(100)
+	assert.False(t, tree.Remove(_point{id: 100, x: 0, y: 0}))
+	for i := 0; i < 100; i += 2 {
+		assert.True(t, tree.Remove(_point{id: i, x: float64(i % 10), y: float64(i / 10)}))
+	}
+	assert.EqualValues(t, 50, tree.Count())
+	for _, id := range _pointIDs(tree.ToArray()) {
+		assert.Equal(t, 1, id%2)
+	}
+	for i := 1; i < 100; i += 2 {
+		assert.True(t, tree.Remove(_point{id: i, x: float64(i % 10), y: float64(i / 10)}))
+	}
+	assert.True(t, tree.IsEmpty())
+	assert.Nil(t, tree.root.children)
+}
+
+func TestQuadTree_Random(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	tree := NewQuadTree[_point](NewRect(0, 0, 100, 100), 4, 0)
+	points := map[int]_point{}
+	for i := 0; i < 1000; i++ {
+		if len(points) > 0 && random.Intn(3) == 0 {
+			for id, point := range points {
+				assert.True(t, tree.Remove(point))
+				delete(points, id)
+				break
+			}
+			continue
+		}
+		point := _point{id: i, x: random.Float64() * 100, y: random.Float64() * 100}
+		tree.Insert(point)
+		points[i] = point
+	}
+	assert.EqualValues(t, len(points), tree.Count())
+	query := NewRect(20, 30, 60, 50)
+	var expected []_point
+	for _, point := range points {
+		if query.ContainsPoint(point.x, point.y) {
+			expected = append(expected, point)
+		}
+	}
+	assert.Equal(t, _pointIDs(expected), _pointIDs(tree.Search(query)))
+}
+
+func TestQuadTree_Each(t *testing.T) {
+	tree := _gridQuadTree(10)
+	var ids []int
+	tree.Each(func(_ int, point _point) bool {
+		ids = append(ids, point.id)
+		tree.Remove(point)
+		return len(ids) < 5
+	})
+	assert.Len(t, ids, 5)
+	assert.EqualValues(t, 5, tree.Count())
+}
+
+func TestQuadTree_Concurrent(t *testing.T) {
+	tree := NewQuadTree[_point](NewRect(0, 0, 100, 100), 0, 0)
+	wg := new(sync.WaitGroup)
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			tree.Insert(_point{id: i, x: float64(i), y: float64(i)})
+			tree.Search(NewRect(0, 0, 10, 10))
+		}(i)
+	}
+	wg.Wait()
+	assert.EqualValues(t, 100, tree.Count())
+}
+
+func TestQuadTree_String(t *testing.T) {
+	tree := _gridQuadTree(10)
+	pattern := regexp.MustCompile(fmt.Sprintf(`QuadTree\[tree\._point\]\(len=%d\)\{\n(\t\{[^}]+\},\n){5}\t(\.){3}\n\}`, tree.Count()))
+	assert.True(t, pattern.MatchString(tree.String()))
+}
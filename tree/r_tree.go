@@ -0,0 +1,207 @@
+package tree
+
+import (
+	"fmt"
+	"iter"
+	"log/slog"
+	"sync"
+
+	"github.com/gopi-frame/collection/internal/preview"
+)
+
+// DefaultRTreeMaxEntries is the default max number of entries in a node of [RTree]
+const DefaultRTreeMaxEntries = 9
+
+// NewRTree new r-tree with the max number of entries in a node,
+// [DefaultRTreeMaxEntries] is used when maxEntries is not positive and the min value is 4
+func NewRTree[E comparable](maxEntries int) *RTree[E] {
+	if maxEntries <= 0 {
+		maxEntries = DefaultRTreeMaxEntries
+	}
+	maxEntries = max(maxEntries, 4)
+	tree := new(RTree[E])
+	tree.root = new(rTreeNode[E])
+	tree.maxEntries = maxEntries
+	tree.minEntries = max(maxEntries*2/5, 2)
+	return tree
+}
+
+// RTree r-tree indexing values by axis-aligned bounding boxes, all methods are safe for concurrent use
+type RTree[E comparable] struct {
+	lock       sync.RWMutex
+	root       *rTreeNode[E]
+	height     int
+	size       int64
+	maxEntries int
+	minEntries int
+	limit      preview.Limit
+}
+
+// Count returns the number of values in the tree
+func (t *RTree[E]) Count() int64 {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	return t.size
+}
+
+// IsEmpty returns whether the tree is empty
+func (t *RTree[E]) IsEmpty() bool {
+	return t.Count() == 0
+}
+
+// IsNotEmpty returns whether the tree is not empty
+func (t *RTree[E]) IsNotEmpty() bool {
+	return !t.IsEmpty()
+}
+
+// Insert inserts the value with its bounding box
+func (t *RTree[E]) Insert(rect Rect, value E) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.insert(rTreeEntry[E]{rect: rect, value: value})
+	t.size++
+}
+
+func (t *RTree[E]) insert(entry rTreeEntry[E]) {
+	sibling := t.root.insert(entry, 0, t.height, t.maxEntries, t.minEntries)
+	if sibling == nil {
+		return
+	}
+	t.root = &rTreeNode[E]{
+		entries: []rTreeEntry[E]{
+			{rect: t.root.bounds(), child: t.root},
+			{rect: sibling.bounds(), child: sibling},
+		},
+	}
+	t.height++
+}
+
+// Remove removes the value with the bounding box, it returns false if the value is not found
+func (t *RTree[E]) Remove(rect Rect, value E) bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	var orphans []rTreeEntry[E]
+	if !t.root.remove(rect, value, t.height, t.minEntries, &orphans) {
+		return false
+	}
+	for t.height > 0 && len(t.root.entries) == 1 {
+		t.root = t.root.entries[0].child
+		t.height--
+	}
+	if len(t.root.entries) == 0 {
+		t.root = new(rTreeNode[E])
+		t.height = 0
+	}
+	for _, orphan := range orphans {
+		t.insert(orphan)
+	}
+	t.size--
+	return true
+}
+
+// Clear clears the tree
+func (t *RTree[E]) Clear() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.root = new(rTreeNode[E])
+	t.height = 0
+	t.size = 0
+}
+
+// Bounds returns the bounding box of all values,
+// it returns a zero value and false when the tree is empty
+func (t *RTree[E]) Bounds() (Rect, bool) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	if len(t.root.entries) == 0 {
+		return Rect{}, false
+	}
+	return t.root.bounds(), true
+}
+
+// Search returns the values whose bounding boxes intersect with the rect
+func (t *RTree[E]) Search(rect Rect) []E {
+	return t.search(rect, func(Rect) bool { return true })
+}
+
+// SearchContained returns the values whose bounding boxes are contained in the rect
+func (t *RTree[E]) SearchContained(rect Rect) []E {
+	return t.search(rect, rect.Contains)
+}
+
+// SearchContaining returns the values whose bounding boxes contain the rect
+func (t *RTree[E]) SearchContaining(rect Rect) []E {
+	return t.search(rect, func(r Rect) bool { return r.Contains(rect) })
+}
+
+func (t *RTree[E]) search(rect Rect, filter func(Rect) bool) []E {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	values := make([]E, 0)
+	t.root.search(rect, t.height, filter, func(_ Rect, value E) bool {
+		values = append(values, value)
+		return true
+	})
+	return values
+}
+
+// Each runs callback for each value with its bounding box, it breaks when callback returns false.
+// The values are snapshotted before visiting, so it is allowed to modify the tree in callback.
+func (t *RTree[E]) Each(callback func(rect Rect, value E) bool) {
+	t.lock.RLock()
+	entries := t.root.leaves(make([]rTreeEntry[E], 0, t.size), t.height)
+	t.lock.RUnlock()
+	for _, entry := range entries {
+		if !callback(entry.rect, entry.value) {
+			break
+		}
+	}
+}
+
+// All returns an iterator over the values with their bounding boxes
+func (t *RTree[E]) All() iter.Seq2[Rect, E] {
+	return func(yield func(Rect, E) bool) {
+		t.Each(func(rect Rect, value E) bool {
+			return yield(rect, value)
+		})
+	}
+}
+
+// SetPreviewLimit sets the number of entries shown by String, %v and LogValue, a negative limit shows all entries
+func (t *RTree[E]) SetPreviewLimit(limit int) {
+	t.limit.Set(limit)
+}
+
+// String converts to string, only the preview limit of entries are shown
+func (t *RTree[E]) String() string {
+	return t.format(t.limit.Get(preview.DefaultLimit))
+}
+
+// Format implements [fmt.Formatter], %v prints the preview as String does, %+v and %#v print all entries
+func (t *RTree[E]) Format(f fmt.State, verb rune) {
+	preview.Format(f, verb, t.format, t.limit.Get(preview.DefaultLimit))
+}
+
+func (t *RTree[E]) format(limit int) string {
+	rects, values := t.snapshot()
+	return preview.Entries(fmt.Sprintf("RTree[%T](len=%d)", *new(E), len(rects)), rects, values, limit)
+}
+
+// LogValue implements [slog.LogValuer], only the preview limit of entries are logged
+func (t *RTree[E]) LogValue() slog.Value {
+	rects, values := t.snapshot()
+	return preview.LogEntries(fmt.Sprintf("RTree[%T]", *new(E)), len(rects), rects, values, t.limit.Get(preview.DefaultLimit))
+}
+
+func (t *RTree[E]) snapshot() ([]Rect, []E) {
+	t.lock.RLock()
+	entries := t.root.leaves(make([]rTreeEntry[E], 0, t.size), t.height)
+	t.lock.RUnlock()
+	rects := make([]Rect, len(entries))
+	values := make([]E, len(entries))
+	for index, entry := range entries {
+		rects[index] = entry.rect
+		values[index] = entry.value
+	}
+	return rects, values
+}
@@ -0,0 +1,184 @@
+package tree
+
+import "math"
+
+// rTreeEntry is an entry of an r-tree node,
+// it holds a value in leaf nodes and a child node in branch nodes
+type rTreeEntry[E comparable] struct {
+	rect  Rect
+	value E
+	child *rTreeNode[E]
+}
+
+type rTreeNode[E comparable] struct {
+	entries []rTreeEntry[E]
+}
+
+func (node *rTreeNode[E]) bounds() Rect {
+	rect := node.entries[0].rect
+	for _, entry := range node.entries[1:] {
+		rect = rect.Union(entry.rect)
+	}
+	return rect
+}
+
+// chooseSubtree returns the index of the entry which needs the least enlargement to include the rect
+func (node *rTreeNode[E]) chooseSubtree(rect Rect) int {
+	index := 0
+	minEnlargement := math.Inf(1)
+	minArea := math.Inf(1)
+	for i, entry := range node.entries {
+		area := entry.rect.Area()
+		enlargement := entry.rect.Union(rect).Area() - area
+		if enlargement < minEnlargement || (enlargement == minEnlargement && area < minArea) {
+			index = i
+			minEnlargement = enlargement
+			minArea = area
+		}
+	}
+	return index
+}
+
+// insert inserts the entry into the node of the given level, leaves are on level 0.
+// It returns the new sibling if the node is split.
+func (node *rTreeNode[E]) insert(entry rTreeEntry[E], level, nodeLevel, maxEntries, minEntries int) *rTreeNode[E] {
+	if nodeLevel == level {
+		node.entries = append(node.entries, entry)
+	} else {
+		index := node.chooseSubtree(entry.rect)
+		child := node.entries[index].child
+		sibling := child.insert(entry, level, nodeLevel-1, maxEntries, minEntries)
+		node.entries[index].rect = child.bounds()
+		if sibling != nil {
+			node.entries = append(node.entries, rTreeEntry[E]{rect: sibling.bounds(), child: sibling})
+		}
+	}
+	if len(node.entries) > maxEntries {
+		return node.split(minEntries)
+	}
+	return nil
+}
+
+// split splits the entries of the node into two groups with the quadratic algorithm,
+// the node keeps the first group and the second group is returned as a new node
+func (node *rTreeNode[E]) split(minEntries int) *rTreeNode[E] {
+	entries := node.entries
+	seed1, seed2 := 0, 1
+	maxWaste := math.Inf(-1)
+	for i := 0; i < len(entries); i++ {
+		for j := i + 1; j < len(entries); j++ {
+			waste := entries[i].rect.Union(entries[j].rect).Area() - entries[i].rect.Area() - entries[j].rect.Area()
+			if waste > maxWaste {
+				seed1, seed2 = i, j
+				maxWaste = waste
+			}
+		}
+	}
+	group1 := []rTreeEntry[E]{entries[seed1]}
+	group2 := []rTreeEntry[E]{entries[seed2]}
+	rect1, rect2 := entries[seed1].rect, entries[seed2].rect
+	rest := make([]rTreeEntry[E], 0, len(entries)-2)
+	for i, entry := range entries {
+		if i != seed1 && i != seed2 {
+			rest = append(rest, entry)
+		}
+	}
+	for len(rest) > 0 {
+		if len(group1)+len(rest) == minEntries {
+			group1 = append(group1, rest...)
+			break
+		}
+		if len(group2)+len(rest) == minEntries {
+			group2 = append(group2, rest...)
+			break
+		}
+		next := 0
+		maxDiff := math.Inf(-1)
+		var nextGrowth1, nextGrowth2 float64
+		for i, entry := range rest {
+			growth1 := rect1.Union(entry.rect).Area() - rect1.Area()
+			growth2 := rect2.Union(entry.rect).Area() - rect2.Area()
+			if diff := math.Abs(growth1 - growth2); diff > maxDiff {
+				next = i
+				maxDiff = diff
+				nextGrowth1, nextGrowth2 = growth1, growth2
+			}
+		}
+		entry := rest[next]
+		rest = append(rest[:next], rest[next+1:]...)
+		toFirst := nextGrowth1 < nextGrowth2
+		if nextGrowth1 == nextGrowth2 {
+			area1, area2 := rect1.Area(), rect2.Area()
+			toFirst = area1 < area2 || (area1 == area2 && len(group1) <= len(group2))
+		}
+		if toFirst {
+			group1 = append(group1, entry)
+			rect1 = rect1.Union(entry.rect)
+		} else {
+			group2 = append(group2, entry)
+			rect2 = rect2.Union(entry.rect)
+		}
+	}
+	node.entries = group1
+	return &rTreeNode[E]{entries: group2}
+}
+
+// remove removes the leaf entry with the rect and value from the subtree,
+// the entries of underflowed nodes are removed and appended to orphans for reinsertion.
+func (node *rTreeNode[E]) remove(rect Rect, value E, nodeLevel, minEntries int, orphans *[]rTreeEntry[E]) bool {
+	for index, entry := range node.entries {
+		if nodeLevel == 0 {
+			if entry.rect == rect && entry.value == value {
+				node.entries = append(node.entries[:index], node.entries[index+1:]...)
+				return true
+			}
+			continue
+		}
+		if !entry.rect.Contains(rect) {
+			continue
+		}
+		if !entry.child.remove(rect, value, nodeLevel-1, minEntries, orphans) {
+			continue
+		}
+		if len(entry.child.entries) < minEntries {
+			*orphans = entry.child.leaves(*orphans, nodeLevel-1)
+			node.entries = append(node.entries[:index], node.entries[index+1:]...)
+		} else {
+			node.entries[index].rect = entry.child.bounds()
+		}
+		return true
+	}
+	return false
+}
+
+// leaves appends the leaf entries of the subtree to entries
+func (node *rTreeNode[E]) leaves(entries []rTreeEntry[E], nodeLevel int) []rTreeEntry[E] {
+	if nodeLevel == 0 {
+		return append(entries, node.entries...)
+	}
+	for _, entry := range node.entries {
+		entries = entry.child.leaves(entries, nodeLevel-1)
+	}
+	return entries
+}
+
+// search runs callback for each leaf entry whose rect matches the filter,
+// branches are skipped when their rect does not intersect the query rect.
+// It returns false when callback returns false.
+func (node *rTreeNode[E]) search(rect Rect, nodeLevel int, filter func(Rect) bool, callback func(rect Rect, value E) bool) bool {
+	for _, entry := range node.entries {
+		if !entry.rect.Intersects(rect) {
+			continue
+		}
+		if nodeLevel == 0 {
+			if filter(entry.rect) && !callback(entry.rect, entry.value) {
+				return false
+			}
+			continue
+		}
+		if !entry.child.search(rect, nodeLevel-1, filter, callback) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,146 @@
+package tree
+
+import (
+	"fmt"
+	"math/rand"
+	"regexp"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func _gridRTree(n int) *RTree[int] {
+	tree := NewRTree[int](4)
+	for i := 0; i < n; i++ {
+		x, y := float64(i%10), float64(i/10)
+		tree.Insert(NewRect(x, y, x+0.5, y+0.5), i)
+	}
+	return tree
+}
+
+func TestRTree_Count(t *testing.T) {
+	tree := _gridRTree(100)
+	assert.EqualValues(t, 100, tree.Count())
+	assert.True(t, tree.IsNotEmpty())
+	tree.Clear()
+	assert.True(t, tree.IsEmpty())
+}
+
+func TestRTree_Search(t *testing.T) {
+	tree := _gridRTree(100)
+	values := tree.Search(NewRect(2.2, 3.2, 4, 4))
+	slices.Sort(values)
+	assert.Equal(t, []int{32, 33, 34, 42, 43, 44}, values)
+	assert.Empty(t, tree.Search(NewRect(20, 20, 30, 30)))
+}
+
+func TestRTree_SearchContained(t *testing.T) {
+	tree := _gridRTree(100)
+	values := tree.SearchContained(NewRect(2.2, 2.9, 4, 5))
+	slices.Sort(values)
+	assert.Equal(t, []int{33, 43}, values)
+}
+
+func TestRTree_SearchContaining(t *testing.T) {
+	tree := _gridRTree(100)
+	tree.Insert(NewRect(0, 0, 10, 10), 100)
+	values := tree.SearchContaining(NewRect(3.1, 3.1, 3.2, 3.2))
+	slices.Sort(values)
+	assert.Equal(t, []int{33, 100}, values)
+}
+
+func TestRTree_Remove(t *testing.T) {
+	tree := _gridRTree(100)
+	assert.False(t, tree.Remove(NewRect(0, 0, 1, 1), 0))
+	for i := 0; i < 100; i += 2 {
+		x, y := float64(i%10), float64(i/10)
+		assert.True(t, tree.Remove(NewRect(x, y, x+0.5, y+0.5), i))
+	}
+	assert.EqualValues(t, 50, tree.Count())
+	values := tree.Search(NewRect(0, 0, 10, 10))
+	slices.Sort(values)
+	for index, value := range values {
+		assert.Equal(t, index*2+1, value)
+	}
+	for i := 1; i < 100; i += 2 {
+		x, y := float64(i%10), float64(i/10)
+		assert.True(t, tree.Remove(NewRect(x, y, x+0.5, y+0.5), i))
+	}
+	assert.True(t, tree.IsEmpty())
+	_, ok := tree.Bounds()
+	assert.False(t, ok)
+}
+
+func TestRTree_Random(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	tree := NewRTree[int](0)
+	rects := map[int]Rect{}
+	for i := 0; i < 1000; i++ {
+		if len(rects) > 0 && random.Intn(3) == 0 {
+			for value, rect := range rects {
+				assert.True(t, tree.Remove(rect, value))
+				delete(rects, value)
+				break
+			}
+			continue
+		}
+		x, y := random.Float64()*100, random.Float64()*100
+		rect := NewRect(x, y, x+random.Float64()*5, y+random.Float64()*5)
+		tree.Insert(rect, i)
+		rects[i] = rect
+	}
+	assert.EqualValues(t, len(rects), tree.Count())
+	query := NewRect(20, 20, 60, 60)
+	var expected []int
+	for value, rect := range rects {
+		if rect.Intersects(query) {
+			expected = append(expected, value)
+		}
+	}
+	actual := tree.Search(query)
+	slices.Sort(expected)
+	slices.Sort(actual)
+	assert.Equal(t, expected, actual)
+}
+
+func TestRTree_Bounds(t *testing.T) {
+	tree := _gridRTree(100)
+	bounds, ok := tree.Bounds()
+	assert.True(t, ok)
+	assert.Equal(t, NewRect(0, 0, 9.5, 9.5), bounds)
+}
+
+func TestRTree_Each(t *testing.T) {
+	tree := _gridRTree(10)
+	var values []int
+	tree.Each(func(rect Rect, value int) bool {
+		values = append(values, value)
+		tree.Remove(rect, value)
+		return len(values) < 5
+	})
+	assert.Len(t, values, 5)
+	assert.EqualValues(t, 5, tree.Count())
+}
+
+func TestRTree_Concurrent(t *testing.T) {
+	tree := NewRTree[int](0)
+	wg := new(sync.WaitGroup)
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			tree.Insert(NewRect(float64(i), 0, float64(i)+1, 1), i)
+			tree.Search(NewRect(0, 0, 10, 10))
+		}(i)
+	}
+	wg.Wait()
+	assert.EqualValues(t, 100, tree.Count())
+}
+
+func TestRTree_String(t *testing.T) {
+	tree := _gridRTree(10)
+	pattern := regexp.MustCompile(fmt.Sprintf(`RTree\[int\]\(len=%d\)\{\n(\t\{[^}]+\}: \d+,\n){5}\t(\.){3}\n\}`, tree.Count()))
+	assert.True(t, pattern.MatchString(tree.String()))
+}
@@ -0,0 +1,377 @@
+package tree
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"log/slog"
+	"slices"
+	"sync"
+
+	"github.com/gopi-frame/collection/internal/preview"
+)
+
+// NewRadixTree new radix tree
+func NewRadixTree[V any]() *RadixTree[V] {
+	return &RadixTree[V]{root: new(radixNode[V])}
+}
+
+// RadixTree compressed prefix tree of byte slice keys, all methods are safe for concurrent use.
+// A chain of nodes with a single child is merged into one node holding the whole segment, so the tree has
+// at most 2n nodes for n keys and a lookup compares segments instead of stepping byte by byte as [Trie] does.
+// Get, ContainsKey, HasPrefix and LongestPrefixMatch do not allocate, which suits routing tables
+// and IP prefix matching. The keys are copied on Insert, so the caller may reuse its buffers.
+type RadixTree[V any] struct {
+	lock  sync.RWMutex
+	root  *radixNode[V]
+	size  int64
+	limit preview.Limit
+}
+
+// radixNode node of a radix tree, the children are sorted by the first byte of their segments which are distinct
+type radixNode[V any] struct {
+	segment  []byte
+	children []*radixNode[V]
+	value    V
+	terminal bool
+}
+
+// child returns the index of the child whose segment starts with the byte and whether it exists
+func (n *radixNode[V]) child(b byte) (int, bool) {
+	return slices.BinarySearchFunc(n.children, b, func(child *radixNode[V], b byte) int {
+		return int(child.segment[0]) - int(b)
+	})
+}
+
+// merge merges the only child into the node
+func (n *radixNode[V]) merge() {
+	child := n.children[0]
+	n.segment = slices.Concat(n.segment, child.segment)
+	n.children, n.value, n.terminal = child.children, child.value, child.terminal
+}
+
+// commonPrefix returns the length of the common prefix of a and b
+func commonPrefix(a, b []byte) int {
+	length := min(len(a), len(b))
+	for index := range length {
+		if a[index] != b[index] {
+			return index
+		}
+	}
+	return length
+}
+
+// find returns the node of the key, it returns nil when the key does not end on a node
+func (t *RadixTree[V]) find(key []byte) *radixNode[V] {
+	n := t.root
+	for len(key) > 0 {
+		index, found := n.child(key[0])
+		if !found || !bytes.HasPrefix(key, n.children[index].segment) {
+			return nil
+		}
+		n = n.children[index]
+		key = key[len(n.segment):]
+	}
+	return n
+}
+
+// Count returns the number of keys
+func (t *RadixTree[V]) Count() int64 {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	return t.size
+}
+
+// IsEmpty returns whether the tree is empty
+func (t *RadixTree[V]) IsEmpty() bool {
+	return t.Count() == 0
+}
+
+// IsNotEmpty returns whether the tree is not empty
+func (t *RadixTree[V]) IsNotEmpty() bool {
+	return !t.IsEmpty()
+}
+
+// Insert sets the value to the specific key, it returns true when the key is new
+func (t *RadixTree[V]) Insert(key []byte, value V) bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	n := t.root
+	for len(key) > 0 {
+		index, found := n.child(key[0])
+		if !found {
+			leaf := &radixNode[V]{segment: slices.Clone(key), value: value, terminal: true}
+			n.children = slices.Insert(n.children, index, leaf)
+			t.size++
+			return true
+		}
+		child := n.children[index]
+		common := commonPrefix(child.segment, key)
+		if common < len(child.segment) {
+			split := &radixNode[V]{segment: child.segment[:common:common], children: []*radixNode[V]{child}}
+			child.segment = child.segment[common:]
+			n.children[index] = split
+			child = split
+		}
+		n, key = child, key[common:]
+	}
+	added := !n.terminal
+	n.value, n.terminal = value, true
+	if added {
+		t.size++
+	}
+	return added
+}
+
+// Get returns the value of the specific key.
+// A zero value and false will be returned when the key is not exist.
+func (t *RadixTree[V]) Get(key []byte) (V, bool) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	if n := t.find(key); n != nil && n.terminal {
+		return n.value, true
+	}
+	return *new(V), false
+}
+
+// GetOr returns the value of the specific key, or the default value when the key is not exist
+func (t *RadixTree[V]) GetOr(key []byte, value V) V {
+	if v, ok := t.Get(key); ok {
+		return v
+	}
+	return value
+}
+
+// ContainsKey returns whether the tree contains the specific key
+func (t *RadixTree[V]) ContainsKey(key []byte) bool {
+	_, ok := t.Get(key)
+	return ok
+}
+
+// Delete removes the specific key, the nodes left with a single child are merged back into one.
+// It returns false when the tree does not contain the key.
+func (t *RadixTree[V]) Delete(key []byte) bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	var parent *radixNode[V]
+	n := t.root
+	for len(key) > 0 {
+		index, found := n.child(key[0])
+		if !found || !bytes.HasPrefix(key, n.children[index].segment) {
+			return false
+		}
+		parent, n = n, n.children[index]
+		key = key[len(n.segment):]
+	}
+	if !n.terminal {
+		return false
+	}
+	n.value, n.terminal = *new(V), false
+	t.size--
+	switch {
+	case n == t.root:
+	case len(n.children) == 1:
+		n.merge()
+	case len(n.children) == 0:
+		index, _ := parent.child(n.segment[0])
+		parent.children = slices.Delete(parent.children, index, index+1)
+		if parent != t.root && !parent.terminal && len(parent.children) == 1 {
+			parent.merge()
+		}
+	}
+	return true
+}
+
+// HasPrefix returns whether the tree contains any key starting with the prefix
+func (t *RadixTree[V]) HasPrefix(prefix []byte) bool {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	n := t.root
+	for len(prefix) > 0 {
+		index, found := n.child(prefix[0])
+		if !found {
+			return false
+		}
+		n = n.children[index]
+		if len(prefix) <= len(n.segment) {
+			return bytes.HasPrefix(n.segment, prefix)
+		}
+		if !bytes.HasPrefix(prefix, n.segment) {
+			return false
+		}
+		prefix = prefix[len(n.segment):]
+	}
+	return n.terminal || len(n.children) > 0
+}
+
+// LongestPrefixMatch returns the longest key which is a prefix of the specific key and its value,
+// such as the most specific network of an address. The returned key is a subslice of the specific key.
+// It returns false when no key is a prefix of it.
+func (t *RadixTree[V]) LongestPrefixMatch(key []byte) ([]byte, V, bool) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	length, value, found := 0, *new(V), false
+	n, consumed := t.root, 0
+	for {
+		if n.terminal {
+			length, value, found = consumed, n.value, true
+		}
+		if consumed == len(key) {
+			break
+		}
+		index, ok := n.child(key[consumed])
+		if !ok || !bytes.HasPrefix(key[consumed:], n.children[index].segment) {
+			break
+		}
+		n = n.children[index]
+		consumed += len(n.segment)
+	}
+	if !found {
+		return nil, value, false
+	}
+	return key[:length], value, true
+}
+
+// Walk runs callback for each key starting with the prefix in lexicographic order, it breaks when callback false.
+// It walks over a snapshot of the keys, so the callback is allowed to modify the tree and keep the keys.
+func (t *RadixTree[V]) Walk(prefix []byte, callback func(key []byte, value V) bool) {
+	keys, values := t.snapshot(prefix)
+	for index, key := range keys {
+		if !callback(key, values[index]) {
+			return
+		}
+	}
+}
+
+// snapshot returns copies of the keys starting with the prefix and their values in lexicographic order
+func (t *RadixTree[V]) snapshot(prefix []byte) ([][]byte, []V) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	var keys [][]byte
+	var values []V
+	n, path := t.root, make([]byte, 0, len(prefix))
+	for remaining := prefix; len(remaining) > 0; {
+		index, found := n.child(remaining[0])
+		if !found {
+			return keys, values
+		}
+		n = n.children[index]
+		if len(remaining) <= len(n.segment) {
+			if !bytes.HasPrefix(n.segment, remaining) {
+				return keys, values
+			}
+		} else if !bytes.HasPrefix(remaining, n.segment) {
+			return keys, values
+		}
+		path = append(path, n.segment...)
+		remaining = remaining[min(len(remaining), len(n.segment)):]
+	}
+	var walk func(n *radixNode[V], key []byte)
+	walk = func(n *radixNode[V], key []byte) {
+		if n.terminal {
+			keys = append(keys, slices.Clone(key))
+			values = append(values, n.value)
+		}
+		for _, child := range n.children {
+			walk(child, append(key, child.segment...))
+		}
+	}
+	walk(n, path)
+	return keys, values
+}
+
+// All returns an iterator over a snapshot of the keys and the values in lexicographic order,
+// so the loop body is allowed to modify the tree
+func (t *RadixTree[V]) All() iter.Seq2[[]byte, V] {
+	return func(yield func([]byte, V) bool) {
+		t.Walk(nil, yield)
+	}
+}
+
+// Keys returns copies of the keys in lexicographic order
+func (t *RadixTree[V]) Keys() [][]byte {
+	keys, _ := t.snapshot(nil)
+	return keys
+}
+
+// Values returns the values in the order of the keys
+func (t *RadixTree[V]) Values() []V {
+	_, values := t.snapshot(nil)
+	return values
+}
+
+// Clear clears the tree
+func (t *RadixTree[V]) Clear() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.root = new(radixNode[V])
+	t.size = 0
+}
+
+// ToJSON converts to json, the entries are encoded as an array in key order and the keys as base64 strings,
+// so the keys which are not valid utf-8 survive the round trip
+func (t *RadixTree[V]) ToJSON() ([]byte, error) {
+	keys, values := t.snapshot(nil)
+	entries := make([]Entry[[]byte, V], len(keys))
+	for index, key := range keys {
+		entries[index] = Entry[[]byte, V]{Key: key, Value: values[index]}
+	}
+	return json.Marshal(entries)
+}
+
+// MarshalJSON implements [json.Marshaller]
+func (t *RadixTree[V]) MarshalJSON() ([]byte, error) {
+	return t.ToJSON()
+}
+
+// UnmarshalJSON implements [json.Unmarshaller], the keys are inserted into the tree
+func (t *RadixTree[V]) UnmarshalJSON(data []byte) error {
+	var entries []Entry[[]byte, V]
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return err
+	}
+	if t.root == nil {
+		t.root = new(radixNode[V])
+	}
+	for _, entry := range entries {
+		t.Insert(entry.Key, entry.Value)
+	}
+	return nil
+}
+
+// SetPreviewLimit sets the number of entries shown by String, %v and LogValue, a negative limit shows all entries
+func (t *RadixTree[V]) SetPreviewLimit(limit int) {
+	t.limit.Set(limit)
+}
+
+// String converts to string, only the preview limit of entries are shown, the keys are shown as quoted strings
+func (t *RadixTree[V]) String() string {
+	return t.format(t.limit.Get(-1))
+}
+
+// Format implements [fmt.Formatter], %v prints the preview as String does, %+v and %#v print all entries
+func (t *RadixTree[V]) Format(f fmt.State, verb rune) {
+	preview.Format(f, verb, t.format, t.limit.Get(-1))
+}
+
+// previewKeys returns the keys as quoted strings
+func (t *RadixTree[V]) previewKeys() ([]string, []V) {
+	keys, values := t.snapshot(nil)
+	quoted := make([]string, len(keys))
+	for index, key := range keys {
+		quoted[index] = fmt.Sprintf("%q", key)
+	}
+	return quoted, values
+}
+
+func (t *RadixTree[V]) format(limit int) string {
+	keys, values := t.previewKeys()
+	return preview.Entries(fmt.Sprintf("RadixTree[%T](len=%d)", *new(V), len(keys)), keys, values, limit)
+}
+
+// LogValue implements [slog.LogValuer], only the preview limit of entries are logged
+func (t *RadixTree[V]) LogValue() slog.Value {
+	keys, values := t.previewKeys()
+	return preview.LogEntries(fmt.Sprintf("RadixTree[%T]", *new(V)), len(keys), keys, values, t.limit.Get(preview.DefaultLimit))
+}
@@ -0,0 +1,241 @@
+package tree
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func networks() *RadixTree[string] {
+	tree := NewRadixTree[string]()
+	tree.Insert([]byte{10}, "10/8")
+	tree.Insert([]byte{10, 1}, "10.1/16")
+	tree.Insert([]byte{10, 1, 2}, "10.1.2/24")
+	tree.Insert([]byte{192, 168}, "192.168/16")
+	return tree
+}
+
+// radixKeys returns the keys of the tree as strings
+func radixKeys[V any](tree *RadixTree[V]) []string {
+	var keys []string
+	for _, key := range tree.Keys() {
+		keys = append(keys, string(key))
+	}
+	return keys
+}
+
+func TestRadixTree_Insert(t *testing.T) {
+	tree := NewRadixTree[int]()
+	assert.True(t, tree.Insert([]byte("romane"), 1))
+	assert.True(t, tree.Insert([]byte("romanus"), 2))
+	assert.True(t, tree.Insert([]byte("romulus"), 3))
+	assert.True(t, tree.Insert([]byte("rom"), 4))
+	assert.True(t, tree.Insert(nil, 0))
+	assert.False(t, tree.Insert([]byte("romane"), 5))
+	assert.Equal(t, int64(5), tree.Count())
+	assert.Equal(t, []string{"", "rom", "romane", "romanus", "romulus"}, radixKeys(tree))
+	assert.Equal(t, 5, tree.GetOr([]byte("romane"), -1))
+
+	t.Run("compressed", func(t *testing.T) {
+		assert.Len(t, tree.root.children, 1)
+		assert.Equal(t, "rom", string(tree.root.children[0].segment))
+	})
+
+	t.Run("copies key", func(t *testing.T) {
+		tree := NewRadixTree[int]()
+		key := []byte("abc")
+		tree.Insert(key, 1)
+		key[0] = 'x'
+		assert.True(t, tree.ContainsKey([]byte("abc")))
+		assert.False(t, tree.ContainsKey(key))
+	})
+}
+
+func TestRadixTree_Get(t *testing.T) {
+	tree := networks()
+	value, ok := tree.Get([]byte{10, 1})
+	assert.True(t, ok)
+	assert.Equal(t, "10.1/16", value)
+	_, ok = tree.Get([]byte{192})
+	assert.False(t, ok)
+	_, ok = tree.Get([]byte{10, 1, 2, 3})
+	assert.False(t, ok)
+	assert.False(t, tree.ContainsKey(nil))
+	assert.Equal(t, "none", tree.GetOr([]byte{172}, "none"))
+}
+
+func TestRadixTree_Delete(t *testing.T) {
+	tree := NewRadixTree[int]()
+	for index, key := range []string{"team", "test", "toast", "te"} {
+		tree.Insert([]byte(key), index)
+	}
+	assert.False(t, tree.Delete([]byte("tea")))
+	assert.False(t, tree.Delete([]byte("t")))
+	assert.True(t, tree.Delete([]byte("te")))
+	assert.False(t, tree.Delete([]byte("te")))
+	assert.Equal(t, []string{"team", "test", "toast"}, radixKeys(tree))
+
+	t.Run("merges nodes", func(t *testing.T) {
+		assert.True(t, tree.Delete([]byte("team")))
+		assert.True(t, tree.Delete([]byte("toast")))
+		assert.Len(t, tree.root.children, 1)
+		assert.Equal(t, "test", string(tree.root.children[0].segment))
+		assert.True(t, tree.Delete([]byte("test")))
+		assert.True(t, tree.IsEmpty())
+		assert.Empty(t, tree.root.children)
+	})
+
+	t.Run("random keys", func(t *testing.T) {
+		tree := NewRadixTree[int]()
+		expected := map[string]int{}
+		for range 3000 {
+			key := fmt.Sprint(rand.IntN(2000))
+			if rand.IntN(2) == 0 {
+				_, exists := expected[key]
+				assert.Equal(t, !exists, tree.Insert([]byte(key), len(key)))
+				expected[key] = len(key)
+			} else {
+				_, exists := expected[key]
+				assert.Equal(t, exists, tree.Delete([]byte(key)))
+				delete(expected, key)
+			}
+		}
+		keys := make([]string, 0, len(expected))
+		for key := range expected {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+		assert.Equal(t, keys, radixKeys(tree))
+		assert.Equal(t, int64(len(expected)), tree.Count())
+	})
+}
+
+func TestRadixTree_HasPrefix(t *testing.T) {
+	tree := NewRadixTree[int]()
+	tree.Insert([]byte("/api/users"), 1)
+	assert.True(t, tree.HasPrefix(nil))
+	assert.True(t, tree.HasPrefix([]byte("/ap")))
+	assert.True(t, tree.HasPrefix([]byte("/api/users")))
+	assert.False(t, tree.HasPrefix([]byte("/api/users/1")))
+	assert.False(t, tree.HasPrefix([]byte("/apx")))
+	assert.False(t, NewRadixTree[int]().HasPrefix(nil))
+}
+
+func TestRadixTree_LongestPrefixMatch(t *testing.T) {
+	tree := networks()
+	key, value, ok := tree.LongestPrefixMatch([]byte{10, 1, 2, 3})
+	assert.True(t, ok)
+	assert.Equal(t, []byte{10, 1, 2}, key)
+	assert.Equal(t, "10.1.2/24", value)
+
+	key, value, ok = tree.LongestPrefixMatch([]byte{10, 1, 9, 9})
+	assert.True(t, ok)
+	assert.Equal(t, []byte{10, 1}, key)
+	assert.Equal(t, "10.1/16", value)
+
+	key, _, ok = tree.LongestPrefixMatch([]byte{10, 9, 9, 9})
+	assert.True(t, ok)
+	assert.Equal(t, []byte{10}, key)
+
+	_, _, ok = tree.LongestPrefixMatch([]byte{192, 1, 1, 1})
+	assert.False(t, ok)
+
+	t.Run("no allocation", func(t *testing.T) {
+		address := []byte{10, 1, 2, 3}
+		allocs := testing.AllocsPerRun(100, func() {
+			tree.LongestPrefixMatch(address)
+			tree.Get(address)
+		})
+		assert.Zero(t, allocs)
+	})
+}
+
+func TestRadixTree_Walk(t *testing.T) {
+	tree := NewRadixTree[int]()
+	for index, key := range []string{"/users", "/users/admin", "/uploads", "/posts"} {
+		tree.Insert([]byte(key), index)
+	}
+	var keys []string
+	tree.Walk([]byte("/u"), func(key []byte, value int) bool {
+		keys = append(keys, string(key))
+		return true
+	})
+	assert.Equal(t, []string{"/uploads", "/users", "/users/admin"}, keys)
+
+	t.Run("prefix inside segment", func(t *testing.T) {
+		var keys []string
+		tree.Walk([]byte("/users/a"), func(key []byte, value int) bool {
+			keys = append(keys, string(key))
+			return true
+		})
+		assert.Equal(t, []string{"/users/admin"}, keys)
+	})
+
+	t.Run("missing prefix", func(t *testing.T) {
+		for _, prefix := range []string{"/x", "/usx", "/users/admins"} {
+			tree.Walk([]byte(prefix), func(key []byte, value int) bool {
+				assert.Fail(t, "unexpected key")
+				return true
+			})
+		}
+	})
+
+	t.Run("break", func(t *testing.T) {
+		count := 0
+		tree.Walk(nil, func(key []byte, value int) bool {
+			count++
+			return false
+		})
+		assert.Equal(t, 1, count)
+	})
+}
+
+func TestRadixTree_All(t *testing.T) {
+	tree := networks()
+	var values []string
+	for _, value := range tree.All() {
+		values = append(values, value)
+	}
+	assert.Equal(t, []string{"10/8", "10.1/16", "10.1.2/24", "192.168/16"}, values)
+	assert.Equal(t, values, tree.Values())
+}
+
+func TestRadixTree_Clear(t *testing.T) {
+	tree := networks()
+	tree.Clear()
+	assert.True(t, tree.IsEmpty())
+	assert.Empty(t, tree.Keys())
+}
+
+func TestRadixTree_ToJSON(t *testing.T) {
+	tree := NewRadixTree[int]()
+	tree.Insert([]byte("a"), 1)
+	tree.Insert([]byte{0xff}, 2)
+	data, err := tree.ToJSON()
+	assert.NoError(t, err)
+	assert.JSONEq(t, `[{"key":"YQ==","value":1},{"key":"/w==","value":2}]`, string(data))
+}
+
+func TestRadixTree_UnmarshalJSON(t *testing.T) {
+	data, err := json.Marshal(networks())
+	assert.NoError(t, err)
+	tree := new(RadixTree[string])
+	assert.NoError(t, json.Unmarshal(data, tree))
+	assert.Equal(t, networks().Keys(), tree.Keys())
+	assert.Equal(t, networks().Values(), tree.Values())
+
+	t.Run("invalid json", func(t *testing.T) {
+		assert.Error(t, json.Unmarshal([]byte(`{}`), tree))
+	})
+}
+
+func TestRadixTree_String(t *testing.T) {
+	tree := NewRadixTree[int]()
+	tree.Insert([]byte("b"), 2)
+	tree.Insert([]byte("a"), 1)
+	assert.Equal(t, "RadixTree[int](len=2){\n\t\"a\": 1,\n\t\"b\": 2,\n}", tree.String())
+}
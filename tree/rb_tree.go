@@ -1,11 +1,23 @@
 package tree
 
 import (
+	"cmp"
 	"encoding/json"
 	"fmt"
-	"strings"
+	"io"
+	"iter"
+	"log/slog"
+	"math"
+	"math/bits"
+	"slices"
 	"sync"
 
+	"github.com/fxamacker/cbor/v2"
+	"github.com/gopi-frame/collection"
+	"github.com/gopi-frame/collection/comparator"
+	"github.com/gopi-frame/collection/internal/debug"
+	"github.com/gopi-frame/collection/internal/jsonstream"
+	"github.com/gopi-frame/collection/internal/preview"
 	"github.com/gopi-frame/contract"
 )
 
@@ -13,30 +25,80 @@ import (
 func NewRBTree[E any](comparator contract.Comparator[E], values ...E) *RBTree[E] {
 	tree := new(RBTree[E])
 	tree.comparator = comparator
-	tree.Push(values...)
+	tree.push(values...)
 	return tree
 }
 
-// RBTree red black tree
+// NewRBTreeOrdered new rb tree of ordered elements, the elements are compared with [cmp.Compare]
+func NewRBTreeOrdered[E cmp.Ordered](values ...E) *RBTree[E] {
+	return NewRBTree[E](comparator.Ordered[E](), values...)
+}
+
+// NewRBTreeWithPolicy new rb tree with the policy to handle duplicate elements
+func NewRBTreeWithPolicy[E any](comparator contract.Comparator[E], policy DuplicatePolicy, values ...E) *RBTree[E] {
+	tree := new(RBTree[E])
+	tree.comparator = comparator
+	tree.policy = policy
+	tree.push(values...)
+	return tree
+}
+
+// NewRBTreeFromSorted new rb tree from values sorted in comparator order.
+// The tree is built in O(n) instead of inserting the values one by one.
+func NewRBTreeFromSorted[E any](comparator contract.Comparator[E], sorted []E) *RBTree[E] {
+	tree := new(RBTree[E])
+	tree.comparator = comparator
+	tree.bulkLoad(sorted)
+	return tree
+}
+
+// NewRBTreeWithAggregate new rb tree which keeps the aggregate of the measures of the elements in each subtree,
+// so AggregateBetween folds any range in O(log n) time, see [Aggregate]
+func NewRBTreeWithAggregate[E any](comparator contract.Comparator[E], aggregate *Aggregate[E], values ...E) *RBTree[E] {
+	tree := new(RBTree[E])
+	tree.comparator = comparator
+	tree.aggregate = aggregate
+	tree.push(values...)
+	return tree
+}
+
+// RBTree red black tree, all methods are safe for concurrent use
 type RBTree[E any] struct {
-	sync.RWMutex
+	lock       sync.RWMutex
 	root       *rbNode[E]
+	size       int64
 	comparator contract.Comparator[E]
+	policy     DuplicatePolicy
+	limit      preview.Limit
+	pool       *nodePool[rbNode[E]]
+	aggregate  *Aggregate[E]
 }
 
+// Count returns the size of tree
 func (t *RBTree[E]) Count() int64 {
-	return int64(len(t.root.inOrderRange()))
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	return t.size
 }
 
+// IsEmpty returns whether the tree is empty
 func (t *RBTree[E]) IsEmpty() bool {
-	return t.Count() == 0
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	return t.size == 0
 }
 
+// IsNotEmpty returns whether the tree is not empty
 func (t *RBTree[E]) IsNotEmpty() bool {
-	return t.Count() > 0
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	return t.size > 0
 }
 
+// Contains returns whether the tree contains the specific element
 func (t *RBTree[E]) Contains(value E) bool {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
 	if t.root == nil {
 		return false
 	}
@@ -46,21 +108,72 @@ func (t *RBTree[E]) Contains(value E) bool {
 	return true
 }
 
+// Push pushes elements into the tree
 func (t *RBTree[E]) Push(values ...E) *RBTree[E] {
+	t.lock.Lock()
+	defer t.unlock()
+	t.push(values...)
+	return t
+}
+
+// Add adds the element into the tree and reports whether it is inserted according to the duplicate policy
+func (t *RBTree[E]) Add(value E) bool {
+	t.lock.Lock()
+	defer t.unlock()
+	return t.push(value) > 0
+}
+
+func (t *RBTree[E]) push(values ...E) int {
+	inserted := 0
 	for _, value := range values {
-		t.root = t.root.insert(value, t.comparator)
-		t.root.color = black
+		if t.insert(value, 1) {
+			inserted++
+		}
 	}
-	return t
+	return inserted
 }
 
+// insert inserts the value with the weight according to the duplicate policy and reports whether it is inserted
+func (t *RBTree[E]) insert(value E, weight float64) bool {
+	var ok bool
+	t.root, ok = t.root.insert(value, weight, t.comparator, t.policy, t.pool, t.aggregate)
+	t.root.color = black
+	t.size = int64(t.root.getSize())
+	return ok
+}
+
+// BulkLoad replaces the elements of the tree with the sorted values in O(n).
+// The values are pushed one by one if they are not sorted in comparator order.
+func (t *RBTree[E]) BulkLoad(sorted []E) {
+	t.lock.Lock()
+	defer t.unlock()
+	t.bulkLoad(sorted)
+}
+
+func (t *RBTree[E]) bulkLoad(values []E) {
+	runs, size, ok := sortedRuns(values, t.comparator, t.policy)
+	if !ok {
+		t.root = nil
+		t.size = 0
+		t.push(values...)
+		return
+	}
+	t.root = buildRBNode(runs, bits.Len(uint(len(runs)+1))-1, t.aggregate)
+	t.size = int64(size)
+}
+
+// Remove removes the specific element from the tree
 func (t *RBTree[E]) Remove(value E) *RBTree[E] {
+	t.lock.Lock()
+	defer t.unlock()
 	if t.root == nil {
 		return t
 	}
-	if t.root.find(value, t.comparator) == nil {
+	node := t.root.find(value, t.comparator)
+	if node == nil {
 		return t
 	}
+	t.size -= int64(node.count)
 	if t.root.left.isBlack() && t.root.right.isBlack() {
 		t.root.color = red
 	}
@@ -71,16 +184,209 @@ func (t *RBTree[E]) Remove(value E) *RBTree[E] {
 	return t
 }
 
+// RemoveBetween removes the elements in the range [from, to) and returns the number of removed elements, counting duplicates.
+// The range is cut out with two splits and a merge, so it takes O(log n) time however many elements it holds.
+// It removes nothing when from is not less than to.
+func (t *RBTree[E]) RemoveBetween(from, to E) int64 {
+	t.lock.Lock()
+	defer t.unlock()
+	if t.comparator.Compare(from, to) >= 0 {
+		return 0
+	}
+	left, rest := splitRB(t.root, from, t.comparator)
+	removed, right := splitRB(rest, to, t.comparator)
+	t.root = mergeRB(left, right)
+	t.size = int64(t.root.getSize())
+	return int64(removed.getSize())
+}
+
+// Split splits the tree into two trees in O(log n) time,
+// the first one contains the elements less than the value
+// and the second one contains the elements greater than or equal to the value.
+// The elements are moved into the new trees, which leaves the tree empty.
+func (t *RBTree[E]) Split(value E) (*RBTree[E], *RBTree[E]) {
+	t.lock.Lock()
+	defer t.unlock()
+	left, right := splitRB(t.root, value, t.comparator)
+	t.root = nil
+	t.size = 0
+	return t.derive(left), t.derive(right)
+}
+
+// derive returns a new tree of the root which keeps the settings of the tree,
+// the comparator, the duplicate policy, the aggregate, the node pool and the preview limit
+func (t *RBTree[E]) derive(root *rbNode[E]) *RBTree[E] {
+	tree := &RBTree[E]{root: root, size: int64(root.getSize()), comparator: t.comparator, policy: t.policy, pool: t.pool, aggregate: t.aggregate}
+	tree.limit.CopyFrom(&t.limit)
+	return tree
+}
+
+// Merge moves all elements of the other tree into the tree, which leaves the other tree empty.
+// It takes O(log n) time when the elements of the two trees are in disjoint ranges and the trees share the aggregate,
+// otherwise the elements of the other tree are pushed one by one.
+func (t *RBTree[E]) Merge(other *RBTree[E]) {
+	if t == other {
+		return
+	}
+	other.lock.Lock()
+	root, size := other.root, other.size
+	other.root = nil
+	other.size = 0
+	other.lock.Unlock()
+	if root == nil {
+		return
+	}
+	t.lock.Lock()
+	defer t.unlock()
+	if root.aggregator != t.aggregate {
+		for _, node := range root.inOrderRange() {
+			t.insert(node.value, node.weight/float64(node.count))
+		}
+		return
+	}
+	if t.root == nil {
+		t.root = root
+	} else if t.comparator.Compare(t.root.max().value, root.min().value) < 0 {
+		t.root = mergeRB(t.root, root)
+	} else if t.comparator.Compare(root.max().value, t.root.min().value) < 0 {
+		t.root = mergeRB(root, t.root)
+	} else {
+		for _, node := range root.inOrderRange() {
+			t.insert(node.value, node.weight/float64(node.count))
+		}
+		return
+	}
+	t.size += size
+}
+
+// Sub returns a new tree with the elements in the range [from, to), like the Sub method of list.
+// The new tree keeps the settings of the tree, such as the comparator, the duplicate policy, the node pool and the preview limit,
+// and it is built in O(k) for k elements after the range is located.
+func (t *RBTree[E]) Sub(from, to E) *RBTree[E] {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	runs := t.root.rangeRuns(from, to, t.comparator, nil)
+	return t.derive(buildRBNode(runs, bits.Len(uint(len(runs)+1))-1, t.aggregate))
+}
+
+// CountBetween returns the number of elements in the range [from, to), counting duplicates,
+// it takes O(log n) time using the subtree sizes, so histograms and percentiles are computed without walking the range.
+// It returns 0 when from is not less than to.
+func (t *RBTree[E]) CountBetween(from, to E) int64 {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	if t.comparator.Compare(from, to) >= 0 {
+		return 0
+	}
+	return int64(t.root.countLess(to, t.comparator) - t.root.countLess(from, t.comparator))
+}
+
+// AggregateBetween returns the aggregate of the elements in the range [from, to) in O(log n) time,
+// the subtree aggregates cover the middle of the range, so only the paths to its bounds are walked.
+// It returns the identity of the aggregate when the range is empty, and 0 when the tree is created without an aggregate.
+func (t *RBTree[E]) AggregateBetween(from, to E) float64 {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	if t.aggregate == nil {
+		return 0
+	}
+	if t.comparator.Compare(from, to) >= 0 {
+		return t.aggregate.Identity
+	}
+	return t.root.aggregateBetween(from, to, t.comparator, t.aggregate)
+}
+
+// PushWeighted pushes the element with the weight, which the weighted methods count instead of 1,
+// equal elements add their weights up when duplicates are allowed, and the weight is replaced with the element when they are replaced.
+// Push gives each element the weight 1, and so do the decoders, as the serialized forms keep only the elements.
+// It panics with [ErrInvalidWeight] when the weight is negative, infinite or NaN.
+func (t *RBTree[E]) PushWeighted(value E, weight float64) *RBTree[E] {
+	checkWeight(weight)
+	t.lock.Lock()
+	defer t.unlock()
+	t.insert(value, weight)
+	return t
+}
+
+// Weight returns the weight of the element, the sum of the weights of its duplicates, or 0 when it does not exist
+func (t *RBTree[E]) Weight(value E) float64 {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	if node := t.root.find(value, t.comparator); node != nil {
+		return node.weight
+	}
+	return 0
+}
+
+// TotalWeight returns the sum of the weights of all elements
+func (t *RBTree[E]) TotalWeight() float64 {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	return t.root.getTotal()
+}
+
+// WeightedRank returns the total weight of the elements less than the value in O(log n) time,
+// so the fraction of the total weight below the value is WeightedRank(value) / TotalWeight().
+func (t *RBTree[E]) WeightedRank(value E) float64 {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	return t.root.weightLess(value, t.comparator)
+}
+
+// SelectByWeight returns the least element whose cumulative weight, from the least element up to and including it,
+// is greater than the weight in O(log n) time, so SelectByWeight(q * TotalWeight()) is the weighted q quantile
+// and elements with the weight 0 are never selected.
+// It returns a zero value and false when the weight is negative or not less than the total weight.
+func (t *RBTree[E]) SelectByWeight(weight float64) (E, bool) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	if node := t.root.selectWeight(weight); node != nil {
+		return node.value, true
+	}
+	return *new(E), false
+}
+
+// Clear clears the tree
 func (t *RBTree[E]) Clear() *RBTree[E] {
+	t.lock.Lock()
+	defer t.unlock()
 	t.root = nil
+	t.size = 0
+	return t
+}
+
+// EnableNodePool makes the tree take its nodes from a [sync.Pool], Release gives them back to it.
+// The nodes of removed elements are not reused.
+func (t *RBTree[E]) EnableNodePool() *RBTree[E] {
+	t.lock.Lock()
+	defer t.unlock()
+	if t.pool == nil {
+		t.pool = newNodePool[rbNode[E]]()
+	}
 	return t
 }
 
+// Release clears the tree and gives its nodes back to the pool, it works as Clear when the pool is not enabled.
+// It must not run while the tree is iterated.
+func (t *RBTree[E]) Release() *RBTree[E] {
+	t.lock.Lock()
+	defer t.unlock()
+	t.root.release(t.pool)
+	t.root = nil
+	t.size = 0
+	return t
+}
+
+// Comparator returns the comparator of the tree
 func (t *RBTree[E]) Comparator() contract.Comparator[E] {
 	return t.comparator
 }
 
+// First returns the first element of the tree.
+// It returns zero value and false when the tree is empty.
 func (t *RBTree[E]) First() (E, bool) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
 	if t.root == nil {
 		return *new(E), false
 	}
@@ -88,15 +394,19 @@ func (t *RBTree[E]) First() (E, bool) {
 	return value, true
 }
 
+// FirstOr returns the first element of the tree or the default value if the tree is empty
 func (t *RBTree[E]) FirstOr(value E) E {
-	if t.root == nil {
-		return value
+	if v, ok := t.First(); ok {
+		return v
 	}
-	v := t.root.min().value
-	return v
+	return value
 }
 
+// Last returns the last element of the tree.
+// It returns zero value and false when the tree is empty
 func (t *RBTree[E]) Last() (E, bool) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
 	if t.root == nil {
 		return *new(E), false
 	}
@@ -104,28 +414,169 @@ func (t *RBTree[E]) Last() (E, bool) {
 	return value, true
 }
 
+// LastOr returns the last element of the tree or the default value if the tree is empty
 func (t *RBTree[E]) LastOr(value E) E {
-	if t.root == nil {
-		return value
+	if v, ok := t.Last(); ok {
+		return v
+	}
+	return value
+}
+
+// Next returns the smallest element greater than the value, whether or not the value exists in the tree.
+// It returns zero value and false when there is no such element.
+func (t *RBTree[E]) Next(value E) (E, bool) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	if node := t.root.higher(value, t.comparator); node != nil {
+		return node.value, true
+	}
+	return *new(E), false
+}
+
+// Prev returns the largest element less than the value, whether or not the value exists in the tree.
+// It returns zero value and false when there is no such element.
+func (t *RBTree[E]) Prev(value E) (E, bool) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	if node := t.root.lower(value, t.comparator); node != nil {
+		return node.value, true
+	}
+	return *new(E), false
+}
+
+// Floor returns the largest element less than or equal to the value.
+// It returns zero value and false when there is no such element.
+func (t *RBTree[E]) Floor(value E) (E, bool) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	if node := t.root.floor(value, t.comparator); node != nil {
+		return node.value, true
+	}
+	return *new(E), false
+}
+
+// Ceiling returns the smallest element greater than or equal to the value.
+// It returns zero value and false when there is no such element.
+func (t *RBTree[E]) Ceiling(value E) (E, bool) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	if node := t.root.ceiling(value, t.comparator); node != nil {
+		return node.value, true
+	}
+	return *new(E), false
+}
+
+// Closest returns the element closest to the value, the distance is the absolute value of the comparator result.
+// The floor wins when the floor and the ceiling are equally close, so comparators which only return -1, 0 and 1
+// always snap to the floor unless there is no floor.
+// It returns zero value and false when the tree is empty.
+func (t *RBTree[E]) Closest(value E) (E, bool) {
+	return t.ClosestFunc(value, func(a, b E) float64 {
+		return float64(t.comparator.Compare(a, b))
+	})
+}
+
+// ClosestFunc returns the element closest to the value by the distance function, it chooses between the floor and the ceiling.
+// The floor wins when they are equally close.
+// It returns zero value and false when the tree is empty.
+func (t *RBTree[E]) ClosestFunc(value E, distance func(a, b E) float64) (E, bool) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	floor := t.root.floor(value, t.comparator)
+	ceiling := t.root.ceiling(value, t.comparator)
+	switch {
+	case floor == nil && ceiling == nil:
+		return *new(E), false
+	case floor == nil:
+		return ceiling.value, true
+	case ceiling == nil:
+		return floor.value, true
+	case math.Abs(distance(value, ceiling.value)) < math.Abs(distance(value, floor.value)):
+		return ceiling.value, true
+	default:
+		return floor.value, true
 	}
-	v := t.root.max().value
-	return v
 }
 
+// Each runs callback for each element, it breaks when callback returns false.
+// The callback runs on a snapshot of the tree, so it is allowed to modify the tree.
 func (t *RBTree[E]) Each(callback func(_ int, value E) bool) {
-	for index, node := range t.root.inOrderRange() {
-		if !callback(index, node.value) {
+	for index, value := range t.ToArray() {
+		if !callback(index, value) {
 			break
 		}
 	}
 }
 
+// WalkParallel runs fn for each element with the given number of goroutines,
+// the elements are partitioned by their positions so each goroutine walks a contiguous range of the tree.
+// The number of CPUs is used when workers is not positive.
+// It stops on the first error and returns it. The tree is read locked during the walk, so fn must not modify it.
+func (t *RBTree[E]) WalkParallel(workers int, fn func(E) error) error {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	return walkParallel(int(t.size), workers, t.root.walkIndex, fn)
+}
+
+// EachReverse runs callback for each element from the last one to the first one,
+// it breaks when callback returns false.
+// The index is the visiting order, the elements are visited lazily, so it is allowed to modify the tree.
+func (t *RBTree[E]) EachReverse(callback func(index int, value E) bool) {
+	t.lock.RLock()
+	var node *rbNode[E]
+	if t.root != nil {
+		node = t.root.max()
+	}
+	for index := 0; node != nil; {
+		value, count := node.value, node.count
+		t.lock.RUnlock()
+		for i := 0; i < count; i++ {
+			if !callback(index, value) {
+				return
+			}
+			index++
+		}
+		t.lock.RLock()
+		node = t.root.lower(value, t.comparator)
+	}
+	t.lock.RUnlock()
+}
+
+// Clone clones the tree, the clone keeps the settings of the tree such as the node pool and the preview limit
 func (t *RBTree[E]) Clone() *RBTree[E] {
-	rbTree := NewRBTree(t.comparator, t.ToArray()...)
-	return rbTree
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	runs := t.root.runs(nil)
+	return t.derive(buildRBNode(runs, bits.Len(uint(len(runs)+1))-1, t.aggregate))
+}
+
+// DeepClone clones the tree and its elements, see [collection.CloneValue].
+// The clones must be ordered as the elements they are cloned from.
+func (t *RBTree[E]) DeepClone() *RBTree[E] {
+	t.lock.RLock()
+	runs := t.root.runs(nil)
+	t.lock.RUnlock()
+	for index := range runs {
+		runs[index].value = collection.CloneValue(runs[index].value)
+	}
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	return t.derive(buildRBNode(runs, bits.Len(uint(len(runs)+1))-1, t.aggregate))
+}
+
+// All returns an iterator over the elements
+func (t *RBTree[E]) All() iter.Seq[E] {
+	return slices.Values(t.ToArray())
 }
 
+// ToArray converts to array, the returned slice is a copy, so modifying it does not affect the collection
 func (t *RBTree[E]) ToArray() []E {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	return t.toArray()
+}
+
+func (t *RBTree[E]) toArray() []E {
 	nodes := t.root.inOrderRange()
 	values := make([]E, 0, len(nodes))
 	for _, node := range nodes {
@@ -134,45 +585,181 @@ func (t *RBTree[E]) ToArray() []E {
 	return values
 }
 
+// ToJSON converts to json
 func (t *RBTree[E]) ToJSON() ([]byte, error) {
 	return json.Marshal(t.ToArray())
 }
 
+// MarshalJSON implements [json.Marshaller]
 func (t *RBTree[E]) MarshalJSON() ([]byte, error) {
 	return t.ToJSON()
 }
 
+// UnmarshalJSON implements [json.Unmarshaller]
 func (t *RBTree[E]) UnmarshalJSON(data []byte) error {
 	values := make([]E, 0)
 	if err := json.Unmarshal(data, &values); err != nil {
 		return err
 	}
-	t.Clear().Push(values...)
+	t.lock.Lock()
+	defer t.unlock()
+	t.bulkLoad(values)
 	return nil
 }
 
-func (t *RBTree[E]) String() string {
-	str := new(strings.Builder)
-	str.WriteString(fmt.Sprintf("RBTree[%T](len=%d)", *new(E), t.Count()))
-	str.WriteByte('{')
-	str.WriteByte('\n')
-	items := t.ToArray()
-	for index, item := range items {
-		str.WriteByte('\t')
-		if v, ok := any(item).(contract.Stringable); ok {
-			str.WriteString(v.String())
-		} else {
-			str.WriteString(fmt.Sprintf("%v", item))
-		}
-		str.WriteByte(',')
-		str.WriteByte('\n')
-		if index >= 4 {
-			break
-		}
+// EncodeJSON writes the tree to w as a json array one element at a time in order, the tree is read locked while writing
+func (t *RBTree[E]) EncodeJSON(w io.Writer) error {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	return jsonstream.Encode(w, func(yield func(E) bool) {
+		t.root.walkIndex(0, int(t.size), yield)
+	})
+}
+
+// DecodeJSON reads a json array from r one element at a time and replaces the elements of the tree
+func (t *RBTree[E]) DecodeJSON(r io.Reader) error {
+	var values []E
+	if err := jsonstream.Decode(r, func(value E) {
+		values = append(values, value)
+	}); err != nil {
+		return err
 	}
-	if len(items) > 5 {
-		str.WriteString("\t...\n")
+	t.lock.Lock()
+	defer t.unlock()
+	t.bulkLoad(values)
+	return nil
+}
+
+// ToCBOR converts to cbor
+func (t *RBTree[E]) ToCBOR() ([]byte, error) {
+	return cbor.Marshal(t.ToArray())
+}
+
+// MarshalCBOR implements [cbor.Marshaler]
+func (t *RBTree[E]) MarshalCBOR() ([]byte, error) {
+	return t.ToCBOR()
+}
+
+// UnmarshalCBOR implements [cbor.Unmarshaler]
+func (t *RBTree[E]) UnmarshalCBOR(data []byte) error {
+	values := make([]E, 0)
+	if err := cbor.Unmarshal(data, &values); err != nil {
+		return err
+	}
+	t.lock.Lock()
+	defer t.unlock()
+	t.bulkLoad(values)
+	return nil
+}
+
+// MarshalBinary implements [encoding.BinaryMarshaler]
+func (t *RBTree[E]) MarshalBinary() ([]byte, error) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	return encodeRuns(t.root.runs(make([]sortedRun[E], 0, t.size)))
+}
+
+// UnmarshalBinary implements [encoding.BinaryUnmarshaler],
+// the tree is rebuilt balanced in O(n) without re-insertion
+func (t *RBTree[E]) UnmarshalBinary(data []byte) error {
+	t.lock.Lock()
+	defer t.unlock()
+	runs, size, err := decodeRuns(data, t.comparator, t.policy)
+	if err != nil {
+		return err
+	}
+	t.root = buildRBNode(runs, bits.Len(uint(len(runs)+1))-1, t.aggregate)
+	t.size = int64(size)
+	return nil
+}
+
+// Height returns the number of nodes on the longest path from the root to a leaf
+func (t *RBTree[E]) Height() int {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	return t.root.height()
+}
+
+// BlackHeight returns the number of black nodes on each path from the root to a leaf
+func (t *RBTree[E]) BlackHeight() int {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	return t.root.blackHeight()
+}
+
+// Stats returns the statistics of the tree structure
+func (t *RBTree[E]) Stats() Stats {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	stats := collectStats(t.root)
+	stats.Size = t.size
+	return stats
+}
+
+// Validate checks the ordering of the elements and the invariants of the tree,
+// it returns an error wrapping [ErrInvalidTree] when the tree is broken
+func (t *RBTree[E]) Validate() error {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	return t.validate()
+}
+
+func (t *RBTree[E]) validate() error {
+	if t.root.isRed() {
+		return fmt.Errorf("%w: the root is red", ErrInvalidTree)
 	}
-	str.WriteByte('}')
-	return str.String()
+	if _, err := t.root.validate(); err != nil {
+		return err
+	}
+	if t.size != int64(t.root.getSize()) {
+		return fmt.Errorf("%w: tree has size %d, expected %d", ErrInvalidTree, t.size, t.root.getSize())
+	}
+	return validateOrder(t.root.values(nil), t.comparator)
+}
+
+// unlock unlocks the tree after a mutation, the invariants are checked first in debug builds
+func (t *RBTree[E]) unlock() {
+	if debug.Enabled {
+		debug.Check(t, t.validate())
+	}
+	t.lock.Unlock()
+}
+
+// ToDOT converts the structure of the tree to graphviz dot language
+func (t *RBTree[E]) ToDOT() string {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	return renderDOT("RBTree", t.root.render())
+}
+
+// PrintTree writes the structure of the tree as ascii art into the writer
+func (t *RBTree[E]) PrintTree(w io.Writer) error {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	return renderASCII(w, t.root.render())
+}
+
+// SetPreviewLimit sets the number of elements shown by String, %v and LogValue, a negative limit shows all elements
+func (t *RBTree[E]) SetPreviewLimit(limit int) {
+	t.limit.Set(limit)
+}
+
+// String converts to string, only the preview limit of elements are shown
+func (t *RBTree[E]) String() string {
+	return t.format(t.limit.Get(preview.DefaultLimit))
+}
+
+// Format implements [fmt.Formatter], %v prints the preview as String does, %+v and %#v print all elements
+func (t *RBTree[E]) Format(f fmt.State, verb rune) {
+	preview.Format(f, verb, t.format, t.limit.Get(preview.DefaultLimit))
+}
+
+func (t *RBTree[E]) format(limit int) string {
+	items := t.ToArray()
+	return preview.Elements(fmt.Sprintf("RBTree[%T](len=%d)", *new(E), len(items)), items, limit)
+}
+
+// LogValue implements [slog.LogValuer], only the preview limit of elements are logged
+func (t *RBTree[E]) LogValue() slog.Value {
+	return preview.LogElements(fmt.Sprintf("RBTree[%T]", *new(E)), t.ToArray(), t.limit.Get(preview.DefaultLimit))
 }
@@ -1,6 +1,11 @@
 package tree
 
-import "github.com/gopi-frame/contract"
+import (
+	"fmt"
+	"math"
+
+	"github.com/gopi-frame/contract"
+)
 
 const (
 	red   = true
@@ -8,11 +13,47 @@ const (
 )
 
 type rbNode[E any] struct {
-	value E
-	left  *rbNode[E]
-	right *rbNode[E]
-	color bool
-	count int
+	value  E
+	left   *rbNode[E]
+	right  *rbNode[E]
+	color  bool
+	count  int
+	size   int
+	weight float64
+	total  float64
+	// aggregator is the aggregation hook of the tree, the aggregate is kept only when it is set
+	aggregator *Aggregate[E]
+	aggregate  float64
+}
+
+func (node *rbNode[E]) getSize() int {
+	if node == nil {
+		return 0
+	}
+	return node.size
+}
+
+func (node *rbNode[E]) getTotal() float64 {
+	if node == nil {
+		return 0
+	}
+	return node.total
+}
+
+func (node *rbNode[E]) getAggregate(aggregator *Aggregate[E]) float64 {
+	if node == nil {
+		return aggregator.Identity
+	}
+	return node.aggregate
+}
+
+// updateSize updates the size, the total weight and the aggregate of the node
+func (node *rbNode[E]) updateSize() {
+	node.size = node.left.getSize() + node.right.getSize() + node.count
+	node.total = node.left.getTotal() + node.right.getTotal() + node.weight
+	if a := node.aggregator; a != nil {
+		node.aggregate = a.node(node.left.getAggregate(a), node.value, node.count, node.right.getAggregate(a))
+	}
 }
 
 func (node *rbNode[E]) leftRotate() *rbNode[E] {
@@ -24,6 +65,8 @@ func (node *rbNode[E]) leftRotate() *rbNode[E] {
 	pivot.left = node
 	pivot.color = node.color
 	node.color = red
+	node.updateSize()
+	pivot.updateSize()
 	return pivot
 }
 
@@ -36,6 +79,8 @@ func (node *rbNode[E]) rightRotate() *rbNode[E] {
 	pivot.right = node
 	pivot.color = node.color
 	node.color = red
+	node.updateSize()
+	pivot.updateSize()
 	return pivot
 }
 
@@ -81,23 +126,42 @@ func (node *rbNode[E]) moveRedRight() *rbNode[E] {
 	return node
 }
 
-func (node *rbNode[E]) insert(value E, comparator contract.Comparator[E]) *rbNode[E] {
+// insert inserts the value with the weight into the subtree, it returns the new subtree and whether the value is inserted
+func (node *rbNode[E]) insert(value E, weight float64, comparator contract.Comparator[E], policy DuplicatePolicy, pool *nodePool[rbNode[E]], aggregator *Aggregate[E]) (*rbNode[E], bool) {
 	if node == nil {
-		return &rbNode[E]{
-			value: value,
-			color: red,
-			count: 1,
+		node = pool.get()
+		*node = rbNode[E]{
+			value:      value,
+			color:      red,
+			count:      1,
+			weight:     weight,
+			aggregator: aggregator,
 		}
+		node.updateSize()
+		return node, true
 	}
+	var inserted bool
 	result := comparator.Compare(value, node.value)
 	if result == 0 {
+		switch policy {
+		case IgnoreDuplicates:
+			return node, false
+		case ReplaceDuplicates:
+			node.value = value
+			node.weight = weight
+			node.updateSize()
+			return node, true
+		}
 		node.count++
-		return node
+		node.weight += weight
+		node.updateSize()
+		return node, true
 	} else if result < 0 {
-		node.left = node.left.insert(value, comparator)
+		node.left, inserted = node.left.insert(value, weight, comparator, policy, pool, aggregator)
 	} else {
-		node.right = node.right.insert(value, comparator)
+		node.right, inserted = node.right.insert(value, weight, comparator, policy, pool, aggregator)
 	}
+	node.updateSize()
 	activeNode := node
 	if activeNode.right.isRed() && activeNode.left.isBlack() {
 		activeNode = node.leftRotate()
@@ -109,7 +173,7 @@ func (node *rbNode[E]) insert(value E, comparator contract.Comparator[E]) *rbNod
 			activeNode.switchColor()
 		}
 	}
-	return activeNode
+	return activeNode, inserted
 }
 
 func (node *rbNode[E]) remove(value E, comparator contract.Comparator[E]) *rbNode[E] {
@@ -133,6 +197,7 @@ func (node *rbNode[E]) remove(value E, comparator contract.Comparator[E]) *rbNod
 			m := activeNode.right.min()
 			activeNode.value = m.value
 			activeNode.count = m.count
+			activeNode.weight = m.weight
 			activeNode.right = activeNode.right.removeMin()
 		} else {
 			activeNode.right = activeNode.right.remove(value, comparator)
@@ -164,6 +229,7 @@ func (node *rbNode[E]) fix() *rbNode[E] {
 	if activeNode.left.isRed() && activeNode.right.isRed() {
 		activeNode.switchColor()
 	}
+	activeNode.updateSize()
 	return activeNode
 }
 
@@ -195,6 +261,50 @@ func (node *rbNode[E]) find(value E, comparator contract.Comparator[E]) *rbNode[
 	}
 }
 
+// higher returns the node with the smallest value greater than the value
+func (node *rbNode[E]) higher(value E, comparator contract.Comparator[E]) *rbNode[E] {
+	var result *rbNode[E]
+	for current := node; current != nil; {
+		if comparator.Compare(value, current.value) < 0 {
+			result = current
+			current = current.left
+		} else {
+			current = current.right
+		}
+	}
+	return result
+}
+
+// lower returns the node with the largest value less than the value
+func (node *rbNode[E]) lower(value E, comparator contract.Comparator[E]) *rbNode[E] {
+	var result *rbNode[E]
+	for current := node; current != nil; {
+		if comparator.Compare(value, current.value) > 0 {
+			result = current
+			current = current.right
+		} else {
+			current = current.left
+		}
+	}
+	return result
+}
+
+// floor returns the node with the largest value less than or equal to the value
+func (node *rbNode[E]) floor(value E, comparator contract.Comparator[E]) *rbNode[E] {
+	if found := node.find(value, comparator); found != nil {
+		return found
+	}
+	return node.lower(value, comparator)
+}
+
+// ceiling returns the node with the smallest value greater than or equal to the value
+func (node *rbNode[E]) ceiling(value E, comparator contract.Comparator[E]) *rbNode[E] {
+	if found := node.find(value, comparator); found != nil {
+		return found
+	}
+	return node.higher(value, comparator)
+}
+
 func (node *rbNode[E]) inOrderRange() (nodes []*rbNode[E]) {
 	if node == nil {
 		return
@@ -206,3 +316,351 @@ func (node *rbNode[E]) inOrderRange() (nodes []*rbNode[E]) {
 	nodes = append(nodes, node.right.inOrderRange()...)
 	return
 }
+
+// blackHeight returns the number of black nodes on the path from the node to a leaf
+func (node *rbNode[E]) blackHeight() int {
+	height := 0
+	for n := node; n != nil; n = n.left {
+		if n.isBlack() {
+			height++
+		}
+	}
+	return height
+}
+
+func (node *rbNode[E]) blacken() {
+	if node != nil {
+		node.color = black
+	}
+}
+
+// joinRight replaces the black node with the given black height on the right spine with the red middle node
+func (node *rbNode[E]) joinRight(mid, right *rbNode[E], height, target int) *rbNode[E] {
+	if height == target {
+		mid.left = node
+		mid.right = right
+		mid.color = red
+		mid.updateSize()
+		return mid
+	}
+	node.right = node.right.joinRight(mid, right, height-1, target)
+	return node.fix()
+}
+
+// joinLeft replaces the black node with the given black height on the left spine with the red middle node
+func (node *rbNode[E]) joinLeft(left, mid *rbNode[E], height, target int) *rbNode[E] {
+	if height == target && node.isBlack() {
+		mid.left = left
+		mid.right = node
+		mid.color = red
+		mid.updateSize()
+		return mid
+	}
+	childHeight := height
+	if node.isBlack() {
+		childHeight--
+	}
+	node.left = node.left.joinLeft(left, mid, childHeight, target)
+	return node.fix()
+}
+
+// joinRB joins the trees with the middle node, all elements of the left tree must be less than the middle node
+// and all elements of the right tree must be greater than the middle node.
+// The roots of both trees must be black.
+func joinRB[E any](left, mid, right *rbNode[E]) *rbNode[E] {
+	leftHeight, rightHeight := left.blackHeight(), right.blackHeight()
+	var root *rbNode[E]
+	if leftHeight > rightHeight {
+		root = left.joinRight(mid, right, leftHeight, rightHeight)
+	} else if leftHeight < rightHeight {
+		root = right.joinLeft(left, mid, rightHeight, leftHeight)
+	} else {
+		mid.left = left
+		mid.right = right
+		mid.updateSize()
+		root = mid
+	}
+	root.color = black
+	return root
+}
+
+// mergeRB merges the trees, all elements of the left tree must be less than the elements of the right tree.
+// The roots of both trees must be black.
+func mergeRB[E any](left, right *rbNode[E]) *rbNode[E] {
+	if left == nil {
+		return right
+	}
+	if right == nil {
+		return left
+	}
+	m := right.min()
+	mid := &rbNode[E]{value: m.value, count: m.count, weight: m.weight, aggregator: m.aggregator}
+	if right.left.isBlack() && right.right.isBlack() {
+		right.color = red
+	}
+	right = right.removeMin()
+	right.blacken()
+	return joinRB(left, mid, right)
+}
+
+// splitRB splits the tree into the elements less than the value and the elements greater than or equal to the value
+func splitRB[E any](node *rbNode[E], value E, comparator contract.Comparator[E]) (*rbNode[E], *rbNode[E]) {
+	if node == nil {
+		return nil, nil
+	}
+	left, right := node.left, node.right
+	left.blacken()
+	right.blacken()
+	if comparator.Compare(value, node.value) <= 0 {
+		l, r := splitRB(left, value, comparator)
+		return l, joinRB(r, node, right)
+	}
+	l, r := splitRB(right, value, comparator)
+	return joinRB(left, node, l), r
+}
+
+// buildRBNode builds a left-leaning red black tree with the given black height from the sorted runs.
+// The number of runs must be in range [2^height-1, 3^height-1],
+// each level is made of 2-nodes (a black node) or 3-nodes (a black node with a red left child).
+func buildRBNode[E any](runs []sortedRun[E], height int, aggregator *Aggregate[E]) *rbNode[E] {
+	if len(runs) == 0 {
+		return nil
+	}
+	maxChildSize := 1
+	for i := 1; i < height; i++ {
+		maxChildSize *= 3
+	}
+	maxChildSize--
+	if len(runs)-1 <= 2*maxChildSize {
+		mid := (len(runs) - 1) / 2
+		node := &rbNode[E]{value: runs[mid].value, count: runs[mid].count, weight: runs[mid].weight, color: black, aggregator: aggregator}
+		node.left = buildRBNode(runs[:mid], height-1, aggregator)
+		node.right = buildRBNode(runs[mid+1:], height-1, aggregator)
+		node.updateSize()
+		return node
+	}
+	rest := len(runs) - 2
+	first := rest / 3
+	second := (rest - first) / 2
+	redNode := &rbNode[E]{value: runs[first].value, count: runs[first].count, weight: runs[first].weight, color: red, aggregator: aggregator}
+	redNode.left = buildRBNode(runs[:first], height-1, aggregator)
+	redNode.right = buildRBNode(runs[first+1:first+1+second], height-1, aggregator)
+	redNode.updateSize()
+	node := &rbNode[E]{value: runs[first+1+second].value, count: runs[first+1+second].count, weight: runs[first+1+second].weight, color: black, aggregator: aggregator}
+	node.left = redNode
+	node.right = buildRBNode(runs[first+2+second:], height-1, aggregator)
+	node.updateSize()
+	return node
+}
+
+func (node *rbNode[E]) render() *renderNode {
+	if node == nil {
+		return nil
+	}
+	color := "black"
+	if node.isRed() {
+		color = "red"
+	}
+	return &renderNode{
+		label: renderLabel(node.value, node.count),
+		info:  color,
+		fill:  color,
+		left:  node.left.render(),
+		right: node.right.render(),
+	}
+}
+
+func (node *rbNode[E]) children() (*rbNode[E], *rbNode[E]) {
+	return node.left, node.right
+}
+
+// values returns the distinct values of the subtree in order
+func (node *rbNode[E]) values(values []E) []E {
+	if node == nil {
+		return values
+	}
+	values = node.left.values(values)
+	values = append(values, node.value)
+	return node.right.values(values)
+}
+
+// validate checks the count, colors and size of each node in the subtree, it returns the black height of the subtree
+func (node *rbNode[E]) validate() (int, error) {
+	if node == nil {
+		return 0, nil
+	}
+	leftHeight, err := node.left.validate()
+	if err != nil {
+		return 0, err
+	}
+	rightHeight, err := node.right.validate()
+	if err != nil {
+		return 0, err
+	}
+	if node.count < 1 {
+		return 0, fmt.Errorf("%w: node %v has count %d", ErrInvalidTree, node.value, node.count)
+	}
+	if node.right.isRed() {
+		return 0, fmt.Errorf("%w: node %v has a red right child", ErrInvalidTree, node.value)
+	}
+	if node.isRed() && node.left.isRed() {
+		return 0, fmt.Errorf("%w: red node %v has a red child", ErrInvalidTree, node.value)
+	}
+	if leftHeight != rightHeight {
+		return 0, fmt.Errorf("%w: node %v has black heights %d and %d", ErrInvalidTree, node.value, leftHeight, rightHeight)
+	}
+	if size := node.left.getSize() + node.right.getSize() + node.count; node.size != size {
+		return 0, fmt.Errorf("%w: node %v has size %d, expected %d", ErrInvalidTree, node.value, node.size, size)
+	}
+	if total := node.left.getTotal() + node.right.getTotal() + node.weight; node.total != total {
+		return 0, fmt.Errorf("%w: node %v has total weight %v, expected %v", ErrInvalidTree, node.value, node.total, total)
+	}
+	if a := node.aggregator; a != nil {
+		if aggregate := a.node(node.left.getAggregate(a), node.value, node.count, node.right.getAggregate(a)); node.aggregate != aggregate && !math.IsNaN(aggregate) {
+			return 0, fmt.Errorf("%w: node %v has aggregate %v, expected %v", ErrInvalidTree, node.value, node.aggregate, aggregate)
+		}
+	}
+	if node.isBlack() {
+		leftHeight++
+	}
+	return leftHeight, nil
+}
+
+func (node *rbNode[E]) height() int {
+	if node == nil {
+		return 0
+	}
+	return max(node.left.height(), node.right.height()) + 1
+}
+
+// runs returns the sorted runs of the subtree
+func (node *rbNode[E]) runs(runs []sortedRun[E]) []sortedRun[E] {
+	if node == nil {
+		return runs
+	}
+	runs = node.left.runs(runs)
+	runs = append(runs, sortedRun[E]{value: node.value, count: node.count, weight: node.weight})
+	return node.right.runs(runs)
+}
+
+// rangeRuns appends the sorted runs of the values in [from, to) of the subtree to runs
+func (node *rbNode[E]) rangeRuns(from, to E, comparator contract.Comparator[E], runs []sortedRun[E]) []sortedRun[E] {
+	if node == nil {
+		return runs
+	}
+	afterFrom := comparator.Compare(node.value, from) >= 0
+	beforeTo := comparator.Compare(node.value, to) < 0
+	if afterFrom {
+		runs = node.left.rangeRuns(from, to, comparator, runs)
+	}
+	if afterFrom && beforeTo {
+		runs = append(runs, sortedRun[E]{value: node.value, count: node.count, weight: node.weight})
+	}
+	if beforeTo {
+		runs = node.right.rangeRuns(from, to, comparator, runs)
+	}
+	return runs
+}
+
+// walkIndex runs callback for each element of the subtree whose in-order position is in [from, to),
+// it returns false when callback returns false.
+func (node *rbNode[E]) walkIndex(from, to int, callback func(E) bool) bool {
+	if node == nil || from >= to {
+		return true
+	}
+	leftSize := node.left.getSize()
+	if from < leftSize && !node.left.walkIndex(from, min(to, leftSize), callback) {
+		return false
+	}
+	rightStart := leftSize + node.count
+	for i := max(from, leftSize); i < min(to, rightStart); i++ {
+		if !callback(node.value) {
+			return false
+		}
+	}
+	if to > rightStart {
+		return node.right.walkIndex(max(from-rightStart, 0), to-rightStart, callback)
+	}
+	return true
+}
+
+// countLess returns the number of elements of the subtree which are less than the value, counting duplicates,
+// it takes O(log n) time as the sizes of the skipped subtrees are added without visiting them.
+func (node *rbNode[E]) countLess(value E, comparator contract.Comparator[E]) int {
+	count := 0
+	for node != nil {
+		if comparator.Compare(value, node.value) <= 0 {
+			node = node.left
+		} else {
+			count += node.left.getSize() + node.count
+			node = node.right
+		}
+	}
+	return count
+}
+
+// weightLess returns the total weight of the elements of the subtree which are less than the value
+func (node *rbNode[E]) weightLess(value E, comparator contract.Comparator[E]) float64 {
+	var weight float64
+	for node != nil {
+		if comparator.Compare(value, node.value) <= 0 {
+			node = node.left
+		} else {
+			weight += node.left.getTotal() + node.weight
+			node = node.right
+		}
+	}
+	return weight
+}
+
+// selectWeight returns the node whose weight covers the position in the cumulative weights of the subtree,
+// it returns nil when the position is out of [0, total)
+func (node *rbNode[E]) selectWeight(position float64) *rbNode[E] {
+	for node != nil {
+		if left := node.left.getTotal(); position < left {
+			node = node.left
+		} else if position < left+node.weight {
+			return node
+		} else {
+			position -= left + node.weight
+			node = node.right
+		}
+	}
+	return nil
+}
+
+// aggregateBetween returns the aggregate of the elements of the subtree in [from, to)
+func (node *rbNode[E]) aggregateBetween(from, to E, comparator contract.Comparator[E], aggregator *Aggregate[E]) float64 {
+	for node != nil {
+		if comparator.Compare(node.value, from) < 0 {
+			node = node.right
+		} else if comparator.Compare(node.value, to) >= 0 {
+			node = node.left
+		} else {
+			return aggregator.node(node.left.aggregateFrom(from, comparator, aggregator), node.value, node.count, node.right.aggregateBefore(to, comparator, aggregator))
+		}
+	}
+	return aggregator.Identity
+}
+
+// aggregateFrom returns the aggregate of the elements of the subtree greater than or equal to the value
+func (node *rbNode[E]) aggregateFrom(value E, comparator contract.Comparator[E], aggregator *Aggregate[E]) float64 {
+	if node == nil {
+		return aggregator.Identity
+	}
+	if comparator.Compare(node.value, value) < 0 {
+		return node.right.aggregateFrom(value, comparator, aggregator)
+	}
+	return aggregator.node(node.left.aggregateFrom(value, comparator, aggregator), node.value, node.count, node.right.getAggregate(aggregator))
+}
+
+// aggregateBefore returns the aggregate of the elements of the subtree less than the value
+func (node *rbNode[E]) aggregateBefore(value E, comparator contract.Comparator[E], aggregator *Aggregate[E]) float64 {
+	if node == nil {
+		return aggregator.Identity
+	}
+	if comparator.Compare(node.value, value) >= 0 {
+		return node.left.aggregateBefore(value, comparator, aggregator)
+	}
+	return aggregator.node(node.left.getAggregate(aggregator), node.value, node.count, node.right.aggregateBefore(value, comparator, aggregator))
+}
@@ -1,17 +1,33 @@
 package tree
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"math"
 	"regexp"
+	"strings"
+	"sync"
 	"testing"
 
+	"github.com/fxamacker/cbor/v2"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestRBTree_Count(t *testing.T) {
 	tree := NewRBTree(_cmp{}, 1, 2, 3)
 	assert.Equal(t, int64(3), tree.Count())
+
+	t.Run("duplicated elements", func(t *testing.T) {
+		tree := NewRBTree(_cmp{}, 1, 2, 2, 3, 3, 3)
+		assert.Equal(t, int64(6), tree.Count())
+		tree.Remove(3)
+		assert.Equal(t, int64(3), tree.Count())
+		tree.Remove(4)
+		assert.Equal(t, int64(3), tree.Count())
+		tree.Clear()
+		assert.Equal(t, int64(0), tree.Count())
+	})
 }
 
 func TestRBTree_IsEmpty(t *testing.T) {
@@ -60,6 +76,16 @@ func TestRBTree_Clear(t *testing.T) {
 	assert.True(t, tree.IsEmpty())
 }
 
+func TestRBTree_Release(t *testing.T) {
+	tree := NewRBTree(_cmp{}, 1, 2, 3).EnableNodePool()
+	tree.Release()
+	assert.True(t, tree.IsEmpty())
+	tree.Push(3, 1, 2)
+	assert.Equal(t, []int{1, 2, 3}, tree.ToArray())
+	tree.Release().Push(5, 4)
+	assert.Equal(t, []int{4, 5}, tree.ToArray())
+}
+
 func TestRBTree_First(t *testing.T) {
 	t.Run("empty tree", func(t *testing.T) {
 		tree := NewRBTree[int](_cmp{})
@@ -136,6 +162,32 @@ func TestRBTree_Clone(t *testing.T) {
 	assert.Equal(t, []int{1, 2, 2, 3, 5}, tree2.ToArray())
 }
 
+func TestRBTree_Clone_Settings(t *testing.T) {
+	tree := NewRBTree(_cmp{}, 1, 2, 3, 5)
+	tree.EnableNodePool()
+	tree.SetPreviewLimit(1)
+	left, right := tree.Clone().Split(3)
+	for _, derived := range []*RBTree[int]{tree.Clone(), tree.DeepClone(), tree.Sub(2, 5), left, right} {
+		assert.NotNil(t, derived.pool)
+		assert.Equal(t, 1, derived.limit.Get(0))
+		assert.Contains(t, derived.String(), "\t...\n")
+	}
+	assert.Equal(t, []int{1, 2}, left.ToArray())
+	assert.Equal(t, []int{3, 5}, right.ToArray())
+	assert.EqualValues(t, 2, left.Count())
+}
+
+func TestRBTree_DeepClone(t *testing.T) {
+	items := []*_item{{value: 1}, {value: 2}, {value: 3}}
+	tree := NewRBTree[*_item](_itemCmp{}, items...)
+	clone := tree.DeepClone()
+	assert.Equal(t, items, clone.ToArray())
+	for index, item := range clone.ToArray() {
+		assert.NotSame(t, items[index], item)
+	}
+	assert.True(t, clone.Contains(&_item{value: 2}))
+}
+
 func TestRBTree_ToArray(t *testing.T) {
 	tree := NewRBTree(_cmp{}, 1, 2, 3, 5, 2)
 	assert.Equal(t, []int{1, 2, 2, 3, 5}, tree.ToArray())
@@ -170,9 +222,596 @@ func TestRBTree_UnmarshalJSON(t *testing.T) {
 	})
 }
 
+func TestRBTree_EncodeJSON(t *testing.T) {
+	value := NewRBTree(_cmp{}, 3, 1, 2)
+	buf := new(bytes.Buffer)
+	assert.Nil(t, value.EncodeJSON(buf))
+	expected, _ := value.ToJSON()
+	assert.JSONEq(t, string(expected), buf.String())
+}
+
+func TestRBTree_DecodeJSON(t *testing.T) {
+	value := NewRBTree(_cmp{}, 3, 1, 2)
+	assert.Nil(t, value.DecodeJSON(strings.NewReader(`[4, 5]`)))
+	assert.Equal(t, []int{4, 5}, value.ToArray())
+	assert.Error(t, value.DecodeJSON(strings.NewReader(`[6, "a"]`)))
+	assert.Equal(t, []int{4, 5}, value.ToArray())
+}
+
 func TestRBTree_String(t *testing.T) {
 	tree := NewRBTree(_cmp{}, 1, 2, 3, 5, 2)
 	str := tree.String()
 	pattern := regexp.MustCompile(fmt.Sprintf(`RBTree\[int\]\(len=%d\)\{\n(\t\d+,\n){5}\}`, tree.Count()))
 	assert.True(t, pattern.MatchString(str))
 }
+
+func TestRBTree_Concurrent(t *testing.T) {
+	tree := NewRBTree[int](_cmp{})
+	wg := new(sync.WaitGroup)
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			tree.Push(i)
+			tree.Contains(i)
+			tree.Each(func(_ int, value int) bool {
+				return true
+			})
+		}(i)
+	}
+	wg.Wait()
+	assert.Equal(t, int64(100), tree.Count())
+}
+
+func TestRBTree_FromSorted(t *testing.T) {
+	t.Run("sorted values", func(t *testing.T) {
+		tree := NewRBTreeFromSorted[int](_cmp{}, []int{1, 2, 2, 3, 4, 5, 6, 7})
+		assert.Equal(t, int64(8), tree.Count())
+		assert.Equal(t, []int{1, 2, 2, 3, 4, 5, 6, 7}, tree.ToArray())
+		tree.Remove(2)
+		tree.Push(0)
+		assert.Equal(t, []int{0, 1, 3, 4, 5, 6, 7}, tree.ToArray())
+	})
+
+	t.Run("unsorted values", func(t *testing.T) {
+		tree := NewRBTreeFromSorted[int](_cmp{}, []int{3, 1, 2})
+		assert.Equal(t, []int{1, 2, 3}, tree.ToArray())
+	})
+}
+
+func TestRBTree_BulkLoad(t *testing.T) {
+	tree := NewRBTree(_cmp{}, 10, 11)
+	tree.BulkLoad([]int{1, 2, 3})
+	assert.Equal(t, int64(3), tree.Count())
+	assert.Equal(t, []int{1, 2, 3}, tree.ToArray())
+	tree.BulkLoad(nil)
+	assert.True(t, tree.IsEmpty())
+}
+
+func TestRBTree_RemoveBetween(t *testing.T) {
+	t.Run("range", func(t *testing.T) {
+		tree := NewRBTree[int](_cmp{})
+		for i := 0; i < 100; i++ {
+			tree.Push(i, i)
+		}
+		assert.EqualValues(t, 20, tree.RemoveBetween(20, 30))
+		assert.Nil(t, tree.Validate())
+		assert.EqualValues(t, 180, tree.Count())
+		assert.False(t, tree.Contains(20))
+		assert.False(t, tree.Contains(29))
+		assert.True(t, tree.Contains(19))
+		assert.True(t, tree.Contains(30))
+		assert.EqualValues(t, 0, tree.CountBetween(20, 30))
+	})
+
+	t.Run("empty range", func(t *testing.T) {
+		tree := NewRBTree(_cmp{}, 1, 2, 3)
+		assert.EqualValues(t, 0, tree.RemoveBetween(3, 1))
+		assert.EqualValues(t, 0, tree.RemoveBetween(2, 2))
+		assert.EqualValues(t, 0, tree.RemoveBetween(4, 10))
+		assert.Equal(t, []int{1, 2, 3}, tree.ToArray())
+	})
+
+	t.Run("all elements", func(t *testing.T) {
+		tree := NewRBTree(_cmp{}, 1, 2, 3)
+		assert.EqualValues(t, 3, tree.RemoveBetween(0, 10))
+		assert.True(t, tree.IsEmpty())
+		assert.Nil(t, tree.Validate())
+	})
+
+	t.Run("aggregate", func(t *testing.T) {
+		tree := NewRBTreeWithAggregate(_cmp{}, SumAggregate(func(value int) float64 {
+			return float64(value)
+		}), 1, 2, 3, 4, 5)
+		tree.RemoveBetween(2, 4)
+		assert.Nil(t, tree.Validate())
+		assert.Equal(t, 10.0, tree.AggregateBetween(0, 10))
+	})
+}
+
+func TestRBTree_Split(t *testing.T) {
+	tree := NewRBTree(_cmp{}, 5, 1, 4, 2, 3, 3)
+	left, right := tree.Split(3)
+	assert.Equal(t, []int{1, 2}, left.ToArray())
+	assert.Equal(t, int64(2), left.Count())
+	assert.Equal(t, []int{3, 3, 4, 5}, right.ToArray())
+	assert.Equal(t, int64(4), right.Count())
+	assert.True(t, tree.IsEmpty())
+}
+
+func TestRBTree_Merge(t *testing.T) {
+	t.Run("disjoint ranges", func(t *testing.T) {
+		tree := NewRBTree(_cmp{}, 1, 2, 3)
+		other := NewRBTree(_cmp{}, 4, 5, 5)
+		tree.Merge(other)
+		assert.Equal(t, []int{1, 2, 3, 4, 5, 5}, tree.ToArray())
+		assert.Equal(t, int64(6), tree.Count())
+		assert.True(t, other.IsEmpty())
+	})
+
+	t.Run("overlapping ranges", func(t *testing.T) {
+		tree := NewRBTree(_cmp{}, 1, 3, 5)
+		other := NewRBTree(_cmp{}, 2, 3, 4)
+		tree.Merge(other)
+		assert.Equal(t, []int{1, 2, 3, 3, 4, 5}, tree.ToArray())
+		assert.Equal(t, int64(6), tree.Count())
+		assert.True(t, other.IsEmpty())
+	})
+}
+
+func TestRBTree_ToDOT(t *testing.T) {
+	tree := NewRBTree(_cmp{}, 1, 2, 2)
+	dot := tree.ToDOT()
+	assert.Contains(t, dot, "digraph RBTree {")
+	assert.Contains(t, dot, `n0 [label="2(x2)\nblack", style=filled, fillcolor=black, fontcolor=white];`)
+	assert.Contains(t, dot, `n1 [label="1\nred", style=filled, fillcolor=red, fontcolor=white];`)
+	assert.Contains(t, dot, `n0 -> n1 [label="L"];`)
+}
+
+func TestRBTree_PrintTree(t *testing.T) {
+	t.Run("empty tree", func(t *testing.T) {
+		tree := NewRBTree[int](_cmp{})
+		str := new(strings.Builder)
+		assert.Nil(t, tree.PrintTree(str))
+		assert.Equal(t, "<empty>\n", str.String())
+	})
+
+	t.Run("non-empty tree", func(t *testing.T) {
+		tree := NewRBTree(_cmp{}, 1, 2, 3)
+		str := new(strings.Builder)
+		assert.Nil(t, tree.PrintTree(str))
+		assert.Equal(t, "2 [black]\n"+
+			"├── L: 1 [black]\n"+
+			"└── R: 3 [black]\n", str.String())
+	})
+}
+
+func TestRBTree_Next(t *testing.T) {
+	tree := NewRBTree(_cmp{}, 1, 3, 3, 5)
+	v, ok := tree.Next(3)
+	assert.True(t, ok)
+	assert.Equal(t, 5, v)
+	v, ok = tree.Next(2)
+	assert.True(t, ok)
+	assert.Equal(t, 3, v)
+	_, ok = tree.Next(5)
+	assert.False(t, ok)
+	_, ok = NewRBTree[int](_cmp{}).Next(1)
+	assert.False(t, ok)
+}
+
+func TestRBTree_Prev(t *testing.T) {
+	tree := NewRBTree(_cmp{}, 1, 3, 3, 5)
+	v, ok := tree.Prev(3)
+	assert.True(t, ok)
+	assert.Equal(t, 1, v)
+	v, ok = tree.Prev(4)
+	assert.True(t, ok)
+	assert.Equal(t, 3, v)
+	_, ok = tree.Prev(1)
+	assert.False(t, ok)
+	_, ok = NewRBTree[int](_cmp{}).Prev(1)
+	assert.False(t, ok)
+}
+
+func TestRBTree_Policy(t *testing.T) {
+	t.Run("allow duplicates", func(t *testing.T) {
+		tree := NewRBTreeWithPolicy(_cmp{}, AllowDuplicates, 1, 1, 2)
+		assert.True(t, tree.Add(2))
+		assert.Equal(t, []int{1, 1, 2, 2}, tree.ToArray())
+		assert.Equal(t, int64(4), tree.Count())
+	})
+
+	t.Run("ignore duplicates", func(t *testing.T) {
+		tree := NewRBTreeWithPolicy(_entryCmp{}, IgnoreDuplicates, _entry{1, "a"}, _entry{1, "b"})
+		assert.False(t, tree.Add(_entry{1, "c"}))
+		assert.True(t, tree.Add(_entry{2, "a"}))
+		assert.Equal(t, []_entry{{1, "a"}, {2, "a"}}, tree.ToArray())
+		assert.Equal(t, int64(2), tree.Count())
+		tree.BulkLoad([]_entry{{1, "x"}, {1, "y"}})
+		assert.Equal(t, []_entry{{1, "x"}}, tree.ToArray())
+		assert.Equal(t, int64(1), tree.Count())
+	})
+
+	t.Run("replace duplicates", func(t *testing.T) {
+		tree := NewRBTreeWithPolicy(_entryCmp{}, ReplaceDuplicates, _entry{1, "a"}, _entry{1, "b"})
+		assert.True(t, tree.Add(_entry{1, "c"}))
+		assert.Equal(t, []_entry{{1, "c"}}, tree.ToArray())
+		assert.Equal(t, int64(1), tree.Count())
+		tree.BulkLoad([]_entry{{1, "x"}, {1, "y"}})
+		assert.Equal(t, []_entry{{1, "y"}}, tree.ToArray())
+		assert.Equal(t, int64(1), tree.Clone().Count())
+	})
+}
+
+func TestRBTree_Ordered(t *testing.T) {
+	tree := NewRBTreeOrdered(3, 1, 2)
+	assert.Equal(t, []int{1, 2, 3}, tree.ToArray())
+	strTree := NewRBTreeOrdered("b", "c", "a")
+	assert.Equal(t, []string{"a", "b", "c"}, strTree.ToArray())
+}
+
+func TestRBTree_EachReverse(t *testing.T) {
+	t.Run("break", func(t *testing.T) {
+		tree := NewRBTree(_cmp{}, 1, 2, 3, 5, 3)
+		var items []int
+		var indexes []int
+		tree.EachReverse(func(index int, value int) bool {
+			items = append(items, value)
+			indexes = append(indexes, index)
+			return value > 3
+		})
+		assert.Equal(t, []int{5, 3}, items)
+		assert.Equal(t, []int{0, 1}, indexes)
+	})
+
+	t.Run("modify in callback", func(t *testing.T) {
+		tree := NewRBTree(_cmp{}, 1, 2, 3, 4)
+		var items []int
+		tree.EachReverse(func(_ int, value int) bool {
+			items = append(items, value)
+			tree.Remove(value)
+			return true
+		})
+		assert.Equal(t, []int{4, 3, 2, 1}, items)
+		assert.True(t, tree.IsEmpty())
+	})
+}
+
+func TestRBTree_Stats(t *testing.T) {
+	t.Run("empty", func(t *testing.T) {
+		tree := NewRBTree[int](_cmp{})
+		assert.Equal(t, 0, tree.Height())
+		assert.Equal(t, 0, tree.BlackHeight())
+		assert.Equal(t, Stats{}, tree.Stats())
+	})
+
+	t.Run("not empty", func(t *testing.T) {
+		tree := NewRBTree(_cmp{}, 1, 2, 3, 4, 5, 6, 7, 7)
+		stats := tree.Stats()
+		assert.EqualValues(t, 8, stats.Size)
+		assert.Equal(t, 7, stats.Nodes)
+		assert.Equal(t, tree.Height(), stats.Height)
+		assert.LessOrEqual(t, stats.MinLeafDepth, stats.Height)
+		assert.LessOrEqual(t, stats.Height, 2*tree.BlackHeight())
+	})
+}
+
+func TestRBTree_Validate(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		tree := NewRBTree[int](_cmp{})
+		assert.Nil(t, tree.Validate())
+		for i := 0; i < 100; i++ {
+			tree.Push((i * 37) % 50)
+		}
+		for i := 0; i < 30; i++ {
+			tree.Remove(i)
+		}
+		assert.Nil(t, tree.Validate())
+	})
+
+	t.Run("broken order", func(t *testing.T) {
+		tree := NewRBTree(_cmp{}, 1, 2, 3)
+		tree.root.left.value = 4
+		assert.ErrorIs(t, tree.Validate(), ErrInvalidTree)
+	})
+
+	t.Run("red root", func(t *testing.T) {
+		tree := NewRBTree(_cmp{}, 1, 2, 3)
+		tree.root.color = red
+		assert.ErrorIs(t, tree.Validate(), ErrInvalidTree)
+	})
+
+	t.Run("broken size", func(t *testing.T) {
+		tree := NewRBTree(_cmp{}, 1, 2, 3)
+		tree.size = 5
+		assert.ErrorIs(t, tree.Validate(), ErrInvalidTree)
+	})
+}
+
+func TestRBTree_MarshalBinary(t *testing.T) {
+	t.Run("unique", func(t *testing.T) {
+		tree := NewRBTree(_cmp{}, 5, 3, 1, 4, 2)
+		data, err := tree.MarshalBinary()
+		assert.Nil(t, err)
+		newTree := NewRBTree[int](_cmp{})
+		assert.Nil(t, newTree.UnmarshalBinary(data))
+		assert.Equal(t, []int{1, 2, 3, 4, 5}, newTree.ToArray())
+		assert.EqualValues(t, 5, newTree.Count())
+		assert.Nil(t, newTree.Validate())
+	})
+
+	t.Run("duplicates", func(t *testing.T) {
+		tree := NewRBTree(_cmp{}, 1, 2, 2, 3, 3, 3)
+		data, err := tree.MarshalBinary()
+		assert.Nil(t, err)
+		newTree := NewRBTree[int](_cmp{})
+		assert.Nil(t, newTree.UnmarshalBinary(data))
+		assert.Equal(t, []int{1, 2, 2, 3, 3, 3}, newTree.ToArray())
+		assert.Nil(t, newTree.Validate())
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		data, err := NewRBTree(_cmp{}, 1, 2, 3).MarshalBinary()
+		assert.Nil(t, err)
+		assert.ErrorIs(t, NewRBTree[int](_reverseCmp{}).UnmarshalBinary(data), ErrInvalidTree)
+	})
+}
+
+func TestRBTree_Floor(t *testing.T) {
+	tree := NewRBTree(_cmp{}, 1, 3, 5)
+	v, ok := tree.Floor(3)
+	assert.True(t, ok)
+	assert.Equal(t, 3, v)
+	v, ok = tree.Floor(4)
+	assert.True(t, ok)
+	assert.Equal(t, 3, v)
+	_, ok = tree.Floor(0)
+	assert.False(t, ok)
+}
+
+func TestRBTree_Ceiling(t *testing.T) {
+	tree := NewRBTree(_cmp{}, 1, 3, 5)
+	v, ok := tree.Ceiling(3)
+	assert.True(t, ok)
+	assert.Equal(t, 3, v)
+	v, ok = tree.Ceiling(4)
+	assert.True(t, ok)
+	assert.Equal(t, 5, v)
+	_, ok = tree.Ceiling(6)
+	assert.False(t, ok)
+}
+
+func TestRBTree_Closest(t *testing.T) {
+	t.Run("comparator distance", func(t *testing.T) {
+		tree := NewRBTree[int](_diffCmp{}, 10, 20, 40)
+		for value, expected := range map[int]int{0: 10, 14: 10, 16: 20, 15: 10, 29: 20, 31: 40, 100: 40} {
+			v, ok := tree.Closest(value)
+			assert.True(t, ok)
+			assert.Equal(t, expected, v, "closest to %d", value)
+		}
+		_, ok := NewRBTree[int](_diffCmp{}).Closest(1)
+		assert.False(t, ok)
+	})
+
+	t.Run("distance function", func(t *testing.T) {
+		tree := NewRBTree(_cmp{}, 10, 20, 40)
+		distance := func(a, b int) float64 { return float64(a - b) }
+		v, ok := tree.ClosestFunc(16, distance)
+		assert.True(t, ok)
+		assert.Equal(t, 20, v)
+		v, ok = tree.Closest(16)
+		assert.True(t, ok)
+		assert.Equal(t, 10, v)
+	})
+}
+
+func TestRBTree_Sub(t *testing.T) {
+	tree := NewRBTreeWithPolicy(_cmp{}, AllowDuplicates, 1, 2, 3, 3, 4, 5, 6)
+	sub := tree.Sub(2, 5)
+	assert.Equal(t, []int{2, 3, 3, 4}, sub.ToArray())
+	assert.EqualValues(t, 4, sub.Count())
+	assert.Nil(t, sub.Validate())
+	sub.Push(10)
+	assert.EqualValues(t, 7, tree.Count())
+	assert.True(t, tree.Sub(7, 10).IsEmpty())
+	assert.True(t, tree.Sub(5, 2).IsEmpty())
+}
+
+func TestRBTree_CountBetween(t *testing.T) {
+	tree := NewRBTreeWithPolicy(_cmp{}, AllowDuplicates, 1, 2, 3, 3, 4, 5, 6)
+	assert.EqualValues(t, 4, tree.CountBetween(2, 5))
+	assert.EqualValues(t, 7, tree.CountBetween(0, 10))
+	assert.EqualValues(t, 2, tree.CountBetween(3, 4))
+	assert.EqualValues(t, 0, tree.CountBetween(7, 10))
+	assert.EqualValues(t, 0, tree.CountBetween(5, 2))
+	assert.EqualValues(t, 0, tree.CountBetween(3, 3))
+
+	large := NewRBTree[int](_cmp{})
+	for i := 0; i < 1000; i++ {
+		large.Push(i)
+	}
+	for _, r := range [][2]int{{0, 1000}, {100, 200}, {-5, 3}, {998, 2000}} {
+		assert.EqualValues(t, large.Sub(r[0], r[1]).Count(), large.CountBetween(r[0], r[1]))
+	}
+}
+
+func TestRBTree_PushWeighted(t *testing.T) {
+	tree := NewRBTreeWithPolicy(_cmp{}, AllowDuplicates)
+	tree.PushWeighted(10, 1)
+	tree.PushWeighted(20, 5)
+	tree.PushWeighted(30, 2)
+	tree.PushWeighted(20, 1)
+	tree.Push(40)
+	assert.EqualValues(t, 5, tree.Count())
+	assert.Equal(t, 10.0, tree.TotalWeight())
+	assert.Equal(t, 6.0, tree.Weight(20))
+	assert.Equal(t, 0.0, tree.Weight(25))
+	assert.Equal(t, 7.0, tree.WeightedRank(30))
+	assert.Equal(t, 1.0, tree.WeightedRank(15))
+
+	median, ok := tree.SelectByWeight(tree.TotalWeight() / 2)
+	assert.True(t, ok)
+	assert.Equal(t, 20, median)
+	for weight, expected := range map[float64]int{0: 10, 0.5: 10, 1: 20, 6.9: 20, 7: 30, 9.5: 40} {
+		v, ok := tree.SelectByWeight(weight)
+		assert.True(t, ok)
+		assert.Equal(t, expected, v, weight)
+	}
+	_, ok = tree.SelectByWeight(10)
+	assert.False(t, ok)
+	_, ok = tree.SelectByWeight(-1)
+	assert.False(t, ok)
+
+	clone := tree.Clone()
+	tree.Remove(20)
+	assert.Equal(t, 4.0, tree.TotalWeight())
+	assert.Nil(t, tree.Validate())
+	assert.Equal(t, 10.0, clone.TotalWeight())
+	assert.Equal(t, 6.0, clone.DeepClone().Weight(20))
+
+	assert.PanicsWithError(t, "weight must be a finite non-negative number: -1", func() {
+		tree.PushWeighted(50, -1)
+	})
+}
+
+func TestRBTree_PushWeighted_Split(t *testing.T) {
+	tree := NewRBTree[int](_cmp{})
+	for i := 0; i < 100; i++ {
+		tree.PushWeighted(i, float64(i%4))
+	}
+	assert.Nil(t, tree.Validate())
+	assert.Equal(t, 150.0, tree.TotalWeight())
+	left, right := tree.Split(50)
+	assert.Nil(t, left.Validate())
+	assert.Nil(t, right.Validate())
+	assert.Equal(t, 73.0, left.TotalWeight())
+	left.Merge(right)
+	assert.Equal(t, 150.0, left.TotalWeight())
+	assert.Equal(t, 72.0, left.WeightedRank(49))
+	v, ok := left.SelectByWeight(0)
+	assert.True(t, ok)
+	assert.Equal(t, 1, v)
+}
+
+func TestRBTree_AggregateBetween(t *testing.T) {
+	measure := func(value int) float64 {
+		return float64(value % 7)
+	}
+	// first keeps the measure of the first element, it is associative but not commutative
+	first := &Aggregate[int]{
+		Measure: measure,
+		Combine: func(a, b float64) float64 {
+			if math.IsNaN(a) {
+				return b
+			}
+			return a
+		},
+		Identity: math.NaN(),
+	}
+	expected := func(values []int, from, to int, aggregate *Aggregate[int]) float64 {
+		result := aggregate.Identity
+		for _, value := range values {
+			if value >= from && value < to {
+				result = aggregate.Combine(result, aggregate.Measure(value))
+			}
+		}
+		return result
+	}
+	for name, aggregate := range map[string]*Aggregate[int]{
+		"sum":   SumAggregate(measure),
+		"min":   MinAggregate(measure),
+		"max":   MaxAggregate(measure),
+		"first": first,
+	} {
+		t.Run(name, func(t *testing.T) {
+			tree := NewRBTreeWithAggregate(_cmp{}, aggregate)
+			for i := 0; i < 200; i++ {
+				tree.Push(i*37%101, i%50)
+			}
+			for i := 0; i < 100; i += 3 {
+				tree.Remove(i)
+			}
+			assert.Nil(t, tree.Validate())
+			values := tree.ToArray()
+			for _, bounds := range [][2]int{{0, 101}, {10, 20}, {13, 14}, {50, 40}, {-5, 3}, {99, 200}} {
+				assert.Equal(t, fmt.Sprint(expected(values, bounds[0], bounds[1], aggregate)), fmt.Sprint(tree.AggregateBetween(bounds[0], bounds[1])), bounds)
+			}
+
+			clone := tree.Clone()
+			left, right := tree.Split(40)
+			assert.Nil(t, left.Validate())
+			assert.Nil(t, right.Validate())
+			assert.Equal(t, fmt.Sprint(expected(values, 0, 40, aggregate)), fmt.Sprint(left.AggregateBetween(0, 101)))
+			left.Merge(right)
+			assert.Nil(t, left.Validate())
+			assert.Equal(t, fmt.Sprint(clone.AggregateBetween(5, 95)), fmt.Sprint(left.AggregateBetween(5, 95)))
+			assert.Equal(t, fmt.Sprint(expected(values, 5, 95, aggregate)), fmt.Sprint(clone.Sub(5, 95).AggregateBetween(0, 101)))
+		})
+	}
+
+	t.Run("merge other aggregate", func(t *testing.T) {
+		tree := NewRBTreeWithAggregate(_cmp{}, SumAggregate(measure), 1, 2, 3)
+		tree.Merge(NewRBTree(_cmp{}, 4, 5, 6))
+		assert.Nil(t, tree.Validate())
+		assert.Equal(t, 21.0, tree.AggregateBetween(0, 10))
+	})
+
+	t.Run("without aggregate", func(t *testing.T) {
+		tree := NewRBTree(_cmp{}, 1, 2, 3)
+		assert.Equal(t, 0.0, tree.AggregateBetween(0, 10))
+	})
+}
+
+func TestRBTree_WalkParallel(t *testing.T) {
+	t.Run("all elements", func(t *testing.T) {
+		tree := NewRBTree[int](_cmp{})
+		for i := 0; i < 1000; i++ {
+			tree.Push(i % 300)
+		}
+		var lock sync.Mutex
+		counts := map[int]int{}
+		err := tree.WalkParallel(4, func(value int) error {
+			lock.Lock()
+			defer lock.Unlock()
+			counts[value]++
+			return nil
+		})
+		assert.Nil(t, err)
+		assert.Len(t, counts, 300)
+		for value, count := range counts {
+			if value < 100 {
+				assert.Equal(t, 4, count)
+			} else {
+				assert.Equal(t, 3, count)
+			}
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		tree := NewRBTree(_cmp{}, 1, 2, 3, 4, 5, 6, 7, 8)
+		err := fmt.Errorf("failed")
+		assert.Equal(t, err, tree.WalkParallel(0, func(value int) error {
+			if value == 5 {
+				return err
+			}
+			return nil
+		}))
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		assert.Nil(t, NewRBTree[int](_cmp{}).WalkParallel(4, func(int) error {
+			return fmt.Errorf("failed")
+		}))
+	})
+}
+
+func TestRBTree_MarshalCBOR(t *testing.T) {
+	value := NewRBTree(_cmp{}, 3, 1, 2, 2)
+	data, err := cbor.Marshal(value)
+	assert.Nil(t, err)
+	decoded := NewRBTree[int](_cmp{})
+	err = cbor.Unmarshal(data, decoded)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1, 2, 2, 3}, decoded.ToArray())
+}
@@ -0,0 +1,49 @@
+package tree
+
+// Rect axis-aligned bounding box, the edges are inclusive
+type Rect struct {
+	MinX, MinY float64
+	MaxX, MaxY float64
+}
+
+// NewRect new rect, the corners are normalized so that the min corner is less than the max corner
+func NewRect(x1, y1, x2, y2 float64) Rect {
+	return Rect{
+		MinX: min(x1, x2),
+		MinY: min(y1, y2),
+		MaxX: max(x1, x2),
+		MaxY: max(y1, y2),
+	}
+}
+
+// Area returns the area of the rect
+func (r Rect) Area() float64 {
+	return (r.MaxX - r.MinX) * (r.MaxY - r.MinY)
+}
+
+// Intersects returns whether the rect intersects with the other one
+func (r Rect) Intersects(other Rect) bool {
+	return r.MinX <= other.MaxX && other.MinX <= r.MaxX &&
+		r.MinY <= other.MaxY && other.MinY <= r.MaxY
+}
+
+// Contains returns whether the rect contains the other one
+func (r Rect) Contains(other Rect) bool {
+	return r.MinX <= other.MinX && other.MaxX <= r.MaxX &&
+		r.MinY <= other.MinY && other.MaxY <= r.MaxY
+}
+
+// ContainsPoint returns whether the rect contains the point
+func (r Rect) ContainsPoint(x, y float64) bool {
+	return r.MinX <= x && x <= r.MaxX && r.MinY <= y && y <= r.MaxY
+}
+
+// Union returns the smallest rect containing both rects
+func (r Rect) Union(other Rect) Rect {
+	return Rect{
+		MinX: min(r.MinX, other.MinX),
+		MinY: min(r.MinY, other.MinY),
+		MaxX: max(r.MaxX, other.MaxX),
+		MaxY: max(r.MaxY, other.MaxY),
+	}
+}
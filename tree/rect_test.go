@@ -0,0 +1,36 @@
+package tree
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewRect(t *testing.T) {
+	assert.Equal(t, Rect{MinX: 1, MinY: 2, MaxX: 3, MaxY: 4}, NewRect(3, 4, 1, 2))
+}
+
+func TestRect_Area(t *testing.T) {
+	assert.Equal(t, 6.0, NewRect(0, 0, 2, 3).Area())
+	assert.Equal(t, 0.0, NewRect(1, 1, 1, 1).Area())
+}
+
+func TestRect_Intersects(t *testing.T) {
+	rect := NewRect(0, 0, 2, 2)
+	assert.True(t, rect.Intersects(NewRect(1, 1, 3, 3)))
+	assert.True(t, rect.Intersects(NewRect(2, 2, 3, 3)))
+	assert.False(t, rect.Intersects(NewRect(3, 0, 4, 2)))
+}
+
+func TestRect_Contains(t *testing.T) {
+	rect := NewRect(0, 0, 2, 2)
+	assert.True(t, rect.Contains(NewRect(0, 0, 1, 1)))
+	assert.True(t, rect.Contains(rect))
+	assert.False(t, rect.Contains(NewRect(1, 1, 3, 3)))
+	assert.True(t, rect.ContainsPoint(2, 0))
+	assert.False(t, rect.ContainsPoint(2, 3))
+}
+
+func TestRect_Union(t *testing.T) {
+	assert.Equal(t, NewRect(0, -1, 3, 2), NewRect(0, 0, 2, 2).Union(NewRect(1, -1, 3, 1)))
+}
@@ -0,0 +1,102 @@
+package tree
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/gopi-frame/contract"
+)
+
+// renderNode is the view of a tree node used by the renderers
+type renderNode struct {
+	label string
+	info  string
+	fill  string
+	left  *renderNode
+	right *renderNode
+}
+
+func renderLabel[E any](value E, count int) string {
+	var label string
+	if v, ok := any(value).(contract.Stringable); ok {
+		label = v.String()
+	} else {
+		label = fmt.Sprintf("%v", value)
+	}
+	if count > 1 {
+		label = fmt.Sprintf("%s(x%d)", label, count)
+	}
+	return label
+}
+
+// renderDOT renders the tree in graphviz dot language
+func renderDOT(name string, root *renderNode) string {
+	str := new(strings.Builder)
+	str.WriteString("digraph ")
+	str.WriteString(name)
+	str.WriteString(" {\n")
+	str.WriteString("\tnode [shape=circle];\n")
+	id := 0
+	var walk func(node *renderNode) int
+	walk = func(node *renderNode) int {
+		current := id
+		id++
+		str.WriteString(fmt.Sprintf("\tn%d [label=%s", current, strconv.Quote(node.label+"\n"+node.info)))
+		if node.fill != "" {
+			str.WriteString(fmt.Sprintf(", style=filled, fillcolor=%s, fontcolor=white", node.fill))
+		}
+		str.WriteString("];\n")
+		if node.left != nil {
+			str.WriteString(fmt.Sprintf("\tn%d -> n%d [label=\"L\"];\n", current, walk(node.left)))
+		}
+		if node.right != nil {
+			str.WriteString(fmt.Sprintf("\tn%d -> n%d [label=\"R\"];\n", current, walk(node.right)))
+		}
+		return current
+	}
+	if root != nil {
+		walk(root)
+	}
+	str.WriteString("}\n")
+	return str.String()
+}
+
+// renderASCII writes the tree as ascii art, the left child is printed before the right child
+func renderASCII(w io.Writer, root *renderNode) error {
+	str := new(strings.Builder)
+	if root == nil {
+		str.WriteString("<empty>\n")
+	} else {
+		str.WriteString(fmt.Sprintf("%s [%s]\n", root.label, root.info))
+		renderASCIIChildren(str, root, "")
+	}
+	_, err := io.WriteString(w, str.String())
+	return err
+}
+
+func renderASCIIChildren(str *strings.Builder, node *renderNode, prefix string) {
+	if node.left == nil && node.right == nil {
+		return
+	}
+	children := []struct {
+		side   string
+		node   *renderNode
+		branch string
+		indent string
+	}{
+		{"L", node.left, "├── ", "│   "},
+		{"R", node.right, "└── ", "    "},
+	}
+	for _, child := range children {
+		str.WriteString(prefix)
+		str.WriteString(child.branch)
+		if child.node == nil {
+			str.WriteString(fmt.Sprintf("%s: <nil>\n", child.side))
+			continue
+		}
+		str.WriteString(fmt.Sprintf("%s: %s [%s]\n", child.side, child.node.label, child.node.info))
+		renderASCIIChildren(str, child.node, prefix+child.indent)
+	}
+}
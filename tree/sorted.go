@@ -0,0 +1,42 @@
+package tree
+
+import (
+	"github.com/gopi-frame/contract"
+)
+
+// sortedRun is a run of equal elements in a sorted slice
+type sortedRun[E any] struct {
+	value  E
+	count  int
+	weight float64
+}
+
+// sortedRuns groups equal adjacent elements of the sorted slice into runs according to the duplicate policy,
+// and returns the runs with the number of elements in them.
+// It returns false if the values are not sorted in comparator order.
+func sortedRuns[E any](values []E, comparator contract.Comparator[E], policy DuplicatePolicy) ([]sortedRun[E], int, bool) {
+	runs := make([]sortedRun[E], 0, len(values))
+	size := 0
+	for index, value := range values {
+		if index > 0 {
+			result := comparator.Compare(values[index-1], value)
+			if result > 0 {
+				return nil, 0, false
+			}
+			if result == 0 {
+				switch policy {
+				case AllowDuplicates:
+					runs[len(runs)-1].count++
+					runs[len(runs)-1].weight++
+					size++
+				case ReplaceDuplicates:
+					runs[len(runs)-1].value = value
+				}
+				continue
+			}
+		}
+		runs = append(runs, sortedRun[E]{value: value, count: 1, weight: 1})
+		size++
+	}
+	return runs, size, true
+}
@@ -0,0 +1,73 @@
+package tree
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gopi-frame/contract"
+)
+
+// ErrInvalidTree is returned when the structure of a tree breaks its invariants
+var ErrInvalidTree = errors.New("invalid tree")
+
+// Stats statistics of the tree structure
+type Stats struct {
+	// Size is the number of elements
+	Size int64
+	// Nodes is the number of nodes, equal elements share the same node
+	Nodes int
+	// Height is the number of nodes on the longest path from the root to a leaf
+	Height int
+	// MinLeafDepth is the number of nodes on the shortest path from the root to a leaf
+	MinLeafDepth int
+	// AverageDepth is the average number of nodes on the paths from the root to each node
+	AverageDepth float64
+}
+
+// statsNode is the view of a tree node used to collect statistics
+type statsNode[E any] interface {
+	comparable
+	children() (left, right E)
+}
+
+func collectStats[N statsNode[N]](root N) Stats {
+	var stats Stats
+	var nilNode N
+	if root == nilNode {
+		return stats
+	}
+	stats.MinLeafDepth = -1
+	totalDepth := 0
+	var walk func(node N, depth int)
+	walk = func(node N, depth int) {
+		stats.Nodes++
+		totalDepth += depth
+		stats.Height = max(stats.Height, depth)
+		left, right := node.children()
+		if left == nilNode && right == nilNode {
+			if stats.MinLeafDepth < 0 || depth < stats.MinLeafDepth {
+				stats.MinLeafDepth = depth
+			}
+			return
+		}
+		if left != nilNode {
+			walk(left, depth+1)
+		}
+		if right != nilNode {
+			walk(right, depth+1)
+		}
+	}
+	walk(root, 1)
+	stats.AverageDepth = float64(totalDepth) / float64(stats.Nodes)
+	return stats
+}
+
+// validateOrder checks that the values are strictly increasing in comparator order
+func validateOrder[E any](values []E, comparator contract.Comparator[E]) error {
+	for i := 1; i < len(values); i++ {
+		if comparator.Compare(values[i-1], values[i]) >= 0 {
+			return fmt.Errorf("%w: element %v is not less than its successor %v", ErrInvalidTree, values[i-1], values[i])
+		}
+	}
+	return nil
+}
@@ -0,0 +1,297 @@
+package tree
+
+import (
+	"encoding/json"
+	"fmt"
+	"iter"
+	"log/slog"
+	"slices"
+	"sync"
+
+	"github.com/gopi-frame/collection/internal/preview"
+)
+
+// NewTrie new trie
+func NewTrie[V any]() *Trie[V] {
+	return &Trie[V]{root: new(trieNode[V])}
+}
+
+// Trie prefix tree of string keys, all methods are safe for concurrent use.
+// A key is stored byte by byte along a path from the root, so the lookups take O(len(key)) time
+// regardless of the number of keys, and the keys sharing a prefix can be walked in lexicographic order.
+type Trie[V any] struct {
+	lock  sync.RWMutex
+	root  *trieNode[V]
+	size  int64
+	limit preview.Limit
+}
+
+// trieNode node of a trie, the children are sorted by their labels
+type trieNode[V any] struct {
+	children []*trieNode[V]
+	label    byte
+	value    V
+	terminal bool
+}
+
+// child returns the child of the label, it returns nil when the node has none
+func (n *trieNode[V]) child(label byte) *trieNode[V] {
+	if index, found := n.search(label); found {
+		return n.children[index]
+	}
+	return nil
+}
+
+func (n *trieNode[V]) search(label byte) (int, bool) {
+	return slices.BinarySearchFunc(n.children, label, func(child *trieNode[V], label byte) int {
+		return int(child.label) - int(label)
+	})
+}
+
+// find returns the node of the key, it returns nil when there is no path of the key
+func (t *Trie[V]) find(key string) *trieNode[V] {
+	n := t.root
+	for index := 0; index < len(key) && n != nil; index++ {
+		n = n.child(key[index])
+	}
+	return n
+}
+
+// Count returns the number of keys
+func (t *Trie[V]) Count() int64 {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	return t.size
+}
+
+// IsEmpty returns whether the trie is empty
+func (t *Trie[V]) IsEmpty() bool {
+	return t.Count() == 0
+}
+
+// IsNotEmpty returns whether the trie is not empty
+func (t *Trie[V]) IsNotEmpty() bool {
+	return !t.IsEmpty()
+}
+
+// Insert sets the value to the specific key, it returns true when the key is new
+func (t *Trie[V]) Insert(key string, value V) bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	n := t.root
+	for index := 0; index < len(key); index++ {
+		position, found := n.search(key[index])
+		if !found {
+			n.children = slices.Insert(n.children, position, &trieNode[V]{label: key[index]})
+		}
+		n = n.children[position]
+	}
+	added := !n.terminal
+	n.value, n.terminal = value, true
+	if added {
+		t.size++
+	}
+	return added
+}
+
+// Get returns the value of the specific key.
+// A zero value and false will be returned when the key is not exist.
+func (t *Trie[V]) Get(key string) (V, bool) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	if n := t.find(key); n != nil && n.terminal {
+		return n.value, true
+	}
+	return *new(V), false
+}
+
+// GetOr returns the value of the specific key, or the default value when the key is not exist
+func (t *Trie[V]) GetOr(key string, value V) V {
+	if v, ok := t.Get(key); ok {
+		return v
+	}
+	return value
+}
+
+// ContainsKey returns whether the trie contains the specific key
+func (t *Trie[V]) ContainsKey(key string) bool {
+	_, ok := t.Get(key)
+	return ok
+}
+
+// Delete removes the specific key and the nodes left without keys, it returns false when the trie does not contain it
+func (t *Trie[V]) Delete(key string) bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	path := make([]*trieNode[V], 0, len(key)+1)
+	n := t.root
+	for index := 0; index < len(key) && n != nil; index++ {
+		path = append(path, n)
+		n = n.child(key[index])
+	}
+	if n == nil || !n.terminal {
+		return false
+	}
+	n.value, n.terminal = *new(V), false
+	t.size--
+	for index := len(path) - 1; index >= 0 && !n.terminal && len(n.children) == 0; index-- {
+		parent := path[index]
+		position, _ := parent.search(n.label)
+		parent.children = slices.Delete(parent.children, position, position+1)
+		n = parent
+	}
+	return true
+}
+
+// HasPrefix returns whether the trie contains any key starting with the prefix
+func (t *Trie[V]) HasPrefix(prefix string) bool {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	n := t.find(prefix)
+	return n != nil && (n.terminal || len(n.children) > 0)
+}
+
+// LongestPrefixMatch returns the longest key which is a prefix of the specific key and its value,
+// such as the most specific route of a path. It returns false when no key is a prefix of it.
+func (t *Trie[V]) LongestPrefixMatch(key string) (string, V, bool) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	length, value, found := 0, *new(V), false
+	n := t.root
+	for index := 0; ; index++ {
+		if n.terminal {
+			length, value, found = index, n.value, true
+		}
+		if index == len(key) {
+			break
+		}
+		if n = n.child(key[index]); n == nil {
+			break
+		}
+	}
+	return key[:length], value, found
+}
+
+// Walk runs callback for each key starting with the prefix in lexicographic order, it breaks when callback false.
+// It walks over a snapshot of the keys, so the callback is allowed to modify the trie.
+func (t *Trie[V]) Walk(prefix string, callback func(key string, value V) bool) {
+	keys, values := t.snapshot(prefix)
+	for index, key := range keys {
+		if !callback(key, values[index]) {
+			return
+		}
+	}
+}
+
+// snapshot returns the keys starting with the prefix and their values in lexicographic order
+func (t *Trie[V]) snapshot(prefix string) ([]string, []V) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	var keys []string
+	var values []V
+	n := t.find(prefix)
+	if n == nil {
+		return keys, values
+	}
+	var walk func(n *trieNode[V], key []byte)
+	walk = func(n *trieNode[V], key []byte) {
+		if n.terminal {
+			keys = append(keys, string(key))
+			values = append(values, n.value)
+		}
+		for _, child := range n.children {
+			walk(child, append(key, child.label))
+		}
+	}
+	walk(n, []byte(prefix))
+	return keys, values
+}
+
+// All returns an iterator over a snapshot of the keys and the values in lexicographic order,
+// so the loop body is allowed to modify the trie
+func (t *Trie[V]) All() iter.Seq2[string, V] {
+	return func(yield func(string, V) bool) {
+		t.Walk("", yield)
+	}
+}
+
+// Keys returns the keys in lexicographic order
+func (t *Trie[V]) Keys() []string {
+	keys, _ := t.snapshot("")
+	return keys
+}
+
+// Values returns the values in the order of the keys
+func (t *Trie[V]) Values() []V {
+	_, values := t.snapshot("")
+	return values
+}
+
+// Clear clears the trie
+func (t *Trie[V]) Clear() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.root = new(trieNode[V])
+	t.size = 0
+}
+
+// ToMap converts to map, the returned map is a copy, so modifying it does not affect the collection
+func (t *Trie[V]) ToMap() map[string]V {
+	keys, values := t.snapshot("")
+	items := make(map[string]V, len(keys))
+	for index, key := range keys {
+		items[key] = values[index]
+	}
+	return items
+}
+
+// ToJSON converts to json, the trie is encoded as an object of the keys
+func (t *Trie[V]) ToJSON() ([]byte, error) {
+	return json.Marshal(t.ToMap())
+}
+
+// MarshalJSON implements [json.Marshaller]
+func (t *Trie[V]) MarshalJSON() ([]byte, error) {
+	return t.ToJSON()
+}
+
+// UnmarshalJSON implements [json.Unmarshaller], the keys are inserted into the trie
+func (t *Trie[V]) UnmarshalJSON(data []byte) error {
+	items := map[string]V{}
+	if err := json.Unmarshal(data, &items); err != nil {
+		return err
+	}
+	if t.root == nil {
+		t.root = new(trieNode[V])
+	}
+	for key, value := range items {
+		t.Insert(key, value)
+	}
+	return nil
+}
+
+// SetPreviewLimit sets the number of entries shown by String, %v and LogValue, a negative limit shows all entries
+func (t *Trie[V]) SetPreviewLimit(limit int) {
+	t.limit.Set(limit)
+}
+
+// String converts to string, only the preview limit of entries are shown
+func (t *Trie[V]) String() string {
+	return t.format(t.limit.Get(-1))
+}
+
+// Format implements [fmt.Formatter], %v prints the preview as String does, %+v and %#v print all entries
+func (t *Trie[V]) Format(f fmt.State, verb rune) {
+	preview.Format(f, verb, t.format, t.limit.Get(-1))
+}
+
+func (t *Trie[V]) format(limit int) string {
+	keys, values := t.snapshot("")
+	return preview.Entries(fmt.Sprintf("Trie[%T](len=%d)", *new(V), len(keys)), keys, values, limit)
+}
+
+// LogValue implements [slog.LogValuer], only the preview limit of entries are logged
+func (t *Trie[V]) LogValue() slog.Value {
+	keys, values := t.snapshot("")
+	return preview.LogEntries(fmt.Sprintf("Trie[%T]", *new(V)), len(keys), keys, values, t.limit.Get(preview.DefaultLimit))
+}
@@ -0,0 +1,186 @@
+package tree
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func routes() *Trie[string] {
+	trie := NewTrie[string]()
+	trie.Insert("/", "root")
+	trie.Insert("/users", "users")
+	trie.Insert("/users/admin", "admin")
+	trie.Insert("/posts", "posts")
+	return trie
+}
+
+func TestTrie_Insert(t *testing.T) {
+	trie := NewTrie[int]()
+	assert.True(t, trie.Insert("car", 1))
+	assert.True(t, trie.Insert("cart", 2))
+	assert.True(t, trie.Insert("", 0))
+	assert.False(t, trie.Insert("car", 3))
+	assert.Equal(t, int64(3), trie.Count())
+	assert.Equal(t, 3, trie.GetOr("car", -1))
+	assert.Equal(t, 0, trie.GetOr("", -1))
+}
+
+func TestTrie_Get(t *testing.T) {
+	trie := routes()
+	value, ok := trie.Get("/users")
+	assert.True(t, ok)
+	assert.Equal(t, "users", value)
+	_, ok = trie.Get("/user")
+	assert.False(t, ok)
+	_, ok = trie.Get("/users/admin/x")
+	assert.False(t, ok)
+	assert.True(t, trie.ContainsKey("/"))
+	assert.False(t, trie.ContainsKey(""))
+	assert.Equal(t, "none", trie.GetOr("/none", "none"))
+}
+
+func TestTrie_Delete(t *testing.T) {
+	trie := routes()
+	assert.True(t, trie.Delete("/users"))
+	assert.False(t, trie.Delete("/users"))
+	assert.False(t, trie.Delete("/user"))
+	assert.False(t, trie.Delete("/missing"))
+	assert.Equal(t, int64(3), trie.Count())
+	assert.False(t, trie.ContainsKey("/users"))
+	assert.True(t, trie.ContainsKey("/users/admin"))
+
+	t.Run("prunes nodes", func(t *testing.T) {
+		assert.True(t, trie.Delete("/users/admin"))
+		assert.False(t, trie.HasPrefix("/u"))
+		assert.True(t, trie.HasPrefix("/p"))
+		assert.True(t, trie.Delete("/posts"))
+		assert.True(t, trie.Delete("/"))
+		assert.True(t, trie.IsEmpty())
+		assert.Empty(t, trie.root.children)
+	})
+}
+
+func TestTrie_HasPrefix(t *testing.T) {
+	trie := routes()
+	assert.True(t, trie.HasPrefix(""))
+	assert.True(t, trie.HasPrefix("/us"))
+	assert.True(t, trie.HasPrefix("/users/admin"))
+	assert.False(t, trie.HasPrefix("/users/admins"))
+	assert.False(t, trie.HasPrefix("/x"))
+	assert.False(t, NewTrie[int]().HasPrefix(""))
+}
+
+func TestTrie_Walk(t *testing.T) {
+	trie := routes()
+	var keys []string
+	trie.Walk("/u", func(key string, value string) bool {
+		keys = append(keys, key)
+		return true
+	})
+	assert.Equal(t, []string{"/users", "/users/admin"}, keys)
+
+	t.Run("lexicographic order", func(t *testing.T) {
+		var keys []string
+		trie.Walk("", func(key string, value string) bool {
+			keys = append(keys, key)
+			return true
+		})
+		assert.Equal(t, []string{"/", "/posts", "/users", "/users/admin"}, keys)
+	})
+
+	t.Run("break", func(t *testing.T) {
+		count := 0
+		trie.Walk("/", func(key string, value string) bool {
+			count++
+			return false
+		})
+		assert.Equal(t, 1, count)
+	})
+
+	t.Run("missing prefix", func(t *testing.T) {
+		trie.Walk("/x", func(key string, value string) bool {
+			assert.Fail(t, "unexpected key")
+			return true
+		})
+	})
+
+	t.Run("modify in callback", func(t *testing.T) {
+		trie := routes()
+		trie.Walk("/users", func(key string, value string) bool {
+			trie.Delete(key)
+			return true
+		})
+		assert.Equal(t, []string{"/", "/posts"}, trie.Keys())
+	})
+}
+
+func TestTrie_LongestPrefixMatch(t *testing.T) {
+	trie := routes()
+	key, value, ok := trie.LongestPrefixMatch("/users/admin/settings")
+	assert.True(t, ok)
+	assert.Equal(t, "/users/admin", key)
+	assert.Equal(t, "admin", value)
+
+	key, value, ok = trie.LongestPrefixMatch("/users/42")
+	assert.True(t, ok)
+	assert.Equal(t, "/users", key)
+	assert.Equal(t, "users", value)
+
+	key, _, ok = trie.LongestPrefixMatch("/about")
+	assert.True(t, ok)
+	assert.Equal(t, "/", key)
+
+	_, _, ok = trie.LongestPrefixMatch("about")
+	assert.False(t, ok)
+}
+
+func TestTrie_All(t *testing.T) {
+	trie := routes()
+	var values []string
+	for _, value := range trie.All() {
+		values = append(values, value)
+	}
+	assert.Equal(t, []string{"root", "posts", "users", "admin"}, values)
+	assert.Equal(t, values, trie.Values())
+}
+
+func TestTrie_Clear(t *testing.T) {
+	trie := routes()
+	trie.Clear()
+	assert.True(t, trie.IsEmpty())
+	assert.False(t, trie.HasPrefix(""))
+}
+
+func TestTrie_ToJSON(t *testing.T) {
+	data, err := routes().ToJSON()
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"/":"root","/users":"users","/users/admin":"admin","/posts":"posts"}`, string(data))
+}
+
+func TestTrie_UnmarshalJSON(t *testing.T) {
+	data, err := json.Marshal(routes())
+	assert.NoError(t, err)
+	trie := NewTrie[string]()
+	assert.NoError(t, json.Unmarshal(data, trie))
+	assert.Equal(t, routes().Keys(), trie.Keys())
+	assert.Equal(t, routes().Values(), trie.Values())
+
+	t.Run("zero value", func(t *testing.T) {
+		trie := new(Trie[string])
+		assert.NoError(t, json.Unmarshal(data, trie))
+		assert.Equal(t, int64(4), trie.Count())
+	})
+
+	t.Run("invalid json", func(t *testing.T) {
+		assert.Error(t, json.Unmarshal([]byte(`[1]`), trie))
+	})
+}
+
+func TestTrie_String(t *testing.T) {
+	trie := NewTrie[int]()
+	trie.Insert("b", 2)
+	trie.Insert("a", 1)
+	assert.Equal(t, "Trie[int](len=2){\n\ta: 1,\n\tb: 2,\n}", trie.String())
+}
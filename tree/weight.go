@@ -0,0 +1,17 @@
+package tree
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ErrInvalidWeight is the panic of PushWeighted when the weight is negative, infinite or NaN
+var ErrInvalidWeight = errors.New("weight must be a finite non-negative number")
+
+// checkWeight panics with [ErrInvalidWeight] when the weight is invalid
+func checkWeight(weight float64) {
+	if !(weight >= 0) || math.IsInf(weight, 1) {
+		panic(fmt.Errorf("%w: %v", ErrInvalidWeight, weight))
+	}
+}